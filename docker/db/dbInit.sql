@@ -11,18 +11,157 @@ CREATE TABLE IF NOT EXISTS patient (
     id_user INT NOT NULL UNIQUE,
     first_name VARCHAR(255) NOT NULL,
     second_name VARCHAR(255) NOT NULL,
-    email VARCHAR(255) NOT NULL UNIQUE,
-    phone_number VARCHAR(20) NOT NULL,
-    cnp CHAR(13) NOT NULL UNIQUE ,
+    -- email, phone_number and cnp are encrypted, they are looked up and kept unique by their blind indexes
+    email VARCHAR(1024) NOT NULL,
+    phone_number VARCHAR(512) NOT NULL,
+    cnp VARCHAR(512) NOT NULL,
+    email_bidx CHAR(64) NULL UNIQUE,
+    phone_number_bidx CHAR(64) NULL,
+    cnp_bidx CHAR(64) NULL UNIQUE,
     birth_day DATE NOT NULL,
-    is_active BOOLEAN DEFAULT true
+    is_active BOOLEAN DEFAULT true,
+    sex ENUM('M', 'F') NULL, -- derived from the CNP, NULL for foreign citizens without a declared sex
+    INDEX (phone_number_bidx)
 );
 
--- Inserting two sample patients
-INSERT INTO patient (id_user, first_name, second_name, email, phone_number, cnp, birth_day, is_active)
+-- Inserting two sample patients, in plaintext. The key rotation run at startup encrypts and indexes them.
+INSERT INTO patient (id_user, first_name, second_name, email, phone_number, cnp, birth_day, is_active, sex)
 VALUES
-    (1, 'Popescu', 'Ion', 'ion.popescu@example.com', '0712345678', '1010100890123', '2000-01-01', true),
-    (2, 'Ionescu', 'Ana', 'ana.ionescu@example.com', '0712345679', '9150290210987', '1990-02-15', false);
+    (1, 'Popescu', 'Ion', 'ion.popescu@example.com', '0712345678', '5000101400127', '2000-01-01', true, 'M'),
+    (2, 'Ionescu', 'Ana', 'ana.ionescu@example.com', '0712345679', '2900215210987', '1990-02-15', false, 'F');
+
+-- Pairs of patient records that likely belong to the same person, id_patient_a is the lower ID
+CREATE TABLE IF NOT EXISTS patient_duplicate_candidate (
+    id_candidate INT AUTO_INCREMENT PRIMARY KEY NOT NULL,
+    id_patient_a INT NOT NULL,
+    id_patient_b INT NOT NULL,
+    score DOUBLE NOT NULL,
+    reasons VARCHAR(64) NOT NULL,
+    status ENUM('open', 'dismissed', 'merged') NOT NULL DEFAULT 'open',
+    detected_at DATETIME NOT NULL,
+    UNIQUE KEY unique_candidate_pair (id_patient_a, id_patient_b),
+    INDEX (status, score),
+    FOREIGN KEY (id_patient_a) REFERENCES patient(id_patient) ON DELETE CASCADE,
+    FOREIGN KEY (id_patient_b) REFERENCES patient(id_patient) ON DELETE CASCADE
+);
+
+-- Merge history, kept after the patients are deleted. step is the next step to run, a failed merge resumes from it
+CREATE TABLE IF NOT EXISTS patient_merge (
+    id_merge INT AUTO_INCREMENT PRIMARY KEY NOT NULL,
+    id_survivor INT NOT NULL,
+    id_duplicate INT NOT NULL,
+    status ENUM('pending', 'failed', 'completed') NOT NULL,
+    step ENUM('appointments', 'consultations', 'finalize') NOT NULL,
+    appointments_moved INT NOT NULL DEFAULT 0,
+    appointments_dropped VARCHAR(1024) NOT NULL DEFAULT '',
+    consultations_moved INT NOT NULL DEFAULT 0,
+    error VARCHAR(1024) NULL,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL,
+    completed_at DATETIME NULL,
+    INDEX (id_survivor),
+    INDEX (id_duplicate)
+);
+
+-- Clinical profile of a patient. version grows with every change, an update naming an older version is refused
+CREATE TABLE IF NOT EXISTS patient_clinical_profile (
+    id_patient INT PRIMARY KEY NOT NULL,
+    blood_type ENUM('O+', 'O-', 'A+', 'A-', 'B+', 'B-', 'AB+', 'AB-') NULL,
+    version INT NOT NULL,
+    updated_by INT NULL, -- IDM user of the last change
+    updated_at DATETIME NOT NULL,
+    FOREIGN KEY (id_patient) REFERENCES patient(id_patient) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS patient_allergy (
+    id_allergy INT AUTO_INCREMENT PRIMARY KEY NOT NULL,
+    id_patient INT NOT NULL,
+    substance VARCHAR(255) NOT NULL,
+    severity ENUM('mild', 'moderate', 'severe', 'life_threatening') NOT NULL,
+    reaction VARCHAR(255) NULL,
+    UNIQUE KEY unique_patient_allergy (id_patient, substance),
+    FOREIGN KEY (id_patient) REFERENCES patient_clinical_profile(id_patient) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS patient_chronic_condition (
+    id_condition INT AUTO_INCREMENT PRIMARY KEY NOT NULL,
+    id_patient INT NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    diagnosed_on DATE NULL,
+    notes VARCHAR(1024) NULL,
+    FOREIGN KEY (id_patient) REFERENCES patient_clinical_profile(id_patient) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS patient_medication (
+    id_medication INT AUTO_INCREMENT PRIMARY KEY NOT NULL,
+    id_patient INT NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    dosage VARCHAR(100) NOT NULL,
+    frequency VARCHAR(100) NOT NULL,
+    started_on DATE NULL,
+    FOREIGN KEY (id_patient) REFERENCES patient_clinical_profile(id_patient) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS patient_emergency_contact (
+    id_contact INT AUTO_INCREMENT PRIMARY KEY NOT NULL,
+    id_patient INT NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    relationship VARCHAR(100) NOT NULL,
+    phone_number VARCHAR(20) NOT NULL,
+    FOREIGN KEY (id_patient) REFERENCES patient_clinical_profile(id_patient) ON DELETE CASCADE
+);
+
+-- Every change of a clinical profile with the profile as it was after it, a deletion keeps the deleted profile.
+-- Kept when the profile is deleted, so a profile created again continues the version numbers.
+CREATE TABLE IF NOT EXISTS patient_clinical_profile_history (
+    id_change INT AUTO_INCREMENT PRIMARY KEY NOT NULL,
+    id_patient INT NOT NULL,
+    version INT NOT NULL,
+    action ENUM('created', 'updated', 'deleted') NOT NULL,
+    sections VARCHAR(128) NOT NULL,
+    snapshot JSON NOT NULL,
+    changed_by INT NULL,
+    changed_at DATETIME NOT NULL,
+    INDEX (id_patient, version),
+    FOREIGN KEY (id_patient) REFERENCES patient(id_patient) ON DELETE CASCADE
+);
+
+-- Insurance policies of a patient. A CNAS policy is identified by the CNP of the patient, a private one by the number
+-- issued by the insurer. A policy without valid_until is open-ended, a rejected policy covers nothing.
+CREATE TABLE IF NOT EXISTS patient_insurance_policy (
+    id_policy INT AUTO_INCREMENT PRIMARY KEY NOT NULL,
+    id_patient INT NOT NULL,
+    payer_type ENUM('cnas', 'private') NOT NULL,
+    payer_name VARCHAR(255) NOT NULL,
+    policy_number VARCHAR(64) NOT NULL,
+    coverage_category ENUM('basic', 'extended', 'full') NOT NULL,
+    valid_from DATE NOT NULL,
+    valid_until DATE NULL,
+    verification_status ENUM('unverified', 'verified', 'rejected') NOT NULL DEFAULT 'unverified',
+    verification_note VARCHAR(255) NULL,
+    verified_at DATETIME NULL,
+    created_at DATETIME NOT NULL,
+    UNIQUE KEY unique_policy_number (payer_name, policy_number),
+    INDEX (id_patient, valid_from),
+    FOREIGN KEY (id_patient) REFERENCES patient(id_patient) ON DELETE CASCADE
+);
+
+-- A patient under legal hold cannot be erased, e.g. while their records are evidence in a dispute
+CREATE TABLE IF NOT EXISTS patient_legal_hold (
+    id_patient INT PRIMARY KEY NOT NULL,
+    reason VARCHAR(255) NOT NULL,
+    placed_by INT NULL, -- IDM user placing the hold
+    placed_at DATETIME NOT NULL,
+    FOREIGN KEY (id_patient) REFERENCES patient(id_patient) ON DELETE CASCADE
+);
+
+-- Patients whose personal data was erased. The pseudonymized patient row stays for the records under legal retention.
+CREATE TABLE IF NOT EXISTS patient_erasure (
+    id_patient INT PRIMARY KEY NOT NULL,
+    id_erasure VARCHAR(64) NOT NULL,
+    erased_at DATETIME NOT NULL,
+    FOREIGN KEY (id_patient) REFERENCES patient(id_patient) ON DELETE CASCADE
+);
 
 
 CREATE TABLE IF NOT EXISTS doctor (
@@ -43,15 +182,131 @@ VALUES
     (2, 'Ionescu', 'Ana', 'ana.ionescu@example.com', '+40123456790', 'Neurology');
 
 
+-- Rooms and equipment are booked per day, each one can serve daily_capacity appointments
+CREATE TABLE IF NOT EXISTS resource (
+    id_resource INT AUTO_INCREMENT PRIMARY KEY NOT NULL,
+    name VARCHAR(64) NOT NULL UNIQUE,
+    kind ENUM('room', 'equipment') NOT NULL,
+    category VARCHAR(64) NOT NULL,
+    daily_capacity INT NOT NULL,
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    INDEX (category, is_active)
+);
+
+CREATE TABLE IF NOT EXISTS appointment_type (
+    id_type INT AUTO_INCREMENT PRIMARY KEY NOT NULL,
+    name VARCHAR(64) NOT NULL UNIQUE
+);
+
+-- An appointment of the type needs quantity resources of the category
+CREATE TABLE IF NOT EXISTS appointment_type_requirement (
+    id_type INT NOT NULL,
+    category VARCHAR(64) NOT NULL,
+    quantity INT NOT NULL DEFAULT 1,
+    PRIMARY KEY (id_type, category),
+    FOREIGN KEY (id_type) REFERENCES appointment_type(id_type) ON DELETE CASCADE
+);
+
+INSERT INTO resource (name, kind, category, daily_capacity)
+VALUES
+    ('Room 101', 'room', 'consultation_room', 16),
+    ('Room 102', 'room', 'consultation_room', 16),
+    ('Imaging Room', 'room', 'imaging_room', 12),
+    ('Ultrasound 1', 'equipment', 'ultrasound', 12),
+    ('ECG 1', 'equipment', 'ecg', 20),
+    ('MRI 1', 'equipment', 'mri', 8);
+
+INSERT INTO appointment_type (name)
+VALUES
+    ('Consultation'),
+    ('Ultrasound'),
+    ('ECG'),
+    ('MRI');
+
+INSERT INTO appointment_type_requirement (id_type, category, quantity)
+VALUES
+    (1, 'consultation_room', 1),
+    (2, 'consultation_room', 1),
+    (2, 'ultrasound', 1),
+    (3, 'consultation_room', 1),
+    (3, 'ecg', 1),
+    (4, 'imaging_room', 1),
+    (4, 'mri', 1);
+
 CREATE TABLE IF NOT EXISTS appointment (
     id_appointment INT AUTO_INCREMENT PRIMARY KEY NOT NULL,
     id_patient INT NOT NULL,
     id_doctor INT NOT NULL,
     date DATE NOT NULL,
     status ENUM('honored', 'scheduled', 'confirmed', 'not_present', 'canceled') NOT NULL,
+    id_type INT NULL,
     UNIQUE KEY unique_appointment (id_patient, id_doctor, date),
     FOREIGN KEY (id_patient) REFERENCES patient(id_patient),
-    FOREIGN KEY (id_doctor) REFERENCES doctor(id_doctor)
+    FOREIGN KEY (id_doctor) REFERENCES doctor(id_doctor),
+    FOREIGN KEY (id_type) REFERENCES appointment_type(id_type)
+);
+
+-- Resources reserved for an appointment, only scheduled and confirmed appointments hold them
+CREATE TABLE IF NOT EXISTS appointment_resource (
+    id_appointment INT NOT NULL,
+    id_resource INT NOT NULL,
+    date DATE NOT NULL,
+    PRIMARY KEY (id_appointment, id_resource),
+    INDEX (id_resource, date),
+    FOREIGN KEY (id_appointment) REFERENCES appointment(id_appointment) ON DELETE CASCADE,
+    FOREIGN KEY (id_resource) REFERENCES resource(id_resource)
+);
+
+
+CREATE TABLE IF NOT EXISTS appointment_reminder (
+    id_appointment INT NOT NULL,
+    offset_minutes INT NOT NULL,
+    channel VARCHAR(32) NOT NULL,
+    sent_at DATETIME NOT NULL,
+    PRIMARY KEY (id_appointment, offset_minutes, channel),
+    FOREIGN KEY (id_appointment) REFERENCES appointment(id_appointment) ON DELETE CASCADE
+);
+
+-- Status transitions are kept after an appointment is deleted, they drive the no-show and late cancellation counters
+CREATE TABLE IF NOT EXISTS appointment_status_history (
+    id_history INT AUTO_INCREMENT PRIMARY KEY NOT NULL,
+    id_appointment INT NOT NULL,
+    id_patient INT NOT NULL,
+    old_status ENUM('honored', 'scheduled', 'confirmed', 'not_present', 'canceled') NULL,
+    new_status ENUM('honored', 'scheduled', 'confirmed', 'not_present', 'canceled') NOT NULL,
+    appointment_date DATE NOT NULL,
+    changed_at DATETIME NOT NULL,
+    policy_exempt BOOLEAN NOT NULL DEFAULT false,
+    INDEX (id_patient)
+);
+
+CREATE TABLE IF NOT EXISTS rescheduling_job (
+    id_job INT AUTO_INCREMENT PRIMARY KEY NOT NULL,
+    id_doctor INT NOT NULL,
+    unavailable_until DATE NULL,
+    status ENUM('running', 'awaiting_responses', 'completed', 'aborted') NOT NULL,
+    total INT NOT NULL DEFAULT 0,
+    processed INT NOT NULL DEFAULT 0,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL,
+    INDEX (id_doctor, status)
+);
+
+CREATE TABLE IF NOT EXISTS rescheduling_proposal (
+    id_proposal INT AUTO_INCREMENT PRIMARY KEY NOT NULL,
+    id_job INT NOT NULL,
+    id_appointment INT NOT NULL,
+    id_patient INT NOT NULL,
+    original_doctor INT NOT NULL,
+    original_date DATE NOT NULL,
+    proposed_doctor INT NULL,
+    proposed_date DATE NULL,
+    status ENUM('pending', 'accepted', 'declined', 'expired', 'unavailable') NOT NULL,
+    expires_at DATETIME NULL,
+    responded_at DATETIME NULL,
+    UNIQUE KEY unique_job_appointment (id_job, id_appointment),
+    INDEX (status, expires_at),
+    FOREIGN KEY (id_job) REFERENCES rescheduling_job(id_job) ON DELETE CASCADE
 );
 
 
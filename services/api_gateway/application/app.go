@@ -8,6 +8,8 @@ import (
 	"time"
 
 	"github.com/mihnea1711/POS_Project/services/gateway/idm"
+	"github.com/mihnea1711/POS_Project/services/gateway/internal/calendar"
+	"github.com/mihnea1711/POS_Project/services/gateway/internal/documents"
 	"github.com/mihnea1711/POS_Project/services/gateway/internal/routes"
 	"github.com/mihnea1711/POS_Project/services/gateway/pkg/config"
 	"github.com/mihnea1711/POS_Project/services/gateway/pkg/utils"
@@ -19,11 +21,27 @@ type App struct {
 	router    http.Handler
 	idmClient idm.IDMClient
 	config    *config.AppConfig
+	documents *documents.Renderer
+	feedKeys  *calendar.FeedKeys
 }
 
 func New(config *config.AppConfig, parentCtx context.Context) (*App, error) {
+	// The document templates are read once, a broken template stops the start
+	renderer, err := documents.NewRenderer(config.Documents)
+	if err != nil {
+		return nil, err
+	}
+
+	// A keys file that cannot be read stops the start, starting without it would revoke every calendar feed
+	feedKeys, err := calendar.NewFeedKeys(config.Calendar)
+	if err != nil {
+		return nil, err
+	}
+
 	app := &App{
-		config: config,
+		config:    config,
+		documents: renderer,
+		feedKeys:  feedKeys,
 	}
 
 	log.Println("[GATEWAY] Application successfully initialized.")
@@ -51,7 +69,7 @@ func (a *App) Start(ctx context.Context) error {
 	a.idmClient = idm.NewIDMClient(conn)
 
 	// setup router for the app
-	router := routes.SetupRoutes(a.idmClient, a.config.JWT)
+	router := routes.SetupRoutes(a.idmClient, a.config.JWT, a.config.Calendar, a.feedKeys, a.config.Export, a.config.FHIR, a.documents)
 	a.router = router
 
 	server := &http.Server{
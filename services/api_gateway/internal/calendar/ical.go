@@ -0,0 +1,98 @@
+package calendar
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	icalDateFormat     = "20060102"
+	icalDateTimeFormat = "20060102T150405Z"
+	icalLineLimit      = 75
+	icalProductID      = "-//RestInMedicine//Appointments//EN"
+)
+
+// Event statuses defined by RFC 5545 for VEVENT components
+const (
+	StatusTentative = "TENTATIVE"
+	StatusConfirmed = "CONFIRMED"
+	StatusCancelled = "CANCELLED"
+)
+
+// Event is a single all-day VEVENT, appointments being stored with day granularity
+type Event struct {
+	UID         string
+	Date        time.Time
+	Summary     string
+	Description string
+	Status      string
+}
+
+// Render serializes the events as an RFC 5545 VCALENDAR document
+func Render(name string, events []Event) []byte {
+	var buf bytes.Buffer
+	stamp := time.Now().UTC().Format(icalDateTimeFormat)
+
+	writeLine(&buf, "BEGIN:VCALENDAR")
+	writeLine(&buf, "VERSION:2.0")
+	writeLine(&buf, "PRODID:"+icalProductID)
+	writeLine(&buf, "CALSCALE:GREGORIAN")
+	writeLine(&buf, "METHOD:PUBLISH")
+	writeLine(&buf, "X-WR-CALNAME:"+escapeText(name))
+
+	for _, event := range events {
+		writeLine(&buf, "BEGIN:VEVENT")
+		writeLine(&buf, "UID:"+event.UID)
+		writeLine(&buf, "DTSTAMP:"+stamp)
+		writeLine(&buf, "DTSTART;VALUE=DATE:"+event.Date.Format(icalDateFormat))
+		writeLine(&buf, "DTEND;VALUE=DATE:"+event.Date.AddDate(0, 0, 1).Format(icalDateFormat))
+		writeLine(&buf, "SUMMARY:"+escapeText(event.Summary))
+		if event.Description != "" {
+			writeLine(&buf, "DESCRIPTION:"+escapeText(event.Description))
+		}
+		writeLine(&buf, "STATUS:"+event.Status)
+		writeLine(&buf, "TRANSP:OPAQUE")
+		writeLine(&buf, "END:VEVENT")
+	}
+
+	writeLine(&buf, "END:VCALENDAR")
+	return buf.Bytes()
+}
+
+// writeLine writes a content line terminated by CRLF, folding it at 75 octets as required by RFC 5545
+func writeLine(buf *bytes.Buffer, line string) {
+	limit := icalLineLimit
+	for len(line) > limit {
+		cut := limit
+		// Never split a multi-byte UTF-8 sequence
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		buf.WriteString(line[:cut])
+		buf.WriteString("\r\n ")
+		line = line[cut:]
+		// Continuation lines start with a space, which counts towards the limit
+		limit = icalLineLimit - 1
+	}
+	buf.WriteString(line)
+	buf.WriteString("\r\n")
+}
+
+// escapeText escapes a TEXT property value
+func escapeText(value string) string {
+	replacer := strings.NewReplacer(
+		`\`, `\\`,
+		`;`, `\;`,
+		`,`, `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	)
+	return replacer.Replace(value)
+}
+
+// EventUID builds a stable unique identifier for an appointment, so calendar apps update events instead of duplicating them
+func EventUID(appointmentID int) string {
+	return fmt.Sprintf("appointment-%d@restinmedicine", appointmentID)
+}
@@ -0,0 +1,124 @@
+package calendar
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/mihnea1711/POS_Project/services/gateway/pkg/config"
+)
+
+const (
+	keysFilePermissions = 0600
+	keysDirPermissions  = 0700
+	feedKeyBytes        = 16
+)
+
+// FeedKeys keeps the random key each doctor and patient feed token is derived from. Replacing the key of a user revokes
+// the feed URLs handed out before. The keys are written to disk on every change, so the tokens survive a restart of the
+// gateway; if the file is lost every feed URL stops working, none of the revoked ones comes back.
+type FeedKeys struct {
+	mu   sync.Mutex
+	keys map[string]string
+	path string
+}
+
+// NewFeedKeys loads the feed keys kept in the keys file of the calendar configuration, starting with none if there
+// is no file yet
+func NewFeedKeys(calendarConfig config.CalendarConfig) (*FeedKeys, error) {
+	feedKeys := &FeedKeys{
+		keys: make(map[string]string),
+		path: calendarConfig.KeysFile,
+	}
+
+	data, err := os.ReadFile(feedKeys.path)
+	if os.IsNotExist(err) {
+		return feedKeys, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the calendar feed keys: %w", err)
+	}
+	if err := json.Unmarshal(data, &feedKeys.keys); err != nil {
+		return nil, fmt.Errorf("failed to decode the calendar feed keys: %w", err)
+	}
+	return feedKeys, nil
+}
+
+// Lookup returns the feed key of a doctor or patient, false if none was issued yet
+func (k *FeedKeys) Lookup(owner string, ownerID int) (string, bool) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	key, ok := k.keys[feedKeyName(owner, ownerID)]
+	return key, ok
+}
+
+// Issue returns the feed key of a doctor or patient, issuing one on the first subscription
+func (k *FeedKeys) Issue(owner string, ownerID int) (string, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if key, ok := k.keys[feedKeyName(owner, ownerID)]; ok {
+		return key, nil
+	}
+	return k.replace(owner, ownerID)
+}
+
+// Rotate replaces the feed key of a doctor or patient, the feed URLs derived from the previous one stop working
+func (k *FeedKeys) Rotate(owner string, ownerID int) (string, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	return k.replace(owner, ownerID)
+}
+
+// replace draws a new key and saves it, the previous one is kept if it cannot be saved
+func (k *FeedKeys) replace(owner string, ownerID int) (string, error) {
+	buffer := make([]byte, feedKeyBytes)
+	if _, err := rand.Read(buffer); err != nil {
+		return "", fmt.Errorf("failed to generate a calendar feed key: %w", err)
+	}
+	key := hex.EncodeToString(buffer)
+
+	name := feedKeyName(owner, ownerID)
+	previous, existed := k.keys[name]
+	k.keys[name] = key
+	if err := k.save(); err != nil {
+		if existed {
+			k.keys[name] = previous
+		} else {
+			delete(k.keys, name)
+		}
+		return "", err
+	}
+	return key, nil
+}
+
+// save writes the keys to a temporary file first, so a failed write never leaves a truncated keys file behind
+func (k *FeedKeys) save() error {
+	data, err := json.MarshalIndent(k.keys, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode the calendar feed keys: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(k.path), keysDirPermissions); err != nil {
+		return fmt.Errorf("failed to create the calendar feed keys directory: %w", err)
+	}
+
+	temporary := k.path + ".tmp"
+	if err := os.WriteFile(temporary, data, keysFilePermissions); err != nil {
+		return fmt.Errorf("failed to write the calendar feed keys: %w", err)
+	}
+	if err := os.Rename(temporary, k.path); err != nil {
+		os.Remove(temporary)
+		return fmt.Errorf("failed to replace the calendar feed keys: %w", err)
+	}
+	return nil
+}
+
+func feedKeyName(owner string, ownerID int) string {
+	return fmt.Sprintf("%s:%d", owner, ownerID)
+}
@@ -5,10 +5,12 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"net/url"
 	"strconv"
 	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/mihnea1711/POS_Project/services/gateway/internal/middleware/authorization"
 	"github.com/mihnea1711/POS_Project/services/gateway/internal/models"
 	"github.com/mihnea1711/POS_Project/services/gateway/pkg/utils"
 )
@@ -51,7 +53,7 @@ func (gc *GatewayController) CreateAppointment(w http.ResponseWriter, r *http.Re
 	}
 
 	// Redirect the request body to appointment module to create the appointment
-	decodedResponse, status, err := gc.redirectRequestBody(ctx, utils.POST, utils.APPOINTMENT_HOST, utils.APPOINTMENT_CREATE_APPOINTMENT_ENDPOINT, utils.APPOINTMENT_PORT, appointmentRequest)
+	decodedResponse, status, err := gc.redirectRequestBody(ctx, utils.POST, utils.APPOINTMENT_HOST, withPolicyOverride(r, utils.APPOINTMENT_CREATE_APPOINTMENT_ENDPOINT), utils.APPOINTMENT_PORT, appointmentRequest)
 	if err != nil {
 		log.Printf("[GATEWAY] Error redirecting appointment request: %v", err)
 		utils.SendErrorResponse(w, http.StatusBadGateway, "Failed to redirect request", err.Error())
@@ -70,6 +72,14 @@ func (gc *GatewayController) CreateAppointment(w http.ResponseWriter, r *http.Re
 		log.Printf("[GATEWAY] CreateAppointment: Request failed with conflict status %d", status)
 		utils.SendErrorResponse(w, http.StatusConflict, decodedResponse.Message, "Appointment Create Conflict: "+decodedResponse.Error)
 		return
+	case http.StatusForbidden:
+		log.Printf("[GATEWAY] CreateAppointment: Request refused by the cancellation policy or for lack of coverage with status %d", status)
+		utils.SendErrorResponse(w, http.StatusForbidden, decodedResponse.Message, decodedResponse.Error)
+		return
+	case http.StatusUnprocessableEntity:
+		log.Printf("[GATEWAY] CreateAppointment: Unknown appointment type with status %d", status)
+		utils.SendErrorResponse(w, http.StatusUnprocessableEntity, decodedResponse.Message, decodedResponse.Error)
+		return
 	default:
 		log.Printf("[GATEWAY] CreateAppointment: Request failed with unexpected status %d", status)
 		utils.SendErrorResponse(w, http.StatusInternalServerError, decodedResponse.Message, "Unexpected status code: "+strconv.Itoa(status)+". Error: "+decodedResponse.Error)
@@ -205,7 +215,8 @@ func (gc *GatewayController) UpdateAppointmentByID(w http.ResponseWriter, r *htt
 	}
 
 	// Redirect the request body to appointment module to update the appointment
-	decodedResponse, status, err := gc.redirectRequestBody(ctx, utils.PUT, utils.APPOINTMENT_HOST, fmt.Sprintf("%s/%d", utils.APPOINTMENT_UPDATE_APPOINTMENT_BY_ID_ENDPOINT, appointmentID), utils.APPOINTMENT_PORT, appointmentData)
+	targetURL := withPolicyOverride(r, fmt.Sprintf("%s/%d", utils.APPOINTMENT_UPDATE_APPOINTMENT_BY_ID_ENDPOINT, appointmentID))
+	decodedResponse, status, err := gc.redirectRequestBody(ctx, utils.PUT, utils.APPOINTMENT_HOST, targetURL, utils.APPOINTMENT_PORT, appointmentData)
 	if err != nil {
 		log.Printf("[GATEWAY] Error redirecting appointment request: %v", err)
 		utils.SendErrorResponse(w, http.StatusBadGateway, "Failed to redirect request", err.Error())
@@ -226,6 +237,14 @@ func (gc *GatewayController) UpdateAppointmentByID(w http.ResponseWriter, r *htt
 		log.Printf("[GATEWAY] UpdateAppointmentByID: Request failed with conflict status %d", status)
 		utils.SendErrorResponse(w, http.StatusConflict, decodedResponse.Message, "Appointment Update Conflict: "+decodedResponse.Error)
 		return
+	case http.StatusForbidden:
+		log.Printf("[GATEWAY] UpdateAppointmentByID: Request refused by the cancellation policy with status %d", status)
+		utils.SendErrorResponse(w, http.StatusForbidden, decodedResponse.Message, decodedResponse.Error)
+		return
+	case http.StatusUnprocessableEntity:
+		log.Printf("[GATEWAY] UpdateAppointmentByID: Unknown appointment type with status %d", status)
+		utils.SendErrorResponse(w, http.StatusUnprocessableEntity, decodedResponse.Message, decodedResponse.Error)
+		return
 	default:
 		log.Printf("[GATEWAY] UpdateAppointmentByID: Request failed with unexpected status %d", status)
 		utils.SendErrorResponse(w, http.StatusInternalServerError, decodedResponse.Message, "Unexpected status code: "+strconv.Itoa(status)+". Error: "+decodedResponse.Error)
@@ -275,3 +294,102 @@ func (gc *GatewayController) DeleteAppointmentByID(w http.ResponseWriter, r *htt
 		return
 	}
 }
+
+// ConfirmAppointment forwards the tokenized confirmation link from an appointment reminder.
+func (gc *GatewayController) ConfirmAppointment(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to confirm an appointment.")
+
+	// Get appointmentID from request params
+	appointmentIDString := mux.Vars(r)[utils.CONFIRM_APPOINTMENT_ID_PARAMETER]
+	// Convert appointmentIDString to int64
+	appointmentID, err := strconv.ParseInt(appointmentIDString, 10, 64)
+	if err != nil {
+		log.Printf("[GATEWAY] Invalid appointment ID: %v", err)
+		utils.SendErrorResponse(w, http.StatusBadRequest, "Invalid appointment ID", err.Error())
+		return
+	}
+
+	// Create a context with a timeout (adjust the timeout as needed)
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_CONTEXT_TIMEOUT*time.Second)
+	defer cancel()
+
+	// Forward only the confirmation token to the appointment module
+	targetURL := fmt.Sprintf("%s/%d/confirm?%s=%s", utils.APPOINTMENT_CONFIRM_APPOINTMENT_ENDPOINT, appointmentID, utils.QUERY_TOKEN, url.QueryEscape(r.URL.Query().Get(utils.QUERY_TOKEN)))
+	decodedResponse, status, err := gc.redirectRequestBody(ctx, utils.GET, utils.APPOINTMENT_HOST, targetURL, utils.APPOINTMENT_PORT, nil)
+	if err != nil {
+		log.Printf("[GATEWAY] Error redirecting appointment request: %v", err)
+		utils.SendErrorResponse(w, http.StatusBadGateway, "Failed to redirect request", err.Error())
+		return
+	}
+
+	// Check the response status and handle accordingly
+	switch status {
+	case http.StatusOK:
+		log.Printf("[GATEWAY] ConfirmAppointment: Request successful with status %d", status)
+		utils.SendMessageResponse(w, http.StatusOK, decodedResponse.Message, decodedResponse.Payload)
+		return
+	case http.StatusForbidden, http.StatusConflict:
+		log.Printf("[GATEWAY] ConfirmAppointment: Request failed with status %d", status)
+		utils.SendErrorResponse(w, status, decodedResponse.Message, decodedResponse.Error)
+		return
+	default:
+		log.Printf("[GATEWAY] ConfirmAppointment: Request failed with unexpected status %d", status)
+		utils.SendErrorResponse(w, http.StatusInternalServerError, decodedResponse.Message, "Unexpected status code: "+strconv.Itoa(status)+". Error: "+decodedResponse.Error)
+		return
+	}
+}
+
+// GetPolicyOffenders handles the retrieval of the patients flagged by the cancellation policy.
+func (gc *GatewayController) GetPolicyOffenders(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to get cancellation policy offenders.")
+
+	// Create a context with a timeout (adjust the timeout as needed)
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_CONTEXT_TIMEOUT*time.Second)
+	defer cancel()
+
+	// Forward only the pagination parameters
+	query := url.Values{}
+	for _, key := range []string{utils.QUERY_PAGE, utils.QUERY_LIMIT} {
+		if value := r.URL.Query().Get(key); value != "" {
+			query.Set(key, value)
+		}
+	}
+	targetURL := utils.APPOINTMENT_FETCH_POLICY_OFFENDERS_ENDPOINT
+	if len(query) > 0 {
+		targetURL += "?" + query.Encode()
+	}
+
+	decodedResponse, status, err := gc.redirectRequestBody(ctx, utils.GET, utils.APPOINTMENT_HOST, targetURL, utils.APPOINTMENT_PORT, nil)
+	if err != nil {
+		log.Printf("[GATEWAY] Error redirecting appointment request: %v", err)
+		utils.SendErrorResponse(w, http.StatusBadGateway, "Failed to redirect request", err.Error())
+		return
+	}
+
+	// Check the response status and handle accordingly
+	switch status {
+	case http.StatusOK:
+		log.Printf("[GATEWAY] GetPolicyOffenders: Request successful with status %d", status)
+		utils.SendMessageResponse(w, http.StatusOK, decodedResponse.Message, decodedResponse.Payload)
+		return
+	default:
+		log.Printf("[GATEWAY] GetPolicyOffenders: Request failed with unexpected status %d", status)
+		utils.SendErrorResponse(w, http.StatusInternalServerError, decodedResponse.Message, "Unexpected status code: "+strconv.Itoa(status)+". Error: "+decodedResponse.Error)
+		return
+	}
+}
+
+// withPolicyOverride forwards the cancellation policy override, which only admins may use
+func withPolicyOverride(r *http.Request, targetURL string) string {
+	if r.URL.Query().Get(utils.QUERY_OVERRIDE) != "true" {
+		return targetURL
+	}
+
+	claims, ok := r.Context().Value(utils.JWT_CLAIMS_CONTEXT_KEY).(*authorization.MyCustomClaims)
+	if !ok || claims.Role != utils.ADMIN_ROLE {
+		log.Printf("[GATEWAY] Ignoring cancellation policy override requested by a non-admin user")
+		return targetURL
+	}
+
+	return fmt.Sprintf("%s?%s=true", targetURL, utils.QUERY_OVERRIDE)
+}
@@ -0,0 +1,237 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mihnea1711/POS_Project/services/gateway/internal/middleware/authorization"
+	"github.com/mihnea1711/POS_Project/services/gateway/internal/models"
+	"github.com/mihnea1711/POS_Project/services/gateway/pkg/utils"
+)
+
+// The headers of a downloaded attachment passed on to the client
+var attachmentDownloadHeaders = []string{"Content-Type", "Content-Length", "Content-Disposition", "X-Content-Type-Options"}
+
+// UploadAttachment streams a multipart upload to the consultation module as it arrives, the body is never buffered
+// or decoded here. Patients may only attach files to their own record.
+func (gc *GatewayController) UploadAttachment(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to upload an attachment.")
+
+	patientID, err := strconv.Atoi(r.URL.Query().Get(utils.QUERY_ID_PATIENT))
+	if err != nil {
+		log.Printf("[GATEWAY] Invalid patient ID: %v", err)
+		utils.SendErrorResponse(w, http.StatusBadRequest, "Invalid patient ID", err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), utils.ATTACHMENT_REQUEST_CONTEXT_TIMEOUT*time.Second)
+	defer cancel()
+
+	if !gc.authorizePatientAttachments(ctx, w, r, patientID) {
+		return
+	}
+
+	endpoint := fmt.Sprintf("%s?%s", utils.CONSULTATION_ATTACHMENTS_ENDPOINT, r.URL.RawQuery)
+	response, err := gc.redirectRequestStream(ctx, utils.POST, utils.CONSULTATION_HOST, endpoint, utils.CONSULTATION_PORT, r.Body, r.ContentLength, r.Header.Get("Content-Type"))
+	if err != nil {
+		log.Printf("[GATEWAY] Error streaming attachment upload: %v", err)
+		utils.SendErrorResponse(w, http.StatusBadGateway, "Failed to redirect request", err.Error())
+		return
+	}
+	defer response.Body.Close()
+
+	log.Printf("[GATEWAY] Attachment upload for patient %d answered with status %d", patientID, response.StatusCode)
+	forwardModuleResponse(w, response)
+}
+
+// GetAttachments lists attachments by patient, consultation or investigation. Patients only see their own.
+func (gc *GatewayController) GetAttachments(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to fetch attachments.")
+
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_CONTEXT_TIMEOUT*time.Second)
+	defer cancel()
+
+	query := r.URL.Query()
+	claims := r.Context().Value(utils.JWT_CLAIMS_CONTEXT_KEY).(*authorization.MyCustomClaims)
+	if claims.Role == utils.PATIENT_ROLE {
+		ownID, err := gc.fetchOwnProfileID(ctx, claims)
+		if err != nil {
+			log.Printf("[GATEWAY] Error resolving own profile: %v", err)
+			utils.SendErrorResponse(w, http.StatusUnauthorized, "Error resolving own profile", err.Error())
+			return
+		}
+		if requested := query.Get(utils.QUERY_ID_PATIENT); requested != "" && requested != strconv.Itoa(ownID) {
+			log.Printf("[GATEWAY] Patient %d tried to list the attachments of patient %s", ownID, requested)
+			utils.SendErrorResponse(w, http.StatusForbidden, "Access denied", "You can only access your own attachments")
+			return
+		}
+		query.Set(utils.QUERY_ID_PATIENT, strconv.Itoa(ownID))
+	}
+
+	result, status, err := gc.redirectRequestBody(ctx, utils.GET, utils.CONSULTATION_HOST, fmt.Sprintf("%s?%s", utils.CONSULTATION_ATTACHMENTS_ENDPOINT, query.Encode()), utils.CONSULTATION_PORT, nil)
+	if err != nil {
+		log.Printf("[GATEWAY] Error redirecting attachments request: %v", err)
+		utils.SendErrorResponse(w, http.StatusBadGateway, "Failed to redirect request", err.Error())
+		return
+	}
+	if status != http.StatusOK {
+		log.Printf("[GATEWAY] Attachments could not be fetched, status %d", status)
+		utils.SendErrorResponse(w, status, result.Message, result.Error)
+		return
+	}
+
+	utils.SendMessageResponse(w, status, result.Message, result.Payload)
+}
+
+// GetAttachmentByID returns the metadata of an attachment the user may access
+func (gc *GatewayController) GetAttachmentByID(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to fetch an attachment by ID.")
+
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_CONTEXT_TIMEOUT*time.Second)
+	defer cancel()
+
+	attachment, ok := gc.fetchAuthorizedAttachment(ctx, w, r)
+	if !ok {
+		return
+	}
+
+	utils.SendMessageResponse(w, http.StatusOK, "Attachment retrieved successfully.", attachment)
+}
+
+// DownloadAttachment streams the file from the consultation module to the client once access is checked
+func (gc *GatewayController) DownloadAttachment(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to download an attachment.")
+
+	ctx, cancel := context.WithTimeout(r.Context(), utils.ATTACHMENT_REQUEST_CONTEXT_TIMEOUT*time.Second)
+	defer cancel()
+
+	attachment, ok := gc.fetchAuthorizedAttachment(ctx, w, r)
+	if !ok {
+		return
+	}
+
+	endpoint := fmt.Sprintf("%s/%s/content", utils.CONSULTATION_ATTACHMENTS_ENDPOINT, attachment.IDAttachment.Hex())
+	response, err := gc.redirectRequestStream(ctx, utils.GET, utils.CONSULTATION_HOST, endpoint, utils.CONSULTATION_PORT, nil, 0, "")
+	if err != nil {
+		log.Printf("[GATEWAY] Error redirecting attachment download: %v", err)
+		utils.SendErrorResponse(w, http.StatusBadGateway, "Failed to redirect request", err.Error())
+		return
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		log.Printf("[GATEWAY] Attachment %s could not be downloaded, status %d", attachment.IDAttachment.Hex(), response.StatusCode)
+		forwardModuleResponse(w, response)
+		return
+	}
+
+	for _, header := range attachmentDownloadHeaders {
+		if value := response.Header.Get(header); value != "" {
+			w.Header().Set(header, value)
+		}
+	}
+	w.WriteHeader(http.StatusOK)
+
+	written, err := io.Copy(w, response.Body)
+	if err != nil {
+		log.Printf("[GATEWAY] Error streaming attachment %s after %d bytes: %v", attachment.IDAttachment.Hex(), written, err)
+		return
+	}
+	log.Printf("[GATEWAY] Attachment %s downloaded, %d bytes", attachment.IDAttachment.Hex(), written)
+}
+
+// DeleteAttachmentByID removes an attachment
+func (gc *GatewayController) DeleteAttachmentByID(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to delete an attachment by ID.")
+
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_CONTEXT_TIMEOUT*time.Second)
+	defer cancel()
+
+	attachmentID := mux.Vars(r)[utils.ATTACHMENT_ID_PARAMETER]
+	result, status, err := gc.redirectRequestBody(ctx, utils.DELETE, utils.CONSULTATION_HOST, fmt.Sprintf("%s/%s", utils.CONSULTATION_ATTACHMENTS_ENDPOINT, attachmentID), utils.CONSULTATION_PORT, nil)
+	if err != nil {
+		log.Printf("[GATEWAY] Error redirecting attachment deletion: %v", err)
+		utils.SendErrorResponse(w, http.StatusBadGateway, "Failed to redirect request", err.Error())
+		return
+	}
+	if status != http.StatusOK {
+		log.Printf("[GATEWAY] Attachment %s could not be deleted, status %d", attachmentID, status)
+		utils.SendErrorResponse(w, status, result.Message, result.Error)
+		return
+	}
+
+	utils.SendMessageResponse(w, status, result.Message, result.Payload)
+}
+
+// fetchAuthorizedAttachment reads the metadata of the requested attachment and checks the user may access its patient
+func (gc *GatewayController) fetchAuthorizedAttachment(ctx context.Context, w http.ResponseWriter, r *http.Request) (*models.AttachmentData, bool) {
+	attachmentID := mux.Vars(r)[utils.ATTACHMENT_ID_PARAMETER]
+
+	result, status, err := gc.redirectRequestBody(ctx, utils.GET, utils.CONSULTATION_HOST, fmt.Sprintf("%s/%s", utils.CONSULTATION_ATTACHMENTS_ENDPOINT, attachmentID), utils.CONSULTATION_PORT, nil)
+	if err != nil {
+		log.Printf("[GATEWAY] Error redirecting attachment request: %v", err)
+		utils.SendErrorResponse(w, http.StatusBadGateway, "Failed to redirect request", err.Error())
+		return nil, false
+	}
+	if status != http.StatusOK {
+		log.Printf("[GATEWAY] Attachment %s could not be fetched, status %d", attachmentID, status)
+		utils.SendErrorResponse(w, status, result.Message, result.Error)
+		return nil, false
+	}
+
+	var attachment models.AttachmentData
+	if err := decodePayload(result.Payload, &attachment); err != nil {
+		log.Printf("[GATEWAY] Error decoding attachment %s: %v", attachmentID, err)
+		utils.SendErrorResponse(w, http.StatusInternalServerError, "Failed to decode attachment", err.Error())
+		return nil, false
+	}
+
+	if !gc.authorizePatientAttachments(ctx, w, r, attachment.IDPatient) {
+		return nil, false
+	}
+	return &attachment, true
+}
+
+// authorizePatientAttachments lets admins and doctors through, patients only reach their own attachments
+func (gc *GatewayController) authorizePatientAttachments(ctx context.Context, w http.ResponseWriter, r *http.Request, patientID int) bool {
+	claims := r.Context().Value(utils.JWT_CLAIMS_CONTEXT_KEY).(*authorization.MyCustomClaims)
+	if claims.Role != utils.PATIENT_ROLE {
+		return true
+	}
+
+	ownID, err := gc.fetchOwnProfileID(ctx, claims)
+	if err != nil {
+		log.Printf("[GATEWAY] Error resolving own profile: %v", err)
+		utils.SendErrorResponse(w, http.StatusUnauthorized, "Error resolving own profile", err.Error())
+		return false
+	}
+	if ownID != patientID {
+		log.Printf("[GATEWAY] Patient %d tried to access the attachments of patient %d", ownID, patientID)
+		utils.SendErrorResponse(w, http.StatusForbidden, "Access denied", "You can only access your own attachments")
+		return false
+	}
+
+	return true
+}
+
+// forwardModuleResponse passes on the JSON answer of a module read from a streamed request
+func forwardModuleResponse(w http.ResponseWriter, response *http.Response) {
+	decoded, err := utils.DecodeSanitizedResponse(response)
+	if err != nil {
+		log.Printf("[GATEWAY] Error decoding module response: %v", err)
+		utils.SendErrorResponse(w, http.StatusBadGateway, "Failed to decode module response", err.Error())
+		return
+	}
+
+	if response.StatusCode >= http.StatusBadRequest {
+		utils.SendErrorResponse(w, response.StatusCode, decoded.Message, decoded.Error)
+		return
+	}
+	utils.SendMessageResponse(w, response.StatusCode, decoded.Message, decoded.Payload)
+}
@@ -0,0 +1,234 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mihnea1711/POS_Project/services/gateway/internal/calendar"
+	"github.com/mihnea1711/POS_Project/services/gateway/internal/middleware/authorization"
+	"github.com/mihnea1711/POS_Project/services/gateway/internal/models"
+	"github.com/mihnea1711/POS_Project/services/gateway/pkg/utils"
+)
+
+// CalendarSubscription holds the URL calendar apps use to subscribe to a feed
+type CalendarSubscription struct {
+	URL   string `json:"url"`
+	Token string `json:"token"`
+}
+
+// GetDoctorCalendar renders the appointments of a doctor as an iCalendar feed.
+func (gc *GatewayController) GetDoctorCalendar(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to get doctor calendar feed.")
+	gc.serveCalendar(w, r, utils.CALENDAR_OWNER_DOCTOR, mux.Vars(r)[utils.GET_DOCTOR_BY_ID_PARAMETER])
+}
+
+// GetPatientCalendar renders the appointments of a patient as an iCalendar feed.
+func (gc *GatewayController) GetPatientCalendar(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to get patient calendar feed.")
+	gc.serveCalendar(w, r, utils.CALENDAR_OWNER_PATIENT, mux.Vars(r)[utils.GET_PATIENT_ID_PARAMETER])
+}
+
+// GetDoctorCalendarSubscription returns the secret feed URL of a doctor.
+func (gc *GatewayController) GetDoctorCalendarSubscription(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to get doctor calendar subscription.")
+	gc.serveCalendarSubscription(w, r, utils.CALENDAR_OWNER_DOCTOR, mux.Vars(r)[utils.GET_DOCTOR_BY_ID_PARAMETER], false)
+}
+
+// GetPatientCalendarSubscription returns the secret feed URL of a patient.
+func (gc *GatewayController) GetPatientCalendarSubscription(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to get patient calendar subscription.")
+	gc.serveCalendarSubscription(w, r, utils.CALENDAR_OWNER_PATIENT, mux.Vars(r)[utils.GET_PATIENT_ID_PARAMETER], false)
+}
+
+// RegenerateDoctorCalendarSubscription revokes the feed URL of a doctor and returns a new one.
+func (gc *GatewayController) RegenerateDoctorCalendarSubscription(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to regenerate doctor calendar subscription.")
+	gc.serveCalendarSubscription(w, r, utils.CALENDAR_OWNER_DOCTOR, mux.Vars(r)[utils.GET_DOCTOR_BY_ID_PARAMETER], true)
+}
+
+// RegeneratePatientCalendarSubscription revokes the feed URL of a patient and returns a new one.
+func (gc *GatewayController) RegeneratePatientCalendarSubscription(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to regenerate patient calendar subscription.")
+	gc.serveCalendarSubscription(w, r, utils.CALENDAR_OWNER_PATIENT, mux.Vars(r)[utils.GET_PATIENT_ID_PARAMETER], true)
+}
+
+func (gc *GatewayController) serveCalendar(w http.ResponseWriter, r *http.Request, owner, ownerIDString string) {
+	ownerID, err := strconv.Atoi(ownerIDString)
+	if err != nil {
+		log.Printf("[GATEWAY] Invalid %s ID: %v", owner, err)
+		utils.SendErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("Invalid %s ID", owner), err.Error())
+		return
+	}
+
+	// Calendar apps authenticate with the feed token instead of a JWT, derived from the current feed key of the owner
+	token := r.URL.Query().Get(utils.QUERY_TOKEN)
+	key, issued := gc.FeedKeys.Lookup(owner, ownerID)
+	if token == "" || !issued || !utils.ValidateFeedToken(gc.CalendarConfig.FeedSecret, owner, ownerID, key, token) {
+		log.Printf("[GATEWAY] Invalid calendar feed token for %s %d", owner, ownerID)
+		utils.SendErrorResponse(w, http.StatusForbidden, "Access denied", "Invalid calendar feed token")
+		return
+	}
+
+	// Create a context with a timeout (adjust the timeout as needed)
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_CONTEXT_TIMEOUT*time.Second)
+	defer cancel()
+
+	filterKey := utils.QUERY_ID_DOCTOR
+	if owner == utils.CALENDAR_OWNER_PATIENT {
+		filterKey = utils.QUERY_ID_PATIENT
+	}
+
+	appointments, err := gc.fetchAllAppointments(ctx, filterKey, ownerID)
+	if err != nil {
+		log.Printf("[GATEWAY] Error fetching appointments for %s %d: %v", owner, ownerID, err)
+		utils.SendErrorResponse(w, http.StatusBadGateway, "Failed to fetch appointments", err.Error())
+		return
+	}
+
+	// Resolve each counterpart only once, a doctor usually sees the same patients many times
+	names := make(map[int]string)
+	events := make([]calendar.Event, 0, len(appointments))
+	for _, appointment := range appointments {
+		var summary string
+		if owner == utils.CALENDAR_OWNER_DOCTOR {
+			summary = "Appointment with " + gc.counterpartName(ctx, names, utils.CALENDAR_OWNER_PATIENT, appointment.IDPatient)
+		} else {
+			summary = "Appointment with Dr. " + gc.counterpartName(ctx, names, utils.CALENDAR_OWNER_DOCTOR, appointment.IDDoctor)
+		}
+
+		events = append(events, calendar.Event{
+			UID:         calendar.EventUID(appointment.IDProgramare),
+			Date:        appointment.Date,
+			Summary:     summary,
+			Description: fmt.Sprintf("Appointment #%d (%s)", appointment.IDProgramare, appointment.Status),
+			Status:      mapAppointmentStatus(appointment.Status),
+		})
+	}
+
+	log.Printf("[GATEWAY] Rendering calendar feed with %d events for %s %d", len(events), owner, ownerID)
+
+	w.Header().Set("Content-Type", utils.CALENDAR_CONTENT_TYPE)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", fmt.Sprintf("%s-%d.ics", owner, ownerID)))
+	w.WriteHeader(http.StatusOK)
+	w.Write(calendar.Render(fmt.Sprintf("Appointments (%s %d)", owner, ownerID), events))
+}
+
+// serveCalendarSubscription returns the feed URL of the owner, after replacing their feed key when regenerate is set
+func (gc *GatewayController) serveCalendarSubscription(w http.ResponseWriter, r *http.Request, owner, ownerIDString string, regenerate bool) {
+	ownerID, err := strconv.Atoi(ownerIDString)
+	if err != nil {
+		log.Printf("[GATEWAY] Invalid %s ID: %v", owner, err)
+		utils.SendErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("Invalid %s ID", owner), err.Error())
+		return
+	}
+
+	// Create a context with a timeout (adjust the timeout as needed)
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_CONTEXT_TIMEOUT*time.Second)
+	defer cancel()
+
+	// Doctors and patients may only subscribe to their own calendar
+	claims := r.Context().Value(utils.JWT_CLAIMS_CONTEXT_KEY).(*authorization.MyCustomClaims)
+	if claims.Role != utils.ADMIN_ROLE {
+		ownID, err := gc.fetchOwnProfileID(ctx, claims)
+		if err != nil {
+			log.Printf("[GATEWAY] Error resolving own profile: %v", err)
+			utils.SendErrorResponse(w, http.StatusUnauthorized, "Error resolving own profile", err.Error())
+			return
+		}
+		if ownID != ownerID {
+			log.Printf("[GATEWAY] User tried to access the calendar of %s %d", owner, ownerID)
+			utils.SendErrorResponse(w, http.StatusForbidden, "Access denied", "You can only subscribe to your own calendar")
+			return
+		}
+	}
+
+	issueKey, message := gc.FeedKeys.Issue, "Calendar subscription generated successfully"
+	if regenerate {
+		issueKey, message = gc.FeedKeys.Rotate, "Calendar subscription regenerated successfully, the previous feed URL no longer works"
+	}
+	key, err := issueKey(owner, ownerID)
+	if err != nil {
+		log.Printf("[GATEWAY] Error issuing the calendar feed key of %s %d: %v", owner, ownerID, err)
+		utils.SendErrorResponse(w, http.StatusInternalServerError, "Failed to issue the calendar feed key", err.Error())
+		return
+	}
+	if regenerate {
+		log.Printf("[GATEWAY] Calendar feed key of %s %d replaced", owner, ownerID)
+	}
+
+	token := utils.GenerateFeedToken(gc.CalendarConfig.FeedSecret, owner, ownerID, key)
+	subscription := CalendarSubscription{
+		URL:   fmt.Sprintf("%s/api/%ss/%d/calendar.ics?%s=%s", gc.CalendarConfig.BaseURL, owner, ownerID, utils.QUERY_TOKEN, token),
+		Token: token,
+	}
+
+	utils.SendMessageResponse(w, http.StatusOK, message, subscription)
+}
+
+// fetchAllAppointments walks every page of appointments matching the given filter
+func (gc *GatewayController) fetchAllAppointments(ctx context.Context, filterKey string, filterValue int) ([]models.AppointmentData, error) {
+	var appointments []models.AppointmentData
+
+	for page := utils.DEFAULT_PAGINATION_PAGE; ; page++ {
+		targetURL := fmt.Sprintf("%s?%s=%d&%s=%d&%s=%d", utils.APPOINTMENT_FETCH_ALL_APPOINTMENTS_ENDPOINT, filterKey, filterValue, utils.QUERY_PAGE, page, utils.QUERY_LIMIT, utils.MAX_PAGINATION_LIMIT)
+		result, status, err := gc.redirectRequestBody(ctx, utils.GET, utils.APPOINTMENT_HOST, targetURL, utils.APPOINTMENT_PORT, nil)
+		if err != nil {
+			return nil, err
+		}
+		if status != http.StatusOK {
+			return nil, fmt.Errorf("appointment module responded with status %d: %s", status, result.Error)
+		}
+
+		var pageAppointments []models.AppointmentData
+		if err := decodePayload(result.Payload, &pageAppointments); err != nil {
+			return nil, err
+		}
+
+		appointments = append(appointments, pageAppointments...)
+		if len(pageAppointments) < utils.MAX_PAGINATION_LIMIT {
+			return appointments, nil
+		}
+	}
+}
+
+// counterpartName resolves the display name of a doctor or patient, falling back to the ID if it cannot be fetched
+func (gc *GatewayController) counterpartName(ctx context.Context, cache map[int]string, kind string, id int) string {
+	if name, ok := cache[id]; ok {
+		return name
+	}
+
+	host, port, endpoint := utils.PATIENT_HOST, utils.PATIENT_PORT, utils.PATIENT_FETCH_PATIENT_BY_ID_ENDPOINT
+	if kind == utils.CALENDAR_OWNER_DOCTOR {
+		host, port, endpoint = utils.DOCTOR_HOST, utils.DOCTOR_PORT, utils.DOCTOR_FETCH_DOCTOR_BY_ID_ENDPOINT
+	}
+
+	name := fmt.Sprintf("%s #%d", kind, id)
+	result, status, err := gc.redirectRequestBody(ctx, utils.GET, host, fmt.Sprintf("%s/%d", endpoint, id), port, nil)
+	if err == nil && status == http.StatusOK {
+		// Both patients and doctors expose firstName and secondName
+		var person models.PatientData
+		if err := decodePayload(result.Payload, &person); err == nil {
+			name = fmt.Sprintf("%s %s", person.FirstName, person.SecondName)
+		}
+	}
+
+	cache[id] = name
+	return name
+}
+
+// mapAppointmentStatus maps an appointment status to a VEVENT status
+func mapAppointmentStatus(status models.StatusAppointment) string {
+	switch status {
+	case utils.APPOINTMENT_STATUS_CANCELED:
+		return calendar.StatusCancelled
+	case utils.APPOINTMENT_STATUS_SCHEDULED:
+		return calendar.StatusTentative
+	default:
+		return calendar.StatusConfirmed
+	}
+}
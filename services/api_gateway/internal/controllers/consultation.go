@@ -52,31 +52,28 @@ func (gc *GatewayController) CreateConsultation(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	// Check if there is an appointment with the same IDPatient, IDDoctor and date already created.
-	targetQuery := fmt.Sprintf(
-		"%s=%d&%s=%d&%s=%s",
-		utils.QUERY_ID_PATIENT, consultationRequest.IDPatient,
-		utils.QUERY_ID_DOCTOR, consultationRequest.IDDoctor,
-		utils.QUERY_DATE, consultationRequest.Date.Format(utils.TIME_PARSE))
-	targetURL = fmt.Sprintf("%s?%s", utils.APPOINTMENT_FETCH_ALL_APPOINTMENTS_ENDPOINT, targetQuery)
-	log.Println(targetURL)
-	log.Println(targetQuery)
-	decodedResponseAppointment, statusAppointment, errAppointment := gc.redirectRequestBody(ctx, http.MethodGet, utils.APPOINTMENT_HOST, targetURL, utils.APPOINTMENT_PORT, nil)
-	log.Println("aici --------------------------------------------------------------------------")
-	log.Println(decodedResponseAppointment, statusAppointment, errAppointment)
-	if errAppointment != nil {
-		log.Printf("[GATEWAY] Error redirecting appointment ID request: %v", errAppointment)
-		utils.SendErrorResponse(w, http.StatusBadGateway, "Failed to validate appointment ID", errAppointment.Error())
+	// The consultation fulfils an appointment, which is honored before the consultation is saved: a consultation that
+	// fails to save leaves a honored appointment without consultation, which the unrecorded visits report lists
+	appointmentID, ok := gc.resolveConsultationAppointment(ctx, w, consultationRequest)
+	if !ok {
 		return
 	}
-	if statusAppointment != http.StatusOK || decodedResponseAppointment.Payload == nil {
-		log.Printf("[GATEWAY] Appointment doesn't exist for this consultation or an unexpected error occured with status: %d", statusAppointment)
-		utils.SendErrorResponse(w, http.StatusFailedDependency, decodedResponseAppointment.Message, decodedResponseAppointment.Error)
+	consultationRequest.IDAppointment = &appointmentID
+	if !gc.honorAppointment(ctx, w, appointmentID) {
+		return
+	}
+
+	// The consultation module records the user as the author of the first revision
+	amendment, err := amendmentQuery(r)
+	if err != nil {
+		log.Printf("[GATEWAY] %v", err)
+		utils.SendErrorResponse(w, http.StatusUnauthorized, "Failed to identify the user", err.Error())
 		return
 	}
 
 	// Redirect the request body to appointment module to create the appointment
-	decodedResponse, status, err := gc.redirectRequestBody(ctx, utils.POST, utils.CONSULTATION_HOST, utils.CONSULTATION_CREATE_CONSULTATIE_ENDPOINT, utils.CONSULTATION_PORT, consultationRequest)
+	targetURL = fmt.Sprintf("%s?%s", utils.CONSULTATION_CREATE_CONSULTATIE_ENDPOINT, amendment)
+	decodedResponse, status, err := gc.redirectRequestBody(ctx, utils.POST, utils.CONSULTATION_HOST, targetURL, utils.CONSULTATION_PORT, consultationRequest)
 	if err != nil {
 		log.Printf("[GATEWAY] Failed to redirect request: %v", err)
 		utils.SendErrorResponse(w, http.StatusBadGateway, "Failed to redirect request", err.Error())
@@ -91,6 +88,10 @@ func (gc *GatewayController) CreateConsultation(w http.ResponseWriter, r *http.R
 		w.Header().Set(utils.HEADER_LOCATION_KEY, fmt.Sprintf("/api%s", locationHeader))
 		utils.SendMessageResponse(w, http.StatusOK, decodedResponse.Message, decodedResponse.Payload)
 		return
+	case http.StatusBadRequest:
+		log.Printf("[GATEWAY] CreateConsultation: Request failed with bad request status %d", status)
+		utils.SendErrorResponse(w, http.StatusBadRequest, decodedResponse.Message, decodedResponse.Error)
+		return
 	case http.StatusConflict:
 		log.Printf("[GATEWAY] CreateConsultation: Request failed with conflict status %d", status)
 		utils.SendErrorResponse(w, http.StatusConflict, decodedResponse.Message, "Consultation Create Conflict: "+decodedResponse.Error)
@@ -103,6 +104,7 @@ func (gc *GatewayController) CreateConsultation(w http.ResponseWriter, r *http.R
 }
 
 // GetConsultations handles the retrieval of all consultations.
+// The text search q covers the coded diagnoses and the investigation names, not the encrypted diagnostic and results.
 func (gc *GatewayController) GetConsultations(w http.ResponseWriter, r *http.Request) {
 	log.Printf("[GATEWAY] Attempting to get all consultations.")
 
@@ -132,6 +134,10 @@ func (gc *GatewayController) GetConsultations(w http.ResponseWriter, r *http.Req
 		log.Printf("[GATEWAY] GetConsultations: Request successful with status %d", status)
 		utils.SendMessageResponse(w, http.StatusOK, decodedResponse.Message, decodedResponse.Payload)
 		return
+	case http.StatusBadRequest:
+		log.Printf("[GATEWAY] GetConsultations: Invalid search with status %d", status)
+		utils.SendErrorResponse(w, http.StatusBadRequest, decodedResponse.Message, decodedResponse.Error)
+		return
 	default:
 		log.Printf("[GATEWAY] GetConsultations: Request failed with unexpected status %d", status)
 		utils.SendErrorResponse(w, http.StatusInternalServerError, decodedResponse.Message, "Unexpected status code: "+strconv.Itoa(status)+". Error: "+decodedResponse.Error)
@@ -215,8 +221,17 @@ func (gc *GatewayController) UpdateConsultationByID(w http.ResponseWriter, r *ht
 		return
 	}
 
+	// Every update is an amendment recorded with its author and reason
+	amendment, err := amendmentQuery(r)
+	if err != nil {
+		log.Printf("[GATEWAY] %v", err)
+		utils.SendErrorResponse(w, http.StatusUnauthorized, "Failed to identify the user", err.Error())
+		return
+	}
+
 	// Redirect the request body to appointment module to create the consultation
-	decodedResponse, status, err := gc.redirectRequestBody(ctx, utils.PUT, utils.CONSULTATION_HOST, fmt.Sprintf("%s/%s", utils.CONSULTATION_UPDATE_CONSULTATIE_BY_ID_ENDPOINT, consultationIDString), utils.CONSULTATION_PORT, consultationData)
+	targetURL := fmt.Sprintf("%s/%s?%s", utils.CONSULTATION_UPDATE_CONSULTATIE_BY_ID_ENDPOINT, consultationIDString, amendment)
+	decodedResponse, status, err := gc.redirectRequestBody(ctx, utils.PUT, utils.CONSULTATION_HOST, targetURL, utils.CONSULTATION_PORT, consultationData)
 	if err != nil {
 		log.Printf("[GATEWAY] Error redirecting consultation request: %v", err)
 		utils.SendErrorResponse(w, http.StatusBadGateway, "Failed to redirect request", err.Error())
@@ -229,6 +244,10 @@ func (gc *GatewayController) UpdateConsultationByID(w http.ResponseWriter, r *ht
 		log.Printf("[GATEWAY] UpdateConsultationByID: Request successful with status %d", status)
 		utils.SendMessageResponse(w, http.StatusOK, decodedResponse.Message, decodedResponse.Payload)
 		return
+	case http.StatusBadRequest:
+		log.Printf("[GATEWAY] UpdateConsultationByID: Request failed with bad request status %d", status)
+		utils.SendErrorResponse(w, http.StatusBadRequest, decodedResponse.Message, decodedResponse.Error)
+		return
 	case http.StatusNotFound:
 		log.Printf("[GATEWAY] UpdateConsultationByID: Request failed with not found status %d", status)
 		utils.SendErrorResponse(w, http.StatusNotFound, decodedResponse.Message, "Consultation not found: "+decodedResponse.Error)
@@ -5,18 +5,28 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"strconv"
 
 	"github.com/mihnea1711/POS_Project/services/gateway/idm"
+	"github.com/mihnea1711/POS_Project/services/gateway/internal/calendar"
+	"github.com/mihnea1711/POS_Project/services/gateway/internal/documents"
+	"github.com/mihnea1711/POS_Project/services/gateway/internal/export"
 	"github.com/mihnea1711/POS_Project/services/gateway/internal/middleware/authorization"
 	"github.com/mihnea1711/POS_Project/services/gateway/internal/models"
+	"github.com/mihnea1711/POS_Project/services/gateway/pkg/config"
 	"github.com/mihnea1711/POS_Project/services/gateway/pkg/utils"
 )
 
 type GatewayController struct {
-	IDMClient idm.IDMClient
+	IDMClient      idm.IDMClient
+	CalendarConfig config.CalendarConfig
+	FeedKeys       *calendar.FeedKeys
+	Exports        *export.Store
+	FHIRConfig     config.FHIRConfig
+	Documents      *documents.Renderer
 }
 
 func (gc *GatewayController) redirectRequestBody(ctx context.Context, methodType, host, endpoint string, port int, data interface{}) (*models.ResponseDataWrapper, int, error) {
@@ -63,6 +73,29 @@ func (gc *GatewayController) redirectRequestBody(ctx context.Context, methodType
 	return decodedResponse, response.StatusCode, nil
 }
 
+// redirectRequestStream forwards the body while it is read and returns the module response unread, for files that must
+// be neither buffered nor marshaled to JSON. The caller closes the response body.
+func (gc *GatewayController) redirectRequestStream(ctx context.Context, methodType, host, endpoint string, port int, body io.Reader, contentLength int64, contentType string) (*http.Response, error) {
+	request, err := http.NewRequestWithContext(ctx, methodType, fmt.Sprintf("http://%s:%d%s", host, port, endpoint), body)
+	if err != nil {
+		log.Printf("[GATEWAY] Error creating HTTP request: %v", err)
+		return nil, err
+	}
+	if contentLength > 0 {
+		request.ContentLength = contentLength
+	}
+	if contentType != "" {
+		request.Header.Set("Content-Type", contentType)
+	}
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Printf("[GATEWAY] Error making HTTP request: %v", err)
+		return nil, err
+	}
+	return response, nil
+}
+
 func (gc *GatewayController) GenerateTargetURL(r *http.Request, baseEndpoint string) (string, error) {
 	claims := r.Context().Value(utils.JWT_CLAIMS_CONTEXT_KEY).(*authorization.MyCustomClaims)
 
@@ -99,7 +132,7 @@ func (gc *GatewayController) GenerateTargetURL(r *http.Request, baseEndpoint str
 			return "", fmt.Errorf("failed to unmarshal JSON into PatientData: %v", err)
 		}
 
-		targetURL += utils.AppendQueryParam(r.URL.RawQuery, utils.QUERY_ID_PATIENT, strconv.Itoa(payloadPatient.IDPatient))
+		targetURL += utils.SetQueryParam(r.URL.RawQuery, utils.QUERY_ID_PATIENT, strconv.Itoa(payloadPatient.IDPatient))
 	case utils.DOCTOR_ROLE:
 		// Doctors can only view their own data
 
@@ -123,8 +156,11 @@ func (gc *GatewayController) GenerateTargetURL(r *http.Request, baseEndpoint str
 			return "", fmt.Errorf("failed to unmarshal JSON into PatientData: %v", err)
 		}
 
-		targetURL += utils.AppendQueryParam(r.URL.RawQuery, utils.QUERY_ID_DOCTOR, strconv.Itoa(payloadDoctor.IDDoctor))
+		targetURL += utils.SetQueryParam(r.URL.RawQuery, utils.QUERY_ID_DOCTOR, strconv.Itoa(payloadDoctor.IDDoctor))
 		// Add more cases if needed for other roles
+	default:
+		// Admins search everything, with their own filters
+		targetURL += r.URL.RawQuery
 	}
 
 	return targetURL, nil
@@ -174,3 +210,53 @@ func (gc *GatewayController) GenerateTargetURLPatients(r *http.Request, baseEndp
 
 	return targetURL, nil
 }
+
+// decodePayload converts a generic response payload into the given target struct
+func decodePayload(payload interface{}, target interface{}) error {
+	payloadJSON, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal result payload to JSON: %v", err)
+	}
+
+	if err := json.Unmarshal(payloadJSON, target); err != nil {
+		return fmt.Errorf("failed to unmarshal payload JSON: %v", err)
+	}
+
+	return nil
+}
+
+// fetchOwnProfileID returns the patient or doctor ID that belongs to the authenticated user.
+// Admins do not own a profile, so 0 is returned for them.
+func (gc *GatewayController) fetchOwnProfileID(ctx context.Context, claims *authorization.MyCustomClaims) (int, error) {
+	userID, err := claims.GetSubject()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get user ID from claims: %v", err)
+	}
+
+	switch claims.Role {
+	case utils.PATIENT_ROLE:
+		result, status, err := gc.redirectRequestBody(ctx, utils.GET, utils.PATIENT_HOST, fmt.Sprintf("%s/%s", utils.PATIENT_FETCH_PATIENT_BY_USER_ID_ENDPOINT, userID), utils.PATIENT_PORT, nil)
+		if err != nil || status != http.StatusOK {
+			return 0, fmt.Errorf("failed to fetch patient for user %s: status %d, %v", userID, status, err)
+		}
+
+		var patient models.PatientData
+		if err := decodePayload(result.Payload, &patient); err != nil {
+			return 0, err
+		}
+		return patient.IDPatient, nil
+	case utils.DOCTOR_ROLE:
+		result, status, err := gc.redirectRequestBody(ctx, utils.GET, utils.DOCTOR_HOST, fmt.Sprintf("%s/%s", utils.DOCTOR_FETCH_DOCTOR_BY_USER_ID_ENDPOINT, userID), utils.DOCTOR_PORT, nil)
+		if err != nil || status != http.StatusOK {
+			return 0, fmt.Errorf("failed to fetch doctor for user %s: status %d, %v", userID, status, err)
+		}
+
+		var doctor models.DoctorData
+		if err := decodePayload(result.Payload, &doctor); err != nil {
+			return 0, err
+		}
+		return doctor.IDDoctor, nil
+	}
+
+	return 0, nil
+}
@@ -0,0 +1,50 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/mihnea1711/POS_Project/services/gateway/pkg/utils"
+)
+
+// SearchICD10 handles the autocomplete of the ICD-10 codes a consultation is coded with.
+// The q and limit queries are passed on to the consultation module.
+func (gc *GatewayController) SearchICD10(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to search ICD-10 codes.")
+
+	// Create a context with a timeout (adjust the timeout as needed)
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_CONTEXT_TIMEOUT*time.Second)
+	defer cancel()
+
+	targetURL := utils.CONSULTATION_ICD10_SEARCH_ENDPOINT
+	if r.URL.RawQuery != "" {
+		targetURL = fmt.Sprintf("%s?%s", targetURL, r.URL.RawQuery)
+	}
+
+	decodedResponse, status, err := gc.redirectRequestBody(ctx, utils.GET, utils.CONSULTATION_HOST, targetURL, utils.CONSULTATION_PORT, nil)
+	if err != nil {
+		log.Printf("[GATEWAY] Error redirecting ICD-10 request: %v", err)
+		utils.SendErrorResponse(w, http.StatusBadGateway, "Failed to redirect request", err.Error())
+		return
+	}
+
+	// Check the response status and handle accordingly
+	switch status {
+	case http.StatusOK:
+		log.Printf("[GATEWAY] SearchICD10: Request successful with status %d", status)
+		utils.SendMessageResponse(w, http.StatusOK, decodedResponse.Message, decodedResponse.Payload)
+		return
+	case http.StatusBadRequest:
+		log.Printf("[GATEWAY] SearchICD10: Request failed with bad request status %d", status)
+		utils.SendErrorResponse(w, http.StatusBadRequest, decodedResponse.Message, decodedResponse.Error)
+		return
+	default:
+		log.Printf("[GATEWAY] SearchICD10: Request failed with unexpected status %d", status)
+		utils.SendErrorResponse(w, http.StatusInternalServerError, decodedResponse.Message, "Unexpected status code: "+strconv.Itoa(status)+". Error: "+decodedResponse.Error)
+		return
+	}
+}
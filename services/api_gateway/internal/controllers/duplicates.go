@@ -0,0 +1,232 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mihnea1711/POS_Project/services/gateway/internal/models"
+	"github.com/mihnea1711/POS_Project/services/gateway/pkg/utils"
+)
+
+// GetDuplicateCandidates handles the retrieval of the likely duplicate patients.
+func (gc *GatewayController) GetDuplicateCandidates(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to get duplicate candidates.")
+
+	// Create a context with a timeout (adjust the timeout as needed)
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_CONTEXT_TIMEOUT*time.Second)
+	defer cancel()
+
+	targetURL := withForwardedQuery(r, utils.PATIENT_DUPLICATES_ENDPOINT, utils.QUERY_STATUS, utils.QUERY_PAGE, utils.QUERY_LIMIT)
+	decodedResponse, status, err := gc.redirectRequestBody(ctx, utils.GET, utils.PATIENT_HOST, targetURL, utils.PATIENT_PORT, nil)
+	if err != nil {
+		log.Printf("[GATEWAY] Error redirecting patient request: %v", err)
+		utils.SendErrorResponse(w, http.StatusBadGateway, "Failed to redirect request", err.Error())
+		return
+	}
+
+	// Check the response status and handle accordingly
+	switch status {
+	case http.StatusOK:
+		log.Printf("[GATEWAY] GetDuplicateCandidates: Request successful with status %d", status)
+		utils.SendMessageResponse(w, http.StatusOK, decodedResponse.Message, decodedResponse.Payload)
+		return
+	case http.StatusBadRequest:
+		log.Printf("[GATEWAY] GetDuplicateCandidates: Request failed with bad request status %d", status)
+		utils.SendErrorResponse(w, http.StatusBadRequest, decodedResponse.Message, decodedResponse.Error)
+		return
+	default:
+		log.Printf("[GATEWAY] GetDuplicateCandidates: Request failed with unexpected status %d", status)
+		utils.SendErrorResponse(w, http.StatusInternalServerError, decodedResponse.Message, "Unexpected status code: "+strconv.Itoa(status)+". Error: "+decodedResponse.Error)
+		return
+	}
+}
+
+// ScanDuplicates handles an on-demand scan for duplicate patients.
+func (gc *GatewayController) ScanDuplicates(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to scan for duplicate patients.")
+
+	// A scan compares every active patient, allow it more time than a usual request
+	ctx, cancel := context.WithTimeout(r.Context(), utils.LONG_REQUEST_CONTEXT_TIMEOUT*time.Second)
+	defer cancel()
+
+	decodedResponse, status, err := gc.redirectRequestBody(ctx, utils.POST, utils.PATIENT_HOST, utils.PATIENT_DUPLICATES_ENDPOINT+"/scan", utils.PATIENT_PORT, nil)
+	if err != nil {
+		log.Printf("[GATEWAY] Error redirecting patient request: %v", err)
+		utils.SendErrorResponse(w, http.StatusBadGateway, "Failed to redirect request", err.Error())
+		return
+	}
+
+	// Check the response status and handle accordingly
+	switch status {
+	case http.StatusOK:
+		log.Printf("[GATEWAY] ScanDuplicates: Request successful with status %d", status)
+		utils.SendMessageResponse(w, http.StatusOK, decodedResponse.Message, decodedResponse.Payload)
+		return
+	default:
+		log.Printf("[GATEWAY] ScanDuplicates: Request failed with unexpected status %d", status)
+		utils.SendErrorResponse(w, http.StatusInternalServerError, decodedResponse.Message, "Unexpected status code: "+strconv.Itoa(status)+". Error: "+decodedResponse.Error)
+		return
+	}
+}
+
+// DismissDuplicateCandidate handles marking a candidate pair as two different patients.
+func (gc *GatewayController) DismissDuplicateCandidate(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to dismiss a duplicate candidate.")
+
+	// Get candidateID from request params
+	candidateID, err := strconv.Atoi(mux.Vars(r)[utils.DUPLICATE_CANDIDATE_ID_PARAMETER])
+	if err != nil {
+		log.Printf("[GATEWAY] Invalid duplicate candidate ID: %v", err)
+		utils.SendErrorResponse(w, http.StatusBadRequest, "Invalid duplicate candidate ID", err.Error())
+		return
+	}
+
+	// Create a context with a timeout (adjust the timeout as needed)
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_CONTEXT_TIMEOUT*time.Second)
+	defer cancel()
+
+	targetURL := fmt.Sprintf("%s/%d/dismiss", utils.PATIENT_DUPLICATES_ENDPOINT, candidateID)
+	decodedResponse, status, err := gc.redirectRequestBody(ctx, utils.POST, utils.PATIENT_HOST, targetURL, utils.PATIENT_PORT, nil)
+	if err != nil {
+		log.Printf("[GATEWAY] Error redirecting patient request: %v", err)
+		utils.SendErrorResponse(w, http.StatusBadGateway, "Failed to redirect request", err.Error())
+		return
+	}
+
+	// Check the response status and handle accordingly
+	switch status {
+	case http.StatusOK:
+		log.Printf("[GATEWAY] DismissDuplicateCandidate: Request successful with status %d", status)
+		utils.SendMessageResponse(w, http.StatusOK, decodedResponse.Message, decodedResponse.Payload)
+		return
+	case http.StatusNotFound:
+		log.Printf("[GATEWAY] DismissDuplicateCandidate: Duplicate candidate not found with status %d", status)
+		utils.SendErrorResponse(w, http.StatusNotFound, decodedResponse.Message, decodedResponse.Error)
+		return
+	default:
+		log.Printf("[GATEWAY] DismissDuplicateCandidate: Request failed with unexpected status %d", status)
+		utils.SendErrorResponse(w, http.StatusInternalServerError, decodedResponse.Message, "Unexpected status code: "+strconv.Itoa(status)+". Error: "+decodedResponse.Error)
+		return
+	}
+}
+
+// CreatePatientMerge handles merging a duplicate patient into the surviving one. Requesting a failed merge again resumes it.
+func (gc *GatewayController) CreatePatientMerge(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to merge patients.")
+
+	// Take merge data from the context after validation
+	mergeRequest := r.Context().Value(utils.DECODED_MERGE_DATA).(*models.MergeData)
+
+	// The merge moves appointments and consultations through their modules, allow it more time than a usual request
+	ctx, cancel := context.WithTimeout(r.Context(), utils.LONG_REQUEST_CONTEXT_TIMEOUT*time.Second)
+	defer cancel()
+
+	decodedResponse, status, err := gc.redirectRequestBody(ctx, utils.POST, utils.PATIENT_HOST, utils.PATIENT_MERGES_ENDPOINT, utils.PATIENT_PORT, mergeRequest)
+	if err != nil {
+		log.Printf("[GATEWAY] Error redirecting patient request: %v", err)
+		utils.SendErrorResponse(w, http.StatusBadGateway, "Failed to redirect request", err.Error())
+		return
+	}
+
+	// Check the response status and handle accordingly
+	switch status {
+	case http.StatusCreated:
+		log.Printf("[GATEWAY] CreatePatientMerge: Request successful with status %d", status)
+		locationHeader := decodedResponse.Header.Get(utils.HEADER_LOCATION_KEY)
+		w.Header().Set(utils.HEADER_LOCATION_KEY, fmt.Sprintf("/api%s", locationHeader))
+		utils.SendMessageResponse(w, http.StatusCreated, decodedResponse.Message, decodedResponse.Payload)
+		return
+	case http.StatusOK:
+		// A failed merge was resumed and completed
+		log.Printf("[GATEWAY] CreatePatientMerge: Request successful with status %d", status)
+		utils.SendMessageResponse(w, http.StatusOK, decodedResponse.Message, decodedResponse.Payload)
+		return
+	case http.StatusConflict, http.StatusBadGateway:
+		// The payload holds the conflicting merge, or the merge that stopped and can be resumed
+		log.Printf("[GATEWAY] CreatePatientMerge: Request failed with status %d", status)
+		utils.RespondWithJSON(w, status, models.ResponseData{Message: decodedResponse.Message, Error: decodedResponse.Error, Payload: decodedResponse.Payload})
+		return
+	case http.StatusNotFound, http.StatusBadRequest, http.StatusUnprocessableEntity:
+		log.Printf("[GATEWAY] CreatePatientMerge: Request failed with status %d", status)
+		utils.SendErrorResponse(w, status, decodedResponse.Message, decodedResponse.Error)
+		return
+	default:
+		log.Printf("[GATEWAY] CreatePatientMerge: Request failed with unexpected status %d", status)
+		utils.SendErrorResponse(w, http.StatusInternalServerError, decodedResponse.Message, "Unexpected status code: "+strconv.Itoa(status)+". Error: "+decodedResponse.Error)
+		return
+	}
+}
+
+// GetPatientMerges handles the retrieval of the patient merge history.
+func (gc *GatewayController) GetPatientMerges(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to get all patient merges.")
+
+	// Create a context with a timeout (adjust the timeout as needed)
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_CONTEXT_TIMEOUT*time.Second)
+	defer cancel()
+
+	targetURL := withForwardedQuery(r, utils.PATIENT_MERGES_ENDPOINT, utils.QUERY_PAGE, utils.QUERY_LIMIT)
+	decodedResponse, status, err := gc.redirectRequestBody(ctx, utils.GET, utils.PATIENT_HOST, targetURL, utils.PATIENT_PORT, nil)
+	if err != nil {
+		log.Printf("[GATEWAY] Error redirecting patient request: %v", err)
+		utils.SendErrorResponse(w, http.StatusBadGateway, "Failed to redirect request", err.Error())
+		return
+	}
+
+	// Check the response status and handle accordingly
+	switch status {
+	case http.StatusOK:
+		log.Printf("[GATEWAY] GetPatientMerges: Request successful with status %d", status)
+		utils.SendMessageResponse(w, http.StatusOK, decodedResponse.Message, decodedResponse.Payload)
+		return
+	default:
+		log.Printf("[GATEWAY] GetPatientMerges: Request failed with unexpected status %d", status)
+		utils.SendErrorResponse(w, http.StatusInternalServerError, decodedResponse.Message, "Unexpected status code: "+strconv.Itoa(status)+". Error: "+decodedResponse.Error)
+		return
+	}
+}
+
+// GetPatientMergeByID handles the retrieval of a patient merge and its progress.
+func (gc *GatewayController) GetPatientMergeByID(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to get a patient merge by ID.")
+
+	// Get mergeID from request params
+	mergeID, err := strconv.Atoi(mux.Vars(r)[utils.PATIENT_MERGE_ID_PARAMETER])
+	if err != nil {
+		log.Printf("[GATEWAY] Invalid patient merge ID: %v", err)
+		utils.SendErrorResponse(w, http.StatusBadRequest, "Invalid patient merge ID", err.Error())
+		return
+	}
+
+	// Create a context with a timeout (adjust the timeout as needed)
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_CONTEXT_TIMEOUT*time.Second)
+	defer cancel()
+
+	decodedResponse, status, err := gc.redirectRequestBody(ctx, utils.GET, utils.PATIENT_HOST, fmt.Sprintf("%s/%d", utils.PATIENT_MERGES_ENDPOINT, mergeID), utils.PATIENT_PORT, nil)
+	if err != nil {
+		log.Printf("[GATEWAY] Error redirecting patient request: %v", err)
+		utils.SendErrorResponse(w, http.StatusBadGateway, "Failed to redirect request", err.Error())
+		return
+	}
+
+	// Check the response status and handle accordingly
+	switch status {
+	case http.StatusOK:
+		log.Printf("[GATEWAY] GetPatientMergeByID: Request successful with status %d", status)
+		utils.SendMessageResponse(w, http.StatusOK, decodedResponse.Message, decodedResponse.Payload)
+		return
+	case http.StatusNotFound:
+		log.Printf("[GATEWAY] GetPatientMergeByID: Patient merge not found with status %d", status)
+		utils.SendErrorResponse(w, http.StatusNotFound, decodedResponse.Message, decodedResponse.Error)
+		return
+	default:
+		log.Printf("[GATEWAY] GetPatientMergeByID: Request failed with unexpected status %d", status)
+		utils.SendErrorResponse(w, http.StatusInternalServerError, decodedResponse.Message, "Unexpected status code: "+strconv.Itoa(status)+". Error: "+decodedResponse.Error)
+		return
+	}
+}
@@ -0,0 +1,365 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mihnea1711/POS_Project/services/gateway/idm/proto_files"
+	"github.com/mihnea1711/POS_Project/services/gateway/internal/export"
+	"github.com/mihnea1711/POS_Project/services/gateway/internal/middleware/authorization"
+	"github.com/mihnea1711/POS_Project/services/gateway/internal/models"
+	"github.com/mihnea1711/POS_Project/services/gateway/pkg/utils"
+)
+
+// The sections of a patient export, in the order they appear in the bundle
+const (
+	exportSectionAccount         = "account"
+	exportSectionPatient         = "patient"
+	exportSectionClinicalProfile = "clinicalProfile"
+	exportSectionProfileHistory  = "clinicalProfileHistory"
+	exportSectionInsurance       = "insurancePolicies"
+	exportSectionAppointments    = "appointments"
+	exportSectionConsultations   = "consultations"
+	exportSectionPrescriptions   = "prescriptions"
+	exportSectionAttachments     = "attachments"
+	exportSectionMeasurements    = "measurements"
+)
+
+// CreatePatientExport starts assembling everything held about a patient into a downloadable bundle.
+// Patients may only export their own data.
+func (gc *GatewayController) CreatePatientExport(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to create a patient export.")
+
+	patientID, err := strconv.Atoi(mux.Vars(r)[utils.GET_PATIENT_ID_PARAMETER])
+	if err != nil {
+		log.Printf("[GATEWAY] Invalid patient ID: %v", err)
+		utils.SendErrorResponse(w, http.StatusBadRequest, "Invalid patient ID", err.Error())
+		return
+	}
+
+	// Create a context with a timeout (adjust the timeout as needed)
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_CONTEXT_TIMEOUT*time.Second)
+	defer cancel()
+
+	if !gc.authorizePatientExport(ctx, w, r, patientID) {
+		return
+	}
+
+	userID, err := claimsUserID(r)
+	if err != nil {
+		log.Printf("[GATEWAY] %v", err)
+		utils.SendErrorResponse(w, http.StatusUnauthorized, "Failed to identify the user", err.Error())
+		return
+	}
+
+	// The patient is fetched up front, so an unknown patient is refused instead of failing in the background
+	patientResponse, status, err := gc.redirectRequestBody(ctx, utils.GET, utils.PATIENT_HOST, fmt.Sprintf("%s/%d", utils.PATIENT_FETCH_PATIENT_BY_ID_ENDPOINT, patientID), utils.PATIENT_PORT, nil)
+	if err != nil {
+		log.Printf("[GATEWAY] Error redirecting patient request: %v", err)
+		utils.SendErrorResponse(w, http.StatusBadGateway, "Failed to redirect request", err.Error())
+		return
+	}
+	if status != http.StatusOK {
+		log.Printf("[GATEWAY] CreatePatientExport: Patient %d could not be fetched, status %d", patientID, status)
+		utils.SendErrorResponse(w, status, patientResponse.Message, patientResponse.Error)
+		return
+	}
+
+	var patient models.PatientData
+	if err := decodePayload(patientResponse.Payload, &patient); err != nil {
+		log.Printf("[GATEWAY] Error decoding patient %d: %v", patientID, err)
+		utils.SendErrorResponse(w, http.StatusInternalServerError, "Failed to decode patient", err.Error())
+		return
+	}
+
+	job, created, err := gc.Exports.Start(patientID, userID)
+	if err != nil {
+		log.Printf("[GATEWAY] Error starting export of patient %d: %v", patientID, err)
+		utils.SendErrorResponse(w, http.StatusInternalServerError, "Failed to start export", err.Error())
+		return
+	}
+
+	message := fmt.Sprintf("Export of patient %d started", patientID)
+	if created {
+		go gc.runPatientExport(job.ID, &patient, patientResponse.Payload)
+	} else {
+		message = fmt.Sprintf("An export of patient %d is already in progress", patientID)
+	}
+
+	log.Printf("[GATEWAY] %s: %s", message, job.ID)
+	w.Header().Set(utils.HEADER_LOCATION_KEY, patientExportURL(patientID, job.ID))
+	utils.SendMessageResponse(w, http.StatusAccepted, message, job)
+}
+
+// GetPatientExport returns the status of an export, with its download link once it is completed.
+func (gc *GatewayController) GetPatientExport(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to get a patient export.")
+
+	patientID, job, ok := gc.requestedPatientExport(w, r)
+	if !ok {
+		return
+	}
+
+	log.Printf("[GATEWAY] Export %s of patient %d is %s", job.ID, patientID, job.Status)
+	utils.SendMessageResponse(w, http.StatusOK, fmt.Sprintf("Export of patient %d is %s", patientID, job.Status), job)
+}
+
+// DownloadPatientExport serves the bundle of a completed export as a JSON attachment.
+func (gc *GatewayController) DownloadPatientExport(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to download a patient export.")
+
+	patientID, job, ok := gc.requestedPatientExport(w, r)
+	if !ok {
+		return
+	}
+	if job.Status != export.StatusCompleted {
+		log.Printf("[GATEWAY] Export %s of patient %d is %s, nothing to download", job.ID, patientID, job.Status)
+		utils.SendErrorResponse(w, http.StatusConflict, fmt.Sprintf("Export of patient %d is %s", patientID, job.Status), "The export has no bundle to download")
+		return
+	}
+
+	bundle, err := gc.Exports.Open(job.ID)
+	if err != nil {
+		log.Printf("[GATEWAY] Error opening export %s: %v", job.ID, err)
+		utils.SendErrorResponse(w, http.StatusNotFound, "Export not found", err.Error())
+		return
+	}
+	defer bundle.Close()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fmt.Sprintf("patient-%d-export-%s.json", patientID, job.ID)))
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, bundle); err != nil {
+		log.Printf("[GATEWAY] Error sending export %s: %v", job.ID, err)
+	}
+}
+
+// requestedPatientExport resolves the export named in the route, which must belong to the patient the user may access
+func (gc *GatewayController) requestedPatientExport(w http.ResponseWriter, r *http.Request) (int, *export.Job, bool) {
+	vars := mux.Vars(r)
+	patientID, err := strconv.Atoi(vars[utils.GET_PATIENT_ID_PARAMETER])
+	if err != nil {
+		log.Printf("[GATEWAY] Invalid patient ID: %v", err)
+		utils.SendErrorResponse(w, http.StatusBadRequest, "Invalid patient ID", err.Error())
+		return 0, nil, false
+	}
+
+	// Create a context with a timeout (adjust the timeout as needed)
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_CONTEXT_TIMEOUT*time.Second)
+	defer cancel()
+
+	if !gc.authorizePatientExport(ctx, w, r, patientID) {
+		return 0, nil, false
+	}
+
+	job, err := gc.Exports.Get(vars[utils.PATIENT_EXPORT_ID_PARAMETER])
+	if err != nil || job.PatientID != patientID {
+		log.Printf("[GATEWAY] Export %s of patient %d not found", vars[utils.PATIENT_EXPORT_ID_PARAMETER], patientID)
+		utils.SendErrorResponse(w, http.StatusNotFound, "Export not found", export.ErrJobNotFound.Error())
+		return 0, nil, false
+	}
+
+	return patientID, job, true
+}
+
+// authorizePatientExport lets admins export any patient and patients only themselves
+func (gc *GatewayController) authorizePatientExport(ctx context.Context, w http.ResponseWriter, r *http.Request, patientID int) bool {
+	claims := r.Context().Value(utils.JWT_CLAIMS_CONTEXT_KEY).(*authorization.MyCustomClaims)
+	if claims.Role == utils.ADMIN_ROLE {
+		return true
+	}
+
+	ownID, err := gc.fetchOwnProfileID(ctx, claims)
+	if err != nil {
+		log.Printf("[GATEWAY] Error resolving own profile: %v", err)
+		utils.SendErrorResponse(w, http.StatusUnauthorized, "Error resolving own profile", err.Error())
+		return false
+	}
+	if ownID != patientID {
+		log.Printf("[GATEWAY] User tried to access the export of patient %d", patientID)
+		utils.SendErrorResponse(w, http.StatusForbidden, "Access denied", "You can only export your own data")
+		return false
+	}
+
+	return true
+}
+
+// runPatientExport assembles the bundle of an export in the background
+func (gc *GatewayController) runPatientExport(jobID string, patient *models.PatientData, patientPayload interface{}) {
+	ctx, cancel := context.WithTimeout(context.Background(), utils.EXPORT_JOB_TIMEOUT*time.Second)
+	defer cancel()
+
+	log.Printf("[GATEWAY] Assembling export %s of patient %d", jobID, patient.IDPatient)
+
+	sections, err := gc.collectPatientData(ctx, patient, patientPayload)
+	if err == nil {
+		var bundle *export.Bundle
+		bundle, err = export.NewBundle(jobID, patient.IDPatient, sections)
+		if err == nil {
+			err = gc.Exports.Complete(jobID, bundle, patientExportURL(patient.IDPatient, jobID)+"/download")
+		}
+	}
+
+	if err != nil {
+		log.Printf("[GATEWAY] Export %s of patient %d failed: %v", jobID, patient.IDPatient, err)
+		gc.Exports.Fail(jobID, err)
+		return
+	}
+
+	log.Printf("[GATEWAY] Export %s of patient %d completed with %d sections", jobID, patient.IDPatient, len(sections))
+}
+
+// collectPatientData fetches everything held about a patient from the IDM and the patient, appointment and consultation modules
+func (gc *GatewayController) collectPatientData(ctx context.Context, patient *models.PatientData, patientPayload interface{}) ([]export.Section, error) {
+	account, err := gc.fetchExportAccount(ctx, patient.IDUser)
+	if err != nil {
+		return nil, fmt.Errorf("account: %w", err)
+	}
+
+	sections := []export.Section{
+		{Name: exportSectionAccount, Source: "idm", Records: 1, Data: account},
+		{Name: exportSectionPatient, Source: "patients", Records: 1, Data: patientPayload},
+	}
+
+	profile, err := gc.fetchExportResource(ctx, utils.PATIENT_HOST, utils.PATIENT_PORT, fmt.Sprintf("%s/%d/profile", utils.PATIENT_FETCH_PATIENT_BY_ID_ENDPOINT, patient.IDPatient))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", exportSectionClinicalProfile, err)
+	}
+	profileRecords := 0
+	if profile != nil {
+		profileRecords = 1
+	}
+	sections = append(sections, export.Section{Name: exportSectionClinicalProfile, Source: "patients", Records: profileRecords, Data: profile})
+
+	listed := []struct {
+		name, source, host string
+		port               int
+		endpoint           string
+		paginated          bool
+	}{
+		{exportSectionProfileHistory, "patients", utils.PATIENT_HOST, utils.PATIENT_PORT, fmt.Sprintf("%s/%d/profile/history", utils.PATIENT_FETCH_PATIENT_BY_ID_ENDPOINT, patient.IDPatient), true},
+		{exportSectionInsurance, "patients", utils.PATIENT_HOST, utils.PATIENT_PORT, fmt.Sprintf("%s/%d/insurance", utils.PATIENT_FETCH_PATIENT_BY_ID_ENDPOINT, patient.IDPatient), false},
+		{exportSectionAppointments, "appointments", utils.APPOINTMENT_HOST, utils.APPOINTMENT_PORT, fmt.Sprintf("%s?%s=%d", utils.APPOINTMENT_FETCH_ALL_APPOINTMENTS_ENDPOINT, utils.QUERY_ID_PATIENT, patient.IDPatient), true},
+		{exportSectionConsultations, "consultations", utils.CONSULTATION_HOST, utils.CONSULTATION_PORT, fmt.Sprintf("%s?%s=%d", utils.CONSULTATION_FETCH_ALL_CONSULTATII_ENDPOINT, utils.QUERY_ID_PATIENT, patient.IDPatient), true},
+		// Canceled and dispensed prescriptions are exported too, with their status
+		{exportSectionPrescriptions, "consultations", utils.CONSULTATION_HOST, utils.CONSULTATION_PORT, fmt.Sprintf("%s?%s=%d", utils.CONSULTATION_PATIENT_PRESCRIPTIONS_ENDPOINT, utils.QUERY_ID_PATIENT, patient.IDPatient), true},
+		// Only the metadata of the attachments is exported, the files are downloaded one by one
+		{exportSectionAttachments, "consultations", utils.CONSULTATION_HOST, utils.CONSULTATION_PORT, fmt.Sprintf("%s?%s=%d", utils.CONSULTATION_ATTACHMENTS_ENDPOINT, utils.QUERY_ID_PATIENT, patient.IDPatient), true},
+		// The measurements entered in error are exported too, with their status
+		{exportSectionMeasurements, "consultations", utils.CONSULTATION_HOST, utils.CONSULTATION_PORT, fmt.Sprintf("%s?%s=%d&%s=%s", utils.CONSULTATION_MEASUREMENTS_ENDPOINT, utils.QUERY_ID_PATIENT, patient.IDPatient, utils.QUERY_STATUS, utils.MEASUREMENT_STATUS_ANY), true},
+	}
+	for _, list := range listed {
+		var records []interface{}
+		if list.paginated {
+			records, err = gc.fetchAllPages(ctx, list.host, list.port, list.endpoint)
+		} else {
+			records, err = gc.fetchModuleList(ctx, list.host, list.port, list.endpoint)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", list.name, err)
+		}
+		sections = append(sections, export.Section{Name: list.name, Source: list.source, Records: len(records), Data: records})
+	}
+
+	return sections, nil
+}
+
+// fetchExportAccount reads the IDM account of the patient, without its credentials
+func (gc *GatewayController) fetchExportAccount(ctx context.Context, userID int) (map[string]interface{}, error) {
+	request := &proto_files.UserIDRequest{UserID: &proto_files.UserID{ID: int64(userID)}}
+
+	userResponse, err := gc.IDMClient.GetUserByID(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	if userResponse.Info == nil || userResponse.Info.Status != http.StatusOK || userResponse.User == nil {
+		return nil, fmt.Errorf("user %d could not be fetched from the IDM", userID)
+	}
+
+	account := map[string]interface{}{
+		"idUser":   userID,
+		"username": userResponse.User.Username,
+	}
+
+	roleResponse, err := gc.IDMClient.GetUserRole(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	if roleResponse.Info != nil && roleResponse.Info.Status == http.StatusOK {
+		account["role"] = roleResponse.Role
+	}
+
+	return account, nil
+}
+
+// fetchExportResource reads a single resource, nil when the module does not hold it
+func (gc *GatewayController) fetchExportResource(ctx context.Context, host string, port int, endpoint string) (interface{}, error) {
+	result, status, err := gc.redirectRequestBody(ctx, utils.GET, host, endpoint, port, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	switch status {
+	case http.StatusOK:
+		return result.Payload, nil
+	case http.StatusNotFound:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("module responded with status %d: %s", status, result.Error)
+	}
+}
+
+func (gc *GatewayController) fetchModuleList(ctx context.Context, host string, port int, endpoint string) ([]interface{}, error) {
+	result, status, err := gc.redirectRequestBody(ctx, utils.GET, host, endpoint, port, nil)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("module responded with status %d: %s", status, result.Error)
+	}
+
+	var records []interface{}
+	if err := decodePayload(result.Payload, &records); err != nil {
+		return nil, err
+	}
+	if records == nil {
+		// Some modules list nothing as null
+		records = []interface{}{}
+	}
+	return records, nil
+}
+
+// fetchAllPages walks every page of a paginated listing
+func (gc *GatewayController) fetchAllPages(ctx context.Context, host string, port int, endpoint string) ([]interface{}, error) {
+	separator := "?"
+	if strings.Contains(endpoint, "?") {
+		separator = "&"
+	}
+
+	records := []interface{}{}
+	for page := utils.DEFAULT_PAGINATION_PAGE; ; page++ {
+		pageEndpoint := fmt.Sprintf("%s%s%s=%d&%s=%d", endpoint, separator, utils.QUERY_PAGE, page, utils.QUERY_LIMIT, utils.MAX_PAGINATION_LIMIT)
+		pageRecords, err := gc.fetchModuleList(ctx, host, port, pageEndpoint)
+		if err != nil {
+			return nil, err
+		}
+
+		records = append(records, pageRecords...)
+		if len(pageRecords) < utils.MAX_PAGINATION_LIMIT {
+			return records, nil
+		}
+	}
+}
+
+func patientExportURL(patientID int, exportID string) string {
+	url := strings.Replace(utils.GET_PATIENT_EXPORT_ENDPOINT, "{"+utils.GET_PATIENT_ID_PARAMETER+"}", strconv.Itoa(patientID), 1)
+	return strings.Replace(url, "{"+utils.PATIENT_EXPORT_ID_PARAMETER+"}", exportID, 1)
+}
+
@@ -0,0 +1,685 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mihnea1711/POS_Project/services/gateway/internal/fhir"
+	"github.com/mihnea1711/POS_Project/services/gateway/internal/models"
+	"github.com/mihnea1711/POS_Project/services/gateway/pkg/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// fhirError is answered with an OperationOutcome carrying the issue code
+type fhirError struct {
+	status int
+	code   string
+	msg    string
+}
+
+func (e *fhirError) Error() string {
+	return e.msg
+}
+
+func newFHIRError(status int, code, format string, args ...interface{}) error {
+	return &fhirError{status: status, code: code, msg: fmt.Sprintf(format, args...)}
+}
+
+// GetFHIRMetadata returns the CapabilityStatement of the FHIR facade
+func (gc *GatewayController) GetFHIRMetadata(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to fetch the FHIR capability statement.")
+	writeFHIR(w, http.StatusOK, fhir.CapabilityStatement(gc.FHIRConfig.BaseURL))
+}
+
+// ReadFHIRResource maps a single patient, doctor, appointment or consultation to the requested FHIR resource
+func (gc *GatewayController) ReadFHIRResource(w http.ResponseWriter, r *http.Request) {
+	resourceType := mux.Vars(r)[utils.FHIR_RESOURCE_TYPE_PARAMETER]
+	resourceID := mux.Vars(r)[utils.FHIR_RESOURCE_ID_PARAMETER]
+	log.Printf("[GATEWAY] Attempting to read FHIR resource %s/%s.", resourceType, resourceID)
+
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_CONTEXT_TIMEOUT*time.Second)
+	defer cancel()
+
+	resource, err := gc.newFHIRReader().read(ctx, resourceType, resourceID)
+	if err != nil {
+		writeFHIRError(w, err)
+		return
+	}
+
+	log.Printf("[GATEWAY] FHIR resource %s/%s read successfully.", resourceType, resourceID)
+	writeFHIR(w, http.StatusOK, resource)
+}
+
+// SearchFHIRResources answers a search with a searchset Bundle. Paging is done by the modules, _include follows
+// the references of the matches with one read per referenced resource.
+func (gc *GatewayController) SearchFHIRResources(w http.ResponseWriter, r *http.Request) {
+	resourceType := mux.Vars(r)[utils.FHIR_RESOURCE_TYPE_PARAMETER]
+	log.Printf("[GATEWAY] Attempting to search FHIR %s resources.", resourceType)
+
+	if _, ok := fhir.SearchParameters[resourceType]; !ok {
+		writeFHIRError(w, newFHIRError(http.StatusNotFound, fhir.IssueNotSupported, "Resource type %s is not supported", resourceType))
+		return
+	}
+
+	query := r.URL.Query()
+	for name := range query {
+		if !fhir.IsSearchParameter(resourceType, name) {
+			writeFHIRError(w, newFHIRError(http.StatusBadRequest, fhir.IssueNotSupported, "Search parameter %s is not supported for %s", name, resourceType))
+			return
+		}
+	}
+
+	count, page, err := fhirPaging(query)
+	if err != nil {
+		writeFHIRError(w, err)
+		return
+	}
+
+	var includeTypes []string
+	for _, include := range query[fhir.ParamInclude] {
+		types, err := fhir.ParseInclude(resourceType, include)
+		if err != nil {
+			writeFHIRError(w, newFHIRError(http.StatusBadRequest, fhir.IssueNotSupported, "%v", err))
+			return
+		}
+		includeTypes = append(includeTypes, types...)
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), utils.LONG_REQUEST_CONTEXT_TIMEOUT*time.Second)
+	defer cancel()
+
+	reader := gc.newFHIRReader()
+	set := fhir.NewSearchSet(gc.FHIRConfig.BaseURL)
+
+	var records int
+	switch resourceType {
+	case fhir.ResourcePatient:
+		records, err = reader.searchPatients(ctx, query, count, page, set)
+	case fhir.ResourcePractitioner:
+		records, err = reader.searchPractitioners(ctx, query, count, page, set)
+	case fhir.ResourceAppointment:
+		records, err = reader.searchAppointments(ctx, query, count, page, set)
+	default:
+		records, err = reader.searchConsultations(ctx, resourceType, query, count, page, set)
+	}
+	if err != nil {
+		writeFHIRError(w, err)
+		return
+	}
+
+	if err := reader.include(ctx, set, includeTypes); err != nil {
+		writeFHIRError(w, err)
+		return
+	}
+
+	set.Link("self", fhirSearchURL(gc.FHIRConfig.BaseURL, resourceType, query, page))
+	// A full page means the module may hold more records
+	if records == count {
+		set.Link("next", fhirSearchURL(gc.FHIRConfig.BaseURL, resourceType, query, page+1))
+	}
+
+	log.Printf("[GATEWAY] FHIR %s search returned %d matches.", resourceType, len(set.Matches()))
+	writeFHIR(w, http.StatusOK, set.Bundle())
+}
+
+// fhirReader fetches the records behind the resources of a single FHIR request. Consultations are kept,
+// as the encounter, observations and diagnostic report of a consultation are all mapped from the same record.
+type fhirReader struct {
+	gc            *GatewayController
+	consultations map[string]models.ConsultationData
+}
+
+func (gc *GatewayController) newFHIRReader() *fhirReader {
+	return &fhirReader{
+		gc:            gc,
+		consultations: make(map[string]models.ConsultationData),
+	}
+}
+
+// fetch decodes the payload of a module response into target. A module answering 404 reports a missing resource,
+// a 400 an invalid search, anything else a failure of the gateway.
+func (fr *fhirReader) fetch(ctx context.Context, host string, port int, endpoint string, target interface{}) error {
+	result, status, err := fr.gc.redirectRequestBody(ctx, utils.GET, host, endpoint, port, nil)
+	if err != nil {
+		return fmt.Errorf("failed to redirect request to %s: %v", endpoint, err)
+	}
+
+	switch status {
+	case http.StatusOK:
+		return decodePayload(result.Payload, target)
+	case http.StatusNotFound:
+		return newFHIRError(http.StatusNotFound, fhir.IssueNotFound, "%s", result.Message)
+	case http.StatusBadRequest:
+		return newFHIRError(http.StatusBadRequest, fhir.IssueInvalid, "%s: %s", result.Message, result.Error)
+	default:
+		return fmt.Errorf("%s responded with status %d: %s", endpoint, status, result.Error)
+	}
+}
+
+func (fr *fhirReader) read(ctx context.Context, resourceType, id string) (fhir.Resource, error) {
+	notFound := newFHIRError(http.StatusNotFound, fhir.IssueNotFound, "Resource %s/%s not found", resourceType, id)
+
+	switch resourceType {
+	case fhir.ResourcePatient:
+		patientID, ok := fhirNumericID(id)
+		if !ok {
+			return nil, notFound
+		}
+		var patient models.PatientData
+		if err := fr.fetch(ctx, utils.PATIENT_HOST, utils.PATIENT_PORT, fmt.Sprintf("%s/%d", utils.PATIENT_FETCH_PATIENT_BY_ID_ENDPOINT, patientID), &patient); err != nil {
+			return nil, err
+		}
+		return fhir.FromPatient(patient), nil
+	case fhir.ResourcePractitioner:
+		doctorID, ok := fhirNumericID(id)
+		if !ok {
+			return nil, notFound
+		}
+		var doctor models.DoctorData
+		if err := fr.fetch(ctx, utils.DOCTOR_HOST, utils.DOCTOR_PORT, fmt.Sprintf("%s/%d", utils.DOCTOR_FETCH_DOCTOR_BY_ID_ENDPOINT, doctorID), &doctor); err != nil {
+			return nil, err
+		}
+		return fhir.FromDoctor(doctor), nil
+	case fhir.ResourceAppointment:
+		appointmentID, ok := fhirNumericID(id)
+		if !ok {
+			return nil, notFound
+		}
+		var appointment models.AppointmentData
+		if err := fr.fetch(ctx, utils.APPOINTMENT_HOST, utils.APPOINTMENT_PORT, fmt.Sprintf("%s/%d", utils.APPOINTMENT_FETCH_APPOINTMENT_BY_ID_ENDPOINT, appointmentID), &appointment); err != nil {
+			return nil, err
+		}
+		return fhir.FromAppointment(appointment), nil
+	case fhir.ResourceEncounter, fhir.ResourceDiagnosticReport, fhir.ResourceObservation:
+		consultationID, position := id, -1
+		if resourceType == fhir.ResourceObservation {
+			var ok bool
+			if consultationID, position, ok = splitObservationID(id); !ok {
+				return nil, notFound
+			}
+		}
+
+		consultation, err := fr.consultation(ctx, consultationID)
+		if err != nil {
+			return nil, err
+		}
+
+		encounter, observations, report := fhir.FromConsultation(consultation)
+		switch resourceType {
+		case fhir.ResourceEncounter:
+			return encounter, nil
+		case fhir.ResourceDiagnosticReport:
+			return report, nil
+		}
+		if position >= len(observations) {
+			return nil, notFound
+		}
+		return observations[position], nil
+	default:
+		return nil, newFHIRError(http.StatusNotFound, fhir.IssueNotSupported, "Resource type %s is not supported", resourceType)
+	}
+}
+
+func (fr *fhirReader) consultation(ctx context.Context, id string) (models.ConsultationData, error) {
+	if consultation, ok := fr.consultations[id]; ok {
+		return consultation, nil
+	}
+	if _, err := primitive.ObjectIDFromHex(id); err != nil {
+		return models.ConsultationData{}, newFHIRError(http.StatusNotFound, fhir.IssueNotFound, "Consultation %s not found", id)
+	}
+
+	var consultation models.ConsultationData
+	if err := fr.fetch(ctx, utils.CONSULTATION_HOST, utils.CONSULTATION_PORT, fmt.Sprintf("%s/%s", utils.CONSULTATION_FETCH_CONSULTATIE_BY_ID_ENDPOINT, id), &consultation); err != nil {
+		return models.ConsultationData{}, err
+	}
+	fr.consultations[id] = consultation
+	return consultation, nil
+}
+
+// searchByID answers an _id search, a missing resource is an empty result rather than an error
+func (fr *fhirReader) searchByID(ctx context.Context, resourceType, id string, set *fhir.SearchSet) (int, error) {
+	resource, err := fr.read(ctx, resourceType, id)
+	if isFHIRNotFound(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	set.AddMatch(resource)
+	return 1, nil
+}
+
+// searchPatients uses the patient search when there is a criterion, the listing filters on activity only
+func (fr *fhirReader) searchPatients(ctx context.Context, query url.Values, count, page int, set *fhir.SearchSet) (int, error) {
+	if id := query.Get(fhir.ParamID); id != "" {
+		return fr.searchByID(ctx, fhir.ResourcePatient, id, set)
+	}
+
+	params := url.Values{}
+	var names []string
+	for _, name := range []string{"name", "family", "given"} {
+		if value := query.Get(name); value != "" {
+			names = append(names, value)
+		}
+	}
+	if len(names) > 0 {
+		params.Set(utils.QUERY_NAME, strings.Join(names, " "))
+	}
+
+	if identifier := query.Get("identifier"); identifier != "" {
+		system, value := "", identifier
+		if i := strings.LastIndex(identifier, "|"); i >= 0 {
+			system, value = identifier[:i], identifier[i+1:]
+		}
+		// Patients are only identified by their CNP
+		if system != "" && system != fhir.CNPSystem {
+			return 0, nil
+		}
+		params.Set(utils.QUERY_CNP, value)
+	}
+	if email := query.Get("email"); email != "" {
+		params.Set(utils.QUERY_EMAIL, email)
+	}
+	if phone := query.Get("phone"); phone != "" {
+		params.Set(utils.QUERY_PHONE, phone)
+	}
+
+	if birthDates := query["birthdate"]; len(birthDates) > 0 {
+		from, to, err := fhir.DateRangeParam("birthdate", birthDates)
+		if err != nil {
+			return 0, newFHIRError(http.StatusBadRequest, fhir.IssueInvalid, "%v", err)
+		}
+		if from != nil {
+			params.Set(utils.QUERY_BIRTH_FROM, from.Format(utils.TIME_PARSE))
+		}
+		if to != nil {
+			params.Set(utils.QUERY_BIRTH_TO, to.Format(utils.TIME_PARSE))
+		}
+	}
+
+	endpoint := utils.PATIENT_SEARCH_PATIENTS_ENDPOINT
+	if len(params) == 0 {
+		endpoint = utils.PATIENT_FETCH_ALL_PATIENTS_ENDPOINT
+	}
+
+	if err := fhirActiveParam(query, params); err != nil {
+		return 0, err
+	}
+	fhirPageParams(params, count, page)
+
+	var patients []models.PatientData
+	if err := fr.fetch(ctx, utils.PATIENT_HOST, utils.PATIENT_PORT, endpoint+"?"+params.Encode(), &patients); err != nil {
+		return 0, err
+	}
+
+	for _, patient := range patients {
+		set.AddMatch(fhir.FromPatient(patient))
+	}
+	return len(patients), nil
+}
+
+// searchPractitioners filters doctors on the first name, which is the family name of the practitioner
+func (fr *fhirReader) searchPractitioners(ctx context.Context, query url.Values, count, page int, set *fhir.SearchSet) (int, error) {
+	if id := query.Get(fhir.ParamID); id != "" {
+		return fr.searchByID(ctx, fhir.ResourcePractitioner, id, set)
+	}
+
+	params := url.Values{}
+	if err := fhirActiveParam(query, params); err != nil {
+		return 0, err
+	}
+
+	// Emails are unique, so the doctor is read directly
+	if email := query.Get("email"); email != "" {
+		var doctor models.DoctorData
+		err := fr.fetch(ctx, utils.DOCTOR_HOST, utils.DOCTOR_PORT, fmt.Sprintf("%s/%s", utils.DOCTOR_FETCH_DOCTOR_BY_EMAIL_ENDPOINT, url.PathEscape(email)), &doctor)
+		if isFHIRNotFound(err) {
+			return 0, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		if active := params.Get(utils.QUERY_IS_ACTIVE); active != "" && active != strconv.FormatBool(doctor.IsActive) {
+			return 0, nil
+		}
+		set.AddMatch(fhir.FromDoctor(doctor))
+		return 1, nil
+	}
+
+	if name := query.Get("name"); name != "" {
+		params.Set(utils.QUERY_FIRST_NAME, name)
+	}
+	if family := query.Get("family"); family != "" {
+		params.Set(utils.QUERY_FIRST_NAME, family)
+	}
+	fhirPageParams(params, count, page)
+
+	var doctors []models.DoctorData
+	if err := fr.fetch(ctx, utils.DOCTOR_HOST, utils.DOCTOR_PORT, utils.DOCTOR_FETCH_ALL_DOCTORS_ENDPOINT+"?"+params.Encode(), &doctors); err != nil {
+		return 0, err
+	}
+
+	for _, doctor := range doctors {
+		set.AddMatch(fhir.FromDoctor(doctor))
+	}
+	return len(doctors), nil
+}
+
+func (fr *fhirReader) searchAppointments(ctx context.Context, query url.Values, count, page int, set *fhir.SearchSet) (int, error) {
+	if id := query.Get(fhir.ParamID); id != "" {
+		return fr.searchByID(ctx, fhir.ResourceAppointment, id, set)
+	}
+
+	params := url.Values{}
+	if err := fhirParticipantParams(query, params, []string{"patient"}, []string{"practitioner", "actor"}); err != nil {
+		return 0, err
+	}
+	if err := fhirDateParam(query, params); err != nil {
+		return 0, err
+	}
+
+	if status := query.Get("status"); status != "" {
+		appointmentStatus, ok := fhir.AppointmentStatusFromFHIR(status)
+		// Appointments never hold the other FHIR statuses
+		if !ok {
+			return 0, nil
+		}
+		params.Set(utils.QUERY_STATUS, appointmentStatus)
+	}
+	fhirPageParams(params, count, page)
+
+	var appointments []models.AppointmentData
+	if err := fr.fetch(ctx, utils.APPOINTMENT_HOST, utils.APPOINTMENT_PORT, utils.APPOINTMENT_FETCH_ALL_APPOINTMENTS_ENDPOINT+"?"+params.Encode(), &appointments); err != nil {
+		return 0, err
+	}
+
+	for _, appointment := range appointments {
+		set.AddMatch(fhir.FromAppointment(appointment))
+	}
+	return len(appointments), nil
+}
+
+// searchConsultations answers the searches of the resources mapped from consultations. Observations are
+// flattened from the consultations of the page, so the page counts consultations rather than observations.
+func (fr *fhirReader) searchConsultations(ctx context.Context, resourceType string, query url.Values, count, page int, set *fhir.SearchSet) (int, error) {
+	params := url.Values{}
+	if err := fhirParticipantParams(query, params, []string{"patient", "subject"}, []string{"practitioner", "participant", "performer"}); err != nil {
+		return 0, err
+	}
+	if err := fhirDateParam(query, params); err != nil {
+		return 0, err
+	}
+
+	consultationID, position := query.Get("encounter"), -1
+	if consultationID != "" {
+		_, id, err := fhir.ReferenceParam("encounter", consultationID, fhir.ResourceEncounter)
+		if err != nil {
+			return 0, newFHIRError(http.StatusBadRequest, fhir.IssueInvalid, "%v", err)
+		}
+		consultationID = id
+	}
+	if id := query.Get(fhir.ParamID); id != "" {
+		if resourceType == fhir.ResourceObservation {
+			var ok bool
+			if id, position, ok = splitObservationID(id); !ok {
+				return 0, nil
+			}
+		}
+		// _id and encounter name different consultations
+		if consultationID != "" && consultationID != id {
+			return 0, nil
+		}
+		consultationID = id
+	}
+
+	// A single consultation is read and the other criteria are checked here
+	if consultationID != "" {
+		consultation, err := fr.consultation(ctx, consultationID)
+		if isFHIRNotFound(err) {
+			return 0, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		if !consultationMatches(consultation, params) {
+			return 0, nil
+		}
+		fr.addConsultation(set, resourceType, consultation, position)
+		return 0, nil
+	}
+
+	fhirPageParams(params, count, page)
+
+	var consultations []models.ConsultationData
+	if err := fr.fetch(ctx, utils.CONSULTATION_HOST, utils.CONSULTATION_PORT, utils.CONSULTATION_FETCH_ALL_CONSULTATII_ENDPOINT+"?"+params.Encode(), &consultations); err != nil {
+		return 0, err
+	}
+
+	for _, consultation := range consultations {
+		fr.consultations[consultation.IDConsultation.Hex()] = consultation
+		fr.addConsultation(set, resourceType, consultation, -1)
+	}
+	return len(consultations), nil
+}
+
+// addConsultation adds the resources of the requested type mapped from the consultation, a single observation
+// when its position is known
+func (fr *fhirReader) addConsultation(set *fhir.SearchSet, resourceType string, consultation models.ConsultationData, position int) {
+	encounter, observations, report := fhir.FromConsultation(consultation)
+	switch resourceType {
+	case fhir.ResourceEncounter:
+		set.AddMatch(encounter)
+	case fhir.ResourceDiagnosticReport:
+		set.AddMatch(report)
+	case fhir.ResourceObservation:
+		for i, observation := range observations {
+			if position < 0 || position == i {
+				set.AddMatch(observation)
+			}
+		}
+	}
+}
+
+// include adds the resources of the given types referenced by the matches. References to resources that
+// no longer exist are skipped, the matches are still returned.
+func (fr *fhirReader) include(ctx context.Context, set *fhir.SearchSet, includeTypes []string) error {
+	for _, match := range set.Matches() {
+		for _, includeType := range includeTypes {
+			for _, reference := range fhir.IncludedReferences(match, includeType) {
+				resourceType, id, _ := fhir.SplitReference(reference.Reference)
+				if set.Has(resourceType, id) {
+					continue
+				}
+
+				resource, err := fr.read(ctx, resourceType, id)
+				if isFHIRNotFound(err) {
+					log.Printf("[GATEWAY] Included FHIR resource %s not found, skipping it.", reference.Reference)
+					continue
+				}
+				if err != nil {
+					return err
+				}
+				set.AddInclude(resource)
+			}
+		}
+	}
+	return nil
+}
+
+// consultationMatches checks the participant and date criteria the modules would otherwise filter on
+func consultationMatches(consultation models.ConsultationData, params url.Values) bool {
+	if patientID := params.Get(utils.QUERY_ID_PATIENT); patientID != "" && patientID != strconv.Itoa(consultation.IDPatient) {
+		return false
+	}
+	if doctorID := params.Get(utils.QUERY_ID_DOCTOR); doctorID != "" && doctorID != strconv.Itoa(consultation.IDDoctor) {
+		return false
+	}
+	if date := params.Get(utils.QUERY_DATE); date != "" && date != consultation.Date.UTC().Format(utils.TIME_PARSE) {
+		return false
+	}
+	return true
+}
+
+// fhirParticipantParams maps the reference parameters naming a patient or a practitioner to the module filters.
+// A bare ID is read as the first type the parameter accepts.
+func fhirParticipantParams(query url.Values, params url.Values, patientParams, practitionerParams []string) error {
+	names := append(append([]string{}, patientParams...), practitionerParams...)
+	for i, name := range names {
+		value := query.Get(name)
+		if value == "" {
+			continue
+		}
+
+		expected := []string{fhir.ResourcePatient}
+		if i >= len(patientParams) {
+			expected = []string{fhir.ResourcePractitioner}
+			if name == "actor" {
+				expected = append(expected, fhir.ResourcePatient)
+			}
+		}
+
+		resourceType, id, err := fhir.ReferenceParam(name, value, expected...)
+		if err != nil {
+			return newFHIRError(http.StatusBadRequest, fhir.IssueInvalid, "%v", err)
+		}
+		if _, ok := fhirNumericID(id); !ok {
+			return newFHIRError(http.StatusBadRequest, fhir.IssueInvalid, "%s must reference a numeric ID", name)
+		}
+
+		if resourceType == fhir.ResourcePatient {
+			params.Set(utils.QUERY_ID_PATIENT, id)
+		} else {
+			params.Set(utils.QUERY_ID_DOCTOR, id)
+		}
+	}
+	return nil
+}
+
+func fhirDateParam(query url.Values, params url.Values) error {
+	value := query.Get("date")
+	if value == "" {
+		return nil
+	}
+
+	day, err := fhir.DateParam("date", value)
+	if err != nil {
+		return newFHIRError(http.StatusBadRequest, fhir.IssueInvalid, "%v", err)
+	}
+	params.Set(utils.QUERY_DATE, day.Format(utils.TIME_PARSE))
+	return nil
+}
+
+func fhirActiveParam(query url.Values, params url.Values) error {
+	value := query.Get("active")
+	if value == "" {
+		return nil
+	}
+
+	active, err := strconv.ParseBool(value)
+	if err != nil {
+		return newFHIRError(http.StatusBadRequest, fhir.IssueInvalid, "active must be true or false")
+	}
+	params.Set(utils.QUERY_IS_ACTIVE, strconv.FormatBool(active))
+	return nil
+}
+
+func fhirPageParams(params url.Values, count, page int) {
+	params.Set(utils.QUERY_LIMIT, strconv.Itoa(count))
+	params.Set(utils.QUERY_PAGE, strconv.Itoa(page))
+}
+
+// fhirPaging reads _count and _page, the count is capped at the pagination limit of the modules
+func fhirPaging(query url.Values) (int, int, error) {
+	count, page := utils.DEFAULT_PAGINATION_LIMIT, utils.DEFAULT_PAGINATION_PAGE
+
+	if value := query.Get(fhir.ParamCount); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			return 0, 0, newFHIRError(http.StatusBadRequest, fhir.IssueInvalid, "_count must be a positive number")
+		}
+		count = parsed
+		if count > utils.MAX_PAGINATION_LIMIT {
+			count = utils.MAX_PAGINATION_LIMIT
+		}
+	}
+
+	if value := query.Get(fhir.ParamPage); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			return 0, 0, newFHIRError(http.StatusBadRequest, fhir.IssueInvalid, "_page must be a positive number")
+		}
+		page = parsed
+	}
+
+	return count, page, nil
+}
+
+func fhirSearchURL(baseURL, resourceType string, query url.Values, page int) string {
+	params := url.Values{}
+	for name, values := range query {
+		params[name] = values
+	}
+	params.Set(fhir.ParamPage, strconv.Itoa(page))
+	return fmt.Sprintf("%s/%s?%s", baseURL, resourceType, params.Encode())
+}
+
+func fhirNumericID(id string) (int, bool) {
+	value, err := strconv.Atoi(id)
+	if err != nil || value < 1 {
+		return 0, false
+	}
+	return value, true
+}
+
+// splitObservationID splits an observation ID into the consultation ID and the investigation position
+func splitObservationID(id string) (string, int, bool) {
+	i := strings.LastIndex(id, "-")
+	if i < 0 {
+		return "", 0, false
+	}
+
+	position, err := strconv.Atoi(id[i+1:])
+	if err != nil || position < 0 {
+		return "", 0, false
+	}
+	return id[:i], position, true
+}
+
+func isFHIRNotFound(err error) bool {
+	var fe *fhirError
+	return errors.As(err, &fe) && fe.status == http.StatusNotFound
+}
+
+func writeFHIRError(w http.ResponseWriter, err error) {
+	var fe *fhirError
+	if !errors.As(err, &fe) {
+		fe = &fhirError{status: http.StatusBadGateway, code: fhir.IssueException, msg: err.Error()}
+	}
+
+	log.Printf("[GATEWAY] FHIR request failed with status %d: %s", fe.status, fe.msg)
+	writeFHIR(w, fe.status, fhir.NewOperationOutcome(fe.code, fe.msg))
+}
+
+func writeFHIR(w http.ResponseWriter, status int, body interface{}) {
+	response, err := json.Marshal(body)
+	if err != nil {
+		log.Printf("[GATEWAY] Error marshaling FHIR response: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", utils.FHIR_CONTENT_TYPE)
+	w.WriteHeader(status)
+	if _, err := w.Write(response); err != nil {
+		log.Printf("[GATEWAY] Error writing FHIR response: %v", err)
+	}
+}
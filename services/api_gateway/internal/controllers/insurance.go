@@ -0,0 +1,234 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mihnea1711/POS_Project/services/gateway/internal/models"
+	"github.com/mihnea1711/POS_Project/services/gateway/pkg/utils"
+)
+
+// CreateInsurancePolicy handles adding an insurance policy to a patient, the patient module verifies it with the payer.
+func (gc *GatewayController) CreateInsurancePolicy(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to create an insurance policy.")
+
+	patientID, err := strconv.Atoi(mux.Vars(r)[utils.GET_PATIENT_ID_PARAMETER])
+	if err != nil {
+		log.Printf("[GATEWAY] Invalid patient ID: %v", err)
+		utils.SendErrorResponse(w, http.StatusBadRequest, "Invalid patient ID", err.Error())
+		return
+	}
+
+	// Take policy data from the context after validation
+	policyRequest := r.Context().Value(utils.DECODED_INSURANCE_POLICY_DATA).(*models.InsurancePolicyData)
+
+	// Create a context with a timeout (adjust the timeout as needed)
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_CONTEXT_TIMEOUT*time.Second)
+	defer cancel()
+
+	targetURL := fmt.Sprintf("%s/%d/insurance", utils.PATIENT_FETCH_PATIENT_BY_ID_ENDPOINT, patientID)
+	gc.forwardInsuranceRequest(ctx, w, utils.POST, targetURL, policyRequest, "CreateInsurancePolicy")
+}
+
+// UpdateInsurancePolicy handles replacing the details of an insurance policy, which is verified again.
+func (gc *GatewayController) UpdateInsurancePolicy(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to update an insurance policy.")
+
+	patientID, policyID, ok := insurancePolicyIDs(w, r)
+	if !ok {
+		return
+	}
+
+	// Take policy data from the context after validation
+	policyRequest := r.Context().Value(utils.DECODED_INSURANCE_POLICY_DATA).(*models.InsurancePolicyData)
+
+	// Create a context with a timeout (adjust the timeout as needed)
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_CONTEXT_TIMEOUT*time.Second)
+	defer cancel()
+
+	targetURL := fmt.Sprintf("%s/%d/insurance/%d", utils.PATIENT_FETCH_PATIENT_BY_ID_ENDPOINT, patientID, policyID)
+	gc.forwardInsuranceRequest(ctx, w, utils.PUT, targetURL, policyRequest, "UpdateInsurancePolicy")
+}
+
+// VerifyInsurancePolicy handles asking the payer about a saved insurance policy again.
+func (gc *GatewayController) VerifyInsurancePolicy(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to verify an insurance policy.")
+
+	patientID, policyID, ok := insurancePolicyIDs(w, r)
+	if !ok {
+		return
+	}
+
+	// Create a context with a timeout (adjust the timeout as needed)
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_CONTEXT_TIMEOUT*time.Second)
+	defer cancel()
+
+	targetURL := fmt.Sprintf("%s/%d/insurance/%d/verify", utils.PATIENT_FETCH_PATIENT_BY_ID_ENDPOINT, patientID, policyID)
+	gc.forwardInsuranceRequest(ctx, w, utils.POST, targetURL, nil, "VerifyInsurancePolicy")
+}
+
+// GetInsurancePolicies handles the retrieval of the insurance policies of a patient.
+func (gc *GatewayController) GetInsurancePolicies(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to get the insurance policies of a patient.")
+
+	patientID, err := strconv.Atoi(mux.Vars(r)[utils.GET_PATIENT_ID_PARAMETER])
+	if err != nil {
+		log.Printf("[GATEWAY] Invalid patient ID: %v", err)
+		utils.SendErrorResponse(w, http.StatusBadRequest, "Invalid patient ID", err.Error())
+		return
+	}
+
+	// Create a context with a timeout (adjust the timeout as needed)
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_CONTEXT_TIMEOUT*time.Second)
+	defer cancel()
+
+	targetURL := fmt.Sprintf("%s/%d/insurance", utils.PATIENT_FETCH_PATIENT_BY_ID_ENDPOINT, patientID)
+	gc.forwardInsuranceRequest(ctx, w, utils.GET, targetURL, nil, "GetInsurancePolicies")
+}
+
+// GetInsurancePolicyByID handles the retrieval of an insurance policy of a patient.
+func (gc *GatewayController) GetInsurancePolicyByID(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to get an insurance policy.")
+
+	patientID, policyID, ok := insurancePolicyIDs(w, r)
+	if !ok {
+		return
+	}
+
+	// Create a context with a timeout (adjust the timeout as needed)
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_CONTEXT_TIMEOUT*time.Second)
+	defer cancel()
+
+	targetURL := fmt.Sprintf("%s/%d/insurance/%d", utils.PATIENT_FETCH_PATIENT_BY_ID_ENDPOINT, patientID, policyID)
+	gc.forwardInsuranceRequest(ctx, w, utils.GET, targetURL, nil, "GetInsurancePolicyByID")
+}
+
+// DeleteInsurancePolicy handles the deletion of an insurance policy of a patient.
+func (gc *GatewayController) DeleteInsurancePolicy(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to delete an insurance policy.")
+
+	patientID, policyID, ok := insurancePolicyIDs(w, r)
+	if !ok {
+		return
+	}
+
+	// Create a context with a timeout (adjust the timeout as needed)
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_CONTEXT_TIMEOUT*time.Second)
+	defer cancel()
+
+	targetURL := fmt.Sprintf("%s/%d/insurance/%d", utils.PATIENT_FETCH_PATIENT_BY_ID_ENDPOINT, patientID, policyID)
+	gc.forwardInsuranceRequest(ctx, w, utils.DELETE, targetURL, nil, "DeleteInsurancePolicy")
+}
+
+// GetPatientCoverage handles telling whether a patient is insured on a day, today unless a date is given.
+func (gc *GatewayController) GetPatientCoverage(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to get the coverage of a patient.")
+
+	patientID, err := strconv.Atoi(mux.Vars(r)[utils.GET_PATIENT_ID_PARAMETER])
+	if err != nil {
+		log.Printf("[GATEWAY] Invalid patient ID: %v", err)
+		utils.SendErrorResponse(w, http.StatusBadRequest, "Invalid patient ID", err.Error())
+		return
+	}
+
+	// Create a context with a timeout (adjust the timeout as needed)
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_CONTEXT_TIMEOUT*time.Second)
+	defer cancel()
+
+	targetURL := withForwardedQuery(r, fmt.Sprintf("%s/%d/coverage", utils.PATIENT_FETCH_PATIENT_BY_ID_ENDPOINT, patientID), utils.QUERY_DATE)
+	gc.forwardInsuranceRequest(ctx, w, utils.GET, targetURL, nil, "GetPatientCoverage")
+}
+
+// GetAppointmentCoverage handles telling whether the patient of an appointment is insured on its day.
+func (gc *GatewayController) GetAppointmentCoverage(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to get the coverage of an appointment.")
+
+	// Get appointmentID from request params
+	appointmentID, err := strconv.Atoi(mux.Vars(r)[utils.GET_APPOINTMENT_ID_PARAMETER])
+	if err != nil {
+		log.Printf("[GATEWAY] Invalid appointment ID: %v", err)
+		utils.SendErrorResponse(w, http.StatusBadRequest, "Invalid appointment ID", err.Error())
+		return
+	}
+
+	// Create a context with a timeout (adjust the timeout as needed)
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_CONTEXT_TIMEOUT*time.Second)
+	defer cancel()
+
+	decodedResponse, status, err := gc.redirectRequestBody(ctx, utils.GET, utils.APPOINTMENT_HOST, fmt.Sprintf("%s/%d/coverage", utils.APPOINTMENT_FETCH_APPOINTMENT_BY_ID_ENDPOINT, appointmentID), utils.APPOINTMENT_PORT, nil)
+	if err != nil {
+		log.Printf("[GATEWAY] Error redirecting appointment request: %v", err)
+		utils.SendErrorResponse(w, http.StatusBadGateway, "Failed to redirect request", err.Error())
+		return
+	}
+
+	// Check the response status and handle accordingly
+	switch status {
+	case http.StatusOK:
+		log.Printf("[GATEWAY] GetAppointmentCoverage: Request successful with status %d", status)
+		utils.SendMessageResponse(w, http.StatusOK, decodedResponse.Message, decodedResponse.Payload)
+		return
+	case http.StatusBadRequest, http.StatusNotFound:
+		log.Printf("[GATEWAY] GetAppointmentCoverage: Request failed with status %d", status)
+		utils.SendErrorResponse(w, status, decodedResponse.Message, decodedResponse.Error)
+		return
+	default:
+		log.Printf("[GATEWAY] GetAppointmentCoverage: Request failed with unexpected status %d", status)
+		utils.SendErrorResponse(w, http.StatusInternalServerError, decodedResponse.Message, "Unexpected status code: "+strconv.Itoa(status)+". Error: "+decodedResponse.Error)
+		return
+	}
+}
+
+func (gc *GatewayController) forwardInsuranceRequest(ctx context.Context, w http.ResponseWriter, method, targetURL string, body interface{}, handlerName string) {
+	decodedResponse, status, err := gc.redirectRequestBody(ctx, method, utils.PATIENT_HOST, targetURL, utils.PATIENT_PORT, body)
+	if err != nil {
+		log.Printf("[GATEWAY] Error redirecting patient request: %v", err)
+		utils.SendErrorResponse(w, http.StatusBadGateway, "Failed to redirect request", err.Error())
+		return
+	}
+
+	// Check the response status and handle accordingly
+	switch status {
+	case http.StatusCreated:
+		log.Printf("[GATEWAY] %s: Request successful with status %d", handlerName, status)
+		locationHeader := decodedResponse.Header.Get(utils.HEADER_LOCATION_KEY)
+		w.Header().Set(utils.HEADER_LOCATION_KEY, fmt.Sprintf("/api%s", locationHeader))
+		utils.SendMessageResponse(w, http.StatusCreated, decodedResponse.Message, decodedResponse.Payload)
+		return
+	case http.StatusOK:
+		log.Printf("[GATEWAY] %s: Request successful with status %d", handlerName, status)
+		utils.SendMessageResponse(w, http.StatusOK, decodedResponse.Message, decodedResponse.Payload)
+		return
+	case http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusUnprocessableEntity, http.StatusBadGateway:
+		log.Printf("[GATEWAY] %s: Request failed with status %d", handlerName, status)
+		utils.SendErrorResponse(w, status, decodedResponse.Message, decodedResponse.Error)
+		return
+	default:
+		log.Printf("[GATEWAY] %s: Request failed with unexpected status %d", handlerName, status)
+		utils.SendErrorResponse(w, http.StatusInternalServerError, decodedResponse.Message, "Unexpected status code: "+strconv.Itoa(status)+". Error: "+decodedResponse.Error)
+		return
+	}
+}
+
+func insurancePolicyIDs(w http.ResponseWriter, r *http.Request) (int, int, bool) {
+	vars := mux.Vars(r)
+	patientID, err := strconv.Atoi(vars[utils.GET_PATIENT_ID_PARAMETER])
+	if err != nil {
+		log.Printf("[GATEWAY] Invalid patient ID: %v", err)
+		utils.SendErrorResponse(w, http.StatusBadRequest, "Invalid patient ID", err.Error())
+		return 0, 0, false
+	}
+
+	policyID, err := strconv.Atoi(vars[utils.INSURANCE_POLICY_ID_PARAMETER])
+	if err != nil {
+		log.Printf("[GATEWAY] Invalid insurance policy ID: %v", err)
+		utils.SendErrorResponse(w, http.StatusBadRequest, "Invalid insurance policy ID", err.Error())
+		return 0, 0, false
+	}
+	return patientID, policyID, true
+}
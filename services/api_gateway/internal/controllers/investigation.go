@@ -0,0 +1,150 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mihnea1711/POS_Project/services/gateway/internal/models"
+	"github.com/mihnea1711/POS_Project/services/gateway/pkg/utils"
+)
+
+// GetPendingInvestigations handles the worklist of the lab, the investigations still waiting for a result.
+// The patient, doctor, status and overdue filters are passed on to the consultation module.
+func (gc *GatewayController) GetPendingInvestigations(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to get pending investigations.")
+
+	// Create a context with a timeout (adjust the timeout as needed)
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_CONTEXT_TIMEOUT*time.Second)
+	defer cancel()
+
+	targetURL := utils.CONSULTATION_PENDING_INVESTIGATIONS_ENDPOINT
+	if r.URL.RawQuery != "" {
+		targetURL = fmt.Sprintf("%s?%s", targetURL, r.URL.RawQuery)
+	}
+	gc.forwardInvestigationRequest(ctx, w, utils.GET, targetURL, nil, "GetPendingInvestigations")
+}
+
+// OrderInvestigation handles ordering an investigation on an existing consultation, its result is recorded later.
+func (gc *GatewayController) OrderInvestigation(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to order an investigation.")
+
+	// Take investigation order from the context after validation
+	orderRequest := r.Context().Value(utils.DECODED_INVESTIGATION_ORDER).(*models.InvestigationOrderData)
+
+	// Create a context with a timeout (adjust the timeout as needed)
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_CONTEXT_TIMEOUT*time.Second)
+	defer cancel()
+
+	// The consultation module records the change as a revision by the user
+	amendment, err := amendmentQuery(r)
+	if err != nil {
+		log.Printf("[GATEWAY] %v", err)
+		utils.SendErrorResponse(w, http.StatusUnauthorized, "Failed to identify the user", err.Error())
+		return
+	}
+	gc.forwardInvestigationRequest(ctx, w, utils.POST, investigationsURL(r)+"?"+amendment, orderRequest, "OrderInvestigation")
+}
+
+// GetInvestigations handles the retrieval of the investigations of a consultation.
+func (gc *GatewayController) GetInvestigations(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to get the investigations of a consultation.")
+
+	// Create a context with a timeout (adjust the timeout as needed)
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_CONTEXT_TIMEOUT*time.Second)
+	defer cancel()
+
+	gc.forwardInvestigationRequest(ctx, w, utils.GET, investigationsURL(r), nil, "GetInvestigations")
+}
+
+// GetInvestigationByID handles the retrieval of an investigation of a consultation.
+func (gc *GatewayController) GetInvestigationByID(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to get an investigation by ID.")
+
+	// Create a context with a timeout (adjust the timeout as needed)
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_CONTEXT_TIMEOUT*time.Second)
+	defer cancel()
+
+	gc.forwardInvestigationRequest(ctx, w, utils.GET, investigationURL(r), nil, "GetInvestigationByID")
+}
+
+// UpdateInvestigationStatus handles moving an investigation along its lifecycle, such as collecting the sample.
+func (gc *GatewayController) UpdateInvestigationStatus(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to change the status of an investigation.")
+
+	// Take status change from the context after validation
+	statusRequest := r.Context().Value(utils.DECODED_INVESTIGATION_STATUS).(*models.InvestigationStatusData)
+
+	// Create a context with a timeout (adjust the timeout as needed)
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_CONTEXT_TIMEOUT*time.Second)
+	defer cancel()
+
+	// The consultation module records the change as a revision by the user
+	amendment, err := amendmentQuery(r)
+	if err != nil {
+		log.Printf("[GATEWAY] %v", err)
+		utils.SendErrorResponse(w, http.StatusUnauthorized, "Failed to identify the user", err.Error())
+		return
+	}
+	gc.forwardInvestigationRequest(ctx, w, utils.PUT, investigationURL(r)+"/status"+"?"+amendment, statusRequest, "UpdateInvestigationStatus")
+}
+
+// RecordInvestigationResult handles recording the result of an investigation without replacing the consultation.
+func (gc *GatewayController) RecordInvestigationResult(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to record the result of an investigation.")
+
+	// Take investigation result from the context after validation
+	resultRequest := r.Context().Value(utils.DECODED_INVESTIGATION_RESULT).(*models.InvestigationResultData)
+
+	// Create a context with a timeout (adjust the timeout as needed)
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_CONTEXT_TIMEOUT*time.Second)
+	defer cancel()
+
+	// The consultation module records the change as a revision by the user
+	amendment, err := amendmentQuery(r)
+	if err != nil {
+		log.Printf("[GATEWAY] %v", err)
+		utils.SendErrorResponse(w, http.StatusUnauthorized, "Failed to identify the user", err.Error())
+		return
+	}
+	gc.forwardInvestigationRequest(ctx, w, utils.PUT, investigationURL(r)+"/result"+"?"+amendment, resultRequest, "RecordInvestigationResult")
+}
+
+func (gc *GatewayController) forwardInvestigationRequest(ctx context.Context, w http.ResponseWriter, method, targetURL string, body interface{}, handlerName string) {
+	decodedResponse, status, err := gc.redirectRequestBody(ctx, method, utils.CONSULTATION_HOST, targetURL, utils.CONSULTATION_PORT, body)
+	if err != nil {
+		log.Printf("[GATEWAY] Error redirecting investigation request: %v", err)
+		utils.SendErrorResponse(w, http.StatusBadGateway, "Failed to redirect request", err.Error())
+		return
+	}
+
+	// Check the response status and handle accordingly
+	switch status {
+	case http.StatusOK, http.StatusCreated:
+		log.Printf("[GATEWAY] %s: Request successful with status %d", handlerName, status)
+		utils.SendMessageResponse(w, status, decodedResponse.Message, decodedResponse.Payload)
+		return
+	case http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusUnprocessableEntity:
+		log.Printf("[GATEWAY] %s: Request failed with status %d", handlerName, status)
+		utils.SendErrorResponse(w, status, decodedResponse.Message, decodedResponse.Error)
+		return
+	default:
+		log.Printf("[GATEWAY] %s: Request failed with unexpected status %d", handlerName, status)
+		utils.SendErrorResponse(w, http.StatusInternalServerError, decodedResponse.Message, "Unexpected status code: "+strconv.Itoa(status)+". Error: "+decodedResponse.Error)
+		return
+	}
+}
+
+// investigationsURL is the consultation module path of the investigations of the consultation in the request
+func investigationsURL(r *http.Request) string {
+	return fmt.Sprintf("%s/%s/investigations", utils.CONSULTATION_FETCH_CONSULTATIE_BY_ID_ENDPOINT, mux.Vars(r)[utils.GET_CONSULTATION_BY_ID_PARAMETER])
+}
+
+// investigationURL is the consultation module path of the investigation in the request
+func investigationURL(r *http.Request) string {
+	return fmt.Sprintf("%s/%s", investigationsURL(r), mux.Vars(r)[utils.INVESTIGATION_ID_PARAMETER])
+}
@@ -0,0 +1,116 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mihnea1711/POS_Project/services/gateway/internal/models"
+	"github.com/mihnea1711/POS_Project/services/gateway/pkg/utils"
+)
+
+// ImportLabResults streams the HL7 messages posted by the lab to the consultation module, which records the results
+// and queues those it cannot match. The report of the module is passed back as it is.
+func (gc *GatewayController) ImportLabResults(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to import lab results.")
+
+	ctx, cancel := context.WithTimeout(r.Context(), utils.LAB_IMPORT_REQUEST_CONTEXT_TIMEOUT*time.Second)
+	defer cancel()
+
+	// The consultation module records the results as revisions by the user
+	amendment, err := amendmentQuery(r)
+	if err != nil {
+		log.Printf("[GATEWAY] %v", err)
+		utils.SendErrorResponse(w, http.StatusUnauthorized, "Failed to identify the user", err.Error())
+		return
+	}
+
+	endpoint := fmt.Sprintf("%s?%s", utils.CONSULTATION_LAB_RESULTS_ENDPOINT, amendment)
+	response, err := gc.redirectRequestStream(ctx, utils.POST, utils.CONSULTATION_HOST, endpoint, utils.CONSULTATION_PORT, r.Body, r.ContentLength, r.Header.Get("Content-Type"))
+	if err != nil {
+		log.Printf("[GATEWAY] Error streaming lab results: %v", err)
+		utils.SendErrorResponse(w, http.StatusBadGateway, "Failed to redirect request", err.Error())
+		return
+	}
+	defer response.Body.Close()
+
+	log.Printf("[GATEWAY] Lab result import answered with status %d", response.StatusCode)
+	forwardModuleResponse(w, response)
+}
+
+// GetLabReconciliationItems handles the review queue of the lab results that could not be matched.
+// The status and reason filters are passed on to the consultation module.
+func (gc *GatewayController) GetLabReconciliationItems(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to get lab reconciliation items.")
+
+	// Create a context with a timeout (adjust the timeout as needed)
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_CONTEXT_TIMEOUT*time.Second)
+	defer cancel()
+
+	targetURL := utils.CONSULTATION_LAB_RECONCILIATION_ENDPOINT
+	if r.URL.RawQuery != "" {
+		targetURL = fmt.Sprintf("%s?%s", targetURL, r.URL.RawQuery)
+	}
+	gc.forwardInvestigationRequest(ctx, w, utils.GET, targetURL, nil, "GetLabReconciliationItems")
+}
+
+// GetLabReconciliationItemByID handles the retrieval of a queued lab result with the message it came in.
+func (gc *GatewayController) GetLabReconciliationItemByID(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to get a lab reconciliation item by ID.")
+
+	// Create a context with a timeout (adjust the timeout as needed)
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_CONTEXT_TIMEOUT*time.Second)
+	defer cancel()
+
+	gc.forwardInvestigationRequest(ctx, w, utils.GET, labReconciliationItemURL(r), nil, "GetLabReconciliationItemByID")
+}
+
+// ResolveLabReconciliationItem handles recording a queued lab result on the investigation the reviewer matched it to.
+func (gc *GatewayController) ResolveLabReconciliationItem(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to resolve a lab reconciliation item.")
+
+	// Take resolution from the context after validation
+	resolutionRequest := r.Context().Value(utils.DECODED_LAB_RESOLUTION).(*models.LabResolutionData)
+
+	// Create a context with a timeout (adjust the timeout as needed)
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_CONTEXT_TIMEOUT*time.Second)
+	defer cancel()
+
+	// The consultation module records the result as a revision by the user
+	amendment, err := amendmentQuery(r)
+	if err != nil {
+		log.Printf("[GATEWAY] %v", err)
+		utils.SendErrorResponse(w, http.StatusUnauthorized, "Failed to identify the user", err.Error())
+		return
+	}
+	gc.forwardInvestigationRequest(ctx, w, utils.POST, labReconciliationItemURL(r)+"/resolve"+"?"+amendment, resolutionRequest, "ResolveLabReconciliationItem")
+}
+
+// DiscardLabReconciliationItem handles closing a queued lab result without recording it.
+func (gc *GatewayController) DiscardLabReconciliationItem(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to discard a lab reconciliation item.")
+
+	// Take discard from the context after validation
+	discardRequest := r.Context().Value(utils.DECODED_LAB_DISCARD).(*models.LabDiscardData)
+
+	// Create a context with a timeout (adjust the timeout as needed)
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_CONTEXT_TIMEOUT*time.Second)
+	defer cancel()
+
+	// The consultation module keeps the user who reviewed the result
+	amendment, err := amendmentQuery(r)
+	if err != nil {
+		log.Printf("[GATEWAY] %v", err)
+		utils.SendErrorResponse(w, http.StatusUnauthorized, "Failed to identify the user", err.Error())
+		return
+	}
+	gc.forwardInvestigationRequest(ctx, w, utils.POST, labReconciliationItemURL(r)+"/discard"+"?"+amendment, discardRequest, "DiscardLabReconciliationItem")
+}
+
+// labReconciliationItemURL is the consultation module path of the queued lab result in the request
+func labReconciliationItemURL(r *http.Request) string {
+	return fmt.Sprintf("%s/%s", utils.CONSULTATION_LAB_RECONCILIATION_ENDPOINT, mux.Vars(r)[utils.LAB_RECONCILIATION_ID_PARAMETER])
+}
@@ -0,0 +1,107 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mihnea1711/POS_Project/services/gateway/internal/models"
+	"github.com/mihnea1711/POS_Project/services/gateway/pkg/utils"
+)
+
+// PlaceLegalHold handles keeping a patient from being erased, the hold is recorded under the admin who placed it.
+func (gc *GatewayController) PlaceLegalHold(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to place a legal hold.")
+
+	patientID, err := strconv.Atoi(mux.Vars(r)[utils.GET_PATIENT_ID_PARAMETER])
+	if err != nil {
+		log.Printf("[GATEWAY] Invalid patient ID: %v", err)
+		utils.SendErrorResponse(w, http.StatusBadRequest, "Invalid patient ID", err.Error())
+		return
+	}
+
+	// Take legal hold data from the context after validation
+	holdRequest := r.Context().Value(utils.DECODED_LEGAL_HOLD_DATA).(*models.LegalHoldData)
+
+	adminID, err := claimsUserID(r)
+	if err != nil {
+		log.Printf("[GATEWAY] PlaceLegalHold: %v", err)
+		utils.SendErrorResponse(w, http.StatusUnauthorized, "Failed to place legal hold", err.Error())
+		return
+	}
+	holdRequest.PlacedBy = &adminID
+
+	// Create a context with a timeout (adjust the timeout as needed)
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_CONTEXT_TIMEOUT*time.Second)
+	defer cancel()
+
+	targetURL := fmt.Sprintf("%s/%d/legal-hold", utils.PATIENT_FETCH_PATIENT_BY_ID_ENDPOINT, patientID)
+	gc.forwardLegalHoldRequest(ctx, w, utils.PUT, targetURL, holdRequest, "PlaceLegalHold")
+}
+
+// GetLegalHold handles the retrieval of the legal hold of a patient.
+func (gc *GatewayController) GetLegalHold(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to get a legal hold.")
+
+	patientID, err := strconv.Atoi(mux.Vars(r)[utils.GET_PATIENT_ID_PARAMETER])
+	if err != nil {
+		log.Printf("[GATEWAY] Invalid patient ID: %v", err)
+		utils.SendErrorResponse(w, http.StatusBadRequest, "Invalid patient ID", err.Error())
+		return
+	}
+
+	// Create a context with a timeout (adjust the timeout as needed)
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_CONTEXT_TIMEOUT*time.Second)
+	defer cancel()
+
+	targetURL := fmt.Sprintf("%s/%d/legal-hold", utils.PATIENT_FETCH_PATIENT_BY_ID_ENDPOINT, patientID)
+	gc.forwardLegalHoldRequest(ctx, w, utils.GET, targetURL, nil, "GetLegalHold")
+}
+
+// ReleaseLegalHold handles lifting the legal hold of a patient, who can be erased again.
+func (gc *GatewayController) ReleaseLegalHold(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to release a legal hold.")
+
+	patientID, err := strconv.Atoi(mux.Vars(r)[utils.GET_PATIENT_ID_PARAMETER])
+	if err != nil {
+		log.Printf("[GATEWAY] Invalid patient ID: %v", err)
+		utils.SendErrorResponse(w, http.StatusBadRequest, "Invalid patient ID", err.Error())
+		return
+	}
+
+	// Create a context with a timeout (adjust the timeout as needed)
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_CONTEXT_TIMEOUT*time.Second)
+	defer cancel()
+
+	targetURL := fmt.Sprintf("%s/%d/legal-hold", utils.PATIENT_FETCH_PATIENT_BY_ID_ENDPOINT, patientID)
+	gc.forwardLegalHoldRequest(ctx, w, utils.DELETE, targetURL, nil, "ReleaseLegalHold")
+}
+
+func (gc *GatewayController) forwardLegalHoldRequest(ctx context.Context, w http.ResponseWriter, method, targetURL string, body interface{}, handlerName string) {
+	decodedResponse, status, err := gc.redirectRequestBody(ctx, method, utils.PATIENT_HOST, targetURL, utils.PATIENT_PORT, body)
+	if err != nil {
+		log.Printf("[GATEWAY] Error redirecting patient request: %v", err)
+		utils.SendErrorResponse(w, http.StatusBadGateway, "Failed to redirect request", err.Error())
+		return
+	}
+
+	// Check the response status and handle accordingly
+	switch status {
+	case http.StatusOK, http.StatusCreated:
+		log.Printf("[GATEWAY] %s: Request successful with status %d", handlerName, status)
+		utils.SendMessageResponse(w, status, decodedResponse.Message, decodedResponse.Payload)
+		return
+	case http.StatusBadRequest, http.StatusNotFound, http.StatusUnprocessableEntity:
+		log.Printf("[GATEWAY] %s: Request failed with status %d", handlerName, status)
+		utils.SendErrorResponse(w, status, decodedResponse.Message, decodedResponse.Error)
+		return
+	default:
+		log.Printf("[GATEWAY] %s: Request failed with unexpected status %d", handlerName, status)
+		utils.SendErrorResponse(w, http.StatusInternalServerError, decodedResponse.Message, "Unexpected status code: "+strconv.Itoa(status)+". Error: "+decodedResponse.Error)
+		return
+	}
+}
@@ -0,0 +1,115 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mihnea1711/POS_Project/services/gateway/internal/models"
+	"github.com/mihnea1711/POS_Project/services/gateway/pkg/utils"
+)
+
+// RecordMeasurements handles recording the vital signs and clinical measurements taken together.
+// The consultation module converts the units and flags the values against the reference ranges.
+func (gc *GatewayController) RecordMeasurements(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to record measurements.")
+
+	// Take measurements from the context after validation
+	measurementRequest := r.Context().Value(utils.DECODED_MEASUREMENTS).(*models.MeasurementData)
+
+	// Create a context with a timeout (adjust the timeout as needed)
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_CONTEXT_TIMEOUT*time.Second)
+	defer cancel()
+
+	// The consultation module keeps the user who recorded the measurements
+	amendment, err := amendmentQuery(r)
+	if err != nil {
+		log.Printf("[GATEWAY] %v", err)
+		utils.SendErrorResponse(w, http.StatusUnauthorized, "Failed to identify the user", err.Error())
+		return
+	}
+	gc.forwardInvestigationRequest(ctx, w, utils.POST, utils.CONSULTATION_MEASUREMENTS_ENDPOINT+"?"+amendment, measurementRequest, "RecordMeasurements")
+}
+
+// GetMeasurements handles listing the measurements of a patient or of a consultation.
+// The filters are passed on to the consultation module.
+func (gc *GatewayController) GetMeasurements(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to get measurements.")
+
+	// Create a context with a timeout (adjust the timeout as needed)
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_CONTEXT_TIMEOUT*time.Second)
+	defer cancel()
+
+	targetURL := utils.CONSULTATION_MEASUREMENTS_ENDPOINT
+	if r.URL.RawQuery != "" {
+		targetURL = fmt.Sprintf("%s?%s", targetURL, r.URL.RawQuery)
+	}
+	gc.forwardInvestigationRequest(ctx, w, utils.GET, targetURL, nil, "GetMeasurements")
+}
+
+// GetMeasurementTypes handles listing the types of measurements with their units and reference ranges.
+func (gc *GatewayController) GetMeasurementTypes(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to get the measurement types.")
+
+	// Create a context with a timeout (adjust the timeout as needed)
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_CONTEXT_TIMEOUT*time.Second)
+	defer cancel()
+
+	gc.forwardInvestigationRequest(ctx, w, utils.GET, utils.CONSULTATION_MEASUREMENT_TYPES_ENDPOINT, nil, "GetMeasurementTypes")
+}
+
+// GetMeasurementTrend handles following a type of measurement of a patient over a period.
+// The patient, the type, the period and the interval are passed on to the consultation module.
+func (gc *GatewayController) GetMeasurementTrend(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to get a measurement trend.")
+
+	// Create a context with a timeout (adjust the timeout as needed)
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_CONTEXT_TIMEOUT*time.Second)
+	defer cancel()
+
+	targetURL := utils.CONSULTATION_MEASUREMENT_TREND_ENDPOINT
+	if r.URL.RawQuery != "" {
+		targetURL = fmt.Sprintf("%s?%s", targetURL, r.URL.RawQuery)
+	}
+	gc.forwardInvestigationRequest(ctx, w, utils.GET, targetURL, nil, "GetMeasurementTrend")
+}
+
+// GetMeasurementByID handles the retrieval of a measurement.
+func (gc *GatewayController) GetMeasurementByID(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to get a measurement by ID.")
+
+	// Create a context with a timeout (adjust the timeout as needed)
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_CONTEXT_TIMEOUT*time.Second)
+	defer cancel()
+
+	gc.forwardInvestigationRequest(ctx, w, utils.GET, measurementURL(r), nil, "GetMeasurementByID")
+}
+
+// RetractMeasurement handles marking a measurement as entered in error.
+func (gc *GatewayController) RetractMeasurement(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to retract a measurement.")
+
+	// Take retraction from the context after validation
+	retractionRequest := r.Context().Value(utils.DECODED_MEASUREMENT_RETRACTION).(*models.MeasurementRetractionData)
+
+	// Create a context with a timeout (adjust the timeout as needed)
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_CONTEXT_TIMEOUT*time.Second)
+	defer cancel()
+
+	// The consultation module keeps the user who retracted the measurement
+	amendment, err := amendmentQuery(r)
+	if err != nil {
+		log.Printf("[GATEWAY] %v", err)
+		utils.SendErrorResponse(w, http.StatusUnauthorized, "Failed to identify the user", err.Error())
+		return
+	}
+	gc.forwardInvestigationRequest(ctx, w, utils.POST, measurementURL(r)+"/retraction"+"?"+amendment, retractionRequest, "RetractMeasurement")
+}
+
+// measurementURL is the consultation module path of the measurement in the request
+func measurementURL(r *http.Request) string {
+	return fmt.Sprintf("%s/%s", utils.CONSULTATION_MEASUREMENTS_ENDPOINT, mux.Vars(r)[utils.MEASUREMENT_ID_PARAMETER])
+}
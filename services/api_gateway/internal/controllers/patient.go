@@ -5,11 +5,13 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"net/url"
 	"strconv"
 	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/mihnea1711/POS_Project/services/gateway/idm/proto_files"
+	"github.com/mihnea1711/POS_Project/services/gateway/internal/middleware/authorization"
 	"github.com/mihnea1711/POS_Project/services/gateway/internal/models"
 	"github.com/mihnea1711/POS_Project/services/gateway/pkg/utils"
 	"github.com/mihnea1711/POS_Project/services/gateway/pkg/utils/wrappers"
@@ -117,6 +119,58 @@ func (gc *GatewayController) GetPatients(w http.ResponseWriter, r *http.Request)
 	}
 }
 
+// SearchPatients handles the search of patients by name, identifiers and birth date.
+// The identifiers are encrypted by the patient module, so they match whole only, never by prefix.
+// Doctors only search the active patients.
+func (gc *GatewayController) SearchPatients(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to search patients.")
+
+	claims := r.Context().Value(utils.JWT_CLAIMS_CONTEXT_KEY).(*authorization.MyCustomClaims)
+
+	// Create a context with a timeout (adjust the timeout as needed)
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_CONTEXT_TIMEOUT*time.Second)
+	defer cancel()
+
+	// Forward only the search and pagination parameters
+	query := url.Values{}
+	for _, key := range []string{utils.QUERY_TEXT, utils.QUERY_NAME, utils.QUERY_CNP, utils.QUERY_PHONE, utils.QUERY_EMAIL, utils.QUERY_BIRTH_FROM, utils.QUERY_BIRTH_TO, utils.QUERY_IS_ACTIVE, utils.QUERY_PAGE, utils.QUERY_LIMIT} {
+		if value := r.URL.Query().Get(key); value != "" {
+			query.Set(key, value)
+		}
+	}
+	if claims.Role == utils.DOCTOR_ROLE {
+		query.Set(utils.QUERY_IS_ACTIVE, "true")
+	}
+
+	targetURL := utils.PATIENT_SEARCH_PATIENTS_ENDPOINT
+	if len(query) > 0 {
+		targetURL += "?" + query.Encode()
+	}
+
+	decodedResponse, status, err := gc.redirectRequestBody(ctx, utils.GET, utils.PATIENT_HOST, targetURL, utils.PATIENT_PORT, nil)
+	if err != nil {
+		log.Printf("[GATEWAY] Error redirecting patient request: %v", err)
+		utils.SendErrorResponse(w, http.StatusBadGateway, "Failed to redirect request", "Failed to redirect request: "+err.Error())
+		return
+	}
+
+	// Check the response status and handle accordingly
+	switch status {
+	case http.StatusOK:
+		log.Printf("[GATEWAY] SearchPatients: Request successful with status %d", status)
+		utils.SendMessageResponse(w, http.StatusOK, decodedResponse.Message, decodedResponse.Payload)
+		return
+	case http.StatusBadRequest:
+		log.Printf("[GATEWAY] SearchPatients: Request failed with bad request status %d", status)
+		utils.SendErrorResponse(w, http.StatusBadRequest, decodedResponse.Message, decodedResponse.Error)
+		return
+	default:
+		log.Printf("[GATEWAY] SearchPatients: Request failed with unexpected status %d", status)
+		utils.SendErrorResponse(w, http.StatusInternalServerError, decodedResponse.Message, "Unexpected status code: "+strconv.Itoa(status)+". Error: "+decodedResponse.Error)
+		return
+	}
+}
+
 // GetPatientByID handles fetching a patient by ID.
 func (gc *GatewayController) GetPatientByID(w http.ResponseWriter, r *http.Request) {
 	log.Printf("[GATEWAY] Attempting to get patient by ID.")
@@ -0,0 +1,311 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mihnea1711/POS_Project/services/gateway/internal/documents"
+	"github.com/mihnea1711/POS_Project/services/gateway/internal/models"
+	"github.com/mihnea1711/POS_Project/services/gateway/pkg/utils"
+)
+
+// IssuePrescription handles writing a prescription for a consultation. The allergies and medications of the patient's
+// clinical profile are added to the request, so the consultation module checks the drugs against them.
+func (gc *GatewayController) IssuePrescription(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to issue a prescription.")
+
+	// Take prescription from the context after validation
+	prescriptionRequest := r.Context().Value(utils.DECODED_PRESCRIPTION).(*models.PrescriptionData)
+
+	userID, err := claimsUserID(r)
+	if err != nil {
+		log.Printf("[GATEWAY] %v", err)
+		utils.SendErrorResponse(w, http.StatusUnauthorized, "Failed to identify the user", err.Error())
+		return
+	}
+
+	// Create a context with a timeout (adjust the timeout as needed)
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_CONTEXT_TIMEOUT*time.Second)
+	defer cancel()
+
+	consultation, ok := gc.fetchPrescriptionConsultation(ctx, w, r)
+	if !ok {
+		return
+	}
+
+	moduleRequest := models.PrescriptionRequestData{
+		Items:               prescriptionRequest.Items,
+		AcknowledgeWarnings: prescriptionRequest.AcknowledgeWarnings,
+		Allergies:           []string{},
+		Medications:         []string{},
+	}
+
+	profileResponse, status, err := gc.redirectRequestBody(ctx, utils.GET, utils.PATIENT_HOST, fmt.Sprintf("%s/%d/profile", utils.PATIENT_FETCH_PATIENT_BY_ID_ENDPOINT, consultation.IDPatient), utils.PATIENT_PORT, nil)
+	if err != nil {
+		log.Printf("[GATEWAY] Error fetching the clinical profile of patient %d: %v", consultation.IDPatient, err)
+		utils.SendErrorResponse(w, http.StatusBadGateway, "Failed to fetch the clinical profile of the patient", err.Error())
+		return
+	}
+	switch status {
+	case http.StatusOK:
+		var profile models.ClinicalProfileData
+		if err := decodePayload(profileResponse.Payload, &profile); err != nil {
+			log.Printf("[GATEWAY] Error decoding the clinical profile of patient %d: %v", consultation.IDPatient, err)
+			utils.SendErrorResponse(w, http.StatusInternalServerError, "Failed to decode the clinical profile of the patient", err.Error())
+			return
+		}
+		moduleRequest.ProfileRecorded = true
+		for _, allergy := range profile.Allergies {
+			moduleRequest.Allergies = append(moduleRequest.Allergies, allergy.Substance)
+		}
+		for _, medication := range profile.Medications {
+			moduleRequest.Medications = append(moduleRequest.Medications, medication.Name)
+		}
+	case http.StatusNotFound:
+		// Without a profile the prescription is issued with a warning that nothing was checked
+		log.Printf("[GATEWAY] Patient %d has no clinical profile, the prescription is not checked against it", consultation.IDPatient)
+	default:
+		log.Printf("[GATEWAY] Clinical profile of patient %d could not be fetched, status %d", consultation.IDPatient, status)
+		utils.SendErrorResponse(w, http.StatusBadGateway, "Failed to fetch the clinical profile of the patient", profileResponse.Error)
+		return
+	}
+
+	query := url.Values{}
+	query.Set(utils.QUERY_CHANGED_BY, strconv.Itoa(userID))
+	targetURL := fmt.Sprintf("%s?%s", prescriptionsURL(r), query.Encode())
+
+	decodedResponse, status, err := gc.redirectRequestBody(ctx, utils.POST, utils.CONSULTATION_HOST, targetURL, utils.CONSULTATION_PORT, moduleRequest)
+	if err != nil {
+		log.Printf("[GATEWAY] Error redirecting prescription request: %v", err)
+		utils.SendErrorResponse(w, http.StatusBadGateway, "Failed to redirect request", err.Error())
+		return
+	}
+
+	// The warnings to acknowledge come with the conflict
+	if status == http.StatusConflict {
+		log.Printf("[GATEWAY] IssuePrescription: Request failed with status %d", status)
+		utils.RespondWithJSON(w, status, models.ResponseData{Message: decodedResponse.Message, Error: decodedResponse.Error, Payload: decodedResponse.Payload})
+		return
+	}
+	respondWithPrescriptionResponse(w, decodedResponse, status, "IssuePrescription")
+}
+
+// GetPrescriptions handles the retrieval of the prescriptions of a consultation.
+func (gc *GatewayController) GetPrescriptions(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to get the prescriptions of a consultation.")
+
+	// Create a context with a timeout (adjust the timeout as needed)
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_CONTEXT_TIMEOUT*time.Second)
+	defer cancel()
+
+	gc.forwardPrescriptionRequest(ctx, w, utils.GET, prescriptionsURL(r), nil, "GetPrescriptions")
+}
+
+// GetPrescriptionByID handles the retrieval of a prescription of a consultation.
+func (gc *GatewayController) GetPrescriptionByID(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to get a prescription by ID.")
+
+	// Create a context with a timeout (adjust the timeout as needed)
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_CONTEXT_TIMEOUT*time.Second)
+	defer cancel()
+
+	gc.forwardPrescriptionRequest(ctx, w, utils.GET, prescriptionURL(r), nil, "GetPrescriptionByID")
+}
+
+// UpdatePrescriptionStatus handles dispensing or canceling a prescription.
+func (gc *GatewayController) UpdatePrescriptionStatus(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to change the status of a prescription.")
+
+	// Take status change from the context after validation
+	statusRequest := r.Context().Value(utils.DECODED_PRESCRIPTION_STATUS).(*models.PrescriptionStatusData)
+
+	// Create a context with a timeout (adjust the timeout as needed)
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_CONTEXT_TIMEOUT*time.Second)
+	defer cancel()
+
+	gc.forwardPrescriptionRequest(ctx, w, utils.PUT, prescriptionURL(r)+"/status", statusRequest, "UpdatePrescriptionStatus")
+}
+
+// prescriptionDocument is the data the prescription template is laid out from. The patient or the doctor is nil when
+// their profile could not be read, the template then prints their ID.
+type prescriptionDocument struct {
+	Prescription     models.PrescriptionRecordData
+	Consultation     *models.ConsultationData
+	Patient          *models.PatientData
+	Doctor           *models.DoctorData
+	ReviewedWarnings []string
+	GeneratedAt      time.Time
+}
+
+// GetPrescriptionPDF handles the printable rendition of a prescription. The consultation module keeps no names, so the
+// patient and the doctor are looked up here; the document prints their IDs when they cannot be read.
+func (gc *GatewayController) GetPrescriptionPDF(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to get the document of a prescription.")
+
+	// Create a context with a timeout (adjust the timeout as needed)
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_CONTEXT_TIMEOUT*time.Second)
+	defer cancel()
+
+	consultation, ok := gc.fetchPrescriptionConsultation(ctx, w, r)
+	if !ok {
+		return
+	}
+
+	result, status, err := gc.redirectRequestBody(ctx, utils.GET, utils.CONSULTATION_HOST, prescriptionURL(r), utils.CONSULTATION_PORT, nil)
+	if err != nil {
+		log.Printf("[GATEWAY] Error redirecting prescription request: %v", err)
+		utils.SendErrorResponse(w, http.StatusBadGateway, "Failed to redirect request", err.Error())
+		return
+	}
+	if status != http.StatusOK {
+		log.Printf("[GATEWAY] Prescription could not be fetched, status %d", status)
+		utils.SendErrorResponse(w, status, result.Message, result.Error)
+		return
+	}
+
+	document := prescriptionDocument{Consultation: consultation, GeneratedAt: time.Now()}
+	if err := decodePayload(result.Payload, &document.Prescription); err != nil {
+		log.Printf("[GATEWAY] Error decoding prescription: %v", err)
+		utils.SendErrorResponse(w, http.StatusInternalServerError, "Failed to decode prescription", err.Error())
+		return
+	}
+
+	// The warnings the doctor could not be shown, for want of a clinical profile, were never reviewed
+	for _, warning := range document.Prescription.Warnings {
+		if warning.Kind != utils.PRESCRIPTION_WARNING_KIND_UNCHECKED {
+			document.ReviewedWarnings = append(document.ReviewedWarnings, fmt.Sprintf("[%s] %s", strings.ToUpper(warning.Severity), warning.Message))
+		}
+	}
+
+	var patient models.PatientData
+	if gc.fetchDocumentParty(ctx, utils.PATIENT_HOST, utils.PATIENT_PORT, fmt.Sprintf("%s/%d", utils.PATIENT_FETCH_PATIENT_BY_ID_ENDPOINT, consultation.IDPatient), &patient) {
+		document.Patient = &patient
+	}
+	var doctor models.DoctorData
+	if gc.fetchDocumentParty(ctx, utils.DOCTOR_HOST, utils.DOCTOR_PORT, fmt.Sprintf("%s/%d", utils.DOCTOR_FETCH_DOCTOR_BY_ID_ENDPOINT, consultation.IDDoctor), &doctor) {
+		document.Doctor = &doctor
+	}
+
+	rendered, err := gc.Documents.Render(documents.Prescription, document)
+	if err != nil {
+		log.Printf("[GATEWAY] Error rendering prescription %s: %v", document.Prescription.IDPrescription, err)
+		utils.SendErrorResponse(w, http.StatusInternalServerError, "Failed to render the prescription", err.Error())
+		return
+	}
+
+	// The disposition also keeps the response sanitizer away from the document bytes
+	fileName := fmt.Sprintf("prescription-%s.pdf", document.Prescription.IDPrescription)
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Length", strconv.Itoa(len(rendered)))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": fileName}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := w.Write(rendered); err != nil {
+		log.Printf("[GATEWAY] Error writing prescription %s: %v", document.Prescription.IDPrescription, err)
+		return
+	}
+	log.Printf("[GATEWAY] Prescription document sent, %d bytes", len(rendered))
+}
+
+// SearchDrugs handles the autocomplete of the drug catalog prescriptions are written from.
+// The q and limit queries are passed on to the consultation module.
+func (gc *GatewayController) SearchDrugs(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to search drugs.")
+
+	// Create a context with a timeout (adjust the timeout as needed)
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_CONTEXT_TIMEOUT*time.Second)
+	defer cancel()
+
+	targetURL := utils.CONSULTATION_DRUG_SEARCH_ENDPOINT
+	if r.URL.RawQuery != "" {
+		targetURL = fmt.Sprintf("%s?%s", targetURL, r.URL.RawQuery)
+	}
+	gc.forwardPrescriptionRequest(ctx, w, utils.GET, targetURL, nil, "SearchDrugs")
+}
+
+func (gc *GatewayController) forwardPrescriptionRequest(ctx context.Context, w http.ResponseWriter, method, targetURL string, body interface{}, handlerName string) {
+	decodedResponse, status, err := gc.redirectRequestBody(ctx, method, utils.CONSULTATION_HOST, targetURL, utils.CONSULTATION_PORT, body)
+	if err != nil {
+		log.Printf("[GATEWAY] Error redirecting prescription request: %v", err)
+		utils.SendErrorResponse(w, http.StatusBadGateway, "Failed to redirect request", err.Error())
+		return
+	}
+	respondWithPrescriptionResponse(w, decodedResponse, status, handlerName)
+}
+
+func respondWithPrescriptionResponse(w http.ResponseWriter, decodedResponse *models.ResponseDataWrapper, status int, handlerName string) {
+	// Check the response status and handle accordingly
+	switch status {
+	case http.StatusOK, http.StatusCreated:
+		log.Printf("[GATEWAY] %s: Request successful with status %d", handlerName, status)
+		utils.SendMessageResponse(w, status, decodedResponse.Message, decodedResponse.Payload)
+		return
+	case http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusUnprocessableEntity:
+		log.Printf("[GATEWAY] %s: Request failed with status %d", handlerName, status)
+		utils.SendErrorResponse(w, status, decodedResponse.Message, decodedResponse.Error)
+		return
+	default:
+		log.Printf("[GATEWAY] %s: Request failed with unexpected status %d", handlerName, status)
+		utils.SendErrorResponse(w, http.StatusInternalServerError, decodedResponse.Message, "Unexpected status code: "+strconv.Itoa(status)+". Error: "+decodedResponse.Error)
+		return
+	}
+}
+
+// fetchPrescriptionConsultation reads the consultation in the request, answering the request when it cannot
+func (gc *GatewayController) fetchPrescriptionConsultation(ctx context.Context, w http.ResponseWriter, r *http.Request) (*models.ConsultationData, bool) {
+	consultationID := mux.Vars(r)[utils.GET_CONSULTATION_BY_ID_PARAMETER]
+
+	result, status, err := gc.redirectRequestBody(ctx, utils.GET, utils.CONSULTATION_HOST, fmt.Sprintf("%s/%s", utils.CONSULTATION_FETCH_CONSULTATIE_BY_ID_ENDPOINT, consultationID), utils.CONSULTATION_PORT, nil)
+	if err != nil {
+		log.Printf("[GATEWAY] Error redirecting consultation request: %v", err)
+		utils.SendErrorResponse(w, http.StatusBadGateway, "Failed to redirect request", err.Error())
+		return nil, false
+	}
+	if status != http.StatusOK {
+		log.Printf("[GATEWAY] Consultation %s could not be fetched, status %d", consultationID, status)
+		utils.SendErrorResponse(w, status, result.Message, result.Error)
+		return nil, false
+	}
+
+	var consultation models.ConsultationData
+	if err := decodePayload(result.Payload, &consultation); err != nil {
+		log.Printf("[GATEWAY] Error decoding consultation %s: %v", consultationID, err)
+		utils.SendErrorResponse(w, http.StatusInternalServerError, "Failed to decode consultation", err.Error())
+		return nil, false
+	}
+	return &consultation, true
+}
+
+// fetchDocumentParty reads the patient or the doctor a document is printed for. The document falls back to their
+// IDs, so a failure is only logged.
+func (gc *GatewayController) fetchDocumentParty(ctx context.Context, host string, port int, endpoint string, target interface{}) bool {
+	result, status, err := gc.redirectRequestBody(ctx, utils.GET, host, endpoint, port, nil)
+	if err != nil || status != http.StatusOK {
+		log.Printf("[GATEWAY] %s could not be fetched for the document, status %d: %v", endpoint, status, err)
+		return false
+	}
+	if err := decodePayload(result.Payload, target); err != nil {
+		log.Printf("[GATEWAY] Error decoding %s for the document: %v", endpoint, err)
+		return false
+	}
+	return true
+}
+
+// prescriptionsURL is the consultation module path of the prescriptions of the consultation in the request
+func prescriptionsURL(r *http.Request) string {
+	return fmt.Sprintf("%s/%s/prescriptions", utils.CONSULTATION_FETCH_CONSULTATIE_BY_ID_ENDPOINT, mux.Vars(r)[utils.GET_CONSULTATION_BY_ID_PARAMETER])
+}
+
+// prescriptionURL is the consultation module path of the prescription in the request
+func prescriptionURL(r *http.Request) string {
+	return fmt.Sprintf("%s/%s", prescriptionsURL(r), mux.Vars(r)[utils.PRESCRIPTION_ID_PARAMETER])
+}
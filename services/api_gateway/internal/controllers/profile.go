@@ -0,0 +1,193 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mihnea1711/POS_Project/services/gateway/internal/middleware/authorization"
+	"github.com/mihnea1711/POS_Project/services/gateway/internal/models"
+	"github.com/mihnea1711/POS_Project/services/gateway/pkg/utils"
+)
+
+// CreateClinicalProfile handles the creation of the clinical profile of a patient.
+func (gc *GatewayController) CreateClinicalProfile(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to create a clinical profile.")
+	gc.saveClinicalProfile(w, r, utils.POST)
+}
+
+// UpdateClinicalProfile handles replacing the clinical profile of a patient, from the version named in the request.
+func (gc *GatewayController) UpdateClinicalProfile(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to update a clinical profile.")
+	gc.saveClinicalProfile(w, r, utils.PUT)
+}
+
+func (gc *GatewayController) saveClinicalProfile(w http.ResponseWriter, r *http.Request, method string) {
+	patientID, err := strconv.Atoi(mux.Vars(r)[utils.GET_PATIENT_ID_PARAMETER])
+	if err != nil {
+		log.Printf("[GATEWAY] Invalid patient ID: %v", err)
+		utils.SendErrorResponse(w, http.StatusBadRequest, "Invalid patient ID", err.Error())
+		return
+	}
+
+	// Take profile data from the context after validation
+	profileRequest := r.Context().Value(utils.DECODED_CLINICAL_PROFILE_DATA).(*models.ClinicalProfileData)
+
+	// The history records the user making the change, whatever the request says
+	userID, err := claimsUserID(r)
+	if err != nil {
+		log.Printf("[GATEWAY] %v", err)
+		utils.SendErrorResponse(w, http.StatusUnauthorized, "Failed to identify the user", err.Error())
+		return
+	}
+	profileRequest.UpdatedBy = &userID
+
+	// Create a context with a timeout (adjust the timeout as needed)
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_CONTEXT_TIMEOUT*time.Second)
+	defer cancel()
+
+	targetURL := fmt.Sprintf("%s/%d/profile", utils.PATIENT_FETCH_PATIENT_BY_ID_ENDPOINT, patientID)
+	decodedResponse, status, err := gc.redirectRequestBody(ctx, method, utils.PATIENT_HOST, targetURL, utils.PATIENT_PORT, profileRequest)
+	if err != nil {
+		log.Printf("[GATEWAY] Error redirecting patient request: %v", err)
+		utils.SendErrorResponse(w, http.StatusBadGateway, "Failed to redirect request", err.Error())
+		return
+	}
+
+	// Check the response status and handle accordingly
+	switch status {
+	case http.StatusCreated:
+		log.Printf("[GATEWAY] Clinical profile %s: Request successful with status %d", method, status)
+		locationHeader := decodedResponse.Header.Get(utils.HEADER_LOCATION_KEY)
+		w.Header().Set(utils.HEADER_LOCATION_KEY, fmt.Sprintf("/api%s", locationHeader))
+		utils.SendMessageResponse(w, http.StatusCreated, decodedResponse.Message, decodedResponse.Payload)
+		return
+	case http.StatusOK:
+		log.Printf("[GATEWAY] Clinical profile %s: Request successful with status %d", method, status)
+		utils.SendMessageResponse(w, http.StatusOK, decodedResponse.Message, decodedResponse.Payload)
+		return
+	case http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusUnprocessableEntity:
+		log.Printf("[GATEWAY] Clinical profile %s: Request failed with status %d", method, status)
+		utils.SendErrorResponse(w, status, decodedResponse.Message, decodedResponse.Error)
+		return
+	default:
+		log.Printf("[GATEWAY] Clinical profile %s: Request failed with unexpected status %d", method, status)
+		utils.SendErrorResponse(w, http.StatusInternalServerError, decodedResponse.Message, "Unexpected status code: "+strconv.Itoa(status)+". Error: "+decodedResponse.Error)
+		return
+	}
+}
+
+// GetClinicalProfile handles the retrieval of the clinical profile of a patient.
+func (gc *GatewayController) GetClinicalProfile(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to get a clinical profile.")
+
+	patientID, err := strconv.Atoi(mux.Vars(r)[utils.GET_PATIENT_ID_PARAMETER])
+	if err != nil {
+		log.Printf("[GATEWAY] Invalid patient ID: %v", err)
+		utils.SendErrorResponse(w, http.StatusBadRequest, "Invalid patient ID", err.Error())
+		return
+	}
+
+	// Create a context with a timeout (adjust the timeout as needed)
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_CONTEXT_TIMEOUT*time.Second)
+	defer cancel()
+
+	targetURL := fmt.Sprintf("%s/%d/profile", utils.PATIENT_FETCH_PATIENT_BY_ID_ENDPOINT, patientID)
+	gc.forwardClinicalProfileRequest(ctx, w, utils.GET, targetURL, "GetClinicalProfile")
+}
+
+// GetClinicalProfileHistory handles the retrieval of the changes of the clinical profile of a patient.
+func (gc *GatewayController) GetClinicalProfileHistory(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to get a clinical profile history.")
+
+	patientID, err := strconv.Atoi(mux.Vars(r)[utils.GET_PATIENT_ID_PARAMETER])
+	if err != nil {
+		log.Printf("[GATEWAY] Invalid patient ID: %v", err)
+		utils.SendErrorResponse(w, http.StatusBadRequest, "Invalid patient ID", err.Error())
+		return
+	}
+
+	// Create a context with a timeout (adjust the timeout as needed)
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_CONTEXT_TIMEOUT*time.Second)
+	defer cancel()
+
+	targetURL := withForwardedQuery(r, fmt.Sprintf("%s/%d/profile/history", utils.PATIENT_FETCH_PATIENT_BY_ID_ENDPOINT, patientID), utils.QUERY_PAGE, utils.QUERY_LIMIT)
+	gc.forwardClinicalProfileRequest(ctx, w, utils.GET, targetURL, "GetClinicalProfileHistory")
+}
+
+// DeleteClinicalProfile handles the deletion of the clinical profile of a patient, its history is kept.
+func (gc *GatewayController) DeleteClinicalProfile(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to delete a clinical profile.")
+
+	patientID, err := strconv.Atoi(mux.Vars(r)[utils.GET_PATIENT_ID_PARAMETER])
+	if err != nil {
+		log.Printf("[GATEWAY] Invalid patient ID: %v", err)
+		utils.SendErrorResponse(w, http.StatusBadRequest, "Invalid patient ID", err.Error())
+		return
+	}
+
+	userID, err := claimsUserID(r)
+	if err != nil {
+		log.Printf("[GATEWAY] %v", err)
+		utils.SendErrorResponse(w, http.StatusUnauthorized, "Failed to identify the user", err.Error())
+		return
+	}
+
+	// Create a context with a timeout (adjust the timeout as needed)
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_CONTEXT_TIMEOUT*time.Second)
+	defer cancel()
+
+	query := url.Values{}
+	query.Set(utils.QUERY_CHANGED_BY, strconv.Itoa(userID))
+	targetURL := fmt.Sprintf("%s/%d/profile?%s", utils.PATIENT_FETCH_PATIENT_BY_ID_ENDPOINT, patientID, query.Encode())
+	gc.forwardClinicalProfileRequest(ctx, w, utils.DELETE, targetURL, "DeleteClinicalProfile")
+}
+
+func (gc *GatewayController) forwardClinicalProfileRequest(ctx context.Context, w http.ResponseWriter, method, targetURL, handlerName string) {
+	decodedResponse, status, err := gc.redirectRequestBody(ctx, method, utils.PATIENT_HOST, targetURL, utils.PATIENT_PORT, nil)
+	if err != nil {
+		log.Printf("[GATEWAY] Error redirecting patient request: %v", err)
+		utils.SendErrorResponse(w, http.StatusBadGateway, "Failed to redirect request", err.Error())
+		return
+	}
+
+	// Check the response status and handle accordingly
+	switch status {
+	case http.StatusOK:
+		log.Printf("[GATEWAY] %s: Request successful with status %d", handlerName, status)
+		utils.SendMessageResponse(w, http.StatusOK, decodedResponse.Message, decodedResponse.Payload)
+		return
+	case http.StatusBadRequest, http.StatusNotFound:
+		log.Printf("[GATEWAY] %s: Request failed with status %d", handlerName, status)
+		utils.SendErrorResponse(w, status, decodedResponse.Message, decodedResponse.Error)
+		return
+	default:
+		log.Printf("[GATEWAY] %s: Request failed with unexpected status %d", handlerName, status)
+		utils.SendErrorResponse(w, http.StatusInternalServerError, decodedResponse.Message, "Unexpected status code: "+strconv.Itoa(status)+". Error: "+decodedResponse.Error)
+		return
+	}
+}
+
+// claimsUserID returns the IDM user ID of the authenticated user
+func claimsUserID(r *http.Request) (int, error) {
+	claims, ok := r.Context().Value(utils.JWT_CLAIMS_CONTEXT_KEY).(*authorization.MyCustomClaims)
+	if !ok {
+		return 0, fmt.Errorf("missing JWT claims")
+	}
+
+	subject, err := claims.GetSubject()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get user ID from claims: %v", err)
+	}
+
+	userID, err := strconv.Atoi(subject)
+	if err != nil {
+		return 0, fmt.Errorf("invalid user ID in claims: %s", subject)
+	}
+	return userID, nil
+}
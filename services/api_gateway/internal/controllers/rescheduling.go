@@ -0,0 +1,198 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mihnea1711/POS_Project/services/gateway/internal/models"
+	"github.com/mihnea1711/POS_Project/services/gateway/pkg/utils"
+)
+
+// CreateReschedulingJob handles the creation of a rescheduling job for the appointments of an unavailable doctor.
+func (gc *GatewayController) CreateReschedulingJob(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to create a rescheduling job.")
+
+	// Take job data from the context after validation
+	jobRequest := r.Context().Value(utils.DECODED_RESCHEDULING_JOB_DATA).(*models.ReschedulingJobData)
+
+	// Create a context with a timeout (adjust the timeout as needed)
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_CONTEXT_TIMEOUT*time.Second)
+	defer cancel()
+
+	// Check if jobRequest.IDDoctor exists
+	decodedResponseDoctor, statusDoctor, errDoctor := gc.redirectRequestBody(ctx, http.MethodGet, utils.DOCTOR_HOST, fmt.Sprintf("%s/%d", utils.DOCTOR_FETCH_DOCTOR_BY_ID_ENDPOINT, jobRequest.IDDoctor), utils.DOCTOR_PORT, nil)
+	if errDoctor != nil {
+		log.Printf("[GATEWAY] Error redirecting doctor ID request: %v", errDoctor)
+		utils.SendErrorResponse(w, http.StatusBadGateway, "Failed to validate doctor ID", errDoctor.Error())
+		return
+	}
+	if statusDoctor != http.StatusOK {
+		log.Printf("[GATEWAY] Doctor ID doesn't exist or an unexpected error occured with status: %d", statusDoctor)
+		utils.SendErrorResponse(w, statusDoctor, decodedResponseDoctor.Message, decodedResponseDoctor.Error)
+		return
+	}
+
+	// Redirect the request body to appointment module to create the job
+	decodedResponse, status, err := gc.redirectRequestBody(ctx, utils.POST, utils.APPOINTMENT_HOST, utils.APPOINTMENT_RESCHEDULING_JOBS_ENDPOINT, utils.APPOINTMENT_PORT, jobRequest)
+	if err != nil {
+		log.Printf("[GATEWAY] Error redirecting appointment request: %v", err)
+		utils.SendErrorResponse(w, http.StatusBadGateway, "Failed to redirect request", err.Error())
+		return
+	}
+
+	// Check the response status and handle accordingly
+	switch status {
+	case http.StatusCreated:
+		log.Printf("[GATEWAY] CreateReschedulingJob: Request successful with status %d", status)
+		locationHeader := decodedResponse.Header.Get(utils.HEADER_LOCATION_KEY)
+		w.Header().Set(utils.HEADER_LOCATION_KEY, fmt.Sprintf("/api%s", locationHeader))
+		utils.SendMessageResponse(w, http.StatusCreated, decodedResponse.Message, decodedResponse.Payload)
+		return
+	case http.StatusConflict:
+		// The payload holds the job already open for the doctor
+		log.Printf("[GATEWAY] CreateReschedulingJob: Request failed with conflict status %d", status)
+		utils.SendMessageResponse(w, http.StatusConflict, decodedResponse.Message, decodedResponse.Payload)
+		return
+	default:
+		log.Printf("[GATEWAY] CreateReschedulingJob: Request failed with unexpected status %d", status)
+		utils.SendErrorResponse(w, http.StatusInternalServerError, decodedResponse.Message, "Unexpected status code: "+strconv.Itoa(status)+". Error: "+decodedResponse.Error)
+		return
+	}
+}
+
+// GetReschedulingJobs handles the retrieval of all rescheduling jobs.
+func (gc *GatewayController) GetReschedulingJobs(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to get all rescheduling jobs.")
+
+	// Create a context with a timeout (adjust the timeout as needed)
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_CONTEXT_TIMEOUT*time.Second)
+	defer cancel()
+
+	// Forward only the pagination parameters
+	query := url.Values{}
+	for _, key := range []string{utils.QUERY_PAGE, utils.QUERY_LIMIT} {
+		if value := r.URL.Query().Get(key); value != "" {
+			query.Set(key, value)
+		}
+	}
+	targetURL := utils.APPOINTMENT_RESCHEDULING_JOBS_ENDPOINT
+	if len(query) > 0 {
+		targetURL += "?" + query.Encode()
+	}
+
+	decodedResponse, status, err := gc.redirectRequestBody(ctx, utils.GET, utils.APPOINTMENT_HOST, targetURL, utils.APPOINTMENT_PORT, nil)
+	if err != nil {
+		log.Printf("[GATEWAY] Error redirecting appointment request: %v", err)
+		utils.SendErrorResponse(w, http.StatusBadGateway, "Failed to redirect request", err.Error())
+		return
+	}
+
+	// Check the response status and handle accordingly
+	switch status {
+	case http.StatusOK:
+		log.Printf("[GATEWAY] GetReschedulingJobs: Request successful with status %d", status)
+		utils.SendMessageResponse(w, http.StatusOK, decodedResponse.Message, decodedResponse.Payload)
+		return
+	default:
+		log.Printf("[GATEWAY] GetReschedulingJobs: Request failed with unexpected status %d", status)
+		utils.SendErrorResponse(w, http.StatusInternalServerError, decodedResponse.Message, "Unexpected status code: "+strconv.Itoa(status)+". Error: "+decodedResponse.Error)
+		return
+	}
+}
+
+// GetReschedulingJobByID handles the retrieval of a rescheduling job and its proposals.
+func (gc *GatewayController) GetReschedulingJobByID(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to get a rescheduling job by ID.")
+
+	// Get jobID from request params
+	jobID, err := strconv.Atoi(mux.Vars(r)[utils.RESCHEDULING_JOB_ID_PARAMETER])
+	if err != nil {
+		log.Printf("[GATEWAY] Invalid rescheduling job ID: %v", err)
+		utils.SendErrorResponse(w, http.StatusBadRequest, "Invalid rescheduling job ID", err.Error())
+		return
+	}
+
+	// Create a context with a timeout (adjust the timeout as needed)
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_CONTEXT_TIMEOUT*time.Second)
+	defer cancel()
+
+	decodedResponse, status, err := gc.redirectRequestBody(ctx, utils.GET, utils.APPOINTMENT_HOST, fmt.Sprintf("%s/%d", utils.APPOINTMENT_RESCHEDULING_JOBS_ENDPOINT, jobID), utils.APPOINTMENT_PORT, nil)
+	if err != nil {
+		log.Printf("[GATEWAY] Error redirecting appointment request: %v", err)
+		utils.SendErrorResponse(w, http.StatusBadGateway, "Failed to redirect request", err.Error())
+		return
+	}
+
+	// Check the response status and handle accordingly
+	switch status {
+	case http.StatusOK:
+		log.Printf("[GATEWAY] GetReschedulingJobByID: Request successful with status %d", status)
+		utils.SendMessageResponse(w, http.StatusOK, decodedResponse.Message, decodedResponse.Payload)
+		return
+	case http.StatusNotFound:
+		log.Printf("[GATEWAY] GetReschedulingJobByID: Rescheduling job not found with status %d", status)
+		utils.SendErrorResponse(w, http.StatusNotFound, decodedResponse.Message, decodedResponse.Error)
+		return
+	default:
+		log.Printf("[GATEWAY] GetReschedulingJobByID: Request failed with unexpected status %d", status)
+		utils.SendErrorResponse(w, http.StatusInternalServerError, decodedResponse.Message, "Unexpected status code: "+strconv.Itoa(status)+". Error: "+decodedResponse.Error)
+		return
+	}
+}
+
+// AcceptReschedulingProposal forwards the tokenized accept link from a rescheduling proposal.
+func (gc *GatewayController) AcceptReschedulingProposal(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to accept a rescheduling proposal.")
+	gc.answerReschedulingProposal(w, r, "accept")
+}
+
+// DeclineReschedulingProposal forwards the tokenized decline link from a rescheduling proposal.
+func (gc *GatewayController) DeclineReschedulingProposal(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to decline a rescheduling proposal.")
+	gc.answerReschedulingProposal(w, r, "decline")
+}
+
+func (gc *GatewayController) answerReschedulingProposal(w http.ResponseWriter, r *http.Request, action string) {
+	// Get proposalID from request params
+	proposalID, err := strconv.Atoi(mux.Vars(r)[utils.RESCHEDULING_PROPOSAL_ID_PARAMETER])
+	if err != nil {
+		log.Printf("[GATEWAY] Invalid rescheduling proposal ID: %v", err)
+		utils.SendErrorResponse(w, http.StatusBadRequest, "Invalid rescheduling proposal ID", err.Error())
+		return
+	}
+
+	// Create a context with a timeout (adjust the timeout as needed)
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_CONTEXT_TIMEOUT*time.Second)
+	defer cancel()
+
+	// Forward only the proposal token to the appointment module
+	targetURL := fmt.Sprintf("%s/%d/%s?%s=%s", utils.APPOINTMENT_RESCHEDULING_PROPOSALS_ENDPOINT, proposalID, action, utils.QUERY_TOKEN, url.QueryEscape(r.URL.Query().Get(utils.QUERY_TOKEN)))
+	decodedResponse, status, err := gc.redirectRequestBody(ctx, utils.GET, utils.APPOINTMENT_HOST, targetURL, utils.APPOINTMENT_PORT, nil)
+	if err != nil {
+		log.Printf("[GATEWAY] Error redirecting appointment request: %v", err)
+		utils.SendErrorResponse(w, http.StatusBadGateway, "Failed to redirect request", err.Error())
+		return
+	}
+
+	// Check the response status and handle accordingly
+	switch status {
+	case http.StatusOK:
+		log.Printf("[GATEWAY] Rescheduling proposal %s: Request successful with status %d", action, status)
+		utils.SendMessageResponse(w, http.StatusOK, decodedResponse.Message, decodedResponse.Payload)
+		return
+	case http.StatusForbidden, http.StatusNotFound, http.StatusConflict:
+		log.Printf("[GATEWAY] Rescheduling proposal %s: Request failed with status %d", action, status)
+		utils.SendErrorResponse(w, status, decodedResponse.Message, decodedResponse.Error)
+		return
+	default:
+		log.Printf("[GATEWAY] Rescheduling proposal %s: Request failed with unexpected status %d", action, status)
+		utils.SendErrorResponse(w, http.StatusInternalServerError, decodedResponse.Message, "Unexpected status code: "+strconv.Itoa(status)+". Error: "+decodedResponse.Error)
+		return
+	}
+}
@@ -0,0 +1,246 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mihnea1711/POS_Project/services/gateway/internal/models"
+	"github.com/mihnea1711/POS_Project/services/gateway/pkg/utils"
+)
+
+// GetAvailability handles the day by day availability of a doctor for an appointment type.
+func (gc *GatewayController) GetAvailability(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to get appointment availability.")
+
+	// Create a context with a timeout (adjust the timeout as needed)
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_CONTEXT_TIMEOUT*time.Second)
+	defer cancel()
+
+	targetURL := withForwardedQuery(r, utils.APPOINTMENT_AVAILABILITY_ENDPOINT, utils.QUERY_ID_DOCTOR, utils.QUERY_TYPE_ID, utils.QUERY_FROM, utils.QUERY_TO)
+	decodedResponse, status, err := gc.redirectRequestBody(ctx, utils.GET, utils.APPOINTMENT_HOST, targetURL, utils.APPOINTMENT_PORT, nil)
+	if err != nil {
+		log.Printf("[GATEWAY] Error redirecting appointment request: %v", err)
+		utils.SendErrorResponse(w, http.StatusBadGateway, "Failed to redirect request", err.Error())
+		return
+	}
+
+	// Check the response status and handle accordingly
+	switch status {
+	case http.StatusOK:
+		log.Printf("[GATEWAY] GetAvailability: Request successful with status %d", status)
+		utils.SendMessageResponse(w, http.StatusOK, decodedResponse.Message, decodedResponse.Payload)
+		return
+	case http.StatusBadRequest, http.StatusNotFound:
+		log.Printf("[GATEWAY] GetAvailability: Request failed with status %d", status)
+		utils.SendErrorResponse(w, status, decodedResponse.Message, decodedResponse.Error)
+		return
+	default:
+		log.Printf("[GATEWAY] GetAvailability: Request failed with unexpected status %d", status)
+		utils.SendErrorResponse(w, http.StatusInternalServerError, decodedResponse.Message, "Unexpected status code: "+strconv.Itoa(status)+". Error: "+decodedResponse.Error)
+		return
+	}
+}
+
+// GetAppointmentResources handles the retrieval of the rooms and equipment reserved for an appointment.
+func (gc *GatewayController) GetAppointmentResources(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to get the resources of an appointment.")
+
+	// Get appointmentID from request params
+	appointmentID, err := strconv.Atoi(mux.Vars(r)[utils.GET_APPOINTMENT_ID_PARAMETER])
+	if err != nil {
+		log.Printf("[GATEWAY] Invalid appointment ID: %v", err)
+		utils.SendErrorResponse(w, http.StatusBadRequest, "Invalid appointment ID", err.Error())
+		return
+	}
+
+	// Create a context with a timeout (adjust the timeout as needed)
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_CONTEXT_TIMEOUT*time.Second)
+	defer cancel()
+
+	decodedResponse, status, err := gc.redirectRequestBody(ctx, utils.GET, utils.APPOINTMENT_HOST, fmt.Sprintf("%s/%d/resources", utils.APPOINTMENT_FETCH_APPOINTMENT_BY_ID_ENDPOINT, appointmentID), utils.APPOINTMENT_PORT, nil)
+	if err != nil {
+		log.Printf("[GATEWAY] Error redirecting appointment request: %v", err)
+		utils.SendErrorResponse(w, http.StatusBadGateway, "Failed to redirect request", err.Error())
+		return
+	}
+
+	// Check the response status and handle accordingly
+	switch status {
+	case http.StatusOK:
+		log.Printf("[GATEWAY] GetAppointmentResources: Request successful with status %d", status)
+		utils.SendMessageResponse(w, http.StatusOK, decodedResponse.Message, decodedResponse.Payload)
+		return
+	default:
+		log.Printf("[GATEWAY] GetAppointmentResources: Request failed with unexpected status %d", status)
+		utils.SendErrorResponse(w, http.StatusInternalServerError, decodedResponse.Message, "Unexpected status code: "+strconv.Itoa(status)+". Error: "+decodedResponse.Error)
+		return
+	}
+}
+
+// CreateResource handles the creation of a room or a piece of equipment.
+func (gc *GatewayController) CreateResource(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to create a resource.")
+
+	// Take resource data from the context after validation
+	resourceData := r.Context().Value(utils.DECODED_RESOURCE_DATA).(*models.ResourceData)
+
+	// Create a context with a timeout (adjust the timeout as needed)
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_CONTEXT_TIMEOUT*time.Second)
+	defer cancel()
+
+	decodedResponse, status, err := gc.redirectRequestBody(ctx, utils.POST, utils.APPOINTMENT_HOST, utils.APPOINTMENT_RESOURCES_ENDPOINT, utils.APPOINTMENT_PORT, resourceData)
+	if err != nil {
+		log.Printf("[GATEWAY] Error redirecting appointment request: %v", err)
+		utils.SendErrorResponse(w, http.StatusBadGateway, "Failed to redirect request", err.Error())
+		return
+	}
+
+	gc.respondCatalogWrite(w, "CreateResource", status, decodedResponse)
+}
+
+// GetResources handles the retrieval of the rooms and equipment, optionally filtered by category and kind.
+func (gc *GatewayController) GetResources(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to get all resources.")
+
+	// Create a context with a timeout (adjust the timeout as needed)
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_CONTEXT_TIMEOUT*time.Second)
+	defer cancel()
+
+	targetURL := withForwardedQuery(r, utils.APPOINTMENT_RESOURCES_ENDPOINT, utils.QUERY_CATEGORY, utils.QUERY_KIND)
+	decodedResponse, status, err := gc.redirectRequestBody(ctx, utils.GET, utils.APPOINTMENT_HOST, targetURL, utils.APPOINTMENT_PORT, nil)
+	if err != nil {
+		log.Printf("[GATEWAY] Error redirecting appointment request: %v", err)
+		utils.SendErrorResponse(w, http.StatusBadGateway, "Failed to redirect request", err.Error())
+		return
+	}
+
+	// Check the response status and handle accordingly
+	switch status {
+	case http.StatusOK:
+		log.Printf("[GATEWAY] GetResources: Request successful with status %d", status)
+		utils.SendMessageResponse(w, http.StatusOK, decodedResponse.Message, decodedResponse.Payload)
+		return
+	default:
+		log.Printf("[GATEWAY] GetResources: Request failed with unexpected status %d", status)
+		utils.SendErrorResponse(w, http.StatusInternalServerError, decodedResponse.Message, "Unexpected status code: "+strconv.Itoa(status)+". Error: "+decodedResponse.Error)
+		return
+	}
+}
+
+// UpdateResourceByID handles the update of a room or a piece of equipment.
+func (gc *GatewayController) UpdateResourceByID(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to update a resource.")
+
+	// Get resourceID from request params
+	resourceID, err := strconv.Atoi(mux.Vars(r)[utils.RESOURCE_ID_PARAMETER])
+	if err != nil {
+		log.Printf("[GATEWAY] Invalid resource ID: %v", err)
+		utils.SendErrorResponse(w, http.StatusBadRequest, "Invalid resource ID", err.Error())
+		return
+	}
+
+	// Take resource data from the context after validation
+	resourceData := r.Context().Value(utils.DECODED_RESOURCE_DATA).(*models.ResourceData)
+
+	// Create a context with a timeout (adjust the timeout as needed)
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_CONTEXT_TIMEOUT*time.Second)
+	defer cancel()
+
+	decodedResponse, status, err := gc.redirectRequestBody(ctx, utils.PUT, utils.APPOINTMENT_HOST, fmt.Sprintf("%s/%d", utils.APPOINTMENT_RESOURCES_ENDPOINT, resourceID), utils.APPOINTMENT_PORT, resourceData)
+	if err != nil {
+		log.Printf("[GATEWAY] Error redirecting appointment request: %v", err)
+		utils.SendErrorResponse(w, http.StatusBadGateway, "Failed to redirect request", err.Error())
+		return
+	}
+
+	gc.respondCatalogWrite(w, "UpdateResourceByID", status, decodedResponse)
+}
+
+// CreateAppointmentType handles the creation of an appointment type and of the resources it requires.
+func (gc *GatewayController) CreateAppointmentType(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to create an appointment type.")
+
+	// Take appointment type data from the context after validation
+	typeData := r.Context().Value(utils.DECODED_APPOINTMENT_TYPE_DATA).(*models.AppointmentTypeData)
+
+	// Create a context with a timeout (adjust the timeout as needed)
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_CONTEXT_TIMEOUT*time.Second)
+	defer cancel()
+
+	decodedResponse, status, err := gc.redirectRequestBody(ctx, utils.POST, utils.APPOINTMENT_HOST, utils.APPOINTMENT_TYPES_ENDPOINT, utils.APPOINTMENT_PORT, typeData)
+	if err != nil {
+		log.Printf("[GATEWAY] Error redirecting appointment request: %v", err)
+		utils.SendErrorResponse(w, http.StatusBadGateway, "Failed to redirect request", err.Error())
+		return
+	}
+
+	gc.respondCatalogWrite(w, "CreateAppointmentType", status, decodedResponse)
+}
+
+// GetAppointmentTypes handles the retrieval of the appointment types.
+func (gc *GatewayController) GetAppointmentTypes(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to get all appointment types.")
+
+	// Create a context with a timeout (adjust the timeout as needed)
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_CONTEXT_TIMEOUT*time.Second)
+	defer cancel()
+
+	decodedResponse, status, err := gc.redirectRequestBody(ctx, utils.GET, utils.APPOINTMENT_HOST, utils.APPOINTMENT_TYPES_ENDPOINT, utils.APPOINTMENT_PORT, nil)
+	if err != nil {
+		log.Printf("[GATEWAY] Error redirecting appointment request: %v", err)
+		utils.SendErrorResponse(w, http.StatusBadGateway, "Failed to redirect request", err.Error())
+		return
+	}
+
+	// Check the response status and handle accordingly
+	switch status {
+	case http.StatusOK:
+		log.Printf("[GATEWAY] GetAppointmentTypes: Request successful with status %d", status)
+		utils.SendMessageResponse(w, http.StatusOK, decodedResponse.Message, decodedResponse.Payload)
+		return
+	default:
+		log.Printf("[GATEWAY] GetAppointmentTypes: Request failed with unexpected status %d", status)
+		utils.SendErrorResponse(w, http.StatusInternalServerError, decodedResponse.Message, "Unexpected status code: "+strconv.Itoa(status)+". Error: "+decodedResponse.Error)
+		return
+	}
+}
+
+// respondCatalogWrite relays the answer of the appointment module to a write of a resource or an appointment type
+func (gc *GatewayController) respondCatalogWrite(w http.ResponseWriter, handlerName string, status int, decodedResponse *models.ResponseDataWrapper) {
+	switch status {
+	case http.StatusCreated:
+		log.Printf("[GATEWAY] %s: Request successful with status %d", handlerName, status)
+		locationHeader := decodedResponse.Header.Get(utils.HEADER_LOCATION_KEY)
+		w.Header().Set(utils.HEADER_LOCATION_KEY, fmt.Sprintf("/api%s", locationHeader))
+		utils.SendMessageResponse(w, http.StatusCreated, decodedResponse.Message, decodedResponse.Payload)
+	case http.StatusOK:
+		log.Printf("[GATEWAY] %s: Request successful with status %d", handlerName, status)
+		utils.SendMessageResponse(w, http.StatusOK, decodedResponse.Message, decodedResponse.Payload)
+	case http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusUnprocessableEntity:
+		log.Printf("[GATEWAY] %s: Request failed with status %d", handlerName, status)
+		utils.SendErrorResponse(w, status, decodedResponse.Message, decodedResponse.Error)
+	default:
+		log.Printf("[GATEWAY] %s: Request failed with unexpected status %d", handlerName, status)
+		utils.SendErrorResponse(w, http.StatusInternalServerError, decodedResponse.Message, "Unexpected status code: "+strconv.Itoa(status)+". Error: "+decodedResponse.Error)
+	}
+}
+
+// withForwardedQuery appends the listed query parameters of the request to the target URL
+func withForwardedQuery(r *http.Request, targetURL string, keys ...string) string {
+	query := url.Values{}
+	for _, key := range keys {
+		if value := r.URL.Query().Get(key); value != "" {
+			query.Set(key, value)
+		}
+	}
+	if len(query) > 0 {
+		targetURL += "?" + query.Encode()
+	}
+	return targetURL
+}
@@ -0,0 +1,102 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mihnea1711/POS_Project/services/gateway/pkg/utils"
+)
+
+// GetConsultationRevisions handles the retrieval of the amendment trail of a consultation.
+func (gc *GatewayController) GetConsultationRevisions(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to get the revisions of a consultation.")
+
+	// Create a context with a timeout (adjust the timeout as needed)
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_CONTEXT_TIMEOUT*time.Second)
+	defer cancel()
+
+	targetURL := revisionsURL(r)
+	if r.URL.RawQuery != "" {
+		targetURL = fmt.Sprintf("%s?%s", targetURL, r.URL.RawQuery)
+	}
+	gc.forwardRevisionRequest(ctx, w, targetURL, "GetConsultationRevisions")
+}
+
+// GetConsultationRevision handles the retrieval of a consultation as it was at a version.
+func (gc *GatewayController) GetConsultationRevision(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to get a consultation revision.")
+
+	// Create a context with a timeout (adjust the timeout as needed)
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_CONTEXT_TIMEOUT*time.Second)
+	defer cancel()
+
+	targetURL := fmt.Sprintf("%s/%s", revisionsURL(r), mux.Vars(r)[utils.REVISION_VERSION_PARAMETER])
+	gc.forwardRevisionRequest(ctx, w, targetURL, "GetConsultationRevision")
+}
+
+// DiffConsultationRevisions handles the comparison of two versions of a consultation, given as from and to.
+func (gc *GatewayController) DiffConsultationRevisions(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to compare consultation revisions.")
+
+	// Create a context with a timeout (adjust the timeout as needed)
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_CONTEXT_TIMEOUT*time.Second)
+	defer cancel()
+
+	query := url.Values{}
+	query.Set(utils.QUERY_FROM, r.URL.Query().Get(utils.QUERY_FROM))
+	query.Set(utils.QUERY_TO, r.URL.Query().Get(utils.QUERY_TO))
+	targetURL := fmt.Sprintf("%s/diff?%s", revisionsURL(r), query.Encode())
+	gc.forwardRevisionRequest(ctx, w, targetURL, "DiffConsultationRevisions")
+}
+
+func (gc *GatewayController) forwardRevisionRequest(ctx context.Context, w http.ResponseWriter, targetURL string, handlerName string) {
+	decodedResponse, status, err := gc.redirectRequestBody(ctx, utils.GET, utils.CONSULTATION_HOST, targetURL, utils.CONSULTATION_PORT, nil)
+	if err != nil {
+		log.Printf("[GATEWAY] Error redirecting revision request: %v", err)
+		utils.SendErrorResponse(w, http.StatusBadGateway, "Failed to redirect request", err.Error())
+		return
+	}
+
+	// Check the response status and handle accordingly
+	switch status {
+	case http.StatusOK:
+		log.Printf("[GATEWAY] %s: Request successful with status %d", handlerName, status)
+		utils.SendMessageResponse(w, http.StatusOK, decodedResponse.Message, decodedResponse.Payload)
+		return
+	case http.StatusBadRequest, http.StatusNotFound:
+		log.Printf("[GATEWAY] %s: Request failed with status %d", handlerName, status)
+		utils.SendErrorResponse(w, status, decodedResponse.Message, decodedResponse.Error)
+		return
+	default:
+		log.Printf("[GATEWAY] %s: Request failed with unexpected status %d", handlerName, status)
+		utils.SendErrorResponse(w, http.StatusInternalServerError, decodedResponse.Message, "Unexpected status code: "+strconv.Itoa(status)+". Error: "+decodedResponse.Error)
+		return
+	}
+}
+
+// amendmentQuery names the authenticated user as the author of a change to a consultation, with the reason the client
+// gave for it. The consultation module records both in the revision.
+func amendmentQuery(r *http.Request) (string, error) {
+	userID, err := claimsUserID(r)
+	if err != nil {
+		return "", err
+	}
+
+	query := url.Values{}
+	query.Set(utils.QUERY_CHANGED_BY, strconv.Itoa(userID))
+	if reason := r.URL.Query().Get(utils.QUERY_REASON); reason != "" {
+		query.Set(utils.QUERY_REASON, reason)
+	}
+	return query.Encode(), nil
+}
+
+// revisionsURL is the consultation module path of the revisions of the consultation in the request
+func revisionsURL(r *http.Request) string {
+	return fmt.Sprintf("%s/%s/revisions", utils.CONSULTATION_FETCH_CONSULTATIE_BY_ID_ENDPOINT, mux.Vars(r)[utils.GET_CONSULTATION_BY_ID_PARAMETER])
+}
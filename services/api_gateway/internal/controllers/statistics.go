@@ -0,0 +1,37 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/mihnea1711/POS_Project/services/gateway/pkg/utils"
+)
+
+// GetDiagnosisStatistics handles the most common coded diagnoses per doctor and month.
+// The consultation search filters and limit are passed on to the consultation module.
+func (gc *GatewayController) GetDiagnosisStatistics(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to get diagnosis statistics.")
+	gc.forwardStatisticsRequest(w, r, utils.CONSULTATION_DIAGNOSIS_STATISTICS_ENDPOINT, "GetDiagnosisStatistics")
+}
+
+// GetInvestigationStatistics handles the counts and processing times of the investigations per name.
+// The consultation search filters are passed on to the consultation module.
+func (gc *GatewayController) GetInvestigationStatistics(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to get investigation statistics.")
+	gc.forwardStatisticsRequest(w, r, utils.CONSULTATION_INVESTIGATION_STATISTICS_ENDPOINT, "GetInvestigationStatistics")
+}
+
+func (gc *GatewayController) forwardStatisticsRequest(w http.ResponseWriter, r *http.Request, endpoint, handlerName string) {
+	// Create a context with a timeout (adjust the timeout as needed)
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_CONTEXT_TIMEOUT*time.Second)
+	defer cancel()
+
+	targetURL := endpoint
+	if r.URL.RawQuery != "" {
+		targetURL = fmt.Sprintf("%s?%s", targetURL, r.URL.RawQuery)
+	}
+	gc.forwardInvestigationRequest(ctx, w, utils.GET, targetURL, nil, handlerName)
+}
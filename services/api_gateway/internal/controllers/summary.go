@@ -0,0 +1,108 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/mihnea1711/POS_Project/services/gateway/internal/documents"
+	"github.com/mihnea1711/POS_Project/services/gateway/internal/middleware/authorization"
+	"github.com/mihnea1711/POS_Project/services/gateway/internal/models"
+	"github.com/mihnea1711/POS_Project/services/gateway/pkg/utils"
+)
+
+// visitSummary is the data the visit summary template is laid out from. The patient or the doctor is nil when their
+// profile could not be read, the template then prints their ID.
+type visitSummary struct {
+	Consultation  *models.ConsultationData
+	Patient       *models.PatientData
+	Doctor        *models.DoctorData
+	Prescriptions []models.PrescriptionRecordData
+	GeneratedAt   time.Time
+}
+
+// GetVisitSummary handles the printable summary of a visit: the diagnoses, the investigations with their results and
+// the treatment prescribed at a consultation, with the patient's and the doctor's details. Patients may only print the
+// summaries of their own visits.
+func (gc *GatewayController) GetVisitSummary(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to get the summary of a visit.")
+
+	// Create a context with a timeout (adjust the timeout as needed)
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_CONTEXT_TIMEOUT*time.Second)
+	defer cancel()
+
+	consultation, ok := gc.fetchPrescriptionConsultation(ctx, w, r)
+	if !ok {
+		return
+	}
+
+	claims := r.Context().Value(utils.JWT_CLAIMS_CONTEXT_KEY).(*authorization.MyCustomClaims)
+	if claims.Role == utils.PATIENT_ROLE {
+		ownID, err := gc.fetchOwnProfileID(ctx, claims)
+		if err != nil {
+			log.Printf("[GATEWAY] Error resolving own profile: %v", err)
+			utils.SendErrorResponse(w, http.StatusUnauthorized, "Error resolving own profile", err.Error())
+			return
+		}
+		if ownID != consultation.IDPatient {
+			log.Printf("[GATEWAY] Patient %d tried to get the visit summary of patient %d", ownID, consultation.IDPatient)
+			utils.SendErrorResponse(w, http.StatusForbidden, "Access denied", "You can only get the summaries of your own visits")
+			return
+		}
+	}
+
+	summary := visitSummary{Consultation: consultation, GeneratedAt: time.Now()}
+
+	// A canceled prescription is not part of the treatment
+	prescriptions, status, err := gc.redirectRequestBody(ctx, utils.GET, utils.CONSULTATION_HOST, prescriptionsURL(r), utils.CONSULTATION_PORT, nil)
+	if err != nil || status != http.StatusOK {
+		log.Printf("[GATEWAY] Prescriptions of consultation %s could not be fetched, status %d: %v", consultation.IDConsultation.Hex(), status, err)
+		utils.SendErrorResponse(w, http.StatusBadGateway, "Failed to fetch the prescriptions of the visit", fmt.Sprintf("unexpected status %d", status))
+		return
+	}
+	var issued []models.PrescriptionRecordData
+	if err := decodePayload(prescriptions.Payload, &issued); err != nil {
+		log.Printf("[GATEWAY] Error decoding the prescriptions of consultation %s: %v", consultation.IDConsultation.Hex(), err)
+		utils.SendErrorResponse(w, http.StatusInternalServerError, "Failed to decode the prescriptions of the visit", err.Error())
+		return
+	}
+	for _, prescription := range issued {
+		if prescription.Status != utils.PRESCRIPTION_STATUS_CANCELED {
+			summary.Prescriptions = append(summary.Prescriptions, prescription)
+		}
+	}
+
+	var patient models.PatientData
+	if gc.fetchDocumentParty(ctx, utils.PATIENT_HOST, utils.PATIENT_PORT, fmt.Sprintf("%s/%d", utils.PATIENT_FETCH_PATIENT_BY_ID_ENDPOINT, consultation.IDPatient), &patient) {
+		summary.Patient = &patient
+	}
+	var doctor models.DoctorData
+	if gc.fetchDocumentParty(ctx, utils.DOCTOR_HOST, utils.DOCTOR_PORT, fmt.Sprintf("%s/%d", utils.DOCTOR_FETCH_DOCTOR_BY_ID_ENDPOINT, consultation.IDDoctor), &doctor) {
+		summary.Doctor = &doctor
+	}
+
+	document, err := gc.Documents.Render(documents.VisitSummary, summary)
+	if err != nil {
+		log.Printf("[GATEWAY] Error rendering the summary of consultation %s: %v", consultation.IDConsultation.Hex(), err)
+		utils.SendErrorResponse(w, http.StatusInternalServerError, "Failed to render the visit summary", err.Error())
+		return
+	}
+
+	// The disposition also keeps the response sanitizer away from the document bytes
+	fileName := fmt.Sprintf("visit-summary-%s.pdf", consultation.Date.Format("2006-01-02"))
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Length", strconv.Itoa(len(document)))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": fileName}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := w.Write(document); err != nil {
+		log.Printf("[GATEWAY] Error writing the summary of consultation %s: %v", consultation.IDConsultation.Hex(), err)
+		return
+	}
+	log.Printf("[GATEWAY] Summary of consultation %s sent, %d bytes", consultation.IDConsultation.Hex(), len(document))
+}
@@ -0,0 +1,345 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mihnea1711/POS_Project/services/gateway/internal/models"
+	"github.com/mihnea1711/POS_Project/services/gateway/pkg/utils"
+)
+
+// timelineEventRank orders the events that happened at the same time, the latest step of a visit first
+var timelineEventRank = map[string]int{
+	utils.TIMELINE_EVENT_RESULT:        0,
+	utils.TIMELINE_EVENT_INVESTIGATION: 1,
+	utils.TIMELINE_EVENT_CONSULTATION:  2,
+	utils.TIMELINE_EVENT_STATUS_CHANGE: 3,
+	utils.TIMELINE_EVENT_APPOINTMENT:   4,
+}
+
+// timelineSourceEvents names the events each module holds
+var timelineSourceEvents = map[string][]string{
+	utils.TIMELINE_SOURCE_APPOINTMENTS:   {utils.TIMELINE_EVENT_APPOINTMENT},
+	utils.TIMELINE_SOURCE_STATUS_HISTORY: {utils.TIMELINE_EVENT_STATUS_CHANGE},
+	utils.TIMELINE_SOURCE_CONSULTATIONS:  {utils.TIMELINE_EVENT_CONSULTATION, utils.TIMELINE_EVENT_INVESTIGATION, utils.TIMELINE_EVENT_RESULT},
+}
+
+// timelineSource is the outcome of reading the events of a patient from a module
+type timelineSource struct {
+	name   string
+	events []models.TimelineEventData
+	err    error
+}
+
+// GetPatientTimeline handles the history of a patient as a single feed: the appointments, the changes of their
+// status, the consultations, the investigations ordered and their results, the latest first. The modules are read
+// concurrently. When one of them cannot be read the others are still listed and the response tells which is missing.
+func (gc *GatewayController) GetPatientTimeline(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to get the timeline of a patient.")
+
+	// Create a context with a timeout (adjust the timeout as needed)
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_CONTEXT_TIMEOUT*time.Second)
+	defer cancel()
+
+	patientID, err := strconv.Atoi(mux.Vars(r)[utils.GET_PATIENT_ID_PARAMETER])
+	if err != nil {
+		log.Printf("[GATEWAY] Invalid patient ID: %v", err)
+		utils.SendErrorResponse(w, http.StatusBadRequest, "Invalid patient ID", err.Error())
+		return
+	}
+
+	types, err := parseTimelineTypes(r.URL.Query().Get(utils.QUERY_TYPES))
+	if err != nil {
+		log.Printf("[GATEWAY] Invalid timeline types: %v", err)
+		utils.SendErrorResponse(w, http.StatusBadRequest, "Invalid event types", err.Error())
+		return
+	}
+	from, to, err := parseTimelineRange(r.URL.Query().Get(utils.QUERY_FROM), r.URL.Query().Get(utils.QUERY_TO))
+	if err != nil {
+		log.Printf("[GATEWAY] Invalid timeline range: %v", err)
+		utils.SendErrorResponse(w, http.StatusBadRequest, "Invalid date range", err.Error())
+		return
+	}
+	limit, page := utils.ExtractPaginationParams(r)
+
+	sources := []string{}
+	for _, source := range []string{utils.TIMELINE_SOURCE_APPOINTMENTS, utils.TIMELINE_SOURCE_STATUS_HISTORY, utils.TIMELINE_SOURCE_CONSULTATIONS} {
+		for _, eventType := range timelineSourceEvents[source] {
+			if types[eventType] {
+				sources = append(sources, source)
+				break
+			}
+		}
+	}
+
+	// The patient is looked up alongside the events, so an unknown patient costs no more than a known one
+	var (
+		wg         sync.WaitGroup
+		patient    interface{}
+		patientErr error
+	)
+	results := make([]timelineSource, len(sources))
+	wg.Add(len(sources) + 1)
+	go func() {
+		defer wg.Done()
+		patient, patientErr = gc.fetchExportResource(ctx, utils.PATIENT_HOST, utils.PATIENT_PORT, fmt.Sprintf("%s/%d", utils.PATIENT_FETCH_PATIENT_BY_ID_ENDPOINT, patientID))
+	}()
+	for i, source := range sources {
+		go func(i int, source string) {
+			defer wg.Done()
+			events, err := gc.fetchTimelineSource(ctx, source, patientID)
+			results[i] = timelineSource{name: source, events: events, err: err}
+		}(i, source)
+	}
+	wg.Wait()
+
+	timeline := models.TimelineData{IDPatient: patientID, Events: []models.TimelineEventData{}, Page: page, Limit: limit}
+	if patientErr != nil {
+		log.Printf("[GATEWAY] Patient %d could not be fetched for the timeline: %v", patientID, patientErr)
+		timeline.Failures = append(timeline.Failures, models.TimelineFailureData{Source: utils.TIMELINE_SOURCE_PATIENTS, Error: patientErr.Error()})
+	} else if patient == nil {
+		log.Printf("[GATEWAY] Patient %d not found", patientID)
+		utils.SendErrorResponse(w, http.StatusNotFound, "Patient not found", fmt.Sprintf("no patient with ID %d", patientID))
+		return
+	}
+
+	events := []models.TimelineEventData{}
+	failed := 0
+	for _, result := range results {
+		if result.err != nil {
+			failed++
+			log.Printf("[GATEWAY] The %s of patient %d could not be fetched for the timeline: %v", result.name, patientID, result.err)
+			timeline.Failures = append(timeline.Failures, models.TimelineFailureData{Source: result.name, Error: result.err.Error()})
+			continue
+		}
+		for _, event := range result.events {
+			if types[event.Type] && !event.Time.Before(from) && event.Time.Before(to) {
+				events = append(events, event)
+			}
+		}
+	}
+	if failed > 0 && failed == len(sources) {
+		log.Printf("[GATEWAY] No module could be read for the timeline of patient %d", patientID)
+		utils.SendErrorResponse(w, http.StatusBadGateway, "Failed to fetch the timeline of the patient", "none of the modules could be read")
+		return
+	}
+	timeline.Partial = len(timeline.Failures) > 0
+
+	sortTimeline(events)
+	timeline.Total = len(events)
+	if start := (page - 1) * limit; start < len(events) {
+		timeline.Events = events[start:min(start+limit, len(events))]
+	}
+
+	log.Printf("[GATEWAY] Timeline of patient %d fetched: %d of %d events, partial %t", patientID, len(timeline.Events), timeline.Total, timeline.Partial)
+	utils.SendMessageResponse(w, http.StatusOK, "Patient timeline fetched successfully", timeline)
+}
+
+// fetchTimelineSource reads every record of the patient kept by a module and turns it into events
+func (gc *GatewayController) fetchTimelineSource(ctx context.Context, source string, patientID int) ([]models.TimelineEventData, error) {
+	switch source {
+	case utils.TIMELINE_SOURCE_APPOINTMENTS:
+		var appointments []models.AppointmentData
+		if err := gc.fetchTimelineRecords(ctx, utils.APPOINTMENT_HOST, utils.APPOINTMENT_PORT, utils.APPOINTMENT_FETCH_ALL_APPOINTMENTS_ENDPOINT, patientID, &appointments); err != nil {
+			return nil, err
+		}
+		return appointmentEvents(appointments), nil
+	case utils.TIMELINE_SOURCE_STATUS_HISTORY:
+		var changes []models.StatusChangeData
+		if err := gc.fetchTimelineRecords(ctx, utils.APPOINTMENT_HOST, utils.APPOINTMENT_PORT, utils.APPOINTMENT_STATUS_HISTORY_ENDPOINT, patientID, &changes); err != nil {
+			return nil, err
+		}
+		return statusChangeEvents(changes), nil
+	case utils.TIMELINE_SOURCE_CONSULTATIONS:
+		var consultations []models.ConsultationData
+		if err := gc.fetchTimelineRecords(ctx, utils.CONSULTATION_HOST, utils.CONSULTATION_PORT, utils.CONSULTATION_FETCH_ALL_CONSULTATII_ENDPOINT, patientID, &consultations); err != nil {
+			return nil, err
+		}
+		return consultationEvents(consultations), nil
+	default:
+		return nil, fmt.Errorf("unknown timeline source %s", source)
+	}
+}
+
+// fetchTimelineRecords walks every page of a listing filtered by the patient and decodes the records into target
+func (gc *GatewayController) fetchTimelineRecords(ctx context.Context, host string, port int, endpoint string, patientID int, target interface{}) error {
+	records, err := gc.fetchAllPages(ctx, host, port, fmt.Sprintf("%s?%s=%d", endpoint, utils.QUERY_ID_PATIENT, patientID))
+	if err != nil {
+		return err
+	}
+	return decodePayload(records, target)
+}
+
+func appointmentEvents(appointments []models.AppointmentData) []models.TimelineEventData {
+	events := make([]models.TimelineEventData, 0, len(appointments))
+	for _, appointment := range appointments {
+		events = append(events, models.TimelineEventData{
+			Type:          utils.TIMELINE_EVENT_APPOINTMENT,
+			Time:          appointment.Date,
+			Summary:       fmt.Sprintf("Appointment with doctor %d, %s", appointment.IDDoctor, appointment.Status),
+			IDDoctor:      appointment.IDDoctor,
+			IDAppointment: appointment.IDProgramare,
+			Status:        string(appointment.Status),
+		})
+	}
+	return events
+}
+
+func statusChangeEvents(changes []models.StatusChangeData) []models.TimelineEventData {
+	events := make([]models.TimelineEventData, 0, len(changes))
+	for _, change := range changes {
+		event := models.TimelineEventData{
+			Type:          utils.TIMELINE_EVENT_STATUS_CHANGE,
+			Time:          change.ChangedAt,
+			Summary:       fmt.Sprintf("Appointment of %s booked as %s", change.AppointmentDate.Format(utils.TIME_PARSE), change.NewStatus),
+			IDAppointment: change.IDAppointment,
+			Status:        change.NewStatus,
+		}
+		if change.OldStatus != nil {
+			event.PreviousStatus = *change.OldStatus
+			event.Summary = fmt.Sprintf("Appointment of %s changed from %s to %s", change.AppointmentDate.Format(utils.TIME_PARSE), *change.OldStatus, change.NewStatus)
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+// consultationEvents turns a consultation into its own event and one for every investigation ordered at it and every
+// result recorded. Investigations kept before their lifecycle was tracked are dated by the consultation.
+func consultationEvents(consultations []models.ConsultationData) []models.TimelineEventData {
+	events := []models.TimelineEventData{}
+	for _, consultation := range consultations {
+		id := consultation.IDConsultation.Hex()
+		summary := consultation.Diagnostic
+		if codes := diagnosisCodes(consultation.Diagnoses); codes != "" {
+			summary = codes
+		}
+		event := models.TimelineEventData{
+			Type:           utils.TIMELINE_EVENT_CONSULTATION,
+			Time:           consultation.Date,
+			Summary:        fmt.Sprintf("Consultation with doctor %d: %s", consultation.IDDoctor, summary),
+			IDDoctor:       consultation.IDDoctor,
+			IDConsultation: id,
+			Diagnoses:      consultation.Diagnoses,
+		}
+		if consultation.IDAppointment != nil {
+			event.IDAppointment = *consultation.IDAppointment
+		}
+		events = append(events, event)
+
+		for _, investigation := range consultation.Investigations {
+			ordered := consultation.Date
+			if investigation.OrderedAt != nil {
+				ordered = *investigation.OrderedAt
+			}
+			events = append(events, models.TimelineEventData{
+				Type:            utils.TIMELINE_EVENT_INVESTIGATION,
+				Time:            ordered,
+				Summary:         fmt.Sprintf("%s ordered", investigation.Name),
+				IDDoctor:        consultation.IDDoctor,
+				IDConsultation:  id,
+				IDInvestigation: investigation.ID.Hex(),
+				Status:          investigation.Status,
+			})
+
+			if investigation.Result == "" {
+				continue
+			}
+			resulted := ordered
+			if investigation.ResultedAt != nil {
+				resulted = *investigation.ResultedAt
+			}
+			events = append(events, models.TimelineEventData{
+				Type:            utils.TIMELINE_EVENT_RESULT,
+				Time:            resulted,
+				Summary:         fmt.Sprintf("%s resulted", investigation.Name),
+				IDDoctor:        consultation.IDDoctor,
+				IDConsultation:  id,
+				IDInvestigation: investigation.ID.Hex(),
+				Result:          investigation.Result,
+			})
+		}
+	}
+	return events
+}
+
+func diagnosisCodes(diagnoses []models.Diagnosis) string {
+	codes := make([]string, 0, len(diagnoses))
+	for _, diagnosis := range diagnoses {
+		codes = append(codes, diagnosis.Code)
+	}
+	return strings.Join(codes, ", ")
+}
+
+// sortTimeline puts the latest events first. Events at the same time keep the order of a visit, then their IDs keep the
+// pages stable.
+func sortTimeline(events []models.TimelineEventData) {
+	sort.SliceStable(events, func(i, j int) bool {
+		a, b := events[i], events[j]
+		if !a.Time.Equal(b.Time) {
+			return a.Time.After(b.Time)
+		}
+		if timelineEventRank[a.Type] != timelineEventRank[b.Type] {
+			return timelineEventRank[a.Type] < timelineEventRank[b.Type]
+		}
+		if a.IDAppointment != b.IDAppointment {
+			return a.IDAppointment > b.IDAppointment
+		}
+		if a.IDConsultation != b.IDConsultation {
+			return a.IDConsultation > b.IDConsultation
+		}
+		return a.IDInvestigation > b.IDInvestigation
+	})
+}
+
+// parseTimelineTypes reads a comma separated list of event types, none means every type
+func parseTimelineTypes(value string) (map[string]bool, error) {
+	types := map[string]bool{}
+	if strings.TrimSpace(value) == "" {
+		for eventType := range timelineEventRank {
+			types[eventType] = true
+		}
+		return types, nil
+	}
+	for _, eventType := range strings.Split(value, ",") {
+		eventType = strings.TrimSpace(eventType)
+		if _, ok := timelineEventRank[eventType]; !ok {
+			return nil, fmt.Errorf("unknown event type %q", eventType)
+		}
+		types[eventType] = true
+	}
+	return types, nil
+}
+
+// parseTimelineRange reads the dates the events are kept between, the last day included. A missing bound is open.
+func parseTimelineRange(fromValue, toValue string) (time.Time, time.Time, error) {
+	from := time.Time{}
+	to := time.Date(9999, time.December, 31, 0, 0, 0, 0, time.UTC)
+
+	if fromValue != "" {
+		parsed, err := time.Parse(utils.TIME_PARSE, fromValue)
+		if err != nil {
+			return from, to, fmt.Errorf("invalid %s date %q, expected %s", utils.QUERY_FROM, fromValue, utils.TIME_PARSE)
+		}
+		from = parsed
+	}
+	if toValue != "" {
+		parsed, err := time.Parse(utils.TIME_PARSE, toValue)
+		if err != nil {
+			return from, to, fmt.Errorf("invalid %s date %q, expected %s", utils.QUERY_TO, toValue, utils.TIME_PARSE)
+		}
+		to = parsed.AddDate(0, 0, 1)
+	}
+	if !from.Before(to) {
+		return from, to, fmt.Errorf("%s must not be after %s", utils.QUERY_FROM, utils.QUERY_TO)
+	}
+	return from, to, nil
+}
@@ -0,0 +1,293 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mihnea1711/POS_Project/services/gateway/internal/models"
+	"github.com/mihnea1711/POS_Project/services/gateway/pkg/utils"
+)
+
+// StartVisit pre-fills the consultation of an appointment with its patient, doctor and day, the time of the visit when
+// it takes place today. Nothing is saved: the appointment is honored when the consultation is created.
+func (gc *GatewayController) StartVisit(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to start the visit of an appointment.")
+
+	appointmentID, err := strconv.Atoi(mux.Vars(r)[utils.GET_APPOINTMENT_ID_PARAMETER])
+	if err != nil {
+		log.Printf("[GATEWAY] Invalid appointment ID: %v", err)
+		utils.SendErrorResponse(w, http.StatusBadRequest, "Invalid appointment ID", err.Error())
+		return
+	}
+
+	// Create a context with a timeout (adjust the timeout as needed)
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_CONTEXT_TIMEOUT*time.Second)
+	defer cancel()
+
+	appointment, ok := gc.fetchVisitAppointment(ctx, w, appointmentID)
+	if !ok {
+		return
+	}
+
+	// A visit is recorded once, the consultation already opened is amended instead
+	links, err := gc.fetchAppointmentLinks(ctx, []int{appointmentID})
+	if err != nil {
+		log.Printf("[GATEWAY] Error looking up the consultation of appointment %d: %v", appointmentID, err)
+		utils.SendErrorResponse(w, http.StatusBadGateway, "Failed to look up the consultation of the appointment", err.Error())
+		return
+	}
+	if len(links) > 0 {
+		log.Printf("[GATEWAY] StartVisit: Appointment %d already has consultation %s", appointmentID, links[0].IDConsultation)
+		utils.RespondWithJSON(w, http.StatusConflict, models.ResponseData{
+			Error:   fmt.Sprintf("consultation %s was already opened for appointment %d", links[0].IDConsultation, appointmentID),
+			Message: "The visit was already recorded",
+			Payload: links[0],
+		})
+		return
+	}
+
+	date := appointment.Date
+	if now := time.Now().UTC(); now.Format(utils.TIME_PARSE) == date.Format(utils.TIME_PARSE) {
+		date = now
+	}
+
+	log.Printf("[GATEWAY] StartVisit: Visit of appointment %d pre-filled", appointmentID)
+	utils.SendMessageResponse(w, http.StatusOK, "Consultation pre-filled from the appointment", models.VisitDraftData{
+		IDAppointment:  appointmentID,
+		IDPatient:      appointment.IDPatient,
+		IDDoctor:       appointment.IDDoctor,
+		Date:           date,
+		Diagnoses:      []models.Diagnosis{},
+		Investigations: []models.Investigation{},
+	})
+}
+
+// GetUnrecordedVisits lists the honored appointments with no consultation. The doctorID, date, page and limit queries
+// page through the honored appointments, a page lists those of them without a consultation, so it can come out shorter
+// than the limit.
+func (gc *GatewayController) GetUnrecordedVisits(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[GATEWAY] Attempting to get the honored appointments with no consultation.")
+
+	// Create a context with a timeout (adjust the timeout as needed)
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_CONTEXT_TIMEOUT*time.Second)
+	defer cancel()
+
+	// Forward only the known filters, the limit is kept within what one lookup of the consultations takes
+	query := url.Values{}
+	for _, key := range []string{utils.QUERY_ID_DOCTOR, utils.QUERY_DATE, utils.QUERY_PAGE} {
+		if value := r.URL.Query().Get(key); value != "" {
+			query.Set(key, value)
+		}
+	}
+	limit, _ := utils.ExtractPaginationParams(r)
+	query.Set(utils.QUERY_LIMIT, strconv.Itoa(min(limit, utils.MAX_PAGINATION_LIMIT)))
+	query.Set(utils.QUERY_STATUS, utils.APPOINTMENT_STATUS_HONORED)
+
+	targetURL := fmt.Sprintf("%s?%s", utils.APPOINTMENT_FETCH_ALL_APPOINTMENTS_ENDPOINT, query.Encode())
+	decodedResponse, status, err := gc.redirectRequestBody(ctx, utils.GET, utils.APPOINTMENT_HOST, targetURL, utils.APPOINTMENT_PORT, nil)
+	if err != nil {
+		log.Printf("[GATEWAY] Error redirecting appointment request: %v", err)
+		utils.SendErrorResponse(w, http.StatusBadGateway, "Failed to redirect request", err.Error())
+		return
+	}
+	switch status {
+	case http.StatusOK:
+	case http.StatusBadRequest:
+		log.Printf("[GATEWAY] GetUnrecordedVisits: Request failed with bad request status %d", status)
+		utils.SendErrorResponse(w, http.StatusBadRequest, decodedResponse.Message, decodedResponse.Error)
+		return
+	default:
+		log.Printf("[GATEWAY] GetUnrecordedVisits: Request failed with unexpected status %d", status)
+		utils.SendErrorResponse(w, http.StatusInternalServerError, decodedResponse.Message, "Unexpected status code: "+strconv.Itoa(status)+". Error: "+decodedResponse.Error)
+		return
+	}
+
+	appointments := []models.AppointmentData{}
+	if decodedResponse.Payload != nil {
+		if err := decodePayload(decodedResponse.Payload, &appointments); err != nil {
+			log.Printf("[GATEWAY] Error decoding appointments: %v", err)
+			utils.SendErrorResponse(w, http.StatusInternalServerError, "Failed to read the appointments", err.Error())
+			return
+		}
+	}
+
+	unrecorded := []models.AppointmentData{}
+	if len(appointments) > 0 {
+		appointmentIDs := make([]int, len(appointments))
+		for i, appointment := range appointments {
+			appointmentIDs[i] = appointment.IDProgramare
+		}
+
+		links, err := gc.fetchAppointmentLinks(ctx, appointmentIDs)
+		if err != nil {
+			log.Printf("[GATEWAY] Error looking up the consultations of appointments: %v", err)
+			utils.SendErrorResponse(w, http.StatusBadGateway, "Failed to look up the consultations of the appointments", err.Error())
+			return
+		}
+
+		recorded := make(map[int]struct{}, len(links))
+		for _, link := range links {
+			recorded[link.IDAppointment] = struct{}{}
+		}
+		for _, appointment := range appointments {
+			if _, ok := recorded[appointment.IDProgramare]; !ok {
+				unrecorded = append(unrecorded, appointment)
+			}
+		}
+	}
+
+	log.Printf("[GATEWAY] GetUnrecordedVisits: %d of %d honored appointments have no consultation", len(unrecorded), len(appointments))
+	utils.SendMessageResponse(w, http.StatusOK, "Honored appointments with no consultation retrieved successfully.", unrecorded)
+}
+
+// resolveConsultationAppointment finds the appointment a new consultation fulfils: the one it names, which must be of
+// the same patient and doctor, or else the appointment of the patient with the doctor on the day of the consultation.
+// It answers the request when there is none or it cannot be honored.
+func (gc *GatewayController) resolveConsultationAppointment(ctx context.Context, w http.ResponseWriter, consultation *models.ConsultationData) (int, bool) {
+	if consultation.IDAppointment != nil {
+		appointment, ok := gc.fetchVisitAppointment(ctx, w, *consultation.IDAppointment)
+		if !ok {
+			return 0, false
+		}
+		if appointment.IDPatient != consultation.IDPatient || appointment.IDDoctor != consultation.IDDoctor {
+			errMsg := fmt.Sprintf("appointment %d is of patient %d with doctor %d", appointment.IDProgramare, appointment.IDPatient, appointment.IDDoctor)
+			log.Printf("[GATEWAY] Consultation does not match its appointment: %s", errMsg)
+			utils.SendErrorResponse(w, http.StatusBadRequest, "The consultation does not match its appointment", errMsg)
+			return 0, false
+		}
+		return appointment.IDProgramare, true
+	}
+
+	// Check if there is an appointment with the same IDPatient, IDDoctor and date already created.
+	targetQuery := fmt.Sprintf(
+		"%s=%d&%s=%d&%s=%s",
+		utils.QUERY_ID_PATIENT, consultation.IDPatient,
+		utils.QUERY_ID_DOCTOR, consultation.IDDoctor,
+		utils.QUERY_DATE, consultation.Date.Format(utils.TIME_PARSE))
+	targetURL := fmt.Sprintf("%s?%s", utils.APPOINTMENT_FETCH_ALL_APPOINTMENTS_ENDPOINT, targetQuery)
+	decodedResponse, status, err := gc.redirectRequestBody(ctx, http.MethodGet, utils.APPOINTMENT_HOST, targetURL, utils.APPOINTMENT_PORT, nil)
+	if err != nil {
+		log.Printf("[GATEWAY] Error redirecting appointment ID request: %v", err)
+		utils.SendErrorResponse(w, http.StatusBadGateway, "Failed to validate appointment ID", err.Error())
+		return 0, false
+	}
+	if status != http.StatusOK || decodedResponse.Payload == nil {
+		log.Printf("[GATEWAY] Appointment doesn't exist for this consultation or an unexpected error occured with status: %d", status)
+		utils.SendErrorResponse(w, http.StatusFailedDependency, decodedResponse.Message, decodedResponse.Error)
+		return 0, false
+	}
+
+	appointments := []models.AppointmentData{}
+	if err := decodePayload(decodedResponse.Payload, &appointments); err != nil || len(appointments) == 0 {
+		log.Printf("[GATEWAY] No appointment found for this consultation: %v", err)
+		utils.SendErrorResponse(w, http.StatusFailedDependency, "Appointment not found for the consultation", "no appointment of the patient with the doctor on the day of the consultation")
+		return 0, false
+	}
+	if !checkVisitStatus(w, &appointments[0]) {
+		return 0, false
+	}
+	return appointments[0].IDProgramare, true
+}
+
+// fetchVisitAppointment returns an appointment a visit can be recorded for, answering the request when it is missing,
+// canceled or missed
+func (gc *GatewayController) fetchVisitAppointment(ctx context.Context, w http.ResponseWriter, appointmentID int) (*models.AppointmentData, bool) {
+	targetURL := fmt.Sprintf("%s/%d", utils.APPOINTMENT_FETCH_APPOINTMENT_BY_ID_ENDPOINT, appointmentID)
+	decodedResponse, status, err := gc.redirectRequestBody(ctx, http.MethodGet, utils.APPOINTMENT_HOST, targetURL, utils.APPOINTMENT_PORT, nil)
+	if err != nil {
+		log.Printf("[GATEWAY] Error redirecting appointment request: %v", err)
+		utils.SendErrorResponse(w, http.StatusBadGateway, "Failed to fetch the appointment", err.Error())
+		return nil, false
+	}
+	switch status {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		log.Printf("[GATEWAY] Appointment %d not found", appointmentID)
+		utils.SendErrorResponse(w, http.StatusNotFound, decodedResponse.Message, decodedResponse.Error)
+		return nil, false
+	default:
+		log.Printf("[GATEWAY] Fetching appointment %d failed with unexpected status %d", appointmentID, status)
+		utils.SendErrorResponse(w, http.StatusInternalServerError, decodedResponse.Message, "Unexpected status code: "+strconv.Itoa(status)+". Error: "+decodedResponse.Error)
+		return nil, false
+	}
+
+	var appointment models.AppointmentData
+	if err := decodePayload(decodedResponse.Payload, &appointment); err != nil {
+		log.Printf("[GATEWAY] Error decoding appointment %d: %v", appointmentID, err)
+		utils.SendErrorResponse(w, http.StatusInternalServerError, "Failed to read the appointment", err.Error())
+		return nil, false
+	}
+	if !checkVisitStatus(w, &appointment) {
+		return nil, false
+	}
+	return &appointment, true
+}
+
+// checkVisitStatus answers the request when the appointment was canceled or missed, a visit cannot be recorded for it
+func checkVisitStatus(w http.ResponseWriter, appointment *models.AppointmentData) bool {
+	switch appointment.Status {
+	case utils.APPOINTMENT_STATUS_CANCELED, utils.APPOINTMENT_STATUS_NOT_PRESENT:
+		errMsg := fmt.Sprintf("appointment %d is %s", appointment.IDProgramare, appointment.Status)
+		log.Printf("[GATEWAY] No visit can be recorded: %s", errMsg)
+		utils.SendErrorResponse(w, http.StatusConflict, "No visit can be recorded for a canceled or missed appointment", errMsg)
+		return false
+	}
+	return true
+}
+
+// honorAppointment moves the appointment of a consultation to honored, answering the request when it cannot
+func (gc *GatewayController) honorAppointment(ctx context.Context, w http.ResponseWriter, appointmentID int) bool {
+	targetURL := fmt.Sprintf("%s/%d/honor", utils.APPOINTMENT_HONOR_APPOINTMENT_ENDPOINT, appointmentID)
+	decodedResponse, status, err := gc.redirectRequestBody(ctx, utils.POST, utils.APPOINTMENT_HOST, targetURL, utils.APPOINTMENT_PORT, nil)
+	if err != nil {
+		log.Printf("[GATEWAY] Error redirecting appointment request: %v", err)
+		utils.SendErrorResponse(w, http.StatusBadGateway, "Failed to honor the appointment", err.Error())
+		return false
+	}
+
+	switch status {
+	case http.StatusOK:
+		log.Printf("[GATEWAY] Appointment %d honored", appointmentID)
+		return true
+	case http.StatusNotFound, http.StatusConflict:
+		log.Printf("[GATEWAY] Honoring appointment %d failed with status %d", appointmentID, status)
+		utils.SendErrorResponse(w, status, decodedResponse.Message, decodedResponse.Error)
+		return false
+	default:
+		log.Printf("[GATEWAY] Honoring appointment %d failed with unexpected status %d", appointmentID, status)
+		utils.SendErrorResponse(w, http.StatusInternalServerError, decodedResponse.Message, "Unexpected status code: "+strconv.Itoa(status)+". Error: "+decodedResponse.Error)
+		return false
+	}
+}
+
+// fetchAppointmentLinks returns the consultations opened for the appointments
+func (gc *GatewayController) fetchAppointmentLinks(ctx context.Context, appointmentIDs []int) ([]models.AppointmentLinkData, error) {
+	ids := make([]string, len(appointmentIDs))
+	for i, id := range appointmentIDs {
+		ids[i] = strconv.Itoa(id)
+	}
+
+	targetURL := fmt.Sprintf("%s?%s=%s", utils.CONSULTATION_APPOINTMENT_LINKS_ENDPOINT, utils.QUERY_APPOINTMENT_IDS, strings.Join(ids, ","))
+	decodedResponse, status, err := gc.redirectRequestBody(ctx, utils.GET, utils.CONSULTATION_HOST, targetURL, utils.CONSULTATION_PORT, nil)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("consultation module answered %d: %s", status, decodedResponse.Error)
+	}
+
+	links := []models.AppointmentLinkData{}
+	if decodedResponse.Payload != nil {
+		if err := decodePayload(decodedResponse.Payload, &links); err != nil {
+			return nil, err
+		}
+	}
+	return links, nil
+}
@@ -0,0 +1,345 @@
+package documents
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// font is a TrueType font read far enough to measure text and embed the glyphs a document uses
+type font struct {
+	name       string
+	tables     map[string][]byte
+	unitsPerEm float64
+	ascent     int
+	descent    int
+	bbox       [4]int
+	longLoca   bool
+	advances   []uint16
+	cmap       map[rune]uint16
+}
+
+// The tables kept in the embedded subsets, the ones a PDF reader needs to draw TrueType outlines
+var subsetTables = []string{"cvt ", "fpgm", "glyf", "head", "hhea", "hmtx", "loca", "maxp", "prep"}
+
+var errMalformedFont = errors.New("malformed font")
+
+func parseFont(name string, data []byte) (*font, error) {
+	if len(data) < 12 {
+		return nil, errMalformedFont
+	}
+	f := &font{name: name, tables: map[string][]byte{}}
+
+	numTables := int(binary.BigEndian.Uint16(data[4:]))
+	if len(data) < 12+16*numTables {
+		return nil, errMalformedFont
+	}
+	for i := 0; i < numTables; i++ {
+		record := data[12+16*i:]
+		offset, length := binary.BigEndian.Uint32(record[8:]), binary.BigEndian.Uint32(record[12:])
+		if uint64(offset)+uint64(length) > uint64(len(data)) {
+			return nil, fmt.Errorf("%w: table %q out of bounds", errMalformedFont, record[:4])
+		}
+		f.tables[string(record[:4])] = data[offset : offset+length]
+	}
+	for _, tag := range []string{"cmap", "head", "hhea", "hmtx", "loca", "glyf", "maxp"} {
+		if _, ok := f.tables[tag]; !ok {
+			return nil, fmt.Errorf("%w: missing table %q", errMalformedFont, tag)
+		}
+	}
+
+	head := f.tables["head"]
+	if len(head) < 54 {
+		return nil, fmt.Errorf("%w: short head table", errMalformedFont)
+	}
+	f.unitsPerEm = float64(binary.BigEndian.Uint16(head[18:]))
+	for i := range f.bbox {
+		f.bbox[i] = int(int16(binary.BigEndian.Uint16(head[36+2*i:])))
+	}
+	f.longLoca = binary.BigEndian.Uint16(head[50:]) == 1
+
+	hhea := f.tables["hhea"]
+	if len(hhea) < 36 {
+		return nil, fmt.Errorf("%w: short hhea table", errMalformedFont)
+	}
+	f.ascent = int(int16(binary.BigEndian.Uint16(hhea[4:])))
+	f.descent = int(int16(binary.BigEndian.Uint16(hhea[6:])))
+
+	numGlyphs := int(binary.BigEndian.Uint16(f.tables["maxp"][4:]))
+	numMetrics := int(binary.BigEndian.Uint16(hhea[34:]))
+	hmtx := f.tables["hmtx"]
+	if numMetrics == 0 || len(hmtx) < 4*numMetrics {
+		return nil, fmt.Errorf("%w: short hmtx table", errMalformedFont)
+	}
+	// The glyphs past the last metric share its advance
+	f.advances = make([]uint16, numGlyphs)
+	for i := range f.advances {
+		f.advances[i] = binary.BigEndian.Uint16(hmtx[4*min(i, numMetrics-1):])
+	}
+
+	cmap, err := parseCmap(f.tables["cmap"])
+	if err != nil {
+		return nil, err
+	}
+	f.cmap = cmap
+	return f, nil
+}
+
+// parseCmap reads the Unicode mapping of the font, the full repertoire (format 12) when there is one, otherwise the
+// Basic Multilingual Plane (format 4)
+func parseCmap(table []byte) (map[rune]uint16, error) {
+	if len(table) < 4 {
+		return nil, fmt.Errorf("%w: short cmap table", errMalformedFont)
+	}
+	var bmp, full []byte
+	for i := 0; i < int(binary.BigEndian.Uint16(table[2:])); i++ {
+		record := table[4+8*i:]
+		platform, encoding := binary.BigEndian.Uint16(record), binary.BigEndian.Uint16(record[2:])
+		offset := binary.BigEndian.Uint32(record[4:])
+		if int(offset) >= len(table) {
+			continue
+		}
+		switch {
+		case platform == 3 && encoding == 10:
+			full = table[offset:]
+		case platform == 3 && encoding == 1, platform == 0 && bmp == nil:
+			bmp = table[offset:]
+		}
+	}
+
+	cmap := map[rune]uint16{}
+	switch {
+	case full != nil && binary.BigEndian.Uint16(full) == 12:
+		groups := int(binary.BigEndian.Uint32(full[12:]))
+		for i := 0; i < groups && 16+12*i+12 <= len(full); i++ {
+			group := full[16+12*i:]
+			start, end, glyph := binary.BigEndian.Uint32(group), binary.BigEndian.Uint32(group[4:]), binary.BigEndian.Uint32(group[8:])
+			for r := start; r <= end; r++ {
+				cmap[rune(r)] = uint16(glyph + r - start)
+			}
+		}
+	case bmp != nil && binary.BigEndian.Uint16(bmp) == 4:
+		segments := int(binary.BigEndian.Uint16(bmp[6:])) / 2
+		if len(bmp) < 16+8*segments {
+			return nil, fmt.Errorf("%w: short cmap subtable", errMalformedFont)
+		}
+		ends, starts := bmp[14:], bmp[16+2*segments:]
+		deltas, rangeOffsets := bmp[16+4*segments:], bmp[16+6*segments:]
+		for i := 0; i < segments; i++ {
+			start, end := binary.BigEndian.Uint16(starts[2*i:]), binary.BigEndian.Uint16(ends[2*i:])
+			delta, rangeOffset := binary.BigEndian.Uint16(deltas[2*i:]), int(binary.BigEndian.Uint16(rangeOffsets[2*i:]))
+			for r := uint32(start); r <= uint32(end) && r != 0xffff; r++ {
+				glyph := uint16(r) + delta
+				if rangeOffset != 0 {
+					// The offset is counted from the range offset entry itself
+					at := 2*i + rangeOffset + 2*int(r-uint32(start))
+					if at+2 > len(rangeOffsets) {
+						continue
+					}
+					if glyph = binary.BigEndian.Uint16(rangeOffsets[at:]); glyph != 0 {
+						glyph += delta
+					}
+				}
+				if glyph != 0 {
+					cmap[rune(r)] = glyph
+				}
+			}
+		}
+	default:
+		return nil, fmt.Errorf("%w: no Unicode cmap", errMalformedFont)
+	}
+	return cmap, nil
+}
+
+// glyph is the glyph drawing r, the missing glyph 0 when the font has none
+func (f *font) glyph(r rune) uint16 {
+	return f.cmap[r]
+}
+
+// width measures text set at size, in points
+func (f *font) width(text string, size float64) float64 {
+	units := 0
+	for _, r := range text {
+		units += int(f.advances[f.glyph(r)])
+	}
+	return float64(units) * size / f.unitsPerEm
+}
+
+// scale converts font units to the thousandths of the text size PDF measures glyphs in
+func (f *font) scale(units int) int {
+	return int(float64(units) * 1000 / f.unitsPerEm)
+}
+
+// subset rebuilds the font with the outlines of the used glyphs only. The glyph IDs are kept, the other glyphs are
+// left empty, so the text drawn with the full font draws the same with the subset.
+func (f *font) subset(used map[uint16]bool) ([]byte, error) {
+	keep := map[uint16]bool{}
+	pending := []uint16{0}
+	for glyph := range used {
+		pending = append(pending, glyph)
+	}
+	// Composite glyphs are drawn from other glyphs, which must be kept as well
+	for len(pending) > 0 {
+		glyph := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if keep[glyph] {
+			continue
+		}
+		keep[glyph] = true
+		outline, err := f.outline(glyph)
+		if err != nil {
+			return nil, err
+		}
+		pending = append(pending, componentGlyphs(outline)...)
+	}
+
+	var glyf bytes.Buffer
+	loca := make([]byte, 4*(len(f.advances)+1))
+	for glyph := range f.advances {
+		binary.BigEndian.PutUint32(loca[4*glyph:], uint32(glyf.Len()))
+		if !keep[uint16(glyph)] {
+			continue
+		}
+		outline, err := f.outline(uint16(glyph))
+		if err != nil {
+			return nil, err
+		}
+		glyf.Write(outline)
+		// Glyphs start on even offsets
+		if glyf.Len()%2 == 1 {
+			glyf.WriteByte(0)
+		}
+	}
+	binary.BigEndian.PutUint32(loca[4*len(f.advances):], uint32(glyf.Len()))
+
+	head := append([]byte{}, f.tables["head"]...)
+	binary.BigEndian.PutUint32(head[8:], 0)
+	binary.BigEndian.PutUint16(head[50:], 1)
+
+	tables := map[string][]byte{"glyf": glyf.Bytes(), "loca": loca, "head": head}
+	tags := []string{}
+	for _, tag := range subsetTables {
+		if _, ok := tables[tag]; !ok {
+			if table, ok := f.tables[tag]; ok {
+				tables[tag] = table
+			}
+		}
+		if _, ok := tables[tag]; ok {
+			tags = append(tags, tag)
+		}
+	}
+	sort.Strings(tags)
+
+	return assembleFont(tags, tables), nil
+}
+
+// outline is the glyf entry of a glyph, empty for the glyphs without contours such as the space
+func (f *font) outline(glyph uint16) ([]byte, error) {
+	if int(glyph) >= len(f.advances) {
+		return nil, fmt.Errorf("%w: glyph %d out of range", errMalformedFont, glyph)
+	}
+	loca := f.tables["loca"]
+	var start, end int
+	if f.longLoca {
+		if len(loca) < 4*int(glyph)+8 {
+			return nil, fmt.Errorf("%w: short loca table", errMalformedFont)
+		}
+		start, end = int(binary.BigEndian.Uint32(loca[4*glyph:])), int(binary.BigEndian.Uint32(loca[4*glyph+4:]))
+	} else {
+		if len(loca) < 2*int(glyph)+4 {
+			return nil, fmt.Errorf("%w: short loca table", errMalformedFont)
+		}
+		start, end = 2*int(binary.BigEndian.Uint16(loca[2*glyph:])), 2*int(binary.BigEndian.Uint16(loca[2*glyph+2:]))
+	}
+	glyf := f.tables["glyf"]
+	if start > end || end > len(glyf) {
+		return nil, fmt.Errorf("%w: glyph %d out of bounds", errMalformedFont, glyph)
+	}
+	return glyf[start:end], nil
+}
+
+// componentGlyphs lists the glyphs a composite glyph is made of, none for a simple glyph
+func componentGlyphs(outline []byte) []uint16 {
+	const (
+		argsAreWords  = 0x0001
+		hasScale      = 0x0008
+		moreComponent = 0x0020
+		hasXYScale    = 0x0040
+		hasTwoByTwo   = 0x0080
+	)
+	if len(outline) < 10 || int16(binary.BigEndian.Uint16(outline)) >= 0 {
+		return nil
+	}
+	components := []uint16{}
+	for at := 10; at+4 <= len(outline); {
+		flags := binary.BigEndian.Uint16(outline[at:])
+		components = append(components, binary.BigEndian.Uint16(outline[at+2:]))
+		at += 4
+		if flags&argsAreWords != 0 {
+			at += 4
+		} else {
+			at += 2
+		}
+		switch {
+		case flags&hasScale != 0:
+			at += 2
+		case flags&hasXYScale != 0:
+			at += 4
+		case flags&hasTwoByTwo != 0:
+			at += 8
+		}
+		if flags&moreComponent == 0 {
+			break
+		}
+	}
+	return components
+}
+
+// assembleFont writes the tables as a TrueType file, with the table directory and the checksums
+func assembleFont(tags []string, tables map[string][]byte) []byte {
+	var out bytes.Buffer
+	numTables := len(tags)
+	entrySelector := 0
+	for 1<<(entrySelector+1) <= numTables {
+		entrySelector++
+	}
+	searchRange := 16 << entrySelector
+
+	header := make([]byte, 12)
+	binary.BigEndian.PutUint32(header, 0x00010000)
+	binary.BigEndian.PutUint16(header[4:], uint16(numTables))
+	binary.BigEndian.PutUint16(header[6:], uint16(searchRange))
+	binary.BigEndian.PutUint16(header[8:], uint16(entrySelector))
+	binary.BigEndian.PutUint16(header[10:], uint16(numTables*16-searchRange))
+	out.Write(header)
+
+	offset := 12 + 16*numTables
+	for _, tag := range tags {
+		table := tables[tag]
+		record := make([]byte, 16)
+		copy(record, tag)
+		binary.BigEndian.PutUint32(record[4:], checksum(table))
+		binary.BigEndian.PutUint32(record[8:], uint32(offset))
+		binary.BigEndian.PutUint32(record[12:], uint32(len(table)))
+		out.Write(record)
+		offset += (len(table) + 3) &^ 3
+	}
+	for _, tag := range tags {
+		table := tables[tag]
+		out.Write(table)
+		out.Write(make([]byte, ((len(table)+3)&^3)-len(table)))
+	}
+	return out.Bytes()
+}
+
+func checksum(table []byte) uint32 {
+	var sum uint32
+	for i := 0; i < len(table); i += 4 {
+		var word [4]byte
+		copy(word[:], table[i:])
+		sum += binary.BigEndian.Uint32(word[:])
+	}
+	return sum
+}
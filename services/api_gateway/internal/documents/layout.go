@@ -0,0 +1,234 @@
+package documents
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A4 in points, with the margins of the printed text
+const (
+	pageWidth    = 595.0
+	pageHeight   = 842.0
+	margin       = 50.0
+	textWidth    = pageWidth - 2*margin
+	headerHeight = 72.0
+	footerHeight = 36.0
+	titleSize    = 17.0
+	headingSize  = 12.0
+	textSize     = 10.0
+	noteSize     = 8.0
+	brandSize    = 14.0
+	lineSpacing  = 1.35
+	labelWidth   = 130.0
+	indent       = 14.0
+)
+
+const (
+	fontRegular = iota
+	fontBold
+)
+
+var (
+	black = color{0, 0, 0}
+	muted = color{0.4, 0.4, 0.4}
+	faint = color{0.8, 0.8, 0.8}
+)
+
+// layout sets the blocks of a document on the pages one after the other, starting a new page when one is full. Every
+// page carries the clinic's letterhead at the top and its footer with the page number at the bottom.
+type layout struct {
+	canvas *canvas
+	brand  branding
+	title  string
+	y      float64
+}
+
+func newLayout(brand branding, fonts ...*font) *layout {
+	l := &layout{canvas: newCanvas(fonts...), brand: brand}
+	l.newPage()
+	return l
+}
+
+// Title writes the title of the document, the first one also names the PDF
+func (l *layout) Title(text string) string {
+	if l.title == "" {
+		l.title = text
+	}
+	l.paragraph(text, fontBold, titleSize, l.brand.accent, margin, textWidth)
+	l.Space()
+	return ""
+}
+
+// Heading starts a section, underlined in the accent color. A heading is never left alone at the bottom of a page.
+func (l *layout) Heading(text string) string {
+	l.ensure(headingSize*lineSpacing*2 + textSize*lineSpacing*3)
+	l.Space()
+	l.paragraph(text, fontBold, headingSize, l.brand.accent, margin, textWidth)
+	l.y -= 3
+	l.canvas.line(l.page(), margin, l.y, pageWidth-margin, l.y, 0.5, faint)
+	l.y -= 4
+	return ""
+}
+
+// Text writes a paragraph
+func (l *layout) Text(text string) string {
+	l.paragraph(text, fontRegular, textSize, black, margin, textWidth)
+	return ""
+}
+
+// Item writes an entry of a list with a bullet
+func (l *layout) Item(text string) string {
+	l.ensure(textSize * lineSpacing)
+	l.canvas.text(l.page(), margin+4, l.y-textSize*lineSpacing, fontRegular, textSize, l.brand.accent, "•")
+	l.paragraph(text, fontRegular, textSize, black, margin+indent, textWidth-indent)
+	return ""
+}
+
+// Field writes a labeled value, the label in bold in a column of its own
+func (l *layout) Field(label string, value interface{}) string {
+	text := fmt.Sprint(value)
+	labelLines := wrap(l.canvas.fonts[fontBold], label, textSize, labelWidth-8)
+	valueLines := wrap(l.canvas.fonts[fontRegular], text, textSize, textWidth-labelWidth)
+	l.ensure(textSize * lineSpacing * float64(min(len(valueLines), 3)))
+
+	top := l.y
+	l.lines(labelLines, fontBold, textSize, black, margin)
+	bottom := l.y
+	l.y = top
+	if l.lines(valueLines, fontRegular, textSize, black, margin+labelWidth) {
+		// The value went on to the next page, the label stays where it was written
+		return ""
+	}
+	l.y = min(l.y, bottom)
+	return ""
+}
+
+// Note writes a paragraph in small gray type, for remarks and legal notices
+func (l *layout) Note(text string) string {
+	l.paragraph(text, fontRegular, noteSize, muted, margin, textWidth)
+	return ""
+}
+
+// Rule draws a line across the page
+func (l *layout) Rule() string {
+	l.ensure(textSize)
+	l.y -= textSize / 2
+	l.canvas.line(l.page(), margin, l.y, pageWidth-margin, l.y, 0.5, faint)
+	l.y -= textSize / 2
+	return ""
+}
+
+// Space leaves an empty line
+func (l *layout) Space() string {
+	l.y -= textSize * lineSpacing
+	return ""
+}
+
+// Signature leaves room for a signature at the right of the page, over a line with the label under it
+func (l *layout) Signature(label string) string {
+	const width = 200.0
+	l.ensure(textSize * lineSpacing * 6)
+	l.y -= textSize * lineSpacing * 4
+	l.canvas.line(l.page(), pageWidth-margin-width, l.y, pageWidth-margin, l.y, 0.5, black)
+	l.paragraph(label, fontRegular, noteSize, muted, pageWidth-margin-width, width)
+	return ""
+}
+
+func (l *layout) page() int {
+	return len(l.canvas.pages) - 1
+}
+
+// newPage starts a page with the letterhead: the clinic's name, its contacts and a rule in the accent color
+func (l *layout) newPage() {
+	l.canvas.addPage()
+	page := l.page()
+
+	y := pageHeight - margin + 10
+	l.canvas.text(page, margin, y-brandSize, fontBold, brandSize, l.brand.accent, l.brand.name)
+	y -= brandSize * lineSpacing
+	for _, contact := range l.brand.contacts {
+		l.canvas.text(page, margin, y-noteSize, fontRegular, noteSize, muted, contact)
+		y -= noteSize * lineSpacing
+	}
+	l.canvas.rect(page, margin, pageHeight-margin-headerHeight+18, textWidth, 1.5, l.brand.accent)
+
+	l.y = pageHeight - margin - headerHeight
+}
+
+// ensure starts a new page unless there is room for height on the current one
+func (l *layout) ensure(height float64) {
+	if l.y-height < margin+footerHeight {
+		l.newPage()
+	}
+}
+
+func (l *layout) paragraph(text string, fontIndex int, size float64, fill color, x, width float64) {
+	l.lines(wrap(l.canvas.fonts[fontIndex], text, size, width), fontIndex, size, fill, x)
+}
+
+// lines writes the lines one under the other and tells whether a page was started on the way
+func (l *layout) lines(lines []string, fontIndex int, size float64, fill color, x float64) bool {
+	broke := false
+	for _, line := range lines {
+		if l.y-size*lineSpacing < margin+footerHeight {
+			l.newPage()
+			broke = true
+		}
+		l.y -= size * lineSpacing
+		if line != "" {
+			l.canvas.text(l.page(), x, l.y, fontIndex, size, fill, line)
+		}
+	}
+	return broke
+}
+
+// finish writes the footer of every page, now that the number of pages is known
+func (l *layout) finish() {
+	pages := len(l.canvas.pages)
+	regular := l.canvas.fonts[fontRegular]
+	for page := 0; page < pages; page++ {
+		y := margin + footerHeight - 12
+		l.canvas.line(page, margin, y, pageWidth-margin, y, 0.5, faint)
+		for _, line := range wrap(regular, l.brand.footer, noteSize, textWidth-80) {
+			y -= noteSize * lineSpacing
+			l.canvas.text(page, margin, y, fontRegular, noteSize, muted, line)
+		}
+		number := fmt.Sprintf("Page %d of %d", page+1, pages)
+		l.canvas.text(page, pageWidth-margin-regular.width(number, noteSize), margin+footerHeight-12-noteSize*lineSpacing, fontRegular, noteSize, muted, number)
+	}
+}
+
+// wrap breaks a text into the lines that fit the width, measured with the font. Line breaks in the text are kept and
+// a word longer than a line is cut.
+func wrap(f *font, text string, size float64, width float64) []string {
+	lines := []string{}
+	for _, paragraph := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		current := ""
+		for _, word := range strings.Fields(paragraph) {
+			for f.width(word, size) > width {
+				if current != "" {
+					lines = append(lines, current)
+					current = ""
+				}
+				runes := []rune(word)
+				cut := 1
+				for cut < len(runes) && f.width(string(runes[:cut+1]), size) <= width {
+					cut++
+				}
+				lines = append(lines, string(runes[:cut]))
+				word = string(runes[cut:])
+			}
+			switch {
+			case current == "":
+				current = word
+			case f.width(current+" "+word, size) <= width:
+				current += " " + word
+			default:
+				lines = append(lines, current)
+				current = word
+			}
+		}
+		lines = append(lines, current)
+	}
+	return lines
+}
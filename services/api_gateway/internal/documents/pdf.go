@@ -0,0 +1,188 @@
+package documents
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// color is an RGB color with components from 0 to 1
+type color [3]float64
+
+// canvas collects the drawing operations of the pages and writes them as a PDF. Text is set in embedded TrueType fonts
+// addressed by glyph ID, so any character the fonts have can be drawn, and the fonts are embedded with the used glyphs
+// only.
+type canvas struct {
+	fonts []*font
+	used  []map[uint16]rune
+	pages []*bytes.Buffer
+}
+
+func newCanvas(fonts ...*font) *canvas {
+	c := &canvas{fonts: fonts}
+	for range fonts {
+		c.used = append(c.used, map[uint16]rune{})
+	}
+	return c
+}
+
+func (c *canvas) addPage() {
+	c.pages = append(c.pages, &bytes.Buffer{})
+}
+
+// text draws a line of text on a page, from its baseline at x, y
+func (c *canvas) text(page int, x, y float64, fontIndex int, size float64, fill color, text string) {
+	f := c.fonts[fontIndex]
+	var glyphs strings.Builder
+	for _, r := range text {
+		glyph := f.glyph(r)
+		if _, ok := c.used[fontIndex][glyph]; !ok {
+			c.used[fontIndex][glyph] = r
+		}
+		fmt.Fprintf(&glyphs, "%04X", glyph)
+	}
+	fmt.Fprintf(c.pages[page], "BT /F%d %.1f Tf %.3f %.3f %.3f rg %.2f %.2f Td <%s> Tj ET\n",
+		fontIndex+1, size, fill[0], fill[1], fill[2], x, y, glyphs.String())
+}
+
+// line draws a straight line on a page
+func (c *canvas) line(page int, x1, y1, x2, y2, width float64, stroke color) {
+	fmt.Fprintf(c.pages[page], "%.3f %.3f %.3f RG %.2f w %.2f %.2f m %.2f %.2f l S\n",
+		stroke[0], stroke[1], stroke[2], width, x1, y1, x2, y2)
+}
+
+// rect fills a rectangle on a page, from its lower left corner
+func (c *canvas) rect(page int, x, y, width, height float64, fill color) {
+	fmt.Fprintf(c.pages[page], "%.3f %.3f %.3f rg %.2f %.2f %.2f %.2f re f\n", fill[0], fill[1], fill[2], x, y, width, height)
+}
+
+// writeTo writes the PDF. The title and the author are kept in the document information.
+func (c *canvas) writeTo(w io.Writer, title, author string, created time.Time) (int64, error) {
+	var buf bytes.Buffer
+	offsets := []int{}
+	object := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+	stream := func(dictionary string, data []byte) error {
+		var compressed bytes.Buffer
+		zw := zlib.NewWriter(&compressed)
+		if _, err := zw.Write(data); err != nil {
+			return err
+		}
+		if err := zw.Close(); err != nil {
+			return err
+		}
+		object(fmt.Sprintf("<< %s /Filter /FlateDecode /Length %d >>\nstream\n%s\nendstream", dictionary, compressed.Len(), compressed.String()))
+		return nil
+	}
+
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// Objects 1 to 3 are the catalog, the page tree and the information, each font takes five objects and each page
+	// its content stream and itself
+	fontObjects := 4
+	pageObjects := fontObjects + 5*len(c.fonts)
+	kids := make([]string, len(c.pages))
+	for i := range c.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", pageObjects+2*i+1)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(c.pages)))
+	object(fmt.Sprintf("<< /Title %s /Author %s /Producer %s /CreationDate (D:%s) >>",
+		textString(title), textString(author), textString(author), created.UTC().Format("20060102150405Z")))
+
+	fontResources := []string{}
+	for i, f := range c.fonts {
+		first := fontObjects + 5*i
+		// A subset is named with a tag of six capital letters
+		baseFont := fmt.Sprintf("DOCSU%c+%s", 'A'+i, f.name)
+		fontResources = append(fontResources, fmt.Sprintf("/F%d %d 0 R", i+1, first))
+
+		glyphs := make([]int, 0, len(c.used[i]))
+		for glyph := range c.used[i] {
+			glyphs = append(glyphs, int(glyph))
+		}
+		sort.Ints(glyphs)
+
+		var widths, toUnicode strings.Builder
+		for _, glyph := range glyphs {
+			fmt.Fprintf(&widths, "%d [%d] ", glyph, f.scale(int(f.advances[glyph])))
+		}
+		toUnicode.WriteString("/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n" +
+			"/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n/CMapName /Adobe-Identity-UCS def\n/CMapType 2 def\n" +
+			"1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n")
+		// A bfchar block holds at most 100 mappings
+		for start := 0; start < len(glyphs); start += 100 {
+			block := glyphs[start:min(start+100, len(glyphs))]
+			fmt.Fprintf(&toUnicode, "%d beginbfchar\n", len(block))
+			for _, glyph := range block {
+				fmt.Fprintf(&toUnicode, "<%04X> <%s>\n", glyph, utf16Hex(string(c.used[i][uint16(glyph)])))
+			}
+			toUnicode.WriteString("endbfchar\n")
+		}
+		toUnicode.WriteString("endcmap\nCMapName currentdict /CMap defineresource pop\nend\nend\n")
+
+		subset, err := f.subset(usedGlyphs(c.used[i]))
+		if err != nil {
+			return 0, fmt.Errorf("embedding font %s: %w", f.name, err)
+		}
+
+		object(fmt.Sprintf("<< /Type /Font /Subtype /Type0 /BaseFont /%s /Encoding /Identity-H /DescendantFonts [%d 0 R] /ToUnicode %d 0 R >>",
+			baseFont, first+1, first+4))
+		object(fmt.Sprintf("<< /Type /Font /Subtype /CIDFontType2 /BaseFont /%s /CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> /FontDescriptor %d 0 R /CIDToGIDMap /Identity /W [%s] >>",
+			baseFont, first+2, widths.String()))
+		object(fmt.Sprintf("<< /Type /FontDescriptor /FontName /%s /Flags 32 /FontBBox [%d %d %d %d] /ItalicAngle 0 /Ascent %d /Descent %d /CapHeight %d /StemV 80 /FontFile2 %d 0 R >>",
+			baseFont, f.scale(f.bbox[0]), f.scale(f.bbox[1]), f.scale(f.bbox[2]), f.scale(f.bbox[3]), f.scale(f.ascent), f.scale(f.descent), f.scale(f.ascent), first+3))
+		if err := stream(fmt.Sprintf("/Length1 %d", len(subset)), subset); err != nil {
+			return 0, err
+		}
+		if err := stream("", []byte(toUnicode.String())); err != nil {
+			return 0, err
+		}
+	}
+
+	for _, page := range c.pages {
+		if err := stream("", page.Bytes()); err != nil {
+			return 0, err
+		}
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] /Contents %d 0 R /Resources << /Font << %s >> >> >>",
+			pageWidth, pageHeight, len(offsets), strings.Join(fontResources, " ")))
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R /Info 3 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return buf.WriteTo(w)
+}
+
+func usedGlyphs(used map[uint16]rune) map[uint16]bool {
+	glyphs := make(map[uint16]bool, len(used))
+	for glyph := range used {
+		glyphs[glyph] = true
+	}
+	return glyphs
+}
+
+// textString writes text as a PDF string in UTF-16, which the document information needs for characters outside
+// Latin-1
+func textString(text string) string {
+	return "<FEFF" + utf16Hex(text) + ">"
+}
+
+func utf16Hex(text string) string {
+	var b strings.Builder
+	for _, unit := range utf16.Encode([]rune(text)) {
+		fmt.Fprintf(&b, "%04X", unit)
+	}
+	return b.String()
+}
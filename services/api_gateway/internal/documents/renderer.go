@@ -0,0 +1,174 @@
+package documents
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"io"
+	"log"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	// The documents are dated in the clinic's time zone without relying on the zone database of the host
+	_ "time/tzdata"
+
+	"github.com/mihnea1711/POS_Project/services/gateway/pkg/config"
+)
+
+// The templates of the documents, by file name
+const (
+	VisitSummary = "visit_summary.tmpl"
+	Prescription = "prescription.tmpl"
+)
+
+// DejaVu Sans covers the Romanian letters and the medical symbols the documents need, see fonts/LICENSE
+var (
+	//go:embed fonts/DejaVuSans.ttf
+	regularFontData []byte
+	//go:embed fonts/DejaVuSans-Bold.ttf
+	boldFontData []byte
+)
+
+// branding is the letterhead and the footer of the clinic, printed on every page
+type branding struct {
+	name     string
+	contacts []string
+	footer   string
+	accent   color
+}
+
+// Renderer turns templates into PDF documents. A template is a text/template that lays out the document with the
+// layout functions (title, heading, text, item, field, note, rule, space, signature) in the order the blocks are
+// printed, any other text it writes is ignored. The values are drawn as they are, so nothing in them is read as
+// layout. The fonts are bundled and the PDF is written without any external tool.
+type Renderer struct {
+	templates *template.Template
+	clinic    config.ClinicConfig
+	brand     branding
+	location  *time.Location
+	fonts     []*font
+}
+
+func NewRenderer(documentsConfig config.DocumentsConfig) (*Renderer, error) {
+	regular, err := parseFont("DejaVuSans", regularFontData)
+	if err != nil {
+		return nil, fmt.Errorf("error reading the regular font: %w", err)
+	}
+	bold, err := parseFont("DejaVuSans-Bold", boldFontData)
+	if err != nil {
+		return nil, fmt.Errorf("error reading the bold font: %w", err)
+	}
+
+	accent, err := parseColor(documentsConfig.AccentColor)
+	if err != nil {
+		return nil, err
+	}
+	location, err := time.LoadLocation(documentsConfig.TimeZone)
+	if err != nil {
+		return nil, fmt.Errorf("error loading the time zone of the documents: %w", err)
+	}
+
+	renderer := &Renderer{
+		clinic:   documentsConfig.Clinic,
+		location: location,
+		fonts:    []*font{regular, bold},
+		brand: branding{
+			name:     documentsConfig.Clinic.Name,
+			contacts: clinicContacts(documentsConfig.Clinic),
+			footer:   documentsConfig.Footer,
+			accent:   accent,
+		},
+	}
+
+	// The layout functions are bound to the document being rendered, these only declare them for parsing
+	templates, err := template.New("documents").Funcs(renderer.funcs(&layout{})).ParseGlob(filepath.Join(documentsConfig.Templates, "*.tmpl"))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing the document templates: %w", err)
+	}
+	renderer.templates = templates
+
+	log.Printf("[GATEWAY] Document templates loaded from %s: %s", documentsConfig.Templates, templates.DefinedTemplates())
+	return renderer, nil
+}
+
+// Render lays out the named template with data and returns the PDF
+func (r *Renderer) Render(name string, data interface{}) ([]byte, error) {
+	document := newLayout(r.brand, r.fonts...)
+
+	templates, err := r.templates.Clone()
+	if err != nil {
+		return nil, err
+	}
+	if err := templates.Funcs(r.funcs(document)).ExecuteTemplate(io.Discard, name, data); err != nil {
+		return nil, fmt.Errorf("error laying out %s: %w", name, err)
+	}
+	document.finish()
+
+	var buf bytes.Buffer
+	if _, err := document.canvas.writeTo(&buf, document.title, r.brand.name, time.Now()); err != nil {
+		return nil, fmt.Errorf("error writing %s: %w", name, err)
+	}
+	return buf.Bytes(), nil
+}
+
+func (r *Renderer) funcs(document *layout) template.FuncMap {
+	return template.FuncMap{
+		"title":     document.Title,
+		"heading":   document.Heading,
+		"text":      document.Text,
+		"item":      document.Item,
+		"field":     document.Field,
+		"note":      document.Note,
+		"rule":      document.Rule,
+		"space":     document.Space,
+		"signature": document.Signature,
+		"clinic":    func() config.ClinicConfig { return r.clinic },
+		"date":      r.date,
+		"join":      strings.Join,
+	}
+}
+
+// date formats a time.Time or a *time.Time in the clinic's time zone, a missing time is empty
+func (r *Renderer) date(layout string, value interface{}) string {
+	switch t := value.(type) {
+	case time.Time:
+		return t.In(r.location).Format(layout)
+	case *time.Time:
+		if t == nil {
+			return ""
+		}
+		return t.In(r.location).Format(layout)
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// clinicContacts puts the address on a line and the other contacts together on the next one
+func clinicContacts(clinic config.ClinicConfig) []string {
+	contacts := []string{}
+	if clinic.Address != "" {
+		contacts = append(contacts, clinic.Address)
+	}
+	reach := []string{}
+	for _, contact := range []string{clinic.Phone, clinic.Email, clinic.Website} {
+		if contact != "" {
+			reach = append(reach, contact)
+		}
+	}
+	if len(reach) > 0 {
+		contacts = append(contacts, strings.Join(reach, "  ·  "))
+	}
+	return contacts
+}
+
+// parseColor reads a color written as #RRGGBB
+func parseColor(hex string) (color, error) {
+	value, err := strconv.ParseUint(strings.TrimPrefix(hex, "#"), 16, 32)
+	if err != nil || len(strings.TrimPrefix(hex, "#")) != 6 {
+		return color{}, fmt.Errorf("invalid accent color %q, expected #RRGGBB", hex)
+	}
+	return color{float64(value>>16&0xff) / 255, float64(value>>8&0xff) / 255, float64(value&0xff) / 255}, nil
+}
@@ -0,0 +1,73 @@
+package export
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+// FormatVersion is raised whenever the layout of the bundle changes, so readers of old exports can tell them apart
+const FormatVersion = "1"
+
+// Section is a part of the data held about a patient, as returned by the module keeping it
+type Section struct {
+	Name    string
+	Source  string
+	Records int
+	Data    interface{}
+}
+
+// ManifestEntry describes a section of the bundle. The checksum is the SHA-256 of the JSON encoding of the section data.
+type ManifestEntry struct {
+	Name     string `json:"name"`
+	Source   string `json:"source"`
+	Records  int    `json:"records"`
+	Checksum string `json:"sha256"`
+}
+
+type Manifest struct {
+	ExportID      string          `json:"exportID"`
+	PatientID     int             `json:"patientID"`
+	FormatVersion string          `json:"formatVersion"`
+	GeneratedAt   time.Time       `json:"generatedAt"`
+	Sections      []ManifestEntry `json:"sections"`
+}
+
+// Bundle is the machine-readable export of everything held about a patient
+type Bundle struct {
+	Manifest Manifest                   `json:"manifest"`
+	Data     map[string]json.RawMessage `json:"data"`
+}
+
+// NewBundle assembles the sections in the given order and lists them in the manifest
+func NewBundle(exportID string, patientID int, sections []Section) (*Bundle, error) {
+	bundle := &Bundle{
+		Manifest: Manifest{
+			ExportID:      exportID,
+			PatientID:     patientID,
+			FormatVersion: FormatVersion,
+			GeneratedAt:   time.Now().UTC().Truncate(time.Second),
+			Sections:      make([]ManifestEntry, 0, len(sections)),
+		},
+		Data: make(map[string]json.RawMessage, len(sections)),
+	}
+
+	for _, section := range sections {
+		data, err := json.Marshal(section.Data)
+		if err != nil {
+			return nil, err
+		}
+		checksum := sha256.Sum256(data)
+
+		bundle.Data[section.Name] = data
+		bundle.Manifest.Sections = append(bundle.Manifest.Sections, ManifestEntry{
+			Name:     section.Name,
+			Source:   section.Source,
+			Records:  section.Records,
+			Checksum: hex.EncodeToString(checksum[:]),
+		})
+	}
+
+	return bundle, nil
+}
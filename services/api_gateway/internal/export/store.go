@@ -0,0 +1,211 @@
+package export
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mihnea1711/POS_Project/services/gateway/pkg/config"
+)
+
+const (
+	StatusPending   = "pending"
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+)
+
+const (
+	bundleFilePermissions = 0600
+	bundleDirPermissions  = 0700
+	jobIDBytes            = 16
+)
+
+// ErrJobNotFound is returned for unknown and expired exports
+var ErrJobNotFound = errors.New("export not found or expired")
+
+// Job is an export requested for a patient. The bundle can be downloaded once the job is completed, until it expires.
+type Job struct {
+	ID          string     `json:"exportID"`
+	PatientID   int        `json:"patientID"`
+	RequestedBy int        `json:"requestedBy"`
+	Status      string     `json:"status"`
+	Error       string     `json:"error,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+	ExpiresAt   *time.Time `json:"expiresAt,omitempty"`
+	DownloadURL string     `json:"downloadURL,omitempty"`
+}
+
+// Store keeps the export jobs in memory and their bundles on disk. Expired bundles are removed whenever a new export
+// is started, a restart of the gateway forgets the jobs and the bundles are removed with the next export.
+type Store struct {
+	mu        sync.Mutex
+	jobs      map[string]*Job
+	directory string
+	retention time.Duration
+}
+
+func NewStore(exportConfig config.ExportConfig) *Store {
+	return &Store{
+		jobs:      make(map[string]*Job),
+		directory: exportConfig.Directory,
+		retention: time.Duration(exportConfig.RetentionHours) * time.Hour,
+	}
+}
+
+// Start records a new export of a patient. If one is already pending for the patient it is returned with false.
+func (s *Store) Start(patientID, requestedBy int) (*Job, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.prune(time.Now())
+
+	for _, job := range s.jobs {
+		if job.PatientID == patientID && job.Status == StatusPending {
+			copied := *job
+			return &copied, false, nil
+		}
+	}
+
+	id, err := newJobID()
+	if err != nil {
+		return nil, false, err
+	}
+
+	job := &Job{
+		ID:          id,
+		PatientID:   patientID,
+		RequestedBy: requestedBy,
+		Status:      StatusPending,
+		CreatedAt:   time.Now().UTC().Truncate(time.Second),
+	}
+	s.jobs[id] = job
+
+	copied := *job
+	return &copied, true, nil
+}
+
+// Get returns a copy of an export job
+func (s *Store) Get(id string) (*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[id]
+	if !ok || job.expired(time.Now()) {
+		return nil, ErrJobNotFound
+	}
+
+	copied := *job
+	return &copied, nil
+}
+
+// Complete writes the bundle of an export and makes it downloadable from downloadURL
+func (s *Store) Complete(id string, bundle *Bundle, downloadURL string) error {
+	data, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode the bundle: %w", err)
+	}
+	if err := os.MkdirAll(s.directory, bundleDirPermissions); err != nil {
+		return fmt.Errorf("failed to create the export directory: %w", err)
+	}
+	if err := os.WriteFile(s.bundlePath(id), data, bundleFilePermissions); err != nil {
+		return fmt.Errorf("failed to write the bundle: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[id]
+	if !ok {
+		os.Remove(s.bundlePath(id))
+		return ErrJobNotFound
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	expiresAt := now.Add(s.retention)
+	job.Status = StatusCompleted
+	job.CompletedAt = &now
+	job.ExpiresAt = &expiresAt
+	job.DownloadURL = downloadURL
+	return nil
+}
+
+// Fail records why an export could not be assembled, the patient may request a new one
+func (s *Store) Fail(id string, cause error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[id]
+	if !ok {
+		return
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	expiresAt := now.Add(s.retention)
+	job.Status = StatusFailed
+	job.Error = cause.Error()
+	job.CompletedAt = &now
+	job.ExpiresAt = &expiresAt
+}
+
+// Open returns the bundle file of a completed export, the caller closes it
+func (s *Store) Open(id string) (*os.File, error) {
+	job, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if job.Status != StatusCompleted {
+		return nil, ErrJobNotFound
+	}
+	return os.Open(s.bundlePath(id))
+}
+
+// prune forgets the expired jobs and removes their bundles, together with bundles left behind by a previous run
+func (s *Store) prune(now time.Time) {
+	for id, job := range s.jobs {
+		if job.expired(now) {
+			delete(s.jobs, id)
+			if err := os.Remove(s.bundlePath(id)); err != nil && !os.IsNotExist(err) {
+				log.Printf("[GATEWAY] Failed to remove expired export %s: %v", id, err)
+			}
+		}
+	}
+
+	entries, err := os.ReadDir(s.directory)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		id := strings.TrimSuffix(entry.Name(), ".json")
+		if _, ok := s.jobs[id]; !ok {
+			os.Remove(filepath.Join(s.directory, entry.Name()))
+		}
+	}
+}
+
+func (s *Store) bundlePath(id string) string {
+	return filepath.Join(s.directory, id+".json")
+}
+
+func (j *Job) expired(now time.Time) bool {
+	return j.ExpiresAt != nil && now.After(*j.ExpiresAt)
+}
+
+func newJobID() (string, error) {
+	buf := make([]byte, jobIDBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate export ID: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,92 @@
+package fhir
+
+import (
+	"time"
+)
+
+const (
+	SearchModeMatch   = "match"
+	SearchModeInclude = "include"
+)
+
+// Issue codes of the OperationOutcome errors returned by the gateway
+const (
+	IssueInvalid      = "invalid"
+	IssueNotFound     = "not-found"
+	IssueNotSupported = "not-supported"
+	IssueForbidden    = "forbidden"
+	IssueException    = "exception"
+)
+
+// SearchSet assembles the Bundle answering a search. A resource is listed once, a match that is also
+// included by another match keeps its match entry.
+type SearchSet struct {
+	baseURL string
+	bundle  Bundle
+	listed  map[string]bool
+	matches []Resource
+}
+
+func NewSearchSet(baseURL string) *SearchSet {
+	return &SearchSet{
+		baseURL: baseURL,
+		bundle: Bundle{
+			ResourceType: ResourceBundle,
+			Type:         "searchset",
+			Meta:         &Meta{LastUpdated: time.Now().UTC().Format(dateTimeFormat)},
+			Entry:        []BundleEntry{},
+		},
+		listed: make(map[string]bool),
+	}
+}
+
+func (s *SearchSet) AddMatch(resource Resource) {
+	s.add(resource, SearchModeMatch)
+}
+
+func (s *SearchSet) AddInclude(resource Resource) {
+	s.add(resource, SearchModeInclude)
+}
+
+// Has tells whether the resource is already listed, so it does not have to be fetched again
+func (s *SearchSet) Has(resourceType, id string) bool {
+	return s.listed[resourceType+"/"+id]
+}
+
+// Matches returns the resources matching the search, without the included ones
+func (s *SearchSet) Matches() []Resource {
+	return s.matches
+}
+
+func (s *SearchSet) Link(relation, url string) {
+	s.bundle.Link = append(s.bundle.Link, BundleLink{Relation: relation, URL: url})
+}
+
+func (s *SearchSet) Bundle() *Bundle {
+	return &s.bundle
+}
+
+func (s *SearchSet) add(resource Resource, mode string) {
+	key := resource.Type() + "/" + resource.ResourceID()
+	if s.listed[key] {
+		return
+	}
+	s.listed[key] = true
+	if mode == SearchModeMatch {
+		s.matches = append(s.matches, resource)
+	}
+
+	s.bundle.Entry = append(s.bundle.Entry, BundleEntry{
+		FullURL:  s.baseURL + "/" + key,
+		Resource: resource,
+		Search:   &BundleSearch{Mode: mode},
+	})
+}
+
+// NewOperationOutcome describes an error with a single issue
+func NewOperationOutcome(code, diagnostics string) *OperationOutcome {
+	return &OperationOutcome{
+		ResourceType: ResourceOperationOutcome,
+		Issue:        []OperationOutcomeIssue{{Severity: "error", Code: code, Diagnostics: diagnostics}},
+	}
+}
@@ -0,0 +1,243 @@
+package fhir
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/mihnea1711/POS_Project/services/gateway/internal/models"
+	"github.com/mihnea1711/POS_Project/services/gateway/pkg/utils"
+)
+
+const (
+	ResourcePatient          = "Patient"
+	ResourcePractitioner     = "Practitioner"
+	ResourceAppointment      = "Appointment"
+	ResourceEncounter        = "Encounter"
+	ResourceObservation      = "Observation"
+	ResourceDiagnosticReport = "DiagnosticReport"
+	ResourceBundle           = "Bundle"
+	ResourceOperationOutcome = "OperationOutcome"
+)
+
+const (
+	// CNPSystem names the Romanian personal numeric code in patient identifiers
+	CNPSystem = "urn:ro:cnp"
+
+	dateFormat     = "2006-01-02"
+	dateTimeFormat = time.RFC3339
+
+	actCodeSystem = "http://terminology.hl7.org/CodeSystem/v3-ActCode"
+	icd10System   = "http://hl7.org/fhir/sid/icd-10"
+)
+
+// PatientReference and PractitionerReference point to the resources by their ID in the owning module
+func PatientReference(patientID int) Reference {
+	return Reference{Reference: ResourcePatient + "/" + strconv.Itoa(patientID)}
+}
+
+func PractitionerReference(doctorID int) Reference {
+	return Reference{Reference: ResourcePractitioner + "/" + strconv.Itoa(doctorID)}
+}
+
+// FromPatient maps a patient. The first name of a patient is the family name, as it is written in Romanian records.
+func FromPatient(patient models.PatientData) *Patient {
+	resource := &Patient{
+		ResourceType: ResourcePatient,
+		ID:           strconv.Itoa(patient.IDPatient),
+		Active:       patient.IsActive,
+		Name:         humanNames(patient.FirstName, patient.SecondName),
+		Telecom:      telecom(patient.PhoneNumber, patient.Email),
+		Gender:       gender(patient.Sex),
+	}
+	if patient.CNP != "" {
+		resource.Identifier = []Identifier{{System: CNPSystem, Value: patient.CNP}}
+	}
+	if !patient.BirthDay.IsZero() {
+		resource.BirthDate = patient.BirthDay.Format(dateFormat)
+	}
+	return resource
+}
+
+// FromDoctor maps a doctor, the specialization becomes the qualification
+func FromDoctor(doctor models.DoctorData) *Practitioner {
+	resource := &Practitioner{
+		ResourceType: ResourcePractitioner,
+		ID:           strconv.Itoa(doctor.IDDoctor),
+		Active:       doctor.IsActive,
+		Name:         humanNames(doctor.FirstName, doctor.SecondName),
+		Telecom:      telecom(doctor.PhoneNumber, doctor.Email),
+	}
+	if doctor.SSpecialization != "" {
+		resource.Qualification = []Qualification{{Code: CodeableConcept{Text: string(doctor.SSpecialization)}}}
+	}
+	return resource
+}
+
+// FromAppointment maps an appointment. Appointments are booked for a day, so they span the whole day.
+func FromAppointment(appointment models.AppointmentData) *Appointment {
+	participantStatus := "accepted"
+	if appointment.Status == utils.APPOINTMENT_STATUS_SCHEDULED {
+		participantStatus = "needs-action"
+	}
+
+	day := appointment.Date.UTC().Truncate(24 * time.Hour)
+	return &Appointment{
+		ResourceType: ResourceAppointment,
+		ID:           strconv.Itoa(appointment.IDProgramare),
+		Status:       AppointmentStatus(string(appointment.Status)),
+		Start:        day.Format(dateTimeFormat),
+		End:          day.AddDate(0, 0, 1).Format(dateTimeFormat),
+		Participant: []AppointmentParticipant{
+			{Actor: PatientReference(appointment.IDPatient), Status: participantStatus},
+			{Actor: PractitionerReference(appointment.IDDoctor), Status: participantStatus},
+		},
+	}
+}
+
+// FromConsultation maps a consultation to the encounter, an observation for every investigation and a diagnostic report
+// concluding with the diagnostic and the ICD-10 codes. The report shares the ID of the encounter, the observations add their position to it.
+func FromConsultation(consultation models.ConsultationData) (*Encounter, []*Observation, *DiagnosticReport) {
+	id := consultation.IDConsultation.Hex()
+	effective := consultation.Date.UTC().Format(dateTimeFormat)
+	subject := PatientReference(consultation.IDPatient)
+	performer := PractitionerReference(consultation.IDDoctor)
+	encounterReference := &Reference{Reference: ResourceEncounter + "/" + id}
+
+	encounter := &Encounter{
+		ResourceType: ResourceEncounter,
+		ID:           id,
+		Status:       "finished",
+		Class:        Coding{System: actCodeSystem, Code: "AMB", Display: "ambulatory"},
+		Subject:      subject,
+		Participant:  []EncounterParticipant{{Individual: performer}},
+		Period:       &Period{Start: effective},
+	}
+
+	observations := make([]*Observation, 0, len(consultation.Investigations))
+	results := make([]Reference, 0, len(consultation.Investigations))
+	for i, investigation := range consultation.Investigations {
+		status := ObservationStatus(investigation)
+
+		observation := &Observation{
+			ResourceType:      ResourceObservation,
+			ID:                ObservationID(id, i),
+			Status:            status,
+			Code:              CodeableConcept{Text: investigation.Name},
+			Subject:           subject,
+			Encounter:         encounterReference,
+			EffectiveDateTime: effective,
+			Performer:         []Reference{performer},
+			ValueString:       investigation.Result,
+		}
+		observations = append(observations, observation)
+		results = append(results, Reference{Reference: ResourceObservation + "/" + observation.ID, Display: investigation.Name})
+	}
+
+	report := &DiagnosticReport{
+		ResourceType:      ResourceDiagnosticReport,
+		ID:                id,
+		Status:            "final",
+		Code:              CodeableConcept{Text: "Consultation"},
+		Subject:           subject,
+		Encounter:         encounterReference,
+		EffectiveDateTime: effective,
+		Performer:         []Reference{performer},
+		Result:            results,
+		Conclusion:        consultation.Diagnostic,
+	}
+	if len(report.Result) == 0 {
+		report.Result = nil
+	}
+
+	// The coded diagnoses conclude the report too, the primary one first
+	for _, diagnosis := range consultation.Diagnoses {
+		code := CodeableConcept{Coding: []Coding{{System: icd10System, Code: diagnosis.Code, Display: diagnosis.Description}}, Text: diagnosis.Description}
+		if diagnosis.Primary {
+			report.ConclusionCode = append([]CodeableConcept{code}, report.ConclusionCode...)
+			continue
+		}
+		report.ConclusionCode = append(report.ConclusionCode, code)
+	}
+
+	return encounter, observations, report
+}
+
+// ObservationStatus maps the lifecycle of an investigation to the FHIR observation status. Investigations read from
+// a module without a status are final once they have a result.
+func ObservationStatus(investigation models.Investigation) string {
+	switch investigation.Status {
+	case "canceled":
+		return "cancelled"
+	case "resulted":
+		return "final"
+	case "":
+		if investigation.Result != "" {
+			return "final"
+		}
+	}
+	return "registered"
+}
+
+// ObservationID is the ID of the observation of an investigation, from the consultation ID and the investigation position
+func ObservationID(consultationID string, position int) string {
+	return fmt.Sprintf("%s-%d", consultationID, position)
+}
+
+// AppointmentStatus maps an appointment status to the FHIR one
+func AppointmentStatus(status string) string {
+	for fhirStatus, appointmentStatus := range appointmentStatuses {
+		if appointmentStatus == status {
+			return fhirStatus
+		}
+	}
+	return "pending"
+}
+
+// AppointmentStatusFromFHIR maps a FHIR appointment status back, reporting whether it has a counterpart
+func AppointmentStatusFromFHIR(status string) (string, bool) {
+	appointmentStatus, ok := appointmentStatuses[status]
+	return appointmentStatus, ok
+}
+
+var appointmentStatuses = map[string]string{
+	"pending":   utils.APPOINTMENT_STATUS_SCHEDULED,
+	"booked":    utils.APPOINTMENT_STATUS_CONFIRMED,
+	"fulfilled": utils.APPOINTMENT_STATUS_HONORED,
+	"noshow":    utils.APPOINTMENT_STATUS_NOT_PRESENT,
+	"cancelled": utils.APPOINTMENT_STATUS_CANCELED,
+}
+
+func humanNames(family, given string) []HumanName {
+	if family == "" && given == "" {
+		return nil
+	}
+
+	name := HumanName{Use: "official", Family: family, Text: family + " " + given}
+	if given != "" {
+		name.Given = []string{given}
+	}
+	return []HumanName{name}
+}
+
+func telecom(phone, email string) []ContactPoint {
+	var points []ContactPoint
+	if phone != "" {
+		points = append(points, ContactPoint{System: "phone", Value: phone, Use: "mobile"})
+	}
+	if email != "" {
+		points = append(points, ContactPoint{System: "email", Value: email})
+	}
+	return points
+}
+
+func gender(sex string) string {
+	switch sex {
+	case utils.SexMale:
+		return "male"
+	case utils.SexFemale:
+		return "female"
+	default:
+		return "unknown"
+	}
+}
@@ -0,0 +1,214 @@
+package fhir
+
+// The resources and data types below cover the subset of FHIR R4 the gateway maps its data to.
+// Empty elements are omitted, FHIR does not allow empty strings, arrays or objects.
+
+// Resource is a resource that can be returned on its own or in a Bundle
+type Resource interface {
+	Type() string
+	ResourceID() string
+}
+
+type Meta struct {
+	LastUpdated string `json:"lastUpdated,omitempty"`
+}
+
+type Identifier struct {
+	System string `json:"system,omitempty"`
+	Value  string `json:"value"`
+}
+
+type HumanName struct {
+	Use    string   `json:"use,omitempty"`
+	Family string   `json:"family,omitempty"`
+	Given  []string `json:"given,omitempty"`
+	Text   string   `json:"text,omitempty"`
+}
+
+type ContactPoint struct {
+	System string `json:"system"`
+	Value  string `json:"value"`
+	Use    string `json:"use,omitempty"`
+}
+
+type Coding struct {
+	System  string `json:"system,omitempty"`
+	Code    string `json:"code"`
+	Display string `json:"display,omitempty"`
+}
+
+type CodeableConcept struct {
+	Coding []Coding `json:"coding,omitempty"`
+	Text   string   `json:"text,omitempty"`
+}
+
+type Reference struct {
+	Reference string `json:"reference"`
+	Display   string `json:"display,omitempty"`
+}
+
+type Period struct {
+	Start string `json:"start,omitempty"`
+	End   string `json:"end,omitempty"`
+}
+
+type Patient struct {
+	ResourceType string         `json:"resourceType"`
+	ID           string         `json:"id"`
+	Identifier   []Identifier   `json:"identifier,omitempty"`
+	Active       bool           `json:"active"`
+	Name         []HumanName    `json:"name,omitempty"`
+	Telecom      []ContactPoint `json:"telecom,omitempty"`
+	Gender       string         `json:"gender,omitempty"`
+	BirthDate    string         `json:"birthDate,omitempty"`
+}
+
+type Qualification struct {
+	Code CodeableConcept `json:"code"`
+}
+
+type Practitioner struct {
+	ResourceType  string          `json:"resourceType"`
+	ID            string          `json:"id"`
+	Active        bool            `json:"active"`
+	Name          []HumanName     `json:"name,omitempty"`
+	Telecom       []ContactPoint  `json:"telecom,omitempty"`
+	Qualification []Qualification `json:"qualification,omitempty"`
+}
+
+type AppointmentParticipant struct {
+	Actor  Reference `json:"actor"`
+	Status string    `json:"status"`
+}
+
+type Appointment struct {
+	ResourceType string                   `json:"resourceType"`
+	ID           string                   `json:"id"`
+	Status       string                   `json:"status"`
+	ServiceType  []CodeableConcept        `json:"serviceType,omitempty"`
+	Start        string                   `json:"start,omitempty"`
+	End          string                   `json:"end,omitempty"`
+	Participant  []AppointmentParticipant `json:"participant"`
+}
+
+type EncounterParticipant struct {
+	Individual Reference `json:"individual"`
+}
+
+type Encounter struct {
+	ResourceType string                 `json:"resourceType"`
+	ID           string                 `json:"id"`
+	Status       string                 `json:"status"`
+	Class        Coding                 `json:"class"`
+	Subject      Reference              `json:"subject"`
+	Participant  []EncounterParticipant `json:"participant,omitempty"`
+	Period       *Period                `json:"period,omitempty"`
+}
+
+type Observation struct {
+	ResourceType      string          `json:"resourceType"`
+	ID                string          `json:"id"`
+	Status            string          `json:"status"`
+	Code              CodeableConcept `json:"code"`
+	Subject           Reference       `json:"subject"`
+	Encounter         *Reference      `json:"encounter,omitempty"`
+	EffectiveDateTime string          `json:"effectiveDateTime,omitempty"`
+	Performer         []Reference     `json:"performer,omitempty"`
+	ValueString       string          `json:"valueString,omitempty"`
+}
+
+type DiagnosticReport struct {
+	ResourceType      string            `json:"resourceType"`
+	ID                string            `json:"id"`
+	Status            string            `json:"status"`
+	Code              CodeableConcept   `json:"code"`
+	Subject           Reference         `json:"subject"`
+	Encounter         *Reference        `json:"encounter,omitempty"`
+	EffectiveDateTime string            `json:"effectiveDateTime,omitempty"`
+	Performer         []Reference       `json:"performer,omitempty"`
+	Result            []Reference       `json:"result,omitempty"`
+	Conclusion        string            `json:"conclusion,omitempty"`
+	ConclusionCode    []CodeableConcept `json:"conclusionCode,omitempty"`
+}
+
+type BundleLink struct {
+	Relation string `json:"relation"`
+	URL      string `json:"url"`
+}
+
+type BundleSearch struct {
+	Mode string `json:"mode"`
+}
+
+type BundleEntry struct {
+	FullURL  string        `json:"fullUrl"`
+	Resource Resource      `json:"resource"`
+	Search   *BundleSearch `json:"search,omitempty"`
+}
+
+type Bundle struct {
+	ResourceType string        `json:"resourceType"`
+	Type         string        `json:"type"`
+	Meta         *Meta         `json:"meta,omitempty"`
+	Link         []BundleLink  `json:"link,omitempty"`
+	Entry        []BundleEntry `json:"entry"`
+}
+
+type OperationOutcomeIssue struct {
+	Severity    string `json:"severity"`
+	Code        string `json:"code"`
+	Diagnostics string `json:"diagnostics,omitempty"`
+}
+
+type OperationOutcome struct {
+	ResourceType string                  `json:"resourceType"`
+	Issue        []OperationOutcomeIssue `json:"issue"`
+}
+
+func (p *Patient) Type() string {
+	return ResourcePatient
+}
+
+func (p *Patient) ResourceID() string {
+	return p.ID
+}
+
+func (p *Practitioner) Type() string {
+	return ResourcePractitioner
+}
+
+func (p *Practitioner) ResourceID() string {
+	return p.ID
+}
+
+func (a *Appointment) Type() string {
+	return ResourceAppointment
+}
+
+func (a *Appointment) ResourceID() string {
+	return a.ID
+}
+
+func (e *Encounter) Type() string {
+	return ResourceEncounter
+}
+
+func (e *Encounter) ResourceID() string {
+	return e.ID
+}
+
+func (o *Observation) Type() string {
+	return ResourceObservation
+}
+
+func (o *Observation) ResourceID() string {
+	return o.ID
+}
+
+func (d *DiagnosticReport) Type() string {
+	return ResourceDiagnosticReport
+}
+
+func (d *DiagnosticReport) ResourceID() string {
+	return d.ID
+}
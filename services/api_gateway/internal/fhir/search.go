@@ -0,0 +1,280 @@
+package fhir
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Control parameters of a search, accepted by every resource type
+const (
+	ParamCount   = "_count"
+	ParamPage    = "_page"
+	ParamInclude = "_include"
+	ParamFormat  = "_format"
+	ParamID      = "_id"
+)
+
+// SearchParameters lists the search parameters of every resource type the gateway serves.
+// The modules only filter on equality, so dates are matched on the day except for the birth date of patients.
+var SearchParameters = map[string][]string{
+	ResourcePatient:          {ParamID, "identifier", "name", "family", "given", "birthdate", "email", "phone", "active"},
+	ResourcePractitioner:     {ParamID, "name", "family", "email", "active"},
+	ResourceAppointment:      {ParamID, "patient", "practitioner", "actor", "date", "status"},
+	ResourceEncounter:        {ParamID, "patient", "subject", "practitioner", "participant", "date"},
+	ResourceObservation:      {ParamID, "patient", "subject", "performer", "encounter", "date"},
+	ResourceDiagnosticReport: {ParamID, "patient", "subject", "performer", "encounter", "date"},
+}
+
+// Includes lists the _include values of every resource type and the type of the resources they add
+var Includes = map[string]map[string][]string{
+	ResourceAppointment: {
+		"patient":      {ResourcePatient},
+		"practitioner": {ResourcePractitioner},
+		"actor":        {ResourcePatient, ResourcePractitioner},
+	},
+	ResourceEncounter: {
+		"patient":      {ResourcePatient},
+		"subject":      {ResourcePatient},
+		"practitioner": {ResourcePractitioner},
+		"participant":  {ResourcePractitioner},
+	},
+	ResourceObservation: {
+		"patient":   {ResourcePatient},
+		"subject":   {ResourcePatient},
+		"performer": {ResourcePractitioner},
+		"encounter": {ResourceEncounter},
+	},
+	ResourceDiagnosticReport: {
+		"patient":   {ResourcePatient},
+		"subject":   {ResourcePatient},
+		"performer": {ResourcePractitioner},
+		"encounter": {ResourceEncounter},
+		"result":    {ResourceObservation},
+	},
+}
+
+// IsSearchParameter tells whether the parameter is a search parameter of the resource type or a control parameter
+func IsSearchParameter(resourceType, name string) bool {
+	switch name {
+	case ParamCount, ParamPage, ParamInclude, ParamFormat:
+		return true
+	}
+	for _, param := range SearchParameters[resourceType] {
+		if param == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseInclude validates an _include value such as "Appointment:patient" and returns the resource types it adds.
+// The wildcard "Appointment:*" follows every reference of the resource.
+func ParseInclude(resourceType, include string) ([]string, error) {
+	parts := strings.Split(include, ":")
+	if len(parts) < 2 || parts[0] != resourceType {
+		return nil, fmt.Errorf("_include %q does not start with %s:", include, resourceType)
+	}
+
+	if parts[1] == "*" {
+		var targets []string
+		for _, types := range Includes[resourceType] {
+			targets = append(targets, types...)
+		}
+		return targets, nil
+	}
+
+	targets, ok := Includes[resourceType][parts[1]]
+	if !ok {
+		return nil, fmt.Errorf("_include %q is not supported", include)
+	}
+	// A target type, as in Appointment:actor:Patient, narrows the included resources
+	if len(parts) == 3 {
+		for _, target := range targets {
+			if target == parts[2] {
+				return []string{target}, nil
+			}
+		}
+		return nil, fmt.Errorf("_include %q does not reference %s resources", include, parts[2])
+	}
+	return targets, nil
+}
+
+// IncludedReferences returns the references of the resource to resources of the given type
+func IncludedReferences(resource Resource, targetType string) []Reference {
+	var references []Reference
+	switch r := resource.(type) {
+	case *Appointment:
+		for _, participant := range r.Participant {
+			references = append(references, participant.Actor)
+		}
+	case *Encounter:
+		references = append(references, r.Subject)
+		for _, participant := range r.Participant {
+			references = append(references, participant.Individual)
+		}
+	case *Observation:
+		references = append(references, r.Subject)
+		references = append(references, r.Performer...)
+		if r.Encounter != nil {
+			references = append(references, *r.Encounter)
+		}
+	case *DiagnosticReport:
+		references = append(references, r.Subject)
+		references = append(references, r.Performer...)
+		references = append(references, r.Result...)
+		if r.Encounter != nil {
+			references = append(references, *r.Encounter)
+		}
+	}
+
+	var matching []Reference
+	for _, reference := range references {
+		if referenceType, _, ok := SplitReference(reference.Reference); ok && referenceType == targetType {
+			matching = append(matching, reference)
+		}
+	}
+	return matching
+}
+
+// SplitReference splits a relative reference such as "Patient/12" into the resource type and ID
+func SplitReference(reference string) (string, string, bool) {
+	parts := strings.Split(reference, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+// ReferenceParam reads a reference search parameter, given either as "Patient/12" or as "12".
+// A type other than the expected ones is an error.
+func ReferenceParam(name, value string, expectedTypes ...string) (string, string, error) {
+	if !strings.Contains(value, "/") {
+		return expectedTypes[0], value, nil
+	}
+
+	resourceType, id, ok := SplitReference(value)
+	if !ok {
+		return "", "", fmt.Errorf("invalid reference %q in %s", value, name)
+	}
+	for _, expected := range expectedTypes {
+		if resourceType == expected {
+			return resourceType, id, nil
+		}
+	}
+	return "", "", fmt.Errorf("%s must reference %s resources", name, strings.Join(expectedTypes, " or "))
+}
+
+// DateParam reads a date search parameter matched on the day, only the eq prefix is supported
+func DateParam(name, value string) (time.Time, error) {
+	day, err := time.Parse(dateFormat, strings.TrimPrefix(value, "eq"))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s must be a day such as 2024-01-31, optionally prefixed by eq", name)
+	}
+	return day, nil
+}
+
+// DateRangeParam reads the values of a date search parameter that may be given as a range, such as
+// birthdate=ge1990-01-01&birthdate=lt2000-01-01. It returns the first and the last day of the range, nil when unbounded.
+func DateRangeParam(name string, values []string) (*time.Time, *time.Time, error) {
+	var from, to *time.Time
+	for _, value := range values {
+		prefix := "eq"
+		if len(value) > 2 && value[0] >= 'a' && value[0] <= 'z' {
+			prefix, value = value[:2], value[2:]
+		}
+
+		day, err := time.Parse(dateFormat, value)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s must be a day such as 2024-01-31", name)
+		}
+
+		switch prefix {
+		case "eq":
+			from, to = later(from, day), earlier(to, day)
+		case "ge":
+			from = later(from, day)
+		case "gt":
+			from = later(from, day.AddDate(0, 0, 1))
+		case "le":
+			to = earlier(to, day)
+		case "lt":
+			to = earlier(to, day.AddDate(0, 0, -1))
+		default:
+			return nil, nil, fmt.Errorf("the %s prefix of %s is not supported", prefix, name)
+		}
+	}
+	return from, to, nil
+}
+
+// CapabilityStatement describes the read and search interactions of the gateway
+func CapabilityStatement(baseURL string) map[string]interface{} {
+	types := make([]string, 0, len(SearchParameters))
+	for resourceType := range SearchParameters {
+		types = append(types, resourceType)
+	}
+	sort.Strings(types)
+
+	resources := make([]map[string]interface{}, 0, len(types))
+	for _, resourceType := range types {
+		searchParams := make([]map[string]string, 0, len(SearchParameters[resourceType]))
+		for _, param := range SearchParameters[resourceType] {
+			searchParams = append(searchParams, map[string]string{"name": param, "type": searchParamType(param)})
+		}
+
+		var includes []string
+		for include := range Includes[resourceType] {
+			includes = append(includes, resourceType+":"+include)
+		}
+		sort.Strings(includes)
+
+		resource := map[string]interface{}{
+			"type":        resourceType,
+			"interaction": []map[string]string{{"code": "read"}, {"code": "search-type"}},
+			"searchParam": searchParams,
+		}
+		if len(includes) > 0 {
+			resource["searchInclude"] = includes
+		}
+		resources = append(resources, resource)
+	}
+
+	return map[string]interface{}{
+		"resourceType":   "CapabilityStatement",
+		"status":         "active",
+		"date":           time.Now().UTC().Format(dateFormat),
+		"kind":           "instance",
+		"fhirVersion":    "4.0.1",
+		"format":         []string{"json"},
+		"implementation": map[string]string{"description": "RestInMedicine FHIR facade", "url": baseURL},
+		"rest":           []map[string]interface{}{{"mode": "server", "resource": resources}},
+	}
+}
+
+func searchParamType(param string) string {
+	switch param {
+	case ParamID, "identifier", "active", "status", "email", "phone":
+		return "token"
+	case "birthdate", "date":
+		return "date"
+	case "name", "family", "given":
+		return "string"
+	default:
+		return "reference"
+	}
+}
+
+func later(current *time.Time, day time.Time) *time.Time {
+	if current == nil || day.After(*current) {
+		return &day
+	}
+	return current
+}
+
+func earlier(current *time.Time, day time.Time) *time.Time {
+	if current == nil || day.Before(*current) {
+		return &day
+	}
+	return current
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"path"
 
 	"github.com/gorilla/mux"
 	"github.com/mihnea1711/POS_Project/services/gateway/idm"
@@ -27,6 +28,13 @@ func BlacklistMiddleware(idmClient idm.IDMClient, jwtConfig config.JWTConfig) mu
 					return
 				}
 			}
+			for _, pattern := range utils.EXCLUDED_PATH_PATTERNS {
+				if matched, _ := path.Match(pattern, r.URL.Path); matched {
+					// The path carries its own token, skip the JWT check
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
 
 			// Extract and parse the JWT token from the Authorization header
 			tokenString := ExtractJWTFromHeader(r)
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"log"
 	"net/http"
+	"strings"
 
 	"github.com/mihnea1711/POS_Project/services/gateway/internal/models"
 	"github.com/mihnea1711/POS_Project/services/gateway/pkg/utils"
@@ -15,6 +16,11 @@ type ResponseInterceptor struct {
 }
 
 func (ri *ResponseInterceptor) Write(data []byte) (int, error) {
+	// Only JSON responses carry links, files and feeds are written untouched
+	if contentType := ri.Header().Get("Content-Type"); contentType != "" && !strings.HasPrefix(contentType, "application/json") {
+		return ri.ResponseWriter.Write(data)
+	}
+
 	var responseData models.ResponseData
 	err := json.Unmarshal(data, &responseData)
 	if err == nil {
@@ -54,7 +54,12 @@ func newSanitizedResponseWriter(w http.ResponseWriter, policy *bluemonday.Policy
 }
 
 // Write method intercepts the response body and sanitizes it.
+// File downloads, feeds and exports carry a Content-Disposition header and are written untouched.
 func (sw *SanitizedResponseWriter) Write(b []byte) (int, error) {
+	if sw.Header().Get("Content-Disposition") != "" {
+		return sw.ResponseWriter.Write(b)
+	}
+
 	// Sanitize the response body using the policy
 	sanitizedBody := sw.policy.Sanitize(string(b))
 
@@ -4,6 +4,8 @@ import (
 	"reflect"
 
 	"github.com/go-playground/validator/v10"
+	"github.com/mihnea1711/POS_Project/services/gateway/internal/models"
+	"github.com/mihnea1711/POS_Project/services/gateway/pkg/utils"
 )
 
 // Register custom validation tags
@@ -18,3 +20,27 @@ func ValidateIsBool(fl validator.FieldLevel) bool {
 	kind := field.Kind()
 	return kind == reflect.Bool
 }
+
+// Custom validation function for cnp tag, checks the digits, the birth date, the county and the control digit
+func ValidateCNP(fl validator.FieldLevel) bool {
+	_, err := utils.ParseCNP(fl.Field().String())
+	return err == nil
+}
+
+// validatePatientCNPAgreement checks that the birth day and the sex of a patient, when given, match the CNP
+func validatePatientCNPAgreement(sl validator.StructLevel) {
+	patient := sl.Current().Interface().(models.PatientData)
+
+	info, err := utils.ParseCNP(patient.CNP)
+	if err != nil {
+		// Already reported by the cnp tag
+		return
+	}
+
+	if !patient.BirthDay.IsZero() && !utils.SameDay(patient.BirthDay, info.BirthDay) {
+		sl.ReportError(patient.BirthDay, "BirthDay", "birthDay", "cnpbirthday", "")
+	}
+	if patient.Sex != "" && info.Sex != "" && patient.Sex != info.Sex {
+		sl.ReportError(patient.Sex, "Sex", "sex", "cnpsex", "")
+	}
+}
@@ -0,0 +1,50 @@
+package validation
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/mihnea1711/POS_Project/services/gateway/internal/models"
+	"github.com/mihnea1711/POS_Project/services/gateway/pkg/utils"
+)
+
+// validateInsurancePolicyData validates the InsurancePolicyData struct using the validator package
+func validateInsurancePolicyData(policyData models.InsurancePolicyData) error {
+	validate := validator.New()
+	return validate.Struct(policyData)
+}
+
+// ValidateInsurancePolicyData is a middleware that validates InsurancePolicyData
+func ValidateInsurancePolicyData(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var policyData models.InsurancePolicyData
+
+		contentTypeFlag := isContentTypeJSON(r)
+		if !contentTypeFlag {
+			errMsg := "Unsupported media type. Content-Type must be application/json"
+			log.Printf("[MIDDLEWARE_GATEWAY] %s in request: %s", errMsg, r.RequestURI)
+			utils.RespondWithJSON(w, http.StatusUnsupportedMediaType, models.ResponseData{Error: errMsg, Message: "Insurance policy validation failed due to unsupported media type"})
+			return
+		}
+
+		// Decode the request body into InsurancePolicyData
+		err := json.NewDecoder(r.Body).Decode(&policyData)
+		if err != nil {
+			logAndRespondWithError(w, http.StatusUnprocessableEntity, "Error decoding insurance policy request body", err)
+			return
+		}
+
+		// Validate InsurancePolicyData
+		if err := validateInsurancePolicyData(policyData); err != nil {
+			logAndRespondWithError(w, http.StatusBadRequest, "Validation error for insurance policy struct", err)
+			return
+		}
+
+		// If validation passes, proceed to the next handler
+		ctx := context.WithValue(r.Context(), utils.DECODED_INSURANCE_POLICY_DATA, &policyData)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
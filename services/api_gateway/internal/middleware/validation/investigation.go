@@ -0,0 +1,67 @@
+package validation
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/mihnea1711/POS_Project/services/gateway/internal/models"
+	"github.com/mihnea1711/POS_Project/services/gateway/pkg/utils"
+)
+
+// ValidateInvestigationOrderData is a middleware that validates InvestigationOrderData
+func ValidateInvestigationOrderData(next http.Handler) http.Handler {
+	return validateInvestigationRequest(next, func() interface{} { return &models.InvestigationOrderData{} }, utils.DECODED_INVESTIGATION_ORDER, "investigation order")
+}
+
+// ValidateInvestigationStatusData is a middleware that validates InvestigationStatusData
+func ValidateInvestigationStatusData(next http.Handler) http.Handler {
+	return validateInvestigationRequest(next, func() interface{} { return &models.InvestigationStatusData{} }, utils.DECODED_INVESTIGATION_STATUS, "investigation status")
+}
+
+// ValidateInvestigationResultData is a middleware that validates InvestigationResultData
+func ValidateInvestigationResultData(next http.Handler) http.Handler {
+	return validateInvestigationRequest(next, func() interface{} { return &models.InvestigationResultData{} }, utils.DECODED_INVESTIGATION_RESULT, "investigation result")
+}
+
+// ValidateLabResolutionData is a middleware that validates LabResolutionData
+func ValidateLabResolutionData(next http.Handler) http.Handler {
+	return validateInvestigationRequest(next, func() interface{} { return &models.LabResolutionData{} }, utils.DECODED_LAB_RESOLUTION, "lab result resolution")
+}
+
+// ValidateLabDiscardData is a middleware that validates LabDiscardData
+func ValidateLabDiscardData(next http.Handler) http.Handler {
+	return validateInvestigationRequest(next, func() interface{} { return &models.LabDiscardData{} }, utils.DECODED_LAB_DISCARD, "lab result discard")
+}
+
+// validateInvestigationRequest decodes the body into a new value of the request type, validates it and stores it in
+// the context under the key
+func validateInvestigationRequest(next http.Handler, newRequest func() interface{}, key interface{}, entity string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request := newRequest()
+
+		contentTypeFlag := isContentTypeJSON(r)
+		if !contentTypeFlag {
+			errMsg := "Unsupported media type. Content-Type must be application/json"
+			log.Printf("[MIDDLEWARE_GATEWAY] %s in request: %s", errMsg, r.RequestURI)
+			utils.RespondWithJSON(w, http.StatusUnsupportedMediaType, models.ResponseData{Error: errMsg, Message: "Validation of the " + entity + " failed due to unsupported media type"})
+			return
+		}
+
+		if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+			logAndRespondWithError(w, http.StatusUnprocessableEntity, "Error decoding "+entity+" request body", err)
+			return
+		}
+
+		if err := validator.New().Struct(request); err != nil {
+			logAndRespondWithError(w, http.StatusBadRequest, "Validation error for "+entity+" struct", err)
+			return
+		}
+
+		// If validation passes, proceed to the next handler
+		ctx := context.WithValue(r.Context(), key, request)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,50 @@
+package validation
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/mihnea1711/POS_Project/services/gateway/internal/models"
+	"github.com/mihnea1711/POS_Project/services/gateway/pkg/utils"
+)
+
+// validateLegalHoldData validates the LegalHoldData struct using the validator package
+func validateLegalHoldData(holdData models.LegalHoldData) error {
+	validate := validator.New()
+	return validate.Struct(holdData)
+}
+
+// ValidateLegalHoldData is a middleware that validates LegalHoldData
+func ValidateLegalHoldData(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var holdData models.LegalHoldData
+
+		contentTypeFlag := isContentTypeJSON(r)
+		if !contentTypeFlag {
+			errMsg := "Unsupported media type. Content-Type must be application/json"
+			log.Printf("[MIDDLEWARE_GATEWAY] %s in request: %s", errMsg, r.RequestURI)
+			utils.RespondWithJSON(w, http.StatusUnsupportedMediaType, models.ResponseData{Error: errMsg, Message: "Legal hold validation failed due to unsupported media type"})
+			return
+		}
+
+		// Decode the request body into LegalHoldData
+		err := json.NewDecoder(r.Body).Decode(&holdData)
+		if err != nil {
+			logAndRespondWithError(w, http.StatusUnprocessableEntity, "Error decoding legal hold request body", err)
+			return
+		}
+
+		// Validate LegalHoldData
+		if err := validateLegalHoldData(holdData); err != nil {
+			logAndRespondWithError(w, http.StatusBadRequest, "Validation error for legal hold struct", err)
+			return
+		}
+
+		// If validation passes, proceed to the next handler
+		ctx := context.WithValue(r.Context(), utils.DECODED_LEGAL_HOLD_DATA, &holdData)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
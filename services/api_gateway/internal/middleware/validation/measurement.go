@@ -0,0 +1,18 @@
+package validation
+
+import (
+	"net/http"
+
+	"github.com/mihnea1711/POS_Project/services/gateway/internal/models"
+	"github.com/mihnea1711/POS_Project/services/gateway/pkg/utils"
+)
+
+// ValidateMeasurementData is a middleware that validates MeasurementData
+func ValidateMeasurementData(next http.Handler) http.Handler {
+	return validateInvestigationRequest(next, func() interface{} { return &models.MeasurementData{} }, utils.DECODED_MEASUREMENTS, "measurements")
+}
+
+// ValidateMeasurementRetractionData is a middleware that validates MeasurementRetractionData
+func ValidateMeasurementRetractionData(next http.Handler) http.Handler {
+	return validateInvestigationRequest(next, func() interface{} { return &models.MeasurementRetractionData{} }, utils.DECODED_MEASUREMENT_RETRACTION, "measurement retraction")
+}
@@ -0,0 +1,50 @@
+package validation
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/mihnea1711/POS_Project/services/gateway/internal/models"
+	"github.com/mihnea1711/POS_Project/services/gateway/pkg/utils"
+)
+
+// validateMergeData validates the MergeData struct using the validator package
+func validateMergeData(mergeData models.MergeData) error {
+	validate := validator.New()
+	return validate.Struct(mergeData)
+}
+
+// ValidateMergeData is a middleware that validates MergeData
+func ValidateMergeData(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var mergeData models.MergeData
+
+		contentTypeFlag := isContentTypeJSON(r)
+		if !contentTypeFlag {
+			errMsg := "Unsupported media type. Content-Type must be application/json"
+			log.Printf("[MIDDLEWARE_GATEWAY] %s in request: %s", errMsg, r.RequestURI)
+			utils.RespondWithJSON(w, http.StatusUnsupportedMediaType, models.ResponseData{Error: errMsg, Message: "Patient merge validation failed due to unsupported media type"})
+			return
+		}
+
+		// Decode the request body into MergeData
+		err := json.NewDecoder(r.Body).Decode(&mergeData)
+		if err != nil {
+			logAndRespondWithError(w, http.StatusUnprocessableEntity, "Error decoding patient merge request body", err)
+			return
+		}
+
+		// Validate MergeData
+		if err := validateMergeData(mergeData); err != nil {
+			logAndRespondWithError(w, http.StatusBadRequest, "Validation error for patient merge struct", err)
+			return
+		}
+
+		// If validation passes, proceed to the next handler
+		ctx := context.WithValue(r.Context(), utils.DECODED_MERGE_DATA, &mergeData)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
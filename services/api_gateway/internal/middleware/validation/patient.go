@@ -14,6 +14,8 @@ import (
 // ValidatePatientData validates the PatientData struct using the validator package
 func validatePatientData(patientData models.PatientData) error {
 	validate := validator.New()
+	validate.RegisterValidation("cnp", ValidateCNP)
+	validate.RegisterStructValidation(validatePatientCNPAgreement, models.PatientData{})
 	return validate.Struct(patientData)
 }
 
@@ -0,0 +1,18 @@
+package validation
+
+import (
+	"net/http"
+
+	"github.com/mihnea1711/POS_Project/services/gateway/internal/models"
+	"github.com/mihnea1711/POS_Project/services/gateway/pkg/utils"
+)
+
+// ValidatePrescriptionData is a middleware that validates PrescriptionData
+func ValidatePrescriptionData(next http.Handler) http.Handler {
+	return validateInvestigationRequest(next, func() interface{} { return &models.PrescriptionData{} }, utils.DECODED_PRESCRIPTION, "prescription")
+}
+
+// ValidatePrescriptionStatusData is a middleware that validates PrescriptionStatusData
+func ValidatePrescriptionStatusData(next http.Handler) http.Handler {
+	return validateInvestigationRequest(next, func() interface{} { return &models.PrescriptionStatusData{} }, utils.DECODED_PRESCRIPTION_STATUS, "prescription status")
+}
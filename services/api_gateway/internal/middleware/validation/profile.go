@@ -0,0 +1,50 @@
+package validation
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/mihnea1711/POS_Project/services/gateway/internal/models"
+	"github.com/mihnea1711/POS_Project/services/gateway/pkg/utils"
+)
+
+// validateClinicalProfileData validates the ClinicalProfileData struct using the validator package
+func validateClinicalProfileData(profileData models.ClinicalProfileData) error {
+	validate := validator.New()
+	return validate.Struct(profileData)
+}
+
+// ValidateClinicalProfileData is a middleware that validates ClinicalProfileData
+func ValidateClinicalProfileData(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var profileData models.ClinicalProfileData
+
+		contentTypeFlag := isContentTypeJSON(r)
+		if !contentTypeFlag {
+			errMsg := "Unsupported media type. Content-Type must be application/json"
+			log.Printf("[MIDDLEWARE_GATEWAY] %s in request: %s", errMsg, r.RequestURI)
+			utils.RespondWithJSON(w, http.StatusUnsupportedMediaType, models.ResponseData{Error: errMsg, Message: "Clinical profile validation failed due to unsupported media type"})
+			return
+		}
+
+		// Decode the request body into ClinicalProfileData
+		err := json.NewDecoder(r.Body).Decode(&profileData)
+		if err != nil {
+			logAndRespondWithError(w, http.StatusUnprocessableEntity, "Error decoding clinical profile request body", err)
+			return
+		}
+
+		// Validate ClinicalProfileData
+		if err := validateClinicalProfileData(profileData); err != nil {
+			logAndRespondWithError(w, http.StatusBadRequest, "Validation error for clinical profile struct", err)
+			return
+		}
+
+		// If validation passes, proceed to the next handler
+		ctx := context.WithValue(r.Context(), utils.DECODED_CLINICAL_PROFILE_DATA, &profileData)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
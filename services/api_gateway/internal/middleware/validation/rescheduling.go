@@ -0,0 +1,50 @@
+package validation
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/mihnea1711/POS_Project/services/gateway/internal/models"
+	"github.com/mihnea1711/POS_Project/services/gateway/pkg/utils"
+)
+
+// validateReschedulingJobData validates the ReschedulingJobData struct using the validator package
+func validateReschedulingJobData(jobData models.ReschedulingJobData) error {
+	validate := validator.New()
+	return validate.Struct(jobData)
+}
+
+// ValidateReschedulingJobData is a middleware that validates ReschedulingJobData
+func ValidateReschedulingJobData(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var jobData models.ReschedulingJobData
+
+		contentTypeFlag := isContentTypeJSON(r)
+		if !contentTypeFlag {
+			errMsg := "Unsupported media type. Content-Type must be application/json"
+			log.Printf("[MIDDLEWARE_GATEWAY] %s in request: %s", errMsg, r.RequestURI)
+			utils.RespondWithJSON(w, http.StatusUnsupportedMediaType, models.ResponseData{Error: errMsg, Message: "Rescheduling job validation failed due to unsupported media type"})
+			return
+		}
+
+		// Decode the request body into ReschedulingJobData
+		err := json.NewDecoder(r.Body).Decode(&jobData)
+		if err != nil {
+			logAndRespondWithError(w, http.StatusUnprocessableEntity, "Error decoding rescheduling job request body", err)
+			return
+		}
+
+		// Validate ReschedulingJobData
+		if err := validateReschedulingJobData(jobData); err != nil {
+			logAndRespondWithError(w, http.StatusBadRequest, "Validation error for rescheduling job struct", err)
+			return
+		}
+
+		// If validation passes, proceed to the next handler
+		ctx := context.WithValue(r.Context(), utils.DECODED_RESCHEDULING_JOB_DATA, &jobData)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
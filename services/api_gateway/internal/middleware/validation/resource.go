@@ -0,0 +1,88 @@
+package validation
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/mihnea1711/POS_Project/services/gateway/internal/models"
+	"github.com/mihnea1711/POS_Project/services/gateway/pkg/utils"
+)
+
+// validateResourceData validates the ResourceData struct using the validator package
+func validateResourceData(resourceData models.ResourceData) error {
+	validate := validator.New()
+	return validate.Struct(resourceData)
+}
+
+// ValidateResourceData is a middleware that validates ResourceData
+func ValidateResourceData(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var resourceData models.ResourceData
+
+		contentTypeFlag := isContentTypeJSON(r)
+		if !contentTypeFlag {
+			errMsg := "Unsupported media type. Content-Type must be application/json"
+			log.Printf("[MIDDLEWARE_GATEWAY] %s in request: %s", errMsg, r.RequestURI)
+			utils.RespondWithJSON(w, http.StatusUnsupportedMediaType, models.ResponseData{Error: errMsg, Message: "Resource validation failed due to unsupported media type"})
+			return
+		}
+
+		// Decode the request body into ResourceData
+		err := json.NewDecoder(r.Body).Decode(&resourceData)
+		if err != nil {
+			logAndRespondWithError(w, http.StatusUnprocessableEntity, "Error decoding resource request body", err)
+			return
+		}
+
+		// Validate ResourceData
+		if err := validateResourceData(resourceData); err != nil {
+			logAndRespondWithError(w, http.StatusBadRequest, "Validation error for resource struct", err)
+			return
+		}
+
+		// If validation passes, proceed to the next handler
+		ctx := context.WithValue(r.Context(), utils.DECODED_RESOURCE_DATA, &resourceData)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// validateAppointmentTypeData validates the AppointmentTypeData struct using the validator package
+func validateAppointmentTypeData(typeData models.AppointmentTypeData) error {
+	validate := validator.New()
+	return validate.Struct(typeData)
+}
+
+// ValidateAppointmentTypeData is a middleware that validates AppointmentTypeData
+func ValidateAppointmentTypeData(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var typeData models.AppointmentTypeData
+
+		contentTypeFlag := isContentTypeJSON(r)
+		if !contentTypeFlag {
+			errMsg := "Unsupported media type. Content-Type must be application/json"
+			log.Printf("[MIDDLEWARE_GATEWAY] %s in request: %s", errMsg, r.RequestURI)
+			utils.RespondWithJSON(w, http.StatusUnsupportedMediaType, models.ResponseData{Error: errMsg, Message: "Appointment type validation failed due to unsupported media type"})
+			return
+		}
+
+		// Decode the request body into AppointmentTypeData
+		err := json.NewDecoder(r.Body).Decode(&typeData)
+		if err != nil {
+			logAndRespondWithError(w, http.StatusUnprocessableEntity, "Error decoding appointment type request body", err)
+			return
+		}
+
+		// Validate AppointmentTypeData
+		if err := validateAppointmentTypeData(typeData); err != nil {
+			logAndRespondWithError(w, http.StatusBadRequest, "Validation error for appointment type struct", err)
+			return
+		}
+
+		// If validation passes, proceed to the next handler
+		ctx := context.WithValue(r.Context(), utils.DECODED_APPOINTMENT_TYPE_DATA, &typeData)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -42,9 +42,10 @@ type PatientData struct {
 	SecondName  string    `db:"second_name" json:"secondName" sql:"type:varchar(50)" validate:"required,max=50"`
 	Email       string    `db:"email" json:"email" sql:"type:varchar(70) unique" validate:"required,email"`
 	PhoneNumber string    `db:"phone_number" json:"phoneNumber" sql:"type:char(10) check (phone_number ~ '^[0-9]{10}$')" validate:"required,len=10,numeric"`
-	CNP         string    `db:"cnp" json:"cnp" sql:"type:char(13) unique" validate:"required,len=13,numeric"`
-	BirthDay    time.Time `db:"birth_day" json:"birthDay" sql:"type:date" validate:"required"`
+	CNP         string    `db:"cnp" json:"cnp" sql:"type:char(13) unique" validate:"required,cnp"`
+	BirthDay    time.Time `db:"birth_day" json:"birthDay" sql:"type:date"` // derived from the CNP when missing
 	IsActive    bool      `db:"is_active" json:"isActive" validate:"required"`
+	Sex         string    `db:"sex" json:"sex,omitempty" validate:"omitempty,oneof=M F"` // derived from the CNP when missing
 }
 
 type Specialization string
@@ -66,22 +67,315 @@ type AppointmentData struct {
 	IDDoctor     int               `db:"id_doctor" json:"idDoctor" validate:"required"`
 	Date         time.Time         `db:"date" json:"date" validate:"required"`
 	Status       StatusAppointment `db:"status" json:"status" validate:"required"`
+	IDType       *int              `db:"id_type" json:"idType,omitempty" validate:"omitempty,gt=0"`
+}
+
+// ReschedulingJobData starts the rescheduling of the future appointments of a doctor.
+// Without UnavailableUntil every future appointment of the doctor is moved.
+type ReschedulingJobData struct {
+	IDDoctor         int        `json:"idDoctor" validate:"required"`
+	UnavailableUntil *time.Time `json:"unavailableUntil,omitempty"`
+}
+
+// ClinicalProfileData is the clinical profile of a patient. An update names the Version it was made from.
+// The gateway fills in UpdatedBy with the user making the change.
+type ClinicalProfileData struct {
+	BloodType         string                 `json:"bloodType,omitempty" validate:"omitempty,oneof=O+ O- A+ A- B+ B- AB+ AB-"`
+	Allergies         []AllergyData          `json:"allergies" validate:"max=50,dive"`
+	ChronicConditions []ChronicConditionData `json:"chronicConditions" validate:"max=50,dive"`
+	Medications       []MedicationData       `json:"medications" validate:"max=50,dive"`
+	EmergencyContacts []EmergencyContactData `json:"emergencyContacts" validate:"max=50,dive"`
+	Version           int                    `json:"version" validate:"gte=0"`
+	UpdatedBy         *int                   `json:"updatedBy,omitempty"`
+}
+
+type AllergyData struct {
+	Substance string `json:"substance" validate:"required,max=255"`
+	Severity  string `json:"severity" validate:"required,oneof=mild moderate severe life_threatening"`
+	Reaction  string `json:"reaction,omitempty" validate:"max=255"`
+}
+
+type ChronicConditionData struct {
+	Name        string     `json:"name" validate:"required,max=255"`
+	DiagnosedOn *time.Time `json:"diagnosedOn,omitempty"`
+	Notes       string     `json:"notes,omitempty" validate:"max=1024"`
+}
+
+type MedicationData struct {
+	Name      string     `json:"name" validate:"required,max=255"`
+	Dosage    string     `json:"dosage" validate:"required,max=100"`
+	Frequency string     `json:"frequency" validate:"required,max=100"`
+	StartedOn *time.Time `json:"startedOn,omitempty"`
+}
+
+type EmergencyContactData struct {
+	Name         string `json:"name" validate:"required,max=255"`
+	Relationship string `json:"relationship" validate:"required,max=100"`
+	PhoneNumber  string `json:"phoneNumber" validate:"required,len=10,numeric"`
+}
+
+// InsurancePolicyData is an insurance policy of a patient. The payer of a CNAS policy is always CNAS, so only private policies name it.
+type InsurancePolicyData struct {
+	PayerType        string     `json:"payerType" validate:"required,oneof=cnas private"`
+	PayerName        string     `json:"payerName,omitempty" validate:"required_if=PayerType private,max=255"`
+	PolicyNumber     string     `json:"policyNumber" validate:"required,max=64"`
+	CoverageCategory string     `json:"coverageCategory" validate:"required,oneof=basic extended full"`
+	ValidFrom        time.Time  `json:"validFrom" validate:"required"`
+	ValidUntil       *time.Time `json:"validUntil,omitempty"`
+}
+
+// LegalHoldData keeps a patient from being erased. PlacedBy is taken from the token of the admin.
+type LegalHoldData struct {
+	Reason   string `json:"reason" validate:"required,max=255"`
+	PlacedBy *int   `json:"placedBy,omitempty"`
+}
+
+// MergeData merges the duplicate patient record into the surviving one
+type MergeData struct {
+	IDSurvivor  int `json:"idSurvivor" validate:"required,gt=0"`
+	IDDuplicate int `json:"idDuplicate" validate:"required,gt=0,nefield=IDSurvivor"`
+}
+
+// ResourceData describes a room or a piece of equipment that serves DailyCapacity appointments per day.
+type ResourceData struct {
+	Name          string `json:"name" validate:"required"`
+	Kind          string `json:"kind" validate:"required,oneof=room equipment"`
+	Category      string `json:"category" validate:"required"`
+	DailyCapacity int    `json:"dailyCapacity" validate:"required,gt=0"`
+	IsActive      bool   `json:"isActive"`
+}
+
+// AppointmentTypeData declares the resources an appointment of the type needs.
+type AppointmentTypeData struct {
+	Name         string                    `json:"name" validate:"required"`
+	Requirements []ResourceRequirementData `json:"requirements" validate:"dive"`
+}
+
+// ResourceRequirementData asks for Quantity resources of the category.
+type ResourceRequirementData struct {
+	Category string `json:"category" validate:"required"`
+	Quantity int    `json:"quantity" validate:"required,gt=0"`
 }
 
 type ConsultationData struct {
 	IDConsultation primitive.ObjectID `json:"idConsultation" bson:"_id"`
 	IDPatient      int                `json:"idPatient" bson:"id_patient" validate:"required"`
 	IDDoctor       int                `json:"idDoctor" bson:"id_doctor" validate:"required"`
+	IDAppointment  *int               `json:"idAppointment,omitempty" bson:"id_appointment,omitempty" validate:"omitempty,gt=0"`
 	Date           time.Time          `json:"date" bson:"date" validate:"required"`
-	Diagnostic     string             `json:"diagnostic" bson:"diagnostic" validate:"required"`
+	Diagnostic     string             `json:"diagnostic" bson:"diagnostic" validate:"required_without=Diagnoses"`
+	Diagnoses      []Diagnosis        `json:"diagnoses" bson:"diagnoses" validate:"omitempty,dive"`
 	Investigations []Investigation    `json:"investigations" bson:"investigations" validate:"required"`
+	Version        int                `json:"version" bson:"version"`
+}
+
+// StatusChangeData is a transition of an appointment's status, OldStatus is missing for its creation
+type StatusChangeData struct {
+	IDHistory       int       `json:"idHistory"`
+	IDAppointment   int       `json:"idAppointment"`
+	OldStatus       *string   `json:"oldStatus"`
+	NewStatus       string    `json:"newStatus"`
+	AppointmentDate time.Time `json:"appointmentDate"`
+	ChangedAt       time.Time `json:"changedAt"`
+}
+
+// TimelineData is a page of a patient's timeline, the latest events first. Failures names the modules that could not
+// be read, their events are missing and Partial is set.
+type TimelineData struct {
+	IDPatient int                   `json:"idPatient"`
+	Events    []TimelineEventData   `json:"events"`
+	Page      int                   `json:"page"`
+	Limit     int                   `json:"limit"`
+	Total     int                   `json:"total"`
+	Partial   bool                  `json:"partial"`
+	Failures  []TimelineFailureData `json:"failures,omitempty"`
+}
+
+// TimelineEventData is an event of a patient's timeline. The IDs lead to the appointment, the consultation or the
+// investigation it comes from.
+type TimelineEventData struct {
+	Type            string      `json:"type"`
+	Time            time.Time   `json:"time"`
+	Summary         string      `json:"summary"`
+	IDDoctor        int         `json:"idDoctor,omitempty"`
+	IDAppointment   int         `json:"idAppointment,omitempty"`
+	IDConsultation  string      `json:"idConsultation,omitempty"`
+	IDInvestigation string      `json:"idInvestigation,omitempty"`
+	Status          string      `json:"status,omitempty"`
+	PreviousStatus  string      `json:"previousStatus,omitempty"`
+	Result          string      `json:"result,omitempty"`
+	Diagnoses       []Diagnosis `json:"diagnoses,omitempty"`
+}
+
+// TimelineFailureData is a module the timeline could not be read from
+type TimelineFailureData struct {
+	Source string `json:"source"`
+	Error  string `json:"error"`
+}
+
+// VisitDraftData is the consultation of an appointment pre-filled from it, to be completed and created
+type VisitDraftData struct {
+	IDAppointment  int             `json:"idAppointment"`
+	IDPatient      int             `json:"idPatient"`
+	IDDoctor       int             `json:"idDoctor"`
+	Date           time.Time       `json:"date"`
+	Diagnostic     string          `json:"diagnostic"`
+	Diagnoses      []Diagnosis     `json:"diagnoses"`
+	Investigations []Investigation `json:"investigations"`
+}
+
+// AppointmentLinkData names the consultation opened for an appointment
+type AppointmentLinkData struct {
+	IDAppointment  int    `json:"idAppointment"`
+	IDConsultation string `json:"idConsultation"`
+}
+
+// Diagnosis is an ICD-10 coded diagnosis. The consultation module checks the code against its catalog and fills in the
+// description.
+type Diagnosis struct {
+	Code        string `json:"code" bson:"code" validate:"required"`
+	Description string `json:"description,omitempty" bson:"description"`
+	Primary     bool   `json:"primary" bson:"primary"`
 }
 
+// Investigation is an order for a test. The result may be recorded later, the lifecycle fields are kept by the
+// consultation module.
 type Investigation struct {
-	ID             primitive.ObjectID `json:"idInvestigation" bson:"id_investigatie"`
-	Name           string             `json:"name" bson:"name" validate:"required"`
-	ProcessingTime int                `json:"processingTime" bson:"processing_time" validate:"required"`
-	Result         string             `json:"result" bson:"result" validate:"required"`
+	ID                 primitive.ObjectID `json:"idInvestigation" bson:"id_investigatie"`
+	Name               string             `json:"name" bson:"name" validate:"required"`
+	ProcessingTime     int                `json:"processingTime" bson:"processing_time" validate:"required"`
+	Result             string             `json:"result" bson:"result"`
+	Status             string             `json:"status,omitempty" bson:"status,omitempty"`
+	OrderedAt          *time.Time         `json:"orderedAt,omitempty" bson:"ordered_at,omitempty"`
+	ExpectedCompletion *time.Time         `json:"expectedCompletion,omitempty" bson:"expected_completion,omitempty"`
+	SampleCollectedAt  *time.Time         `json:"sampleCollectedAt,omitempty" bson:"sample_collected_at,omitempty"`
+	ResultedAt         *time.Time         `json:"resultedAt,omitempty" bson:"resulted_at,omitempty"`
+	CanceledAt         *time.Time         `json:"canceledAt,omitempty" bson:"canceled_at,omitempty"`
+	CancelReason       string             `json:"cancelReason,omitempty" bson:"cancel_reason,omitempty"`
+}
+
+// InvestigationOrderData orders an investigation on an existing consultation, the processing time is in minutes
+type InvestigationOrderData struct {
+	Name           string `json:"name" validate:"required"`
+	ProcessingTime int    `json:"processingTime" validate:"required,gt=0"`
+}
+
+// InvestigationStatusData moves an investigation along its lifecycle, the reason is kept for cancellations
+type InvestigationStatusData struct {
+	Status string `json:"status" validate:"required,oneof=sample_collected in_progress canceled"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// InvestigationResultData is the result recorded for an investigation
+type InvestigationResultData struct {
+	Result string `json:"result" validate:"required"`
+}
+
+// LabResolutionData records a lab result queued for review on the investigation it was matched to
+type LabResolutionData struct {
+	IDConsultation  string `json:"idConsultation" validate:"required,len=24,hexadecimal"`
+	IDInvestigation string `json:"idInvestigation" validate:"required,len=24,hexadecimal"`
+	Note            string `json:"note,omitempty"`
+}
+
+// LabDiscardData closes a lab result queued for review without recording it
+type LabDiscardData struct {
+	Reason string `json:"reason" validate:"required"`
+}
+
+// MeasurementData records the readings taken together, for a consultation or for a patient and a doctor. The
+// consultation module checks that one or the other is given.
+type MeasurementData struct {
+	IDPatient      int                      `json:"idPatient,omitempty" validate:"gte=0"`
+	IDDoctor       int                      `json:"idDoctor,omitempty" validate:"gte=0"`
+	IDConsultation string                   `json:"idConsultation,omitempty" validate:"omitempty,len=24,hexadecimal"`
+	MeasuredAt     *time.Time               `json:"measuredAt,omitempty"`
+	Readings       []MeasurementReadingData `json:"readings" validate:"required,min=1,max=20,dive"`
+}
+
+// MeasurementReadingData is a reading in any unit its type accepts, a value or values by component
+type MeasurementReadingData struct {
+	Type   string             `json:"type" validate:"required,max=50"`
+	Unit   string             `json:"unit,omitempty" validate:"max=20"`
+	Value  *float64           `json:"value,omitempty" validate:"required_without=Values"`
+	Values map[string]float64 `json:"values,omitempty" validate:"required_without=Value"`
+	Note   string             `json:"note,omitempty" validate:"max=255"`
+}
+
+// MeasurementRetractionData marks a measurement as entered in error
+type MeasurementRetractionData struct {
+	Reason string `json:"reason" validate:"required,max=255"`
+}
+
+// PrescriptionData issues a prescription for a consultation. Warnings about allergies and major interactions must be
+// acknowledged for it to be issued.
+type PrescriptionData struct {
+	Items               []PrescriptionItemData `json:"items" validate:"required,min=1,max=20,dive"`
+	AcknowledgeWarnings bool                   `json:"acknowledgeWarnings"`
+}
+
+// PrescriptionItemData is a drug of the catalog with how it is taken
+type PrescriptionItemData struct {
+	DrugCode     string `json:"drugCode" validate:"required,max=20"`
+	Dose         string `json:"dose" validate:"required,max=100"`
+	Frequency    string `json:"frequency" validate:"required,max=100"`
+	DurationDays int    `json:"durationDays" validate:"required,gt=0,lte=365"`
+	Quantity     int    `json:"quantity" validate:"required,gt=0"`
+}
+
+// PrescriptionRecordData is a prescription as the consultation module keeps it
+type PrescriptionRecordData struct {
+	IDPrescription string                      `json:"idPrescription"`
+	Items          []PrescriptionRecordItem    `json:"items"`
+	Warnings       []PrescriptionWarningRecord `json:"warnings"`
+	Status         string                      `json:"status"`
+	IssuedAt       time.Time                   `json:"issuedAt"`
+	DispensedAt    *time.Time                  `json:"dispensedAt,omitempty"`
+	CanceledAt     *time.Time                  `json:"canceledAt,omitempty"`
+	CancelReason   string                      `json:"cancelReason,omitempty"`
+}
+
+// PrescriptionWarningRecord is an allergy or an interaction found when the prescription was issued
+type PrescriptionWarningRecord struct {
+	Kind     string `json:"kind"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// PrescriptionRecordItem is a prescribed drug with the name the catalog gives it
+type PrescriptionRecordItem struct {
+	PrescriptionItemData
+	DrugName string `json:"drugName"`
+}
+
+// PrescriptionStatusData dispenses or cancels a prescription, the reason is required for cancellations
+type PrescriptionStatusData struct {
+	Status string `json:"status" validate:"required,oneof=dispensed canceled"`
+	Reason string `json:"reason,omitempty" validate:"required_if=Status canceled,max=255"`
+}
+
+// PrescriptionRequestData is the prescription the gateway sends to the consultation module, with the allergies and the
+// medications of the patient's clinical profile to check it against
+type PrescriptionRequestData struct {
+	Items               []PrescriptionItemData `json:"items"`
+	AcknowledgeWarnings bool                   `json:"acknowledgeWarnings"`
+	ProfileRecorded     bool                   `json:"profileRecorded"`
+	Allergies           []string               `json:"allergies"`
+	Medications         []string               `json:"medications"`
+}
+
+// AttachmentData is a file kept by the consultation module for a patient, optionally linked to a consultation and one of
+// its investigations
+type AttachmentData struct {
+	IDAttachment    primitive.ObjectID  `json:"idAttachment"`
+	IDPatient       int                 `json:"idPatient"`
+	IDConsultation  *primitive.ObjectID `json:"idConsultation,omitempty"`
+	IDInvestigation *primitive.ObjectID `json:"idInvestigation,omitempty"`
+	FileName        string              `json:"fileName"`
+	ContentType     string              `json:"contentType"`
+	Size            int64               `json:"size"`
+	Description     string              `json:"description,omitempty"`
+	UploadedAt      time.Time           `json:"uploadedAt"`
 }
 
 type ActivityData struct {
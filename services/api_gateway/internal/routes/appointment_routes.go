@@ -19,20 +19,91 @@ func loadAppointmentRoutes(router *mux.Router, gatewayController *controllers.Ga
 	router.Handle(utils.CREATE_APPOINTMENT_ENDPOINT, authorization.AdminAndDoctorMiddleware(jwtConfig, validation.ValidateAppointmentData(appointmentCreationHandler))).Methods("POST")
 	log.Println("[GATEWAY] Route POST", utils.CREATE_APPOINTMENT_ENDPOINT, "registered.")
 
+	reschedulingJobCreationHandler := http.HandlerFunc(gatewayController.CreateReschedulingJob)
+	router.Handle(utils.CREATE_RESCHEDULING_JOB_ENDPOINT, authorization.AdminOnlyMiddleware(jwtConfig, validation.ValidateReschedulingJobData(reschedulingJobCreationHandler))).Methods("POST")
+	log.Println("[GATEWAY] Route POST", utils.CREATE_RESCHEDULING_JOB_ENDPOINT, "registered.")
+
+	resourceCreationHandler := http.HandlerFunc(gatewayController.CreateResource)
+	router.Handle(utils.CREATE_RESOURCE_ENDPOINT, authorization.AdminOnlyMiddleware(jwtConfig, validation.ValidateResourceData(resourceCreationHandler))).Methods("POST")
+	log.Println("[GATEWAY] Route POST", utils.CREATE_RESOURCE_ENDPOINT, "registered.")
+
+	appointmentTypeCreationHandler := http.HandlerFunc(gatewayController.CreateAppointmentType)
+	router.Handle(utils.CREATE_APPOINTMENT_TYPE_ENDPOINT, authorization.AdminOnlyMiddleware(jwtConfig, validation.ValidateAppointmentTypeData(appointmentTypeCreationHandler))).Methods("POST")
+	log.Println("[GATEWAY] Route POST", utils.CREATE_APPOINTMENT_TYPE_ENDPOINT, "registered.")
+
 	// ---------------------------------------------------------- Retrieve --------------------------------------------------------------
 	appointmentFetchAllHandler := http.HandlerFunc(gatewayController.GetAppointments)
 	router.HandleFunc(utils.GET_ALL_APPOINTMENTS_ENDPOINT, authorization.AllRolesMiddleware(jwtConfig, appointmentFetchAllHandler)).Methods("GET")
 	log.Println("[GATEWAY] Route GET", utils.GET_ALL_APPOINTMENTS_ENDPOINT, "registered.")
 
+	// Registered before the fetch by ID route so "reports" is not parsed as an appointment ID
+	policyOffendersHandler := http.HandlerFunc(gatewayController.GetPolicyOffenders)
+	router.HandleFunc(utils.GET_POLICY_OFFENDERS_ENDPOINT, authorization.AdminOnlyMiddleware(jwtConfig, policyOffendersHandler)).Methods("GET")
+	log.Println("[GATEWAY] Route GET", utils.GET_POLICY_OFFENDERS_ENDPOINT, "registered.")
+
+	unrecordedVisitsHandler := http.HandlerFunc(gatewayController.GetUnrecordedVisits)
+	router.HandleFunc(utils.GET_UNRECORDED_VISITS_ENDPOINT, authorization.AdminOnlyMiddleware(jwtConfig, unrecordedVisitsHandler)).Methods("GET")
+	log.Println("[GATEWAY] Route GET", utils.GET_UNRECORDED_VISITS_ENDPOINT, "registered.")
+
+	visitStartHandler := http.HandlerFunc(gatewayController.StartVisit)
+	router.HandleFunc(utils.START_VISIT_ENDPOINT, authorization.AdminAndDoctorMiddleware(jwtConfig, visitStartHandler)).Methods("GET")
+	log.Println("[GATEWAY] Route GET", utils.START_VISIT_ENDPOINT, "registered.")
+
+	reschedulingJobsFetchAllHandler := http.HandlerFunc(gatewayController.GetReschedulingJobs)
+	router.HandleFunc(utils.GET_ALL_RESCHEDULING_JOBS_ENDPOINT, authorization.AdminOnlyMiddleware(jwtConfig, reschedulingJobsFetchAllHandler)).Methods("GET")
+	log.Println("[GATEWAY] Route GET", utils.GET_ALL_RESCHEDULING_JOBS_ENDPOINT, "registered.")
+
+	reschedulingJobFetchByIDHandler := http.HandlerFunc(gatewayController.GetReschedulingJobByID)
+	router.HandleFunc(utils.GET_RESCHEDULING_JOB_BY_ID_ENDPOINT, authorization.AdminOnlyMiddleware(jwtConfig, reschedulingJobFetchByIDHandler)).Methods("GET")
+	log.Println("[GATEWAY] Route GET", utils.GET_RESCHEDULING_JOB_BY_ID_ENDPOINT, "registered.")
+
+	availabilityHandler := http.HandlerFunc(gatewayController.GetAvailability)
+	router.HandleFunc(utils.GET_AVAILABILITY_ENDPOINT, authorization.AllRolesMiddleware(jwtConfig, availabilityHandler)).Methods("GET")
+	log.Println("[GATEWAY] Route GET", utils.GET_AVAILABILITY_ENDPOINT, "registered.")
+
+	resourcesFetchAllHandler := http.HandlerFunc(gatewayController.GetResources)
+	router.HandleFunc(utils.GET_ALL_RESOURCES_ENDPOINT, authorization.AllRolesMiddleware(jwtConfig, resourcesFetchAllHandler)).Methods("GET")
+	log.Println("[GATEWAY] Route GET", utils.GET_ALL_RESOURCES_ENDPOINT, "registered.")
+
+	appointmentTypesFetchAllHandler := http.HandlerFunc(gatewayController.GetAppointmentTypes)
+	router.HandleFunc(utils.GET_ALL_APPOINTMENT_TYPES_ENDPOINT, authorization.AllRolesMiddleware(jwtConfig, appointmentTypesFetchAllHandler)).Methods("GET")
+	log.Println("[GATEWAY] Route GET", utils.GET_ALL_APPOINTMENT_TYPES_ENDPOINT, "registered.")
+
 	appointmentFetchByIDHandler := http.HandlerFunc(gatewayController.GetAppointmentByID)
 	router.HandleFunc(utils.GET_APPOINTMENT_BY_ID_ENDPOINT, authorization.AllRolesMiddleware(jwtConfig, appointmentFetchByIDHandler)).Methods("GET")
 	log.Println("[GATEWAY] Route GET", utils.GET_APPOINTMENT_BY_ID_ENDPOINT, "registered.")
 
+	appointmentResourcesFetchHandler := http.HandlerFunc(gatewayController.GetAppointmentResources)
+	router.HandleFunc(utils.GET_APPOINTMENT_RESOURCES_ENDPOINT, authorization.AllRolesMiddleware(jwtConfig, appointmentResourcesFetchHandler)).Methods("GET")
+	log.Println("[GATEWAY] Route GET", utils.GET_APPOINTMENT_RESOURCES_ENDPOINT, "registered.")
+
+	appointmentCoverageFetchHandler := http.HandlerFunc(gatewayController.GetAppointmentCoverage)
+	router.HandleFunc(utils.GET_APPOINTMENT_COVERAGE_ENDPOINT, authorization.AdminAndDoctorMiddleware(jwtConfig, appointmentCoverageFetchHandler)).Methods("GET")
+	log.Println("[GATEWAY] Route GET", utils.GET_APPOINTMENT_COVERAGE_ENDPOINT, "registered.")
+
 	// ---------------------------------------------------------- Update --------------------------------------------------------------
 	appointmentUpdateByIDHandler := http.HandlerFunc(gatewayController.UpdateAppointmentByID)
 	router.Handle(utils.UPDATE_APPOINTMENT_BY_ID_ENDPOINT, authorization.AdminAndDoctorMiddleware(jwtConfig, validation.ValidateAppointmentData(appointmentUpdateByIDHandler))).Methods("PUT")
 	log.Println("[GATEWAY] Route PUT", utils.UPDATE_APPOINTMENT_BY_ID_ENDPOINT, "registered.")
 
+	resourceUpdateByIDHandler := http.HandlerFunc(gatewayController.UpdateResourceByID)
+	router.Handle(utils.UPDATE_RESOURCE_BY_ID_ENDPOINT, authorization.AdminOnlyMiddleware(jwtConfig, validation.ValidateResourceData(resourceUpdateByIDHandler))).Methods("PUT")
+	log.Println("[GATEWAY] Route PUT", utils.UPDATE_RESOURCE_BY_ID_ENDPOINT, "registered.")
+
+	// The confirmation link from the reminder carries its own token, so no JWT is required
+	appointmentConfirmHandler := http.HandlerFunc(gatewayController.ConfirmAppointment)
+	router.Handle(utils.CONFIRM_APPOINTMENT_ENDPOINT, appointmentConfirmHandler).Methods("GET")
+	log.Println("[GATEWAY] Route GET", utils.CONFIRM_APPOINTMENT_ENDPOINT, "registered.")
+
+	// The links from a rescheduling proposal carry their own token as well
+	proposalAcceptHandler := http.HandlerFunc(gatewayController.AcceptReschedulingProposal)
+	router.Handle(utils.ACCEPT_RESCHEDULING_PROPOSAL_ENDPOINT, proposalAcceptHandler).Methods("GET")
+	log.Println("[GATEWAY] Route GET", utils.ACCEPT_RESCHEDULING_PROPOSAL_ENDPOINT, "registered.")
+
+	proposalDeclineHandler := http.HandlerFunc(gatewayController.DeclineReschedulingProposal)
+	router.Handle(utils.DECLINE_RESCHEDULING_PROPOSAL_ENDPOINT, proposalDeclineHandler).Methods("GET")
+	log.Println("[GATEWAY] Route GET", utils.DECLINE_RESCHEDULING_PROPOSAL_ENDPOINT, "registered.")
+
 	// ---------------------------------------------------------- Delete --------------------------------------------------------------
 	appointmentDeleteByIDHandler := http.HandlerFunc(gatewayController.DeleteAppointmentByID)
 	router.Handle(utils.DELETE_APPOINTMENT_BY_ID_ENDPOINT, authorization.AdminAndDoctorMiddleware(jwtConfig, appointmentDeleteByIDHandler)).Methods("DELETE")
@@ -0,0 +1,39 @@
+package routes
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/mihnea1711/POS_Project/services/gateway/internal/controllers"
+	"github.com/mihnea1711/POS_Project/services/gateway/internal/middleware/authorization"
+	"github.com/mihnea1711/POS_Project/services/gateway/pkg/config"
+	"github.com/mihnea1711/POS_Project/services/gateway/pkg/utils"
+)
+
+// loadAttachmentRoutes loads the routes of the files attached to patients, consultations and investigations.
+// Patients may reach their own attachments, the controller checks it.
+func loadAttachmentRoutes(router *mux.Router, gatewayController *controllers.GatewayController, jwtConfig config.JWTConfig) {
+	// ---------------------------------------------------------- Create --------------------------------------------------------------
+	attachmentUploadHandler := http.HandlerFunc(gatewayController.UploadAttachment)
+	router.Handle(utils.UPLOAD_ATTACHMENT_ENDPOINT, authorization.AllRolesMiddleware(jwtConfig, attachmentUploadHandler)).Methods("POST")
+	log.Printf("[GATEWAY] Route POST %s registered.", utils.UPLOAD_ATTACHMENT_ENDPOINT)
+
+	// ---------------------------------------------------------- Retrieve --------------------------------------------------------------
+	attachmentFetchAllHandler := http.HandlerFunc(gatewayController.GetAttachments)
+	router.Handle(utils.GET_ALL_ATTACHMENTS_ENDPOINT, authorization.AllRolesMiddleware(jwtConfig, attachmentFetchAllHandler)).Methods("GET")
+	log.Printf("[GATEWAY] Route GET %s registered.", utils.GET_ALL_ATTACHMENTS_ENDPOINT)
+
+	attachmentFetchByIDHandler := http.HandlerFunc(gatewayController.GetAttachmentByID)
+	router.Handle(utils.GET_ATTACHMENT_BY_ID_ENDPOINT, authorization.AllRolesMiddleware(jwtConfig, attachmentFetchByIDHandler)).Methods("GET")
+	log.Printf("[GATEWAY] Route GET %s registered.", utils.GET_ATTACHMENT_BY_ID_ENDPOINT)
+
+	attachmentDownloadHandler := http.HandlerFunc(gatewayController.DownloadAttachment)
+	router.Handle(utils.DOWNLOAD_ATTACHMENT_ENDPOINT, authorization.AllRolesMiddleware(jwtConfig, attachmentDownloadHandler)).Methods("GET")
+	log.Printf("[GATEWAY] Route GET %s registered.", utils.DOWNLOAD_ATTACHMENT_ENDPOINT)
+
+	// ---------------------------------------------------------- Delete --------------------------------------------------------------
+	attachmentDeleteHandler := http.HandlerFunc(gatewayController.DeleteAttachmentByID)
+	router.Handle(utils.DELETE_ATTACHMENT_BY_ID_ENDPOINT, authorization.AdminAndDoctorMiddleware(jwtConfig, attachmentDeleteHandler)).Methods("DELETE")
+	log.Printf("[GATEWAY] Route DELETE %s registered.", utils.DELETE_ATTACHMENT_BY_ID_ENDPOINT)
+}
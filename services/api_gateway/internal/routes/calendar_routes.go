@@ -0,0 +1,43 @@
+package routes
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/mihnea1711/POS_Project/services/gateway/internal/controllers"
+	"github.com/mihnea1711/POS_Project/services/gateway/internal/middleware/authorization"
+	"github.com/mihnea1711/POS_Project/services/gateway/pkg/config"
+	"github.com/mihnea1711/POS_Project/services/gateway/pkg/utils"
+)
+
+// loadCalendarRoutes loads the iCalendar feed routes for doctors and patients
+func loadCalendarRoutes(router *mux.Router, gatewayController *controllers.GatewayController, jwtConfig config.JWTConfig) {
+	// ---------------------------------------------------------- Feeds --------------------------------------------------------------
+	// Feeds are authorized through the secret feed token, so calendar apps can subscribe without a JWT
+	doctorCalendarHandler := http.HandlerFunc(gatewayController.GetDoctorCalendar)
+	router.Handle(utils.GET_DOCTOR_CALENDAR_ENDPOINT, doctorCalendarHandler).Methods("GET")
+	log.Println("[GATEWAY] Route GET", utils.GET_DOCTOR_CALENDAR_ENDPOINT, "registered.")
+
+	patientCalendarHandler := http.HandlerFunc(gatewayController.GetPatientCalendar)
+	router.Handle(utils.GET_PATIENT_CALENDAR_ENDPOINT, patientCalendarHandler).Methods("GET")
+	log.Println("[GATEWAY] Route GET", utils.GET_PATIENT_CALENDAR_ENDPOINT, "registered.")
+
+	// ---------------------------------------------------------- Subscriptions --------------------------------------------------------------
+	doctorSubscriptionHandler := http.HandlerFunc(gatewayController.GetDoctorCalendarSubscription)
+	router.Handle(utils.GET_DOCTOR_CALENDAR_SUBSCRIPTION_ENDPOINT, authorization.AdminAndDoctorMiddleware(jwtConfig, doctorSubscriptionHandler)).Methods("GET")
+	log.Println("[GATEWAY] Route GET", utils.GET_DOCTOR_CALENDAR_SUBSCRIPTION_ENDPOINT, "registered.")
+
+	patientSubscriptionHandler := http.HandlerFunc(gatewayController.GetPatientCalendarSubscription)
+	router.Handle(utils.GET_PATIENT_CALENDAR_SUBSCRIPTION_ENDPOINT, authorization.AdminAndPatientMiddleware(jwtConfig, patientSubscriptionHandler)).Methods("GET")
+	log.Println("[GATEWAY] Route GET", utils.GET_PATIENT_CALENDAR_SUBSCRIPTION_ENDPOINT, "registered.")
+
+	// Regenerating replaces the feed key, which revokes a feed URL that was shared or leaked
+	doctorRegenerationHandler := http.HandlerFunc(gatewayController.RegenerateDoctorCalendarSubscription)
+	router.Handle(utils.GET_DOCTOR_CALENDAR_SUBSCRIPTION_ENDPOINT, authorization.AdminAndDoctorMiddleware(jwtConfig, doctorRegenerationHandler)).Methods("POST")
+	log.Println("[GATEWAY] Route POST", utils.GET_DOCTOR_CALENDAR_SUBSCRIPTION_ENDPOINT, "registered.")
+
+	patientRegenerationHandler := http.HandlerFunc(gatewayController.RegeneratePatientCalendarSubscription)
+	router.Handle(utils.GET_PATIENT_CALENDAR_SUBSCRIPTION_ENDPOINT, authorization.AdminAndPatientMiddleware(jwtConfig, patientRegenerationHandler)).Methods("POST")
+	log.Println("[GATEWAY] Route POST", utils.GET_PATIENT_CALENDAR_SUBSCRIPTION_ENDPOINT, "registered.")
+}
@@ -28,6 +28,22 @@ func loadConsultationRoutes(router *mux.Router, gatewayController *controllers.G
 	router.HandleFunc(utils.GET_CONSULTATION_BY_ID_ENDPOINT, authorization.AllRolesMiddleware(jwtConfig, consultatieFetchByIDHandler)).Methods("GET")
 	log.Printf("[GATEWAY] Route GET %s registered.", utils.GET_CONSULTATION_BY_ID_ENDPOINT)
 
+	visitSummaryHandler := http.HandlerFunc(gatewayController.GetVisitSummary)
+	router.Handle(utils.GET_VISIT_SUMMARY_ENDPOINT, authorization.AllRolesMiddleware(jwtConfig, visitSummaryHandler)).Methods("GET")
+	log.Printf("[GATEWAY] Route GET %s registered.", utils.GET_VISIT_SUMMARY_ENDPOINT)
+
+	icd10SearchHandler := http.HandlerFunc(gatewayController.SearchICD10)
+	router.Handle(utils.SEARCH_ICD10_ENDPOINT, authorization.AdminAndDoctorMiddleware(jwtConfig, icd10SearchHandler)).Methods("GET")
+	log.Printf("[GATEWAY] Route GET %s registered.", utils.SEARCH_ICD10_ENDPOINT)
+
+	diagnosisStatisticsHandler := http.HandlerFunc(gatewayController.GetDiagnosisStatistics)
+	router.Handle(utils.GET_DIAGNOSIS_STATISTICS_ENDPOINT, authorization.AdminOnlyMiddleware(jwtConfig, diagnosisStatisticsHandler)).Methods("GET")
+	log.Printf("[GATEWAY] Route GET %s registered.", utils.GET_DIAGNOSIS_STATISTICS_ENDPOINT)
+
+	investigationStatisticsHandler := http.HandlerFunc(gatewayController.GetInvestigationStatistics)
+	router.Handle(utils.GET_INVESTIGATION_STATISTICS_ENDPOINT, authorization.AdminOnlyMiddleware(jwtConfig, investigationStatisticsHandler)).Methods("GET")
+	log.Printf("[GATEWAY] Route GET %s registered.", utils.GET_INVESTIGATION_STATISTICS_ENDPOINT)
+
 	// ---------------------------------------------------------- Update --------------------------------------------------------------
 	consultatieUpdateByIDHandler := http.HandlerFunc(gatewayController.UpdateConsultationByID)
 	router.Handle(utils.UPDATE_CONSULTATION_BY_ID_ENDPOINT, authorization.AdminAndDoctorMiddleware(jwtConfig, validation.ValidateConsultationData(consultatieUpdateByIDHandler))).Methods("PUT")
@@ -0,0 +1,28 @@
+package routes
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/mihnea1711/POS_Project/services/gateway/internal/controllers"
+	"github.com/mihnea1711/POS_Project/services/gateway/internal/middleware/authorization"
+	"github.com/mihnea1711/POS_Project/services/gateway/pkg/config"
+	"github.com/mihnea1711/POS_Project/services/gateway/pkg/utils"
+)
+
+// loadFHIRRoutes loads the read-only FHIR R4 facade over patients, doctors, appointments and consultations
+func loadFHIRRoutes(router *mux.Router, gatewayController *controllers.GatewayController, jwtConfig config.JWTConfig) {
+	// The metadata route is registered first, so it is not read as a search of "metadata" resources
+	metadataHandler := http.HandlerFunc(gatewayController.GetFHIRMetadata)
+	router.Handle(utils.FHIR_METADATA_ENDPOINT, metadataHandler).Methods("GET")
+	log.Println("[GATEWAY] Route GET", utils.FHIR_METADATA_ENDPOINT, "registered.")
+
+	searchHandler := http.HandlerFunc(gatewayController.SearchFHIRResources)
+	router.Handle(utils.FHIR_SEARCH_ENDPOINT, authorization.AdminAndDoctorMiddleware(jwtConfig, searchHandler)).Methods("GET")
+	log.Println("[GATEWAY] Route GET", utils.FHIR_SEARCH_ENDPOINT, "registered.")
+
+	readHandler := http.HandlerFunc(gatewayController.ReadFHIRResource)
+	router.Handle(utils.FHIR_READ_ENDPOINT, authorization.AdminAndDoctorMiddleware(jwtConfig, readHandler)).Methods("GET")
+	log.Println("[GATEWAY] Route GET", utils.FHIR_READ_ENDPOINT, "registered.")
+}
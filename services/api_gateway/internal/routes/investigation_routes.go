@@ -0,0 +1,71 @@
+package routes
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/mihnea1711/POS_Project/services/gateway/internal/controllers"
+	"github.com/mihnea1711/POS_Project/services/gateway/internal/middleware/authorization"
+	"github.com/mihnea1711/POS_Project/services/gateway/internal/middleware/validation"
+	"github.com/mihnea1711/POS_Project/services/gateway/pkg/config"
+	"github.com/mihnea1711/POS_Project/services/gateway/pkg/utils"
+)
+
+// loadInvestigationRoutes loads the routes that follow an investigation from the order to the result.
+// Lab staff work with doctor accounts, patients see their investigations in their consultations.
+func loadInvestigationRoutes(router *mux.Router, gatewayController *controllers.GatewayController, jwtConfig config.JWTConfig) {
+	// ---------------------------------------------------------- Create --------------------------------------------------------------
+	investigationOrderHandler := http.HandlerFunc(gatewayController.OrderInvestigation)
+	router.Handle(utils.ORDER_INVESTIGATION_ENDPOINT, authorization.AdminAndDoctorMiddleware(jwtConfig, validation.ValidateInvestigationOrderData(investigationOrderHandler))).Methods("POST")
+	log.Printf("[GATEWAY] Route POST %s registered.", utils.ORDER_INVESTIGATION_ENDPOINT)
+
+	// ---------------------------------------------------------- Retrieve --------------------------------------------------------------
+	pendingInvestigationsHandler := http.HandlerFunc(gatewayController.GetPendingInvestigations)
+	router.Handle(utils.GET_PENDING_INVESTIGATIONS_ENDPOINT, authorization.AdminAndDoctorMiddleware(jwtConfig, pendingInvestigationsHandler)).Methods("GET")
+	log.Printf("[GATEWAY] Route GET %s registered.", utils.GET_PENDING_INVESTIGATIONS_ENDPOINT)
+
+	investigationFetchAllHandler := http.HandlerFunc(gatewayController.GetInvestigations)
+	router.Handle(utils.GET_ALL_INVESTIGATIONS_ENDPOINT, authorization.AdminAndDoctorMiddleware(jwtConfig, investigationFetchAllHandler)).Methods("GET")
+	log.Printf("[GATEWAY] Route GET %s registered.", utils.GET_ALL_INVESTIGATIONS_ENDPOINT)
+
+	investigationFetchByIDHandler := http.HandlerFunc(gatewayController.GetInvestigationByID)
+	router.Handle(utils.GET_INVESTIGATION_BY_ID_ENDPOINT, authorization.AdminAndDoctorMiddleware(jwtConfig, investigationFetchByIDHandler)).Methods("GET")
+	log.Printf("[GATEWAY] Route GET %s registered.", utils.GET_INVESTIGATION_BY_ID_ENDPOINT)
+
+	// ---------------------------------------------------------- Update --------------------------------------------------------------
+	investigationStatusHandler := http.HandlerFunc(gatewayController.UpdateInvestigationStatus)
+	router.Handle(utils.UPDATE_INVESTIGATION_STATUS_ENDPOINT, authorization.AdminAndDoctorMiddleware(jwtConfig, validation.ValidateInvestigationStatusData(investigationStatusHandler))).Methods("PUT")
+	log.Printf("[GATEWAY] Route PUT %s registered.", utils.UPDATE_INVESTIGATION_STATUS_ENDPOINT)
+
+	investigationResultHandler := http.HandlerFunc(gatewayController.RecordInvestigationResult)
+	router.Handle(utils.RECORD_INVESTIGATION_RESULT_ENDPOINT, authorization.AdminAndDoctorMiddleware(jwtConfig, validation.ValidateInvestigationResultData(investigationResultHandler))).Methods("PUT")
+	log.Printf("[GATEWAY] Route PUT %s registered.", utils.RECORD_INVESTIGATION_RESULT_ENDPOINT)
+}
+
+// loadLabResultRoutes loads the import of the results the lab sends as HL7 messages and the review of those that could
+// not be matched. The lab posts with a doctor account, like the lab staff.
+func loadLabResultRoutes(router *mux.Router, gatewayController *controllers.GatewayController, jwtConfig config.JWTConfig) {
+	// ---------------------------------------------------------- Create --------------------------------------------------------------
+	labImportHandler := http.HandlerFunc(gatewayController.ImportLabResults)
+	router.Handle(utils.IMPORT_LAB_RESULTS_ENDPOINT, authorization.AdminAndDoctorMiddleware(jwtConfig, labImportHandler)).Methods("POST")
+	log.Printf("[GATEWAY] Route POST %s registered.", utils.IMPORT_LAB_RESULTS_ENDPOINT)
+
+	// ---------------------------------------------------------- Retrieve --------------------------------------------------------------
+	labReconciliationFetchAllHandler := http.HandlerFunc(gatewayController.GetLabReconciliationItems)
+	router.Handle(utils.GET_LAB_RECONCILIATION_ENDPOINT, authorization.AdminAndDoctorMiddleware(jwtConfig, labReconciliationFetchAllHandler)).Methods("GET")
+	log.Printf("[GATEWAY] Route GET %s registered.", utils.GET_LAB_RECONCILIATION_ENDPOINT)
+
+	labReconciliationFetchByIDHandler := http.HandlerFunc(gatewayController.GetLabReconciliationItemByID)
+	router.Handle(utils.GET_LAB_RECONCILIATION_BY_ID_ENDPOINT, authorization.AdminAndDoctorMiddleware(jwtConfig, labReconciliationFetchByIDHandler)).Methods("GET")
+	log.Printf("[GATEWAY] Route GET %s registered.", utils.GET_LAB_RECONCILIATION_BY_ID_ENDPOINT)
+
+	// ---------------------------------------------------------- Update --------------------------------------------------------------
+	labResolveHandler := http.HandlerFunc(gatewayController.ResolveLabReconciliationItem)
+	router.Handle(utils.RESOLVE_LAB_RECONCILIATION_ENDPOINT, authorization.AdminAndDoctorMiddleware(jwtConfig, validation.ValidateLabResolutionData(labResolveHandler))).Methods("POST")
+	log.Printf("[GATEWAY] Route POST %s registered.", utils.RESOLVE_LAB_RECONCILIATION_ENDPOINT)
+
+	labDiscardHandler := http.HandlerFunc(gatewayController.DiscardLabReconciliationItem)
+	router.Handle(utils.DISCARD_LAB_RECONCILIATION_ENDPOINT, authorization.AdminAndDoctorMiddleware(jwtConfig, validation.ValidateLabDiscardData(labDiscardHandler))).Methods("POST")
+	log.Printf("[GATEWAY] Route POST %s registered.", utils.DISCARD_LAB_RECONCILIATION_ENDPOINT)
+}
@@ -0,0 +1,44 @@
+package routes
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/mihnea1711/POS_Project/services/gateway/internal/controllers"
+	"github.com/mihnea1711/POS_Project/services/gateway/internal/middleware/authorization"
+	"github.com/mihnea1711/POS_Project/services/gateway/internal/middleware/validation"
+	"github.com/mihnea1711/POS_Project/services/gateway/pkg/config"
+	"github.com/mihnea1711/POS_Project/services/gateway/pkg/utils"
+)
+
+// loadMeasurementRoutes loads the routes of the vital signs and clinical measurements of the patients.
+// The types and the trend are registered before the measurement ID so they are not read as one.
+func loadMeasurementRoutes(router *mux.Router, gatewayController *controllers.GatewayController, jwtConfig config.JWTConfig) {
+	// ---------------------------------------------------------- Create --------------------------------------------------------------
+	measurementRecordHandler := http.HandlerFunc(gatewayController.RecordMeasurements)
+	router.Handle(utils.RECORD_MEASUREMENTS_ENDPOINT, authorization.AdminAndDoctorMiddleware(jwtConfig, validation.ValidateMeasurementData(measurementRecordHandler))).Methods("POST")
+	log.Printf("[GATEWAY] Route POST %s registered.", utils.RECORD_MEASUREMENTS_ENDPOINT)
+
+	// ---------------------------------------------------------- Retrieve --------------------------------------------------------------
+	measurementTypesHandler := http.HandlerFunc(gatewayController.GetMeasurementTypes)
+	router.Handle(utils.GET_MEASUREMENT_TYPES_ENDPOINT, authorization.AdminAndDoctorMiddleware(jwtConfig, measurementTypesHandler)).Methods("GET")
+	log.Printf("[GATEWAY] Route GET %s registered.", utils.GET_MEASUREMENT_TYPES_ENDPOINT)
+
+	measurementTrendHandler := http.HandlerFunc(gatewayController.GetMeasurementTrend)
+	router.Handle(utils.GET_MEASUREMENT_TREND_ENDPOINT, authorization.AdminAndDoctorMiddleware(jwtConfig, measurementTrendHandler)).Methods("GET")
+	log.Printf("[GATEWAY] Route GET %s registered.", utils.GET_MEASUREMENT_TREND_ENDPOINT)
+
+	measurementFetchAllHandler := http.HandlerFunc(gatewayController.GetMeasurements)
+	router.Handle(utils.GET_MEASUREMENTS_ENDPOINT, authorization.AdminAndDoctorMiddleware(jwtConfig, measurementFetchAllHandler)).Methods("GET")
+	log.Printf("[GATEWAY] Route GET %s registered.", utils.GET_MEASUREMENTS_ENDPOINT)
+
+	measurementFetchByIDHandler := http.HandlerFunc(gatewayController.GetMeasurementByID)
+	router.Handle(utils.GET_MEASUREMENT_BY_ID_ENDPOINT, authorization.AdminAndDoctorMiddleware(jwtConfig, measurementFetchByIDHandler)).Methods("GET")
+	log.Printf("[GATEWAY] Route GET %s registered.", utils.GET_MEASUREMENT_BY_ID_ENDPOINT)
+
+	// ---------------------------------------------------------- Update --------------------------------------------------------------
+	measurementRetractHandler := http.HandlerFunc(gatewayController.RetractMeasurement)
+	router.Handle(utils.RETRACT_MEASUREMENT_ENDPOINT, authorization.AdminAndDoctorMiddleware(jwtConfig, validation.ValidateMeasurementRetractionData(measurementRetractHandler))).Methods("POST")
+	log.Printf("[GATEWAY] Route POST %s registered.", utils.RETRACT_MEASUREMENT_ENDPOINT)
+}
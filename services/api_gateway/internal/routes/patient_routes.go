@@ -19,11 +19,60 @@ func loadPatientRoutes(router *mux.Router, gatewayController *controllers.Gatewa
 	router.Handle(utils.CREATE_PATIENT_ENDPOINT, authorization.AdminAndDoctorMiddleware(jwtConfig, validation.ValidatePatientData(patientCreationHandler))).Methods("POST")
 	log.Printf("[GATEWAY] Route POST %s registered.\n", utils.CREATE_PATIENT_ENDPOINT)
 
+	// Duplicate detection and merging are reserved to admins
+	duplicateScanHandler := http.HandlerFunc(gatewayController.ScanDuplicates)
+	router.Handle(utils.SCAN_DUPLICATES_ENDPOINT, authorization.AdminOnlyMiddleware(jwtConfig, duplicateScanHandler)).Methods("POST")
+	log.Printf("[GATEWAY] Route POST %s registered.\n", utils.SCAN_DUPLICATES_ENDPOINT)
+
+	duplicateDismissHandler := http.HandlerFunc(gatewayController.DismissDuplicateCandidate)
+	router.Handle(utils.DISMISS_DUPLICATE_CANDIDATE_ENDPOINT, authorization.AdminOnlyMiddleware(jwtConfig, duplicateDismissHandler)).Methods("POST")
+	log.Printf("[GATEWAY] Route POST %s registered.\n", utils.DISMISS_DUPLICATE_CANDIDATE_ENDPOINT)
+
+	patientMergeCreationHandler := http.HandlerFunc(gatewayController.CreatePatientMerge)
+	router.Handle(utils.CREATE_PATIENT_MERGE_ENDPOINT, authorization.AdminOnlyMiddleware(jwtConfig, validation.ValidateMergeData(patientMergeCreationHandler))).Methods("POST")
+	log.Printf("[GATEWAY] Route POST %s registered.\n", utils.CREATE_PATIENT_MERGE_ENDPOINT)
+
+	// The clinical profile is reserved to doctors and admins
+	profileCreationHandler := http.HandlerFunc(gatewayController.CreateClinicalProfile)
+	router.Handle(utils.CLINICAL_PROFILE_ENDPOINT, authorization.AdminAndDoctorMiddleware(jwtConfig, validation.ValidateClinicalProfileData(profileCreationHandler))).Methods("POST")
+	log.Printf("[GATEWAY] Route POST %s registered.\n", utils.CLINICAL_PROFILE_ENDPOINT)
+
+	// Insurance policies are kept by admins, doctors may look them up
+	policyCreationHandler := http.HandlerFunc(gatewayController.CreateInsurancePolicy)
+	router.Handle(utils.INSURANCE_POLICIES_ENDPOINT, authorization.AdminOnlyMiddleware(jwtConfig, validation.ValidateInsurancePolicyData(policyCreationHandler))).Methods("POST")
+	log.Printf("[GATEWAY] Route POST %s registered.\n", utils.INSURANCE_POLICIES_ENDPOINT)
+
+	policyVerificationHandler := http.HandlerFunc(gatewayController.VerifyInsurancePolicy)
+	router.Handle(utils.VERIFY_INSURANCE_POLICY_ENDPOINT, authorization.AdminOnlyMiddleware(jwtConfig, policyVerificationHandler)).Methods("POST")
+	log.Printf("[GATEWAY] Route POST %s registered.\n", utils.VERIFY_INSURANCE_POLICY_ENDPOINT)
+
+	// Patients may only export their own data, the controller checks it
+	exportCreationHandler := http.HandlerFunc(gatewayController.CreatePatientExport)
+	router.Handle(utils.CREATE_PATIENT_EXPORT_ENDPOINT, authorization.AdminAndPatientMiddleware(jwtConfig, exportCreationHandler)).Methods("POST")
+	log.Printf("[GATEWAY] Route POST %s registered.\n", utils.CREATE_PATIENT_EXPORT_ENDPOINT)
+
 	// ---------------------------------------------------------- Retrieve --------------------------------------------------------------
 	patientFetchAllHandler := http.HandlerFunc(gatewayController.GetPatients)
 	router.HandleFunc(utils.GET_ALL_PATIENTS_ENDPOINT, authorization.AllRolesMiddleware(jwtConfig, patientFetchAllHandler)).Methods("GET")
 	log.Printf("[GATEWAY] Route GET %s registered.\n", utils.GET_ALL_PATIENTS_ENDPOINT)
 
+	// Patients cannot look other patients up, doctors only find active patients
+	patientSearchHandler := http.HandlerFunc(gatewayController.SearchPatients)
+	router.HandleFunc(utils.SEARCH_PATIENTS_ENDPOINT, authorization.AdminAndDoctorMiddleware(jwtConfig, patientSearchHandler)).Methods("GET")
+	log.Printf("[GATEWAY] Route GET %s registered.\n", utils.SEARCH_PATIENTS_ENDPOINT)
+
+	duplicateFetchAllHandler := http.HandlerFunc(gatewayController.GetDuplicateCandidates)
+	router.Handle(utils.GET_DUPLICATE_CANDIDATES_ENDPOINT, authorization.AdminOnlyMiddleware(jwtConfig, duplicateFetchAllHandler)).Methods("GET")
+	log.Printf("[GATEWAY] Route GET %s registered.\n", utils.GET_DUPLICATE_CANDIDATES_ENDPOINT)
+
+	patientMergeFetchAllHandler := http.HandlerFunc(gatewayController.GetPatientMerges)
+	router.Handle(utils.GET_ALL_PATIENT_MERGES_ENDPOINT, authorization.AdminOnlyMiddleware(jwtConfig, patientMergeFetchAllHandler)).Methods("GET")
+	log.Printf("[GATEWAY] Route GET %s registered.\n", utils.GET_ALL_PATIENT_MERGES_ENDPOINT)
+
+	patientMergeFetchByIDHandler := http.HandlerFunc(gatewayController.GetPatientMergeByID)
+	router.Handle(utils.GET_PATIENT_MERGE_BY_ID_ENDPOINT, authorization.AdminOnlyMiddleware(jwtConfig, patientMergeFetchByIDHandler)).Methods("GET")
+	log.Printf("[GATEWAY] Route GET %s registered.\n", utils.GET_PATIENT_MERGE_BY_ID_ENDPOINT)
+
 	patientFetchByEmailHandler := http.HandlerFunc(gatewayController.GetPatientByEmail)
 	router.Handle(utils.GET_PATIENT_BY_EMAIL_ENDPOINT, authorization.AllRolesMiddleware(jwtConfig, patientFetchByEmailHandler)).Methods("GET")
 	log.Printf("[GATEWAY] Route GET %s registered.\n", utils.GET_PATIENT_BY_EMAIL_ENDPOINT)
@@ -36,13 +85,74 @@ func loadPatientRoutes(router *mux.Router, gatewayController *controllers.Gatewa
 	router.HandleFunc(utils.GET_PATIENT_BY_ID_ENDPOINT, authorization.AllRolesMiddleware(jwtConfig, patientFetchByIDHandler)).Methods("GET")
 	log.Printf("[GATEWAY] Route GET %s registered.\n", utils.GET_PATIENT_BY_ID_ENDPOINT)
 
+	profileFetchHandler := http.HandlerFunc(gatewayController.GetClinicalProfile)
+	router.Handle(utils.CLINICAL_PROFILE_ENDPOINT, authorization.AdminAndDoctorMiddleware(jwtConfig, profileFetchHandler)).Methods("GET")
+	log.Printf("[GATEWAY] Route GET %s registered.\n", utils.CLINICAL_PROFILE_ENDPOINT)
+
+	profileHistoryFetchHandler := http.HandlerFunc(gatewayController.GetClinicalProfileHistory)
+	router.Handle(utils.CLINICAL_PROFILE_HISTORY_ENDPOINT, authorization.AdminAndDoctorMiddleware(jwtConfig, profileHistoryFetchHandler)).Methods("GET")
+	log.Printf("[GATEWAY] Route GET %s registered.\n", utils.CLINICAL_PROFILE_HISTORY_ENDPOINT)
+
+	timelineFetchHandler := http.HandlerFunc(gatewayController.GetPatientTimeline)
+	router.Handle(utils.GET_PATIENT_TIMELINE_ENDPOINT, authorization.AdminAndDoctorMiddleware(jwtConfig, timelineFetchHandler)).Methods("GET")
+	log.Printf("[GATEWAY] Route GET %s registered.\n", utils.GET_PATIENT_TIMELINE_ENDPOINT)
+
+	policiesFetchHandler := http.HandlerFunc(gatewayController.GetInsurancePolicies)
+	router.Handle(utils.INSURANCE_POLICIES_ENDPOINT, authorization.AdminAndDoctorMiddleware(jwtConfig, policiesFetchHandler)).Methods("GET")
+	log.Printf("[GATEWAY] Route GET %s registered.\n", utils.INSURANCE_POLICIES_ENDPOINT)
+
+	policyFetchByIDHandler := http.HandlerFunc(gatewayController.GetInsurancePolicyByID)
+	router.Handle(utils.INSURANCE_POLICY_BY_ID_ENDPOINT, authorization.AdminAndDoctorMiddleware(jwtConfig, policyFetchByIDHandler)).Methods("GET")
+	log.Printf("[GATEWAY] Route GET %s registered.\n", utils.INSURANCE_POLICY_BY_ID_ENDPOINT)
+
+	coverageFetchHandler := http.HandlerFunc(gatewayController.GetPatientCoverage)
+	router.Handle(utils.GET_PATIENT_COVERAGE_ENDPOINT, authorization.AdminAndDoctorMiddleware(jwtConfig, coverageFetchHandler)).Methods("GET")
+	log.Printf("[GATEWAY] Route GET %s registered.\n", utils.GET_PATIENT_COVERAGE_ENDPOINT)
+
+	// Legal holds keep patients from being erased, only admins see and change them
+	legalHoldFetchHandler := http.HandlerFunc(gatewayController.GetLegalHold)
+	router.Handle(utils.LEGAL_HOLD_ENDPOINT, authorization.AdminOnlyMiddleware(jwtConfig, legalHoldFetchHandler)).Methods("GET")
+	log.Printf("[GATEWAY] Route GET %s registered.\n", utils.LEGAL_HOLD_ENDPOINT)
+
+	exportFetchHandler := http.HandlerFunc(gatewayController.GetPatientExport)
+	router.Handle(utils.GET_PATIENT_EXPORT_ENDPOINT, authorization.AdminAndPatientMiddleware(jwtConfig, exportFetchHandler)).Methods("GET")
+	log.Printf("[GATEWAY] Route GET %s registered.\n", utils.GET_PATIENT_EXPORT_ENDPOINT)
+
+	exportDownloadHandler := http.HandlerFunc(gatewayController.DownloadPatientExport)
+	router.Handle(utils.DOWNLOAD_PATIENT_EXPORT_ENDPOINT, authorization.AdminAndPatientMiddleware(jwtConfig, exportDownloadHandler)).Methods("GET")
+	log.Printf("[GATEWAY] Route GET %s registered.\n", utils.DOWNLOAD_PATIENT_EXPORT_ENDPOINT)
+
 	// ---------------------------------------------------------- Update --------------------------------------------------------------
 	patientUpdateByIDHandler := http.HandlerFunc(gatewayController.UpdatePatientByID)
 	router.Handle(utils.UPDATE_PATIENT_BY_ID_ENDPOINT, authorization.AdminAndPatientMiddleware(jwtConfig, validation.ValidatePatientData(patientUpdateByIDHandler))).Methods("PUT")
 	log.Printf("[GATEWAY] Route PUT %s registered.\n", utils.UPDATE_PATIENT_BY_ID_ENDPOINT)
 
+	profileUpdateHandler := http.HandlerFunc(gatewayController.UpdateClinicalProfile)
+	router.Handle(utils.CLINICAL_PROFILE_ENDPOINT, authorization.AdminAndDoctorMiddleware(jwtConfig, validation.ValidateClinicalProfileData(profileUpdateHandler))).Methods("PUT")
+	log.Printf("[GATEWAY] Route PUT %s registered.\n", utils.CLINICAL_PROFILE_ENDPOINT)
+
+	policyUpdateHandler := http.HandlerFunc(gatewayController.UpdateInsurancePolicy)
+	router.Handle(utils.INSURANCE_POLICY_BY_ID_ENDPOINT, authorization.AdminOnlyMiddleware(jwtConfig, validation.ValidateInsurancePolicyData(policyUpdateHandler))).Methods("PUT")
+	log.Printf("[GATEWAY] Route PUT %s registered.\n", utils.INSURANCE_POLICY_BY_ID_ENDPOINT)
+
+	legalHoldPlacementHandler := http.HandlerFunc(gatewayController.PlaceLegalHold)
+	router.Handle(utils.LEGAL_HOLD_ENDPOINT, authorization.AdminOnlyMiddleware(jwtConfig, validation.ValidateLegalHoldData(legalHoldPlacementHandler))).Methods("PUT")
+	log.Printf("[GATEWAY] Route PUT %s registered.\n", utils.LEGAL_HOLD_ENDPOINT)
+
 	// ---------------------------------------------------------- Delete --------------------------------------------------------------
 	patientDeleteByIDHandler := http.HandlerFunc(gatewayController.DeletePatientByID)
 	router.Handle(utils.DELETE_PATIENT_BY_ID_ENDPOINT, authorization.AdminOnlyMiddleware(jwtConfig, patientDeleteByIDHandler)).Methods("DELETE")
 	log.Printf("[GATEWAY] Route DELETE %s registered.\n", utils.DELETE_PATIENT_BY_ID_ENDPOINT)
+
+	profileDeleteHandler := http.HandlerFunc(gatewayController.DeleteClinicalProfile)
+	router.Handle(utils.CLINICAL_PROFILE_ENDPOINT, authorization.AdminAndDoctorMiddleware(jwtConfig, profileDeleteHandler)).Methods("DELETE")
+	log.Printf("[GATEWAY] Route DELETE %s registered.\n", utils.CLINICAL_PROFILE_ENDPOINT)
+
+	policyDeleteHandler := http.HandlerFunc(gatewayController.DeleteInsurancePolicy)
+	router.Handle(utils.INSURANCE_POLICY_BY_ID_ENDPOINT, authorization.AdminOnlyMiddleware(jwtConfig, policyDeleteHandler)).Methods("DELETE")
+	log.Printf("[GATEWAY] Route DELETE %s registered.\n", utils.INSURANCE_POLICY_BY_ID_ENDPOINT)
+
+	legalHoldReleaseHandler := http.HandlerFunc(gatewayController.ReleaseLegalHold)
+	router.Handle(utils.LEGAL_HOLD_ENDPOINT, authorization.AdminOnlyMiddleware(jwtConfig, legalHoldReleaseHandler)).Methods("DELETE")
+	log.Printf("[GATEWAY] Route DELETE %s registered.\n", utils.LEGAL_HOLD_ENDPOINT)
 }
@@ -0,0 +1,44 @@
+package routes
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/mihnea1711/POS_Project/services/gateway/internal/controllers"
+	"github.com/mihnea1711/POS_Project/services/gateway/internal/middleware/authorization"
+	"github.com/mihnea1711/POS_Project/services/gateway/internal/middleware/validation"
+	"github.com/mihnea1711/POS_Project/services/gateway/pkg/config"
+	"github.com/mihnea1711/POS_Project/services/gateway/pkg/utils"
+)
+
+// loadPrescriptionRoutes loads the routes of the prescriptions written at a consultation.
+// Pharmacy staff dispense with doctor accounts, patients get the printed prescription.
+func loadPrescriptionRoutes(router *mux.Router, gatewayController *controllers.GatewayController, jwtConfig config.JWTConfig) {
+	// ---------------------------------------------------------- Create --------------------------------------------------------------
+	prescriptionIssueHandler := http.HandlerFunc(gatewayController.IssuePrescription)
+	router.Handle(utils.ISSUE_PRESCRIPTION_ENDPOINT, authorization.AdminAndDoctorMiddleware(jwtConfig, validation.ValidatePrescriptionData(prescriptionIssueHandler))).Methods("POST")
+	log.Printf("[GATEWAY] Route POST %s registered.", utils.ISSUE_PRESCRIPTION_ENDPOINT)
+
+	// ---------------------------------------------------------- Retrieve --------------------------------------------------------------
+	drugSearchHandler := http.HandlerFunc(gatewayController.SearchDrugs)
+	router.Handle(utils.SEARCH_DRUGS_ENDPOINT, authorization.AdminAndDoctorMiddleware(jwtConfig, drugSearchHandler)).Methods("GET")
+	log.Printf("[GATEWAY] Route GET %s registered.", utils.SEARCH_DRUGS_ENDPOINT)
+
+	prescriptionFetchAllHandler := http.HandlerFunc(gatewayController.GetPrescriptions)
+	router.Handle(utils.GET_ALL_PRESCRIPTIONS_ENDPOINT, authorization.AdminAndDoctorMiddleware(jwtConfig, prescriptionFetchAllHandler)).Methods("GET")
+	log.Printf("[GATEWAY] Route GET %s registered.", utils.GET_ALL_PRESCRIPTIONS_ENDPOINT)
+
+	prescriptionFetchByIDHandler := http.HandlerFunc(gatewayController.GetPrescriptionByID)
+	router.Handle(utils.GET_PRESCRIPTION_BY_ID_ENDPOINT, authorization.AdminAndDoctorMiddleware(jwtConfig, prescriptionFetchByIDHandler)).Methods("GET")
+	log.Printf("[GATEWAY] Route GET %s registered.", utils.GET_PRESCRIPTION_BY_ID_ENDPOINT)
+
+	prescriptionPDFHandler := http.HandlerFunc(gatewayController.GetPrescriptionPDF)
+	router.Handle(utils.GET_PRESCRIPTION_PDF_ENDPOINT, authorization.AdminAndDoctorMiddleware(jwtConfig, prescriptionPDFHandler)).Methods("GET")
+	log.Printf("[GATEWAY] Route GET %s registered.", utils.GET_PRESCRIPTION_PDF_ENDPOINT)
+
+	// ---------------------------------------------------------- Update --------------------------------------------------------------
+	prescriptionStatusHandler := http.HandlerFunc(gatewayController.UpdatePrescriptionStatus)
+	router.Handle(utils.UPDATE_PRESCRIPTION_STATUS_ENDPOINT, authorization.AdminAndDoctorMiddleware(jwtConfig, validation.ValidatePrescriptionStatusData(prescriptionStatusHandler))).Methods("PUT")
+	log.Printf("[GATEWAY] Route PUT %s registered.", utils.UPDATE_PRESCRIPTION_STATUS_ENDPOINT)
+}
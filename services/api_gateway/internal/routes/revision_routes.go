@@ -0,0 +1,29 @@
+package routes
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/mihnea1711/POS_Project/services/gateway/internal/controllers"
+	"github.com/mihnea1711/POS_Project/services/gateway/internal/middleware/authorization"
+	"github.com/mihnea1711/POS_Project/services/gateway/pkg/config"
+	"github.com/mihnea1711/POS_Project/services/gateway/pkg/utils"
+)
+
+// loadRevisionRoutes loads the routes of the amendment trail of a consultation.
+// The diff route is registered before the version one so "diff" is never read as a version.
+func loadRevisionRoutes(router *mux.Router, gatewayController *controllers.GatewayController, jwtConfig config.JWTConfig) {
+	// ---------------------------------------------------------- Retrieve --------------------------------------------------------------
+	revisionFetchAllHandler := http.HandlerFunc(gatewayController.GetConsultationRevisions)
+	router.Handle(utils.GET_CONSULTATION_REVISIONS_ENDPOINT, authorization.AdminAndDoctorMiddleware(jwtConfig, revisionFetchAllHandler)).Methods("GET")
+	log.Printf("[GATEWAY] Route GET %s registered.", utils.GET_CONSULTATION_REVISIONS_ENDPOINT)
+
+	revisionDiffHandler := http.HandlerFunc(gatewayController.DiffConsultationRevisions)
+	router.Handle(utils.DIFF_CONSULTATION_REVISIONS_ENDPOINT, authorization.AdminAndDoctorMiddleware(jwtConfig, revisionDiffHandler)).Methods("GET")
+	log.Printf("[GATEWAY] Route GET %s registered.", utils.DIFF_CONSULTATION_REVISIONS_ENDPOINT)
+
+	revisionFetchByVersionHandler := http.HandlerFunc(gatewayController.GetConsultationRevision)
+	router.Handle(utils.GET_CONSULTATION_REVISION_ENDPOINT, authorization.AdminAndDoctorMiddleware(jwtConfig, revisionFetchByVersionHandler)).Methods("GET")
+	log.Printf("[GATEWAY] Route GET %s registered.", utils.GET_CONSULTATION_REVISION_ENDPOINT)
+}
@@ -6,7 +6,10 @@ import (
 
 	"github.com/gorilla/mux"
 	"github.com/mihnea1711/POS_Project/services/gateway/idm"
+	"github.com/mihnea1711/POS_Project/services/gateway/internal/calendar"
 	"github.com/mihnea1711/POS_Project/services/gateway/internal/controllers"
+	"github.com/mihnea1711/POS_Project/services/gateway/internal/documents"
+	"github.com/mihnea1711/POS_Project/services/gateway/internal/export"
 	"github.com/mihnea1711/POS_Project/services/gateway/internal/middleware"
 	"github.com/mihnea1711/POS_Project/services/gateway/internal/middleware/authorization"
 	"github.com/mihnea1711/POS_Project/services/gateway/internal/middleware/cors_config"
@@ -15,7 +18,7 @@ import (
 	"github.com/mihnea1711/POS_Project/services/gateway/pkg/utils"
 )
 
-func SetupRoutes(idmClient idm.IDMClient, jwtConfig config.JWTConfig) *mux.Router {
+func SetupRoutes(idmClient idm.IDMClient, jwtConfig config.JWTConfig, calendarConfig config.CalendarConfig, feedKeys *calendar.FeedKeys, exportConfig config.ExportConfig, fhirConfig config.FHIRConfig, renderer *documents.Renderer) *mux.Router {
 	router := mux.NewRouter()
 	log.Println("[GATEWAY] Setting up routes...")
 
@@ -35,7 +38,12 @@ func SetupRoutes(idmClient idm.IDMClient, jwtConfig config.JWTConfig) *mux.Route
 	validation.RegisterCustomValidationTags()
 
 	gatewayController := &controllers.GatewayController{
-		IDMClient: idmClient,
+		IDMClient:      idmClient,
+		CalendarConfig: calendarConfig,
+		FeedKeys:       feedKeys,
+		Exports:        export.NewStore(exportConfig),
+		FHIRConfig:     fhirConfig,
+		Documents:      renderer,
 	}
 
 	loadRoutes(router, gatewayController, jwtConfig)
@@ -54,6 +62,14 @@ func loadRoutes(router *mux.Router, gatewayController *controllers.GatewayContro
 	loadDoctorRoutes(router, gatewayController, jwtConfig)
 	loadAppointmentRoutes(router, gatewayController, jwtConfig)
 	loadConsultationRoutes(router, gatewayController, jwtConfig)
+	loadInvestigationRoutes(router, gatewayController, jwtConfig)
+	loadLabResultRoutes(router, gatewayController, jwtConfig)
+	loadRevisionRoutes(router, gatewayController, jwtConfig)
+	loadPrescriptionRoutes(router, gatewayController, jwtConfig)
+	loadMeasurementRoutes(router, gatewayController, jwtConfig)
+	loadAttachmentRoutes(router, gatewayController, jwtConfig)
+	loadCalendarRoutes(router, gatewayController, jwtConfig)
+	loadFHIRRoutes(router, gatewayController, jwtConfig)
 	loadOtherRoutes(router, gatewayController)
 	log.Println("[GATEWAY] All routes for GATEWAY entity loaded successfully.")
 }
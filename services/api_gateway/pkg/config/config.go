@@ -11,8 +11,12 @@ import (
 )
 
 type AppConfig struct {
-	Server ServerConfig `yaml:"server"`
-	JWT    JWTConfig    `yaml:"jwt"`
+	Server    ServerConfig    `yaml:"server"`
+	JWT       JWTConfig       `yaml:"jwt"`
+	Calendar  CalendarConfig  `yaml:"calendar"`
+	Export    ExportConfig    `yaml:"export"`
+	FHIR      FHIRConfig      `yaml:"fhir"`
+	Documents DocumentsConfig `yaml:"documents"`
 }
 
 type ServerConfig struct {
@@ -23,6 +27,43 @@ type JWTConfig struct {
 	Secret string `yaml:"secret"`
 }
 
+// CalendarConfig signs the calendar feed tokens. KeysFile keeps the per-user keys the tokens are derived from.
+type CalendarConfig struct {
+	FeedSecret string `yaml:"feedSecret"`
+	BaseURL    string `yaml:"baseURL"`
+	KeysFile   string `yaml:"keysFile"`
+}
+
+// ExportConfig locates the patient data exports. Bundles can be downloaded for RetentionHours after they are assembled.
+type ExportConfig struct {
+	Directory      string `yaml:"directory"`
+	RetentionHours int    `yaml:"retentionHours"`
+}
+
+// FHIRConfig holds the public base URL of the FHIR API, the full URLs of the resources start with it
+type FHIRConfig struct {
+	BaseURL string `yaml:"baseURL"`
+}
+
+// DocumentsConfig holds the templates of the printed documents and the clinic's branding. The dates are printed in
+// TimeZone, AccentColor (#RRGGBB) colors the letterhead and the headings.
+type DocumentsConfig struct {
+	Templates   string       `yaml:"templates"`
+	TimeZone    string       `yaml:"timeZone"`
+	AccentColor string       `yaml:"accentColor"`
+	Footer      string       `yaml:"footer"`
+	Clinic      ClinicConfig `yaml:"clinic"`
+}
+
+// ClinicConfig is the letterhead of the clinic
+type ClinicConfig struct {
+	Name    string `yaml:"name"`
+	Address string `yaml:"address"`
+	Phone   string `yaml:"phone"`
+	Email   string `yaml:"email"`
+	Website string `yaml:"website"`
+}
+
 // LoadConfig loads the configuration from the given file path
 func LoadConfig(filePath string) (*AppConfig, error) {
 	log.Println("[GATEWAY] Loading configuration...")
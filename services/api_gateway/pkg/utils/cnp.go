@@ -0,0 +1,117 @@
+package utils
+
+import (
+	"errors"
+	"strconv"
+	"time"
+)
+
+// cnpControlWeights are multiplied with the first 12 digits of a CNP to compute its control digit
+var cnpControlWeights = [12]int{2, 7, 9, 1, 4, 6, 3, 5, 8, 2, 7, 9}
+
+// cnpCountyCodes holds the valid county codes: the counties, the sectors of Bucharest (including the former
+// sectors 7 and 8), Călărași and Giurgiu
+var cnpCountyCodes = func() map[int]struct{} {
+	codes := map[int]struct{}{51: {}, 52: {}}
+	for code := 1; code <= 48; code++ {
+		codes[code] = struct{}{}
+	}
+	return codes
+}()
+
+var (
+	ErrCNPFormat       = errors.New("CNP must have exactly 13 digits")
+	ErrCNPSexDigit     = errors.New("CNP has an invalid sex and century digit")
+	ErrCNPBirthDate    = errors.New("CNP holds an invalid birth date")
+	ErrCNPFutureBirth  = errors.New("CNP holds a birth date in the future")
+	ErrCNPCountyCode   = errors.New("CNP holds an unknown county code")
+	ErrCNPSerialNumber = errors.New("CNP holds an invalid serial number")
+	ErrCNPControlDigit = errors.New("CNP control digit does not match")
+)
+
+// CNPInfo holds the demographics encoded in a CNP. Sex is empty for foreign citizens.
+type CNPInfo struct {
+	BirthDay time.Time
+	Sex      string
+}
+
+// ParseCNP checks a Romanian personal numeric code (CNP) and extracts the birth date and the sex.
+// The layout is S YYMMDD JJ NNN C: sex and century, birth date, county, serial number and control digit.
+// The gateway and the patient module both check CNPs, the two copies of ParseCNP are kept identical on purpose:
+// change them together with services/pacienti/pkg/utils/cnp.go and the cases of cnp_test.go.
+func ParseCNP(cnp string) (*CNPInfo, error) {
+	if len(cnp) != CNP_LENGTH {
+		return nil, ErrCNPFormat
+	}
+
+	var digits [13]int
+	for i, r := range cnp {
+		if r < '0' || r > '9' {
+			return nil, ErrCNPFormat
+		}
+		digits[i] = int(r - '0')
+	}
+
+	// Residents born before 2000 (7, 8) and foreign citizens (9) are assigned to the 20th century
+	var century int
+	var sex string
+	switch digits[0] {
+	case 1, 2:
+		century = 1900
+	case 3, 4:
+		century = 1800
+	case 5, 6:
+		century = 2000
+	case 7, 8, 9:
+		century = 1900
+	default:
+		return nil, ErrCNPSexDigit
+	}
+	switch digits[0] {
+	case 1, 3, 5, 7:
+		sex = SexMale
+	case 2, 4, 6, 8:
+		sex = SexFemale
+	}
+
+	year := century + digits[1]*10 + digits[2]
+	month := digits[3]*10 + digits[4]
+	day := digits[5]*10 + digits[6]
+	birthDay := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	// time.Date normalizes overflowing values, a valid date survives the round trip
+	if month < 1 || month > 12 || birthDay.Day() != day || birthDay.Month() != time.Month(month) {
+		return nil, ErrCNPBirthDate
+	}
+	// A control digit can be computed for any date, a person cannot be born after today
+	if birthDay.After(time.Now()) {
+		return nil, ErrCNPFutureBirth
+	}
+
+	county, _ := strconv.Atoi(cnp[7:9])
+	if _, ok := cnpCountyCodes[county]; !ok {
+		return nil, ErrCNPCountyCode
+	}
+
+	if cnp[9:12] == "000" {
+		return nil, ErrCNPSerialNumber
+	}
+
+	sum := 0
+	for i, weight := range cnpControlWeights {
+		sum += digits[i] * weight
+	}
+	control := sum % 11
+	if control == 10 {
+		control = 1
+	}
+	if control != digits[12] {
+		return nil, ErrCNPControlDigit
+	}
+
+	return &CNPInfo{BirthDay: birthDay, Sex: sex}, nil
+}
+
+// SameDay checks whether two instants fall on the same calendar date, ignoring the time and the location
+func SameDay(a, b time.Time) bool {
+	return a.Year() == b.Year() && a.Month() == b.Month() && a.Day() == b.Day()
+}
@@ -54,10 +54,26 @@ const (
 	DECODED_PATIENT_DATA           contextKey = "patient_data"
 	DECODED_APPOINTMENT_DATA       contextKey = "appointment_data"
 	DECODED_CONSULTATION_DATA      contextKey = "consultation_data"
+	DECODED_RESCHEDULING_JOB_DATA  contextKey = "rescheduling_job_data"
+	DECODED_MERGE_DATA             contextKey = "merge_data"
+	DECODED_CLINICAL_PROFILE_DATA  contextKey = "clinical_profile_data"
+	DECODED_INSURANCE_POLICY_DATA  contextKey = "insurance_policy_data"
+	DECODED_LEGAL_HOLD_DATA        contextKey = "legal_hold_data"
+	DECODED_RESOURCE_DATA          contextKey = "resource_data"
+	DECODED_APPOINTMENT_TYPE_DATA  contextKey = "appointment_type_data"
 	DECODED_USER_DATA              contextKey = "user_data"
 	DECODED_PASSWORD_DATA          contextKey = "password_data"
 	DECODED_ROLE_DATA              contextKey = "role_data"
 	DECODED_BLACKLIST_DATA         contextKey = "blacklist_data"
+	DECODED_INVESTIGATION_ORDER    contextKey = "investigation_order_data"
+	DECODED_INVESTIGATION_STATUS   contextKey = "investigation_status_data"
+	DECODED_INVESTIGATION_RESULT   contextKey = "investigation_result_data"
+	DECODED_PRESCRIPTION           contextKey = "prescription_data"
+	DECODED_PRESCRIPTION_STATUS    contextKey = "prescription_status_data"
+	DECODED_LAB_RESOLUTION         contextKey = "lab_resolution_data"
+	DECODED_LAB_DISCARD            contextKey = "lab_discard_data"
+	DECODED_MEASUREMENTS           contextKey = "measurements_data"
+	DECODED_MEASUREMENT_RETRACTION contextKey = "measurement_retraction_data"
 
 	DECODED_PATIENT_ACTIVITY_DATA contextKey = "patient_activity_data"
 	DECODED_DOCTOR_ACTIVITY_DATA  contextKey = "doctor_activity_data"
@@ -99,44 +115,79 @@ const (
 
 const (
 	// Endpoints
-	CREATE_PATIENT_ENDPOINT          = "/api/patients"
-	GET_ALL_PATIENTS_ENDPOINT        = "/api/patients"
-	GET_PATIENT_BY_ID_ENDPOINT       = "/api/patients/{" + GET_PATIENT_ID_PARAMETER + "}"
-	GET_PATIENT_BY_EMAIL_ENDPOINT    = "/api/patients/email/{" + GET_PATIENT_EMAIL_PARAMETER + "}"
-	GET_PATIENT_BY_USER_ID_ENDPOINT  = "/api/patients/users/{" + GET_PATIENT_USER_ID_PARAMETER + "}"
-	UPDATE_PATIENT_BY_ID_ENDPOINT    = "/api/patients/{" + UPDATE_PATIENT_ID_PARAMETER + "}"
-	DELETE_PATIENT_BY_ID_ENDPOINT    = "/api/patients/{" + DELETE_PATIENT_ID_PARAMETER + "}"
-	TOGGLE_PATIENT_ACTIVITY_ENDPOINT = "/api/patients/{" + SET_PATIENT_ACTIVITY_USER_ID_PARAMETER + "}"
+	CREATE_PATIENT_ENDPOINT                    = "/api/patients"
+	GET_ALL_PATIENTS_ENDPOINT                  = "/api/patients"
+	SEARCH_PATIENTS_ENDPOINT                   = "/api/patients/search"
+	GET_PATIENT_BY_ID_ENDPOINT                 = "/api/patients/{" + GET_PATIENT_ID_PARAMETER + "}"
+	GET_PATIENT_BY_EMAIL_ENDPOINT              = "/api/patients/email/{" + GET_PATIENT_EMAIL_PARAMETER + "}"
+	GET_PATIENT_BY_USER_ID_ENDPOINT            = "/api/patients/users/{" + GET_PATIENT_USER_ID_PARAMETER + "}"
+	UPDATE_PATIENT_BY_ID_ENDPOINT              = "/api/patients/{" + UPDATE_PATIENT_ID_PARAMETER + "}"
+	DELETE_PATIENT_BY_ID_ENDPOINT              = "/api/patients/{" + DELETE_PATIENT_ID_PARAMETER + "}"
+	TOGGLE_PATIENT_ACTIVITY_ENDPOINT           = "/api/patients/{" + SET_PATIENT_ACTIVITY_USER_ID_PARAMETER + "}"
+	GET_PATIENT_CALENDAR_ENDPOINT              = "/api/patients/{" + GET_PATIENT_ID_PARAMETER + "}/calendar.ics"
+	GET_PATIENT_CALENDAR_SUBSCRIPTION_ENDPOINT = "/api/patients/{" + GET_PATIENT_ID_PARAMETER + "}/calendar-subscription"
+
+	GET_DUPLICATE_CANDIDATES_ENDPOINT    = "/api/patients/duplicates"
+	SCAN_DUPLICATES_ENDPOINT             = "/api/patients/duplicates/scan"
+	DISMISS_DUPLICATE_CANDIDATE_ENDPOINT = "/api/patients/duplicates/{" + DUPLICATE_CANDIDATE_ID_PARAMETER + "}/dismiss"
+	CREATE_PATIENT_MERGE_ENDPOINT        = "/api/patients/merges"
+	GET_ALL_PATIENT_MERGES_ENDPOINT      = "/api/patients/merges"
+	GET_PATIENT_MERGE_BY_ID_ENDPOINT     = "/api/patients/merges/{" + PATIENT_MERGE_ID_PARAMETER + "}"
+
+	CLINICAL_PROFILE_ENDPOINT         = "/api/patients/{" + GET_PATIENT_ID_PARAMETER + "}/profile"
+	CLINICAL_PROFILE_HISTORY_ENDPOINT = "/api/patients/{" + GET_PATIENT_ID_PARAMETER + "}/profile/history"
+
+	INSURANCE_POLICIES_ENDPOINT      = "/api/patients/{" + GET_PATIENT_ID_PARAMETER + "}/insurance"
+	INSURANCE_POLICY_BY_ID_ENDPOINT  = "/api/patients/{" + GET_PATIENT_ID_PARAMETER + "}/insurance/{" + INSURANCE_POLICY_ID_PARAMETER + "}"
+	VERIFY_INSURANCE_POLICY_ENDPOINT = "/api/patients/{" + GET_PATIENT_ID_PARAMETER + "}/insurance/{" + INSURANCE_POLICY_ID_PARAMETER + "}/verify"
+	GET_PATIENT_COVERAGE_ENDPOINT    = "/api/patients/{" + GET_PATIENT_ID_PARAMETER + "}/coverage"
+
+	LEGAL_HOLD_ENDPOINT = "/api/patients/{" + GET_PATIENT_ID_PARAMETER + "}/legal-hold"
+
+	CREATE_PATIENT_EXPORT_ENDPOINT   = "/api/patients/{" + GET_PATIENT_ID_PARAMETER + "}/exports"
+	GET_PATIENT_EXPORT_ENDPOINT      = "/api/patients/{" + GET_PATIENT_ID_PARAMETER + "}/exports/{" + PATIENT_EXPORT_ID_PARAMETER + "}"
+	DOWNLOAD_PATIENT_EXPORT_ENDPOINT = "/api/patients/{" + GET_PATIENT_ID_PARAMETER + "}/exports/{" + PATIENT_EXPORT_ID_PARAMETER + "}/download"
+
+	GET_PATIENT_TIMELINE_ENDPOINT = "/api/patients/{" + GET_PATIENT_ID_PARAMETER + "}/timeline"
 
 	// Parameters
 	GET_PATIENT_ID_PARAMETER               = "patientID"
+	INSURANCE_POLICY_ID_PARAMETER          = "policyID"
+	PATIENT_EXPORT_ID_PARAMETER            = "exportID"
 	GET_PATIENT_EMAIL_PARAMETER            = "patientEmail"
 	GET_PATIENT_USER_ID_PARAMETER          = "patientUserID"
 	SET_PATIENT_ACTIVITY_USER_ID_PARAMETER = "patientUserID"
 	UPDATE_PATIENT_ID_PARAMETER            = "patientID"
 	DELETE_PATIENT_ID_PARAMETER            = "patientID"
+	DUPLICATE_CANDIDATE_ID_PARAMETER       = "candidateID"
+	PATIENT_MERGE_ID_PARAMETER             = "mergeID"
 
 	// PATIENT_Endpoints
 	PATIENT_CREATE_PATIENT_ENDPOINT           = "/patients"
 	PATIENT_FETCH_ALL_PATIENTS_ENDPOINT       = "/patients"
+	PATIENT_SEARCH_PATIENTS_ENDPOINT          = "/patients/search"
 	PATIENT_FETCH_PATIENT_BY_ID_ENDPOINT      = "/patients"
 	PATIENT_FETCH_PATIENT_BY_EMAIL_ENDPOINT   = "/patients/email"
 	PATIENT_FETCH_PATIENT_BY_USER_ID_ENDPOINT = "/patients/users"
 	PATIENT_UPDATE_PATIENT_BY_ID_ENDPOINT     = "/patients"
 	PATIENT_DELETE_PATIENT_BY_ID_ENDPOINT     = "/patients"
 	PATIENT_SET_PATIENT_ACTIVITY_ENDPOINT     = "/patients"
+	PATIENT_DUPLICATES_ENDPOINT               = "/patients/duplicates"
+	PATIENT_MERGES_ENDPOINT                   = "/patients/merges"
 )
 
 const (
 	// Endpoints
-	CREATE_DOCTOR_ENDPOINT          = "/api/doctors"
-	GET_ALL_DOCTORS_ENDPOINT        = "/api/doctors"
-	GET_DOCTOR_BY_ID_ENDPOINT       = "/api/doctors/{" + GET_DOCTOR_BY_ID_PARAMETER + "}"
-	GET_DOCTOR_BY_EMAIL_ENDPOINT    = "/api/doctors/email/{" + GET_DOCTOR_BY_EMAIL_PARAMETER + "}"
-	GET_DOCTOR_BY_USER_ID_ENDPOINT  = "/api/doctors/users/{" + GET_DOCTOR_BY_USER_ID_PARAMETER + "}"
-	UPDATE_DOCTOR_BY_ID_ENDPOINT    = "/api/doctors/{" + UPDATE_DOCTOR_BY_ID_PARAMETER + "}"
-	DELETE_DOCTOR_BY_ID_ENDPOINT    = "/api/doctors/{" + DELETE_DOCTOR_BY_ID_PARAMETER + "}"
-	TOGGLE_DOCTOR_ACTIVITY_ENDPOINT = "/api/doctors/{" + SET_DOCTOR_ACTIVITY_USER_ID_PARAMETER + "}"
+	CREATE_DOCTOR_ENDPOINT                    = "/api/doctors"
+	GET_ALL_DOCTORS_ENDPOINT                  = "/api/doctors"
+	GET_DOCTOR_BY_ID_ENDPOINT                 = "/api/doctors/{" + GET_DOCTOR_BY_ID_PARAMETER + "}"
+	GET_DOCTOR_BY_EMAIL_ENDPOINT              = "/api/doctors/email/{" + GET_DOCTOR_BY_EMAIL_PARAMETER + "}"
+	GET_DOCTOR_BY_USER_ID_ENDPOINT            = "/api/doctors/users/{" + GET_DOCTOR_BY_USER_ID_PARAMETER + "}"
+	UPDATE_DOCTOR_BY_ID_ENDPOINT              = "/api/doctors/{" + UPDATE_DOCTOR_BY_ID_PARAMETER + "}"
+	DELETE_DOCTOR_BY_ID_ENDPOINT              = "/api/doctors/{" + DELETE_DOCTOR_BY_ID_PARAMETER + "}"
+	TOGGLE_DOCTOR_ACTIVITY_ENDPOINT           = "/api/doctors/{" + SET_DOCTOR_ACTIVITY_USER_ID_PARAMETER + "}"
+	GET_DOCTOR_CALENDAR_ENDPOINT              = "/api/doctors/{" + GET_DOCTOR_BY_ID_PARAMETER + "}/calendar.ics"
+	GET_DOCTOR_CALENDAR_SUBSCRIPTION_ENDPOINT = "/api/doctors/{" + GET_DOCTOR_BY_ID_PARAMETER + "}/calendar-subscription"
 
 	// Parameters
 	GET_DOCTOR_BY_ID_PARAMETER            = "doctorID"
@@ -164,11 +215,34 @@ const (
 	GET_APPOINTMENT_BY_ID_ENDPOINT    = "/api/appointments/{" + GET_APPOINTMENT_ID_PARAMETER + "}"
 	UPDATE_APPOINTMENT_BY_ID_ENDPOINT = "/api/appointments/{" + UPDATE_APPOINTMENT_ID_PARAMETER + "}"
 	DELETE_APPOINTMENT_BY_ID_ENDPOINT = "/api/appointments/{" + DELETE_APPOINTMENT_ID_PARAMETER + "}"
+	CONFIRM_APPOINTMENT_ENDPOINT      = "/api/appointments/{" + CONFIRM_APPOINTMENT_ID_PARAMETER + "}/confirm"
+	GET_POLICY_OFFENDERS_ENDPOINT     = "/api/appointments/reports/offenders"
+	GET_UNRECORDED_VISITS_ENDPOINT    = "/api/appointments/reports/unrecorded-visits"
+	START_VISIT_ENDPOINT              = "/api/appointments/{" + GET_APPOINTMENT_ID_PARAMETER + "}/visit"
+
+	CREATE_RESCHEDULING_JOB_ENDPOINT       = "/api/appointments/rescheduling-jobs"
+	GET_ALL_RESCHEDULING_JOBS_ENDPOINT     = "/api/appointments/rescheduling-jobs"
+	GET_RESCHEDULING_JOB_BY_ID_ENDPOINT    = "/api/appointments/rescheduling-jobs/{" + RESCHEDULING_JOB_ID_PARAMETER + "}"
+	ACCEPT_RESCHEDULING_PROPOSAL_ENDPOINT  = "/api/appointments/rescheduling-proposals/{" + RESCHEDULING_PROPOSAL_ID_PARAMETER + "}/accept"
+	DECLINE_RESCHEDULING_PROPOSAL_ENDPOINT = "/api/appointments/rescheduling-proposals/{" + RESCHEDULING_PROPOSAL_ID_PARAMETER + "}/decline"
+
+	GET_AVAILABILITY_ENDPOINT          = "/api/appointments/availability"
+	GET_APPOINTMENT_RESOURCES_ENDPOINT = "/api/appointments/{" + GET_APPOINTMENT_ID_PARAMETER + "}/resources"
+	GET_APPOINTMENT_COVERAGE_ENDPOINT  = "/api/appointments/{" + GET_APPOINTMENT_ID_PARAMETER + "}/coverage"
+	CREATE_RESOURCE_ENDPOINT           = "/api/appointments/resources"
+	GET_ALL_RESOURCES_ENDPOINT         = "/api/appointments/resources"
+	UPDATE_RESOURCE_BY_ID_ENDPOINT     = "/api/appointments/resources/{" + RESOURCE_ID_PARAMETER + "}"
+	CREATE_APPOINTMENT_TYPE_ENDPOINT   = "/api/appointments/types"
+	GET_ALL_APPOINTMENT_TYPES_ENDPOINT = "/api/appointments/types"
 
 	// Parameters
-	GET_APPOINTMENT_ID_PARAMETER    = "appointmentID"
-	UPDATE_APPOINTMENT_ID_PARAMETER = "appointmentID"
-	DELETE_APPOINTMENT_ID_PARAMETER = "appointmentID"
+	GET_APPOINTMENT_ID_PARAMETER       = "appointmentID"
+	UPDATE_APPOINTMENT_ID_PARAMETER    = "appointmentID"
+	DELETE_APPOINTMENT_ID_PARAMETER    = "appointmentID"
+	CONFIRM_APPOINTMENT_ID_PARAMETER   = "appointmentID"
+	RESCHEDULING_JOB_ID_PARAMETER      = "jobID"
+	RESCHEDULING_PROPOSAL_ID_PARAMETER = "proposalID"
+	RESOURCE_ID_PARAMETER              = "resourceID"
 
 	// APPOINTMENT_Endpoints
 	APPOINTMENT_CREATE_APPOINTMENT_ENDPOINT       = "/appointments"
@@ -176,6 +250,15 @@ const (
 	APPOINTMENT_FETCH_APPOINTMENT_BY_ID_ENDPOINT  = "/appointments"
 	APPOINTMENT_UPDATE_APPOINTMENT_BY_ID_ENDPOINT = "/appointments"
 	APPOINTMENT_DELETE_APPOINTMENT_BY_ID_ENDPOINT = "/appointments"
+	APPOINTMENT_CONFIRM_APPOINTMENT_ENDPOINT      = "/appointments"
+	APPOINTMENT_FETCH_POLICY_OFFENDERS_ENDPOINT   = "/appointments/reports/offenders"
+	APPOINTMENT_HONOR_APPOINTMENT_ENDPOINT        = "/appointments"
+	APPOINTMENT_STATUS_HISTORY_ENDPOINT           = "/appointments/status-history"
+	APPOINTMENT_RESCHEDULING_JOBS_ENDPOINT        = "/appointments/rescheduling-jobs"
+	APPOINTMENT_RESCHEDULING_PROPOSALS_ENDPOINT   = "/appointments/rescheduling-proposals"
+	APPOINTMENT_AVAILABILITY_ENDPOINT             = "/appointments/availability"
+	APPOINTMENT_RESOURCES_ENDPOINT                = "/appointments/resources"
+	APPOINTMENT_TYPES_ENDPOINT                    = "/appointments/types"
 )
 
 const (
@@ -185,6 +268,7 @@ const (
 	GET_CONSULTATION_BY_ID_ENDPOINT    = "/api/consultations/{" + GET_CONSULTATION_BY_ID_PARAMETER + "}"
 	UPDATE_CONSULTATION_BY_ID_ENDPOINT = "/api/consultations/{" + UPDATE_CONSULTATION_BY_ID_PARAMETER + "}"
 	DELETE_CONSULTATION_BY_ID_ENDPOINT = "/api/consultations/{" + DELETE_CONSULTATION_BY_ID_PARAMETER + "}"
+	GET_VISIT_SUMMARY_ENDPOINT         = "/api/consultations/{" + GET_CONSULTATION_BY_ID_PARAMETER + "}/summary"
 
 	// Parameters
 	GET_CONSULTATION_BY_ID_PARAMETER    = "consultationID"
@@ -196,6 +280,128 @@ const (
 	CONSULTATION_FETCH_CONSULTATIE_BY_ID_ENDPOINT  = "/consultations"
 	CONSULTATION_UPDATE_CONSULTATIE_BY_ID_ENDPOINT = "/consultations"
 	CONSULTATION_DELETE_CONSULTATIE_BY_ID_ENDPOINT = "/consultations"
+	CONSULTATION_APPOINTMENT_LINKS_ENDPOINT        = "/consultations/appointments"
+)
+
+const (
+	// Attachments
+	UPLOAD_ATTACHMENT_ENDPOINT       = "/api/attachments"
+	GET_ALL_ATTACHMENTS_ENDPOINT     = "/api/attachments"
+	GET_ATTACHMENT_BY_ID_ENDPOINT    = "/api/attachments/{" + ATTACHMENT_ID_PARAMETER + "}"
+	DOWNLOAD_ATTACHMENT_ENDPOINT     = "/api/attachments/{" + ATTACHMENT_ID_PARAMETER + "}/content"
+	DELETE_ATTACHMENT_BY_ID_ENDPOINT = "/api/attachments/{" + ATTACHMENT_ID_PARAMETER + "}"
+
+	ATTACHMENT_ID_PARAMETER = "attachmentID"
+
+	CONSULTATION_ATTACHMENTS_ENDPOINT = "/consultations/attachments"
+)
+
+const (
+	// Investigations
+	GET_PENDING_INVESTIGATIONS_ENDPOINT  = "/api/investigations"
+	ORDER_INVESTIGATION_ENDPOINT         = "/api/consultations/{" + GET_CONSULTATION_BY_ID_PARAMETER + "}/investigations"
+	GET_ALL_INVESTIGATIONS_ENDPOINT      = "/api/consultations/{" + GET_CONSULTATION_BY_ID_PARAMETER + "}/investigations"
+	GET_INVESTIGATION_BY_ID_ENDPOINT     = "/api/consultations/{" + GET_CONSULTATION_BY_ID_PARAMETER + "}/investigations/{" + INVESTIGATION_ID_PARAMETER + "}"
+	UPDATE_INVESTIGATION_STATUS_ENDPOINT = "/api/consultations/{" + GET_CONSULTATION_BY_ID_PARAMETER + "}/investigations/{" + INVESTIGATION_ID_PARAMETER + "}/status"
+	RECORD_INVESTIGATION_RESULT_ENDPOINT = "/api/consultations/{" + GET_CONSULTATION_BY_ID_PARAMETER + "}/investigations/{" + INVESTIGATION_ID_PARAMETER + "}/result"
+
+	INVESTIGATION_ID_PARAMETER = "investigationID"
+
+	CONSULTATION_PENDING_INVESTIGATIONS_ENDPOINT = "/consultations/investigations"
+)
+
+const (
+	// Lab results imported from HL7 messages and the review of those that could not be matched
+	IMPORT_LAB_RESULTS_ENDPOINT           = "/api/lab-results"
+	GET_LAB_RECONCILIATION_ENDPOINT       = "/api/lab-results/reconciliation"
+	GET_LAB_RECONCILIATION_BY_ID_ENDPOINT = "/api/lab-results/reconciliation/{" + LAB_RECONCILIATION_ID_PARAMETER + "}"
+	RESOLVE_LAB_RECONCILIATION_ENDPOINT   = "/api/lab-results/reconciliation/{" + LAB_RECONCILIATION_ID_PARAMETER + "}/resolve"
+	DISCARD_LAB_RECONCILIATION_ENDPOINT   = "/api/lab-results/reconciliation/{" + LAB_RECONCILIATION_ID_PARAMETER + "}/discard"
+
+	LAB_RECONCILIATION_ID_PARAMETER = "labItemID"
+
+	CONSULTATION_LAB_RESULTS_ENDPOINT        = "/consultations/lab-results"
+	CONSULTATION_LAB_RECONCILIATION_ENDPOINT = "/consultations/lab-results/reconciliation"
+)
+
+const (
+	// Vital signs and clinical measurements, with their trends
+	RECORD_MEASUREMENTS_ENDPOINT   = "/api/measurements"
+	GET_MEASUREMENTS_ENDPOINT      = "/api/measurements"
+	GET_MEASUREMENT_TYPES_ENDPOINT = "/api/measurements/types"
+	GET_MEASUREMENT_TREND_ENDPOINT = "/api/measurements/trend"
+	GET_MEASUREMENT_BY_ID_ENDPOINT = "/api/measurements/{" + MEASUREMENT_ID_PARAMETER + "}"
+	RETRACT_MEASUREMENT_ENDPOINT   = "/api/measurements/{" + MEASUREMENT_ID_PARAMETER + "}/retraction"
+
+	MEASUREMENT_ID_PARAMETER = "measurementID"
+
+	CONSULTATION_MEASUREMENTS_ENDPOINT      = "/consultations/measurements"
+	CONSULTATION_MEASUREMENT_TYPES_ENDPOINT = "/consultations/measurements/types"
+	CONSULTATION_MEASUREMENT_TREND_ENDPOINT = "/consultations/measurements/trend"
+
+	MEASUREMENT_STATUS_ANY = "any"
+)
+
+const (
+	// ICD-10 catalog
+	SEARCH_ICD10_ENDPOINT              = "/api/icd10"
+	CONSULTATION_ICD10_SEARCH_ENDPOINT = "/consultations/icd10"
+)
+
+const (
+	// Consultation statistics
+	GET_DIAGNOSIS_STATISTICS_ENDPOINT     = "/api/consultations/statistics/diagnoses"
+	GET_INVESTIGATION_STATISTICS_ENDPOINT = "/api/consultations/statistics/investigations"
+
+	CONSULTATION_DIAGNOSIS_STATISTICS_ENDPOINT     = "/consultations/statistics/diagnoses"
+	CONSULTATION_INVESTIGATION_STATISTICS_ENDPOINT = "/consultations/statistics/investigations"
+)
+
+const (
+	// Prescriptions
+	ISSUE_PRESCRIPTION_ENDPOINT         = "/api/consultations/{" + GET_CONSULTATION_BY_ID_PARAMETER + "}/prescriptions"
+	GET_ALL_PRESCRIPTIONS_ENDPOINT      = "/api/consultations/{" + GET_CONSULTATION_BY_ID_PARAMETER + "}/prescriptions"
+	GET_PRESCRIPTION_BY_ID_ENDPOINT     = "/api/consultations/{" + GET_CONSULTATION_BY_ID_PARAMETER + "}/prescriptions/{" + PRESCRIPTION_ID_PARAMETER + "}"
+	UPDATE_PRESCRIPTION_STATUS_ENDPOINT = "/api/consultations/{" + GET_CONSULTATION_BY_ID_PARAMETER + "}/prescriptions/{" + PRESCRIPTION_ID_PARAMETER + "}/status"
+	GET_PRESCRIPTION_PDF_ENDPOINT       = "/api/consultations/{" + GET_CONSULTATION_BY_ID_PARAMETER + "}/prescriptions/{" + PRESCRIPTION_ID_PARAMETER + "}/pdf"
+	SEARCH_DRUGS_ENDPOINT               = "/api/drugs"
+
+	PRESCRIPTION_ID_PARAMETER = "prescriptionID"
+
+	CONSULTATION_DRUG_SEARCH_ENDPOINT           = "/consultations/drugs"
+	CONSULTATION_PATIENT_PRESCRIPTIONS_ENDPOINT = "/consultations/prescriptions"
+
+	PRESCRIPTION_STATUS_CANCELED = "canceled"
+
+	// PRESCRIPTION_WARNING_KIND_UNCHECKED marks the warning of a prescription issued without a clinical profile
+	PRESCRIPTION_WARNING_KIND_UNCHECKED = "unchecked"
+)
+
+const (
+	// Consultation revisions
+	GET_CONSULTATION_REVISIONS_ENDPOINT  = "/api/consultations/{" + GET_CONSULTATION_BY_ID_PARAMETER + "}/revisions"
+	DIFF_CONSULTATION_REVISIONS_ENDPOINT = "/api/consultations/{" + GET_CONSULTATION_BY_ID_PARAMETER + "}/revisions/diff"
+	GET_CONSULTATION_REVISION_ENDPOINT   = "/api/consultations/{" + GET_CONSULTATION_BY_ID_PARAMETER + "}/revisions/{" + REVISION_VERSION_PARAMETER + ":[0-9]+}"
+	REVISION_VERSION_PARAMETER           = "version"
+)
+
+const (
+	// FHIR
+	FHIR_METADATA_ENDPOINT = "/api/fhir/metadata"
+	FHIR_SEARCH_ENDPOINT   = "/api/fhir/{" + FHIR_RESOURCE_TYPE_PARAMETER + "}"
+	FHIR_READ_ENDPOINT     = "/api/fhir/{" + FHIR_RESOURCE_TYPE_PARAMETER + "}/{" + FHIR_RESOURCE_ID_PARAMETER + "}"
+
+	FHIR_RESOURCE_TYPE_PARAMETER = "resourceType"
+	FHIR_RESOURCE_ID_PARAMETER   = "resourceID"
+
+	FHIR_CONTENT_TYPE = "application/fhir+json; charset=utf-8"
+)
+
+const CNP_LENGTH = 13
+
+const (
+	SexMale   = "M"
+	SexFemale = "F"
 )
 
 const (
@@ -203,6 +409,62 @@ const (
 	QUERY_ID_DOCTOR  = "doctorID"
 	QUERY_DATE       = "date"
 	QUERY_IS_ACTIVE  = "isActive"
+	QUERY_TOKEN      = "token"
+	QUERY_PAGE       = "page"
+	QUERY_LIMIT      = "limit"
+	QUERY_OVERRIDE   = "override"
+	QUERY_TYPE_ID    = "typeID"
+	QUERY_FROM       = "from"
+	QUERY_TO         = "to"
+	QUERY_CATEGORY   = "category"
+	QUERY_KIND       = "kind"
+	QUERY_TEXT       = "q"
+	QUERY_NAME       = "name"
+	QUERY_FIRST_NAME = "firstName"
+	QUERY_CNP        = "cnp"
+	QUERY_PHONE      = "phone"
+	QUERY_EMAIL      = "email"
+	QUERY_BIRTH_FROM = "birthFrom"
+	QUERY_BIRTH_TO   = "birthTo"
+	QUERY_STATUS     = "status"
+	QUERY_CHANGED_BY = "changedBy"
+	QUERY_REASON     = "reason"
+
+	QUERY_CONSULTATION_ID  = "consultationID"
+	QUERY_INVESTIGATION_ID = "investigationID"
+	QUERY_OVERDUE          = "overdue"
+
+	QUERY_APPOINTMENT_IDS = "appointmentIDs"
+	QUERY_TYPES           = "types"
+)
+
+const (
+	APPOINTMENT_STATUS_SCHEDULED   = "scheduled"
+	APPOINTMENT_STATUS_CONFIRMED   = "confirmed"
+	APPOINTMENT_STATUS_NOT_PRESENT = "not_present"
+	APPOINTMENT_STATUS_CANCELED    = "canceled"
+	APPOINTMENT_STATUS_HONORED     = "honored"
+)
+
+const (
+	// The events of a patient's timeline
+	TIMELINE_EVENT_APPOINTMENT   = "appointment"
+	TIMELINE_EVENT_STATUS_CHANGE = "status_change"
+	TIMELINE_EVENT_CONSULTATION  = "consultation"
+	TIMELINE_EVENT_INVESTIGATION = "investigation"
+	TIMELINE_EVENT_RESULT        = "result"
+
+	// The modules the timeline is read from
+	TIMELINE_SOURCE_PATIENTS       = "patients"
+	TIMELINE_SOURCE_APPOINTMENTS   = "appointments"
+	TIMELINE_SOURCE_STATUS_HISTORY = "status_history"
+	TIMELINE_SOURCE_CONSULTATIONS  = "consultations"
+)
+
+const (
+	CALENDAR_OWNER_DOCTOR  = "doctor"
+	CALENDAR_OWNER_PATIENT = "patient"
+	CALENDAR_CONTENT_TYPE  = "text/calendar; charset=utf-8"
 )
 
 const (
@@ -211,6 +473,14 @@ const (
 
 	// Timeouts
 	REQUEST_CONTEXT_TIMEOUT = 10
+	// Duplicate scans and patient merges call several modules in turn
+	LONG_REQUEST_CONTEXT_TIMEOUT = 60
+	// An export walks every module holding patient data, it runs in the background
+	EXPORT_JOB_TIMEOUT = 300
+	// Attachment uploads and downloads stream the whole file through the gateway
+	ATTACHMENT_REQUEST_CONTEXT_TIMEOUT = 120
+	// A lab result import looks every patient up in the patient module, the consultation module allows it a minute
+	LAB_IMPORT_REQUEST_CONTEXT_TIMEOUT = 90
 )
 
 const TIME_PARSE = "2006-01-02"
@@ -224,3 +494,12 @@ var EXCLUDED_PATHS = [...]string{
 	LOGIN_USER_ENDPOINT,
 	"/api/test",
 }
+
+// EXCLUDED_PATH_PATTERNS are matched with path.Match and authorize the request through a token in the URL instead of a JWT
+var EXCLUDED_PATH_PATTERNS = [...]string{
+	"/api/appointments/*/confirm",
+	"/api/appointments/rescheduling-proposals/*/accept",
+	"/api/appointments/rescheduling-proposals/*/decline",
+	"/api/doctors/*/calendar.ics",
+	"/api/patients/*/calendar.ics",
+}
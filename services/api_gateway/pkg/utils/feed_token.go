@@ -0,0 +1,22 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// GenerateFeedToken derives the secret calendar feed token of a doctor or patient from their current feed key.
+// Calendar apps cannot send a JWT, so the token is embedded in the subscription URL instead. A new key revokes it.
+func GenerateFeedToken(secret, owner string, ownerID int, key string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(fmt.Sprintf("%s:%d:%s", owner, ownerID, key)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ValidateFeedToken checks that the token belongs to the given doctor or patient and their current feed key.
+func ValidateFeedToken(secret, owner string, ownerID int, key string, token string) bool {
+	expected := GenerateFeedToken(secret, owner, ownerID, key)
+	return hmac.Equal([]byte(expected), []byte(token))
+}
@@ -29,12 +29,22 @@ var UserEndpoints = []models.LinkData{
 var PatientEndpoints = []models.LinkData{
 	{FieldName: "create", EndpointData: models.EndpointData{Endpoint: CREATE_PATIENT_ENDPOINT, Method: "POST"}},
 	{FieldName: "getAll", EndpointData: models.EndpointData{Endpoint: GET_ALL_PATIENTS_ENDPOINT, Method: "GET"}},
+	{FieldName: "search", EndpointData: models.EndpointData{Endpoint: SEARCH_PATIENTS_ENDPOINT, Method: "GET"}},
 	{FieldName: "getById", EndpointData: models.EndpointData{Endpoint: GET_PATIENT_BY_ID_ENDPOINT, Method: "GET"}},
 	{FieldName: "getByEmail", EndpointData: models.EndpointData{Endpoint: GET_PATIENT_BY_EMAIL_ENDPOINT, Method: "GET"}},
 	{FieldName: "getByUserId", EndpointData: models.EndpointData{Endpoint: GET_PATIENT_BY_USER_ID_ENDPOINT, Method: "GET"}},
 	{FieldName: "updateById", EndpointData: models.EndpointData{Endpoint: UPDATE_PATIENT_BY_ID_ENDPOINT, Method: "PUT"}},
 	{FieldName: "deleteById", EndpointData: models.EndpointData{Endpoint: DELETE_PATIENT_BY_ID_ENDPOINT, Method: "DELETE"}},
 	{FieldName: "toggleActivity", EndpointData: models.EndpointData{Endpoint: TOGGLE_PATIENT_ACTIVITY_ENDPOINT, Method: "POST"}},
+	{FieldName: "calendarSubscription", EndpointData: models.EndpointData{Endpoint: GET_PATIENT_CALENDAR_SUBSCRIPTION_ENDPOINT, Method: "GET"}},
+	{FieldName: "regenerateCalendarSubscription", EndpointData: models.EndpointData{Endpoint: GET_PATIENT_CALENDAR_SUBSCRIPTION_ENDPOINT, Method: "POST"}},
+	{FieldName: "clinicalProfile", EndpointData: models.EndpointData{Endpoint: CLINICAL_PROFILE_ENDPOINT, Method: "GET"}},
+	{FieldName: "insurance", EndpointData: models.EndpointData{Endpoint: INSURANCE_POLICIES_ENDPOINT, Method: "GET"}},
+	{FieldName: "coverage", EndpointData: models.EndpointData{Endpoint: GET_PATIENT_COVERAGE_ENDPOINT, Method: "GET"}},
+	{FieldName: "export", EndpointData: models.EndpointData{Endpoint: CREATE_PATIENT_EXPORT_ENDPOINT, Method: "POST"}},
+	{FieldName: "timeline", EndpointData: models.EndpointData{Endpoint: GET_PATIENT_TIMELINE_ENDPOINT, Method: "GET"}},
+	{FieldName: "duplicates", EndpointData: models.EndpointData{Endpoint: GET_DUPLICATE_CANDIDATES_ENDPOINT, Method: "GET"}},
+	{FieldName: "merge", EndpointData: models.EndpointData{Endpoint: CREATE_PATIENT_MERGE_ENDPOINT, Method: "POST"}},
 }
 
 var DoctorEndpoints = []models.LinkData{
@@ -46,6 +56,8 @@ var DoctorEndpoints = []models.LinkData{
 	{FieldName: "updateById", EndpointData: models.EndpointData{Endpoint: UPDATE_DOCTOR_BY_ID_ENDPOINT, Method: "PUT"}},
 	{FieldName: "deleteById", EndpointData: models.EndpointData{Endpoint: DELETE_DOCTOR_BY_ID_ENDPOINT, Method: "DELETE"}},
 	{FieldName: "toggleActivity", EndpointData: models.EndpointData{Endpoint: TOGGLE_DOCTOR_ACTIVITY_ENDPOINT, Method: "POST"}},
+	{FieldName: "calendarSubscription", EndpointData: models.EndpointData{Endpoint: GET_DOCTOR_CALENDAR_SUBSCRIPTION_ENDPOINT, Method: "GET"}},
+	{FieldName: "regenerateCalendarSubscription", EndpointData: models.EndpointData{Endpoint: GET_DOCTOR_CALENDAR_SUBSCRIPTION_ENDPOINT, Method: "POST"}},
 }
 
 var AppointmentEndpoints = []models.LinkData{
@@ -54,6 +66,23 @@ var AppointmentEndpoints = []models.LinkData{
 	{FieldName: "getById", EndpointData: models.EndpointData{Endpoint: GET_APPOINTMENT_BY_ID_ENDPOINT, Method: "GET"}},
 	{FieldName: "updateById", EndpointData: models.EndpointData{Endpoint: UPDATE_APPOINTMENT_BY_ID_ENDPOINT, Method: "PUT"}},
 	{FieldName: "deleteById", EndpointData: models.EndpointData{Endpoint: DELETE_APPOINTMENT_BY_ID_ENDPOINT, Method: "DELETE"}},
+	{FieldName: "confirm", EndpointData: models.EndpointData{Endpoint: CONFIRM_APPOINTMENT_ENDPOINT, Method: "GET"}},
+	{FieldName: "policyOffenders", EndpointData: models.EndpointData{Endpoint: GET_POLICY_OFFENDERS_ENDPOINT, Method: "GET"}},
+	{FieldName: "unrecordedVisits", EndpointData: models.EndpointData{Endpoint: GET_UNRECORDED_VISITS_ENDPOINT, Method: "GET"}},
+	{FieldName: "startVisit", EndpointData: models.EndpointData{Endpoint: START_VISIT_ENDPOINT, Method: "GET"}},
+	{FieldName: "createReschedulingJob", EndpointData: models.EndpointData{Endpoint: CREATE_RESCHEDULING_JOB_ENDPOINT, Method: "POST"}},
+	{FieldName: "getReschedulingJobs", EndpointData: models.EndpointData{Endpoint: GET_ALL_RESCHEDULING_JOBS_ENDPOINT, Method: "GET"}},
+	{FieldName: "getReschedulingJobById", EndpointData: models.EndpointData{Endpoint: GET_RESCHEDULING_JOB_BY_ID_ENDPOINT, Method: "GET"}},
+	{FieldName: "acceptReschedulingProposal", EndpointData: models.EndpointData{Endpoint: ACCEPT_RESCHEDULING_PROPOSAL_ENDPOINT, Method: "GET"}},
+	{FieldName: "declineReschedulingProposal", EndpointData: models.EndpointData{Endpoint: DECLINE_RESCHEDULING_PROPOSAL_ENDPOINT, Method: "GET"}},
+	{FieldName: "availability", EndpointData: models.EndpointData{Endpoint: GET_AVAILABILITY_ENDPOINT, Method: "GET"}},
+	{FieldName: "getResources", EndpointData: models.EndpointData{Endpoint: GET_APPOINTMENT_RESOURCES_ENDPOINT, Method: "GET"}},
+	{FieldName: "getCoverage", EndpointData: models.EndpointData{Endpoint: GET_APPOINTMENT_COVERAGE_ENDPOINT, Method: "GET"}},
+	{FieldName: "createResource", EndpointData: models.EndpointData{Endpoint: CREATE_RESOURCE_ENDPOINT, Method: "POST"}},
+	{FieldName: "getAllResources", EndpointData: models.EndpointData{Endpoint: GET_ALL_RESOURCES_ENDPOINT, Method: "GET"}},
+	{FieldName: "updateResourceById", EndpointData: models.EndpointData{Endpoint: UPDATE_RESOURCE_BY_ID_ENDPOINT, Method: "PUT"}},
+	{FieldName: "createAppointmentType", EndpointData: models.EndpointData{Endpoint: CREATE_APPOINTMENT_TYPE_ENDPOINT, Method: "POST"}},
+	{FieldName: "getAppointmentTypes", EndpointData: models.EndpointData{Endpoint: GET_ALL_APPOINTMENT_TYPES_ENDPOINT, Method: "GET"}},
 }
 
 var ConsultationEndpoints = []models.LinkData{
@@ -62,6 +91,42 @@ var ConsultationEndpoints = []models.LinkData{
 	{FieldName: "getById", EndpointData: models.EndpointData{Endpoint: GET_CONSULTATION_BY_ID_ENDPOINT, Method: "GET"}},
 	{FieldName: "updateById", EndpointData: models.EndpointData{Endpoint: UPDATE_CONSULTATION_BY_ID_ENDPOINT, Method: "PUT"}},
 	{FieldName: "deleteById", EndpointData: models.EndpointData{Endpoint: DELETE_CONSULTATION_BY_ID_ENDPOINT, Method: "DELETE"}},
+	{FieldName: "getVisitSummary", EndpointData: models.EndpointData{Endpoint: GET_VISIT_SUMMARY_ENDPOINT, Method: "GET"}},
+	{FieldName: "searchICD10", EndpointData: models.EndpointData{Endpoint: SEARCH_ICD10_ENDPOINT, Method: "GET"}},
+	{FieldName: "diagnosisStatistics", EndpointData: models.EndpointData{Endpoint: GET_DIAGNOSIS_STATISTICS_ENDPOINT, Method: "GET"}},
+	{FieldName: "investigationStatistics", EndpointData: models.EndpointData{Endpoint: GET_INVESTIGATION_STATISTICS_ENDPOINT, Method: "GET"}},
+}
+
+var AttachmentEndpoints = []models.LinkData{
+	{FieldName: "upload", EndpointData: models.EndpointData{Endpoint: UPLOAD_ATTACHMENT_ENDPOINT, Method: "POST"}},
+	{FieldName: "getAll", EndpointData: models.EndpointData{Endpoint: GET_ALL_ATTACHMENTS_ENDPOINT, Method: "GET"}},
+	{FieldName: "getById", EndpointData: models.EndpointData{Endpoint: GET_ATTACHMENT_BY_ID_ENDPOINT, Method: "GET"}},
+	{FieldName: "download", EndpointData: models.EndpointData{Endpoint: DOWNLOAD_ATTACHMENT_ENDPOINT, Method: "GET"}},
+	{FieldName: "deleteById", EndpointData: models.EndpointData{Endpoint: DELETE_ATTACHMENT_BY_ID_ENDPOINT, Method: "DELETE"}},
+}
+
+var InvestigationEndpoints = []models.LinkData{
+	{FieldName: "getPending", EndpointData: models.EndpointData{Endpoint: GET_PENDING_INVESTIGATIONS_ENDPOINT, Method: "GET"}},
+	{FieldName: "order", EndpointData: models.EndpointData{Endpoint: ORDER_INVESTIGATION_ENDPOINT, Method: "POST"}},
+	{FieldName: "getAll", EndpointData: models.EndpointData{Endpoint: GET_ALL_INVESTIGATIONS_ENDPOINT, Method: "GET"}},
+	{FieldName: "getById", EndpointData: models.EndpointData{Endpoint: GET_INVESTIGATION_BY_ID_ENDPOINT, Method: "GET"}},
+	{FieldName: "updateStatus", EndpointData: models.EndpointData{Endpoint: UPDATE_INVESTIGATION_STATUS_ENDPOINT, Method: "PUT"}},
+	{FieldName: "recordResult", EndpointData: models.EndpointData{Endpoint: RECORD_INVESTIGATION_RESULT_ENDPOINT, Method: "PUT"}},
+}
+
+var RevisionEndpoints = []models.LinkData{
+	{FieldName: "getRevisions", EndpointData: models.EndpointData{Endpoint: GET_CONSULTATION_REVISIONS_ENDPOINT, Method: "GET"}},
+	{FieldName: "getRevision", EndpointData: models.EndpointData{Endpoint: GET_CONSULTATION_REVISION_ENDPOINT, Method: "GET"}},
+	{FieldName: "diffRevisions", EndpointData: models.EndpointData{Endpoint: DIFF_CONSULTATION_REVISIONS_ENDPOINT, Method: "GET"}},
+}
+
+var PrescriptionEndpoints = []models.LinkData{
+	{FieldName: "issue", EndpointData: models.EndpointData{Endpoint: ISSUE_PRESCRIPTION_ENDPOINT, Method: "POST"}},
+	{FieldName: "getAll", EndpointData: models.EndpointData{Endpoint: GET_ALL_PRESCRIPTIONS_ENDPOINT, Method: "GET"}},
+	{FieldName: "getById", EndpointData: models.EndpointData{Endpoint: GET_PRESCRIPTION_BY_ID_ENDPOINT, Method: "GET"}},
+	{FieldName: "updateStatus", EndpointData: models.EndpointData{Endpoint: UPDATE_PRESCRIPTION_STATUS_ENDPOINT, Method: "PUT"}},
+	{FieldName: "getPdf", EndpointData: models.EndpointData{Endpoint: GET_PRESCRIPTION_PDF_ENDPOINT, Method: "GET"}},
+	{FieldName: "searchDrugs", EndpointData: models.EndpointData{Endpoint: SEARCH_DRUGS_ENDPOINT, Method: "GET"}},
 }
 
 var AllEndpointsLinks = [][]models.LinkData{
@@ -71,6 +136,10 @@ var AllEndpointsLinks = [][]models.LinkData{
 	DoctorEndpoints,
 	AppointmentEndpoints,
 	ConsultationEndpoints,
+	AttachmentEndpoints,
+	InvestigationEndpoints,
+	RevisionEndpoints,
+	PrescriptionEndpoints,
 }
 
 func findAdjacentEndpoints(inputEndpoint, inputMethod string) models.EndpointMap {
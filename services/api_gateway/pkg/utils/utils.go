@@ -127,6 +127,13 @@ func DecodeSanitizedResponse(response *http.Response) (*models.ResponseDataWrapp
 	return &decodedResponse, nil
 }
 
+// SetQueryParam replaces the values of a query key with the given one, so a user cannot widen a query scoped to them
+func SetQueryParam(rawQuery, key, value string) string {
+	params, _ := url.ParseQuery(rawQuery)
+	params.Set(key, value)
+	return params.Encode()
+}
+
 func AppendQueryParam(rawQuery, key, value string) string {
 	// Parse the existing query parameters
 	params, _ := url.ParseQuery(rawQuery)
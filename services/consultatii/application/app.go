@@ -10,16 +10,23 @@ import (
 	"github.com/mihnea1711/POS_Project/services/consultatii/internal/database"
 	"github.com/mihnea1711/POS_Project/services/consultatii/internal/database/mongo"
 	"github.com/mihnea1711/POS_Project/services/consultatii/internal/database/redis"
+	"github.com/mihnea1711/POS_Project/services/consultatii/internal/drugs"
+	"github.com/mihnea1711/POS_Project/services/consultatii/internal/encryption"
+	"github.com/mihnea1711/POS_Project/services/consultatii/internal/icd10"
+	"github.com/mihnea1711/POS_Project/services/consultatii/internal/labresults"
+	"github.com/mihnea1711/POS_Project/services/consultatii/internal/measurements"
 	"github.com/mihnea1711/POS_Project/services/consultatii/internal/routes"
 	"github.com/mihnea1711/POS_Project/services/consultatii/pkg/config"
 	"github.com/mihnea1711/POS_Project/services/consultatii/pkg/utils"
 )
 
 type App struct {
-	router   http.Handler
-	database database.Database
-	config   *config.AppConfig
-	rdb      *redis.RedisClient
+	router      http.Handler
+	database    database.Database
+	config      *config.AppConfig
+	rdb         *redis.RedisClient
+	rotator     *encryption.Rotator
+	labImporter *labresults.Importer
 }
 
 func New(config *config.AppConfig, parentCtx context.Context) (*App, error) {
@@ -27,8 +34,15 @@ func New(config *config.AppConfig, parentCtx context.Context) (*App, error) {
 		config: config,
 	}
 
+	// The diagnostics and the investigation results are encrypted with keys from the provider
+	keyProvider, err := encryption.NewKeyProvider(config.Encryption)
+	if err != nil {
+		log.Printf("[CONSULTATION] Error initializing key provider: %v", err)
+		return nil, fmt.Errorf("failed to initialize key provider: %w", err)
+	}
+
 	// Create a MongoDB connection
-	mongoDB, err := mongo.NewMongoDB(parentCtx, &config.Mongo)
+	mongoDB, err := mongo.NewMongoDB(parentCtx, &config.Mongo, encryption.NewFieldCipher(keyProvider))
 	if err != nil {
 		log.Printf("[CONSULTATION] Error initializing MongoDB: %v", err)
 		return nil, fmt.Errorf("failed to initialize MongoDB: %w", err)
@@ -45,8 +59,39 @@ func New(config *config.AppConfig, parentCtx context.Context) (*App, error) {
 	app.rdb = rdb
 	log.Println("[CONSULTATION] Redis connection successfully established.")
 
+	app.rotator = encryption.NewRotator(app.database, keyProvider, config.Encryption)
+
+	// The coded diagnoses are checked against the bundled ICD-10 catalog
+	catalog, err := icd10.NewCatalog(config.ICD10)
+	if err != nil {
+		log.Printf("[CONSULTATION] Error loading ICD-10 catalog: %v", err)
+		return nil, fmt.Errorf("failed to load ICD-10 catalog: %w", err)
+	}
+
+	// Prescriptions are written from the bundled drug catalog, which also lists the interactions to warn about
+	drugCatalog, err := drugs.NewCatalog(config.Drugs)
+	if err != nil {
+		log.Printf("[CONSULTATION] Error loading drug catalog: %v", err)
+		return nil, fmt.Errorf("failed to load drug catalog: %w", err)
+	}
+
+	// The lab sends its results as HL7 messages, posted or dropped in a watched directory
+	labImporter, err := labresults.NewImporter(app.database, config.LabResults)
+	if err != nil {
+		log.Printf("[CONSULTATION] Error initializing lab results importer: %v", err)
+		return nil, fmt.Errorf("failed to initialize lab results importer: %w", err)
+	}
+	app.labImporter = labImporter
+
+	// Vital signs and clinical measurements are flagged against the reference ranges of the measurement catalog
+	measurementCatalog, err := measurements.NewCatalog(config.Measurements)
+	if err != nil {
+		log.Printf("[CONSULTATION] Error building measurement catalog: %v", err)
+		return nil, fmt.Errorf("failed to build measurement catalog: %w", err)
+	}
+
 	// setup router for the app
-	router := routes.SetupRoutes(parentCtx, app.database, app.rdb)
+	router := routes.SetupRoutes(parentCtx, app.database, app.rdb, app.rotator, catalog, drugCatalog, labImporter, measurementCatalog, config.Attachments)
 	app.router = router
 
 	log.Println("[CONSULTATION] Application successfully initialized.")
@@ -62,6 +107,9 @@ func (a *App) Start(ctx context.Context) error {
 	// Log the message just before starting the server in the goroutine
 	fmt.Printf("[CONSULTATION] Server started and listening on port %d\n", a.config.Server.Port)
 
+	go a.rotator.Start(ctx)
+	go a.labImporter.Start(ctx)
+
 	channel := make(chan error, 1)
 	go func() {
 		err := server.ListenAndServe()
@@ -0,0 +1,435 @@
+package controllers
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mihnea1711/POS_Project/services/consultatii/internal/models"
+	"github.com/mihnea1711/POS_Project/services/consultatii/pkg/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// errAttachmentTooLarge stops an upload as soon as it passes the size limit
+var errAttachmentTooLarge = errors.New("attachment exceeds the size limit")
+
+// UploadAttachment streams the "file" part of a multipart request into GridFS. The patient, consultation and investigation
+// come as query parameters, so the file is stored while it is read instead of waiting behind other form fields.
+func (cController *ConsultationController) UploadAttachment(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[CONSULTATION] Attempting to upload an attachment.")
+
+	ctx, cancel := context.WithTimeout(r.Context(), utils.ATTACHMENT_TIMEOUT*time.Second)
+	defer cancel()
+
+	attachment, status, err := cController.attachmentLinks(ctx, r.URL.Query())
+	if err != nil {
+		log.Printf("[CONSULTATION] Invalid attachment links: %v", err)
+		utils.RespondWithJSON(w, status, models.ResponseData{Error: err.Error(), Message: "Failed to upload attachment"})
+		return
+	}
+
+	maxSize := cController.maxAttachmentSize()
+	// The multipart framing is small, the file part itself is limited while it is streamed
+	r.Body = http.MaxBytesReader(w, r.Body, maxSize+utils.ATTACHMENT_SNIFF_LENGTH*2)
+
+	reader, err := r.MultipartReader()
+	if err != nil {
+		log.Printf("[CONSULTATION] Attachment upload is not multipart: %v", err)
+		utils.RespondWithJSON(w, http.StatusUnsupportedMediaType, models.ResponseData{Error: err.Error(), Message: "Attachments must be uploaded as multipart/form-data"})
+		return
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			errMsg := fmt.Sprintf("missing %q file part", utils.ATTACHMENT_FORM_FIELD)
+			log.Printf("[CONSULTATION] %s", errMsg)
+			utils.RespondWithJSON(w, http.StatusBadRequest, models.ResponseData{Error: errMsg, Message: "Failed to upload attachment"})
+			return
+		}
+		if err != nil {
+			log.Printf("[CONSULTATION] Error reading multipart upload: %v", err)
+			utils.RespondWithJSON(w, uploadErrorStatus(err), models.ResponseData{Error: err.Error(), Message: "Failed to read attachment upload"})
+			return
+		}
+		if part.FormName() != utils.ATTACHMENT_FORM_FIELD {
+			part.Close()
+			continue
+		}
+
+		cController.storeAttachment(ctx, w, attachment, part, maxSize)
+		part.Close()
+		return
+	}
+}
+
+// storeAttachment checks the declared content type against the allowed ones and against the leading bytes of the file,
+// so a page declared as a PDF is refused, then streams the part into the database
+func (cController *ConsultationController) storeAttachment(ctx context.Context, w http.ResponseWriter, attachment *models.Attachment, part *multipart.Part, maxSize int64) {
+	attachment.FileName = part.FileName()
+	if attachment.FileName == "" {
+		errMsg := "the file part has no file name"
+		log.Printf("[CONSULTATION] %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusBadRequest, models.ResponseData{Error: errMsg, Message: "Failed to upload attachment"})
+		return
+	}
+
+	contentType, _, err := mime.ParseMediaType(part.Header.Get("Content-Type"))
+	if err != nil || !cController.allowedContentType(contentType) {
+		errMsg := fmt.Sprintf("content type %q is not accepted for attachments", part.Header.Get("Content-Type"))
+		log.Printf("[CONSULTATION] %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusUnsupportedMediaType, models.ResponseData{Error: errMsg, Message: "Failed to upload attachment"})
+		return
+	}
+	attachment.ContentType = contentType
+
+	content := bufio.NewReaderSize(&sizeLimitedReader{reader: part, remaining: maxSize}, utils.ATTACHMENT_SNIFF_LENGTH)
+	head, err := content.Peek(utils.ATTACHMENT_SNIFF_LENGTH)
+	if err != nil && err != io.EOF {
+		log.Printf("[CONSULTATION] Error reading attachment upload: %v", err)
+		utils.RespondWithJSON(w, uploadErrorStatus(err), models.ResponseData{Error: err.Error(), Message: "Failed to read attachment upload"})
+		return
+	}
+	if len(head) == 0 {
+		errMsg := "the file is empty"
+		log.Printf("[CONSULTATION] %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusBadRequest, models.ResponseData{Error: errMsg, Message: "Failed to upload attachment"})
+		return
+	}
+
+	// Unrecognized bytes sniff as octet-stream, any other type must match the declared one
+	sniffed, _, _ := mime.ParseMediaType(http.DetectContentType(head))
+	if sniffed != "application/octet-stream" && sniffed != contentType {
+		errMsg := fmt.Sprintf("the file content looks like %s, not %s", sniffed, contentType)
+		log.Printf("[CONSULTATION] %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusUnsupportedMediaType, models.ResponseData{Error: errMsg, Message: "Failed to upload attachment"})
+		return
+	}
+
+	if err := cController.DbConn.SaveAttachment(ctx, attachment, content); err != nil {
+		status := uploadErrorStatus(err)
+		if status != http.StatusRequestEntityTooLarge {
+			status = http.StatusInternalServerError
+		}
+		log.Printf("[CONSULTATION] Error saving attachment: %v", err)
+		utils.RespondWithJSON(w, status, models.ResponseData{Error: err.Error(), Message: "Failed to upload attachment"})
+		return
+	}
+
+	log.Printf("[CONSULTATION] Successfully uploaded attachment %s", attachment.IDAttachment.Hex())
+	utils.RespondWithJSON(w, http.StatusCreated, models.ResponseData{
+		Message: "Attachment uploaded successfully.",
+		Payload: attachment,
+	})
+}
+
+// GetAttachments lists the attachments of a patient, a consultation or an investigation, the most recent first
+func (cController *ConsultationController) GetAttachments(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[CONSULTATION] Attempting to retrieve attachments.")
+
+	filter, err := attachmentFilter(r.URL.Query())
+	if err != nil {
+		log.Printf("[CONSULTATION] GetAttachments: Failed to extract filters: %v", err)
+		utils.RespondWithJSON(w, http.StatusBadRequest, models.ResponseData{Error: err.Error(), Message: "Failed to extract filters"})
+		return
+	}
+
+	limit, page := utils.ExtractPaginationParams(r)
+
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_TIMEOUT_DURATION*time.Second)
+	defer cancel()
+
+	attachments, err := cController.DbConn.FetchAttachments(ctx, filter, page, limit)
+	if err != nil {
+		log.Printf("[CONSULTATION] Failed to fetch attachments: %v", err)
+		utils.RespondWithJSON(w, http.StatusInternalServerError, models.ResponseData{Error: err.Error(), Message: "Failed to retrieve attachments"})
+		return
+	}
+
+	log.Printf("[CONSULTATION] Successfully retrieved %d attachments", len(attachments))
+	utils.RespondWithJSON(w, http.StatusOK, models.ResponseData{
+		Message: "Attachments retrieved successfully.",
+		Payload: attachments,
+	})
+}
+
+// GetAttachmentByID returns the metadata of an attachment
+func (cController *ConsultationController) GetAttachmentByID(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[CONSULTATION] Attempting to retrieve an attachment by ID.")
+
+	attachmentID, ok := attachmentIDFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_TIMEOUT_DURATION*time.Second)
+	defer cancel()
+
+	attachment, err := cController.DbConn.FetchAttachmentByID(ctx, attachmentID)
+	if err != nil {
+		handleAttachmentError(w, err, attachmentID, "Failed to retrieve attachment")
+		return
+	}
+
+	log.Printf("[CONSULTATION] Successfully fetched attachment %s", attachmentID.Hex())
+	utils.RespondWithJSON(w, http.StatusOK, models.ResponseData{
+		Message: "Attachment retrieved successfully.",
+		Payload: attachment,
+	})
+}
+
+// DownloadAttachment streams the content of an attachment as a file download
+func (cController *ConsultationController) DownloadAttachment(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[CONSULTATION] Attempting to download an attachment.")
+
+	attachmentID, ok := attachmentIDFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), utils.ATTACHMENT_TIMEOUT*time.Second)
+	defer cancel()
+
+	attachment, err := cController.DbConn.FetchAttachmentByID(ctx, attachmentID)
+	if err != nil {
+		handleAttachmentError(w, err, attachmentID, "Failed to download attachment")
+		return
+	}
+
+	content, err := cController.DbConn.OpenAttachmentContent(ctx, attachmentID)
+	if err != nil {
+		handleAttachmentError(w, err, attachmentID, "Failed to download attachment")
+		return
+	}
+	defer content.Close()
+
+	// The disposition also keeps the response sanitizer away from the file bytes
+	w.Header().Set("Content-Type", attachment.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(attachment.Size, 10))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.FileName}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+
+	written, err := io.Copy(w, content)
+	if err != nil {
+		// The status is already sent, the client sees a truncated body
+		log.Printf("[CONSULTATION] Error streaming attachment %s after %d bytes: %v", attachmentID.Hex(), written, err)
+		return
+	}
+
+	log.Printf("[CONSULTATION] Attachment %s downloaded, %d bytes", attachmentID.Hex(), written)
+}
+
+// DeleteAttachmentByID removes an attachment and its content
+func (cController *ConsultationController) DeleteAttachmentByID(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[CONSULTATION] Attempting to delete an attachment by ID.")
+
+	attachmentID, ok := attachmentIDFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_TIMEOUT_DURATION*time.Second)
+	defer cancel()
+
+	rowsAffected, err := cController.DbConn.DeleteAttachmentByID(ctx, attachmentID)
+	if err != nil {
+		handleAttachmentError(w, err, attachmentID, "Failed to delete attachment")
+		return
+	}
+	if rowsAffected == 0 {
+		handleAttachmentError(w, mongo.ErrNoDocuments, attachmentID, "Failed to delete attachment")
+		return
+	}
+
+	log.Printf("[CONSULTATION] Successfully deleted attachment %s", attachmentID.Hex())
+	utils.RespondWithJSON(w, http.StatusOK, models.ResponseData{
+		Message: "Attachment deleted successfully.",
+		Payload: models.RowsAffected{RowsAffected: rowsAffected},
+	})
+}
+
+// attachmentLinks reads the patient, consultation and investigation of an upload. The consultation must belong to the
+// patient and the investigation to the consultation.
+func (cController *ConsultationController) attachmentLinks(ctx context.Context, query url.Values) (*models.Attachment, int, error) {
+	for key := range query {
+		switch key {
+		case utils.QUERY_PATIENT_ID, utils.QUERY_CONSULTATION_ID, utils.QUERY_INVESTIGATION_ID, utils.QUERY_DESCRIPTION:
+		default:
+			return nil, http.StatusBadRequest, fmt.Errorf("unknown parameter: %s", key)
+		}
+	}
+
+	patientID, err := strconv.Atoi(query.Get(utils.QUERY_PATIENT_ID))
+	if err != nil || patientID <= 0 {
+		return nil, http.StatusBadRequest, fmt.Errorf("%s must be a positive number", utils.QUERY_PATIENT_ID)
+	}
+	attachment := &models.Attachment{
+		IDPatient:   patientID,
+		Description: query.Get(utils.QUERY_DESCRIPTION),
+	}
+
+	consultationHex := query.Get(utils.QUERY_CONSULTATION_ID)
+	investigationHex := query.Get(utils.QUERY_INVESTIGATION_ID)
+	if consultationHex == "" {
+		if investigationHex != "" {
+			return nil, http.StatusBadRequest, fmt.Errorf("%s needs the %s it belongs to", utils.QUERY_INVESTIGATION_ID, utils.QUERY_CONSULTATION_ID)
+		}
+		return attachment, http.StatusOK, nil
+	}
+
+	consultationID, err := primitive.ObjectIDFromHex(consultationHex)
+	if err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("invalid %s: %s", utils.QUERY_CONSULTATION_ID, consultationHex)
+	}
+	consultation, err := cController.DbConn.FetchConsultationByID(ctx, consultationID)
+	if err == mongo.ErrNoDocuments {
+		return nil, http.StatusNotFound, fmt.Errorf("consultation %s not found", consultationHex)
+	}
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	if consultation.IDPatient != patientID {
+		return nil, http.StatusConflict, fmt.Errorf("consultation %s does not belong to patient %d", consultationHex, patientID)
+	}
+	attachment.IDConsultation = &consultationID
+
+	if investigationHex == "" {
+		return attachment, http.StatusOK, nil
+	}
+	investigationID, err := primitive.ObjectIDFromHex(investigationHex)
+	if err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("invalid %s: %s", utils.QUERY_INVESTIGATION_ID, investigationHex)
+	}
+	for _, investigation := range consultation.Investigations {
+		if investigation.IDInvestigation == investigationID {
+			attachment.IDInvestigation = &investigationID
+			return attachment, http.StatusOK, nil
+		}
+	}
+	return nil, http.StatusNotFound, fmt.Errorf("investigation %s not found in consultation %s", investigationHex, consultationHex)
+}
+
+func (cController *ConsultationController) maxAttachmentSize() int64 {
+	maxSizeMB := cController.Attachments.MaxSizeMB
+	if maxSizeMB <= 0 {
+		maxSizeMB = utils.DEFAULT_ATTACHMENT_MAX_SIZE_MB
+	}
+	return int64(maxSizeMB) << 20
+}
+
+func (cController *ConsultationController) allowedContentType(contentType string) bool {
+	allowed := cController.Attachments.ContentTypes
+	if len(allowed) == 0 {
+		allowed = utils.DEFAULT_ATTACHMENT_CONTENT_TYPES
+	}
+	for _, candidate := range allowed {
+		if candidate == contentType {
+			return true
+		}
+	}
+	return false
+}
+
+// attachmentFilter builds the listing filter, at least the patient or the consultation is required
+func attachmentFilter(query url.Values) (bson.M, error) {
+	filter := bson.M{}
+	for key, values := range query {
+		value := values[0]
+		switch key {
+		case utils.QUERY_PATIENT_ID:
+			patientID, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s: %s", key, value)
+			}
+			filter[utils.COLUMN_ATTACHMENT_ID_PATIENT] = patientID
+		case utils.QUERY_CONSULTATION_ID, utils.QUERY_INVESTIGATION_ID:
+			id, err := primitive.ObjectIDFromHex(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s: %s", key, value)
+			}
+			column := utils.COLUMN_ATTACHMENT_ID_CONSULTATION
+			if key == utils.QUERY_INVESTIGATION_ID {
+				column = utils.COLUMN_ATTACHMENT_ID_INVESTIGATION
+			}
+			filter[column] = id
+		case utils.QUERY_PAGE, utils.QUERY_LIMIT:
+		default:
+			return nil, fmt.Errorf("unknown filter: %s", key)
+		}
+	}
+
+	_, byPatient := filter[utils.COLUMN_ATTACHMENT_ID_PATIENT]
+	_, byConsultation := filter[utils.COLUMN_ATTACHMENT_ID_CONSULTATION]
+	if !byPatient && !byConsultation {
+		return nil, fmt.Errorf("%s or %s is required", utils.QUERY_PATIENT_ID, utils.QUERY_CONSULTATION_ID)
+	}
+	return filter, nil
+}
+
+func attachmentIDFromRequest(w http.ResponseWriter, r *http.Request) (primitive.ObjectID, bool) {
+	attachmentHex := mux.Vars(r)[utils.ATTACHMENT_ID_PARAMETER]
+	attachmentID, err := primitive.ObjectIDFromHex(attachmentHex)
+	if err != nil {
+		log.Printf("[CONSULTATION] Invalid attachment ID: %s", attachmentHex)
+		utils.RespondWithJSON(w, http.StatusBadRequest, models.ResponseData{
+			Error:   "Invalid attachment ID",
+			Message: "Invalid attachment ID. Please provide a valid ID.",
+		})
+		return primitive.NilObjectID, false
+	}
+	return attachmentID, true
+}
+
+func handleAttachmentError(w http.ResponseWriter, err error, attachmentID primitive.ObjectID, message string) {
+	if err == mongo.ErrNoDocuments {
+		log.Printf("[CONSULTATION] Attachment %s not found", attachmentID.Hex())
+		utils.RespondWithJSON(w, http.StatusNotFound, models.ResponseData{
+			Error:   fmt.Sprintf("Attachment %s not found", attachmentID.Hex()),
+			Message: message,
+		})
+		return
+	}
+
+	log.Printf("[CONSULTATION] %s %s: %v", message, attachmentID.Hex(), err)
+	utils.RespondWithJSON(w, http.StatusInternalServerError, models.ResponseData{
+		Error:   err.Error(),
+		Message: message,
+	})
+}
+
+// uploadErrorStatus tells a body over the size limit apart from a broken upload
+func uploadErrorStatus(err error) int {
+	var maxBytesErr *http.MaxBytesError
+	if errors.Is(err, errAttachmentTooLarge) || errors.As(err, &maxBytesErr) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusBadRequest
+}
+
+// sizeLimitedReader fails with errAttachmentTooLarge once more than the limit was read
+type sizeLimitedReader struct {
+	reader    io.Reader
+	remaining int64
+}
+
+func (l *sizeLimitedReader) Read(p []byte) (int, error) {
+	n, err := l.reader.Read(p)
+	l.remaining -= int64(n)
+	if l.remaining < 0 {
+		return n, errAttachmentTooLarge
+	}
+	return n, err
+}
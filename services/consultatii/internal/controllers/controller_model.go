@@ -6,12 +6,24 @@ import (
 	"net/http"
 
 	"github.com/mihnea1711/POS_Project/services/consultatii/internal/database"
+	"github.com/mihnea1711/POS_Project/services/consultatii/internal/drugs"
+	"github.com/mihnea1711/POS_Project/services/consultatii/internal/encryption"
+	"github.com/mihnea1711/POS_Project/services/consultatii/internal/icd10"
+	"github.com/mihnea1711/POS_Project/services/consultatii/internal/labresults"
+	"github.com/mihnea1711/POS_Project/services/consultatii/internal/measurements"
 	"github.com/mihnea1711/POS_Project/services/consultatii/internal/models"
+	"github.com/mihnea1711/POS_Project/services/consultatii/pkg/config"
 	"github.com/mihnea1711/POS_Project/services/consultatii/pkg/utils"
 )
 
 type ConsultationController struct {
-	DbConn database.Database
+	DbConn       database.Database
+	Rotator      *encryption.Rotator
+	Attachments  config.AttachmentsConfig
+	Catalog      *icd10.Catalog
+	DrugCatalog  *drugs.Catalog
+	LabImporter  *labresults.Importer
+	Measurements *measurements.Catalog
 }
 
 func (cc *ConsultationController) handleContextTimeout(ctx context.Context, w http.ResponseWriter) {
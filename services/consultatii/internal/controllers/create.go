@@ -26,11 +26,32 @@ func (cController *ConsultationController) CreateConsultation(w http.ResponseWri
 
 	cController.handleContextTimeout(ctx, w)
 
+	// The coded diagnoses must be in the ICD-10 catalog
+	if !cController.codeDiagnoses(w, consultation.Diagnoses) {
+		return
+	}
+
 	// Assign an ID to the consultation
 	consultation.IDConsultation = primitive.NewObjectID()
 
+	// The investigations are ordered with the consultation, the ones sent with a result are resulted already
+	now := time.Now().UTC()
+	for i := range consultation.Investigations {
+		startInvestigation(&consultation.Investigations[i], now)
+	}
+
+	// The consultation is saved as its first revision, credited to the user the gateway names
+	author, ok := revisionAuthor(w, r)
+	if !ok {
+		return
+	}
+	reason := r.URL.Query().Get(utils.QUERY_REASON)
+	if reason == "" {
+		reason = utils.REVISION_REASON_CREATED
+	}
+
 	// Use cController.DbConn to save the consultation to the database
-	insertedID, err := cController.DbConn.SaveConsultation(ctx, consultation)
+	insertedID, err := cController.DbConn.SaveConsultation(ctx, consultation, author, reason)
 	if err != nil {
 		handleDatabaseCreateError(w, err)
 		return
@@ -0,0 +1,65 @@
+package controllers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/mihnea1711/POS_Project/services/consultatii/internal/models"
+	"github.com/mihnea1711/POS_Project/services/consultatii/pkg/utils"
+)
+
+// SearchICD10 is the autocomplete of the coded diagnoses. The query q matches the start of a code or words of its
+// description, limit caps the number of suggestions.
+func (cController *ConsultationController) SearchICD10(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[CONSULTATION] Attempting to search the ICD-10 catalog.")
+
+	query := r.URL.Query().Get(utils.QUERY_TEXT)
+	if query == "" {
+		errMsg := fmt.Sprintf("the %s query is required", utils.QUERY_TEXT)
+		log.Printf("[CONSULTATION] %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusBadRequest, models.ResponseData{Error: errMsg, Message: "Invalid ICD-10 search"})
+		return
+	}
+
+	limit := utils.DEFAULT_ICD10_SEARCH_LIMIT
+	if value := r.URL.Query().Get(utils.QUERY_LIMIT); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			errMsg := fmt.Sprintf("invalid %s: %s", utils.QUERY_LIMIT, value)
+			log.Printf("[CONSULTATION] %s", errMsg)
+			utils.RespondWithJSON(w, http.StatusBadRequest, models.ResponseData{Error: errMsg, Message: "Invalid ICD-10 search"})
+			return
+		}
+		limit = min(parsed, utils.MAX_ICD10_SEARCH_LIMIT)
+	}
+
+	entries := cController.Catalog.Search(query, limit)
+
+	log.Printf("[CONSULTATION] Found %d ICD-10 codes for %q", len(entries), query)
+	utils.RespondWithJSON(w, http.StatusOK, models.ResponseData{
+		Message: "ICD-10 codes retrieved successfully.",
+		Payload: entries,
+	})
+}
+
+// codeDiagnoses checks the diagnoses against the ICD-10 catalog and gives them its descriptions, answering the request
+// when a code is not in the catalog
+func (cController *ConsultationController) codeDiagnoses(w http.ResponseWriter, diagnoses []models.Diagnosis) bool {
+	for i := range diagnoses {
+		entry, ok := cController.Catalog.Lookup(diagnoses[i].Code)
+		if !ok {
+			errMsg := fmt.Sprintf("unknown ICD-10 code: %s", diagnoses[i].Code)
+			log.Printf("[CONSULTATION] %s", errMsg)
+			utils.RespondWithJSON(w, http.StatusBadRequest, models.ResponseData{
+				Error:   errMsg,
+				Message: "Consultation validation failed due to an unknown diagnosis code.",
+			})
+			return false
+		}
+		diagnoses[i].Code = entry.Code
+		diagnoses[i].Description = entry.Description
+	}
+	return true
+}
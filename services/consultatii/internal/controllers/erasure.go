@@ -0,0 +1,119 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mihnea1711/POS_Project/services/consultatii/internal/models"
+	"github.com/mihnea1711/POS_Project/services/consultatii/pkg/utils"
+)
+
+// ErasePatientConsultations answers the erasure of a patient. Consultations are medical records that must be kept for
+// the legal retention period, so none is changed: they hold no identifying data besides the patient ID, and the
+// patient module pseudonymizes the patient behind it. Attachments are medical documents and prescriptions are medical
+// records, both are retained as well. The report counts what was retained.
+func (cController *ConsultationController) ErasePatientConsultations(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[CONSULTATION] Attempting to erase patient consultations.")
+
+	patientIDStr := mux.Vars(r)[utils.FETCH_CONSULTATIE_BY_PATIENT_ID_PARAMETER]
+	patientID, err := strconv.Atoi(patientIDStr)
+	if err != nil {
+		errMsg := fmt.Sprintf("Invalid patient ID: %s", patientIDStr)
+		log.Printf("[CONSULTATION] %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusBadRequest, models.ResponseData{Error: errMsg, Message: "Invalid erasure request"})
+		return
+	}
+
+	// Ensure a database operation doesn't take longer than utils.REQUEST_TIMEOUT_DURATION seconds
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_TIMEOUT_DURATION*time.Second)
+	defer cancel()
+
+	count, err := cController.DbConn.CountPatientConsultations(ctx, patientID)
+	if err != nil {
+		log.Printf("[CONSULTATION] Error erasing the consultations of patient %d: %v", patientID, err)
+		utils.RespondWithJSON(w, http.StatusInternalServerError, models.ResponseData{
+			Error:   err.Error(),
+			Message: "Failed to erase patient consultations",
+		})
+		return
+	}
+
+	attachmentCount, err := cController.DbConn.CountPatientAttachments(ctx, patientID)
+	if err != nil {
+		log.Printf("[CONSULTATION] Error erasing the attachments of patient %d: %v", patientID, err)
+		utils.RespondWithJSON(w, http.StatusInternalServerError, models.ResponseData{
+			Error:   err.Error(),
+			Message: "Failed to erase patient consultations",
+		})
+		return
+	}
+
+	// Each revision is a full copy of the consultation it was taken of
+	revisionCount, err := cController.DbConn.CountPatientRevisions(ctx, patientID)
+	if err != nil {
+		log.Printf("[CONSULTATION] Error erasing the consultation revisions of patient %d: %v", patientID, err)
+		utils.RespondWithJSON(w, http.StatusInternalServerError, models.ResponseData{
+			Error:   err.Error(),
+			Message: "Failed to erase patient consultations",
+		})
+		return
+	}
+
+	prescriptionCount, err := cController.DbConn.CountPatientPrescriptions(ctx, patientID)
+	if err != nil {
+		log.Printf("[CONSULTATION] Error erasing the prescriptions of patient %d: %v", patientID, err)
+		utils.RespondWithJSON(w, http.StatusInternalServerError, models.ResponseData{
+			Error:   err.Error(),
+			Message: "Failed to erase patient consultations",
+		})
+		return
+	}
+
+	measurementCount, err := cController.DbConn.CountPatientMeasurements(ctx, patientID)
+	if err != nil {
+		log.Printf("[CONSULTATION] Error erasing the measurements of patient %d: %v", patientID, err)
+		utils.RespondWithJSON(w, http.StatusInternalServerError, models.ResponseData{
+			Error:   err.Error(),
+			Message: "Failed to erase patient consultations",
+		})
+		return
+	}
+
+	records := []models.ErasureRecord{{
+		Category: utils.ERASURE_CATEGORY_CONSULTATIONS,
+		Action:   utils.ErasureActionRetained,
+		Count:    count,
+		Reason:   "medical records, kept for the legal retention period under the pseudonymized patient",
+	}, {
+		Category: utils.ERASURE_CATEGORY_ATTACHMENTS,
+		Action:   utils.ErasureActionRetained,
+		Count:    attachmentCount,
+		Reason:   "medical documents, kept for the legal retention period; their content may still identify the patient",
+	}, {
+		Category: utils.ERASURE_CATEGORY_REVISIONS,
+		Action:   utils.ErasureActionRetained,
+		Count:    revisionCount,
+		Reason:   "amendment trail of the medical records, kept with them for the legal retention period",
+	}, {
+		Category: utils.ERASURE_CATEGORY_PRESCRIPTIONS,
+		Action:   utils.ErasureActionRetained,
+		Count:    prescriptionCount,
+		Reason:   "prescriptions are medical records, kept for the legal retention period under the pseudonymized patient",
+	}, {
+		Category: utils.ERASURE_CATEGORY_MEASUREMENTS,
+		Action:   utils.ErasureActionRetained,
+		Count:    measurementCount,
+		Reason:   "vital signs and clinical measurements are medical records, kept for the legal retention period under the pseudonymized patient",
+	}}
+
+	log.Printf("[CONSULTATION] Retained %d consultations, %d revisions, %d attachments, %d prescriptions and %d measurements of erased patient %d", count, revisionCount, attachmentCount, prescriptionCount, measurementCount, patientID)
+	utils.RespondWithJSON(w, http.StatusOK, models.ResponseData{
+		Payload: records,
+		Message: fmt.Sprintf("Consultations of patient %d retained for the legal retention period", patientID),
+	})
+}
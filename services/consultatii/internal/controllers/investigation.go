@@ -0,0 +1,425 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mihnea1711/POS_Project/services/consultatii/internal/database"
+	"github.com/mihnea1711/POS_Project/services/consultatii/internal/models"
+	"github.com/mihnea1711/POS_Project/services/consultatii/pkg/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// GetInvestigations lists the investigations of a consultation
+func (cController *ConsultationController) GetInvestigations(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[CONSULTATION] Attempting to retrieve the investigations of a consultation.")
+
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_TIMEOUT_DURATION*time.Second)
+	defer cancel()
+
+	consultation, ok := cController.fetchRequestedConsultation(ctx, w, r)
+	if !ok {
+		return
+	}
+
+	investigations := consultation.Investigations
+	if investigations == nil {
+		investigations = []models.Investigation{}
+	}
+
+	log.Printf("[CONSULTATION] Successfully retrieved %d investigations of consultation %s", len(investigations), consultation.IDConsultation.Hex())
+	utils.RespondWithJSON(w, http.StatusOK, models.ResponseData{
+		Message: "Investigations retrieved successfully.",
+		Payload: investigations,
+	})
+}
+
+// GetInvestigationByID returns an investigation of a consultation
+func (cController *ConsultationController) GetInvestigationByID(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[CONSULTATION] Attempting to retrieve an investigation by ID.")
+
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_TIMEOUT_DURATION*time.Second)
+	defer cancel()
+
+	_, investigation, ok := cController.fetchRequestedInvestigation(ctx, w, r)
+	if !ok {
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, models.ResponseData{
+		Message: "Investigation retrieved successfully.",
+		Payload: investigation,
+	})
+}
+
+// OrderInvestigation adds an investigation waiting for its result to an existing consultation
+func (cController *ConsultationController) OrderInvestigation(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[CONSULTATION] Attempting to order an investigation.")
+
+	order := r.Context().Value(utils.DECODED_INVESTIGATION_ORDER).(*models.InvestigationOrder)
+
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_TIMEOUT_DURATION*time.Second)
+	defer cancel()
+
+	consultation, ok := cController.fetchRequestedConsultation(ctx, w, r)
+	if !ok {
+		return
+	}
+
+	investigation := models.Investigation{
+		IDInvestigation: primitive.NewObjectID(),
+		Name:            order.Name,
+		ProcessingTime:  order.ProcessingTime,
+	}
+	startInvestigation(&investigation, time.Now().UTC())
+	consultation.Investigations = append(consultation.Investigations, investigation)
+
+	reason := fmt.Sprintf("investigation %s ordered", investigation.Name)
+	if !cController.saveInvestigation(ctx, w, r, consultation, reason) {
+		return
+	}
+
+	log.Printf("[CONSULTATION] Investigation %s ordered on consultation %s", investigation.IDInvestigation.Hex(), consultation.IDConsultation.Hex())
+	utils.RespondWithJSON(w, http.StatusCreated, models.ResponseData{
+		Message: "Investigation ordered successfully.",
+		Payload: investigation,
+	})
+}
+
+// UpdateInvestigationStatus moves an investigation along its lifecycle, such as when the sample is collected or the
+// order is canceled. A move the lifecycle does not allow is a conflict.
+func (cController *ConsultationController) UpdateInvestigationStatus(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[CONSULTATION] Attempting to change the status of an investigation.")
+
+	change := r.Context().Value(utils.DECODED_INVESTIGATION_STATUS).(*models.InvestigationStatusChange)
+
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_TIMEOUT_DURATION*time.Second)
+	defer cancel()
+
+	consultation, investigation, ok := cController.fetchRequestedInvestigation(ctx, w, r)
+	if !ok {
+		return
+	}
+
+	fromStatus := investigation.Status
+	if !utils.CanTransitionInvestigation(fromStatus, change.Status) {
+		respondWithInvestigationConflict(w, fmt.Sprintf("an investigation cannot move from %s to %s", fromStatus, change.Status))
+		return
+	}
+
+	now := time.Now().UTC()
+	investigation.Status = change.Status
+	switch change.Status {
+	case utils.INVESTIGATION_STATUS_SAMPLE_COLLECTED:
+		investigation.SampleCollectedAt = &now
+		investigation.ExpectedCompletion = utils.ExpectedInvestigationCompletion(investigation)
+	case utils.INVESTIGATION_STATUS_CANCELED:
+		investigation.CanceledAt = &now
+		investigation.CancelReason = change.Reason
+	}
+
+	reason := fmt.Sprintf("investigation %s moved from %s to %s", investigation.Name, fromStatus, investigation.Status)
+	if !cController.saveInvestigation(ctx, w, r, consultation, reason) {
+		return
+	}
+
+	log.Printf("[CONSULTATION] Investigation %s of consultation %s is now %s", investigation.IDInvestigation.Hex(), consultation.IDConsultation.Hex(), investigation.Status)
+	utils.RespondWithJSON(w, http.StatusOK, models.ResponseData{
+		Message: "Investigation status updated successfully.",
+		Payload: investigation,
+	})
+}
+
+// RecordInvestigationResult records the result of an investigation, which is resulted from then on.
+// Lab staff use it instead of replacing the whole consultation.
+func (cController *ConsultationController) RecordInvestigationResult(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[CONSULTATION] Attempting to record the result of an investigation.")
+
+	result := r.Context().Value(utils.DECODED_INVESTIGATION_RESULT).(*models.InvestigationResult)
+
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_TIMEOUT_DURATION*time.Second)
+	defer cancel()
+
+	consultation, investigation, ok := cController.fetchRequestedInvestigation(ctx, w, r)
+	if !ok {
+		return
+	}
+
+	if !utils.CanTransitionInvestigation(investigation.Status, utils.INVESTIGATION_STATUS_RESULTED) {
+		respondWithInvestigationConflict(w, fmt.Sprintf("the result of a %s investigation cannot be recorded", investigation.Status))
+		return
+	}
+
+	now := time.Now().UTC()
+	investigation.Result = result.Result
+	investigation.Status = utils.INVESTIGATION_STATUS_RESULTED
+	investigation.ResultedAt = &now
+
+	reason := fmt.Sprintf("result of investigation %s recorded", investigation.Name)
+	if !cController.saveInvestigation(ctx, w, r, consultation, reason) {
+		return
+	}
+
+	log.Printf("[CONSULTATION] Result of investigation %s of consultation %s recorded", investigation.IDInvestigation.Hex(), consultation.IDConsultation.Hex())
+	utils.RespondWithJSON(w, http.StatusOK, models.ResponseData{
+		Message: "Investigation result recorded successfully.",
+		Payload: investigation,
+	})
+}
+
+// GetPendingInvestigations is the worklist of the lab: the investigations still waiting for a result, the earliest
+// expected first. It filters by patient, doctor and status, overdue=true keeps those past their expected completion.
+func (cController *ConsultationController) GetPendingInvestigations(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[CONSULTATION] Attempting to retrieve pending investigations.")
+
+	filter, statuses, overdue, err := extractPendingInvestigationFilters(r)
+	if err != nil {
+		errMsg := fmt.Sprintf("bad request: %s", err)
+		log.Printf("[CONSULTATION] GetPendingInvestigations: Failed to extract filters: %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusBadRequest, models.ResponseData{
+			Error:   errMsg,
+			Message: "Failed to extract filters",
+		})
+		return
+	}
+
+	limit, page := utils.ExtractPaginationParams(r)
+
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_TIMEOUT_DURATION*time.Second)
+	defer cancel()
+
+	now := time.Now().UTC()
+	var overdueAt *time.Time
+	if overdue {
+		overdueAt = &now
+	}
+
+	pending, err := cController.DbConn.FetchPendingInvestigations(ctx, filter, statuses, overdueAt, page, limit)
+	if err != nil {
+		errMsg := fmt.Sprintf("Internal server error: %s", err)
+		log.Printf("[CONSULTATION] Failed to fetch pending investigations: %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusInternalServerError, models.ResponseData{
+			Error:   errMsg,
+			Message: "Failed to retrieve pending investigations. Internal server error.",
+		})
+		return
+	}
+	for i := range pending {
+		pending[i].Overdue = pending[i].Investigation.ExpectedCompletion.Before(now)
+	}
+
+	log.Printf("[CONSULTATION] Successfully retrieved %d pending investigations", len(pending))
+	utils.RespondWithJSON(w, http.StatusOK, models.ResponseData{
+		Message: "Pending investigations retrieved successfully.",
+		Payload: pending,
+	})
+}
+
+// saveInvestigation saves the consultation with its changed investigations as a new revision, answering the request
+// when it cannot. An update of the consultation since it was read is a conflict.
+func (cController *ConsultationController) saveInvestigation(ctx context.Context, w http.ResponseWriter, r *http.Request, consultation *models.Consultation, reason string) bool {
+	author, ok := revisionAuthor(w, r)
+	if !ok {
+		return false
+	}
+
+	matched, err := cController.DbConn.UpdateConsultationByID(ctx, consultation, author, reason)
+	if err == database.ErrVersionConflict {
+		respondWithInvestigationConflict(w, "the consultation was changed in the meantime, fetch it again")
+		return false
+	}
+	if err != nil {
+		errMsg := fmt.Sprintf("Internal server error: %s", err)
+		log.Printf("[CONSULTATION] Failed to save the investigations of consultation %s: %s", consultation.IDConsultation.Hex(), errMsg)
+		utils.RespondWithJSON(w, http.StatusInternalServerError, models.ResponseData{
+			Error:   errMsg,
+			Message: "Failed to update investigation. Internal server error.",
+		})
+		return false
+	}
+	if matched == 0 {
+		utils.RespondWithJSON(w, http.StatusNotFound, models.ResponseData{
+			Error:   "Consultation not found",
+			Message: "Failed to update investigation. Consultation not found.",
+		})
+		return false
+	}
+	return true
+}
+
+// fetchRequestedConsultation reads the consultation named in the path, answering the request when it cannot
+func (cController *ConsultationController) fetchRequestedConsultation(ctx context.Context, w http.ResponseWriter, r *http.Request) (*models.Consultation, bool) {
+	consultationID, err := primitive.ObjectIDFromHex(mux.Vars(r)[utils.FETCH_CONSULTATIE_BY_ID_PARAMETER])
+	if err != nil {
+		utils.RespondWithJSON(w, http.StatusBadRequest, models.ResponseData{
+			Error:   "Invalid consultation ID",
+			Message: "Invalid consultation ID. Please provide a valid ID.",
+		})
+		return nil, false
+	}
+
+	consultation, err := cController.DbConn.FetchConsultationByID(ctx, consultationID)
+	if err == mongo.ErrNoDocuments {
+		utils.RespondWithJSON(w, http.StatusNotFound, models.ResponseData{
+			Error:   "Consultation not found",
+			Message: "Consultation not found with the provided ID.",
+		})
+		return nil, false
+	}
+	if err != nil {
+		errMsg := fmt.Sprintf("Internal server error: %s", err)
+		log.Printf("[CONSULTATION] Failed to fetch consultation %s: %s", consultationID.Hex(), errMsg)
+		utils.RespondWithJSON(w, http.StatusInternalServerError, models.ResponseData{
+			Error:   errMsg,
+			Message: "Failed to retrieve consultation by ID. Internal server error.",
+		})
+		return nil, false
+	}
+	return consultation, true
+}
+
+// fetchRequestedInvestigation reads the consultation and the investigation named in the path
+func (cController *ConsultationController) fetchRequestedInvestigation(ctx context.Context, w http.ResponseWriter, r *http.Request) (*models.Consultation, *models.Investigation, bool) {
+	investigationID, err := primitive.ObjectIDFromHex(mux.Vars(r)[utils.INVESTIGATION_ID_PARAMETER])
+	if err != nil {
+		utils.RespondWithJSON(w, http.StatusBadRequest, models.ResponseData{
+			Error:   "Invalid investigation ID",
+			Message: "Invalid investigation ID. Please provide a valid ID.",
+		})
+		return nil, nil, false
+	}
+
+	consultation, ok := cController.fetchRequestedConsultation(ctx, w, r)
+	if !ok {
+		return nil, nil, false
+	}
+
+	for i := range consultation.Investigations {
+		if consultation.Investigations[i].IDInvestigation == investigationID {
+			return consultation, &consultation.Investigations[i], true
+		}
+	}
+
+	utils.RespondWithJSON(w, http.StatusNotFound, models.ResponseData{
+		Error:   "Investigation not found",
+		Message: fmt.Sprintf("Investigation not found in consultation %s.", consultation.IDConsultation.Hex()),
+	})
+	return nil, nil, false
+}
+
+func respondWithInvestigationConflict(w http.ResponseWriter, errMsg string) {
+	log.Printf("[CONSULTATION] Investigation conflict: %s", errMsg)
+	utils.RespondWithJSON(w, http.StatusConflict, models.ResponseData{
+		Error:   errMsg,
+		Message: "Failed to update investigation. Conflicting status.",
+	})
+}
+
+// startInvestigation gives a new investigation its lifecycle. One sent with a result, such as a test done during the
+// consultation, is resulted right away.
+func startInvestigation(investigation *models.Investigation, now time.Time) {
+	investigation.OrderedAt = now
+	investigation.SampleCollectedAt = nil
+	investigation.CanceledAt = nil
+	investigation.CancelReason = ""
+	investigation.Status = utils.INVESTIGATION_STATUS_ORDERED
+	investigation.ResultedAt = nil
+	if investigation.Result != "" {
+		investigation.Status = utils.INVESTIGATION_STATUS_RESULTED
+		investigation.ResultedAt = &now
+	}
+	investigation.ExpectedCompletion = utils.ExpectedInvestigationCompletion(investigation)
+}
+
+// prepareInvestigations sets the lifecycle of the investigations of a consultation being saved. The ones already
+// stored keep theirs, a result may be added or corrected but not removed, and a canceled one takes no result.
+func prepareInvestigations(investigations []models.Investigation, stored []models.Investigation, now time.Time) error {
+	storedByID := make(map[primitive.ObjectID]*models.Investigation, len(stored))
+	for i := range stored {
+		storedByID[stored[i].IDInvestigation] = &stored[i]
+	}
+
+	for i := range investigations {
+		investigation := &investigations[i]
+		previous, ok := storedByID[investigation.IDInvestigation]
+		if !ok {
+			startInvestigation(investigation, now)
+			continue
+		}
+
+		investigation.Status = previous.Status
+		investigation.OrderedAt = previous.OrderedAt
+		investigation.SampleCollectedAt = previous.SampleCollectedAt
+		investigation.ResultedAt = previous.ResultedAt
+		investigation.CanceledAt = previous.CanceledAt
+		investigation.CancelReason = previous.CancelReason
+		investigation.ExpectedCompletion = utils.ExpectedInvestigationCompletion(investigation)
+
+		if investigation.Result == "" || investigation.Result == previous.Result {
+			investigation.Result = previous.Result
+			continue
+		}
+		if investigation.Status != utils.INVESTIGATION_STATUS_RESULTED && !utils.CanTransitionInvestigation(investigation.Status, utils.INVESTIGATION_STATUS_RESULTED) {
+			return fmt.Errorf("investigation %s is %s and takes no result", investigation.IDInvestigation.Hex(), investigation.Status)
+		}
+		investigation.Status = utils.INVESTIGATION_STATUS_RESULTED
+		investigation.ResultedAt = &now
+	}
+	return nil
+}
+
+// extractPendingInvestigationFilters reads the patient and doctor filters, the statuses, all pending ones by default,
+// and whether only overdue investigations are asked for
+func extractPendingInvestigationFilters(r *http.Request) (bson.M, []models.InvestigationStatus, bool, error) {
+	query := r.URL.Query()
+	for key := range query {
+		switch key {
+		case utils.QUERY_PATIENT_ID, utils.QUERY_DOCTOR_ID, utils.QUERY_STATUS, utils.QUERY_OVERDUE, utils.QUERY_PAGE, utils.QUERY_LIMIT:
+		default:
+			return nil, nil, false, fmt.Errorf("unknown filter: %s", key)
+		}
+	}
+
+	filter := bson.M{}
+	for param, column := range map[string]string{utils.QUERY_PATIENT_ID: utils.COLUMN_ID_PATIENT, utils.QUERY_DOCTOR_ID: utils.COLUMN_ID_DOCTOR} {
+		value := query.Get(param)
+		if value == "" {
+			continue
+		}
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, nil, false, fmt.Errorf("invalid %s: %v", param, err)
+		}
+		filter[column] = id
+	}
+
+	statuses := utils.PENDING_INVESTIGATION_STATUSES
+	if values := query[utils.QUERY_STATUS]; len(values) > 0 {
+		statuses = make([]models.InvestigationStatus, 0, len(values))
+		for _, value := range values {
+			status := models.InvestigationStatus(value)
+			if !utils.IsInvestigationPending(status) {
+				return nil, nil, false, fmt.Errorf("status %q is not a pending status", value)
+			}
+			statuses = append(statuses, status)
+		}
+	}
+
+	overdue := false
+	if value := query.Get(utils.QUERY_OVERDUE); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, nil, false, fmt.Errorf("invalid %s: %v", utils.QUERY_OVERDUE, err)
+		}
+		overdue = parsed
+	}
+
+	return filter, statuses, overdue, nil
+}
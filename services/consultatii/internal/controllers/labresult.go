@@ -0,0 +1,243 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mihnea1711/POS_Project/services/consultatii/internal/database"
+	"github.com/mihnea1711/POS_Project/services/consultatii/internal/labresults"
+	"github.com/mihnea1711/POS_Project/services/consultatii/internal/models"
+	"github.com/mihnea1711/POS_Project/services/consultatii/pkg/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ImportLabResults imports the HL7 v2 ORU^R01 messages posted by the lab, one or more in the body. The results matched
+// to an investigation are recorded, the others are queued for review; the report tells what became of each order.
+// When the import cannot be gone through the lab should send the messages again, which records nothing twice.
+func (cController *ConsultationController) ImportLabResults(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[CONSULTATION] Attempting to import lab results.")
+
+	contentType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || !isLabMessageContentType(contentType) {
+		errMsg := fmt.Sprintf("content type %q is not accepted for lab messages, send one of %v", r.Header.Get("Content-Type"), utils.LAB_MESSAGE_CONTENT_TYPES)
+		log.Printf("[CONSULTATION] %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusUnsupportedMediaType, models.ResponseData{Error: errMsg, Message: "Failed to import lab results"})
+		return
+	}
+
+	author, ok := revisionAuthor(w, r)
+	if !ok {
+		return
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, cController.LabImporter.MaxSize()))
+	if err != nil {
+		log.Printf("[CONSULTATION] Error reading lab messages: %v", err)
+		utils.RespondWithJSON(w, uploadErrorStatus(err), models.ResponseData{Error: err.Error(), Message: "Failed to read lab messages"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), utils.LAB_IMPORT_TIMEOUT*time.Second)
+	defer cancel()
+
+	report, err := cController.LabImporter.Import(ctx, data, utils.LAB_SOURCE_HTTP, author)
+	if errors.Is(err, labresults.ErrNoMessages) {
+		log.Printf("[CONSULTATION] No lab message in the request")
+		utils.RespondWithJSON(w, http.StatusBadRequest, models.ResponseData{Error: err.Error(), Message: "Failed to import lab results"})
+		return
+	}
+	if err != nil {
+		errMsg := fmt.Sprintf("Service unavailable: %s", err)
+		log.Printf("[CONSULTATION] Failed to import lab results: %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusServiceUnavailable, models.ResponseData{
+			Error:   errMsg,
+			Message: "Failed to import lab results, send the messages again later.",
+		})
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, models.ResponseData{
+		Message: "Lab results imported successfully.",
+		Payload: report,
+	})
+}
+
+// GetLabReconciliationItems lists the lab results queued for review, the latest first. The open ones are listed unless
+// another status is asked for, reason narrows them to one cause.
+func (cController *ConsultationController) GetLabReconciliationItems(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[CONSULTATION] Attempting to retrieve lab reconciliation items.")
+
+	status := models.LabReconciliationStatus(r.URL.Query().Get(utils.QUERY_STATUS))
+	switch status {
+	case "":
+		status = utils.LAB_RECONCILIATION_STATUS_OPEN
+	case utils.LAB_RECONCILIATION_STATUS_OPEN, utils.LAB_RECONCILIATION_STATUS_RESOLVED, utils.LAB_RECONCILIATION_STATUS_DISCARDED:
+	default:
+		errMsg := fmt.Sprintf("bad request: invalid %s %q", utils.QUERY_STATUS, status)
+		log.Printf("[CONSULTATION] GetLabReconciliationItems: %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusBadRequest, models.ResponseData{
+			Error:   errMsg,
+			Message: "Failed to extract filters",
+		})
+		return
+	}
+	filter := bson.M{utils.COLUMN_LAB_STATUS: status}
+	if reason := r.URL.Query().Get(utils.QUERY_REASON); reason != "" {
+		filter[utils.COLUMN_LAB_REASON] = reason
+	}
+
+	limit, page := utils.ExtractPaginationParams(r)
+
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_TIMEOUT_DURATION*time.Second)
+	defer cancel()
+
+	items, err := cController.DbConn.FetchLabReconciliationItems(ctx, filter, page, limit)
+	if err != nil {
+		errMsg := fmt.Sprintf("Internal server error: %s", err)
+		log.Printf("[CONSULTATION] Failed to fetch lab reconciliation items: %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusInternalServerError, models.ResponseData{
+			Error:   errMsg,
+			Message: "Failed to retrieve lab reconciliation items. Internal server error.",
+		})
+		return
+	}
+
+	log.Printf("[CONSULTATION] Successfully retrieved %d lab reconciliation items", len(items))
+	utils.RespondWithJSON(w, http.StatusOK, models.ResponseData{
+		Message: "Lab reconciliation items retrieved successfully.",
+		Payload: items,
+	})
+}
+
+// GetLabReconciliationItemByID returns a queued lab result with the message it came in
+func (cController *ConsultationController) GetLabReconciliationItemByID(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[CONSULTATION] Attempting to retrieve a lab reconciliation item by ID.")
+
+	itemID, ok := labReconciliationItemID(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_TIMEOUT_DURATION*time.Second)
+	defer cancel()
+
+	item, err := cController.DbConn.FetchLabReconciliationItemByID(ctx, itemID)
+	if err != nil {
+		respondWithLabReviewError(w, err, "Failed to retrieve lab reconciliation item")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, models.ResponseData{
+		Message: "Lab reconciliation item retrieved successfully.",
+		Payload: item,
+	})
+}
+
+// ResolveLabReconciliationItem records a queued lab result on the investigation the reviewer matched it to
+func (cController *ConsultationController) ResolveLabReconciliationItem(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[CONSULTATION] Attempting to resolve a lab reconciliation item.")
+
+	resolution := r.Context().Value(utils.DECODED_LAB_RESOLUTION).(*models.LabResolution)
+
+	itemID, ok := labReconciliationItemID(w, r)
+	if !ok {
+		return
+	}
+	author, ok := revisionAuthor(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_TIMEOUT_DURATION*time.Second)
+	defer cancel()
+
+	item, err := cController.LabImporter.Resolve(ctx, itemID, resolution, author)
+	if err != nil {
+		respondWithLabReviewError(w, err, "Failed to resolve lab reconciliation item")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, models.ResponseData{
+		Message: "Lab result recorded successfully.",
+		Payload: item,
+	})
+}
+
+// DiscardLabReconciliationItem closes a queued lab result without recording it, such as a message sent by mistake
+func (cController *ConsultationController) DiscardLabReconciliationItem(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[CONSULTATION] Attempting to discard a lab reconciliation item.")
+
+	discard := r.Context().Value(utils.DECODED_LAB_DISCARD).(*models.LabDiscard)
+
+	itemID, ok := labReconciliationItemID(w, r)
+	if !ok {
+		return
+	}
+	author, ok := revisionAuthor(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_TIMEOUT_DURATION*time.Second)
+	defer cancel()
+
+	item, err := cController.LabImporter.Discard(ctx, itemID, discard.Reason, author)
+	if err != nil {
+		respondWithLabReviewError(w, err, "Failed to discard lab reconciliation item")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, models.ResponseData{
+		Message: "Lab result discarded successfully.",
+		Payload: item,
+	})
+}
+
+func labReconciliationItemID(w http.ResponseWriter, r *http.Request) (primitive.ObjectID, bool) {
+	itemID, err := primitive.ObjectIDFromHex(mux.Vars(r)[utils.LAB_RECONCILIATION_ID_PARAMETER])
+	if err != nil {
+		utils.RespondWithJSON(w, http.StatusBadRequest, models.ResponseData{
+			Error:   "Invalid lab reconciliation item ID",
+			Message: "Invalid lab reconciliation item ID. Please provide a valid ID.",
+		})
+		return primitive.NilObjectID, false
+	}
+	return itemID, true
+}
+
+// respondWithLabReviewError answers a review that failed: a missing item, consultation or investigation is not found,
+// an item already reviewed or a result the investigation does not take is a conflict
+func respondWithLabReviewError(w http.ResponseWriter, err error, message string) {
+	status := http.StatusInternalServerError
+	switch {
+	case err == mongo.ErrNoDocuments:
+		status = http.StatusNotFound
+		err = errors.New("lab reconciliation item or consultation not found")
+	case errors.Is(err, labresults.ErrInvestigationNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, labresults.ErrItemReviewed), errors.Is(err, labresults.ErrNothingToRecord),
+		errors.Is(err, labresults.ErrResultRejected), err == database.ErrVersionConflict:
+		status = http.StatusConflict
+	}
+
+	log.Printf("[CONSULTATION] %s: %v", message, err)
+	utils.RespondWithJSON(w, status, models.ResponseData{Error: err.Error(), Message: message})
+}
+
+func isLabMessageContentType(contentType string) bool {
+	for _, accepted := range utils.LAB_MESSAGE_CONTENT_TYPES {
+		if contentType == accepted {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,335 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mihnea1711/POS_Project/services/consultatii/internal/measurements"
+	"github.com/mihnea1711/POS_Project/services/consultatii/internal/models"
+	"github.com/mihnea1711/POS_Project/services/consultatii/pkg/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// RecordMeasurements records the readings taken together, at a visit or by the patient at home. Each reading is kept in
+// the unit of its type and flagged against the reference ranges. Readings taken at a visit name the consultation, whose
+// patient and doctor they are recorded for.
+func (cController *ConsultationController) RecordMeasurements(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[CONSULTATION] Attempting to record measurements.")
+
+	request := r.Context().Value(utils.DECODED_MEASUREMENTS).(*models.MeasurementRequest)
+
+	recordedBy, ok := revisionAuthor(w, r)
+	if !ok {
+		return
+	}
+
+	now := time.Now().UTC()
+	measuredAt := now
+	if request.MeasuredAt != nil {
+		measuredAt = request.MeasuredAt.UTC()
+	}
+	if measuredAt.After(now.Add(utils.MEASUREMENT_CLOCK_SKEW * time.Minute)) {
+		errMsg := fmt.Sprintf("the measurements cannot be taken in the future, %s", measuredAt.Format(time.RFC3339))
+		log.Printf("[CONSULTATION] %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusBadRequest, models.ResponseData{Error: errMsg, Message: "Failed to record measurements"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_TIMEOUT_DURATION*time.Second)
+	defer cancel()
+
+	patientID, doctorID := request.IDPatient, request.IDDoctor
+	if request.IDConsultation != nil {
+		consultation, err := cController.DbConn.FetchConsultationByID(ctx, *request.IDConsultation)
+		if err == mongo.ErrNoDocuments {
+			utils.RespondWithJSON(w, http.StatusNotFound, models.ResponseData{
+				Error:   "Consultation not found",
+				Message: "Consultation not found with the provided ID.",
+			})
+			return
+		}
+		if err != nil {
+			errMsg := fmt.Sprintf("Internal server error: %s", err)
+			log.Printf("[CONSULTATION] Failed to fetch consultation %s: %s", request.IDConsultation.Hex(), errMsg)
+			utils.RespondWithJSON(w, http.StatusInternalServerError, models.ResponseData{
+				Error:   errMsg,
+				Message: "Failed to record measurements. Internal server error.",
+			})
+			return
+		}
+		if patientID != 0 && patientID != consultation.IDPatient {
+			errMsg := fmt.Sprintf("consultation %s is of patient %d, not %d", consultation.IDConsultation.Hex(), consultation.IDPatient, patientID)
+			log.Printf("[CONSULTATION] %s", errMsg)
+			utils.RespondWithJSON(w, http.StatusBadRequest, models.ResponseData{Error: errMsg, Message: "Failed to record measurements"})
+			return
+		}
+		patientID, doctorID = consultation.IDPatient, consultation.IDDoctor
+	}
+
+	recorded := make([]models.Measurement, 0, len(request.Readings))
+	for _, reading := range request.Readings {
+		unit, values, err := cController.Measurements.Measure(reading)
+		if err != nil {
+			log.Printf("[CONSULTATION] Invalid reading: %v", err)
+			utils.RespondWithJSON(w, http.StatusBadRequest, models.ResponseData{
+				Error:   err.Error(),
+				Message: "Measurement validation failed.",
+			})
+			return
+		}
+		recorded = append(recorded, models.Measurement{
+			IDMeasurement:  primitive.NewObjectID(),
+			IDPatient:      patientID,
+			IDDoctor:       doctorID,
+			IDConsultation: request.IDConsultation,
+			Type:           reading.Type,
+			Unit:           unit,
+			Values:         values,
+			Flag:           measurements.WorstFlag(values),
+			Status:         utils.MEASUREMENT_STATUS_RECORDED,
+			Note:           reading.Note,
+			MeasuredAt:     measuredAt,
+			RecordedAt:     now,
+			RecordedBy:     recordedBy,
+		})
+	}
+
+	if err := cController.DbConn.SaveMeasurements(ctx, recorded); err != nil {
+		errMsg := fmt.Sprintf("Internal server error: %s", err)
+		log.Printf("[CONSULTATION] Failed to save measurements: %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusInternalServerError, models.ResponseData{
+			Error:   errMsg,
+			Message: "Failed to record measurements. Internal server error.",
+		})
+		return
+	}
+
+	log.Printf("[CONSULTATION] %d measurements recorded for patient %d", len(recorded), patientID)
+	utils.RespondWithJSON(w, http.StatusCreated, models.ResponseData{
+		Message: "Measurements recorded successfully.",
+		Payload: recorded,
+	})
+}
+
+// GetMeasurements lists the measurements of a patient or of a consultation, the latest taken first
+func (cController *ConsultationController) GetMeasurements(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[CONSULTATION] Attempting to retrieve measurements.")
+
+	filter, err := utils.ExtractMeasurementFilters(r, cController.Measurements.Location())
+	if err != nil {
+		log.Printf("[CONSULTATION] GetMeasurements: %v", err)
+		utils.RespondWithJSON(w, http.StatusBadRequest, models.ResponseData{
+			Error:   fmt.Sprintf("bad request: %s", err),
+			Message: "Failed to extract filters",
+		})
+		return
+	}
+
+	limit, page := utils.ExtractPaginationParams(r)
+
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_TIMEOUT_DURATION*time.Second)
+	defer cancel()
+
+	measurementList, err := cController.DbConn.FetchMeasurements(ctx, filter, page, limit)
+	if err != nil {
+		errMsg := fmt.Sprintf("Internal server error: %s", err)
+		log.Printf("[CONSULTATION] Failed to fetch measurements: %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusInternalServerError, models.ResponseData{
+			Error:   errMsg,
+			Message: "Failed to retrieve measurements. Internal server error.",
+		})
+		return
+	}
+
+	log.Printf("[CONSULTATION] Successfully retrieved %d measurements", len(measurementList))
+	utils.RespondWithJSON(w, http.StatusOK, models.ResponseData{
+		Message: "Measurements retrieved successfully.",
+		Payload: measurementList,
+	})
+}
+
+// GetMeasurementByID returns a measurement, retracted or not
+func (cController *ConsultationController) GetMeasurementByID(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[CONSULTATION] Attempting to retrieve a measurement by ID.")
+
+	measurementID, ok := requestedMeasurementID(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_TIMEOUT_DURATION*time.Second)
+	defer cancel()
+
+	measurement, ok := cController.fetchMeasurement(ctx, w, measurementID)
+	if !ok {
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, models.ResponseData{
+		Message: "Measurement retrieved successfully.",
+		Payload: measurement,
+	})
+}
+
+// GetMeasurementTypes lists the types of measurements with their units and reference ranges
+func (cController *ConsultationController) GetMeasurementTypes(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[CONSULTATION] Attempting to retrieve the measurement types.")
+
+	utils.RespondWithJSON(w, http.StatusOK, models.ResponseData{
+		Message: "Measurement types retrieved successfully.",
+		Payload: cController.Measurements.Kinds(),
+	})
+}
+
+// GetMeasurementTrend follows a type of measurement of a patient over a period, by day, week or month. The
+// measurements entered in error are left out.
+func (cController *ConsultationController) GetMeasurementTrend(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[CONSULTATION] Attempting to retrieve a measurement trend.")
+
+	patientID, err := strconv.Atoi(r.URL.Query().Get(utils.QUERY_PATIENT_ID))
+	if err != nil {
+		respondWithInvalidTrend(w, fmt.Errorf("a valid %s is required", utils.QUERY_PATIENT_ID))
+		return
+	}
+	measurementType := models.MeasurementType(r.URL.Query().Get(utils.QUERY_TYPE))
+	if _, ok := cController.Measurements.Lookup(measurementType); !ok {
+		respondWithInvalidTrend(w, fmt.Errorf("a known measurement %s is required, not %q", utils.QUERY_TYPE, measurementType))
+		return
+	}
+	from, to, err := utils.ExtractMeasurementPeriod(r, cController.Measurements.Location(), time.Now())
+	if err != nil {
+		respondWithInvalidTrend(w, err)
+		return
+	}
+	interval, err := utils.MeasurementInterval(r.URL.Query().Get(utils.QUERY_INTERVAL), from, to)
+	if err != nil {
+		respondWithInvalidTrend(w, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_TIMEOUT_DURATION*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		utils.COLUMN_MEASUREMENT_ID_PATIENT:  patientID,
+		utils.COLUMN_MEASUREMENT_TYPE:        measurementType,
+		utils.COLUMN_MEASUREMENT_STATUS:      utils.MEASUREMENT_STATUS_RECORDED,
+		utils.COLUMN_MEASUREMENT_MEASURED_AT: bson.M{"$gte": from, "$lt": to},
+	}
+	buckets, err := cController.DbConn.FetchMeasurementBuckets(ctx, filter, interval, cController.Measurements.TimeZone())
+	if err != nil {
+		errMsg := fmt.Sprintf("Internal server error: %s", err)
+		log.Printf("[CONSULTATION] Failed to fetch the %s trend of patient %d: %s", measurementType, patientID, errMsg)
+		utils.RespondWithJSON(w, http.StatusInternalServerError, models.ResponseData{
+			Error:   errMsg,
+			Message: "Failed to retrieve measurement trend. Internal server error.",
+		})
+		return
+	}
+
+	log.Printf("[CONSULTATION] Successfully retrieved the %s trend of patient %d by %s", measurementType, patientID, interval)
+	utils.RespondWithJSON(w, http.StatusOK, models.ResponseData{
+		Message: "Measurement trend retrieved successfully.",
+		Payload: cController.Measurements.Trend(patientID, measurementType, interval, from, to, buckets),
+	})
+}
+
+// RetractMeasurement marks a measurement as entered in error. It stays listed with its status and the reason, but is
+// left out of the trends; a measurement is not retracted twice.
+func (cController *ConsultationController) RetractMeasurement(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[CONSULTATION] Attempting to retract a measurement.")
+
+	retraction := r.Context().Value(utils.DECODED_MEASUREMENT_RETRACTION).(*models.MeasurementRetraction)
+
+	measurementID, ok := requestedMeasurementID(w, r)
+	if !ok {
+		return
+	}
+	author, ok := revisionAuthor(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_TIMEOUT_DURATION*time.Second)
+	defer cancel()
+
+	if _, ok := cController.fetchMeasurement(ctx, w, measurementID); !ok {
+		return
+	}
+
+	retracted, err := cController.DbConn.RetractMeasurement(ctx, measurementID, retraction.Reason, author, time.Now().UTC())
+	if err != nil {
+		errMsg := fmt.Sprintf("Internal server error: %s", err)
+		log.Printf("[CONSULTATION] Failed to retract measurement %s: %s", measurementID.Hex(), errMsg)
+		utils.RespondWithJSON(w, http.StatusInternalServerError, models.ResponseData{
+			Error:   errMsg,
+			Message: "Failed to retract measurement. Internal server error.",
+		})
+		return
+	}
+	if retracted == 0 {
+		errMsg := fmt.Sprintf("measurement %s is already %s", measurementID.Hex(), utils.MEASUREMENT_STATUS_ENTERED_IN_ERROR)
+		log.Printf("[CONSULTATION] %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusConflict, models.ResponseData{Error: errMsg, Message: "Failed to retract measurement"})
+		return
+	}
+
+	measurement, ok := cController.fetchMeasurement(ctx, w, measurementID)
+	if !ok {
+		return
+	}
+
+	log.Printf("[CONSULTATION] Measurement %s retracted", measurementID.Hex())
+	utils.RespondWithJSON(w, http.StatusOK, models.ResponseData{
+		Message: "Measurement retracted successfully.",
+		Payload: measurement,
+	})
+}
+
+func (cController *ConsultationController) fetchMeasurement(ctx context.Context, w http.ResponseWriter, measurementID primitive.ObjectID) (*models.Measurement, bool) {
+	measurement, err := cController.DbConn.FetchMeasurementByID(ctx, measurementID)
+	if err == mongo.ErrNoDocuments {
+		utils.RespondWithJSON(w, http.StatusNotFound, models.ResponseData{
+			Error:   "Measurement not found",
+			Message: "Measurement not found with the provided ID.",
+		})
+		return nil, false
+	}
+	if err != nil {
+		errMsg := fmt.Sprintf("Internal server error: %s", err)
+		log.Printf("[CONSULTATION] Failed to fetch measurement %s: %s", measurementID.Hex(), errMsg)
+		utils.RespondWithJSON(w, http.StatusInternalServerError, models.ResponseData{
+			Error:   errMsg,
+			Message: "Failed to retrieve measurement by ID. Internal server error.",
+		})
+		return nil, false
+	}
+	return measurement, true
+}
+
+func requestedMeasurementID(w http.ResponseWriter, r *http.Request) (primitive.ObjectID, bool) {
+	measurementID, err := primitive.ObjectIDFromHex(mux.Vars(r)[utils.MEASUREMENT_ID_PARAMETER])
+	if err != nil {
+		utils.RespondWithJSON(w, http.StatusBadRequest, models.ResponseData{
+			Error:   "Invalid measurement ID",
+			Message: "Invalid measurement ID. Please provide a valid ID.",
+		})
+		return primitive.NilObjectID, false
+	}
+	return measurementID, true
+}
+
+func respondWithInvalidTrend(w http.ResponseWriter, err error) {
+	log.Printf("[CONSULTATION] GetMeasurementTrend: %v", err)
+	utils.RespondWithJSON(w, http.StatusBadRequest, models.ResponseData{
+		Error:   fmt.Sprintf("bad request: %s", err),
+		Message: "Failed to extract the trend parameters",
+	})
+}
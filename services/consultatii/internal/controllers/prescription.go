@@ -0,0 +1,414 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mihnea1711/POS_Project/services/consultatii/internal/drugs"
+	"github.com/mihnea1711/POS_Project/services/consultatii/internal/models"
+	"github.com/mihnea1711/POS_Project/services/consultatii/pkg/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// IssuePrescription writes a prescription for an existing consultation. Every drug is checked against the allergies and
+// the medications of the patient's clinical profile, against the drugs of the patient's other active prescriptions and
+// against the other drugs of the prescription. Allergies and major interactions are a conflict until the doctor
+// acknowledges them, the warnings are kept on the prescription either way.
+func (cController *ConsultationController) IssuePrescription(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[CONSULTATION] Attempting to issue a prescription.")
+
+	request := r.Context().Value(utils.DECODED_PRESCRIPTION).(*models.PrescriptionRequest)
+
+	issuedBy, ok := revisionAuthor(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_TIMEOUT_DURATION*time.Second)
+	defer cancel()
+
+	consultation, ok := cController.fetchRequestedConsultation(ctx, w, r)
+	if !ok {
+		return
+	}
+
+	prescribed := make([]drugs.Drug, 0, len(request.Items))
+	for i := range request.Items {
+		drug, found := cController.DrugCatalog.Lookup(request.Items[i].DrugCode)
+		if !found {
+			errMsg := fmt.Sprintf("unknown drug code: %s", request.Items[i].DrugCode)
+			log.Printf("[CONSULTATION] %s", errMsg)
+			utils.RespondWithJSON(w, http.StatusBadRequest, models.ResponseData{
+				Error:   errMsg,
+				Message: "Prescription validation failed due to a drug missing from the catalog.",
+			})
+			return
+		}
+		request.Items[i].DrugCode = drug.Code
+		request.Items[i].DrugName = drug.Name
+		prescribed = append(prescribed, drug)
+	}
+
+	now := time.Now().UTC()
+	active, err := cController.DbConn.FetchActivePrescriptions(ctx, consultation.IDPatient, now.AddDate(0, 0, -utils.ACTIVE_PRESCRIPTION_DAYS))
+	if err != nil {
+		errMsg := fmt.Sprintf("Internal server error: %s", err)
+		log.Printf("[CONSULTATION] Failed to fetch the active prescriptions of patient %d: %s", consultation.IDPatient, errMsg)
+		utils.RespondWithJSON(w, http.StatusInternalServerError, models.ResponseData{
+			Error:   errMsg,
+			Message: "Failed to issue prescription. Internal server error.",
+		})
+		return
+	}
+
+	warnings := cController.prescriptionWarnings(request, prescribed, cController.currentDrugs(request.Medications, active))
+	if !request.AcknowledgeWarnings {
+		for _, warning := range warnings {
+			if utils.IsBlockingWarning(warning) {
+				log.Printf("[CONSULTATION] Prescription for consultation %s has warnings to acknowledge", consultation.IDConsultation.Hex())
+				utils.RespondWithJSON(w, http.StatusConflict, models.ResponseData{
+					Error:   "the prescription has allergy or interaction warnings, review them and issue it again with acknowledgeWarnings",
+					Message: "Failed to issue prescription. Warnings must be acknowledged.",
+					Payload: warnings,
+				})
+				return
+			}
+		}
+	}
+
+	prescription := models.Prescription{
+		IDPrescription: primitive.NewObjectID(),
+		IDConsultation: consultation.IDConsultation,
+		IDPatient:      consultation.IDPatient,
+		IDDoctor:       consultation.IDDoctor,
+		Items:          request.Items,
+		Warnings:       warnings,
+		Status:         utils.PRESCRIPTION_STATUS_ISSUED,
+		IssuedAt:       now,
+		IssuedBy:       issuedBy,
+	}
+	if err := cController.DbConn.SavePrescription(ctx, &prescription); err != nil {
+		errMsg := fmt.Sprintf("Internal server error: %s", err)
+		log.Printf("[CONSULTATION] Failed to save prescription: %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusInternalServerError, models.ResponseData{
+			Error:   errMsg,
+			Message: "Failed to issue prescription. Internal server error.",
+		})
+		return
+	}
+
+	log.Printf("[CONSULTATION] Prescription %s issued for consultation %s with %d warnings", prescription.IDPrescription.Hex(), consultation.IDConsultation.Hex(), len(warnings))
+	utils.RespondWithJSON(w, http.StatusCreated, models.ResponseData{
+		Message: "Prescription issued successfully.",
+		Payload: prescription,
+	})
+}
+
+// GetPrescriptions lists the prescriptions of a consultation, the latest first
+func (cController *ConsultationController) GetPrescriptions(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[CONSULTATION] Attempting to retrieve the prescriptions of a consultation.")
+
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_TIMEOUT_DURATION*time.Second)
+	defer cancel()
+
+	consultation, ok := cController.fetchRequestedConsultation(ctx, w, r)
+	if !ok {
+		return
+	}
+
+	prescriptions, err := cController.DbConn.FetchPrescriptions(ctx, consultation.IDConsultation)
+	if err != nil {
+		errMsg := fmt.Sprintf("Internal server error: %s", err)
+		log.Printf("[CONSULTATION] Failed to fetch the prescriptions of consultation %s: %s", consultation.IDConsultation.Hex(), errMsg)
+		utils.RespondWithJSON(w, http.StatusInternalServerError, models.ResponseData{
+			Error:   errMsg,
+			Message: "Failed to retrieve prescriptions. Internal server error.",
+		})
+		return
+	}
+
+	log.Printf("[CONSULTATION] Successfully retrieved %d prescriptions of consultation %s", len(prescriptions), consultation.IDConsultation.Hex())
+	utils.RespondWithJSON(w, http.StatusOK, models.ResponseData{
+		Message: "Prescriptions retrieved successfully.",
+		Payload: prescriptions,
+	})
+}
+
+// GetPatientPrescriptions lists the prescriptions of a patient across all their consultations, the latest first
+func (cController *ConsultationController) GetPatientPrescriptions(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[CONSULTATION] Attempting to retrieve the prescriptions of a patient.")
+
+	patientID, err := strconv.Atoi(r.URL.Query().Get(utils.QUERY_PATIENT_ID))
+	if err != nil || patientID <= 0 {
+		errMsg := fmt.Sprintf("a valid %s is required", utils.QUERY_PATIENT_ID)
+		log.Printf("[CONSULTATION] GetPatientPrescriptions: %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusBadRequest, models.ResponseData{
+			Error:   errMsg,
+			Message: "Failed to extract filters",
+		})
+		return
+	}
+
+	limit, page := utils.ExtractPaginationParams(r)
+
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_TIMEOUT_DURATION*time.Second)
+	defer cancel()
+
+	prescriptions, err := cController.DbConn.FetchPatientPrescriptions(ctx, patientID, page, limit)
+	if err != nil {
+		errMsg := fmt.Sprintf("Internal server error: %s", err)
+		log.Printf("[CONSULTATION] Failed to fetch the prescriptions of patient %d: %s", patientID, errMsg)
+		utils.RespondWithJSON(w, http.StatusInternalServerError, models.ResponseData{
+			Error:   errMsg,
+			Message: "Failed to retrieve prescriptions. Internal server error.",
+		})
+		return
+	}
+
+	log.Printf("[CONSULTATION] Successfully retrieved %d prescriptions of patient %d", len(prescriptions), patientID)
+	utils.RespondWithJSON(w, http.StatusOK, models.ResponseData{
+		Message: "Prescriptions retrieved successfully.",
+		Payload: prescriptions,
+	})
+}
+
+// GetPrescriptionByID returns a prescription of a consultation
+func (cController *ConsultationController) GetPrescriptionByID(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[CONSULTATION] Attempting to retrieve a prescription by ID.")
+
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_TIMEOUT_DURATION*time.Second)
+	defer cancel()
+
+	prescription, ok := cController.fetchRequestedPrescription(ctx, w, r)
+	if !ok {
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, models.ResponseData{
+		Message: "Prescription retrieved successfully.",
+		Payload: prescription,
+	})
+}
+
+// UpdatePrescriptionStatus dispenses or cancels an issued prescription. Dispensed and canceled prescriptions are final,
+// so changing them is a conflict, as is a change made to the prescription since it was read.
+func (cController *ConsultationController) UpdatePrescriptionStatus(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[CONSULTATION] Attempting to change the status of a prescription.")
+
+	change := r.Context().Value(utils.DECODED_PRESCRIPTION_STATUS).(*models.PrescriptionStatusChange)
+
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_TIMEOUT_DURATION*time.Second)
+	defer cancel()
+
+	prescription, ok := cController.fetchRequestedPrescription(ctx, w, r)
+	if !ok {
+		return
+	}
+
+	fromStatus := prescription.Status
+	if !utils.CanTransitionPrescription(fromStatus, change.Status) {
+		respondWithPrescriptionConflict(w, fmt.Sprintf("a prescription cannot move from %s to %s", fromStatus, change.Status))
+		return
+	}
+
+	now := time.Now().UTC()
+	prescription.Status = change.Status
+	switch change.Status {
+	case utils.PRESCRIPTION_STATUS_DISPENSED:
+		prescription.DispensedAt = &now
+	case utils.PRESCRIPTION_STATUS_CANCELED:
+		prescription.CanceledAt = &now
+		prescription.CancelReason = change.Reason
+	}
+
+	matched, err := cController.DbConn.UpdatePrescriptionStatus(ctx, prescription, fromStatus)
+	if err != nil {
+		errMsg := fmt.Sprintf("Internal server error: %s", err)
+		log.Printf("[CONSULTATION] Failed to update prescription %s: %s", prescription.IDPrescription.Hex(), errMsg)
+		utils.RespondWithJSON(w, http.StatusInternalServerError, models.ResponseData{
+			Error:   errMsg,
+			Message: "Failed to update prescription. Internal server error.",
+		})
+		return
+	}
+	if matched == 0 {
+		respondWithPrescriptionConflict(w, "the prescription was changed in the meantime, fetch it again")
+		return
+	}
+
+	log.Printf("[CONSULTATION] Prescription %s is now %s", prescription.IDPrescription.Hex(), prescription.Status)
+	utils.RespondWithJSON(w, http.StatusOK, models.ResponseData{
+		Message: "Prescription status updated successfully.",
+		Payload: prescription,
+	})
+}
+
+// SearchDrugs is the autocomplete of the drug catalog. The query q matches the start of a code or words of the name or
+// the substance, limit caps the number of suggestions.
+func (cController *ConsultationController) SearchDrugs(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[CONSULTATION] Attempting to search the drug catalog.")
+
+	query := r.URL.Query().Get(utils.QUERY_TEXT)
+	if query == "" {
+		errMsg := fmt.Sprintf("the %s query is required", utils.QUERY_TEXT)
+		log.Printf("[CONSULTATION] %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusBadRequest, models.ResponseData{Error: errMsg, Message: "Invalid drug search"})
+		return
+	}
+
+	limit := utils.DEFAULT_DRUG_SEARCH_LIMIT
+	if value := r.URL.Query().Get(utils.QUERY_LIMIT); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			errMsg := fmt.Sprintf("invalid %s: %s", utils.QUERY_LIMIT, value)
+			log.Printf("[CONSULTATION] %s", errMsg)
+			utils.RespondWithJSON(w, http.StatusBadRequest, models.ResponseData{Error: errMsg, Message: "Invalid drug search"})
+			return
+		}
+		limit = min(parsed, utils.MAX_DRUG_SEARCH_LIMIT)
+	}
+
+	found := cController.DrugCatalog.Search(query, limit)
+
+	log.Printf("[CONSULTATION] Found %d drugs for %q", len(found), query)
+	utils.RespondWithJSON(w, http.StatusOK, models.ResponseData{
+		Message: "Drugs retrieved successfully.",
+		Payload: found,
+	})
+}
+
+// currentDrug is a drug the patient already takes, with where it is recorded
+type currentDrug struct {
+	drug   drugs.Drug
+	source string
+}
+
+// currentDrugs lists the drugs of the active prescriptions and the medications of the clinical profile that the
+// catalog knows. A medication the catalog cannot identify is left out of the checks.
+func (cController *ConsultationController) currentDrugs(medications []string, active []models.Prescription) []currentDrug {
+	var current []currentDrug
+	for _, prescription := range active {
+		for _, item := range prescription.Items {
+			if drug, ok := cController.DrugCatalog.Lookup(item.DrugCode); ok {
+				current = append(current, currentDrug{drug: drug, source: fmt.Sprintf("prescription of %s", prescription.IssuedAt.Format(utils.TIME_FORMAT))})
+			}
+		}
+	}
+	for _, medication := range medications {
+		if drug, ok := cController.DrugCatalog.Identify(medication); ok {
+			current = append(current, currentDrug{drug: drug, source: "clinical profile"})
+		}
+	}
+	return current
+}
+
+// prescriptionWarnings checks the prescribed drugs against the allergies of the patient, against each other and
+// against the drugs the patient already takes
+func (cController *ConsultationController) prescriptionWarnings(request *models.PrescriptionRequest, prescribed []drugs.Drug, current []currentDrug) []models.PrescriptionWarning {
+	warnings := []models.PrescriptionWarning{}
+	if !request.ProfileRecorded {
+		warnings = append(warnings, models.PrescriptionWarning{
+			Kind:     utils.WARNING_KIND_UNCHECKED,
+			Severity: utils.WARNING_SEVERITY_INFO,
+			Message:  "the patient has no clinical profile, allergies and medications taken elsewhere were not checked",
+		})
+	}
+
+	for i, drug := range prescribed {
+		for _, allergy := range request.Allergies {
+			if drug.AllergicTo(allergy) {
+				warnings = append(warnings, models.PrescriptionWarning{
+					Kind:     utils.WARNING_KIND_ALLERGY,
+					Severity: utils.WARNING_SEVERITY_CONTRAINDICATED,
+					DrugCode: drug.Code,
+					Message:  fmt.Sprintf("%s (%s) falls under the recorded allergy to %s", drug.Name, drug.Substance, allergy),
+				})
+			}
+		}
+
+		for _, other := range prescribed[i+1:] {
+			warnings = append(warnings, cController.drugPairWarnings(drug, other, "this prescription")...)
+		}
+		for _, other := range current {
+			warnings = append(warnings, cController.drugPairWarnings(drug, other.drug, other.source)...)
+		}
+	}
+	return warnings
+}
+
+// drugPairWarnings returns the duplicate therapy and the interactions between a prescribed drug and another one
+func (cController *ConsultationController) drugPairWarnings(drug, other drugs.Drug, source string) []models.PrescriptionWarning {
+	var warnings []models.PrescriptionWarning
+	if drug.Substance == other.Substance {
+		warnings = append(warnings, models.PrescriptionWarning{
+			Kind:     utils.WARNING_KIND_DUPLICATE,
+			Severity: utils.WARNING_SEVERITY_MODERATE,
+			DrugCode: drug.Code,
+			Message:  fmt.Sprintf("%s duplicates %s from the %s, both are %s", drug.Name, other.Name, source, drug.Substance),
+		})
+		return warnings
+	}
+
+	for _, interaction := range cController.DrugCatalog.Interactions(drug, other) {
+		warnings = append(warnings, models.PrescriptionWarning{
+			Kind:     utils.WARNING_KIND_INTERACTION,
+			Severity: interaction.Severity,
+			DrugCode: drug.Code,
+			Message:  fmt.Sprintf("%s interacts with %s from the %s: %s", drug.Name, other.Name, source, interaction.Description),
+		})
+	}
+	return warnings
+}
+
+// fetchRequestedPrescription reads the prescription named in the path, answering the request when it cannot
+func (cController *ConsultationController) fetchRequestedPrescription(ctx context.Context, w http.ResponseWriter, r *http.Request) (*models.Prescription, bool) {
+	consultationID, err := primitive.ObjectIDFromHex(mux.Vars(r)[utils.FETCH_CONSULTATIE_BY_ID_PARAMETER])
+	if err != nil {
+		utils.RespondWithJSON(w, http.StatusBadRequest, models.ResponseData{
+			Error:   "Invalid consultation ID",
+			Message: "Invalid consultation ID. Please provide a valid ID.",
+		})
+		return nil, false
+	}
+	prescriptionID, err := primitive.ObjectIDFromHex(mux.Vars(r)[utils.PRESCRIPTION_ID_PARAMETER])
+	if err != nil {
+		utils.RespondWithJSON(w, http.StatusBadRequest, models.ResponseData{
+			Error:   "Invalid prescription ID",
+			Message: "Invalid prescription ID. Please provide a valid ID.",
+		})
+		return nil, false
+	}
+
+	prescription, err := cController.DbConn.FetchPrescriptionByID(ctx, consultationID, prescriptionID)
+	if err == mongo.ErrNoDocuments {
+		utils.RespondWithJSON(w, http.StatusNotFound, models.ResponseData{
+			Error:   "Prescription not found",
+			Message: fmt.Sprintf("Prescription not found in consultation %s.", consultationID.Hex()),
+		})
+		return nil, false
+	}
+	if err != nil {
+		errMsg := fmt.Sprintf("Internal server error: %s", err)
+		log.Printf("[CONSULTATION] Failed to fetch prescription %s: %s", prescriptionID.Hex(), errMsg)
+		utils.RespondWithJSON(w, http.StatusInternalServerError, models.ResponseData{
+			Error:   errMsg,
+			Message: "Failed to retrieve prescription by ID. Internal server error.",
+		})
+		return nil, false
+	}
+	return prescription, true
+}
+
+func respondWithPrescriptionConflict(w http.ResponseWriter, errMsg string) {
+	log.Printf("[CONSULTATION] Prescription conflict: %s", errMsg)
+	utils.RespondWithJSON(w, http.StatusConflict, models.ResponseData{
+		Error:   errMsg,
+		Message: "Failed to update prescription. Conflicting status.",
+	})
+}
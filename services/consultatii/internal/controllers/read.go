@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gorilla/mux"
@@ -31,6 +33,15 @@ func (cController *ConsultationController) GetConsultations(w http.ResponseWrite
 		utils.RespondWithJSON(w, http.StatusBadRequest, response)
 		return
 	}
+	sort, err := utils.ExtractSortFromRequest(r)
+	if err != nil {
+		log.Printf("[CONSULTATION] GetConsultations: Failed to extract sort: %v", err)
+		utils.RespondWithJSON(w, http.StatusBadRequest, models.ResponseData{
+			Error:   fmt.Sprintf("bad request: %s", err),
+			Message: "Failed to extract sort",
+		})
+		return
+	}
 
 	// Extract the limit and page query parameters from the request
 	limit, page := utils.ExtractPaginationParams(r)
@@ -42,7 +53,7 @@ func (cController *ConsultationController) GetConsultations(w http.ResponseWrite
 	cController.handleContextTimeout(ctx, w)
 
 	// Use cController.DbConn to fetch filtered consultations from the database
-	consultations, err := cController.DbConn.FetchConsultations(ctx, filters, page, limit)
+	consultations, err := cController.DbConn.FetchConsultations(ctx, filters, sort, page, limit)
 	if err != nil {
 		errMsg := fmt.Sprintf("internal server error: %s", err)
 		log.Printf("[CONSULTATION] Failed to fetch filtered consultations: %s\n", errMsg)
@@ -139,3 +150,60 @@ func (cController *ConsultationController) GetConsultationByID(w http.ResponseWr
 	}
 	utils.RespondWithJSON(w, http.StatusOK, response)
 }
+
+// GetAppointmentLinks tells which of the appointments in the appointmentIDs query, separated by commas, have a
+// consultation, and which one
+func (cController *ConsultationController) GetAppointmentLinks(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[CONSULTATION] Attempting to retrieve the consultations of appointments.")
+
+	appointmentIDs, err := parseAppointmentIDs(r.URL.Query().Get(utils.QUERY_APPOINTMENT_IDS))
+	if err != nil {
+		errMsg := fmt.Sprintf("bad request: %s", err)
+		log.Printf("[CONSULTATION] GetAppointmentLinks: %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusBadRequest, models.ResponseData{
+			Error:   errMsg,
+			Message: "Invalid appointment IDs",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_TIMEOUT_DURATION*time.Second)
+	defer cancel()
+
+	links, err := cController.DbConn.FetchAppointmentLinks(ctx, appointmentIDs)
+	if err != nil {
+		errMsg := fmt.Sprintf("Internal server error: %s", err)
+		log.Printf("[CONSULTATION] Failed to fetch the consultations of appointments: %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusInternalServerError, models.ResponseData{
+			Error:   errMsg,
+			Message: "Failed to retrieve the consultations of appointments. Internal server error.",
+		})
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, models.ResponseData{
+		Message: "Appointment consultations retrieved successfully.",
+		Payload: links,
+	})
+}
+
+func parseAppointmentIDs(value string) ([]int, error) {
+	if value == "" {
+		return nil, fmt.Errorf("the %s query is required", utils.QUERY_APPOINTMENT_IDS)
+	}
+
+	parts := strings.Split(value, ",")
+	if len(parts) > utils.MAX_APPOINTMENT_LINKS {
+		return nil, fmt.Errorf("at most %d appointments can be looked up at once", utils.MAX_APPOINTMENT_LINKS)
+	}
+
+	appointmentIDs := make([]int, 0, len(parts))
+	for _, part := range parts {
+		id, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || id <= 0 {
+			return nil, fmt.Errorf("invalid appointment ID: %s", part)
+		}
+		appointmentIDs = append(appointmentIDs, id)
+	}
+	return appointmentIDs, nil
+}
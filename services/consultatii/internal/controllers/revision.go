@@ -0,0 +1,232 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mihnea1711/POS_Project/services/consultatii/internal/models"
+	"github.com/mihnea1711/POS_Project/services/consultatii/pkg/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// GetConsultationRevisions lists who changed a consultation, when and why, the latest revision first
+func (cController *ConsultationController) GetConsultationRevisions(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[CONSULTATION] Attempting to retrieve the revisions of a consultation.")
+
+	consultationID, ok := revisionConsultationID(w, r)
+	if !ok {
+		return
+	}
+	limit, page := utils.ExtractPaginationParams(r)
+
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_TIMEOUT_DURATION*time.Second)
+	defer cancel()
+
+	revisions, err := cController.DbConn.FetchConsultationRevisions(ctx, consultationID, page, limit)
+	if err != nil {
+		respondWithRevisionError(w, err, "Failed to retrieve consultation revisions.")
+		return
+	}
+	if len(revisions) == 0 && page == utils.DEFAULT_PAGINATION_PAGE {
+		// Consultations saved before versioning have no revision until they are first updated
+		if _, err := cController.DbConn.FetchConsultationByID(ctx, consultationID); err != nil {
+			respondWithRevisionError(w, err, "Failed to retrieve consultation revisions.")
+			return
+		}
+	}
+
+	log.Printf("[CONSULTATION] Successfully retrieved %d revisions of consultation %s", len(revisions), consultationID.Hex())
+	utils.RespondWithJSON(w, http.StatusOK, models.ResponseData{
+		Message: "Consultation revisions retrieved successfully.",
+		Payload: revisions,
+	})
+}
+
+// GetConsultationRevision returns the consultation as it was saved at a version
+func (cController *ConsultationController) GetConsultationRevision(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[CONSULTATION] Attempting to retrieve a consultation revision.")
+
+	consultationID, ok := revisionConsultationID(w, r)
+	if !ok {
+		return
+	}
+	version, err := strconv.Atoi(mux.Vars(r)[utils.REVISION_VERSION_PARAMETER])
+	if err != nil || version < utils.FIRST_CONSULTATION_VERSION {
+		utils.RespondWithJSON(w, http.StatusBadRequest, models.ResponseData{
+			Error:   "Invalid version",
+			Message: "Invalid version. Please provide a positive version.",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_TIMEOUT_DURATION*time.Second)
+	defer cancel()
+
+	revision, err := cController.DbConn.FetchConsultationRevision(ctx, consultationID, version)
+	if err != nil {
+		respondWithRevisionError(w, err, "Failed to retrieve consultation revision.")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, models.ResponseData{
+		Message: "Consultation revision retrieved successfully.",
+		Payload: revision,
+	})
+}
+
+// DiffConsultationRevisions lists the values changed between two versions of a consultation, given as from and to
+func (cController *ConsultationController) DiffConsultationRevisions(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[CONSULTATION] Attempting to compare consultation revisions.")
+
+	consultationID, ok := revisionConsultationID(w, r)
+	if !ok {
+		return
+	}
+
+	versions := make(map[string]int, 2)
+	for _, param := range []string{utils.QUERY_FROM, utils.QUERY_TO} {
+		version, err := strconv.Atoi(r.URL.Query().Get(param))
+		if err != nil || version < utils.FIRST_CONSULTATION_VERSION {
+			errMsg := fmt.Sprintf("%s must be a positive version", param)
+			log.Printf("[CONSULTATION] %s", errMsg)
+			utils.RespondWithJSON(w, http.StatusBadRequest, models.ResponseData{Error: errMsg, Message: "Invalid revision comparison request"})
+			return
+		}
+		versions[param] = version
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_TIMEOUT_DURATION*time.Second)
+	defer cancel()
+
+	from, err := cController.DbConn.FetchConsultationRevision(ctx, consultationID, versions[utils.QUERY_FROM])
+	if err != nil {
+		respondWithRevisionError(w, err, fmt.Sprintf("Failed to retrieve revision %d.", versions[utils.QUERY_FROM]))
+		return
+	}
+	to, err := cController.DbConn.FetchConsultationRevision(ctx, consultationID, versions[utils.QUERY_TO])
+	if err != nil {
+		respondWithRevisionError(w, err, fmt.Sprintf("Failed to retrieve revision %d.", versions[utils.QUERY_TO]))
+		return
+	}
+
+	diff := models.RevisionDiff{
+		IDConsultation: consultationID,
+		FromVersion:    from.Version,
+		ToVersion:      to.Version,
+		Changes:        diffConsultations(from.Consultation, to.Consultation),
+	}
+
+	log.Printf("[CONSULTATION] %d changes between versions %d and %d of consultation %s", len(diff.Changes), from.Version, to.Version, consultationID.Hex())
+	utils.RespondWithJSON(w, http.StatusOK, models.ResponseData{
+		Message: "Consultation revisions compared successfully.",
+		Payload: diff,
+	})
+}
+
+// diffConsultations compares two saved states of a consultation. Investigations are matched by ID and named by it in
+// the changed fields, such as investigations[<id>].result.
+func diffConsultations(from, to *models.Consultation) []models.RevisionChange {
+	if from == nil {
+		from = &models.Consultation{}
+	}
+	if to == nil {
+		to = &models.Consultation{}
+	}
+
+	changes := []models.RevisionChange{}
+	addChange := func(field string, fromValue, toValue interface{}) {
+		if !reflect.DeepEqual(fromValue, toValue) {
+			changes = append(changes, models.RevisionChange{Field: field, From: fromValue, To: toValue})
+		}
+	}
+
+	addChange("idPatient", from.IDPatient, to.IDPatient)
+	addChange("idDoctor", from.IDDoctor, to.IDDoctor)
+	addChange("date", from.Date.UTC(), to.Date.UTC())
+	addChange("diagnostic", from.Diagnostic, to.Diagnostic)
+	addChange("diagnoses", from.Diagnoses, to.Diagnoses)
+
+	fromInvestigations := make(map[primitive.ObjectID]models.Investigation, len(from.Investigations))
+	for _, investigation := range from.Investigations {
+		fromInvestigations[investigation.IDInvestigation] = investigation
+	}
+
+	for _, toInvestigation := range to.Investigations {
+		field := fmt.Sprintf("investigations[%s]", toInvestigation.IDInvestigation.Hex())
+		fromInvestigation, ok := fromInvestigations[toInvestigation.IDInvestigation]
+		if !ok {
+			changes = append(changes, models.RevisionChange{Field: field, From: nil, To: toInvestigation})
+			continue
+		}
+		delete(fromInvestigations, toInvestigation.IDInvestigation)
+
+		addChange(field+".name", fromInvestigation.Name, toInvestigation.Name)
+		addChange(field+".processingTime", fromInvestigation.ProcessingTime, toInvestigation.ProcessingTime)
+		addChange(field+".result", fromInvestigation.Result, toInvestigation.Result)
+		addChange(field+".status", fromInvestigation.Status, toInvestigation.Status)
+		addChange(field+".cancelReason", fromInvestigation.CancelReason, toInvestigation.CancelReason)
+	}
+
+	// Whatever is left was removed, listed in the order of the older revision
+	for _, fromInvestigation := range from.Investigations {
+		if _, removed := fromInvestigations[fromInvestigation.IDInvestigation]; removed {
+			field := fmt.Sprintf("investigations[%s]", fromInvestigation.IDInvestigation.Hex())
+			changes = append(changes, models.RevisionChange{Field: field, From: fromInvestigation, To: nil})
+		}
+	}
+
+	return changes
+}
+
+// revisionAuthor reads the user the gateway names as the author of a change, nil when there is none
+func revisionAuthor(w http.ResponseWriter, r *http.Request) (*int, bool) {
+	value := r.URL.Query().Get(utils.QUERY_CHANGED_BY)
+	if value == "" {
+		return nil, true
+	}
+
+	userID, err := strconv.Atoi(value)
+	if err != nil {
+		errMsg := fmt.Sprintf("Invalid %s: %s", utils.QUERY_CHANGED_BY, value)
+		log.Printf("[CONSULTATION] %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusBadRequest, models.ResponseData{Error: errMsg, Message: "Invalid author of the change"})
+		return nil, false
+	}
+	return &userID, true
+}
+
+func revisionConsultationID(w http.ResponseWriter, r *http.Request) (primitive.ObjectID, bool) {
+	consultationID, err := primitive.ObjectIDFromHex(mux.Vars(r)[utils.FETCH_CONSULTATIE_BY_ID_PARAMETER])
+	if err != nil {
+		utils.RespondWithJSON(w, http.StatusBadRequest, models.ResponseData{
+			Error:   "Invalid consultation ID",
+			Message: "Invalid consultation ID. Please provide a valid ID.",
+		})
+		return primitive.NilObjectID, false
+	}
+	return consultationID, true
+}
+
+func respondWithRevisionError(w http.ResponseWriter, err error, message string) {
+	if err == mongo.ErrNoDocuments {
+		utils.RespondWithJSON(w, http.StatusNotFound, models.ResponseData{
+			Error:   "Consultation revision not found",
+			Message: message,
+		})
+		return
+	}
+
+	errMsg := fmt.Sprintf("Internal server error: %s", err)
+	log.Printf("[CONSULTATION] %s %s", message, errMsg)
+	utils.RespondWithJSON(w, http.StatusInternalServerError, models.ResponseData{
+		Error:   errMsg,
+		Message: message,
+	})
+}
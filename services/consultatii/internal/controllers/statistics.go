@@ -0,0 +1,105 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/mihnea1711/POS_Project/services/consultatii/internal/models"
+	"github.com/mihnea1711/POS_Project/services/consultatii/pkg/utils"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// GetDiagnosisStatistics lists the most common coded diagnoses per doctor and month, for the consultations matching the
+// same filters as the consultation search. limit is the number of diagnoses kept for each month.
+func (cController *ConsultationController) GetDiagnosisStatistics(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[CONSULTATION] Attempting to retrieve diagnosis statistics.")
+
+	filters, ok := extractStatisticsFilters(w, r)
+	if !ok {
+		return
+	}
+
+	top := utils.DEFAULT_TOP_DIAGNOSES
+	if value := r.URL.Query().Get(utils.QUERY_LIMIT); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			errMsg := fmt.Sprintf("invalid %s: %s", utils.QUERY_LIMIT, value)
+			log.Printf("[CONSULTATION] %s", errMsg)
+			utils.RespondWithJSON(w, http.StatusBadRequest, models.ResponseData{Error: errMsg, Message: "Failed to extract filters"})
+			return
+		}
+		top = min(parsed, utils.MAX_TOP_DIAGNOSES)
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_TIMEOUT_DURATION*time.Second)
+	defer cancel()
+
+	statistics, err := cController.DbConn.FetchDiagnosisStatistics(ctx, filters, top)
+	if err != nil {
+		errMsg := fmt.Sprintf("Internal server error: %s", err)
+		log.Printf("[CONSULTATION] Failed to fetch diagnosis statistics: %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusInternalServerError, models.ResponseData{
+			Error:   errMsg,
+			Message: "Failed to retrieve diagnosis statistics. Internal server error.",
+		})
+		return
+	}
+
+	log.Printf("[CONSULTATION] Successfully retrieved the diagnosis statistics of %d doctor months", len(statistics))
+	utils.RespondWithJSON(w, http.StatusOK, models.ResponseData{
+		Message: "Diagnosis statistics retrieved successfully.",
+		Payload: statistics,
+	})
+}
+
+// GetInvestigationStatistics sums up the investigations per name for the consultations matching the same filters as the
+// consultation search: how many were ordered and resulted, the average planned processing time and the average
+// measured turnaround.
+func (cController *ConsultationController) GetInvestigationStatistics(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[CONSULTATION] Attempting to retrieve investigation statistics.")
+
+	filters, ok := extractStatisticsFilters(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_TIMEOUT_DURATION*time.Second)
+	defer cancel()
+
+	statistics, err := cController.DbConn.FetchInvestigationStatistics(ctx, filters)
+	if err != nil {
+		errMsg := fmt.Sprintf("Internal server error: %s", err)
+		log.Printf("[CONSULTATION] Failed to fetch investigation statistics: %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusInternalServerError, models.ResponseData{
+			Error:   errMsg,
+			Message: "Failed to retrieve investigation statistics. Internal server error.",
+		})
+		return
+	}
+
+	log.Printf("[CONSULTATION] Successfully retrieved the statistics of %d investigations", len(statistics))
+	utils.RespondWithJSON(w, http.StatusOK, models.ResponseData{
+		Message: "Investigation statistics retrieved successfully.",
+		Payload: statistics,
+	})
+}
+
+// extractStatisticsFilters reads the consultation search filters of a statistics request, answering the request when
+// they are invalid
+func extractStatisticsFilters(w http.ResponseWriter, r *http.Request) (bson.M, bool) {
+	filters, err := utils.ExtractFiltersFromRequest(r)
+	if err != nil {
+		errMsg := fmt.Sprintf("bad request: %s", err)
+		log.Printf("[CONSULTATION] Failed to extract statistics filters: %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusBadRequest, models.ResponseData{
+			Error:   errMsg,
+			Message: "Failed to extract filters",
+		})
+		return nil, false
+	}
+	return filters, true
+}
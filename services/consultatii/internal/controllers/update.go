@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/mihnea1711/POS_Project/services/consultatii/internal/database"
 	"github.com/mihnea1711/POS_Project/services/consultatii/internal/models"
 	"github.com/mihnea1711/POS_Project/services/consultatii/pkg/utils"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -36,14 +37,58 @@ func (cController *ConsultationController) UpdateConsultationByID(w http.Respons
 	consultation := r.Context().Value(utils.DECODED_CONSULTATION).(*models.Consultation)
 	consultation.IDConsultation = consultationID
 
+	// An amendment names the version it is based on and why it is made
+	if consultation.Version < utils.FIRST_CONSULTATION_VERSION {
+		utils.RespondWithJSON(w, http.StatusBadRequest, models.ResponseData{
+			Error:   "the version the update is based on is required",
+			Message: "Failed to update consultation. Missing version.",
+		})
+		return
+	}
+	reason := r.URL.Query().Get(utils.QUERY_REASON)
+	if reason == "" {
+		utils.RespondWithJSON(w, http.StatusBadRequest, models.ResponseData{
+			Error:   "the reason for the amendment is required",
+			Message: "Failed to update consultation. Missing reason.",
+		})
+		return
+	}
+	author, ok := revisionAuthor(w, r)
+	if !ok {
+		return
+	}
+	if !cController.codeDiagnoses(w, consultation.Diagnoses) {
+		return
+	}
+
 	// Ensure a database operation doesn't take longer than utils.REQUEST_TIMEOUT_DURATION seconds
 	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_TIMEOUT_DURATION*time.Second)
 	defer cancel()
 
 	cController.handleContextTimeout(ctx, w)
 
+	// The stored investigations keep their lifecycle, only the new ones start as ordered
+	stored, err := cController.DbConn.FetchConsultationByID(ctx, consultationID)
+	if err != nil {
+		handleDatabaseUpdateError(w, err, consultationID)
+		return
+	}
+	if stored.Version != consultation.Version {
+		handleDatabaseUpdateError(w, database.ErrVersionConflict, consultationID)
+		return
+	}
+	consultation.IDAppointment = stored.IDAppointment
+	if err := prepareInvestigations(consultation.Investigations, stored.Investigations, time.Now().UTC()); err != nil {
+		log.Printf("[CONSULTATION] Investigations of consultation %s rejected: %v", consultationID.Hex(), err)
+		utils.RespondWithJSON(w, http.StatusConflict, models.ResponseData{
+			Error:   err.Error(),
+			Message: "Failed to update consultation. Conflicting investigation status.",
+		})
+		return
+	}
+
 	// Use cController.DbConn to update the consultation by ID in the database
-	rowsAffected, err := cController.DbConn.UpdateConsultationByID(ctx, consultation)
+	rowsAffected, err := cController.DbConn.UpdateConsultationByID(ctx, consultation, author, reason)
 	if err != nil {
 		handleDatabaseUpdateError(w, err, consultationID)
 		return
@@ -61,7 +106,7 @@ func (cController *ConsultationController) UpdateConsultationByID(w http.Respons
 	}
 
 	// Log the successful update of the consultation
-	log.Printf("[CONSULTATION] Successfully updated consultation %s", consultation.IDConsultation.Hex())
+	log.Printf("[CONSULTATION] Successfully updated consultation %s to version %d", consultation.IDConsultation.Hex(), consultation.Version)
 
 	// Respond with a success message and the number of rows affected
 	response := models.ResponseData{
@@ -86,6 +131,17 @@ func handleDatabaseUpdateError(w http.ResponseWriter, err error, id primitive.Ob
 		return
 	}
 
+	if err == database.ErrVersionConflict {
+		// Handle the case where the consultation was amended since the client read it
+		log.Printf("[CONSULTATION] Failed to update consultation %s: %v", id.Hex(), err)
+		response := models.ResponseData{
+			Error:   err.Error(),
+			Message: "Failed to update consultation. It was changed in the meantime, fetch it again.",
+		}
+		utils.RespondWithJSON(w, http.StatusConflict, response)
+		return
+	}
+
 	// Check for a duplicate key error
 	if writeException, ok := err.(mongo.WriteException); ok {
 		for _, writeError := range writeException.WriteErrors {
@@ -114,3 +170,73 @@ func handleDatabaseUpdateError(w http.ResponseWriter, err error, id primitive.Ob
 	}
 	utils.RespondWithJSON(w, http.StatusInternalServerError, response)
 }
+
+// ReassignPatientConsultations moves the consultations of a duplicate patient record to the surviving one.
+// The patient module calls it while merging two records, a repeated call moves nothing and still succeeds.
+func (cController *ConsultationController) ReassignPatientConsultations(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[CONSULTATION] Attempting to reassign patient consultations.")
+
+	request := r.Context().Value(utils.DECODED_PATIENT_REASSIGNMENT).(*models.PatientReassignment)
+
+	// Ensure a database operation doesn't take longer than utils.REQUEST_TIMEOUT_DURATION seconds
+	ctx, cancel := context.WithTimeout(r.Context(), utils.REQUEST_TIMEOUT_DURATION*time.Second)
+	defer cancel()
+
+	rowsAffected, err := cController.DbConn.ReassignPatientConsultations(ctx, request.IDFromPatient, request.IDToPatient)
+	if err != nil {
+		log.Printf("[CONSULTATION] Error reassigning the consultations of patient %d: %v", request.IDFromPatient, err)
+		utils.RespondWithJSON(w, http.StatusInternalServerError, models.ResponseData{
+			Error:   err.Error(),
+			Message: "Failed to reassign patient consultations",
+		})
+		return
+	}
+
+	// The revisions, attachments, prescriptions and measurements follow the consultations, the rows affected still count
+	// consultations only
+	revisionsMoved, err := cController.DbConn.ReassignPatientRevisions(ctx, request.IDFromPatient, request.IDToPatient)
+	if err != nil {
+		log.Printf("[CONSULTATION] Error reassigning the consultation revisions of patient %d: %v", request.IDFromPatient, err)
+		utils.RespondWithJSON(w, http.StatusInternalServerError, models.ResponseData{
+			Error:   err.Error(),
+			Message: "Failed to reassign patient consultation revisions",
+		})
+		return
+	}
+
+	attachmentsMoved, err := cController.DbConn.ReassignPatientAttachments(ctx, request.IDFromPatient, request.IDToPatient)
+	if err != nil {
+		log.Printf("[CONSULTATION] Error reassigning the attachments of patient %d: %v", request.IDFromPatient, err)
+		utils.RespondWithJSON(w, http.StatusInternalServerError, models.ResponseData{
+			Error:   err.Error(),
+			Message: "Failed to reassign patient attachments",
+		})
+		return
+	}
+
+	prescriptionsMoved, err := cController.DbConn.ReassignPatientPrescriptions(ctx, request.IDFromPatient, request.IDToPatient)
+	if err != nil {
+		log.Printf("[CONSULTATION] Error reassigning the prescriptions of patient %d: %v", request.IDFromPatient, err)
+		utils.RespondWithJSON(w, http.StatusInternalServerError, models.ResponseData{
+			Error:   err.Error(),
+			Message: "Failed to reassign patient prescriptions",
+		})
+		return
+	}
+
+	measurementsMoved, err := cController.DbConn.ReassignPatientMeasurements(ctx, request.IDFromPatient, request.IDToPatient)
+	if err != nil {
+		log.Printf("[CONSULTATION] Error reassigning the measurements of patient %d: %v", request.IDFromPatient, err)
+		utils.RespondWithJSON(w, http.StatusInternalServerError, models.ResponseData{
+			Error:   err.Error(),
+			Message: "Failed to reassign patient measurements",
+		})
+		return
+	}
+
+	log.Printf("[CONSULTATION] Successfully moved %d consultations, %d revisions, %d attachments, %d prescriptions and %d measurements of patient %d to patient %d", rowsAffected, revisionsMoved, attachmentsMoved, prescriptionsMoved, measurementsMoved, request.IDFromPatient, request.IDToPatient)
+	utils.RespondWithJSON(w, http.StatusOK, models.ResponseData{
+		Message: fmt.Sprintf("Successfully moved %d consultations, %d revisions, %d attachments, %d prescriptions and %d measurements of patient %d to patient %d", rowsAffected, revisionsMoved, attachmentsMoved, prescriptionsMoved, measurementsMoved, request.IDFromPatient, request.IDToPatient),
+		Payload: models.RowsAffected{RowsAffected: rowsAffected},
+	})
+}
@@ -2,27 +2,87 @@ package database
 
 import (
 	"context"
+	"errors"
+	"io"
+	"time"
 
 	"github.com/mihnea1711/POS_Project/services/consultatii/internal/models"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// ErrVersionConflict is returned by an update based on a version of the consultation that is not the stored one anymore
+var ErrVersionConflict = errors.New("the consultation was changed by another update, fetch it again")
+
 type Database interface {
 	// create
-	SaveConsultation(ctx context.Context, consultatie *models.Consultation) (primitive.ObjectID, error)
+	SaveConsultation(ctx context.Context, consultatie *models.Consultation, author *int, reason string) (primitive.ObjectID, error)
 
 	// retrieve
-	FetchConsultations(ctx context.Context, filter bson.M, page int, limit int) ([]models.Consultation, error)
+	FetchConsultations(ctx context.Context, filter bson.M, sort bson.D, page int, limit int) ([]models.Consultation, error)
 	FetchConsultationByID(ctx context.Context, consultationID primitive.ObjectID) (*models.Consultation, error)
+	CountPatientConsultations(ctx context.Context, patientID int) (int, error)
+	FetchAppointmentLinks(ctx context.Context, appointmentIDs []int) ([]models.AppointmentLink, error)
 
 	// update
-	UpdateConsultationByID(ctx context.Context, consultatie *models.Consultation) (int, error)
+	UpdateConsultationByID(ctx context.Context, consultatie *models.Consultation, author *int, reason string) (int, error)
+	ReassignPatientConsultations(ctx context.Context, fromPatientID, toPatientID int) (int, error)
 
 	// delete
 	DeleteConsultationByID(ctx context.Context, consultationID primitive.ObjectID) (int, error)
 	DeleteConsultationsByPatientOrDoctorID(ctx context.Context, id int) (int, error)
 
+	// revisions
+	FetchConsultationRevisions(ctx context.Context, consultationID primitive.ObjectID, page int, limit int) ([]models.ConsultationRevision, error)
+	FetchConsultationRevision(ctx context.Context, consultationID primitive.ObjectID, version int) (*models.ConsultationRevision, error)
+	CountPatientRevisions(ctx context.Context, patientID int) (int, error)
+	ReassignPatientRevisions(ctx context.Context, fromPatientID, toPatientID int) (int, error)
+
+	// investigations
+	FetchPendingInvestigations(ctx context.Context, filter bson.M, statuses []models.InvestigationStatus, overdueAt *time.Time, page int, limit int) ([]models.PendingInvestigation, error)
+	FetchConsultationByInvestigationID(ctx context.Context, investigationID primitive.ObjectID) (*models.Consultation, error)
+
+	// lab results
+	SaveLabReconciliationItem(ctx context.Context, item *models.LabReconciliationItem) (bool, error)
+	FetchLabReconciliationItems(ctx context.Context, filter bson.M, page int, limit int) ([]models.LabReconciliationItem, error)
+	FetchLabReconciliationItemByID(ctx context.Context, itemID primitive.ObjectID) (*models.LabReconciliationItem, error)
+	CloseLabReconciliationItem(ctx context.Context, item *models.LabReconciliationItem) (int, error)
+
+	// statistics
+	FetchDiagnosisStatistics(ctx context.Context, filter bson.M, top int) ([]models.DiagnosisStatistics, error)
+	FetchInvestigationStatistics(ctx context.Context, filter bson.M) ([]models.InvestigationStatistics, error)
+
+	// attachments
+	SaveAttachment(ctx context.Context, attachment *models.Attachment, content io.Reader) error
+	FetchAttachments(ctx context.Context, filter bson.M, page int, limit int) ([]models.Attachment, error)
+	FetchAttachmentByID(ctx context.Context, attachmentID primitive.ObjectID) (*models.Attachment, error)
+	OpenAttachmentContent(ctx context.Context, attachmentID primitive.ObjectID) (io.ReadCloser, error)
+	DeleteAttachmentByID(ctx context.Context, attachmentID primitive.ObjectID) (int, error)
+	CountPatientAttachments(ctx context.Context, patientID int) (int, error)
+	ReassignPatientAttachments(ctx context.Context, fromPatientID, toPatientID int) (int, error)
+
+	// prescriptions
+	SavePrescription(ctx context.Context, prescription *models.Prescription) error
+	FetchPrescriptions(ctx context.Context, consultationID primitive.ObjectID) ([]models.Prescription, error)
+	FetchPrescriptionByID(ctx context.Context, consultationID, prescriptionID primitive.ObjectID) (*models.Prescription, error)
+	FetchPatientPrescriptions(ctx context.Context, patientID int, page int, limit int) ([]models.Prescription, error)
+	FetchActivePrescriptions(ctx context.Context, patientID int, since time.Time) ([]models.Prescription, error)
+	UpdatePrescriptionStatus(ctx context.Context, prescription *models.Prescription, from models.PrescriptionStatus) (int, error)
+	CountPatientPrescriptions(ctx context.Context, patientID int) (int, error)
+	ReassignPatientPrescriptions(ctx context.Context, fromPatientID, toPatientID int) (int, error)
+
+	// measurements
+	SaveMeasurements(ctx context.Context, measurements []models.Measurement) error
+	FetchMeasurements(ctx context.Context, filter bson.M, page int, limit int) ([]models.Measurement, error)
+	FetchMeasurementByID(ctx context.Context, measurementID primitive.ObjectID) (*models.Measurement, error)
+	RetractMeasurement(ctx context.Context, measurementID primitive.ObjectID, reason string, author *int, at time.Time) (int, error)
+	FetchMeasurementBuckets(ctx context.Context, filter bson.M, interval string, timeZone string) ([]models.MeasurementBucket, error)
+	CountPatientMeasurements(ctx context.Context, patientID int) (int, error)
+	ReassignPatientMeasurements(ctx context.Context, fromPatientID, toPatientID int) (int, error)
+
+	// encryption
+	RotateConsultationKeys(ctx context.Context) (*models.KeyRotationReport, error)
+
 	// Add more methods as needed
 
 	Close(ctx context.Context) error
@@ -0,0 +1,250 @@
+package mongo
+
+import (
+	"context"
+	"io"
+	"log"
+	"time"
+
+	"github.com/mihnea1711/POS_Project/services/consultatii/internal/models"
+	"github.com/mihnea1711/POS_Project/services/consultatii/pkg/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// attachmentFile is the document GridFS keeps in the files collection of the bucket
+type attachmentFile struct {
+	ID         primitive.ObjectID `bson:"_id"`
+	Length     int64              `bson:"length"`
+	UploadDate time.Time          `bson:"uploadDate"`
+	FileName   string             `bson:"filename"`
+	Metadata   attachmentMetadata `bson:"metadata"`
+}
+
+type attachmentMetadata struct {
+	IDPatient       int                 `bson:"id_patient"`
+	IDConsultation  *primitive.ObjectID `bson:"id_consultation,omitempty"`
+	IDInvestigation *primitive.ObjectID `bson:"id_investigation,omitempty"`
+	ContentType     string              `bson:"content_type"`
+	Description     string              `bson:"description,omitempty"`
+}
+
+func (f *attachmentFile) attachment() models.Attachment {
+	return models.Attachment{
+		IDAttachment:    f.ID,
+		IDPatient:       f.Metadata.IDPatient,
+		IDConsultation:  f.Metadata.IDConsultation,
+		IDInvestigation: f.Metadata.IDInvestigation,
+		FileName:        f.FileName,
+		ContentType:     f.Metadata.ContentType,
+		Size:            f.Length,
+		Description:     f.Metadata.Description,
+		UploadedAt:      f.UploadDate,
+	}
+}
+
+// attachmentBucket opens the GridFS bucket. Deadlines are set on the bucket, so every operation opens its own.
+func (db *MongoDB) attachmentBucket(ctx context.Context) (*gridfs.Bucket, error) {
+	bucket, err := gridfs.NewBucket(db.db, options.GridFSBucket().SetName(utils.ATTACHMENT_BUCKET))
+	if err != nil {
+		return nil, err
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := bucket.SetReadDeadline(deadline); err != nil {
+			return nil, err
+		}
+		if err := bucket.SetWriteDeadline(deadline); err != nil {
+			return nil, err
+		}
+	}
+	return bucket, nil
+}
+
+// SaveAttachment streams the content into GridFS chunk by chunk. The ID, size and upload date are set on the attachment.
+// A failing read aborts the upload and removes the chunks written so far.
+func (db *MongoDB) SaveAttachment(ctx context.Context, attachment *models.Attachment, content io.Reader) error {
+	bucket, err := db.attachmentBucket(ctx)
+	if err != nil {
+		log.Printf("[CONSULTATION] Error opening attachment bucket: %v", err)
+		return err
+	}
+
+	attachment.IDAttachment = primitive.NewObjectID()
+	metadata := attachmentMetadata{
+		IDPatient:       attachment.IDPatient,
+		IDConsultation:  attachment.IDConsultation,
+		IDInvestigation: attachment.IDInvestigation,
+		ContentType:     attachment.ContentType,
+		Description:     attachment.Description,
+	}
+
+	stream, err := bucket.OpenUploadStreamWithID(attachment.IDAttachment, attachment.FileName, options.GridFSUpload().SetMetadata(metadata))
+	if err != nil {
+		log.Printf("[CONSULTATION] Error opening upload stream: %v", err)
+		return err
+	}
+
+	size, err := io.Copy(stream, content)
+	if err != nil {
+		log.Printf("[CONSULTATION] Error uploading attachment %s: %v", attachment.IDAttachment.Hex(), err)
+		if abortErr := stream.Abort(); abortErr != nil {
+			log.Printf("[CONSULTATION] Error aborting upload of attachment %s: %v", attachment.IDAttachment.Hex(), abortErr)
+		}
+		return err
+	}
+
+	if err := stream.Close(); err != nil {
+		log.Printf("[CONSULTATION] Error closing upload of attachment %s: %v", attachment.IDAttachment.Hex(), err)
+		return err
+	}
+
+	attachment.Size = size
+	attachment.UploadedAt = time.Now().UTC()
+
+	log.Printf("[CONSULTATION] Attachment %s of patient %d saved, %d bytes", attachment.IDAttachment.Hex(), attachment.IDPatient, size)
+	return nil
+}
+
+// FetchAttachments lists the attachments matching the filter, the most recent first
+func (db *MongoDB) FetchAttachments(ctx context.Context, filter bson.M, page int, limit int) ([]models.Attachment, error) {
+	bucket, err := db.attachmentBucket(ctx)
+	if err != nil {
+		log.Printf("[CONSULTATION] Error opening attachment bucket: %v", err)
+		return nil, err
+	}
+
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: utils.COLUMN_ATTACHMENT_UPLOAD_DATE, Value: -1}}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+
+	log.Printf("[CONSULTATION] Fetching attachments with filter: %v, Limit of %d, on Page %d", filter, limit, page)
+
+	cursor, err := bucket.GetFilesCollection().Find(ctx, filter, findOptions)
+	if err != nil {
+		log.Printf("[CONSULTATION] Failed to find attachments with filter: %v", filter)
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var files []attachmentFile
+	if err := cursor.All(ctx, &files); err != nil {
+		log.Printf("[CONSULTATION] Failed to decode attachments: %v", err)
+		return nil, err
+	}
+
+	attachments := make([]models.Attachment, 0, len(files))
+	for i := range files {
+		attachments = append(attachments, files[i].attachment())
+	}
+
+	log.Printf("[CONSULTATION] Fetched %d attachments", len(attachments))
+	return attachments, nil
+}
+
+// FetchAttachmentByID returns the metadata of an attachment, mongo.ErrNoDocuments when there is none
+func (db *MongoDB) FetchAttachmentByID(ctx context.Context, attachmentID primitive.ObjectID) (*models.Attachment, error) {
+	bucket, err := db.attachmentBucket(ctx)
+	if err != nil {
+		log.Printf("[CONSULTATION] Error opening attachment bucket: %v", err)
+		return nil, err
+	}
+
+	var file attachmentFile
+	if err := bucket.GetFilesCollection().FindOne(ctx, bson.M{"_id": attachmentID}).Decode(&file); err != nil {
+		log.Printf("[CONSULTATION] Error fetching attachment %s: %v", attachmentID.Hex(), err)
+		return nil, err
+	}
+
+	attachment := file.attachment()
+	return &attachment, nil
+}
+
+// OpenAttachmentContent opens the content of an attachment for reading, mongo.ErrNoDocuments when there is none.
+// The caller closes the stream.
+func (db *MongoDB) OpenAttachmentContent(ctx context.Context, attachmentID primitive.ObjectID) (io.ReadCloser, error) {
+	bucket, err := db.attachmentBucket(ctx)
+	if err != nil {
+		log.Printf("[CONSULTATION] Error opening attachment bucket: %v", err)
+		return nil, err
+	}
+
+	stream, err := bucket.OpenDownloadStream(attachmentID)
+	if err == gridfs.ErrFileNotFound {
+		return nil, mongo.ErrNoDocuments
+	}
+	if err != nil {
+		log.Printf("[CONSULTATION] Error opening download stream of attachment %s: %v", attachmentID.Hex(), err)
+		return nil, err
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := stream.SetReadDeadline(deadline); err != nil {
+			stream.Close()
+			return nil, err
+		}
+	}
+	return stream, nil
+}
+
+// DeleteAttachmentByID removes the file and its chunks and returns the number of files removed
+func (db *MongoDB) DeleteAttachmentByID(ctx context.Context, attachmentID primitive.ObjectID) (int, error) {
+	bucket, err := db.attachmentBucket(ctx)
+	if err != nil {
+		log.Printf("[CONSULTATION] Error opening attachment bucket: %v", err)
+		return 0, err
+	}
+
+	err = bucket.DeleteContext(ctx, attachmentID)
+	if err == gridfs.ErrFileNotFound {
+		log.Printf("[CONSULTATION] No attachment with ID %v has been deleted.", attachmentID.Hex())
+		return 0, nil
+	}
+	if err != nil {
+		log.Printf("[CONSULTATION] Error deleting attachment %s: %v", attachmentID.Hex(), err)
+		return 0, err
+	}
+
+	log.Printf("[CONSULTATION] Attachment with ID %v deleted successfully.", attachmentID.Hex())
+	return 1, nil
+}
+
+func (db *MongoDB) CountPatientAttachments(ctx context.Context, patientID int) (int, error) {
+	bucket, err := db.attachmentBucket(ctx)
+	if err != nil {
+		log.Printf("[CONSULTATION] Error opening attachment bucket: %v", err)
+		return 0, err
+	}
+
+	count, err := bucket.GetFilesCollection().CountDocuments(ctx, bson.M{utils.COLUMN_ATTACHMENT_ID_PATIENT: patientID})
+	if err != nil {
+		log.Printf("[CONSULTATION] Error counting the attachments of patient %d: %v", patientID, err)
+		return 0, err
+	}
+	return int(count), nil
+}
+
+// ReassignPatientAttachments moves every attachment of a patient to another patient, like their consultations
+func (db *MongoDB) ReassignPatientAttachments(ctx context.Context, fromPatientID, toPatientID int) (int, error) {
+	bucket, err := db.attachmentBucket(ctx)
+	if err != nil {
+		log.Printf("[CONSULTATION] Error opening attachment bucket: %v", err)
+		return 0, err
+	}
+
+	result, err := bucket.GetFilesCollection().UpdateMany(ctx,
+		bson.M{utils.COLUMN_ATTACHMENT_ID_PATIENT: fromPatientID},
+		bson.M{"$set": bson.M{utils.COLUMN_ATTACHMENT_ID_PATIENT: toPatientID}},
+	)
+	if err != nil {
+		log.Printf("[CONSULTATION] Error moving the attachments of patient %d: %v", fromPatientID, err)
+		return 0, err
+	}
+
+	log.Printf("[CONSULTATION] Moved %d attachments of patient %d to patient %d", result.ModifiedCount, fromPatientID, toPatientID)
+	return int(result.ModifiedCount), nil
+}
@@ -0,0 +1,191 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/mihnea1711/POS_Project/services/consultatii/internal/encryption"
+	"github.com/mihnea1711/POS_Project/services/consultatii/internal/models"
+	"github.com/mihnea1711/POS_Project/services/consultatii/pkg/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// sealConsultation returns a copy of the consultation with the diagnostic and the investigation results encrypted,
+// the consultation of the caller keeps the plaintext. The ICD-10 codes stay in clear so they can be counted in reports.
+func (db *MongoDB) sealConsultation(ctx context.Context, consultation *models.Consultation) (*models.Consultation, error) {
+	sealed := *consultation
+
+	var err error
+	if sealed.Diagnostic, err = db.cipher.Encrypt(ctx, utils.COLUMN_DIAGNOSTIC, consultation.Diagnostic); err != nil {
+		return nil, err
+	}
+
+	sealed.Investigations = make([]models.Investigation, len(consultation.Investigations))
+	for i := range consultation.Investigations {
+		investigation, err := db.sealInvestigation(ctx, &consultation.Investigations[i])
+		if err != nil {
+			return nil, err
+		}
+		sealed.Investigations[i] = *investigation
+	}
+	return &sealed, nil
+}
+
+// sealInvestigation returns a copy of the investigation with the result encrypted
+func (db *MongoDB) sealInvestigation(ctx context.Context, investigation *models.Investigation) (*models.Investigation, error) {
+	sealed := *investigation
+
+	var err error
+	if sealed.Result, err = db.cipher.Encrypt(ctx, utils.RESULT, investigation.Result); err != nil {
+		return nil, err
+	}
+	return &sealed, nil
+}
+
+// openConsultation decrypts the diagnostic and the investigation results of a consultation read from the database.
+// Investigations stored before they had a lifecycle get one, consultations stored before versioning the first version
+// and those stored before coded diagnoses an empty list of them.
+func (db *MongoDB) openConsultation(ctx context.Context, consultation *models.Consultation) error {
+	var err error
+	if consultation.Diagnostic, err = db.cipher.Decrypt(ctx, utils.COLUMN_DIAGNOSTIC, consultation.Diagnostic); err != nil {
+		return err
+	}
+	for i := range consultation.Investigations {
+		result := &consultation.Investigations[i].Result
+		if *result, err = db.cipher.Decrypt(ctx, utils.RESULT, *result); err != nil {
+			return err
+		}
+		fillLegacyLifecycle(&consultation.Investigations[i], consultation.Date)
+	}
+	if consultation.Diagnoses == nil {
+		consultation.Diagnoses = []models.Diagnosis{}
+	}
+	if consultation.Version == 0 {
+		consultation.Version = utils.FIRST_CONSULTATION_VERSION
+	}
+	return nil
+}
+
+// RotateConsultationKeys wraps the data keys of the values left under a retired key again with the active key, the
+// values themselves are not decrypted. Values written before the encryption was introduced are encrypted.
+// The consultations kept in revisions and the lab results queued for review are swept as well, their content does not
+// change.
+// A consultation changed during the sweep is skipped and picked up by the next one.
+func (db *MongoDB) RotateConsultationKeys(ctx context.Context) (*models.KeyRotationReport, error) {
+	activeKeyID, err := db.cipher.Provider().ActiveKeyID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.KeyRotationReport{ActiveKeyID: activeKeyID, KeysInUse: map[string]int{}}
+	if err := db.rotateCollectionKeys(ctx, utils.CONSULTATIE_TABLE, "", report); err != nil {
+		return nil, err
+	}
+	if err := db.rotateCollectionKeys(ctx, utils.CONSULTATION_REVISION_TABLE, utils.COLUMN_REVISION_CONSULTATION+".", report); err != nil {
+		return nil, err
+	}
+	if err := db.rotateLabReconciliationKeys(ctx, report); err != nil {
+		return nil, err
+	}
+
+	log.Printf("[CONSULTATION] Key rotation scanned %d documents, rewrapped %d, skipped %d.", report.Scanned, report.Rewrapped, report.Skipped)
+	return report, nil
+}
+
+// rotateCollectionKeys rotates the keys of the consultations of a collection, kept under the prefix in its documents
+func (db *MongoDB) rotateCollectionKeys(ctx context.Context, collectionName, prefix string, report *models.KeyRotationReport) error {
+	collection := db.db.Collection(collectionName)
+	diagnosticPath := prefix + utils.COLUMN_DIAGNOSTIC
+	investigationsPath := prefix + utils.COLUMN_INVESTIGATII
+	projection := bson.M{diagnosticPath: 1, investigationsPath + "." + utils.RESULT: 1}
+
+	cursor, err := collection.Find(ctx, bson.M{}, options.Find().SetProjection(projection))
+	if err != nil {
+		log.Printf("[CONSULTATION] Error fetching %s documents to rotate: %v", collectionName, err)
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var stored struct {
+			Diagnostic     string `bson:"diagnostic"`
+			Investigations []struct {
+				Result string `bson:"result"`
+			} `bson:"investigations"`
+		}
+		id, ok := cursor.Current.Lookup("_id").ObjectIDOK()
+		if !ok {
+			log.Printf("[CONSULTATION] Skipping a %s document without an ObjectID", collectionName)
+			continue
+		}
+		consultation := cursor.Current
+		if prefix != "" {
+			if consultation, ok = cursor.Current.Lookup(utils.COLUMN_REVISION_CONSULTATION).DocumentOK(); !ok {
+				continue
+			}
+		}
+		if err := bson.Unmarshal(consultation, &stored); err != nil {
+			log.Printf("[CONSULTATION] Error decoding %s document to rotate: %v", collectionName, err)
+			return err
+		}
+		report.Scanned++
+
+		// Every rotated value is matched with its stored version, so a concurrent update is not overwritten
+		filter := bson.M{"_id": id}
+		set := bson.M{}
+		values := map[string]string{diagnosticPath: stored.Diagnostic}
+		for i, investigation := range stored.Investigations {
+			values[fmt.Sprintf("%s.%d.%s", investigationsPath, i, utils.RESULT)] = investigation.Result
+		}
+
+		for path, value := range values {
+			if value == "" {
+				continue
+			}
+			if encryption.KeyID(value) == report.ActiveKeyID {
+				report.KeysInUse[report.ActiveKeyID]++
+				continue
+			}
+
+			field := utils.COLUMN_DIAGNOSTIC
+			if path != diagnosticPath {
+				field = utils.RESULT
+			}
+			rewrapped, err := db.cipher.Rewrap(ctx, field, value)
+			if err != nil {
+				log.Printf("[CONSULTATION] Error rotating the keys of %s document %s: %v", collectionName, id.Hex(), err)
+				return err
+			}
+			filter[path] = value
+			set[path] = rewrapped
+		}
+		if len(set) == 0 {
+			continue
+		}
+
+		result, err := collection.UpdateOne(ctx, filter, bson.M{"$set": set})
+		if err != nil {
+			log.Printf("[CONSULTATION] Error saving the rotated keys of %s document %s: %v", collectionName, id.Hex(), err)
+			return err
+		}
+		if result.MatchedCount == 0 {
+			report.Skipped++
+			for path := range set {
+				if keyID := encryption.KeyID(values[path]); keyID != "" {
+					report.KeysInUse[keyID]++
+				}
+			}
+			continue
+		}
+
+		report.Rewrapped++
+		report.KeysInUse[report.ActiveKeyID] += len(set)
+	}
+	if err := cursor.Err(); err != nil {
+		log.Printf("[CONSULTATION] Error iterating over %s documents to rotate: %v", collectionName, err)
+		return err
+	}
+	return nil
+}
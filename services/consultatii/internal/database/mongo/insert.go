@@ -10,19 +10,34 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-// SaveConsultatie saves a consultation to the database.
-func (db *MongoDB) SaveConsultation(ctx context.Context, consultation *models.Consultation) (primitive.ObjectID, error) {
+// SaveConsultatie saves a consultation to the database at the first version and records it as the first revision.
+func (db *MongoDB) SaveConsultation(ctx context.Context, consultation *models.Consultation, author *int, reason string) (primitive.ObjectID, error) {
 	// Get the MongoDB collection for consultations
 	collection := db.db.Collection(utils.CONSULTATIE_TABLE)
 
 	// Log the attempt to save the consultation
 	log.Println("[PATIENT] Attempting to save consultation")
 
+	consultation.Version = utils.FIRST_CONSULTATION_VERSION
+	sealed, err := db.sealConsultation(ctx, consultation)
+	if err != nil {
+		log.Printf("[CONSULTATION] Error encrypting consultation: %v", err)
+		return primitive.NilObjectID, err
+	}
+
+	// The revision goes first, so there is never a consultation without its history
+	revisionID, err := db.saveRevision(ctx, sealed, author, reason)
+	if err != nil {
+		log.Printf("[CONSULTATION] Error recording the first revision of consultation %s: %v", consultation.IDConsultation.Hex(), err)
+		return primitive.NilObjectID, err
+	}
+
 	// Insert the consultation document into the collection
-	result, err := collection.InsertOne(ctx, consultation)
+	result, err := collection.InsertOne(ctx, sealed)
 	if err != nil {
 		// Log an error if the insertion operation fails
 		log.Printf("[CONSULTATION] Error saving consultation: %v", err)
+		db.discardRevision(ctx, revisionID)
 		return primitive.NilObjectID, err
 	}
 
@@ -0,0 +1,99 @@
+package mongo
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/mihnea1711/POS_Project/services/consultatii/internal/models"
+	"github.com/mihnea1711/POS_Project/services/consultatii/pkg/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// FetchPendingInvestigations lists the investigations with one of the statuses, of the consultations matching the filter.
+// When overdueAt is set only the investigations expected before it are listed. The earliest expected come first.
+func (db *MongoDB) FetchPendingInvestigations(ctx context.Context, filter bson.M, statuses []models.InvestigationStatus, overdueAt *time.Time, page int, limit int) ([]models.PendingInvestigation, error) {
+	collection := db.db.Collection(utils.CONSULTATIE_TABLE)
+
+	statusPath := utils.COLUMN_INVESTIGATII + "." + utils.STATUS
+	expectedPath := utils.COLUMN_INVESTIGATII + "." + utils.EXPECTED_COMPLETION
+
+	consultationMatch := bson.M{statusPath: bson.M{"$in": statuses}}
+	for key, value := range filter {
+		consultationMatch[key] = value
+	}
+	investigationMatch := bson.M{statusPath: bson.M{"$in": statuses}}
+	if overdueAt != nil {
+		investigationMatch[expectedPath] = bson.M{"$lt": *overdueAt}
+	}
+
+	pipeline := []bson.M{
+		{"$match": consultationMatch},
+		{"$unwind": "$" + utils.COLUMN_INVESTIGATII},
+		{"$match": investigationMatch},
+		{"$sort": bson.D{{Key: expectedPath, Value: 1}}},
+		{"$skip": int64((page - 1) * limit)},
+		{"$limit": int64(limit)},
+		{"$project": bson.M{utils.COLUMN_ID_PATIENT: 1, utils.COLUMN_ID_DOCTOR: 1, "investigation": "$" + utils.COLUMN_INVESTIGATII}},
+	}
+
+	log.Printf("[CONSULTATION] Fetching investigations with statuses %v and filter: %v, Limit of %d, on Page %d", statuses, filter, limit, page)
+
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		log.Printf("[CONSULTATION] Failed to fetch pending investigations: %v", err)
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	pending := []models.PendingInvestigation{}
+	if err := cursor.All(ctx, &pending); err != nil {
+		log.Printf("[CONSULTATION] Failed to decode pending investigations: %v", err)
+		return nil, err
+	}
+	for i := range pending {
+		result := &pending[i].Investigation.Result
+		if *result, err = db.cipher.Decrypt(ctx, utils.RESULT, *result); err != nil {
+			log.Printf("[CONSULTATION] Failed to decrypt investigation %s: %v", pending[i].Investigation.IDInvestigation.Hex(), err)
+			return nil, err
+		}
+	}
+
+	log.Printf("[CONSULTATION] Fetched %d pending investigations", len(pending))
+	return pending, nil
+}
+
+// FetchConsultationByInvestigationID returns the consultation an investigation was ordered in, mongo.ErrNoDocuments
+// when no consultation has it
+func (db *MongoDB) FetchConsultationByInvestigationID(ctx context.Context, investigationID primitive.ObjectID) (*models.Consultation, error) {
+	filter := bson.M{utils.COLUMN_INVESTIGATII + "." + utils.ID_INVESTIGATION: investigationID}
+
+	var consultation models.Consultation
+	if err := db.db.Collection(utils.CONSULTATIE_TABLE).FindOne(ctx, filter).Decode(&consultation); err != nil {
+		log.Printf("[CONSULTATION] Error fetching the consultation of investigation %s: %v", investigationID.Hex(), err)
+		return nil, err
+	}
+	if err := db.openConsultation(ctx, &consultation); err != nil {
+		log.Printf("[CONSULTATION] Error decrypting consultation: %v", err)
+		return nil, err
+	}
+	return &consultation, nil
+}
+
+// fillLegacyLifecycle gives a lifecycle to an investigation stored before investigations had one. Those always had a
+// result and are taken as ordered on the day of the consultation.
+func fillLegacyLifecycle(investigation *models.Investigation, consultationDate time.Time) {
+	if investigation.Status == "" {
+		investigation.Status = utils.INVESTIGATION_STATUS_ORDERED
+		if investigation.Result != "" {
+			investigation.Status = utils.INVESTIGATION_STATUS_RESULTED
+		}
+	}
+	if investigation.OrderedAt.IsZero() {
+		investigation.OrderedAt = consultationDate
+	}
+	if investigation.ExpectedCompletion.IsZero() {
+		investigation.ExpectedCompletion = utils.ExpectedInvestigationCompletion(investigation)
+	}
+}
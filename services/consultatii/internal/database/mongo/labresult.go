@@ -0,0 +1,213 @@
+package mongo
+
+import (
+	"context"
+	"log"
+
+	"github.com/mihnea1711/POS_Project/services/consultatii/internal/encryption"
+	"github.com/mihnea1711/POS_Project/services/consultatii/internal/models"
+	"github.com/mihnea1711/POS_Project/services/consultatii/pkg/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ensureLabReconciliationIndexes serves the review queue, the open items the latest first. An order of a message is
+// queued once, however many times the lab sends the message.
+func ensureLabReconciliationIndexes(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection(utils.LAB_RECONCILIATION_TABLE).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: utils.COLUMN_LAB_STATUS, Value: 1}, {Key: utils.COLUMN_LAB_RECEIVED_AT, Value: -1}}},
+		{Keys: bson.D{{Key: utils.COLUMN_LAB_FINGERPRINT, Value: 1}}, Options: options.Index().SetUnique(true)},
+	})
+	return err
+}
+
+// SaveLabReconciliationItem queues a lab result for review. It returns false when the same order of the same message
+// is already queued.
+func (db *MongoDB) SaveLabReconciliationItem(ctx context.Context, item *models.LabReconciliationItem) (bool, error) {
+	if item.IDItem.IsZero() {
+		item.IDItem = primitive.NewObjectID()
+	}
+
+	sealed := *item
+	for field, value := range labEncryptedValues(&sealed) {
+		encrypted, err := db.cipher.Encrypt(ctx, field, *value)
+		if err != nil {
+			return false, err
+		}
+		*value = encrypted
+	}
+
+	if _, err := db.db.Collection(utils.LAB_RECONCILIATION_TABLE).InsertOne(ctx, sealed); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			log.Printf("[CONSULTATION] Lab result %s is already queued for review", item.Fingerprint)
+			return false, nil
+		}
+		log.Printf("[CONSULTATION] Error queuing lab result %s for review: %v", item.Fingerprint, err)
+		return false, err
+	}
+
+	log.Printf("[CONSULTATION] Lab result queued for review as %s: %s", item.IDItem.Hex(), item.Reason)
+	return true, nil
+}
+
+// FetchLabReconciliationItems lists the queued lab results matching the filter, the latest received first
+func (db *MongoDB) FetchLabReconciliationItems(ctx context.Context, filter bson.M, page int, limit int) ([]models.LabReconciliationItem, error) {
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: utils.COLUMN_LAB_RECEIVED_AT, Value: -1}}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+
+	log.Printf("[CONSULTATION] Fetching lab reconciliation items with filter: %v, Limit of %d, on Page %d", filter, limit, page)
+
+	cursor, err := db.db.Collection(utils.LAB_RECONCILIATION_TABLE).Find(ctx, filter, findOptions)
+	if err != nil {
+		log.Printf("[CONSULTATION] Failed to find lab reconciliation items: %v", err)
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	items := []models.LabReconciliationItem{}
+	if err := cursor.All(ctx, &items); err != nil {
+		log.Printf("[CONSULTATION] Failed to decode lab reconciliation items: %v", err)
+		return nil, err
+	}
+	for i := range items {
+		if err := db.openLabReconciliationItem(ctx, &items[i]); err != nil {
+			log.Printf("[CONSULTATION] Failed to decrypt lab reconciliation item %s: %v", items[i].IDItem.Hex(), err)
+			return nil, err
+		}
+	}
+
+	log.Printf("[CONSULTATION] Fetched %d lab reconciliation items", len(items))
+	return items, nil
+}
+
+// FetchLabReconciliationItemByID returns a queued lab result, mongo.ErrNoDocuments when there is none with the ID
+func (db *MongoDB) FetchLabReconciliationItemByID(ctx context.Context, itemID primitive.ObjectID) (*models.LabReconciliationItem, error) {
+	var item models.LabReconciliationItem
+	if err := db.db.Collection(utils.LAB_RECONCILIATION_TABLE).FindOne(ctx, bson.M{utils.COLUMN_LAB_ID: itemID}).Decode(&item); err != nil {
+		log.Printf("[CONSULTATION] Error fetching lab reconciliation item %s: %v", itemID.Hex(), err)
+		return nil, err
+	}
+	if err := db.openLabReconciliationItem(ctx, &item); err != nil {
+		log.Printf("[CONSULTATION] Failed to decrypt lab reconciliation item %s: %v", itemID.Hex(), err)
+		return nil, err
+	}
+	return &item, nil
+}
+
+// CloseLabReconciliationItem saves the review of a queued lab result, provided it is still open. It returns the number
+// of items closed, 0 when the item is gone or was reviewed in the meantime.
+func (db *MongoDB) CloseLabReconciliationItem(ctx context.Context, item *models.LabReconciliationItem) (int, error) {
+	filter := bson.M{utils.COLUMN_LAB_ID: item.IDItem, utils.COLUMN_LAB_STATUS: utils.LAB_RECONCILIATION_STATUS_OPEN}
+	set := bson.M{
+		utils.COLUMN_LAB_STATUS:      item.Status,
+		utils.COLUMN_LAB_REVIEWED_AT: item.ReviewedAt,
+		utils.COLUMN_LAB_REVIEW_NOTE: item.ReviewNote,
+	}
+	if item.ReviewedBy != nil {
+		set[utils.COLUMN_LAB_REVIEWED_BY] = item.ReviewedBy
+	}
+	if item.IDConsultation != nil {
+		set[utils.COLUMN_LAB_ID_CONSULTATION] = item.IDConsultation
+		set[utils.COLUMN_LAB_ID_INVESTIGATION] = item.IDInvestigation
+	}
+
+	result, err := db.db.Collection(utils.LAB_RECONCILIATION_TABLE).UpdateOne(ctx, filter, bson.M{"$set": set})
+	if err != nil {
+		log.Printf("[CONSULTATION] Error closing lab reconciliation item %s: %v", item.IDItem.Hex(), err)
+		return 0, err
+	}
+
+	log.Printf("[CONSULTATION] Lab reconciliation item %s %s: %d matched", item.IDItem.Hex(), item.Status, result.MatchedCount)
+	return int(result.MatchedCount), nil
+}
+
+func (db *MongoDB) openLabReconciliationItem(ctx context.Context, item *models.LabReconciliationItem) error {
+	for field, value := range labEncryptedValues(item) {
+		decrypted, err := db.cipher.Decrypt(ctx, field, *value)
+		if err != nil {
+			return err
+		}
+		*value = decrypted
+	}
+	return nil
+}
+
+// labEncryptedValues are the values of an item encrypted at rest, by the field name they are encrypted under
+func labEncryptedValues(item *models.LabReconciliationItem) map[string]*string {
+	return map[string]*string{
+		utils.COLUMN_LAB_PATIENT_CNP:  &item.PatientCNP,
+		utils.COLUMN_LAB_PATIENT_NAME: &item.PatientName,
+		utils.COLUMN_LAB_RESULT:       &item.Result,
+		utils.COLUMN_LAB_MESSAGE:      &item.Message,
+	}
+}
+
+// rotateLabReconciliationKeys wraps the values of the queued lab results left under a retired key with the active key.
+// An item reviewed during the sweep is skipped and picked up by the next one.
+func (db *MongoDB) rotateLabReconciliationKeys(ctx context.Context, report *models.KeyRotationReport) error {
+	collection := db.db.Collection(utils.LAB_RECONCILIATION_TABLE)
+	projection := bson.M{}
+	for _, field := range utils.LAB_ENCRYPTED_COLUMNS {
+		projection[field] = 1
+	}
+
+	cursor, err := collection.Find(ctx, bson.M{}, options.Find().SetProjection(projection))
+	if err != nil {
+		log.Printf("[CONSULTATION] Error fetching lab reconciliation items to rotate: %v", err)
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		id, ok := cursor.Current.Lookup(utils.COLUMN_LAB_ID).ObjectIDOK()
+		if !ok {
+			continue
+		}
+		report.Scanned++
+
+		filter := bson.M{utils.COLUMN_LAB_ID: id}
+		set := bson.M{}
+		for _, field := range utils.LAB_ENCRYPTED_COLUMNS {
+			value, _ := cursor.Current.Lookup(field).StringValueOK()
+			if value == "" {
+				continue
+			}
+			if encryption.KeyID(value) == report.ActiveKeyID {
+				report.KeysInUse[report.ActiveKeyID]++
+				continue
+			}
+
+			rewrapped, err := db.cipher.Rewrap(ctx, field, value)
+			if err != nil {
+				log.Printf("[CONSULTATION] Error rotating the keys of lab reconciliation item %s: %v", id.Hex(), err)
+				return err
+			}
+			filter[field] = value
+			set[field] = rewrapped
+		}
+		if len(set) == 0 {
+			continue
+		}
+
+		result, err := collection.UpdateOne(ctx, filter, bson.M{"$set": set})
+		if err != nil {
+			log.Printf("[CONSULTATION] Error saving the rotated keys of lab reconciliation item %s: %v", id.Hex(), err)
+			return err
+		}
+		if result.MatchedCount == 0 {
+			report.Skipped++
+			continue
+		}
+		report.Rewrapped++
+		report.KeysInUse[report.ActiveKeyID] += len(set)
+	}
+	if err := cursor.Err(); err != nil {
+		log.Printf("[CONSULTATION] Error iterating over lab reconciliation items to rotate: %v", err)
+		return err
+	}
+	return nil
+}
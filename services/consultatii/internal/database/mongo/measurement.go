@@ -0,0 +1,197 @@
+package mongo
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/mihnea1711/POS_Project/services/consultatii/internal/models"
+	"github.com/mihnea1711/POS_Project/services/consultatii/pkg/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ensureMeasurementIndexes serves the measurements of a patient by type over time, which every listing and trend reads,
+// and the measurements taken at a consultation
+func ensureMeasurementIndexes(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection(utils.MEASUREMENT_TABLE).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: utils.COLUMN_MEASUREMENT_ID_PATIENT, Value: 1}, {Key: utils.COLUMN_MEASUREMENT_TYPE, Value: 1}, {Key: utils.COLUMN_MEASUREMENT_MEASURED_AT, Value: -1}}},
+		{Keys: bson.D{{Key: utils.COLUMN_MEASUREMENT_CONSULTATION, Value: 1}}, Options: options.Index().SetSparse(true)},
+	})
+	return err
+}
+
+// SaveMeasurements stores the measurements taken together. The values are kept in clear, like the prescriptions, so the
+// trends can be computed by the database.
+func (db *MongoDB) SaveMeasurements(ctx context.Context, measurements []models.Measurement) error {
+	documents := make([]interface{}, 0, len(measurements))
+	for i := range measurements {
+		if measurements[i].IDMeasurement.IsZero() {
+			measurements[i].IDMeasurement = primitive.NewObjectID()
+		}
+		documents = append(documents, measurements[i])
+	}
+
+	if _, err := db.db.Collection(utils.MEASUREMENT_TABLE).InsertMany(ctx, documents); err != nil {
+		log.Printf("[CONSULTATION] Error saving %d measurements: %v", len(measurements), err)
+		return err
+	}
+
+	log.Printf("[CONSULTATION] %d measurements saved for patient %d", len(measurements), measurements[0].IDPatient)
+	return nil
+}
+
+// FetchMeasurements lists the measurements matching the filter, the latest taken first
+func (db *MongoDB) FetchMeasurements(ctx context.Context, filter bson.M, page int, limit int) ([]models.Measurement, error) {
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: utils.COLUMN_MEASUREMENT_MEASURED_AT, Value: -1}, {Key: utils.COLUMN_MEASUREMENT_ID, Value: -1}}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+
+	cursor, err := db.db.Collection(utils.MEASUREMENT_TABLE).Find(ctx, filter, findOptions)
+	if err != nil {
+		log.Printf("[CONSULTATION] Failed to find measurements with filter: %v", filter)
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	measurements := []models.Measurement{}
+	if err := cursor.All(ctx, &measurements); err != nil {
+		log.Printf("[CONSULTATION] Failed to decode measurements: %v", err)
+		return nil, err
+	}
+
+	log.Printf("[CONSULTATION] Fetched %d measurements", len(measurements))
+	return measurements, nil
+}
+
+// FetchMeasurementByID returns a measurement, mongo.ErrNoDocuments when there is none with the ID
+func (db *MongoDB) FetchMeasurementByID(ctx context.Context, measurementID primitive.ObjectID) (*models.Measurement, error) {
+	var measurement models.Measurement
+	err := db.db.Collection(utils.MEASUREMENT_TABLE).FindOne(ctx, bson.M{utils.COLUMN_MEASUREMENT_ID: measurementID}).Decode(&measurement)
+	if err != nil {
+		log.Printf("[CONSULTATION] Error fetching measurement %s: %v", measurementID.Hex(), err)
+		return nil, err
+	}
+	return &measurement, nil
+}
+
+// RetractMeasurement marks a measurement as entered in error, with the time, the user and the reason, provided it is
+// still recorded. It returns the number of measurements retracted, 0 when the measurement is gone or already retracted.
+func (db *MongoDB) RetractMeasurement(ctx context.Context, measurementID primitive.ObjectID, reason string, author *int, at time.Time) (int, error) {
+	filter := bson.M{
+		utils.COLUMN_MEASUREMENT_ID:     measurementID,
+		utils.COLUMN_MEASUREMENT_STATUS: utils.MEASUREMENT_STATUS_RECORDED,
+	}
+	set := bson.M{
+		utils.COLUMN_MEASUREMENT_STATUS:         utils.MEASUREMENT_STATUS_ENTERED_IN_ERROR,
+		utils.COLUMN_MEASUREMENT_RETRACTED_AT:   at,
+		utils.COLUMN_MEASUREMENT_RETRACT_REASON: reason,
+	}
+	if author != nil {
+		set[utils.COLUMN_MEASUREMENT_RETRACTED_BY] = *author
+	}
+
+	result, err := db.db.Collection(utils.MEASUREMENT_TABLE).UpdateOne(ctx, filter, bson.M{"$set": set})
+	if err != nil {
+		log.Printf("[CONSULTATION] Error retracting measurement %s: %v", measurementID.Hex(), err)
+		return 0, err
+	}
+
+	log.Printf("[CONSULTATION] Measurement %s retracted: %d matched", measurementID.Hex(), result.MatchedCount)
+	return int(result.MatchedCount), nil
+}
+
+// FetchMeasurementBuckets sums up the values of the measurements matching the filter by component and by day, week or
+// month in the time zone, with the first and the latest value of each bucket. The buckets are sorted by component and
+// start. A week starts on Monday.
+func (db *MongoDB) FetchMeasurementBuckets(ctx context.Context, filter bson.M, interval string, timeZone string) ([]models.MeasurementBucket, error) {
+	value := "$" + utils.COLUMN_MEASUREMENT_VALUES + "." + utils.COLUMN_MEASUREMENT_VALUE
+	point := bson.M{
+		"value":       value,
+		"flag":        "$" + utils.COLUMN_MEASUREMENT_VALUES + "." + utils.COLUMN_MEASUREMENT_FLAG,
+		"measured_at": "$" + utils.COLUMN_MEASUREMENT_MEASURED_AT,
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$sort", Value: bson.D{{Key: utils.COLUMN_MEASUREMENT_MEASURED_AT, Value: 1}}}},
+		{{Key: "$unwind", Value: "$" + utils.COLUMN_MEASUREMENT_VALUES}},
+		{{Key: "$group", Value: bson.M{
+			"_id": bson.M{
+				"component": "$" + utils.COLUMN_MEASUREMENT_VALUES + "." + utils.COLUMN_MEASUREMENT_COMPONENT,
+				"start": bson.M{"$dateTrunc": bson.M{
+					"date":        "$" + utils.COLUMN_MEASUREMENT_MEASURED_AT,
+					"unit":        interval,
+					"timezone":    timeZone,
+					"startOfWeek": "monday",
+				}},
+			},
+			"count": bson.M{"$sum": 1},
+			"out_of_range": bson.M{"$sum": bson.M{"$cond": bson.A{
+				bson.M{"$eq": bson.A{"$" + utils.COLUMN_MEASUREMENT_VALUES + "." + utils.COLUMN_MEASUREMENT_FLAG, utils.MEASUREMENT_FLAG_NORMAL}}, 0, 1,
+			}}},
+			"minimum": bson.M{"$min": value},
+			"maximum": bson.M{"$max": value},
+			"average": bson.M{"$avg": value},
+			"first":   bson.M{"$first": point},
+			"latest":  bson.M{"$last": point},
+		}}},
+		{{Key: "$project", Value: bson.M{
+			"_id":          0,
+			"component":    "$_id.component",
+			"start":        "$_id.start",
+			"count":        1,
+			"out_of_range": 1,
+			"minimum":      1,
+			"maximum":      1,
+			"average":      1,
+			"first":        1,
+			"latest":       1,
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "component", Value: 1}, {Key: "start", Value: 1}}}},
+	}
+
+	cursor, err := db.db.Collection(utils.MEASUREMENT_TABLE).Aggregate(ctx, pipeline)
+	if err != nil {
+		log.Printf("[CONSULTATION] Error aggregating measurements: %v", err)
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	buckets := []models.MeasurementBucket{}
+	if err := cursor.All(ctx, &buckets); err != nil {
+		log.Printf("[CONSULTATION] Error decoding measurement buckets: %v", err)
+		return nil, err
+	}
+
+	log.Printf("[CONSULTATION] Fetched %d measurement buckets by %s", len(buckets), interval)
+	return buckets, nil
+}
+
+// CountPatientMeasurements returns the number of measurements taken of a patient
+func (db *MongoDB) CountPatientMeasurements(ctx context.Context, patientID int) (int, error) {
+	count, err := db.db.Collection(utils.MEASUREMENT_TABLE).CountDocuments(ctx, bson.M{utils.COLUMN_MEASUREMENT_ID_PATIENT: patientID})
+	if err != nil {
+		log.Printf("[CONSULTATION] Error counting the measurements of patient %d: %v", patientID, err)
+		return 0, err
+	}
+	return int(count), nil
+}
+
+// ReassignPatientMeasurements moves the measurements of a duplicate patient record to the surviving one
+func (db *MongoDB) ReassignPatientMeasurements(ctx context.Context, fromPatientID, toPatientID int) (int, error) {
+	result, err := db.db.Collection(utils.MEASUREMENT_TABLE).UpdateMany(ctx,
+		bson.M{utils.COLUMN_MEASUREMENT_ID_PATIENT: fromPatientID},
+		bson.M{"$set": bson.M{utils.COLUMN_MEASUREMENT_ID_PATIENT: toPatientID}},
+	)
+	if err != nil {
+		log.Printf("[CONSULTATION] Error moving the measurements of patient %d: %v", fromPatientID, err)
+		return 0, err
+	}
+
+	log.Printf("[CONSULTATION] Moved %d measurements of patient %d to patient %d", result.ModifiedCount, fromPatientID, toPatientID)
+	return int(result.ModifiedCount), nil
+}
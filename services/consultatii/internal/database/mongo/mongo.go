@@ -10,15 +10,17 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/readpref"
 
 	"github.com/mihnea1711/POS_Project/services/consultatii/internal/database"
+	"github.com/mihnea1711/POS_Project/services/consultatii/internal/encryption"
 	"github.com/mihnea1711/POS_Project/services/consultatii/pkg/config"
 )
 
 type MongoDB struct {
 	client *mongo.Client
 	db     *mongo.Database
+	cipher *encryption.FieldCipher
 }
 
-func NewMongoDB(ctx context.Context, cfg *config.MongoDBConfig) (database.Database, error) {
+func NewMongoDB(ctx context.Context, cfg *config.MongoDBConfig, cipher *encryption.FieldCipher) (database.Database, error) {
 	// auth := options.Client().SetAuth()
 	clientOptions := options.Client().
 		ApplyURI(fmt.Sprintf("mongodb://%s:%s@%s:%d/?authSource=admin", cfg.User, cfg.Password, cfg.Host, cfg.Port))
@@ -39,8 +41,29 @@ func NewMongoDB(ctx context.Context, cfg *config.MongoDBConfig) (database.Databa
 
 	db := client.Database(cfg.Database)
 
+	if err := ensureConsultationIndexes(ctx, db); err != nil {
+		log.Printf("[CONSULTATION] Error creating the consultation indexes: %v", err)
+		return nil, err
+	}
+	if err := ensureRevisionIndexes(ctx, db); err != nil {
+		log.Printf("[CONSULTATION] Error creating the consultation revision indexes: %v", err)
+		return nil, err
+	}
+	if err := ensurePrescriptionIndexes(ctx, db); err != nil {
+		log.Printf("[CONSULTATION] Error creating the prescription indexes: %v", err)
+		return nil, err
+	}
+	if err := ensureLabReconciliationIndexes(ctx, db); err != nil {
+		log.Printf("[CONSULTATION] Error creating the lab reconciliation indexes: %v", err)
+		return nil, err
+	}
+	if err := ensureMeasurementIndexes(ctx, db); err != nil {
+		log.Printf("[CONSULTATION] Error creating the measurement indexes: %v", err)
+		return nil, err
+	}
+
 	log.Printf("[CONSULTATION] Connected to MongoDB: %s", cfg.Database)
-	return &MongoDB{client: client, db: db}, nil
+	return &MongoDB{client: client, db: db, cipher: cipher}, nil
 }
 
 func (db *MongoDB) Close(ctx context.Context) error {
@@ -0,0 +1,152 @@
+package mongo
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/mihnea1711/POS_Project/services/consultatii/internal/models"
+	"github.com/mihnea1711/POS_Project/services/consultatii/pkg/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ensurePrescriptionIndexes serves the prescriptions of a consultation and the active prescriptions of a patient,
+// which every new prescription is checked against
+func ensurePrescriptionIndexes(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection(utils.PRESCRIPTION_TABLE).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: utils.COLUMN_PRESCRIPTION_CONSULTATION, Value: 1}, {Key: utils.COLUMN_PRESCRIPTION_ISSUED_AT, Value: -1}}},
+		{Keys: bson.D{{Key: utils.COLUMN_PRESCRIPTION_ID_PATIENT, Value: 1}, {Key: utils.COLUMN_PRESCRIPTION_STATUS, Value: 1}, {Key: utils.COLUMN_PRESCRIPTION_ISSUED_AT, Value: -1}}},
+	})
+	return err
+}
+
+// SavePrescription stores a new prescription. The drug codes and doses are kept in clear, like the diagnosis codes, so
+// pharmacies and reports can read them.
+func (db *MongoDB) SavePrescription(ctx context.Context, prescription *models.Prescription) error {
+	if prescription.IDPrescription.IsZero() {
+		prescription.IDPrescription = primitive.NewObjectID()
+	}
+
+	if _, err := db.db.Collection(utils.PRESCRIPTION_TABLE).InsertOne(ctx, prescription); err != nil {
+		log.Printf("[CONSULTATION] Error saving prescription for consultation %s: %v", prescription.IDConsultation.Hex(), err)
+		return err
+	}
+
+	log.Printf("[CONSULTATION] Prescription %s saved for consultation %s", prescription.IDPrescription.Hex(), prescription.IDConsultation.Hex())
+	return nil
+}
+
+// FetchPrescriptions lists the prescriptions of a consultation, the latest first
+func (db *MongoDB) FetchPrescriptions(ctx context.Context, consultationID primitive.ObjectID) ([]models.Prescription, error) {
+	findOptions := options.Find().SetSort(bson.D{{Key: utils.COLUMN_PRESCRIPTION_ISSUED_AT, Value: -1}})
+
+	return db.findPrescriptions(ctx, bson.M{utils.COLUMN_PRESCRIPTION_CONSULTATION: consultationID}, findOptions)
+}
+
+// FetchPrescriptionByID returns a prescription of a consultation, mongo.ErrNoDocuments when the consultation has none
+// with the ID
+func (db *MongoDB) FetchPrescriptionByID(ctx context.Context, consultationID, prescriptionID primitive.ObjectID) (*models.Prescription, error) {
+	filter := bson.M{utils.COLUMN_PRESCRIPTION_ID: prescriptionID, utils.COLUMN_PRESCRIPTION_CONSULTATION: consultationID}
+
+	var prescription models.Prescription
+	if err := db.db.Collection(utils.PRESCRIPTION_TABLE).FindOne(ctx, filter).Decode(&prescription); err != nil {
+		log.Printf("[CONSULTATION] Error fetching prescription %s: %v", prescriptionID.Hex(), err)
+		return nil, err
+	}
+	return &prescription, nil
+}
+
+// FetchPatientPrescriptions lists the prescriptions of a patient across all their consultations, the latest first
+func (db *MongoDB) FetchPatientPrescriptions(ctx context.Context, patientID int, page int, limit int) ([]models.Prescription, error) {
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: utils.COLUMN_PRESCRIPTION_ISSUED_AT, Value: -1}}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+
+	log.Printf("[CONSULTATION] Fetching the prescriptions of patient %d, Limit of %d, on Page %d", patientID, limit, page)
+	return db.findPrescriptions(ctx, bson.M{utils.COLUMN_PRESCRIPTION_ID_PATIENT: patientID}, findOptions)
+}
+
+// FetchActivePrescriptions lists the prescriptions of a patient issued since a time that were not canceled
+func (db *MongoDB) FetchActivePrescriptions(ctx context.Context, patientID int, since time.Time) ([]models.Prescription, error) {
+	filter := bson.M{
+		utils.COLUMN_PRESCRIPTION_ID_PATIENT: patientID,
+		utils.COLUMN_PRESCRIPTION_STATUS:     bson.M{"$in": utils.ACTIVE_PRESCRIPTION_STATUSES},
+		utils.COLUMN_PRESCRIPTION_ISSUED_AT:  bson.M{"$gte": since},
+	}
+
+	return db.findPrescriptions(ctx, filter, options.Find())
+}
+
+func (db *MongoDB) findPrescriptions(ctx context.Context, filter bson.M, findOptions *options.FindOptions) ([]models.Prescription, error) {
+	cursor, err := db.db.Collection(utils.PRESCRIPTION_TABLE).Find(ctx, filter, findOptions)
+	if err != nil {
+		log.Printf("[CONSULTATION] Failed to find prescriptions with filter: %v", filter)
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	prescriptions := []models.Prescription{}
+	if err := cursor.All(ctx, &prescriptions); err != nil {
+		log.Printf("[CONSULTATION] Failed to decode prescriptions: %v", err)
+		return nil, err
+	}
+
+	log.Printf("[CONSULTATION] Fetched %d prescriptions", len(prescriptions))
+	return prescriptions, nil
+}
+
+// UpdatePrescriptionStatus saves the status of a prescription, with the time and the reason of the change, provided
+// it still has the status it was read with. It returns the number of prescriptions changed, 0 when the prescription
+// is gone or another change came first.
+func (db *MongoDB) UpdatePrescriptionStatus(ctx context.Context, prescription *models.Prescription, from models.PrescriptionStatus) (int, error) {
+	filter := bson.M{
+		utils.COLUMN_PRESCRIPTION_ID:     prescription.IDPrescription,
+		utils.COLUMN_PRESCRIPTION_STATUS: from,
+	}
+	set := bson.M{utils.COLUMN_PRESCRIPTION_STATUS: prescription.Status}
+	if prescription.DispensedAt != nil {
+		set[utils.COLUMN_PRESCRIPTION_DISPENSED_AT] = prescription.DispensedAt
+	}
+	if prescription.CanceledAt != nil {
+		set[utils.COLUMN_PRESCRIPTION_CANCELED_AT] = prescription.CanceledAt
+		set[utils.COLUMN_PRESCRIPTION_CANCEL_REASON] = prescription.CancelReason
+	}
+
+	result, err := db.db.Collection(utils.PRESCRIPTION_TABLE).UpdateOne(ctx, filter, bson.M{"$set": set})
+	if err != nil {
+		log.Printf("[CONSULTATION] Error updating the status of prescription %s: %v", prescription.IDPrescription.Hex(), err)
+		return 0, err
+	}
+
+	log.Printf("[CONSULTATION] Prescription %s moved from %s to %s: %d matched", prescription.IDPrescription.Hex(), from, prescription.Status, result.MatchedCount)
+	return int(result.MatchedCount), nil
+}
+
+// CountPatientPrescriptions returns the number of prescriptions issued to a patient
+func (db *MongoDB) CountPatientPrescriptions(ctx context.Context, patientID int) (int, error) {
+	count, err := db.db.Collection(utils.PRESCRIPTION_TABLE).CountDocuments(ctx, bson.M{utils.COLUMN_PRESCRIPTION_ID_PATIENT: patientID})
+	if err != nil {
+		log.Printf("[CONSULTATION] Error counting the prescriptions of patient %d: %v", patientID, err)
+		return 0, err
+	}
+	return int(count), nil
+}
+
+// ReassignPatientPrescriptions moves the prescriptions of a duplicate patient record to the surviving one
+func (db *MongoDB) ReassignPatientPrescriptions(ctx context.Context, fromPatientID, toPatientID int) (int, error) {
+	result, err := db.db.Collection(utils.PRESCRIPTION_TABLE).UpdateMany(ctx,
+		bson.M{utils.COLUMN_PRESCRIPTION_ID_PATIENT: fromPatientID},
+		bson.M{"$set": bson.M{utils.COLUMN_PRESCRIPTION_ID_PATIENT: toPatientID}},
+	)
+	if err != nil {
+		log.Printf("[CONSULTATION] Error moving the prescriptions of patient %d: %v", fromPatientID, err)
+		return 0, err
+	}
+
+	log.Printf("[CONSULTATION] Moved %d prescriptions of patient %d to patient %d", result.ModifiedCount, fromPatientID, toPatientID)
+	return int(result.ModifiedCount), nil
+}
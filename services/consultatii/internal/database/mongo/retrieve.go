@@ -11,8 +11,9 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-// FetchConsultationsByFilter retrieves consultations based on the provconsultationIDed filter criteria.
-func (db *MongoDB) FetchConsultations(ctx context.Context, filter bson.M, page int, limit int) ([]models.Consultation, error) {
+// FetchConsultationsByFilter retrieves consultations based on the provconsultationIDed filter criteria, in the given order.
+// A text search also projects its relevance, so the consultations can be ordered by it.
+func (db *MongoDB) FetchConsultations(ctx context.Context, filter bson.M, sort bson.D, page int, limit int) ([]models.Consultation, error) {
 	// Create a MongoDB cursor for querying the collection
 	collection := db.client.Database(utils.DATABASE_NAME).Collection(utils.CONSULTATIE_TABLE)
 
@@ -20,6 +21,10 @@ func (db *MongoDB) FetchConsultations(ctx context.Context, filter bson.M, page i
 	options := options.Find()
 	options.SetSkip(int64((page - 1) * limit))
 	options.SetLimit(int64(limit))
+	options.SetSort(sort)
+	if _, ok := filter["$text"]; ok {
+		options.SetProjection(bson.M{utils.COLUMN_TEXT_SCORE: bson.M{"$meta": "textScore"}})
+	}
 
 	// Log the filter parameters for debugging
 	log.Printf("[CONSULTATION] Fetching consultations with filter: %v, Limit of %d, on Page %d", filter, limit, page)
@@ -37,6 +42,12 @@ func (db *MongoDB) FetchConsultations(ctx context.Context, filter bson.M, page i
 		log.Printf("[CONSULTATION] Failed to decode consultations: %v", err)
 		return nil, err
 	}
+	for i := range consultations {
+		if err := db.openConsultation(ctx, &consultations[i]); err != nil {
+			log.Printf("[CONSULTATION] Failed to decrypt consultation %s: %v", consultations[i].IDConsultation.Hex(), err)
+			return nil, err
+		}
+	}
 
 	// Log results
 	if len(consultations) == 0 {
@@ -66,8 +77,48 @@ func (db *MongoDB) FetchConsultationByID(ctx context.Context, consultationID pri
 		log.Printf("[CONSULTATION] Error fetching consultation: %v", err)
 		return nil, err
 	}
+	if err := db.openConsultation(ctx, &consultation); err != nil {
+		log.Printf("[CONSULTATION] Error decrypting consultation: %v", err)
+		return nil, err
+	}
 
 	// Log the successful retrieval of the consultation
 	log.Printf("[CONSULTATION] Consultation retrieved successfully: %v", consultationID)
 	return &consultation, nil
 }
+
+// CountPatientConsultations returns the number of consultations of a patient
+func (db *MongoDB) CountPatientConsultations(ctx context.Context, patientID int) (int, error) {
+	collection := db.db.Collection(utils.CONSULTATIE_TABLE)
+
+	count, err := collection.CountDocuments(ctx, bson.M{utils.COLUMN_ID_PATIENT: patientID})
+	if err != nil {
+		log.Printf("[CONSULTATION] Error counting the consultations of patient %d: %v", patientID, err)
+		return 0, err
+	}
+
+	return int(count), nil
+}
+
+// FetchAppointmentLinks returns the consultations opened for the given appointments, the appointments without a
+// consultation are left out
+func (db *MongoDB) FetchAppointmentLinks(ctx context.Context, appointmentIDs []int) ([]models.AppointmentLink, error) {
+	filter := bson.M{utils.COLUMN_ID_APPOINTMENT: bson.M{"$in": appointmentIDs}}
+	findOptions := options.Find().SetProjection(bson.M{utils.COLUMN_ID_CONSULTATIE: 1, utils.COLUMN_ID_APPOINTMENT: 1})
+
+	cursor, err := db.db.Collection(utils.CONSULTATIE_TABLE).Find(ctx, filter, findOptions)
+	if err != nil {
+		log.Printf("[CONSULTATION] Failed to find the consultations of appointments %v: %v", appointmentIDs, err)
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	links := []models.AppointmentLink{}
+	if err := cursor.All(ctx, &links); err != nil {
+		log.Printf("[CONSULTATION] Failed to decode appointment links: %v", err)
+		return nil, err
+	}
+
+	log.Printf("[CONSULTATION] Found consultations for %d of %d appointments", len(links), len(appointmentIDs))
+	return links, nil
+}
@@ -0,0 +1,154 @@
+package mongo
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/mihnea1711/POS_Project/services/consultatii/internal/models"
+	"github.com/mihnea1711/POS_Project/services/consultatii/pkg/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ensureRevisionIndexes makes the version unique per consultation, so two updates based on the same version cannot
+// both record a revision
+func ensureRevisionIndexes(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection(utils.CONSULTATION_REVISION_TABLE).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: utils.COLUMN_REVISION_ID_CONSULTATION, Value: 1}, {Key: utils.COLUMN_REVISION_VERSION, Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
+
+// saveRevision records a sealed consultation as the revision of its version
+func (db *MongoDB) saveRevision(ctx context.Context, sealed *models.Consultation, author *int, reason string) (primitive.ObjectID, error) {
+	revision := models.ConsultationRevision{
+		IDRevision:     primitive.NewObjectID(),
+		IDConsultation: sealed.IDConsultation,
+		Version:        sealed.Version,
+		Author:         author,
+		Reason:         reason,
+		CreatedAt:      time.Now().UTC(),
+		Consultation:   sealed,
+	}
+
+	if _, err := db.db.Collection(utils.CONSULTATION_REVISION_TABLE).InsertOne(ctx, revision); err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	log.Printf("[CONSULTATION] Revision %d of consultation %s recorded", revision.Version, revision.IDConsultation.Hex())
+	return revision.IDRevision, nil
+}
+
+// discardRevision removes a revision recorded for a save that did not go through. It never became the consultation,
+// so no history is lost.
+func (db *MongoDB) discardRevision(ctx context.Context, revisionID primitive.ObjectID) {
+	if _, err := db.db.Collection(utils.CONSULTATION_REVISION_TABLE).DeleteOne(ctx, bson.M{"_id": revisionID}); err != nil {
+		log.Printf("[CONSULTATION] Error discarding revision %s: %v", revisionID.Hex(), err)
+	}
+}
+
+// saveBaselineRevision records a consultation saved before versioning as its first revision, the first time it is
+// updated. It returns mongo.ErrNoDocuments when there is no consultation with the ID.
+func (db *MongoDB) saveBaselineRevision(ctx context.Context, consultationID primitive.ObjectID) error {
+	revisions := db.db.Collection(utils.CONSULTATION_REVISION_TABLE)
+	filter := bson.M{utils.COLUMN_REVISION_ID_CONSULTATION: consultationID, utils.COLUMN_REVISION_VERSION: utils.FIRST_CONSULTATION_VERSION}
+
+	count, err := revisions.CountDocuments(ctx, filter)
+	if err != nil || count > 0 {
+		return err
+	}
+
+	// The stored document is kept as it is, its values are sealed already
+	var stored bson.M
+	if err := db.db.Collection(utils.CONSULTATIE_TABLE).FindOne(ctx, bson.M{utils.COLUMN_ID_CONSULTATIE: consultationID}).Decode(&stored); err != nil {
+		return err
+	}
+
+	update := bson.M{"$setOnInsert": bson.M{
+		utils.COLUMN_REVISION_REASON:       utils.REVISION_REASON_BEFORE_VERSIONING,
+		utils.COLUMN_REVISION_CREATED_AT:   time.Now().UTC(),
+		utils.COLUMN_REVISION_CONSULTATION: stored,
+	}}
+	_, err = revisions.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		return nil
+	}
+	if err == nil {
+		log.Printf("[CONSULTATION] Consultation %s saved before versioning recorded as its first revision", consultationID.Hex())
+	}
+	return err
+}
+
+// FetchConsultationRevisions lists the revisions of a consultation without their content, the latest first
+func (db *MongoDB) FetchConsultationRevisions(ctx context.Context, consultationID primitive.ObjectID, page int, limit int) ([]models.ConsultationRevision, error) {
+	findOptions := options.Find().
+		SetProjection(bson.M{utils.COLUMN_REVISION_CONSULTATION: 0}).
+		SetSort(bson.D{{Key: utils.COLUMN_REVISION_VERSION, Value: -1}}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+
+	cursor, err := db.db.Collection(utils.CONSULTATION_REVISION_TABLE).Find(ctx, bson.M{utils.COLUMN_REVISION_ID_CONSULTATION: consultationID}, findOptions)
+	if err != nil {
+		log.Printf("[CONSULTATION] Failed to find the revisions of consultation %s: %v", consultationID.Hex(), err)
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	revisions := []models.ConsultationRevision{}
+	if err := cursor.All(ctx, &revisions); err != nil {
+		log.Printf("[CONSULTATION] Failed to decode the revisions of consultation %s: %v", consultationID.Hex(), err)
+		return nil, err
+	}
+
+	log.Printf("[CONSULTATION] Fetched %d revisions of consultation %s", len(revisions), consultationID.Hex())
+	return revisions, nil
+}
+
+// FetchConsultationRevision returns the revision of a consultation at a version, mongo.ErrNoDocuments when there is none
+func (db *MongoDB) FetchConsultationRevision(ctx context.Context, consultationID primitive.ObjectID, version int) (*models.ConsultationRevision, error) {
+	filter := bson.M{utils.COLUMN_REVISION_ID_CONSULTATION: consultationID, utils.COLUMN_REVISION_VERSION: version}
+
+	var revision models.ConsultationRevision
+	if err := db.db.Collection(utils.CONSULTATION_REVISION_TABLE).FindOne(ctx, filter).Decode(&revision); err != nil {
+		log.Printf("[CONSULTATION] Error fetching revision %d of consultation %s: %v", version, consultationID.Hex(), err)
+		return nil, err
+	}
+	if revision.Consultation != nil {
+		if err := db.openConsultation(ctx, revision.Consultation); err != nil {
+			log.Printf("[CONSULTATION] Error decrypting revision %d of consultation %s: %v", version, consultationID.Hex(), err)
+			return nil, err
+		}
+	}
+	return &revision, nil
+}
+
+// CountPatientRevisions returns the number of revisions recorded for the consultations of a patient
+func (db *MongoDB) CountPatientRevisions(ctx context.Context, patientID int) (int, error) {
+	count, err := db.db.Collection(utils.CONSULTATION_REVISION_TABLE).CountDocuments(ctx, bson.M{utils.COLUMN_REVISION_CONSULTATION + "." + utils.COLUMN_ID_PATIENT: patientID})
+	if err != nil {
+		log.Printf("[CONSULTATION] Error counting the consultation revisions of patient %d: %v", patientID, err)
+		return 0, err
+	}
+	return int(count), nil
+}
+
+// ReassignPatientRevisions moves the revisions of the consultations of a duplicate patient record to the surviving one.
+// Only the patient of the snapshot changes, the revisions keep the content they were recorded with.
+func (db *MongoDB) ReassignPatientRevisions(ctx context.Context, fromPatientID, toPatientID int) (int, error) {
+	column := utils.COLUMN_REVISION_CONSULTATION + "." + utils.COLUMN_ID_PATIENT
+	result, err := db.db.Collection(utils.CONSULTATION_REVISION_TABLE).UpdateMany(ctx,
+		bson.M{column: fromPatientID},
+		bson.M{"$set": bson.M{column: toPatientID}},
+	)
+	if err != nil {
+		log.Printf("[CONSULTATION] Error moving the consultation revisions of patient %d: %v", fromPatientID, err)
+		return 0, err
+	}
+
+	log.Printf("[CONSULTATION] Moved %d consultation revisions of patient %d to patient %d", result.ModifiedCount, fromPatientID, toPatientID)
+	return int(result.ModifiedCount), nil
+}
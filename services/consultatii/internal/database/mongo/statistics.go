@@ -0,0 +1,151 @@
+package mongo
+
+import (
+	"context"
+	"log"
+
+	"github.com/mihnea1711/POS_Project/services/consultatii/internal/models"
+	"github.com/mihnea1711/POS_Project/services/consultatii/pkg/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ensureConsultationIndexes serves the searches of the consultations: by patient or doctor over a date range, and the
+// text search. The text index has no language, so the Romanian and the Latin medical terms are not stemmed as English.
+// An appointment is fulfilled by a single consultation. Lab results are matched to their investigation by its ID.
+func ensureConsultationIndexes(ctx context.Context, db *mongo.Database) error {
+	diagnosisCode := utils.COLUMN_DIAGNOSES + "." + utils.COLUMN_DIAGNOSIS_CODE
+	diagnosisDescription := utils.COLUMN_DIAGNOSES + "." + utils.COLUMN_DIAGNOSIS_DESCRIPTION
+	investigationName := utils.COLUMN_INVESTIGATII + "." + utils.NAME
+
+	_, err := db.Collection(utils.CONSULTATIE_TABLE).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: utils.COLUMN_DATE, Value: -1}}},
+		{Keys: bson.D{{Key: utils.COLUMN_ID_PATIENT, Value: 1}, {Key: utils.COLUMN_DATE, Value: -1}}},
+		{Keys: bson.D{{Key: utils.COLUMN_ID_DOCTOR, Value: 1}, {Key: utils.COLUMN_DATE, Value: -1}}},
+		{Keys: bson.D{{Key: utils.COLUMN_INVESTIGATII + "." + utils.ID_INVESTIGATION, Value: 1}}},
+		{
+			Keys: bson.D{{Key: utils.COLUMN_ID_APPOINTMENT, Value: 1}},
+			Options: options.Index().
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{utils.COLUMN_ID_APPOINTMENT: bson.M{"$exists": true}}),
+		},
+		{
+			Keys: bson.D{{Key: diagnosisCode, Value: "text"}, {Key: diagnosisDescription, Value: "text"}, {Key: investigationName, Value: "text"}},
+			Options: options.Index().
+				SetName(utils.CONSULTATION_TEXT_INDEX).
+				SetDefaultLanguage("none").
+				SetWeights(bson.D{{Key: diagnosisCode, Value: 5}, {Key: diagnosisDescription, Value: 3}, {Key: investigationName, Value: 1}}),
+		},
+	})
+	return err
+}
+
+// FetchDiagnosisStatistics counts the coded diagnoses of the consultations matching the filter, per doctor and month,
+// and keeps the top most common of each month. The latest months come first.
+func (db *MongoDB) FetchDiagnosisStatistics(ctx context.Context, filter bson.M, top int) ([]models.DiagnosisStatistics, error) {
+	codePath := utils.COLUMN_DIAGNOSES + "." + utils.COLUMN_DIAGNOSIS_CODE
+
+	diagnosisMatch := bson.M{}
+	if condition, ok := filter[codePath]; ok {
+		diagnosisMatch[codePath] = condition
+	}
+
+	pipeline := []bson.M{
+		{"$match": filter},
+		{"$unwind": "$" + utils.COLUMN_DIAGNOSES},
+		{"$match": diagnosisMatch},
+		{"$group": bson.M{
+			"_id": bson.M{
+				"doctor": "$" + utils.COLUMN_ID_DOCTOR,
+				"month":  bson.M{"$dateToString": bson.M{"format": "%Y-%m", "date": "$" + utils.COLUMN_DATE}},
+				"code":   "$" + codePath,
+			},
+			"description": bson.M{"$first": "$" + utils.COLUMN_DIAGNOSES + "." + utils.COLUMN_DIAGNOSIS_DESCRIPTION},
+			"count":       bson.M{"$sum": 1},
+		}},
+		// $push keeps the order the diagnoses come in, the most common first
+		{"$sort": bson.D{{Key: "count", Value: -1}, {Key: "_id.code", Value: 1}}},
+		{"$group": bson.M{
+			"_id":       bson.M{"doctor": "$_id.doctor", "month": "$_id.month"},
+			"total":     bson.M{"$sum": "$count"},
+			"diagnoses": bson.M{"$push": bson.M{"code": "$_id.code", "description": "$description", "count": "$count"}},
+		}},
+		{"$sort": bson.D{{Key: "_id.month", Value: -1}, {Key: "_id.doctor", Value: 1}}},
+		{"$project": bson.M{
+			"_id":                  0,
+			utils.COLUMN_ID_DOCTOR: "$_id.doctor",
+			"month":                "$_id.month",
+			"total":                1,
+			"diagnoses":            bson.M{"$slice": bson.A{"$diagnoses", top}},
+		}},
+	}
+
+	log.Printf("[CONSULTATION] Counting the top %d diagnoses with filter: %v", top, filter)
+
+	statistics := []models.DiagnosisStatistics{}
+	if err := db.aggregateStatistics(ctx, pipeline, &statistics); err != nil {
+		log.Printf("[CONSULTATION] Failed to count the diagnoses: %v", err)
+		return nil, err
+	}
+
+	log.Printf("[CONSULTATION] Counted the diagnoses of %d doctor months", len(statistics))
+	return statistics, nil
+}
+
+// FetchInvestigationStatistics sums up the investigations of the consultations matching the filter, per name, the
+// most ordered first. The names are compared in lower case, the canceled investigations are left out.
+func (db *MongoDB) FetchInvestigationStatistics(ctx context.Context, filter bson.M) ([]models.InvestigationStatistics, error) {
+	namePath := utils.COLUMN_INVESTIGATII + "." + utils.NAME
+	statusPath := "$" + utils.COLUMN_INVESTIGATII + "." + utils.STATUS
+
+	investigationMatch := bson.M{utils.COLUMN_INVESTIGATII + "." + utils.STATUS: bson.M{"$ne": utils.INVESTIGATION_STATUS_CANCELED}}
+	if condition, ok := filter[namePath]; ok {
+		investigationMatch[namePath] = condition
+	}
+
+	// Missing timestamps make the turnaround null, which $avg leaves out
+	turnaround := bson.M{"$divide": bson.A{
+		bson.M{"$subtract": bson.A{
+			"$" + utils.COLUMN_INVESTIGATII + "." + utils.RESULTED_AT,
+			"$" + utils.COLUMN_INVESTIGATII + "." + utils.ORDERED_AT,
+		}},
+		60 * 1000,
+	}}
+
+	pipeline := []bson.M{
+		{"$match": filter},
+		{"$unwind": "$" + utils.COLUMN_INVESTIGATII},
+		{"$match": investigationMatch},
+		{"$group": bson.M{
+			"_id":                     bson.M{"$toLower": bson.M{"$trim": bson.M{"input": "$" + namePath}}},
+			"name":                    bson.M{"$first": "$" + namePath},
+			"count":                   bson.M{"$sum": 1},
+			"resulted":                bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{statusPath, utils.INVESTIGATION_STATUS_RESULTED}}, 1, 0}}},
+			"average_processing_time": bson.M{"$avg": "$" + utils.COLUMN_INVESTIGATII + "." + utils.PROCESSING_TIME},
+			"average_turnaround":      bson.M{"$avg": bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{statusPath, utils.INVESTIGATION_STATUS_RESULTED}}, turnaround, nil}}},
+		}},
+		{"$sort": bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}},
+	}
+
+	log.Printf("[CONSULTATION] Summing up the investigations with filter: %v", filter)
+
+	statistics := []models.InvestigationStatistics{}
+	if err := db.aggregateStatistics(ctx, pipeline, &statistics); err != nil {
+		log.Printf("[CONSULTATION] Failed to sum up the investigations: %v", err)
+		return nil, err
+	}
+
+	log.Printf("[CONSULTATION] Summed up %d investigations", len(statistics))
+	return statistics, nil
+}
+
+func (db *MongoDB) aggregateStatistics(ctx context.Context, pipeline []bson.M, results interface{}) error {
+	cursor, err := db.db.Collection(utils.CONSULTATIE_TABLE).Aggregate(ctx, pipeline)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	return cursor.All(ctx, results)
+}
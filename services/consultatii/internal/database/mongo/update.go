@@ -4,36 +4,110 @@ import (
 	"context"
 	"log"
 
+	"github.com/mihnea1711/POS_Project/services/consultatii/internal/database"
 	"github.com/mihnea1711/POS_Project/services/consultatii/internal/models"
 	"github.com/mihnea1711/POS_Project/services/consultatii/pkg/utils"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
-// UpdateConsultationByID updates a consultation by its ObjectID and returns the number of rows affected.
-func (db *MongoDB) UpdateConsultationByID(ctx context.Context, consultation *models.Consultation) (int, error) {
+// UpdateConsultationByID saves the consultation as the version after the one it was read at and records it as a new
+// revision. The stored consultation is never overwritten by an update based on an older version, that is a
+// database.ErrVersionConflict. It returns the number of consultations updated, 0 when there is none with the ID.
+func (db *MongoDB) UpdateConsultationByID(ctx context.Context, consultation *models.Consultation, author *int, reason string) (int, error) {
 	// Get the MongoDB collection for consultations
 	collection := db.db.Collection(utils.CONSULTATIE_TABLE)
+	basedOn := consultation.Version
 
 	// Log the attempt to update the consultation with its ID
-	log.Printf("[PATIENT] Attempting to update consultation %s", consultation.IDConsultation.Hex())
+	log.Printf("[CONSULTATION] Attempting to update consultation %s from version %d", consultation.IDConsultation.Hex(), basedOn)
+
+	// A consultation saved before versioning keeps its stored state as the first revision
+	if basedOn == utils.FIRST_CONSULTATION_VERSION {
+		if err := db.saveBaselineRevision(ctx, consultation.IDConsultation); err != nil {
+			if err == mongo.ErrNoDocuments {
+				log.Printf("[CONSULTATION] No consultation has been updated for ID: %v", consultation.IDConsultation.Hex())
+				return 0, nil
+			}
+			log.Printf("[CONSULTATION] Error recording the first revision of consultation %s: %v", consultation.IDConsultation.Hex(), err)
+			return 0, err
+		}
+	}
+
+	consultation.Version = basedOn + 1
+	sealed, err := db.sealConsultation(ctx, consultation)
+	if err != nil {
+		consultation.Version = basedOn
+		log.Printf("[CONSULTATION] Error encrypting consultation: %v", err)
+		return 0, err
+	}
+
+	// The revision goes first, the unique version per consultation lets only one update based on a version through
+	revisionID, err := db.saveRevision(ctx, sealed, author, reason)
+	if err != nil {
+		consultation.Version = basedOn
+		if mongo.IsDuplicateKeyError(err) {
+			return db.missingOrConflict(ctx, consultation.IDConsultation)
+		}
+		log.Printf("[CONSULTATION] Error recording revision %d of consultation %s: %v", sealed.Version, consultation.IDConsultation.Hex(), err)
+		return 0, err
+	}
+
+	filter := bson.M{utils.COLUMN_ID_CONSULTATIE: consultation.IDConsultation, utils.COLUMN_VERSION: basedOn}
+	if basedOn == utils.FIRST_CONSULTATION_VERSION {
+		filter[utils.COLUMN_VERSION] = bson.M{"$in": bson.A{basedOn, nil}}
+	}
 
 	// Replace the existing consultation document in the collection with the provided one
-	result, err := collection.ReplaceOne(ctx, bson.M{utils.COLUMN_ID_CONSULTATIE: consultation.IDConsultation}, consultation)
+	result, err := collection.ReplaceOne(ctx, filter, sealed)
 	if err != nil {
 		// Log an error if the update operation fails
 		log.Printf("[CONSULTATION] Error updating consultation by ID: %v", err)
+		db.discardRevision(ctx, revisionID)
+		consultation.Version = basedOn
 		return 0, err
 	}
+	if result.MatchedCount == 0 {
+		db.discardRevision(ctx, revisionID)
+		consultation.Version = basedOn
+		return db.missingOrConflict(ctx, consultation.IDConsultation)
+	}
 
-	// Check if any document was modified during the update
-	if result.ModifiedCount != 0 {
-		// Log a success message if the consultation was updated
-		log.Printf("[CONSULTATION] Consultation updated successfully. ID: %v", consultation.IDConsultation.Hex())
-	} else {
-		// Log a message if no consultation was updated (ID not found)
-		log.Printf("[CONSULTATION] No consultation has been updated for ID: %v", consultation.IDConsultation.Hex())
+	log.Printf("[CONSULTATION] Consultation updated successfully. ID: %v, version %d", consultation.IDConsultation.Hex(), consultation.Version)
+	return int(result.MatchedCount), nil
+}
+
+// missingOrConflict tells apart an update that found no consultation from one based on a version that is not the
+// stored one anymore
+func (db *MongoDB) missingOrConflict(ctx context.Context, consultationID primitive.ObjectID) (int, error) {
+	count, err := db.db.Collection(utils.CONSULTATIE_TABLE).CountDocuments(ctx, bson.M{utils.COLUMN_ID_CONSULTATIE: consultationID})
+	if err != nil {
+		log.Printf("[CONSULTATION] Error checking consultation %s: %v", consultationID.Hex(), err)
+		return 0, err
+	}
+	if count == 0 {
+		log.Printf("[CONSULTATION] No consultation has been updated for ID: %v", consultationID.Hex())
+		return 0, nil
+	}
+
+	log.Printf("[CONSULTATION] Consultation %s was changed by another update", consultationID.Hex())
+	return 0, database.ErrVersionConflict
+}
+
+// ReassignPatientConsultations moves every consultation of a patient to another patient and returns the number of
+// consultations moved. Running it again once the consultations were moved changes nothing.
+func (db *MongoDB) ReassignPatientConsultations(ctx context.Context, fromPatientID, toPatientID int) (int, error) {
+	collection := db.db.Collection(utils.CONSULTATIE_TABLE)
+
+	log.Printf("[CONSULTATION] Attempting to move the consultations of patient %d to patient %d", fromPatientID, toPatientID)
+
+	result, err := collection.UpdateMany(ctx, bson.M{utils.COLUMN_ID_PATIENT: fromPatientID}, bson.M{"$set": bson.M{utils.COLUMN_ID_PATIENT: toPatientID}})
+	if err != nil {
+		log.Printf("[CONSULTATION] Error moving the consultations of patient %d: %v", fromPatientID, err)
+		return 0, err
 	}
 
-	// Return the number of modified documents (rows) and a potential error
+	log.Printf("[CONSULTATION] Moved %d consultations of patient %d to patient %d", result.ModifiedCount, fromPatientID, toPatientID)
 	return int(result.ModifiedCount), nil
 }
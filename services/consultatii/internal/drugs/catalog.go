@@ -0,0 +1,183 @@
+package drugs
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/mihnea1711/POS_Project/services/consultatii/pkg/config"
+)
+
+// Drug is a product of the catalog. The substance, the classes and the allergy groups are what interactions and
+// allergies are matched against, all in lower case.
+type Drug struct {
+	Code          string   `json:"code"`
+	Name          string   `json:"name"`
+	Substance     string   `json:"substance"`
+	Classes       []string `json:"classes"`
+	AllergyGroups []string `json:"allergyGroups,omitempty"`
+}
+
+// Interaction is a known interaction between two substances or classes of drugs
+type Interaction struct {
+	Between     [2]string `json:"between"`
+	Severity    string    `json:"severity"`
+	Description string    `json:"description"`
+}
+
+// Catalog is the local list of drugs a prescription is written from, with the interactions between them. It is read
+// once at startup and only read afterwards, so it is safe for concurrent use.
+type Catalog struct {
+	drugs        []Drug
+	byCode       map[string]Drug
+	interactions []Interaction
+}
+
+// NewCatalog loads the catalog from the bundled JSON file
+func NewCatalog(conf config.DrugsConfig) (*Catalog, error) {
+	data, err := os.ReadFile(conf.Catalog)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read drug catalog: %w", err)
+	}
+
+	var file struct {
+		Drugs        []Drug        `json:"drugs"`
+		Interactions []Interaction `json:"interactions"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to decode drug catalog %s: %w", conf.Catalog, err)
+	}
+
+	catalog := &Catalog{byCode: make(map[string]Drug, len(file.Drugs))}
+	for _, drug := range file.Drugs {
+		drug.Code = strings.ToUpper(strings.TrimSpace(drug.Code))
+		drug.Substance = normalize(drug.Substance)
+		if drug.Code == "" || drug.Name == "" || drug.Substance == "" {
+			return nil, fmt.Errorf("drug %q needs a code, a name and a substance", drug.Code)
+		}
+		if _, ok := catalog.byCode[drug.Code]; ok {
+			return nil, fmt.Errorf("drug %s is listed twice", drug.Code)
+		}
+		for i := range drug.Classes {
+			drug.Classes[i] = normalize(drug.Classes[i])
+		}
+		for i := range drug.AllergyGroups {
+			drug.AllergyGroups[i] = normalize(drug.AllergyGroups[i])
+		}
+		catalog.byCode[drug.Code] = drug
+		catalog.drugs = append(catalog.drugs, drug)
+	}
+	for _, interaction := range file.Interactions {
+		interaction.Between = [2]string{normalize(interaction.Between[0]), normalize(interaction.Between[1])}
+		catalog.interactions = append(catalog.interactions, interaction)
+	}
+
+	sort.Slice(catalog.drugs, func(i, j int) bool { return catalog.drugs[i].Name < catalog.drugs[j].Name })
+
+	log.Printf("[CONSULTATION] Drug catalog loaded with %d drugs and %d interactions from %s", len(catalog.drugs), len(catalog.interactions), conf.Catalog)
+	return catalog, nil
+}
+
+// Lookup returns the drug with a code, in any case
+func (c *Catalog) Lookup(code string) (Drug, bool) {
+	drug, ok := c.byCode[strings.ToUpper(strings.TrimSpace(code))]
+	return drug, ok
+}
+
+// Search returns up to limit drugs whose code starts with the query or whose name or substance contains every word of
+// it, in name order
+func (c *Catalog) Search(query string, limit int) []Drug {
+	results := []Drug{}
+	words := strings.Fields(normalize(query))
+	if len(words) == 0 || limit <= 0 {
+		return results
+	}
+
+	codePrefix := strings.ToUpper(strings.TrimSpace(query))
+	for _, drug := range c.drugs {
+		text := normalize(drug.Name) + " " + drug.Substance
+		if strings.HasPrefix(drug.Code, codePrefix) || containsAll(text, words) {
+			results = append(results, drug)
+			if len(results) == limit {
+				break
+			}
+		}
+	}
+	return results
+}
+
+// Identify finds the drug a free-text medication name refers to, such as "Warfarin 5mg" in a clinical profile. The
+// longest substance named in the text wins, so a combination is not taken for one of its parts.
+func (c *Catalog) Identify(name string) (Drug, bool) {
+	name = normalize(name)
+
+	var found Drug
+	ok := false
+	for _, drug := range c.drugs {
+		if strings.Contains(name, drug.Substance) && (!ok || len(drug.Substance) > len(found.Substance)) {
+			found, ok = drug, true
+		}
+	}
+	return found, ok
+}
+
+// Interactions returns the known interactions between two drugs, matched by substance or class
+func (c *Catalog) Interactions(a, b Drug) []Interaction {
+	var found []Interaction
+	for _, interaction := range c.interactions {
+		first, second := interaction.Between[0], interaction.Between[1]
+		if (a.is(first) && b.is(second)) || (a.is(second) && b.is(first)) {
+			found = append(found, interaction)
+		}
+	}
+	return found
+}
+
+// AllergicTo tells whether a drug falls under a recorded allergy, such as penicillin for amoxicillin. The allergy is
+// free text, so a term of the drug found in it or the other way around is a match.
+func (d Drug) AllergicTo(allergy string) bool {
+	allergy = normalize(allergy)
+	if allergy == "" {
+		return false
+	}
+
+	terms := append([]string{d.Substance}, d.AllergyGroups...)
+	terms = append(terms, d.Classes...)
+	for _, term := range terms {
+		if term == allergy || strings.Contains(allergy, term) || (len(allergy) >= minAllergyMatch && strings.Contains(term, allergy)) {
+			return true
+		}
+	}
+	return false
+}
+
+// minAllergyMatch keeps a short allergy such as "b" from matching every drug whose terms contain the letter
+const minAllergyMatch = 4
+
+func (d Drug) is(term string) bool {
+	if d.Substance == term {
+		return true
+	}
+	for _, class := range d.Classes {
+		if class == term {
+			return true
+		}
+	}
+	return false
+}
+
+func normalize(text string) string {
+	return strings.ToLower(strings.TrimSpace(text))
+}
+
+func containsAll(text string, words []string) bool {
+	for _, word := range words {
+		if !strings.Contains(text, word) {
+			return false
+		}
+	}
+	return true
+}
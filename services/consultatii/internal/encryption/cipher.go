@@ -0,0 +1,147 @@
+package encryption
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+const (
+	keySize = 32
+
+	// envelopePrefix marks an encrypted value: enc:v1:<key ID>:<wrapped data key>:<ciphertext>
+	envelopePrefix = "enc:v1:"
+)
+
+// FieldCipher encrypts single field values with envelope encryption. The fields are never queried, so they have no
+// blind indexes. The name of the field is authenticated with the value, a ciphertext copied into another field does not decrypt.
+type FieldCipher struct {
+	provider KeyProvider
+}
+
+func NewFieldCipher(provider KeyProvider) *FieldCipher {
+	return &FieldCipher{provider: provider}
+}
+
+func (c *FieldCipher) Provider() KeyProvider {
+	return c.provider
+}
+
+// Encrypt seals the value with a new data key. Empty values are kept empty.
+func (c *FieldCipher) Encrypt(ctx context.Context, field, value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+
+	dataKey := make([]byte, keySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", err
+	}
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return "", err
+	}
+	ciphertext, err := seal(aead, []byte(value), []byte(field))
+	if err != nil {
+		return "", err
+	}
+
+	keyID, wrapped, err := c.provider.WrapKey(ctx, dataKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to wrap data key: %w", err)
+	}
+
+	return envelopePrefix + strings.Join([]string{
+		keyID,
+		base64.RawStdEncoding.EncodeToString(wrapped),
+		base64.RawStdEncoding.EncodeToString(ciphertext),
+	}, ":"), nil
+}
+
+// Decrypt opens a value sealed by Encrypt. Values written before the encryption was introduced are returned as they
+// are until the rotation encrypts them.
+func (c *FieldCipher) Decrypt(ctx context.Context, field, value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+
+	keyID, wrapped, ciphertext, err := parseEnvelope(value)
+	if err != nil {
+		return "", err
+	}
+	dataKey, err := c.provider.UnwrapKey(ctx, keyID, wrapped)
+	if err != nil {
+		return "", fmt.Errorf("failed to unwrap data key of %s: %w", field, err)
+	}
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := open(aead, ciphertext, []byte(field))
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt %s: %w", field, err)
+	}
+	return string(plaintext), nil
+}
+
+// Rewrap wraps the data key of a value again with the active key. The data itself is not encrypted again.
+func (c *FieldCipher) Rewrap(ctx context.Context, field, value string) (string, error) {
+	if !IsEncrypted(value) {
+		return c.Encrypt(ctx, field, value)
+	}
+
+	keyID, wrapped, ciphertext, err := parseEnvelope(value)
+	if err != nil {
+		return "", err
+	}
+	dataKey, err := c.provider.UnwrapKey(ctx, keyID, wrapped)
+	if err != nil {
+		return "", fmt.Errorf("failed to unwrap data key of %s: %w", field, err)
+	}
+
+	newKeyID, rewrapped, err := c.provider.WrapKey(ctx, dataKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to wrap data key: %w", err)
+	}
+
+	return envelopePrefix + strings.Join([]string{
+		newKeyID,
+		base64.RawStdEncoding.EncodeToString(rewrapped),
+		base64.RawStdEncoding.EncodeToString(ciphertext),
+	}, ":"), nil
+}
+
+// KeyID names the key encryption key of a value, it is empty for values that are not encrypted
+func KeyID(value string) string {
+	if !IsEncrypted(value) {
+		return ""
+	}
+	keyID, _, _, err := parseEnvelope(value)
+	if err != nil {
+		return ""
+	}
+	return keyID
+}
+
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, envelopePrefix)
+}
+
+func parseEnvelope(value string) (string, []byte, []byte, error) {
+	parts := strings.Split(strings.TrimPrefix(value, envelopePrefix), ":")
+	if len(parts) != 3 {
+		return "", nil, nil, fmt.Errorf("malformed encrypted value")
+	}
+
+	wrapped, err := base64.RawStdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("malformed wrapped data key: %w", err)
+	}
+	ciphertext, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("malformed ciphertext: %w", err)
+	}
+	return parts[0], wrapped, ciphertext, nil
+}
@@ -0,0 +1,37 @@
+package encryption
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/mihnea1711/POS_Project/services/consultatii/pkg/config"
+	"github.com/mihnea1711/POS_Project/services/consultatii/pkg/utils"
+)
+
+// KeyProvider holds the key encryption keys. Every encrypted field has its own data key, which is wrapped with the
+// active key encryption key. The older keys stay available to unwrap what was written before a rotation.
+type KeyProvider interface {
+	// WrapKey encrypts a data key with the active key and names the key it used
+	WrapKey(ctx context.Context, dataKey []byte) (string, []byte, error)
+
+	// UnwrapKey decrypts a data key wrapped with the named key
+	UnwrapKey(ctx context.Context, keyID string, wrapped []byte) ([]byte, error)
+
+	// ActiveKeyID names the key new data keys are wrapped with
+	ActiveKeyID(ctx context.Context) (string, error)
+
+	// Reload picks up the keys added or retired since the provider was created
+	Reload(ctx context.Context) error
+}
+
+// NewKeyProvider returns the key provider named in the configuration
+func NewKeyProvider(conf config.EncryptionConfig) (KeyProvider, error) {
+	switch conf.Provider {
+	case "", utils.KEY_PROVIDER_FILE:
+		log.Printf("[CONSULTATION] Encryption keys are read from the keyring %s.", conf.Keyring)
+		return NewFileKeyring(conf.Keyring)
+	default:
+		return nil, fmt.Errorf("unknown key provider: %s", conf.Provider)
+	}
+}
@@ -0,0 +1,70 @@
+package encryption
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/mihnea1711/POS_Project/services/consultatii/internal/database"
+	"github.com/mihnea1711/POS_Project/services/consultatii/internal/models"
+	"github.com/mihnea1711/POS_Project/services/consultatii/pkg/config"
+	"github.com/mihnea1711/POS_Project/services/consultatii/pkg/utils"
+)
+
+// Rotator moves the stored diagnostics and investigation results to the active key. A key is rotated by adding a new
+// one to the keyring and making it active, the retired key can be removed once no value uses it anymore.
+type Rotator struct {
+	dbConn   database.Database
+	provider KeyProvider
+	interval time.Duration
+	mu       sync.Mutex // a periodic sweep and a requested one do not run together
+}
+
+func NewRotator(dbConn database.Database, provider KeyProvider, encryptionConfig config.EncryptionConfig) *Rotator {
+	interval := time.Duration(encryptionConfig.RotationIntervalMinutes) * time.Minute
+	if interval <= 0 {
+		interval = utils.DEFAULT_KEY_ROTATION_INTERVAL * time.Minute
+	}
+
+	return &Rotator{
+		dbConn:   dbConn,
+		provider: provider,
+		interval: interval,
+	}
+}
+
+// Start sweeps periodically until the context is canceled. The first sweep also encrypts the consultations stored
+// before the encryption was introduced.
+func (r *Rotator) Start(ctx context.Context) {
+	log.Printf("[CONSULTATION] Key rotator started. Sweeping every %s.", r.interval)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		rotateCtx, cancel := context.WithTimeout(ctx, utils.KEY_ROTATION_TIMEOUT*time.Second)
+		if _, err := r.Rotate(rotateCtx); err != nil {
+			log.Printf("[CONSULTATION] Key rotation failed: %v", err)
+		}
+		cancel()
+
+		select {
+		case <-ctx.Done():
+			log.Println("[CONSULTATION] Key rotator stopped.")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Rotate reloads the keyring and wraps again the data keys still wrapped with a retired key
+func (r *Rotator) Rotate(ctx context.Context) (*models.KeyRotationReport, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.provider.Reload(ctx); err != nil {
+		return nil, err
+	}
+	return r.dbConn.RotateConsultationKeys(ctx)
+}
@@ -0,0 +1,228 @@
+package hl7
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// MLLP frames a message between these bytes when it is sent over a socket, files saved from such a link keep them
+const (
+	mllpStart = 0x0b
+	mllpEnd   = 0x1c
+)
+
+// delimiters are the separators a message declares in MSH-1 and MSH-2
+type delimiters struct {
+	field        byte
+	component    byte
+	repetition   byte
+	escape       byte
+	subcomponent byte
+}
+
+var defaultDelimiters = delimiters{field: '|', component: '^', repetition: '~', escape: '\\', subcomponent: '&'}
+
+// Message is an HL7 v2 message in the ER7 encoding, its segments in the order they were sent
+type Message struct {
+	Text     string
+	Segments []Segment
+}
+
+// Segment is a line of a message. Fields are numbered as in the standard: for MSH the field separator itself is MSH-1.
+type Segment struct {
+	Name   string
+	fields []string
+	delims delimiters
+}
+
+// Split cuts the content of a file or a request into the texts of the messages it holds. The line endings may be
+// CR, LF or both, MLLP framing is dropped and the batch header and trailer segments are left out.
+func Split(data []byte) []string {
+	data = bytes.Map(func(r rune) rune {
+		if r == mllpStart || r == mllpEnd {
+			return '\r'
+		}
+		return r
+	}, data)
+	text := strings.ReplaceAll(strings.ReplaceAll(string(data), "\r\n", "\r"), "\n", "\r")
+
+	messages := []string{}
+	var current []string
+	for _, line := range strings.Split(text, "\r") {
+		line = strings.TrimRight(line, " \t")
+		if line == "" {
+			continue
+		}
+		switch segmentName(line) {
+		case "FHS", "BHS", "BTS", "FTS":
+			continue
+		case "MSH":
+			if len(current) > 0 {
+				messages = append(messages, strings.Join(current, "\r"))
+			}
+			current = []string{line}
+		default:
+			// Lines before the first header belong to no message, they are kept so the message cannot be read
+			current = append(current, line)
+		}
+	}
+	if len(current) > 0 {
+		messages = append(messages, strings.Join(current, "\r"))
+	}
+	return messages
+}
+
+// Parse reads a message split from a file or a request. The delimiters are the ones the header declares.
+func Parse(text string) (*Message, error) {
+	if !strings.HasPrefix(text, "MSH") || len(text) < 8 {
+		return nil, fmt.Errorf("a message starts with an MSH segment")
+	}
+
+	delims := defaultDelimiters
+	delims.field = text[3]
+	encoding := text[4:]
+	if end := strings.IndexByte(encoding, delims.field); end >= 0 {
+		encoding = encoding[:end]
+	}
+	if len(encoding) < 4 {
+		return nil, fmt.Errorf("the MSH segment declares %d encoding characters, 4 are expected", len(encoding))
+	}
+	delims.component, delims.repetition, delims.escape, delims.subcomponent = encoding[0], encoding[1], encoding[2], encoding[3]
+
+	message := &Message{Text: text}
+	for _, line := range strings.Split(text, "\r") {
+		if line == "" {
+			continue
+		}
+		name := segmentName(line)
+		if len(name) != 3 {
+			return nil, fmt.Errorf("invalid segment %q", truncate(line, 20))
+		}
+
+		fields := strings.Split(line, string(delims.field))
+		if name == "MSH" {
+			fields = append([]string{name, string(delims.field)}, fields[1:]...)
+		}
+		message.Segments = append(message.Segments, Segment{Name: name, fields: fields, delims: delims})
+	}
+	return message, nil
+}
+
+// Header is the MSH segment
+func (m *Message) Header() *Segment {
+	return &m.Segments[0]
+}
+
+// Type is the message type and trigger event of MSH-9, such as ORU^R01
+func (m *Message) Type() string {
+	header := m.Header()
+	return header.Component(9, 1) + "^" + header.Component(9, 2)
+}
+
+// ControlID is MSH-10, which the sender keeps unique
+func (m *Message) ControlID() string {
+	return m.Header().Value(10)
+}
+
+// Field is the raw value of a field, with its repetitions and components
+func (s *Segment) Field(n int) string {
+	if n < 0 || n >= len(s.fields) {
+		return ""
+	}
+	return s.fields[n]
+}
+
+// Repetitions are the raw repetitions of a field
+func (s *Segment) Repetitions(n int) []string {
+	field := s.Field(n)
+	if field == "" {
+		return nil
+	}
+	if s.Name == "MSH" && n <= 2 {
+		return []string{field}
+	}
+	return strings.Split(field, string(s.delims.repetition))
+}
+
+// Component is a component of the first repetition of a field, unescaped. Components count from 1.
+func (s *Segment) Component(n, c int) string {
+	repetitions := s.Repetitions(n)
+	if len(repetitions) == 0 {
+		return ""
+	}
+	return s.RepetitionComponent(repetitions[0], c)
+}
+
+// RepetitionComponent is a component of a repetition of a field, unescaped
+func (s *Segment) RepetitionComponent(repetition string, c int) string {
+	components := strings.Split(repetition, string(s.delims.component))
+	if c < 1 || c > len(components) {
+		return ""
+	}
+	return s.Unescape(components[c-1])
+}
+
+// Value is the first component of a field, the whole value of the simple ones
+func (s *Segment) Value(n int) string {
+	return s.Component(n, 1)
+}
+
+// Unescape replaces the escape sequences of a value with what they stand for. Formatting sequences other than line
+// breaks are dropped.
+func (s *Segment) Unescape(value string) string {
+	escape := s.delims.escape
+	if strings.IndexByte(value, escape) < 0 {
+		return value
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] != escape {
+			b.WriteByte(value[i])
+			continue
+		}
+		end := strings.IndexByte(value[i+1:], escape)
+		if end < 0 {
+			b.WriteString(value[i:])
+			break
+		}
+		sequence := value[i+1 : i+1+end]
+		i += end + 1
+
+		switch {
+		case sequence == "F":
+			b.WriteByte(s.delims.field)
+		case sequence == "S":
+			b.WriteByte(s.delims.component)
+		case sequence == "T":
+			b.WriteByte(s.delims.subcomponent)
+		case sequence == "R":
+			b.WriteByte(s.delims.repetition)
+		case sequence == "E":
+			b.WriteByte(escape)
+		case sequence == ".br":
+			b.WriteByte('\n')
+		case strings.HasPrefix(sequence, "X"):
+			if decoded, err := hex.DecodeString(sequence[1:]); err == nil {
+				b.Write(decoded)
+			}
+		}
+	}
+	return b.String()
+}
+
+func segmentName(line string) string {
+	if len(line) < 3 {
+		return line
+	}
+	return line[:3]
+}
+
+func truncate(text string, length int) string {
+	if len(text) <= length {
+		return text
+	}
+	return text[:length] + "..."
+}
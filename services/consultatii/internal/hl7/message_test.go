@@ -0,0 +1,177 @@
+package hl7
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want []string
+	}{
+		{
+			name: "CR line endings",
+			data: "MSH|^~\\&|LAB\rPID|1\rMSH|^~\\&|LAB\rPID|2\r",
+			want: []string{"MSH|^~\\&|LAB\rPID|1", "MSH|^~\\&|LAB\rPID|2"},
+		},
+		{
+			name: "CRLF and LF line endings with trailing blanks",
+			data: "MSH|^~\\&|LAB  \r\nPID|1\n\nOBR|1\t\n",
+			want: []string{"MSH|^~\\&|LAB\rPID|1\rOBR|1"},
+		},
+		{
+			name: "MLLP framing",
+			data: "\x0bMSH|^~\\&|LAB\rPID|1\r\x1c\r\x0bMSH|^~\\&|LAB\rPID|2\r\x1c\r",
+			want: []string{"MSH|^~\\&|LAB\rPID|1", "MSH|^~\\&|LAB\rPID|2"},
+		},
+		{
+			name: "batch header and trailer segments",
+			data: "FHS|^~\\&\rBHS|^~\\&\rMSH|^~\\&|LAB\rPID|1\rBTS|1\rFTS|1\r",
+			want: []string{"MSH|^~\\&|LAB\rPID|1"},
+		},
+		{
+			name: "lines before the first header",
+			data: "PID|0\rMSH|^~\\&|LAB\rPID|1\r",
+			want: []string{"PID|0", "MSH|^~\\&|LAB\rPID|1"},
+		},
+		{
+			name: "empty",
+			data: "\r\n\r\n",
+			want: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Split([]byte(tt.data)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Split() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseMalformed(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+	}{
+		{name: "no header", text: "PID|1||1800101221144"},
+		{name: "header too short", text: "MSH|^~"},
+		{name: "missing encoding characters", text: "MSH|^~|LAB|CLINIC"},
+		{name: "invalid segment name", text: "MSH|^~\\&|LAB\rP1"},
+		{name: "empty", text: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if message, err := Parse(tt.text); err == nil {
+				t.Errorf("Parse() = %+v, want an error", message)
+			}
+		})
+	}
+}
+
+func TestParseFields(t *testing.T) {
+	text := "MSH|^~\\&|LAB|Synevo|POS|Clinic|20240301120000||ORU^R01|MSG0001|P|2.5\r" +
+		"PID|1||123^^^LAB^MR~1800101221144^^^^CNP||Popescu^Ion^^^Dr.\r" +
+		"OBX|1|ST|GLU^Glucose||A\\S\\B\\F\\C\\T\\D\\R\\E\\E\\|mg/dL"
+
+	message, err := Parse(text)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if len(message.Segments) != 3 {
+		t.Fatalf("Parse() read %d segments, want 3", len(message.Segments))
+	}
+	if got := message.Type(); got != "ORU^R01" {
+		t.Errorf("Type() = %q, want ORU^R01", got)
+	}
+	if got := message.ControlID(); got != "MSG0001" {
+		t.Errorf("ControlID() = %q, want MSG0001", got)
+	}
+
+	header := message.Header()
+	pid := &message.Segments[1]
+	obx := &message.Segments[2]
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{name: "MSH-1 is the field separator", got: header.Field(1), want: "|"},
+		{name: "MSH-2 keeps the encoding characters", got: header.Field(2), want: "^~\\&"},
+		{name: "MSH-4", got: header.Value(4), want: "Synevo"},
+		{name: "second component", got: pid.Component(5, 2), want: "Ion"},
+		{name: "component past the last", got: pid.Component(5, 9), want: ""},
+		{name: "component zero", got: pid.Component(5, 0), want: ""},
+		{name: "first repetition only", got: pid.Value(3), want: "123"},
+		{name: "field past the last", got: pid.Field(30), want: ""},
+		{name: "negative field", got: pid.Field(-1), want: ""},
+		{name: "escaped delimiters", got: obx.Value(5), want: "A^B|C&D~E\\"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("got %q, want %q", tt.got, tt.want)
+			}
+		})
+	}
+
+	if got := pid.Repetitions(3); !reflect.DeepEqual(got, []string{"123^^^LAB^MR", "1800101221144^^^^CNP"}) {
+		t.Errorf("Repetitions(3) = %q", got)
+	}
+	if got := header.Repetitions(2); !reflect.DeepEqual(got, []string{"^~\\&"}) {
+		t.Errorf("MSH Repetitions(2) = %q, the encoding characters are not split", got)
+	}
+}
+
+func TestParseCustomDelimiters(t *testing.T) {
+	message, err := Parse("MSH#:*!@#LAB\rOBX#1#ST#GLU:Glucose##a!S!b*c")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	obx := &message.Segments[1]
+	if got := obx.Component(3, 2); got != "Glucose" {
+		t.Errorf("Component(3, 2) = %q, want Glucose", got)
+	}
+	if got := obx.Repetitions(5); !reflect.DeepEqual(got, []string{"a!S!b", "c"}) {
+		t.Errorf("Repetitions(5) = %q", got)
+	}
+	if got := obx.Value(5); got != "a:b" {
+		t.Errorf("Value(5) = %q, want a:b", got)
+	}
+}
+
+func TestUnescape(t *testing.T) {
+	segment := &Segment{Name: "OBX", delims: defaultDelimiters}
+
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{name: "plain", value: "5.4", want: "5.4"},
+		{name: "field separator", value: `a\F\b`, want: "a|b"},
+		{name: "component separator", value: `a\S\b`, want: "a^b"},
+		{name: "subcomponent separator", value: `a\T\b`, want: "a&b"},
+		{name: "repetition separator", value: `a\R\b`, want: "a~b"},
+		{name: "escape character", value: `a\E\b`, want: `a\b`},
+		{name: "line break", value: `line 1\.br\line 2`, want: "line 1\nline 2"},
+		{name: "hexadecimal", value: `\X4F4B\`, want: "OK"},
+		{name: "invalid hexadecimal is dropped", value: `a\XZZ\b`, want: "ab"},
+		{name: "other formatting is dropped", value: `\H\bold\N\`, want: "bold"},
+		{name: "unterminated sequence is kept", value: `a\Sb`, want: `a\Sb`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := segment.Unescape(tt.value); got != tt.want {
+				t.Errorf("Unescape(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
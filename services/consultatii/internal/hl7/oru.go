@@ -0,0 +1,316 @@
+package hl7
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The result statuses of OBR-25 the importer tells apart. An order reported without a status is taken as final.
+const (
+	ResultStatusFinal        = "F"
+	ResultStatusCorrected    = "C"
+	ResultStatusNotPerformed = "X"
+)
+
+// The statuses of OBX-11 that leave an observation out of the result or say it could not be done
+const (
+	observationStatusDeleted      = "D"
+	observationStatusNotPerformed = "X"
+)
+
+// The identifier types of PID-3 a CNP is sent under: its own code or the national person identifier of HL7
+var cnpIdentifierTypes = map[string]bool{"": true, "CNP": true, "NN": true, "NNROU": true}
+
+// cnpWeights are the weights of the check digit of a CNP
+const cnpWeights = "279146358279"
+
+// ORU is what the importer reads from an ORU^R01 message: the results of the orders, each with the patient it was
+// reported for
+type ORU struct {
+	ControlID       string
+	SendingFacility string
+	Orders          []Order
+}
+
+// Patient is the patient of a PID segment. CNP is empty when no identifier looks like one.
+type Patient struct {
+	CNP  string
+	Name string
+}
+
+// Order is an OBR segment with the observations and the notes that follow it. The placer order number is the ID the
+// investigation was ordered under.
+type Order struct {
+	Patient       Patient
+	PlacerOrderID string
+	FillerOrderID string
+	Service       string
+	ObservedAt    *time.Time
+	ReportedAt    *time.Time
+	ResultStatus  string
+	Observations  []Observation
+	Notes         []string
+}
+
+// Observation is an OBX segment, a single measured or reported value
+type Observation struct {
+	Code           string
+	Name           string
+	Value          string
+	Units          string
+	ReferenceRange string
+	AbnormalFlags  string
+	Status         string
+}
+
+// ParseORU reads the orders of an ORU^R01 message. Times without an offset are taken in the given location.
+func ParseORU(message *Message, location *time.Location) (*ORU, error) {
+	if messageType := message.Type(); messageType != "ORU^R01" {
+		return nil, fmt.Errorf("message type %s is not ORU^R01", messageType)
+	}
+
+	oru := &ORU{
+		ControlID:       message.ControlID(),
+		SendingFacility: message.Header().Value(4),
+	}
+
+	var (
+		patient      Patient
+		placerFromOR string
+		order        *Order
+	)
+	for i := range message.Segments {
+		segment := &message.Segments[i]
+		switch segment.Name {
+		case "PID":
+			patient = Patient{CNP: patientCNP(segment), Name: patientName(segment)}
+			placerFromOR = ""
+			order = nil
+		case "ORC":
+			placerFromOR = segment.Value(2)
+		case "OBR":
+			oru.Orders = append(oru.Orders, Order{
+				Patient:       patient,
+				PlacerOrderID: firstNonEmpty(segment.Value(2), placerFromOR),
+				FillerOrderID: segment.Value(3),
+				Service:       firstNonEmpty(segment.Component(4, 2), segment.Component(4, 1)),
+				ResultStatus:  strings.ToUpper(segment.Value(25)),
+			})
+			order = &oru.Orders[len(oru.Orders)-1]
+			placerFromOR = ""
+
+			var err error
+			if order.ObservedAt, err = parseTime(segment.Value(7), location); err != nil {
+				return nil, fmt.Errorf("OBR-7 of order %s: %w", order.PlacerOrderID, err)
+			}
+			if order.ReportedAt, err = parseTime(segment.Value(22), location); err != nil {
+				return nil, fmt.Errorf("OBR-22 of order %s: %w", order.PlacerOrderID, err)
+			}
+		case "OBX":
+			if order == nil {
+				return nil, fmt.Errorf("an OBX segment comes before any OBR segment")
+			}
+			order.Observations = append(order.Observations, Observation{
+				Code:           segment.Component(3, 1),
+				Name:           firstNonEmpty(segment.Component(3, 2), segment.Component(3, 1)),
+				Value:          observationValue(segment),
+				Units:          firstNonEmpty(segment.Component(6, 1), segment.Component(6, 2)),
+				ReferenceRange: segment.Value(7),
+				AbnormalFlags:  strings.Join(repetitionValues(segment, 8), ","),
+				Status:         strings.ToUpper(segment.Value(11)),
+			})
+		case "NTE":
+			if order != nil {
+				if note := strings.Join(repetitionValues(segment, 3), "\n"); note != "" {
+					order.Notes = append(order.Notes, note)
+				}
+			}
+		}
+	}
+
+	if len(oru.Orders) == 0 {
+		return nil, fmt.Errorf("the message reports no order")
+	}
+	return oru, nil
+}
+
+// IsFinal tells whether an order reported with the status carries its result. Preliminary and partial results are only
+// progress.
+func IsFinal(resultStatus string) bool {
+	return resultStatus == "" || resultStatus == ResultStatusFinal || resultStatus == ResultStatusCorrected
+}
+
+// ResultText writes the observations of the order as the result of an investigation, one per line with the units, the
+// reference range and the abnormal flags, followed by the notes of the lab
+func (o *Order) ResultText() string {
+	lines := []string{}
+	for _, observation := range o.Observations {
+		if observation.Status == observationStatusDeleted {
+			continue
+		}
+
+		value := observation.Value
+		if observation.Status == observationStatusNotPerformed {
+			value = "not performed"
+		} else if observation.Units != "" {
+			value += " " + observation.Units
+		}
+		line := value
+		if observation.Name != "" {
+			line = observation.Name + ": " + value
+		}
+		if observation.ReferenceRange != "" {
+			line += " (ref. " + observation.ReferenceRange + ")"
+		}
+		if observation.AbnormalFlags != "" && observation.AbnormalFlags != "N" {
+			line += " [" + observation.AbnormalFlags + "]"
+		}
+		lines = append(lines, line)
+	}
+	for _, note := range o.Notes {
+		lines = append(lines, "Note: "+note)
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// patientCNP looks for the CNP among the identifiers of PID-3, then in the older PID-2 and PID-19. Medical record
+// numbers of the lab are told apart by the check digit.
+func patientCNP(segment *Segment) string {
+	for _, repetition := range segment.Repetitions(3) {
+		id := segment.RepetitionComponent(repetition, 1)
+		if cnpIdentifierTypes[strings.ToUpper(segment.RepetitionComponent(repetition, 5))] && isCNP(id) {
+			return id
+		}
+	}
+	for _, field := range []int{2, 19} {
+		if id := segment.Value(field); isCNP(id) {
+			return id
+		}
+	}
+	return ""
+}
+
+// patientName is the first name of PID-5 as given name and family name
+func patientName(segment *Segment) string {
+	return strings.TrimSpace(segment.Component(5, 2) + " " + segment.Component(5, 1))
+}
+
+// observationValue reads OBX-5 by the value type of OBX-2: coded values by their text, structured numbers with their
+// comparator, and text values with their repetitions as lines
+func observationValue(segment *Segment) string {
+	repetitions := segment.Repetitions(5)
+	values := make([]string, 0, len(repetitions))
+	for _, repetition := range repetitions {
+		var value string
+		switch strings.ToUpper(segment.Value(2)) {
+		case "CE", "CWE", "CNE":
+			value = firstNonEmpty(segment.RepetitionComponent(repetition, 2), segment.RepetitionComponent(repetition, 1))
+		case "SN":
+			for c := 1; c <= 4; c++ {
+				value += segment.RepetitionComponent(repetition, c)
+			}
+		default:
+			parts := []string{}
+			for _, component := range strings.Split(repetition, string(segment.delims.component)) {
+				if component != "" {
+					parts = append(parts, segment.Unescape(component))
+				}
+			}
+			value = strings.Join(parts, " ")
+		}
+		values = append(values, value)
+	}
+
+	separator := ", "
+	if valueType := strings.ToUpper(segment.Value(2)); valueType == "FT" || valueType == "TX" {
+		separator = "\n"
+	}
+	return strings.Join(values, separator)
+}
+
+// repetitionValues are the first components of the repetitions of a field that are not empty
+func repetitionValues(segment *Segment, n int) []string {
+	values := []string{}
+	for _, repetition := range segment.Repetitions(n) {
+		if value := segment.RepetitionComponent(repetition, 1); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// parseTime reads an HL7 date and time, YYYY[MM[DD[HH[MM[SS[.S]]]]]] with an optional offset
+func parseTime(value string, location *time.Location) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	offset := ""
+	if i := strings.IndexAny(value, "+-"); i >= 0 {
+		value, offset = value[:i], value[i:]
+	}
+	if i := strings.IndexByte(value, '.'); i >= 0 {
+		value = value[:i]
+	}
+
+	layouts := map[int]string{4: "2006", 6: "200601", 8: "20060102", 10: "2006010215", 12: "200601021504", 14: "20060102150405"}
+	layout, ok := layouts[len(value)]
+	if !ok {
+		return nil, fmt.Errorf("invalid time %q", value)
+	}
+
+	if offset != "" {
+		if len(offset) != 5 {
+			return nil, fmt.Errorf("invalid time offset %q", offset)
+		}
+		hours, errHours := strconv.Atoi(offset[1:3])
+		minutes, errMinutes := strconv.Atoi(offset[3:5])
+		if errHours != nil || errMinutes != nil {
+			return nil, fmt.Errorf("invalid time offset %q", offset)
+		}
+		seconds := hours*3600 + minutes*60
+		if offset[0] == '-' {
+			seconds = -seconds
+		}
+		location = time.FixedZone(offset, seconds)
+	}
+
+	parsed, err := time.ParseInLocation(layout, value, location)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time %q", value)
+	}
+	parsed = parsed.UTC()
+	return &parsed, nil
+}
+
+// isCNP tells whether the value is a Romanian personal numeric code with a valid check digit
+func isCNP(value string) bool {
+	if len(value) != 13 {
+		return false
+	}
+	sum := 0
+	for i := 0; i < 13; i++ {
+		if value[i] < '0' || value[i] > '9' {
+			return false
+		}
+		if i < 12 {
+			sum += int(value[i]-'0') * int(cnpWeights[i]-'0')
+		}
+	}
+	check := sum % 11
+	if check == 10 {
+		check = 1
+	}
+	return int(value[12]-'0') == check
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
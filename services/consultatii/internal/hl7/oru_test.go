@@ -0,0 +1,235 @@
+package hl7
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// oruHeader starts every ORU^R01 message of the tests
+const oruHeader = "MSH|^~\\&|LIS|Synevo|POS|Clinic|20240301120000||ORU^R01|MSG0001|P|2.5"
+
+func parseTestMessage(t *testing.T, segments ...string) *Message {
+	t.Helper()
+	message, err := Parse(strings.Join(segments, "\r"))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	return message
+}
+
+func TestParseORUMalformed(t *testing.T) {
+	tests := []struct {
+		name     string
+		segments []string
+	}{
+		{
+			name:     "not an ORU^R01",
+			segments: []string{"MSH|^~\\&|LIS|Synevo|POS|Clinic|20240301120000||ADT^A01|MSG0001|P|2.5", "PID|1"},
+		},
+		{
+			name:     "no order",
+			segments: []string{oruHeader, "PID|1||1800101221144"},
+		},
+		{
+			name:     "observation before any order",
+			segments: []string{oruHeader, "PID|1||1800101221144", "OBX|1|NM|GLU^Glucose||95|mg/dL"},
+		},
+		{
+			name:     "invalid observation time",
+			segments: []string{oruHeader, "PID|1||1800101221144", "OBR|1|INV-1||GLU^Glucose|||2024-03-01"},
+		},
+		{
+			name:     "invalid report time offset",
+			segments: []string{oruHeader, "PID|1||1800101221144", "OBR|1|INV-1||GLU^Glucose||||||||||||||||||202403011200+2"},
+		},
+	}
+
+	location := time.UTC
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if oru, err := ParseORU(parseTestMessage(t, tt.segments...), location); err == nil {
+				t.Errorf("ParseORU() = %+v, want an error", oru)
+			}
+		})
+	}
+}
+
+func TestParseORUMultipleObservations(t *testing.T) {
+	message := parseTestMessage(t,
+		oruHeader,
+		"PID|1||123^^^LIS^MR~1800101221144^^^^CNP||Popescu^Ion",
+		"ORC|RE|INV-1",
+		"OBR|1|||CBC^Complete blood count|||20240301083000+0200|||||||||||||||20240301110000|||F",
+		"OBX|1|NM|HGB^Hemoglobin||13.5|g/dL|12-16|N|||F",
+		"OBX|2|NM|WBC^Leukocytes||11.2|10\\S\\3/uL|4-10|H~A|||F",
+		"OBX|3|SN|PLT^Platelets||<^150|10\\S\\3/uL|150-400|L|||F",
+		"OBX|4|CWE|ABO^Blood group||A^Group A||||||F",
+		"OBX|5|FT|COM^Comment||first line~second line||||||F",
+		"OBX|6|NM|RET^Reticulocytes||||||||X",
+		"OBX|7|NM|OLD^Removed||1||||||D",
+		"NTE|1||Sample slightly hemolyzed",
+		"PID|2||2851231401230^^^^NN||Ionescu^Maria",
+		"OBR|1|INV-2|LAB-77|GLU^Glucose|||20240301||||||||||||||||||P",
+		"OBX|1|NM|GLU^Glucose||95|mg/dL|70-100|N|||P",
+	)
+
+	oru, err := ParseORU(message, time.UTC)
+	if err != nil {
+		t.Fatalf("ParseORU() error = %v", err)
+	}
+	if oru.ControlID != "MSG0001" || oru.SendingFacility != "Synevo" {
+		t.Errorf("ParseORU() header = %q from %q", oru.ControlID, oru.SendingFacility)
+	}
+	if len(oru.Orders) != 2 {
+		t.Fatalf("ParseORU() read %d orders, want 2", len(oru.Orders))
+	}
+
+	cbc := oru.Orders[0]
+	if cbc.Patient != (Patient{CNP: "1800101221144", Name: "Ion Popescu"}) {
+		t.Errorf("first order patient = %+v", cbc.Patient)
+	}
+	if cbc.PlacerOrderID != "INV-1" {
+		t.Errorf("first order placer = %q, want the ORC placer INV-1", cbc.PlacerOrderID)
+	}
+	if cbc.Service != "Complete blood count" || cbc.ResultStatus != ResultStatusFinal {
+		t.Errorf("first order = %q with status %q", cbc.Service, cbc.ResultStatus)
+	}
+	if want := time.Date(2024, 3, 1, 6, 30, 0, 0, time.UTC); cbc.ObservedAt == nil || !cbc.ObservedAt.Equal(want) {
+		t.Errorf("first order observed at %v, want %v", cbc.ObservedAt, want)
+	}
+	if want := time.Date(2024, 3, 1, 11, 0, 0, 0, time.UTC); cbc.ReportedAt == nil || !cbc.ReportedAt.Equal(want) {
+		t.Errorf("first order reported at %v, want %v", cbc.ReportedAt, want)
+	}
+
+	observations := []struct {
+		name   string
+		value  string
+		units  string
+		flags  string
+		status string
+	}{
+		{name: "Hemoglobin", value: "13.5", units: "g/dL", flags: "N", status: "F"},
+		{name: "Leukocytes", value: "11.2", units: "10^3/uL", flags: "H,A", status: "F"},
+		{name: "Platelets", value: "<150", units: "10^3/uL", flags: "L", status: "F"},
+		{name: "Blood group", value: "Group A", status: "F"},
+		{name: "Comment", value: "first line\nsecond line", status: "F"},
+		{name: "Reticulocytes", status: "X"},
+		{name: "Removed", value: "1", status: "D"},
+	}
+	if len(cbc.Observations) != len(observations) {
+		t.Fatalf("first order has %d observations, want %d", len(cbc.Observations), len(observations))
+	}
+	for i, want := range observations {
+		got := cbc.Observations[i]
+		if got.Name != want.name || got.Value != want.value || got.Units != want.units || got.AbnormalFlags != want.flags || got.Status != want.status {
+			t.Errorf("observation %d = %+v, want %+v", i+1, got, want)
+		}
+	}
+	if len(cbc.Notes) != 1 || cbc.Notes[0] != "Sample slightly hemolyzed" {
+		t.Errorf("first order notes = %q", cbc.Notes)
+	}
+
+	glucose := oru.Orders[1]
+	if glucose.Patient != (Patient{CNP: "2851231401230", Name: "Maria Ionescu"}) {
+		t.Errorf("second order patient = %+v", glucose.Patient)
+	}
+	if glucose.PlacerOrderID != "INV-2" || glucose.FillerOrderID != "LAB-77" {
+		t.Errorf("second order = placer %q, filler %q", glucose.PlacerOrderID, glucose.FillerOrderID)
+	}
+	if len(glucose.Observations) != 1 || glucose.Notes != nil {
+		t.Errorf("second order has %d observations and notes %q, the first order's do not carry over", len(glucose.Observations), glucose.Notes)
+	}
+	if IsFinal(glucose.ResultStatus) {
+		t.Errorf("IsFinal(%q) = true for a preliminary result", glucose.ResultStatus)
+	}
+}
+
+func TestPatientCNP(t *testing.T) {
+	tests := []struct {
+		name string
+		pid  string
+		want string
+	}{
+		{name: "typed CNP among the identifiers", pid: "PID|1||123^^^LIS^MR~1800101221144^^^^CNP", want: "1800101221144"},
+		{name: "untyped identifier", pid: "PID|1||1800101221144", want: "1800101221144"},
+		{name: "national identifier type", pid: "PID|1||1800101221144^^^^NNROU", want: "1800101221144"},
+		{name: "medical record number with the length of a CNP", pid: "PID|1||1800101221144^^^^MR", want: ""},
+		{name: "wrong check digit", pid: "PID|1||1800101221145", want: ""},
+		{name: "older PID-2", pid: "PID|1|1800101221144|123", want: "1800101221144"},
+		{name: "older PID-19", pid: "PID|1||123||||||||||||||||1800101221144", want: "1800101221144"},
+		{name: "none", pid: "PID|1||123", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			message := parseTestMessage(t, oruHeader, tt.pid)
+			if got := patientCNP(&message.Segments[1]); got != tt.want {
+				t.Errorf("patientCNP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResultText(t *testing.T) {
+	order := Order{
+		Observations: []Observation{
+			{Name: "Hemoglobin", Value: "13.5", Units: "g/dL", ReferenceRange: "12-16", AbnormalFlags: "N", Status: "F"},
+			{Name: "Leukocytes", Value: "11.2", Units: "10^3/uL", ReferenceRange: "4-10", AbnormalFlags: "H", Status: "F"},
+			{Name: "Reticulocytes", Status: "X"},
+			{Name: "Removed", Value: "1", Status: "D"},
+			{Value: "unnamed"},
+		},
+		Notes: []string{"Sample slightly hemolyzed"},
+	}
+
+	want := "Hemoglobin: 13.5 g/dL (ref. 12-16)\n" +
+		"Leukocytes: 11.2 10^3/uL (ref. 4-10) [H]\n" +
+		"Reticulocytes: not performed\n" +
+		"unnamed\n" +
+		"Note: Sample slightly hemolyzed"
+	if got := order.ResultText(); got != want {
+		t.Errorf("ResultText() = %q, want %q", got, want)
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	bucharest := time.FixedZone("EET", 2*3600)
+
+	tests := []struct {
+		name    string
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{name: "day", value: "20240301", want: time.Date(2024, 2, 29, 22, 0, 0, 0, time.UTC)},
+		{name: "minutes", value: "202403011230", want: time.Date(2024, 3, 1, 10, 30, 0, 0, time.UTC)},
+		{name: "fractional seconds", value: "20240301123045.123", want: time.Date(2024, 3, 1, 10, 30, 45, 0, time.UTC)},
+		{name: "offset", value: "20240301123045-0500", want: time.Date(2024, 3, 1, 17, 30, 45, 0, time.UTC)},
+		{name: "odd length", value: "2024030", wantErr: true},
+		{name: "short offset", value: "202403011230+02", wantErr: true},
+		{name: "invalid month", value: "20241301", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseTime(tt.value, bucharest)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parseTime(%q) = %v, want an error", tt.value, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseTime(%q) error = %v", tt.value, err)
+			}
+			if got == nil || !got.Equal(tt.want) {
+				t.Errorf("parseTime(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+
+	if got, err := parseTime("", bucharest); got != nil || err != nil {
+		t.Errorf("parseTime(\"\") = %v, %v, want no time", got, err)
+	}
+}
@@ -0,0 +1,147 @@
+package icd10
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/mihnea1711/POS_Project/services/consultatii/pkg/config"
+)
+
+// codePattern matches an ICD-10 category with an optional subcategory, such as I10 or J20.9
+var codePattern = regexp.MustCompile(`^[A-Z][0-9]{2}(\.[0-9A-Z]{1,4})?$`)
+
+// Entry is a code of the catalog with its description
+type Entry struct {
+	Code        string `json:"code"`
+	Description string `json:"description"`
+}
+
+// Catalog is the local list of ICD-10 codes the diagnoses are checked against. It is read once at startup and only
+// read afterwards, so it is safe for concurrent use.
+type Catalog struct {
+	entries []Entry
+	byCode  map[string]Entry
+}
+
+// NewCatalog loads the catalog from the bundled CSV file: a code,description header followed by one code per line
+func NewCatalog(conf config.ICD10Config) (*Catalog, error) {
+	file, err := os.Open(conf.Catalog)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open ICD-10 catalog: %w", err)
+	}
+	defer file.Close()
+
+	catalog, err := readCatalog(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ICD-10 catalog %s: %w", conf.Catalog, err)
+	}
+
+	log.Printf("[CONSULTATION] ICD-10 catalog loaded with %d codes from %s", len(catalog.entries), conf.Catalog)
+	return catalog, nil
+}
+
+func readCatalog(r io.Reader) (*Catalog, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 2
+
+	// Skip the header
+	if _, err := reader.Read(); err != nil {
+		return nil, err
+	}
+
+	catalog := &Catalog{byCode: make(map[string]Entry)}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		entry := Entry{Code: NormalizeCode(record[0]), Description: strings.TrimSpace(record[1])}
+		if !IsCode(entry.Code) || entry.Description == "" {
+			line, _ := reader.FieldPos(0)
+			return nil, fmt.Errorf("invalid entry on line %d: %q", line, record)
+		}
+		if _, ok := catalog.byCode[entry.Code]; ok {
+			return nil, fmt.Errorf("code %s is listed twice", entry.Code)
+		}
+		catalog.byCode[entry.Code] = entry
+		catalog.entries = append(catalog.entries, entry)
+	}
+
+	sort.Slice(catalog.entries, func(i, j int) bool { return catalog.entries[i].Code < catalog.entries[j].Code })
+	return catalog, nil
+}
+
+// Lookup returns the entry of a code, in any case and with or without the dot
+func (c *Catalog) Lookup(code string) (Entry, bool) {
+	entry, ok := c.byCode[NormalizeCode(code)]
+	return entry, ok
+}
+
+// Search returns up to limit entries for an autocomplete. Codes starting with the query come first, in code order,
+// followed by the codes whose description contains every word of it.
+func (c *Catalog) Search(query string, limit int) []Entry {
+	query = strings.TrimSpace(query)
+	results := []Entry{}
+	if query == "" || limit <= 0 {
+		return results
+	}
+
+	codePrefix := NormalizeCode(query)
+	words := strings.Fields(strings.ToLower(query))
+
+	var byDescription []Entry
+	for _, entry := range c.entries {
+		if strings.HasPrefix(entry.Code, codePrefix) {
+			results = append(results, entry)
+			if len(results) == limit {
+				return results
+			}
+			continue
+		}
+		if containsAll(strings.ToLower(entry.Description), words) {
+			byDescription = append(byDescription, entry)
+		}
+	}
+
+	for _, entry := range byDescription {
+		if len(results) == limit {
+			break
+		}
+		results = append(results, entry)
+	}
+	return results
+}
+
+// NormalizeCode writes a code the way the catalog does, upper case with a dot after the category
+func NormalizeCode(code string) string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	code = strings.ReplaceAll(code, ".", "")
+	if len(code) > 3 {
+		code = code[:3] + "." + code[3:]
+	}
+	return code
+}
+
+// IsCode tells whether a normalized code has the form of an ICD-10 code
+func IsCode(code string) bool {
+	return codePattern.MatchString(code)
+}
+
+func containsAll(text string, words []string) bool {
+	for _, word := range words {
+		if !strings.Contains(text, word) {
+			return false
+		}
+	}
+	return true
+}
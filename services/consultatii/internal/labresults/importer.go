@@ -0,0 +1,415 @@
+package labresults
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	// The times of the lab are read in its time zone without relying on the zone database of the host
+	_ "time/tzdata"
+
+	"github.com/mihnea1711/POS_Project/services/consultatii/internal/database"
+	"github.com/mihnea1711/POS_Project/services/consultatii/internal/hl7"
+	"github.com/mihnea1711/POS_Project/services/consultatii/internal/models"
+	"github.com/mihnea1711/POS_Project/services/consultatii/pkg/config"
+	"github.com/mihnea1711/POS_Project/services/consultatii/pkg/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ErrNoMessages is returned for a file or a request that holds no HL7 message
+var ErrNoMessages = errors.New("no HL7 message found")
+
+// The errors of the review of a queued result
+var (
+	ErrItemReviewed          = errors.New("the lab result was already reviewed")
+	ErrNothingToRecord       = errors.New("the lab result holds no result to record, discard it instead")
+	ErrInvestigationNotFound = errors.New("investigation not found in the consultation")
+	ErrResultRejected        = errors.New("the investigation does not take the result")
+)
+
+// Importer records the results the lab sends as HL7 v2 ORU^R01 messages. A result is matched to the patient by the
+// CNP and to the investigation by the placer order number, which is the ID of the investigation. A result that cannot
+// be matched is queued for manual review. Messages are posted to the module or dropped as files in a directory.
+type Importer struct {
+	dbConn        database.Database
+	patients      PatientDirectory
+	location      *time.Location
+	dropDirectory string
+	interval      time.Duration
+	maxSize       int64
+}
+
+// labResult is the result of an order, read from a message or from a queued item
+type labResult struct {
+	text       string
+	status     string
+	observedAt *time.Time
+	reportedAt *time.Time
+}
+
+func NewImporter(dbConn database.Database, labConfig config.LabResultsConfig) (*Importer, error) {
+	location, err := time.LoadLocation(labConfig.TimeZone)
+	if err != nil {
+		return nil, fmt.Errorf("error loading the time zone of the lab: %w", err)
+	}
+
+	interval := time.Duration(labConfig.PollIntervalSeconds) * time.Second
+	if interval <= 0 {
+		interval = utils.DEFAULT_LAB_POLL_INTERVAL * time.Second
+	}
+	maxMessageKB := labConfig.MaxMessageKB
+	if maxMessageKB <= 0 {
+		maxMessageKB = utils.DEFAULT_LAB_MAX_MESSAGE_KB
+	}
+
+	return &Importer{
+		dbConn:        dbConn,
+		patients:      newPatientModule(labConfig.PatientsURL),
+		location:      location,
+		dropDirectory: labConfig.DropDirectory,
+		interval:      interval,
+		maxSize:       int64(maxMessageKB) << 10,
+	}, nil
+}
+
+// MaxSize is the size in bytes of the largest file or request imported
+func (i *Importer) MaxSize() int64 {
+	return i.maxSize
+}
+
+// Import records the results of the messages in the data and queues the ones that cannot be matched. An error means
+// the messages could not be gone through, such as when the patient module is down, and the data should be imported
+// again later: the results already recorded are then found as duplicates and the queued ones are not queued twice.
+func (i *Importer) Import(ctx context.Context, data []byte, source string, author *int) (*models.LabImportReport, error) {
+	texts := hl7.Split(data)
+	if len(texts) == 0 {
+		return nil, ErrNoMessages
+	}
+
+	report := &models.LabImportReport{Source: source, Messages: len(texts), Outcomes: []models.LabImportOutcome{}}
+	receivedAt := time.Now().UTC()
+	for _, text := range texts {
+		outcomes, err := i.importMessage(ctx, text, source, author, receivedAt)
+		if err != nil {
+			return nil, err
+		}
+		report.Outcomes = append(report.Outcomes, outcomes...)
+	}
+
+	log.Printf("[CONSULTATION] Imported %d lab messages from %s with %d orders", report.Messages, source, len(report.Outcomes))
+	return report, nil
+}
+
+func (i *Importer) importMessage(ctx context.Context, text, source string, author *int, receivedAt time.Time) ([]models.LabImportOutcome, error) {
+	// The same message sent again is recognized by its content
+	sum := sha256.Sum256([]byte(text))
+	fingerprint := hex.EncodeToString(sum[:])
+	item := models.LabReconciliationItem{
+		Status:     utils.LAB_RECONCILIATION_STATUS_OPEN,
+		Source:     source,
+		Message:    text,
+		ReceivedAt: receivedAt,
+	}
+
+	message, err := hl7.Parse(text)
+	var oru *hl7.ORU
+	if err == nil {
+		item.ControlID = message.ControlID()
+		item.SendingFacility = message.Header().Value(4)
+		oru, err = hl7.ParseORU(message, i.location)
+	}
+	if err != nil {
+		log.Printf("[CONSULTATION] Unreadable lab message from %s: %v", source, err)
+		item.Fingerprint = fingerprint
+		item.Reason = utils.LAB_REASON_UNREADABLE
+		item.Detail = err.Error()
+		outcome, err := i.queue(ctx, &item, models.LabImportOutcome{ControlID: item.ControlID})
+		if err != nil {
+			return nil, err
+		}
+		return []models.LabImportOutcome{outcome}, nil
+	}
+
+	outcomes := make([]models.LabImportOutcome, 0, len(oru.Orders))
+	for n := range oru.Orders {
+		order := &oru.Orders[n]
+		orderItem := item
+		orderItem.Fingerprint = fmt.Sprintf("%s:%d", fingerprint, n)
+		orderItem.PatientCNP = order.Patient.CNP
+		orderItem.PatientName = order.Patient.Name
+		orderItem.PlacerOrderID = order.PlacerOrderID
+		orderItem.FillerOrderID = order.FillerOrderID
+		orderItem.Service = order.Service
+		orderItem.ResultStatus = order.ResultStatus
+		orderItem.Result = order.ResultText()
+		orderItem.ObservedAt = order.ObservedAt
+		orderItem.ReportedAt = order.ReportedAt
+
+		outcome, err := i.importOrder(ctx, oru.ControlID, order, &orderItem, author)
+		if err != nil {
+			return nil, err
+		}
+		outcomes = append(outcomes, outcome)
+	}
+	return outcomes, nil
+}
+
+// importOrder records the result of an order on its investigation, or queues it with the reason it cannot be
+func (i *Importer) importOrder(ctx context.Context, controlID string, order *hl7.Order, item *models.LabReconciliationItem, author *int) (models.LabImportOutcome, error) {
+	outcome := models.LabImportOutcome{ControlID: controlID, PlacerOrderID: order.PlacerOrderID}
+	queue := func(reason, detail string) (models.LabImportOutcome, error) {
+		item.Reason = reason
+		item.Detail = detail
+		return i.queue(ctx, item, outcome)
+	}
+
+	if order.Patient.CNP == "" {
+		return queue(utils.LAB_REASON_PATIENT_UNIDENTIFIED, "no identifier of the patient is a valid CNP")
+	}
+	patients, err := i.patients.FindPatientsByCNP(ctx, order.Patient.CNP)
+	if err != nil {
+		return outcome, fmt.Errorf("looking up the patient of order %s: %w", order.PlacerOrderID, err)
+	}
+	switch {
+	case len(patients) == 0:
+		return queue(utils.LAB_REASON_PATIENT_NOT_FOUND, "no patient has the CNP")
+	case len(patients) > 1:
+		return queue(utils.LAB_REASON_PATIENT_AMBIGUOUS, fmt.Sprintf("patients %v share the CNP", patients))
+	}
+	patientID := patients[0]
+	item.IDPatient = &patientID
+
+	investigationID, err := primitive.ObjectIDFromHex(order.PlacerOrderID)
+	if err != nil {
+		return queue(utils.LAB_REASON_ORDER_UNIDENTIFIED, fmt.Sprintf("placer order number %q is not an investigation ID", order.PlacerOrderID))
+	}
+
+	result := labResult{text: item.Result, status: order.ResultStatus, observedAt: order.ObservedAt, reportedAt: order.ReportedAt}
+	for attempt := 1; ; attempt++ {
+		consultation, err := i.dbConn.FetchConsultationByInvestigationID(ctx, investigationID)
+		if err == mongo.ErrNoDocuments {
+			return queue(utils.LAB_REASON_ORDER_NOT_FOUND, "no investigation was ordered under the placer order number")
+		}
+		if err != nil {
+			return outcome, err
+		}
+		item.IDConsultation = &consultation.IDConsultation
+		item.IDInvestigation = &investigationID
+		outcome.IDConsultation = &consultation.IDConsultation
+		outcome.IDInvestigation = &investigationID
+
+		if consultation.IDPatient != patientID {
+			return queue(utils.LAB_REASON_PATIENT_MISMATCH, fmt.Sprintf("the investigation was ordered for patient %d, the CNP belongs to patient %d", consultation.IDPatient, patientID))
+		}
+
+		investigation := findInvestigation(consultation, investigationID)
+		recorded, reason, detail := recordResult(investigation, result, time.Now().UTC())
+		if reason != "" {
+			return queue(reason, detail)
+		}
+		outcome.Outcome = recorded
+		if recorded == utils.LAB_OUTCOME_DUPLICATE || recorded == utils.LAB_OUTCOME_UNCHANGED {
+			return outcome, nil
+		}
+
+		revisionReason := fmt.Sprintf("result of investigation %s %s from lab message %s", investigation.Name, recorded, controlID)
+		if recorded == utils.LAB_OUTCOME_PROGRESSED {
+			revisionReason = fmt.Sprintf("investigation %s in progress according to lab message %s", investigation.Name, controlID)
+		}
+
+		// A consultation changed since it was read is read again, a deleted one is then not found
+		matched, err := i.dbConn.UpdateConsultationByID(ctx, consultation, author, revisionReason)
+		if (err == database.ErrVersionConflict || (err == nil && matched == 0)) && attempt < utils.LAB_SAVE_ATTEMPTS {
+			continue
+		}
+		if err != nil {
+			return outcome, err
+		}
+		if matched == 0 {
+			return outcome, fmt.Errorf("consultation %s kept changing while the result of order %s was saved", consultation.IDConsultation.Hex(), order.PlacerOrderID)
+		}
+
+		log.Printf("[CONSULTATION] Lab result of investigation %s %s from message %s", investigationID.Hex(), recorded, controlID)
+		return outcome, nil
+	}
+}
+
+// queue saves an item for review, an item already queued by an earlier delivery of the message is left as it is
+func (i *Importer) queue(ctx context.Context, item *models.LabReconciliationItem, outcome models.LabImportOutcome) (models.LabImportOutcome, error) {
+	created, err := i.dbConn.SaveLabReconciliationItem(ctx, item)
+	if err != nil {
+		return outcome, err
+	}
+
+	outcome.Outcome = utils.LAB_OUTCOME_QUEUED
+	outcome.Reason = item.Reason
+	if created {
+		outcome.IDReconciliation = &item.IDItem
+	}
+	log.Printf("[CONSULTATION] Lab result of order %q queued for review: %s", item.PlacerOrderID, item.Reason)
+	return outcome, nil
+}
+
+// Resolve records a queued result on the investigation the reviewer chose, whatever the patient and the order number
+// the lab sent, and closes the item
+func (i *Importer) Resolve(ctx context.Context, itemID primitive.ObjectID, resolution *models.LabResolution, author *int) (*models.LabReconciliationItem, error) {
+	item, err := i.openItem(ctx, itemID)
+	if err != nil {
+		return nil, err
+	}
+	if item.Result == "" || !hl7.IsFinal(item.ResultStatus) {
+		return nil, ErrNothingToRecord
+	}
+
+	consultation, err := i.dbConn.FetchConsultationByID(ctx, resolution.IDConsultation)
+	if err != nil {
+		return nil, err
+	}
+	investigation := findInvestigation(consultation, resolution.IDInvestigation)
+	if investigation == nil {
+		return nil, ErrInvestigationNotFound
+	}
+
+	result := labResult{text: item.Result, status: item.ResultStatus, observedAt: item.ObservedAt, reportedAt: item.ReportedAt}
+	recorded, reason, detail := recordResult(investigation, result, time.Now().UTC())
+	if reason != "" {
+		return nil, fmt.Errorf("%w: %s", ErrResultRejected, detail)
+	}
+	if recorded != utils.LAB_OUTCOME_DUPLICATE {
+		revisionReason := fmt.Sprintf("result of investigation %s %s from reviewed lab result %s", investigation.Name, recorded, item.IDItem.Hex())
+		matched, err := i.dbConn.UpdateConsultationByID(ctx, consultation, author, revisionReason)
+		if err != nil {
+			return nil, err
+		}
+		if matched == 0 {
+			return nil, mongo.ErrNoDocuments
+		}
+	}
+
+	now := time.Now().UTC()
+	item.Status = utils.LAB_RECONCILIATION_STATUS_RESOLVED
+	item.IDConsultation = &consultation.IDConsultation
+	item.IDInvestigation = &investigation.IDInvestigation
+	item.ReviewedAt = &now
+	item.ReviewedBy = author
+	item.ReviewNote = resolution.Note
+	if err := i.closeItem(ctx, item); err != nil {
+		return nil, err
+	}
+
+	log.Printf("[CONSULTATION] Lab result %s %s on investigation %s", item.IDItem.Hex(), recorded, investigation.IDInvestigation.Hex())
+	return item, nil
+}
+
+// Discard closes a queued result without recording it
+func (i *Importer) Discard(ctx context.Context, itemID primitive.ObjectID, reason string, author *int) (*models.LabReconciliationItem, error) {
+	item, err := i.openItem(ctx, itemID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	item.Status = utils.LAB_RECONCILIATION_STATUS_DISCARDED
+	item.ReviewedAt = &now
+	item.ReviewedBy = author
+	item.ReviewNote = reason
+	if err := i.closeItem(ctx, item); err != nil {
+		return nil, err
+	}
+
+	log.Printf("[CONSULTATION] Lab result %s discarded", item.IDItem.Hex())
+	return item, nil
+}
+
+// openItem reads a queued result that still waits for its review
+func (i *Importer) openItem(ctx context.Context, itemID primitive.ObjectID) (*models.LabReconciliationItem, error) {
+	item, err := i.dbConn.FetchLabReconciliationItemByID(ctx, itemID)
+	if err != nil {
+		return nil, err
+	}
+	if item.Status != utils.LAB_RECONCILIATION_STATUS_OPEN {
+		return nil, ErrItemReviewed
+	}
+	return item, nil
+}
+
+func (i *Importer) closeItem(ctx context.Context, item *models.LabReconciliationItem) error {
+	closed, err := i.dbConn.CloseLabReconciliationItem(ctx, item)
+	if err != nil {
+		return err
+	}
+	if closed == 0 {
+		return ErrItemReviewed
+	}
+	return nil
+}
+
+// recordResult applies a result of the lab to its investigation. It returns what became of it, or the reason and
+// details when the investigation does not take it. A preliminary result only moves the investigation in progress, a
+// final one is recorded unless the investigation has another result and the lab did not send it as a correction.
+func recordResult(investigation *models.Investigation, result labResult, now time.Time) (string, string, string) {
+	if result.status == hl7.ResultStatusNotPerformed {
+		return "", utils.LAB_REASON_ORDER_NOT_PERFORMED, "the lab reports the investigation could not be performed"
+	}
+	if investigation.Status == utils.INVESTIGATION_STATUS_CANCELED {
+		return "", utils.LAB_REASON_ORDER_CANCELED, "the investigation was canceled"
+	}
+
+	if !hl7.IsFinal(result.status) {
+		if !utils.CanTransitionInvestigation(investigation.Status, utils.INVESTIGATION_STATUS_IN_PROGRESS) {
+			return utils.LAB_OUTCOME_UNCHANGED, "", ""
+		}
+		markSampleCollected(investigation, result.observedAt, now)
+		investigation.Status = utils.INVESTIGATION_STATUS_IN_PROGRESS
+		return utils.LAB_OUTCOME_PROGRESSED, "", ""
+	}
+
+	if result.text == "" {
+		return "", utils.LAB_REASON_NO_OBSERVATIONS, "the message holds no observation for the order"
+	}
+
+	outcome := utils.LAB_OUTCOME_RECORDED
+	if investigation.Status == utils.INVESTIGATION_STATUS_RESULTED {
+		switch {
+		case investigation.Result == result.text:
+			return utils.LAB_OUTCOME_DUPLICATE, "", ""
+		case result.status != hl7.ResultStatusCorrected:
+			return "", utils.LAB_REASON_ALREADY_RESULTED, "the investigation has another result and the lab did not send this one as a correction"
+		}
+		outcome = utils.LAB_OUTCOME_CORRECTED
+	}
+
+	markSampleCollected(investigation, result.observedAt, now)
+	resultedAt := now
+	if result.reportedAt != nil && !result.reportedAt.After(now) && !result.reportedAt.Before(investigation.OrderedAt) {
+		resultedAt = *result.reportedAt
+	}
+	investigation.Result = result.text
+	investigation.Status = utils.INVESTIGATION_STATUS_RESULTED
+	investigation.ResultedAt = &resultedAt
+	return outcome, "", ""
+}
+
+// markSampleCollected keeps the time the lab collected the sample, unless it was already recorded
+func markSampleCollected(investigation *models.Investigation, observedAt *time.Time, now time.Time) {
+	if investigation.SampleCollectedAt != nil || observedAt == nil || observedAt.After(now) || observedAt.Before(investigation.OrderedAt) {
+		return
+	}
+	collectedAt := *observedAt
+	investigation.SampleCollectedAt = &collectedAt
+	investigation.ExpectedCompletion = utils.ExpectedInvestigationCompletion(investigation)
+}
+
+func findInvestigation(consultation *models.Consultation, investigationID primitive.ObjectID) *models.Investigation {
+	for i := range consultation.Investigations {
+		if consultation.Investigations[i].IDInvestigation == investigationID {
+			return &consultation.Investigations[i]
+		}
+	}
+	return nil
+}
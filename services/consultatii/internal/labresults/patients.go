@@ -0,0 +1,71 @@
+package labresults
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/mihnea1711/POS_Project/services/consultatii/pkg/utils"
+)
+
+// PatientDirectory finds the patients a CNP belongs to. The CNP is unique, more than one patient means duplicate
+// records that were not merged yet.
+type PatientDirectory interface {
+	FindPatientsByCNP(ctx context.Context, cnp string) ([]int, error)
+}
+
+// patientModule looks the patients up in the patient module, by its search endpoint
+type patientModule struct {
+	baseURL string
+	client  *http.Client
+}
+
+func newPatientModule(baseURL string) *patientModule {
+	return &patientModule{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client:  &http.Client{Timeout: utils.LAB_PATIENT_LOOKUP_TIMEOUT * time.Second},
+	}
+}
+
+func (p *patientModule) FindPatientsByCNP(ctx context.Context, cnp string) ([]int, error) {
+	query := url.Values{}
+	query.Set("cnp", cnp)
+	query.Set(utils.QUERY_LIMIT, fmt.Sprint(utils.MAX_PAGINATION_LIMIT))
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, p.baseURL+utils.LAB_PATIENT_SEARCH_ENDPOINT+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	response, err := p.client.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("patient module unreachable: %w", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("patient module answered the search with status %d", response.StatusCode)
+	}
+
+	var body struct {
+		Payload []struct {
+			IDPatient int    `json:"idPatient"`
+			CNP       string `json:"cnp"`
+		} `json:"payload"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("decoding the patient search: %w", err)
+	}
+
+	// The search also ranks near matches, only the exact CNP counts
+	patients := []int{}
+	for _, patient := range body.Payload {
+		if patient.CNP == cnp {
+			patients = append(patients, patient.IDPatient)
+		}
+	}
+	return patients, nil
+}
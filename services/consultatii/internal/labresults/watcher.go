@@ -0,0 +1,139 @@
+package labresults
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mihnea1711/POS_Project/services/consultatii/pkg/utils"
+)
+
+// Start watches the drop directory until the context is done. Every file dropped there is imported, then moved to
+// processed, or to failed when it holds no message or is too large. A file that cannot be gone through for now is left
+// in place and tried again on the next sweep. Without a drop directory results are only imported over HTTP.
+func (i *Importer) Start(ctx context.Context) {
+	if i.dropDirectory == "" {
+		log.Printf("[CONSULTATION] No lab drop directory configured, lab results are imported over HTTP only")
+		return
+	}
+	for _, directory := range []string{i.dropDirectory, i.processedDirectory(), i.failedDirectory()} {
+		if err := os.MkdirAll(directory, 0o750); err != nil {
+			log.Printf("[CONSULTATION] Error creating lab drop directory %s: %v", directory, err)
+			return
+		}
+	}
+
+	log.Printf("[CONSULTATION] Watching %s for lab results every %s", i.dropDirectory, i.interval)
+	ticker := time.NewTicker(i.interval)
+	defer ticker.Stop()
+
+	for {
+		i.sweep(ctx)
+		select {
+		case <-ctx.Done():
+			log.Printf("[CONSULTATION] Lab drop watcher stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sweep imports the files of the drop directory, the oldest names first. Files still being written, those modified
+// during the last seconds, wait for the next sweep.
+func (i *Importer) sweep(ctx context.Context) {
+	entries, err := os.ReadDir(i.dropDirectory)
+	if err != nil {
+		log.Printf("[CONSULTATION] Error reading lab drop directory %s: %v", i.dropDirectory, err)
+		return
+	}
+	sort.Slice(entries, func(a, b int) bool { return entries[a].Name() < entries[b].Name() })
+
+	settled := time.Now().Add(-utils.LAB_DROP_SETTLE_TIME * time.Second)
+	for _, entry := range entries {
+		if ctx.Err() != nil {
+			return
+		}
+		if !entry.Type().IsRegular() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || info.ModTime().After(settled) {
+			continue
+		}
+
+		if err := i.importFile(ctx, entry.Name()); err != nil {
+			// The module or the database is down, the remaining files would fail the same way
+			log.Printf("[CONSULTATION] Lab result file %s left for the next sweep: %v", entry.Name(), err)
+			return
+		}
+	}
+}
+
+// importFile imports a dropped file and moves it out of the drop directory. It returns an error only when the file is
+// left in place to be imported again.
+func (i *Importer) importFile(ctx context.Context, name string) error {
+	path := filepath.Join(i.dropDirectory, name)
+	data, err := readLimited(path, i.maxSize)
+	if err != nil {
+		log.Printf("[CONSULTATION] Lab result file %s rejected: %v", name, err)
+		i.moveFile(name, i.failedDirectory())
+		return nil
+	}
+
+	importCtx, cancel := context.WithTimeout(ctx, utils.LAB_IMPORT_TIMEOUT*time.Second)
+	defer cancel()
+
+	report, err := i.Import(importCtx, data, utils.LAB_SOURCE_FILE+":"+name, nil)
+	if errors.Is(err, ErrNoMessages) {
+		log.Printf("[CONSULTATION] Lab result file %s rejected: %v", name, err)
+		i.moveFile(name, i.failedDirectory())
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	log.Printf("[CONSULTATION] Lab result file %s imported: %d messages, %d orders", name, report.Messages, len(report.Outcomes))
+	i.moveFile(name, i.processedDirectory())
+	return nil
+}
+
+// moveFile moves a file of the drop directory, prefixed with the time so files dropped under the same name are kept
+func (i *Importer) moveFile(name string, directory string) {
+	target := filepath.Join(directory, time.Now().UTC().Format("20060102T150405.000000000Z")+"_"+name)
+	if err := os.Rename(filepath.Join(i.dropDirectory, name), target); err != nil {
+		log.Printf("[CONSULTATION] Error moving lab result file %s to %s: %v", name, directory, err)
+	}
+}
+
+func (i *Importer) processedDirectory() string {
+	return filepath.Join(i.dropDirectory, utils.LAB_DROP_PROCESSED_DIR)
+}
+
+func (i *Importer) failedDirectory() string {
+	return filepath.Join(i.dropDirectory, utils.LAB_DROP_FAILED_DIR)
+}
+
+func readLimited(path string, maxSize int64) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > maxSize {
+		return nil, fmt.Errorf("the file is larger than %d bytes", maxSize)
+	}
+	return data, nil
+}
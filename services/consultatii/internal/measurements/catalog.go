@@ -0,0 +1,173 @@
+package measurements
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mihnea1711/POS_Project/services/consultatii/internal/models"
+	"github.com/mihnea1711/POS_Project/services/consultatii/pkg/config"
+	"github.com/mihnea1711/POS_Project/services/consultatii/pkg/utils"
+)
+
+// kind is a type of measurement with the units it is accepted in, each converted to the unit it is kept in, and the
+// number of decimals its values are kept with
+type kind struct {
+	models.MeasurementKind
+	conversions map[string]func(float64) float64
+	decimals    int
+}
+
+// Catalog lists the types of measurements with their units and ranges, for adults at rest. It is built once at startup
+// and only read afterwards, so it is safe for concurrent use.
+type Catalog struct {
+	kinds    []kind
+	byType   map[models.MeasurementType]*kind
+	location *time.Location
+}
+
+// NewCatalog builds the catalog with the time zone the trends are grouped in
+func NewCatalog(conf config.MeasurementsConfig) (*Catalog, error) {
+	location, err := time.LoadLocation(conf.TimeZone)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load measurement time zone %q: %w", conf.TimeZone, err)
+	}
+	// The buckets are cut by the database, which only knows the time zones by name
+	if location == time.Local {
+		return nil, fmt.Errorf("the measurement time zone must be named, not %q", conf.TimeZone)
+	}
+
+	catalog := &Catalog{kinds: builtinKinds(), location: location}
+	catalog.byType = make(map[models.MeasurementType]*kind, len(catalog.kinds))
+	for i := range catalog.kinds {
+		catalog.byType[catalog.kinds[i].Type] = &catalog.kinds[i]
+	}
+
+	log.Printf("[CONSULTATION] Measurement catalog built with %d types, trends grouped in %s", len(catalog.kinds), location)
+	return catalog, nil
+}
+
+// Location is the time zone the days, weeks and months of the trends start in
+func (c *Catalog) Location() *time.Location {
+	return c.location
+}
+
+// Kinds lists the types of measurements by name
+func (c *Catalog) Kinds() []models.MeasurementKind {
+	kinds := make([]models.MeasurementKind, 0, len(c.kinds))
+	for _, k := range c.kinds {
+		kinds = append(kinds, k.MeasurementKind)
+	}
+	sort.Slice(kinds, func(i, j int) bool { return kinds[i].Name < kinds[j].Name })
+	return kinds
+}
+
+// Lookup returns a type of measurement, false when the catalog does not know it
+func (c *Catalog) Lookup(measurementType models.MeasurementType) (models.MeasurementKind, bool) {
+	k, ok := c.byType[measurementType]
+	if !ok {
+		return models.MeasurementKind{}, false
+	}
+	return k.MeasurementKind, true
+}
+
+// Measure converts a reading to the unit of its type and flags each value against its ranges. A reading without a
+// unit is taken in the unit of its type. Every component of the type must be given, and a value outside the plausible
+// range is refused, as it is rather a typing error than a measurement.
+func (c *Catalog) Measure(reading models.MeasurementReading) (string, []models.MeasurementValue, error) {
+	k, ok := c.byType[reading.Type]
+	if !ok {
+		return "", nil, fmt.Errorf("unknown measurement type %q", reading.Type)
+	}
+
+	convert := k.conversions[strings.ToLower(k.Unit)]
+	if reading.Unit != "" {
+		if convert, ok = k.conversions[strings.ToLower(strings.TrimSpace(reading.Unit))]; !ok {
+			return "", nil, fmt.Errorf("%s is not measured in %q, use one of %v", k.Type, reading.Unit, k.Units)
+		}
+	}
+
+	given := reading.Values
+	if len(k.Components) == 1 && reading.Value != nil {
+		given = map[string]float64{k.Components[0].Name: *reading.Value}
+	}
+	for name := range given {
+		if k.component(name) == nil {
+			return "", nil, fmt.Errorf("%s has no %q value", k.Type, name)
+		}
+	}
+
+	values := make([]models.MeasurementValue, 0, len(k.Components))
+	for _, component := range k.Components {
+		raw, ok := given[component.Name]
+		if !ok {
+			return "", nil, fmt.Errorf("the %s value of the %s is required", component.Name, k.Type)
+		}
+		value := round(convert(raw), k.decimals)
+		if math.IsNaN(value) || value < component.Minimum || value > component.Maximum {
+			return "", nil, fmt.Errorf("%s %s of %v %s is outside %v..%v %s and cannot be right", k.Type, component.Name, value, k.Unit, component.Minimum, component.Maximum, k.Unit)
+		}
+		values = append(values, models.MeasurementValue{
+			Component:     component.Name,
+			Value:         value,
+			ReferenceLow:  component.ReferenceLow,
+			ReferenceHigh: component.ReferenceHigh,
+			Flag:          Flag(component, value),
+		})
+	}
+	return k.Unit, values, nil
+}
+
+// Flag places a value against the ranges of its component: critical outside the critical range, low or high outside
+// the reference range, normal otherwise or when the component has no range
+func Flag(component models.MeasurementComponent, value float64) models.MeasurementFlag {
+	switch {
+	case component.CriticalLow != nil && value < *component.CriticalLow:
+		return utils.MEASUREMENT_FLAG_CRITICAL_LOW
+	case component.CriticalHigh != nil && value > *component.CriticalHigh:
+		return utils.MEASUREMENT_FLAG_CRITICAL_HIGH
+	case component.ReferenceLow != nil && value < *component.ReferenceLow:
+		return utils.MEASUREMENT_FLAG_LOW
+	case component.ReferenceHigh != nil && value > *component.ReferenceHigh:
+		return utils.MEASUREMENT_FLAG_HIGH
+	}
+	return utils.MEASUREMENT_FLAG_NORMAL
+}
+
+// WorstFlag is the flag of a measurement, that of its furthest value: critical over high or low, those over normal
+func WorstFlag(values []models.MeasurementValue) models.MeasurementFlag {
+	worst := utils.MEASUREMENT_FLAG_NORMAL
+	for _, value := range values {
+		if flagRank(value.Flag) > flagRank(worst) {
+			worst = value.Flag
+		}
+	}
+	return worst
+}
+
+func flagRank(flag models.MeasurementFlag) int {
+	switch flag {
+	case utils.MEASUREMENT_FLAG_CRITICAL_LOW, utils.MEASUREMENT_FLAG_CRITICAL_HIGH:
+		return 2
+	case utils.MEASUREMENT_FLAG_LOW, utils.MEASUREMENT_FLAG_HIGH:
+		return 1
+	}
+	return 0
+}
+
+func (k *kind) component(name string) *models.MeasurementComponent {
+	for i := range k.Components {
+		if k.Components[i].Name == name {
+			return &k.Components[i]
+		}
+	}
+	return nil
+}
+
+func round(value float64, decimals int) float64 {
+	scale := math.Pow(10, float64(decimals))
+	return math.Round(value*scale) / scale
+}
@@ -0,0 +1,88 @@
+package measurements
+
+import (
+	"strings"
+
+	"github.com/mihnea1711/POS_Project/services/consultatii/internal/models"
+	"github.com/mihnea1711/POS_Project/services/consultatii/pkg/utils"
+)
+
+// unit is a unit a type is accepted in, with the conversion to the unit the type is kept in
+type unit struct {
+	name    string
+	aliases []string
+	convert func(float64) float64
+}
+
+func same(value float64) float64 { return value }
+
+func scaled(factor float64) func(float64) float64 {
+	return func(value float64) float64 { return value * factor }
+}
+
+// builtinKinds are the measurements followed at a visit and in chronic care. The reference ranges are those of adults
+// at rest; weight and height have none, only the plausible range that catches typing errors.
+func builtinKinds() []kind {
+	return []kind{
+		newKind(utils.MEASUREMENT_TYPE_BLOOD_PRESSURE, "Blood pressure", 0,
+			[]unit{{name: "mmHg", convert: same}, {name: "kPa", convert: scaled(7.50062)}},
+			models.MeasurementComponent{Name: utils.MEASUREMENT_COMPONENT_SYSTOLIC, ReferenceLow: limit(90), ReferenceHigh: limit(139), CriticalLow: limit(70), CriticalHigh: limit(180), Minimum: 40, Maximum: 300},
+			models.MeasurementComponent{Name: utils.MEASUREMENT_COMPONENT_DIASTOLIC, ReferenceLow: limit(60), ReferenceHigh: limit(89), CriticalLow: limit(40), CriticalHigh: limit(120), Minimum: 20, Maximum: 200},
+		),
+		newKind(utils.MEASUREMENT_TYPE_HEART_RATE, "Heart rate", 0,
+			[]unit{{name: "bpm", aliases: []string{"/min"}, convert: same}},
+			models.MeasurementComponent{ReferenceLow: limit(60), ReferenceHigh: limit(100), CriticalLow: limit(40), CriticalHigh: limit(130), Minimum: 20, Maximum: 300},
+		),
+		newKind(utils.MEASUREMENT_TYPE_RESPIRATORY_RATE, "Respiratory rate", 0,
+			[]unit{{name: "/min", aliases: []string{"breaths/min"}, convert: same}},
+			models.MeasurementComponent{ReferenceLow: limit(12), ReferenceHigh: limit(20), CriticalLow: limit(8), CriticalHigh: limit(30), Minimum: 2, Maximum: 80},
+		),
+		newKind(utils.MEASUREMENT_TYPE_TEMPERATURE, "Body temperature", 1,
+			[]unit{
+				{name: "°C", aliases: []string{"C"}, convert: same},
+				{name: "°F", aliases: []string{"F"}, convert: func(value float64) float64 { return (value - 32) * 5 / 9 }},
+			},
+			models.MeasurementComponent{ReferenceLow: limit(36.1), ReferenceHigh: limit(37.5), CriticalLow: limit(35), CriticalHigh: limit(40), Minimum: 25, Maximum: 45},
+		),
+		newKind(utils.MEASUREMENT_TYPE_OXYGEN_SATURATION, "Oxygen saturation", 0,
+			[]unit{{name: "%", convert: same}},
+			models.MeasurementComponent{ReferenceLow: limit(95), ReferenceHigh: limit(100), CriticalLow: limit(90), Minimum: 50, Maximum: 100},
+		),
+		newKind(utils.MEASUREMENT_TYPE_WEIGHT, "Body weight", 1,
+			[]unit{{name: "kg", convert: same}, {name: "g", convert: scaled(0.001)}, {name: "lb", convert: scaled(0.45359237)}},
+			models.MeasurementComponent{Minimum: 0.2, Maximum: 500},
+		),
+		newKind(utils.MEASUREMENT_TYPE_HEIGHT, "Body height", 1,
+			[]unit{{name: "cm", convert: same}, {name: "m", convert: scaled(100)}, {name: "in", convert: scaled(2.54)}},
+			models.MeasurementComponent{Minimum: 20, Maximum: 260},
+		),
+		newKind(utils.MEASUREMENT_TYPE_GLUCOSE, "Blood glucose", 0,
+			[]unit{{name: "mg/dL", convert: same}, {name: "mmol/L", convert: scaled(18.016)}},
+			models.MeasurementComponent{ReferenceLow: limit(70), ReferenceHigh: limit(140), CriticalLow: limit(54), CriticalHigh: limit(400), Minimum: 10, Maximum: 1500},
+		),
+	}
+}
+
+// newKind builds a type kept in its first unit. A type with a single component names it after itself.
+func newKind(measurementType models.MeasurementType, name string, decimals int, units []unit, components ...models.MeasurementComponent) kind {
+	if len(components) == 1 && components[0].Name == "" {
+		components[0].Name = string(measurementType)
+	}
+
+	k := kind{
+		MeasurementKind: models.MeasurementKind{Type: measurementType, Name: name, Unit: units[0].name, Components: components},
+		conversions:     map[string]func(float64) float64{},
+		decimals:        decimals,
+	}
+	for _, u := range units {
+		k.Units = append(k.Units, u.name)
+		for _, accepted := range append([]string{u.name}, u.aliases...) {
+			k.conversions[strings.ToLower(accepted)] = u.convert
+		}
+	}
+	return k
+}
+
+func limit(value float64) *float64 {
+	return &value
+}
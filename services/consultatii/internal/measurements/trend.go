@@ -0,0 +1,69 @@
+package measurements
+
+import (
+	"time"
+
+	"github.com/mihnea1711/POS_Project/services/consultatii/internal/models"
+)
+
+// Trend sums up the buckets of a type of measurement of a patient by component, in the order of the components of the
+// type. The buckets come sorted by component and start; a component never measured in the period has no buckets and
+// no first or latest value.
+func (c *Catalog) Trend(patientID int, measurementType models.MeasurementType, interval string, from, to time.Time, buckets []models.MeasurementBucket) *models.MeasurementTrend {
+	k := c.byType[measurementType]
+	trend := &models.MeasurementTrend{
+		IDPatient: patientID,
+		Type:      measurementType,
+		Unit:      k.Unit,
+		Interval:  interval,
+		From:      from,
+		To:        to,
+	}
+
+	for _, component := range k.Components {
+		summary := models.ComponentTrend{
+			Component:     component.Name,
+			ReferenceLow:  component.ReferenceLow,
+			ReferenceHigh: component.ReferenceHigh,
+			Buckets:       []models.MeasurementBucket{},
+		}
+
+		var total float64
+		for _, bucket := range buckets {
+			if bucket.Component != component.Name {
+				continue
+			}
+			if summary.Count == 0 || bucket.Minimum < summary.Minimum {
+				summary.Minimum = bucket.Minimum
+			}
+			if summary.Count == 0 || bucket.Maximum > summary.Maximum {
+				summary.Maximum = bucket.Maximum
+			}
+			if summary.First == nil {
+				first := bucket.First
+				summary.First = &first
+			}
+			latest := bucket.Latest
+			summary.Latest = &latest
+
+			summary.Count += bucket.Count
+			summary.OutOfRange += bucket.OutOfRange
+			total += bucket.Average * float64(bucket.Count)
+			bucket.Average = round(bucket.Average, k.decimals+1)
+			summary.Buckets = append(summary.Buckets, bucket)
+		}
+
+		if summary.Count > 0 {
+			summary.Average = round(total/float64(summary.Count), k.decimals+1)
+			change := round(summary.Latest.Value-summary.First.Value, k.decimals)
+			summary.Change = &change
+		}
+		trend.Components = append(trend.Components, summary)
+	}
+	return trend
+}
+
+// TimeZone is the name of the time zone the buckets start in, as understood by $dateTrunc
+func (c *Catalog) TimeZone() string {
+	return c.location.String()
+}
@@ -9,10 +9,11 @@ import (
 	"log"
 	"net/http"
 	"strings"
-	"time"
 
+	"github.com/mihnea1711/POS_Project/services/consultatii/internal/icd10"
 	"github.com/mihnea1711/POS_Project/services/consultatii/internal/models"
 	"github.com/mihnea1711/POS_Project/services/consultatii/pkg/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 func ValidateConsultationInfo(next http.Handler) http.Handler {
@@ -46,12 +47,302 @@ func ValidateConsultationInfo(next http.Handler) http.Handler {
 			return
 		}
 
+		// Investigations keep the ID they were given first, so attachments can point to them across updates
+		for i := range consultation.Investigations {
+			if consultation.Investigations[i].IDInvestigation.IsZero() {
+				consultation.Investigations[i].IDInvestigation = primitive.NewObjectID()
+			}
+		}
+
 		// If all validations pass, proceed to the actual controller
 		ctx := context.WithValue(r.Context(), utils.DECODED_CONSULTATION, &consultation)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
+func ValidatePatientReassignmentInfo(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request models.PatientReassignment
+
+		if !isContentTypeJSON(r) {
+			errMsg := "Unsupported media type. Content-Type must be application/json"
+			log.Printf("[CONSULTATION_VALIDATION] %s in request: %s", errMsg, r.RequestURI)
+			utils.RespondWithJSON(w, http.StatusUnsupportedMediaType, models.ResponseData{Error: errMsg, Message: "Patient reassignment validation failed due to unsupported media type"})
+			return
+		}
+
+		dec := json.NewDecoder(r.Body)
+		dec.DisallowUnknownFields()
+
+		err := dec.Decode(&request)
+		decodeFlag, decodeStatus := checkErrorOnDecode(err, w)
+		if decodeFlag || decodeStatus != http.StatusOK {
+			errMsg := "Failed to decode patient reassignment"
+			log.Printf("[CONSULTATION_VALIDATION] %s in request: %s", errMsg, r.RequestURI)
+			utils.RespondWithJSON(w, http.StatusBadRequest, models.ResponseData{Error: errMsg, Message: "Patient reassignment validation failed due to decoding."})
+			return
+		}
+
+		if request.IDFromPatient <= 0 || request.IDToPatient <= 0 || request.IDFromPatient == request.IDToPatient {
+			errMsg := "idFromPatient and idToPatient must be two different positive IDs"
+			log.Printf("[CONSULTATION_VALIDATION] Validation error: %s", errMsg)
+			utils.RespondWithJSON(w, http.StatusBadRequest, models.ResponseData{Error: errMsg})
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), utils.DECODED_PATIENT_REASSIGNMENT, &request)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// ValidateInvestigationOrderInfo checks a new investigation ordered on an existing consultation
+func ValidateInvestigationOrderInfo(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var order models.InvestigationOrder
+		if !decodeInvestigationRequest(w, r, &order, "investigation order") {
+			return
+		}
+
+		if order.Name == "" || order.ProcessingTime <= 0 {
+			errMsg := "an investigation needs a name and a positive processing time in minutes"
+			log.Printf("[CONSULTATION_VALIDATION] Validation error: %s", errMsg)
+			utils.RespondWithJSON(w, http.StatusBadRequest, models.ResponseData{Error: errMsg})
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), utils.DECODED_INVESTIGATION_ORDER, &order)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// ValidateInvestigationStatusInfo checks a status change of an investigation. Results are recorded through their own
+// endpoint, so resulted is not accepted here.
+func ValidateInvestigationStatusInfo(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var change models.InvestigationStatusChange
+		if !decodeInvestigationRequest(w, r, &change, "investigation status") {
+			return
+		}
+
+		if !utils.IsInvestigationStatus(change.Status) {
+			errMsg := fmt.Sprintf("unknown investigation status %q", change.Status)
+			log.Printf("[CONSULTATION_VALIDATION] Validation error: %s", errMsg)
+			utils.RespondWithJSON(w, http.StatusBadRequest, models.ResponseData{Error: errMsg})
+			return
+		}
+		if change.Status == utils.INVESTIGATION_STATUS_RESULTED {
+			errMsg := "an investigation is resulted by recording its result"
+			log.Printf("[CONSULTATION_VALIDATION] Validation error: %s", errMsg)
+			utils.RespondWithJSON(w, http.StatusBadRequest, models.ResponseData{Error: errMsg})
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), utils.DECODED_INVESTIGATION_STATUS, &change)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// ValidateInvestigationResultInfo checks the result recorded for an investigation
+func ValidateInvestigationResultInfo(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var result models.InvestigationResult
+		if !decodeInvestigationRequest(w, r, &result, "investigation result") {
+			return
+		}
+
+		if strings.TrimSpace(result.Result) == "" {
+			errMsg := "the result must not be empty"
+			log.Printf("[CONSULTATION_VALIDATION] Validation error: %s", errMsg)
+			utils.RespondWithJSON(w, http.StatusBadRequest, models.ResponseData{Error: errMsg})
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), utils.DECODED_INVESTIGATION_RESULT, &result)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// ValidatePrescriptionInfo checks the items of a prescription, the drug codes are checked against the catalog by the
+// controller
+func ValidatePrescriptionInfo(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request models.PrescriptionRequest
+		if !decodeInvestigationRequest(w, r, &request, "prescription") {
+			return
+		}
+
+		if err := validatePrescriptionItems(request.Items); err != nil {
+			log.Printf("[CONSULTATION_VALIDATION] Validation error: %s", err)
+			utils.RespondWithJSON(w, http.StatusBadRequest, models.ResponseData{Error: err.Error()})
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), utils.DECODED_PRESCRIPTION, &request)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// ValidatePrescriptionStatusInfo checks a status change of a prescription, a cancellation needs its reason
+func ValidatePrescriptionStatusInfo(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var change models.PrescriptionStatusChange
+		if !decodeInvestigationRequest(w, r, &change, "prescription status") {
+			return
+		}
+
+		if change.Status != utils.PRESCRIPTION_STATUS_DISPENSED && change.Status != utils.PRESCRIPTION_STATUS_CANCELED {
+			errMsg := fmt.Sprintf("a prescription can only be %s or %s, not %q", utils.PRESCRIPTION_STATUS_DISPENSED, utils.PRESCRIPTION_STATUS_CANCELED, change.Status)
+			log.Printf("[CONSULTATION_VALIDATION] Validation error: %s", errMsg)
+			utils.RespondWithJSON(w, http.StatusBadRequest, models.ResponseData{Error: errMsg})
+			return
+		}
+		change.Reason = strings.TrimSpace(change.Reason)
+		if change.Status == utils.PRESCRIPTION_STATUS_CANCELED && change.Reason == "" {
+			errMsg := "the reason of the cancellation is required"
+			log.Printf("[CONSULTATION_VALIDATION] Validation error: %s", errMsg)
+			utils.RespondWithJSON(w, http.StatusBadRequest, models.ResponseData{Error: errMsg})
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), utils.DECODED_PRESCRIPTION_STATUS, &change)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// ValidateLabResolutionInfo checks the investigation a queued lab result is recorded on
+func ValidateLabResolutionInfo(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var resolution models.LabResolution
+		if !decodeInvestigationRequest(w, r, &resolution, "lab result resolution") {
+			return
+		}
+
+		if resolution.IDConsultation.IsZero() || resolution.IDInvestigation.IsZero() {
+			errMsg := "the consultation and the investigation the result is recorded on are required"
+			log.Printf("[CONSULTATION_VALIDATION] Validation error: %s", errMsg)
+			utils.RespondWithJSON(w, http.StatusBadRequest, models.ResponseData{Error: errMsg})
+			return
+		}
+		resolution.Note = strings.TrimSpace(resolution.Note)
+
+		ctx := context.WithValue(r.Context(), utils.DECODED_LAB_RESOLUTION, &resolution)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// ValidateLabDiscardInfo checks a queued lab result is discarded with its reason
+func ValidateLabDiscardInfo(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var discard models.LabDiscard
+		if !decodeInvestigationRequest(w, r, &discard, "lab result discard") {
+			return
+		}
+
+		discard.Reason = strings.TrimSpace(discard.Reason)
+		if discard.Reason == "" {
+			errMsg := "the reason the lab result is discarded is required"
+			log.Printf("[CONSULTATION_VALIDATION] Validation error: %s", errMsg)
+			utils.RespondWithJSON(w, http.StatusBadRequest, models.ResponseData{Error: errMsg})
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), utils.DECODED_LAB_DISCARD, &discard)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// ValidateMeasurementInfo checks the readings taken together are for a patient, named or through the consultation. The
+// units and the values are checked against the measurement catalog when they are recorded.
+func ValidateMeasurementInfo(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request models.MeasurementRequest
+		if !decodeInvestigationRequest(w, r, &request, "measurements") {
+			return
+		}
+
+		if err := validateMeasurementRequest(&request); err != nil {
+			log.Printf("[CONSULTATION_VALIDATION] Validation error: %s", err)
+			utils.RespondWithJSON(w, http.StatusBadRequest, models.ResponseData{Error: err.Error()})
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), utils.DECODED_MEASUREMENTS, &request)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// ValidateMeasurementRetractionInfo checks a measurement is retracted with its reason
+func ValidateMeasurementRetractionInfo(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var retraction models.MeasurementRetraction
+		if !decodeInvestigationRequest(w, r, &retraction, "measurement retraction") {
+			return
+		}
+
+		retraction.Reason = strings.TrimSpace(retraction.Reason)
+		if retraction.Reason == "" {
+			errMsg := "the reason the measurement is retracted is required"
+			log.Printf("[CONSULTATION_VALIDATION] Validation error: %s", errMsg)
+			utils.RespondWithJSON(w, http.StatusBadRequest, models.ResponseData{Error: errMsg})
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), utils.DECODED_MEASUREMENT_RETRACTION, &retraction)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func validateMeasurementRequest(request *models.MeasurementRequest) error {
+	if request.IDConsultation == nil {
+		if request.IDPatient <= 0 {
+			return errors.New("invalid patient ID, a patient or a consultation is required")
+		}
+		if request.IDDoctor <= 0 {
+			return errors.New("invalid doctor ID, a doctor or a consultation is required")
+		}
+	} else if request.IDConsultation.IsZero() {
+		return errors.New("invalid consultation ID")
+	}
+
+	if len(request.Readings) == 0 || len(request.Readings) > utils.MAX_MEASUREMENT_READINGS {
+		return fmt.Errorf("between 1 and %d readings are required", utils.MAX_MEASUREMENT_READINGS)
+	}
+	for i := range request.Readings {
+		reading := &request.Readings[i]
+		if reading.Type == "" {
+			return fmt.Errorf("reading %d has no type", i+1)
+		}
+		if (reading.Value == nil) == (len(reading.Values) == 0) {
+			return fmt.Errorf("reading %d of %s needs either a value or values by component", i+1, reading.Type)
+		}
+		reading.Note = strings.TrimSpace(reading.Note)
+	}
+	return nil
+}
+
+// decodeInvestigationRequest decodes a JSON body of the investigation endpoints, answering the request when it fails
+func decodeInvestigationRequest(w http.ResponseWriter, r *http.Request, target interface{}, entity string) bool {
+	if !isContentTypeJSON(r) {
+		errMsg := "Unsupported media type. Content-Type must be application/json"
+		log.Printf("[CONSULTATION_VALIDATION] %s in request: %s", errMsg, r.RequestURI)
+		utils.RespondWithJSON(w, http.StatusUnsupportedMediaType, models.ResponseData{Error: errMsg, Message: fmt.Sprintf("Validation of the %s failed due to unsupported media type", entity)})
+		return false
+	}
+
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+
+	err := dec.Decode(target)
+	decodeFlag, decodeStatus := checkErrorOnDecode(err, w)
+	if decodeFlag || decodeStatus != http.StatusOK {
+		errMsg := fmt.Sprintf("Failed to decode %s", entity)
+		log.Printf("[CONSULTATION_VALIDATION] %s in request: %s", errMsg, r.RequestURI)
+		utils.RespondWithJSON(w, http.StatusBadRequest, models.ResponseData{Error: errMsg, Message: fmt.Sprintf("Validation of the %s failed due to decoding.", entity)})
+		return false
+	}
+	return true
+}
+
 // Function to check the error when decoding an object
 func checkErrorOnDecode(err error, w http.ResponseWriter) (bool, int) {
 	if err == nil {
@@ -137,26 +428,30 @@ func validateConsultation(consultation *models.Consultation) error {
 		return errors.New("invalid doctor ID")
 	}
 
-	// Validate the Date (assuming it should not be zero)
+	if consultation.IDAppointment != nil && *consultation.IDAppointment <= 0 {
+		log.Println("[CONSULTATION_VALIDATION] Invalid appointment ID")
+		return errors.New("invalid appointment ID")
+	}
+
+	// Validate the Date (assuming it should not be zero). A visit is often recorded after it took place, so the date
+	// may be in the past.
 	if consultation.Date.IsZero() {
 		log.Println("[CONSULTATION_VALIDATION] Invalid date")
 		return errors.New("invalid date")
 	}
 
-	// Validate that the date is not in the past
-	currentMoment := time.Now()
-	if consultation.Date.Before(currentMoment) {
-		log.Println("[CONSULTATION_VALIDATION] Date should not be in the past")
-		return errors.New("date should not be in the past")
+	// A consultation has a free-text diagnostic, coded diagnoses or both
+	if consultation.Diagnostic == "" && len(consultation.Diagnoses) == 0 {
+		log.Println("[CONSULTATION_VALIDATION] Invalid diagnostic")
+		return errors.New("invalid diagnostic, a diagnostic or at least one coded diagnosis is required")
 	}
 
-	// Validate the Diagnostic field (assuming it should not be empty)
-	if consultation.Diagnostic == "" {
-		log.Println("[CONSULTATION_VALIDATION] Invalid diagnostic")
-		return errors.New("invalid diagnostic")
+	if err := validateDiagnoses(consultation.Diagnoses); err != nil {
+		log.Printf("[CONSULTATION_VALIDATION] Invalid diagnoses: %v", err)
+		return err
 	}
 
-	// Validate each investigation in the list
+	// Validate each investigation in the list, the result may be recorded later
 	for _, inv := range consultation.Investigations {
 		if inv.Name == "" {
 			log.Println("[CONSULTATION_VALIDATION] Invalid investigation denumire")
@@ -167,13 +462,70 @@ func validateConsultation(consultation *models.Consultation) error {
 			log.Println("[CONSULTATION_VALIDATION] Invalid investigation durata de procesare")
 			return errors.New("invalid investigation durata de procesare")
 		}
+	}
+
+	// If all validations pass, return nil
+	return nil
+}
+
+// validateDiagnoses checks the form of the coded diagnoses, the codes are checked against the catalog by the controller.
+// A single diagnosis is the primary one, more need exactly one marked as primary.
+func validateDiagnoses(diagnoses []models.Diagnosis) error {
+	if len(diagnoses) == 1 {
+		diagnoses[0].Primary = true
+	}
+
+	primaries := 0
+	seen := make(map[string]bool, len(diagnoses))
+	for i := range diagnoses {
+		diagnoses[i].Code = icd10.NormalizeCode(diagnoses[i].Code)
+		code := diagnoses[i].Code
+		if !icd10.IsCode(code) {
+			return fmt.Errorf("invalid ICD-10 code: %q", code)
+		}
+		if seen[code] {
+			return fmt.Errorf("diagnosis %s is listed twice", code)
+		}
+		seen[code] = true
 
-		if inv.Result == "" {
-			log.Println("[CONSULTATION_VALIDATION] Invalid investigation rezultat")
-			return errors.New("invalid investigation rezultat")
+		if diagnoses[i].Primary {
+			primaries++
 		}
 	}
 
-	// If all validations pass, return nil
+	if len(diagnoses) > 0 && primaries != 1 {
+		return errors.New("exactly one diagnosis must be primary")
+	}
+	return nil
+}
+
+// validatePrescriptionItems checks that a prescription has at least one item and that each says how the drug is taken
+func validatePrescriptionItems(items []models.PrescriptionItem) error {
+	if len(items) == 0 {
+		return errors.New("a prescription needs at least one drug")
+	}
+
+	seen := make(map[string]bool, len(items))
+	for i := range items {
+		item := &items[i]
+		item.DrugCode = strings.ToUpper(strings.TrimSpace(item.DrugCode))
+		item.Dose = strings.TrimSpace(item.Dose)
+		item.Frequency = strings.TrimSpace(item.Frequency)
+
+		if item.DrugCode == "" {
+			return fmt.Errorf("item %d needs a drug code", i+1)
+		}
+		if seen[item.DrugCode] {
+			return fmt.Errorf("drug %s is listed twice", item.DrugCode)
+		}
+		seen[item.DrugCode] = true
+
+		if item.Dose == "" || item.Frequency == "" {
+			return fmt.Errorf("drug %s needs a dose and a frequency", item.DrugCode)
+		}
+		if item.DurationDays <= 0 || item.Quantity <= 0 {
+			return fmt.Errorf("drug %s needs a positive duration in days and a positive quantity", item.DrugCode)
+		}
+	}
 	return nil
 }
@@ -11,16 +11,418 @@ type Consultation struct {
 	IDConsultation primitive.ObjectID `json:"idConsultation" bson:"_id"`
 	IDPatient      int                `json:"idPatient" bson:"id_patient"`
 	IDDoctor       int                `json:"idDoctor" bson:"id_doctor"`
+	IDAppointment  *int               `json:"idAppointment,omitempty" bson:"id_appointment,omitempty"` // the appointment the consultation fulfils, fixed when it is opened
 	Date           time.Time          `json:"date" bson:"date"`
 	Diagnostic     string             `json:"diagnostic" bson:"diagnostic"`
+	Diagnoses      []Diagnosis        `json:"diagnoses" bson:"diagnoses"`
 	Investigations []Investigation    `json:"investigations" bson:"investigations"`
+	Version        int                `json:"version" bson:"version"`
 }
 
+// Diagnosis is an ICD-10 coded diagnosis of a consultation, the description is the one of the catalog. A consultation
+// with coded diagnoses has exactly one primary diagnosis, the free-text diagnostic keeps the notes of the doctor.
+type Diagnosis struct {
+	Code        string `json:"code" bson:"code"`
+	Description string `json:"description" bson:"description"`
+	Primary     bool   `json:"primary" bson:"primary"`
+}
+
+// ConsultationRevision is the consultation as it was saved at a version, with who saved it and why. Revisions are
+// never changed, a listing leaves the consultation out.
+type ConsultationRevision struct {
+	IDRevision     primitive.ObjectID `json:"idRevision" bson:"_id"`
+	IDConsultation primitive.ObjectID `json:"idConsultation" bson:"id_consultation"`
+	Version        int                `json:"version" bson:"version"`
+	Author         *int               `json:"author,omitempty" bson:"author,omitempty"`
+	Reason         string             `json:"reason" bson:"reason"`
+	CreatedAt      time.Time          `json:"createdAt" bson:"created_at"`
+	Consultation   *Consultation      `json:"consultation,omitempty" bson:"consultation,omitempty"`
+}
+
+// RevisionChange is a value that differs between two revisions, nil on the side where it does not exist
+type RevisionChange struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+// RevisionDiff lists the changes made to a consultation from one version to another
+type RevisionDiff struct {
+	IDConsultation primitive.ObjectID `json:"idConsultation"`
+	FromVersion    int                `json:"fromVersion"`
+	ToVersion      int                `json:"toVersion"`
+	Changes        []RevisionChange   `json:"changes"`
+}
+
+// Investigation is an order for a test, such as a blood panel or a scan. The lifecycle fields are kept by the module,
+// the values a client sends for them are ignored. ProcessingTime is given in minutes.
 type Investigation struct {
-	IDInvestigation primitive.ObjectID `json:"idInvestigation" bson:"id_investigation"`
-	Name            string             `json:"name" bson:"name"`
-	ProcessingTime  int                `json:"processingTime" bson:"processing_time"`
-	Result          string             `json:"result" bson:"result"`
+	IDInvestigation    primitive.ObjectID  `json:"idInvestigation" bson:"id_investigation"`
+	Name               string              `json:"name" bson:"name"`
+	ProcessingTime     int                 `json:"processingTime" bson:"processing_time"`
+	Result             string              `json:"result" bson:"result"`
+	Status             InvestigationStatus `json:"status" bson:"status"`
+	OrderedAt          time.Time           `json:"orderedAt" bson:"ordered_at"`
+	ExpectedCompletion time.Time           `json:"expectedCompletion" bson:"expected_completion"`
+	SampleCollectedAt  *time.Time          `json:"sampleCollectedAt,omitempty" bson:"sample_collected_at,omitempty"`
+	ResultedAt         *time.Time          `json:"resultedAt,omitempty" bson:"resulted_at,omitempty"`
+	CanceledAt         *time.Time          `json:"canceledAt,omitempty" bson:"canceled_at,omitempty"`
+	CancelReason       string              `json:"cancelReason,omitempty" bson:"cancel_reason,omitempty"`
+}
+
+type InvestigationStatus string
+
+// InvestigationOrder asks for a new investigation on an existing consultation
+type InvestigationOrder struct {
+	Name           string `json:"name"`
+	ProcessingTime int    `json:"processingTime"`
+}
+
+// InvestigationStatusChange moves an investigation along its lifecycle, the reason is kept for cancellations
+type InvestigationStatusChange struct {
+	Status InvestigationStatus `json:"status"`
+	Reason string              `json:"reason,omitempty"`
+}
+
+// InvestigationResult is the result lab staff record for an investigation
+type InvestigationResult struct {
+	Result string `json:"result"`
+}
+
+// PendingInvestigation is an investigation waiting for its result, with the consultation it was ordered in
+type PendingInvestigation struct {
+	IDConsultation primitive.ObjectID `json:"idConsultation" bson:"_id"`
+	IDPatient      int                `json:"idPatient" bson:"id_patient"`
+	IDDoctor       int                `json:"idDoctor" bson:"id_doctor"`
+	Investigation  Investigation      `json:"investigation" bson:"investigation"`
+	Overdue        bool               `json:"overdue" bson:"-"`
+}
+
+// LabReconciliationItem is a result from the lab that could not be matched to an investigation and waits for manual
+// review. It keeps the message it came in, the result read from it and why it was not recorded. A reviewer records it on
+// the right investigation or discards it. The CNP, the name, the result and the message are encrypted.
+type LabReconciliationItem struct {
+	IDItem          primitive.ObjectID      `json:"idItem" bson:"_id"`
+	Fingerprint     string                  `json:"-" bson:"fingerprint"`
+	Status          LabReconciliationStatus `json:"status" bson:"status"`
+	Reason          string                  `json:"reason" bson:"reason"`
+	Detail          string                  `json:"detail,omitempty" bson:"detail,omitempty"`
+	Source          string                  `json:"source" bson:"source"`
+	ControlID       string                  `json:"controlId,omitempty" bson:"control_id,omitempty"`
+	SendingFacility string                  `json:"sendingFacility,omitempty" bson:"sending_facility,omitempty"`
+	PatientCNP      string                  `json:"patientCnp,omitempty" bson:"patient_cnp,omitempty"`
+	PatientName     string                  `json:"patientName,omitempty" bson:"patient_name,omitempty"`
+	IDPatient       *int                    `json:"idPatient,omitempty" bson:"id_patient,omitempty"`
+	PlacerOrderID   string                  `json:"placerOrderId,omitempty" bson:"placer_order_id,omitempty"`
+	FillerOrderID   string                  `json:"fillerOrderId,omitempty" bson:"filler_order_id,omitempty"`
+	Service         string                  `json:"service,omitempty" bson:"service,omitempty"`
+	ResultStatus    string                  `json:"resultStatus,omitempty" bson:"result_status,omitempty"`
+	Result          string                  `json:"result,omitempty" bson:"result,omitempty"`
+	ObservedAt      *time.Time              `json:"observedAt,omitempty" bson:"observed_at,omitempty"`
+	ReportedAt      *time.Time              `json:"reportedAt,omitempty" bson:"reported_at,omitempty"`
+	Message         string                  `json:"message" bson:"message"`
+	ReceivedAt      time.Time               `json:"receivedAt" bson:"received_at"`
+	IDConsultation  *primitive.ObjectID     `json:"idConsultation,omitempty" bson:"id_consultation,omitempty"`
+	IDInvestigation *primitive.ObjectID     `json:"idInvestigation,omitempty" bson:"id_investigation,omitempty"`
+	ReviewedAt      *time.Time              `json:"reviewedAt,omitempty" bson:"reviewed_at,omitempty"`
+	ReviewedBy      *int                    `json:"reviewedBy,omitempty" bson:"reviewed_by,omitempty"`
+	ReviewNote      string                  `json:"reviewNote,omitempty" bson:"review_note,omitempty"`
+}
+
+type LabReconciliationStatus string
+
+// LabResolution records a queued result on the investigation the reviewer chose
+type LabResolution struct {
+	IDConsultation  primitive.ObjectID `json:"idConsultation"`
+	IDInvestigation primitive.ObjectID `json:"idInvestigation"`
+	Note            string             `json:"note,omitempty"`
+}
+
+// LabDiscard closes a queued result without recording it, the reason is kept
+type LabDiscard struct {
+	Reason string `json:"reason"`
+}
+
+// LabImportReport tells what became of each order of the messages received in a file or a request
+type LabImportReport struct {
+	Source   string             `json:"source"`
+	Messages int                `json:"messages"`
+	Outcomes []LabImportOutcome `json:"outcomes"`
+}
+
+// LabImportOutcome is what became of an order: its result recorded, corrected or already there, the progress noted,
+// or the order queued for review with the reason
+type LabImportOutcome struct {
+	ControlID        string              `json:"controlId,omitempty"`
+	PlacerOrderID    string              `json:"placerOrderId,omitempty"`
+	Outcome          string              `json:"outcome"`
+	Reason           string              `json:"reason,omitempty"`
+	IDConsultation   *primitive.ObjectID `json:"idConsultation,omitempty"`
+	IDInvestigation  *primitive.ObjectID `json:"idInvestigation,omitempty"`
+	IDReconciliation *primitive.ObjectID `json:"idReconciliation,omitempty"`
+}
+
+// Measurement is a vital sign or a clinical measurement of a patient, such as a blood pressure reading, taken at a
+// visit or brought by a patient followed for a chronic disease. The values are kept in the unit of the type, each with
+// the reference range it was flagged against when recorded. A measurement entered in error is retracted, not deleted,
+// and left out of the trends.
+type Measurement struct {
+	IDMeasurement  primitive.ObjectID  `json:"idMeasurement" bson:"_id"`
+	IDPatient      int                 `json:"idPatient" bson:"id_patient"`
+	IDDoctor       int                 `json:"idDoctor" bson:"id_doctor"`
+	IDConsultation *primitive.ObjectID `json:"idConsultation,omitempty" bson:"id_consultation,omitempty"`
+	Type           MeasurementType     `json:"type" bson:"type"`
+	Unit           string              `json:"unit" bson:"unit"`
+	Values         []MeasurementValue  `json:"values" bson:"values"`
+	Flag           MeasurementFlag     `json:"flag" bson:"flag"`
+	Status         MeasurementStatus   `json:"status" bson:"status"`
+	Note           string              `json:"note,omitempty" bson:"note,omitempty"`
+	MeasuredAt     time.Time           `json:"measuredAt" bson:"measured_at"`
+	RecordedAt     time.Time           `json:"recordedAt" bson:"recorded_at"`
+	RecordedBy     *int                `json:"recordedBy,omitempty" bson:"recorded_by,omitempty"`
+	RetractedAt    *time.Time          `json:"retractedAt,omitempty" bson:"retracted_at,omitempty"`
+	RetractedBy    *int                `json:"retractedBy,omitempty" bson:"retracted_by,omitempty"`
+	RetractReason  string              `json:"retractReason,omitempty" bson:"retract_reason,omitempty"`
+}
+
+type MeasurementType string
+
+type MeasurementFlag string
+
+type MeasurementStatus string
+
+// MeasurementValue is a component of a measurement: the systolic or the diastolic pressure of a blood pressure, the
+// single value of the other types
+type MeasurementValue struct {
+	Component     string          `json:"component" bson:"component"`
+	Value         float64         `json:"value" bson:"value"`
+	ReferenceLow  *float64        `json:"referenceLow,omitempty" bson:"reference_low,omitempty"`
+	ReferenceHigh *float64        `json:"referenceHigh,omitempty" bson:"reference_high,omitempty"`
+	Flag          MeasurementFlag `json:"flag" bson:"flag"`
+}
+
+// MeasurementRequest records the readings taken together, such as the vital signs of a visit. When a consultation is
+// given the patient and the doctor are those of the consultation.
+type MeasurementRequest struct {
+	IDPatient      int                  `json:"idPatient"`
+	IDDoctor       int                  `json:"idDoctor"`
+	IDConsultation *primitive.ObjectID  `json:"idConsultation,omitempty"`
+	MeasuredAt     *time.Time           `json:"measuredAt,omitempty"`
+	Readings       []MeasurementReading `json:"readings"`
+}
+
+// MeasurementReading is a measurement as it was taken, in any unit its type accepts. Value is given for the types with
+// a single component, Values by component for the others.
+type MeasurementReading struct {
+	Type   MeasurementType    `json:"type"`
+	Unit   string             `json:"unit,omitempty"`
+	Value  *float64           `json:"value,omitempty"`
+	Values map[string]float64 `json:"values,omitempty"`
+	Note   string             `json:"note,omitempty"`
+}
+
+// MeasurementRetraction marks a measurement as entered in error, such as one recorded for the wrong patient
+type MeasurementRetraction struct {
+	Reason string `json:"reason"`
+}
+
+// MeasurementKind describes a type of measurement: the unit it is kept in, the other units it is accepted in and its
+// components
+type MeasurementKind struct {
+	Type       MeasurementType        `json:"type"`
+	Name       string                 `json:"name"`
+	Unit       string                 `json:"unit"`
+	Units      []string               `json:"units"`
+	Components []MeasurementComponent `json:"components"`
+}
+
+// MeasurementComponent is a value of a type of measurement with its ranges, in the unit of the type. Values outside
+// the reference range are flagged low or high, those outside the critical range critical, and those outside the
+// plausible range are refused as typing errors.
+type MeasurementComponent struct {
+	Name          string   `json:"name"`
+	ReferenceLow  *float64 `json:"referenceLow,omitempty"`
+	ReferenceHigh *float64 `json:"referenceHigh,omitempty"`
+	CriticalLow   *float64 `json:"criticalLow,omitempty"`
+	CriticalHigh  *float64 `json:"criticalHigh,omitempty"`
+	Minimum       float64  `json:"minimum"`
+	Maximum       float64  `json:"maximum"`
+}
+
+// MeasurementTrend follows a type of measurement of a patient over a period, by component, in buckets of a day, a week
+// or a month
+type MeasurementTrend struct {
+	IDPatient  int              `json:"idPatient"`
+	Type       MeasurementType  `json:"type"`
+	Unit       string           `json:"unit"`
+	Interval   string           `json:"interval"`
+	From       time.Time        `json:"from"`
+	To         time.Time        `json:"to"`
+	Components []ComponentTrend `json:"components"`
+}
+
+// ComponentTrend sums a component up over the period. Change is the latest value less the first one.
+type ComponentTrend struct {
+	Component     string              `json:"component"`
+	Count         int                 `json:"count"`
+	OutOfRange    int                 `json:"outOfRange"`
+	Minimum       float64             `json:"minimum"`
+	Maximum       float64             `json:"maximum"`
+	Average       float64             `json:"average"`
+	First         *MeasurementPoint   `json:"first,omitempty"`
+	Latest        *MeasurementPoint   `json:"latest,omitempty"`
+	Change        *float64            `json:"change,omitempty"`
+	ReferenceLow  *float64            `json:"referenceLow,omitempty"`
+	ReferenceHigh *float64            `json:"referenceHigh,omitempty"`
+	Buckets       []MeasurementBucket `json:"buckets"`
+}
+
+// MeasurementPoint is a value of a component at the time it was measured
+type MeasurementPoint struct {
+	Value      float64         `json:"value" bson:"value"`
+	Flag       MeasurementFlag `json:"flag" bson:"flag"`
+	MeasuredAt time.Time       `json:"measuredAt" bson:"measured_at"`
+}
+
+// MeasurementBucket sums up the values of a component measured in a day, a week or a month, starting at Start. The
+// first and the latest values of the bucket make those of the trend.
+type MeasurementBucket struct {
+	Component  string           `json:"-" bson:"component"`
+	Start      time.Time        `json:"start" bson:"start"`
+	Count      int              `json:"count" bson:"count"`
+	OutOfRange int              `json:"outOfRange" bson:"out_of_range"`
+	Minimum    float64          `json:"minimum" bson:"minimum"`
+	Maximum    float64          `json:"maximum" bson:"maximum"`
+	Average    float64          `json:"average" bson:"average"`
+	First      MeasurementPoint `json:"-" bson:"first"`
+	Latest     MeasurementPoint `json:"-" bson:"latest"`
+}
+
+// AppointmentLink names the consultation opened for an appointment
+type AppointmentLink struct {
+	IDAppointment  int                `json:"idAppointment" bson:"id_appointment"`
+	IDConsultation primitive.ObjectID `json:"idConsultation" bson:"_id"`
+}
+
+// DiagnosisStatistics are the most common coded diagnoses of a doctor in a month. Only the coded diagnoses are counted,
+// the free-text diagnostic is encrypted.
+type DiagnosisStatistics struct {
+	IDDoctor  int              `json:"idDoctor" bson:"id_doctor"`
+	Month     string           `json:"month" bson:"month"`
+	Total     int              `json:"total" bson:"total"`
+	Diagnoses []DiagnosisCount `json:"diagnoses" bson:"diagnoses"`
+}
+
+// DiagnosisCount is how many times a diagnosis was coded
+type DiagnosisCount struct {
+	Code        string `json:"code" bson:"code"`
+	Description string `json:"description" bson:"description"`
+	Count       int    `json:"count" bson:"count"`
+}
+
+// InvestigationStatistics sum up the investigations with the same name. The processing time is the one planned when
+// ordering, the turnaround the one measured from the order to the result, both in minutes. The turnaround is only known
+// for the investigations resulted since the status lifecycle was introduced.
+type InvestigationStatistics struct {
+	Name                  string   `json:"name" bson:"name"`
+	Count                 int      `json:"count" bson:"count"`
+	Resulted              int      `json:"resulted" bson:"resulted"`
+	AverageProcessingTime float64  `json:"averageProcessingTime" bson:"average_processing_time"`
+	AverageTurnaround     *float64 `json:"averageTurnaround" bson:"average_turnaround"`
+}
+
+// Prescription is the treatment prescribed at a consultation, one item per drug. It is issued with the warnings found
+// for the patient, then dispensed by a pharmacy or canceled.
+type Prescription struct {
+	IDPrescription primitive.ObjectID    `json:"idPrescription" bson:"_id"`
+	IDConsultation primitive.ObjectID    `json:"idConsultation" bson:"id_consultation"`
+	IDPatient      int                   `json:"idPatient" bson:"id_patient"`
+	IDDoctor       int                   `json:"idDoctor" bson:"id_doctor"`
+	Items          []PrescriptionItem    `json:"items" bson:"items"`
+	Warnings       []PrescriptionWarning `json:"warnings" bson:"warnings"`
+	Status         PrescriptionStatus    `json:"status" bson:"status"`
+	IssuedAt       time.Time             `json:"issuedAt" bson:"issued_at"`
+	IssuedBy       *int                  `json:"issuedBy,omitempty" bson:"issued_by,omitempty"`
+	DispensedAt    *time.Time            `json:"dispensedAt,omitempty" bson:"dispensed_at,omitempty"`
+	CanceledAt     *time.Time            `json:"canceledAt,omitempty" bson:"canceled_at,omitempty"`
+	CancelReason   string                `json:"cancelReason,omitempty" bson:"cancel_reason,omitempty"`
+}
+
+type PrescriptionStatus string
+
+// PrescriptionItem is a drug of the catalog with how it is taken. DrugName is taken from the catalog.
+type PrescriptionItem struct {
+	DrugCode     string `json:"drugCode" bson:"drug_code"`
+	DrugName     string `json:"drugName" bson:"drug_name"`
+	Dose         string `json:"dose" bson:"dose"`
+	Frequency    string `json:"frequency" bson:"frequency"`
+	DurationDays int    `json:"durationDays" bson:"duration_days"`
+	Quantity     int    `json:"quantity" bson:"quantity"`
+}
+
+// PrescriptionWarning is an allergy, an interaction or a duplicate therapy found when the prescription was issued
+type PrescriptionWarning struct {
+	Kind     string `json:"kind" bson:"kind"`
+	Severity string `json:"severity" bson:"severity"`
+	DrugCode string `json:"drugCode,omitempty" bson:"drug_code,omitempty"`
+	Message  string `json:"message" bson:"message"`
+}
+
+// PrescriptionRequest issues a prescription. The gateway adds what the clinical profile of the patient records, the
+// allergies and the medications taken, and whether there is a profile at all. Warnings that are major or worse must
+// be acknowledged for the prescription to be issued.
+type PrescriptionRequest struct {
+	Items               []PrescriptionItem `json:"items"`
+	AcknowledgeWarnings bool               `json:"acknowledgeWarnings"`
+	ProfileRecorded     bool               `json:"profileRecorded"`
+	Allergies           []string           `json:"allergies"`
+	Medications         []string           `json:"medications"`
+}
+
+// PrescriptionStatusChange dispenses or cancels a prescription, the reason is kept for cancellations
+type PrescriptionStatusChange struct {
+	Status PrescriptionStatus `json:"status"`
+	Reason string             `json:"reason,omitempty"`
+}
+
+// Attachment describes a file kept in GridFS, such as a scan, a lab result or a referral letter. It belongs to a patient
+// and may point to one of their consultations and to an investigation of that consultation.
+type Attachment struct {
+	IDAttachment    primitive.ObjectID  `json:"idAttachment" bson:"_id"`
+	IDPatient       int                 `json:"idPatient" bson:"id_patient"`
+	IDConsultation  *primitive.ObjectID `json:"idConsultation,omitempty" bson:"id_consultation,omitempty"`
+	IDInvestigation *primitive.ObjectID `json:"idInvestigation,omitempty" bson:"id_investigation,omitempty"`
+	FileName        string              `json:"fileName" bson:"file_name"`
+	ContentType     string              `json:"contentType" bson:"content_type"`
+	Size            int64               `json:"size" bson:"size"`
+	Description     string              `json:"description,omitempty" bson:"description,omitempty"`
+	UploadedAt      time.Time           `json:"uploadedAt" bson:"uploaded_at"`
+}
+
+// KeyRotationReport counts the consultations and revisions a rotation sweep visited and the keys their values are
+// wrapped with afterwards
+type KeyRotationReport struct {
+	ActiveKeyID string         `json:"activeKeyId"`
+	Scanned     int            `json:"scanned"`
+	Rewrapped   int            `json:"rewrapped"`
+	Skipped     int            `json:"skipped"`
+	KeysInUse   map[string]int `json:"keysInUse"`
+}
+
+// PatientReassignment moves the consultations of a duplicate patient record to the record that survives the merge
+type PatientReassignment struct {
+	IDFromPatient int `json:"idFromPatient"`
+	IDToPatient   int `json:"idToPatient"`
+}
+
+type ErasureAction string
+
+// ErasureRecord tells what the erasure of a patient did with a category of consultation data and why
+type ErasureRecord struct {
+	Category string        `json:"category"`
+	Action   ErasureAction `json:"action"`
+	Count    int           `json:"count"`
+	Reason   string        `json:"reason,omitempty"`
 }
 
 type ResponseData struct {
@@ -10,11 +10,17 @@ import (
 	"github.com/mihnea1711/POS_Project/services/consultatii/internal/controllers"
 	"github.com/mihnea1711/POS_Project/services/consultatii/internal/database"
 	"github.com/mihnea1711/POS_Project/services/consultatii/internal/database/redis"
+	"github.com/mihnea1711/POS_Project/services/consultatii/internal/drugs"
+	"github.com/mihnea1711/POS_Project/services/consultatii/internal/encryption"
+	"github.com/mihnea1711/POS_Project/services/consultatii/internal/icd10"
+	"github.com/mihnea1711/POS_Project/services/consultatii/internal/labresults"
+	"github.com/mihnea1711/POS_Project/services/consultatii/internal/measurements"
 	"github.com/mihnea1711/POS_Project/services/consultatii/internal/middleware"
+	"github.com/mihnea1711/POS_Project/services/consultatii/pkg/config"
 	"github.com/mihnea1711/POS_Project/services/consultatii/pkg/utils"
 )
 
-func SetupRoutes(ctx context.Context, dbConn database.Database, rdb *redis.RedisClient) *mux.Router {
+func SetupRoutes(ctx context.Context, dbConn database.Database, rdb *redis.RedisClient, rotator *encryption.Rotator, catalog *icd10.Catalog, drugCatalog *drugs.Catalog, labImporter *labresults.Importer, measurementCatalog *measurements.Catalog, attachmentsConfig config.AttachmentsConfig) *mux.Router {
 	log.Println("[CONSULTATION] Setting up rate limiter...")
 	rateLimiter := middleware.NewRedisRateLimiter(ctx, rdb, utils.REQUEST_RATE, utils.REQUEST_WINDOW_DURATION_MULTIPLIER*time.Minute)
 	log.Println("[CONSULTATION] Rate limiter set up successfully.")
@@ -32,9 +38,27 @@ func SetupRoutes(ctx context.Context, dbConn database.Database, rdb *redis.Redis
 	log.Println("[CONSULTATION] Input sanitizer middleware set up successfully.")
 
 	consultatieController := &controllers.ConsultationController{
-		DbConn: dbConn,
+		DbConn:       dbConn,
+		Rotator:      rotator,
+		Attachments:  attachmentsConfig,
+		Catalog:      catalog,
+		DrugCatalog:  drugCatalog,
+		LabImporter:  labImporter,
+		Measurements: measurementCatalog,
 	}
 
+	// Attachment, investigation, lab result, ICD-10, drug and measurement routes go first, so /consultations/attachments,
+	// /consultations/investigations, /consultations/lab-results, /consultations/icd10, /consultations/drugs,
+	// /consultations/prescriptions and /consultations/measurements are not read as consultation IDs
+	loadAttachmentRoutes(router, consultatieController)
+	loadInvestigationRoutes(router, consultatieController)
+	loadLabResultRoutes(router, consultatieController)
+	loadRevisionRoutes(router, consultatieController)
+	loadDiagnosisRoutes(router, consultatieController)
+	loadPrescriptionRoutes(router, consultatieController)
+	loadMeasurementRoutes(router, consultatieController)
+	loadStatisticsRoutes(router, consultatieController)
+	loadAppointmentRoutes(router, consultatieController)
 	loadCrudRoutes(router, consultatieController)
 
 	log.Println("[CONSULTATION] Routes setup completed.")
@@ -69,6 +93,18 @@ func loadCrudRoutes(router *mux.Router, consultatieController *controllers.Consu
 	router.Handle(utils.UPDATE_CONSULTATIE_BY_ID_ENDPOINT, middleware.ValidateConsultationInfo(consultatieUpdateByIDHandler)).Methods("PUT")
 	log.Printf("[CONSULTATION] Route PUT %s registered.", utils.UPDATE_CONSULTATIE_BY_ID_ENDPOINT)
 
+	patientReassignmentHandler := http.HandlerFunc(consultatieController.ReassignPatientConsultations)
+	router.Handle(utils.REASSIGN_PATIENT_CONSULTATIONS_ENDPOINT, middleware.ValidatePatientReassignmentInfo(patientReassignmentHandler)).Methods("POST")
+	log.Printf("[CONSULTATION] Route POST %s registered.", utils.REASSIGN_PATIENT_CONSULTATIONS_ENDPOINT)
+
+	patientErasureHandler := http.HandlerFunc(consultatieController.ErasePatientConsultations)
+	router.Handle(utils.ERASE_PATIENT_CONSULTATIONS_ENDPOINT, patientErasureHandler).Methods("POST")
+	log.Printf("[CONSULTATION] Route POST %s registered.", utils.ERASE_PATIENT_CONSULTATIONS_ENDPOINT)
+
+	keyRotationHandler := http.HandlerFunc(consultatieController.RotateKeys)
+	router.Handle(utils.KEY_ROTATION_ENDPOINT, keyRotationHandler).Methods("POST")
+	log.Printf("[CONSULTATION] Route POST %s registered.", utils.KEY_ROTATION_ENDPOINT)
+
 	// ---------------------------------------------------------- Delete --------------------------------------------------------------
 	consultatieDeleteByIDHandler := http.HandlerFunc(consultatieController.DeleteConsultationByID)
 	router.Handle(utils.DELETE_CONSULTATIE_BY_ID_ENDPOINT, consultatieDeleteByIDHandler).Methods("DELETE")
@@ -76,3 +112,191 @@ func loadCrudRoutes(router *mux.Router, consultatieController *controllers.Consu
 
 	log.Println("[CONSULTATION] All CRUD routes for consultatie entity loaded successfully.")
 }
+
+// loadAttachmentRoutes loads the routes of the files attached to patients, consultations and investigations
+func loadAttachmentRoutes(router *mux.Router, consultatieController *controllers.ConsultationController) {
+	log.Println("[CONSULTATION] Loading attachment routes...")
+
+	attachmentUploadHandler := http.HandlerFunc(consultatieController.UploadAttachment)
+	router.Handle(utils.ATTACHMENTS_ENDPOINT, attachmentUploadHandler).Methods("POST")
+	log.Printf("[CONSULTATION] Route POST %s registered.", utils.ATTACHMENTS_ENDPOINT)
+
+	attachmentFetchAllHandler := http.HandlerFunc(consultatieController.GetAttachments)
+	router.Handle(utils.ATTACHMENTS_ENDPOINT, attachmentFetchAllHandler).Methods("GET")
+	log.Printf("[CONSULTATION] Route GET %s registered.", utils.ATTACHMENTS_ENDPOINT)
+
+	attachmentFetchByIDHandler := http.HandlerFunc(consultatieController.GetAttachmentByID)
+	router.Handle(utils.ATTACHMENT_BY_ID_ENDPOINT, attachmentFetchByIDHandler).Methods("GET")
+	log.Printf("[CONSULTATION] Route GET %s registered.", utils.ATTACHMENT_BY_ID_ENDPOINT)
+
+	attachmentDownloadHandler := http.HandlerFunc(consultatieController.DownloadAttachment)
+	router.Handle(utils.ATTACHMENT_CONTENT_ENDPOINT, attachmentDownloadHandler).Methods("GET")
+	log.Printf("[CONSULTATION] Route GET %s registered.", utils.ATTACHMENT_CONTENT_ENDPOINT)
+
+	attachmentDeleteHandler := http.HandlerFunc(consultatieController.DeleteAttachmentByID)
+	router.Handle(utils.ATTACHMENT_BY_ID_ENDPOINT, attachmentDeleteHandler).Methods("DELETE")
+	log.Printf("[CONSULTATION] Route DELETE %s registered.", utils.ATTACHMENT_BY_ID_ENDPOINT)
+}
+
+// loadInvestigationRoutes loads the routes lab staff use to follow investigations from the order to the result
+func loadInvestigationRoutes(router *mux.Router, consultatieController *controllers.ConsultationController) {
+	log.Println("[CONSULTATION] Loading investigation routes...")
+
+	pendingInvestigationsHandler := http.HandlerFunc(consultatieController.GetPendingInvestigations)
+	router.Handle(utils.PENDING_INVESTIGATIONS_ENDPOINT, pendingInvestigationsHandler).Methods("GET")
+	log.Printf("[CONSULTATION] Route GET %s registered.", utils.PENDING_INVESTIGATIONS_ENDPOINT)
+
+	investigationOrderHandler := http.HandlerFunc(consultatieController.OrderInvestigation)
+	router.Handle(utils.INVESTIGATIONS_ENDPOINT, middleware.ValidateInvestigationOrderInfo(investigationOrderHandler)).Methods("POST")
+	log.Printf("[CONSULTATION] Route POST %s registered.", utils.INVESTIGATIONS_ENDPOINT)
+
+	investigationFetchAllHandler := http.HandlerFunc(consultatieController.GetInvestigations)
+	router.Handle(utils.INVESTIGATIONS_ENDPOINT, investigationFetchAllHandler).Methods("GET")
+	log.Printf("[CONSULTATION] Route GET %s registered.", utils.INVESTIGATIONS_ENDPOINT)
+
+	investigationFetchByIDHandler := http.HandlerFunc(consultatieController.GetInvestigationByID)
+	router.Handle(utils.INVESTIGATION_BY_ID_ENDPOINT, investigationFetchByIDHandler).Methods("GET")
+	log.Printf("[CONSULTATION] Route GET %s registered.", utils.INVESTIGATION_BY_ID_ENDPOINT)
+
+	investigationStatusHandler := http.HandlerFunc(consultatieController.UpdateInvestigationStatus)
+	router.Handle(utils.INVESTIGATION_STATUS_ENDPOINT, middleware.ValidateInvestigationStatusInfo(investigationStatusHandler)).Methods("PUT")
+	log.Printf("[CONSULTATION] Route PUT %s registered.", utils.INVESTIGATION_STATUS_ENDPOINT)
+
+	investigationResultHandler := http.HandlerFunc(consultatieController.RecordInvestigationResult)
+	router.Handle(utils.INVESTIGATION_RESULT_ENDPOINT, middleware.ValidateInvestigationResultInfo(investigationResultHandler)).Methods("PUT")
+	log.Printf("[CONSULTATION] Route PUT %s registered.", utils.INVESTIGATION_RESULT_ENDPOINT)
+}
+
+// loadLabResultRoutes loads the import of the results the lab sends as HL7 messages and the review of those that could
+// not be matched to an investigation
+func loadLabResultRoutes(router *mux.Router, consultatieController *controllers.ConsultationController) {
+	log.Println("[CONSULTATION] Loading lab result routes...")
+
+	labImportHandler := http.HandlerFunc(consultatieController.ImportLabResults)
+	router.Handle(utils.LAB_RESULTS_ENDPOINT, labImportHandler).Methods("POST")
+	log.Printf("[CONSULTATION] Route POST %s registered.", utils.LAB_RESULTS_ENDPOINT)
+
+	labReconciliationFetchAllHandler := http.HandlerFunc(consultatieController.GetLabReconciliationItems)
+	router.Handle(utils.LAB_RECONCILIATION_ENDPOINT, labReconciliationFetchAllHandler).Methods("GET")
+	log.Printf("[CONSULTATION] Route GET %s registered.", utils.LAB_RECONCILIATION_ENDPOINT)
+
+	labReconciliationFetchByIDHandler := http.HandlerFunc(consultatieController.GetLabReconciliationItemByID)
+	router.Handle(utils.LAB_RECONCILIATION_BY_ID_ENDPOINT, labReconciliationFetchByIDHandler).Methods("GET")
+	log.Printf("[CONSULTATION] Route GET %s registered.", utils.LAB_RECONCILIATION_BY_ID_ENDPOINT)
+
+	labResolveHandler := http.HandlerFunc(consultatieController.ResolveLabReconciliationItem)
+	router.Handle(utils.LAB_RECONCILIATION_RESOLVE_ENDPOINT, middleware.ValidateLabResolutionInfo(labResolveHandler)).Methods("POST")
+	log.Printf("[CONSULTATION] Route POST %s registered.", utils.LAB_RECONCILIATION_RESOLVE_ENDPOINT)
+
+	labDiscardHandler := http.HandlerFunc(consultatieController.DiscardLabReconciliationItem)
+	router.Handle(utils.LAB_RECONCILIATION_DISCARD_ENDPOINT, middleware.ValidateLabDiscardInfo(labDiscardHandler)).Methods("POST")
+	log.Printf("[CONSULTATION] Route POST %s registered.", utils.LAB_RECONCILIATION_DISCARD_ENDPOINT)
+}
+
+// loadRevisionRoutes loads the routes of the amendment trail of a consultation
+func loadRevisionRoutes(router *mux.Router, consultatieController *controllers.ConsultationController) {
+	log.Println("[CONSULTATION] Loading revision routes...")
+
+	revisionFetchAllHandler := http.HandlerFunc(consultatieController.GetConsultationRevisions)
+	router.Handle(utils.REVISIONS_ENDPOINT, revisionFetchAllHandler).Methods("GET")
+	log.Printf("[CONSULTATION] Route GET %s registered.", utils.REVISIONS_ENDPOINT)
+
+	revisionDiffHandler := http.HandlerFunc(consultatieController.DiffConsultationRevisions)
+	router.Handle(utils.REVISION_DIFF_ENDPOINT, revisionDiffHandler).Methods("GET")
+	log.Printf("[CONSULTATION] Route GET %s registered.", utils.REVISION_DIFF_ENDPOINT)
+
+	revisionFetchByVersionHandler := http.HandlerFunc(consultatieController.GetConsultationRevision)
+	router.Handle(utils.REVISION_BY_VERSION_ENDPOINT, revisionFetchByVersionHandler).Methods("GET")
+	log.Printf("[CONSULTATION] Route GET %s registered.", utils.REVISION_BY_VERSION_ENDPOINT)
+}
+
+// loadDiagnosisRoutes loads the autocomplete of the ICD-10 catalog the coded diagnoses are chosen from
+func loadDiagnosisRoutes(router *mux.Router, consultatieController *controllers.ConsultationController) {
+	log.Println("[CONSULTATION] Loading diagnosis routes...")
+
+	icd10SearchHandler := http.HandlerFunc(consultatieController.SearchICD10)
+	router.Handle(utils.ICD10_SEARCH_ENDPOINT, icd10SearchHandler).Methods("GET")
+	log.Printf("[CONSULTATION] Route GET %s registered.", utils.ICD10_SEARCH_ENDPOINT)
+}
+
+// loadPrescriptionRoutes loads the routes of the prescriptions written at a consultation and of the drug catalog they
+// are written from
+func loadPrescriptionRoutes(router *mux.Router, consultatieController *controllers.ConsultationController) {
+	log.Println("[CONSULTATION] Loading prescription routes...")
+
+	drugSearchHandler := http.HandlerFunc(consultatieController.SearchDrugs)
+	router.Handle(utils.DRUG_SEARCH_ENDPOINT, drugSearchHandler).Methods("GET")
+	log.Printf("[CONSULTATION] Route GET %s registered.", utils.DRUG_SEARCH_ENDPOINT)
+
+	patientPrescriptionsHandler := http.HandlerFunc(consultatieController.GetPatientPrescriptions)
+	router.Handle(utils.PATIENT_PRESCRIPTIONS_ENDPOINT, patientPrescriptionsHandler).Methods("GET")
+	log.Printf("[CONSULTATION] Route GET %s registered.", utils.PATIENT_PRESCRIPTIONS_ENDPOINT)
+
+	prescriptionIssueHandler := http.HandlerFunc(consultatieController.IssuePrescription)
+	router.Handle(utils.PRESCRIPTIONS_ENDPOINT, middleware.ValidatePrescriptionInfo(prescriptionIssueHandler)).Methods("POST")
+	log.Printf("[CONSULTATION] Route POST %s registered.", utils.PRESCRIPTIONS_ENDPOINT)
+
+	prescriptionFetchAllHandler := http.HandlerFunc(consultatieController.GetPrescriptions)
+	router.Handle(utils.PRESCRIPTIONS_ENDPOINT, prescriptionFetchAllHandler).Methods("GET")
+	log.Printf("[CONSULTATION] Route GET %s registered.", utils.PRESCRIPTIONS_ENDPOINT)
+
+	prescriptionFetchByIDHandler := http.HandlerFunc(consultatieController.GetPrescriptionByID)
+	router.Handle(utils.PRESCRIPTION_BY_ID_ENDPOINT, prescriptionFetchByIDHandler).Methods("GET")
+	log.Printf("[CONSULTATION] Route GET %s registered.", utils.PRESCRIPTION_BY_ID_ENDPOINT)
+
+	prescriptionStatusHandler := http.HandlerFunc(consultatieController.UpdatePrescriptionStatus)
+	router.Handle(utils.PRESCRIPTION_STATUS_ENDPOINT, middleware.ValidatePrescriptionStatusInfo(prescriptionStatusHandler)).Methods("PUT")
+	log.Printf("[CONSULTATION] Route PUT %s registered.", utils.PRESCRIPTION_STATUS_ENDPOINT)
+}
+
+// loadMeasurementRoutes loads the vital signs and clinical measurements of the patients, with their trends
+func loadMeasurementRoutes(router *mux.Router, consultatieController *controllers.ConsultationController) {
+	log.Println("[CONSULTATION] Loading measurement routes...")
+
+	measurementTypesHandler := http.HandlerFunc(consultatieController.GetMeasurementTypes)
+	router.Handle(utils.MEASUREMENT_TYPES_ENDPOINT, measurementTypesHandler).Methods("GET")
+	log.Printf("[CONSULTATION] Route GET %s registered.", utils.MEASUREMENT_TYPES_ENDPOINT)
+
+	measurementTrendHandler := http.HandlerFunc(consultatieController.GetMeasurementTrend)
+	router.Handle(utils.MEASUREMENT_TREND_ENDPOINT, measurementTrendHandler).Methods("GET")
+	log.Printf("[CONSULTATION] Route GET %s registered.", utils.MEASUREMENT_TREND_ENDPOINT)
+
+	measurementRecordHandler := http.HandlerFunc(consultatieController.RecordMeasurements)
+	router.Handle(utils.MEASUREMENTS_ENDPOINT, middleware.ValidateMeasurementInfo(measurementRecordHandler)).Methods("POST")
+	log.Printf("[CONSULTATION] Route POST %s registered.", utils.MEASUREMENTS_ENDPOINT)
+
+	measurementFetchAllHandler := http.HandlerFunc(consultatieController.GetMeasurements)
+	router.Handle(utils.MEASUREMENTS_ENDPOINT, measurementFetchAllHandler).Methods("GET")
+	log.Printf("[CONSULTATION] Route GET %s registered.", utils.MEASUREMENTS_ENDPOINT)
+
+	measurementFetchByIDHandler := http.HandlerFunc(consultatieController.GetMeasurementByID)
+	router.Handle(utils.MEASUREMENT_BY_ID_ENDPOINT, measurementFetchByIDHandler).Methods("GET")
+	log.Printf("[CONSULTATION] Route GET %s registered.", utils.MEASUREMENT_BY_ID_ENDPOINT)
+
+	measurementRetractHandler := http.HandlerFunc(consultatieController.RetractMeasurement)
+	router.Handle(utils.MEASUREMENT_RETRACTION_ENDPOINT, middleware.ValidateMeasurementRetractionInfo(measurementRetractHandler)).Methods("POST")
+	log.Printf("[CONSULTATION] Route POST %s registered.", utils.MEASUREMENT_RETRACTION_ENDPOINT)
+}
+
+// loadStatisticsRoutes loads the aggregations over the consultations, the diagnoses and the investigations
+func loadStatisticsRoutes(router *mux.Router, consultatieController *controllers.ConsultationController) {
+	log.Println("[CONSULTATION] Loading statistics routes...")
+
+	diagnosisStatisticsHandler := http.HandlerFunc(consultatieController.GetDiagnosisStatistics)
+	router.Handle(utils.DIAGNOSIS_STATISTICS_ENDPOINT, diagnosisStatisticsHandler).Methods("GET")
+	log.Printf("[CONSULTATION] Route GET %s registered.", utils.DIAGNOSIS_STATISTICS_ENDPOINT)
+
+	investigationStatisticsHandler := http.HandlerFunc(consultatieController.GetInvestigationStatistics)
+	router.Handle(utils.INVESTIGATION_STATISTICS_ENDPOINT, investigationStatisticsHandler).Methods("GET")
+	log.Printf("[CONSULTATION] Route GET %s registered.", utils.INVESTIGATION_STATISTICS_ENDPOINT)
+}
+
+// loadAppointmentRoutes loads the lookup of the consultations opened for appointments, the appointments themselves
+// belong to the appointment module
+func loadAppointmentRoutes(router *mux.Router, consultatieController *controllers.ConsultationController) {
+	log.Println("[CONSULTATION] Loading appointment routes...")
+
+	appointmentLinksHandler := http.HandlerFunc(consultatieController.GetAppointmentLinks)
+	router.Handle(utils.APPOINTMENT_LINKS_ENDPOINT, appointmentLinksHandler).Methods("GET")
+	log.Printf("[CONSULTATION] Route GET %s registered.", utils.APPOINTMENT_LINKS_ENDPOINT)
+}
@@ -9,9 +9,15 @@ import (
 )
 
 type AppConfig struct {
-	Server ServerConfig  `yaml:"server"`
-	Mongo  MongoDBConfig `yaml:"mongodb"`
-	Redis  RedisConfig   `yaml:"redis"`
+	Server       ServerConfig       `yaml:"server"`
+	Mongo        MongoDBConfig      `yaml:"mongodb"`
+	Redis        RedisConfig        `yaml:"redis"`
+	Encryption   EncryptionConfig   `yaml:"encryption"`
+	Attachments  AttachmentsConfig  `yaml:"attachments"`
+	ICD10        ICD10Config        `yaml:"icd10"`
+	Drugs        DrugsConfig        `yaml:"drugs"`
+	LabResults   LabResultsConfig   `yaml:"labResults"`
+	Measurements MeasurementsConfig `yaml:"measurements"`
 }
 
 type ServerConfig struct {
@@ -33,6 +39,47 @@ type MongoDBConfig struct {
 	Database string
 }
 
+// EncryptionConfig selects the key provider of the diagnostics and investigation results.
+// The rotation sweep moves them to the active key.
+type EncryptionConfig struct {
+	Provider                string `yaml:"provider"`
+	Keyring                 string `yaml:"keyring"`
+	RotationIntervalMinutes int    `yaml:"rotationIntervalMinutes"`
+}
+
+// AttachmentsConfig limits the files uploaded as attachments. Files of other content types are refused.
+type AttachmentsConfig struct {
+	MaxSizeMB    int      `yaml:"maxSizeMB"`
+	ContentTypes []string `yaml:"contentTypes"`
+}
+
+// ICD10Config points to the bundled catalog the coded diagnoses are checked against
+type ICD10Config struct {
+	Catalog string `yaml:"catalog"`
+}
+
+// DrugsConfig points to the bundled drug catalog prescriptions are written from, with the interactions they are checked for
+type DrugsConfig struct {
+	Catalog string `yaml:"catalog"`
+}
+
+// LabResultsConfig sets up the import of the results sent by the lab as HL7 v2 ORU messages. The messages are posted or
+// dropped as files in DropDirectory, which is disabled when empty. The patients are found by CNP in the patient module.
+// Times the lab sends without an offset are in TimeZone.
+type LabResultsConfig struct {
+	DropDirectory       string `yaml:"dropDirectory"`
+	PollIntervalSeconds int    `yaml:"pollIntervalSeconds"`
+	MaxMessageKB        int    `yaml:"maxMessageKB"`
+	PatientsURL         string `yaml:"patientsURL"`
+	TimeZone            string `yaml:"timeZone"`
+}
+
+// MeasurementsConfig sets up the vital signs and clinical measurements. The days, weeks and months of the trends
+// start at midnight in TimeZone.
+type MeasurementsConfig struct {
+	TimeZone string `yaml:"timeZone"`
+}
+
 // LoadConfig loads the configuration from the given file path
 func LoadConfig(filePath string) (*AppConfig, error) {
 	log.Println("[CONSULTATION] Loading configuration...")
@@ -1,13 +1,29 @@
 package utils
 
+import "github.com/mihnea1711/POS_Project/services/consultatii/internal/models"
+
 type contextKey string
 
 const CONFIG_PATH = "configs/config.yaml"
 
 const DECODED_CONSULTATION contextKey = "decodedConsultation"
+const DECODED_PATIENT_REASSIGNMENT contextKey = "decodedPatientReassignment"
+const DECODED_INVESTIGATION_ORDER contextKey = "decodedInvestigationOrder"
+const DECODED_INVESTIGATION_STATUS contextKey = "decodedInvestigationStatus"
+const DECODED_INVESTIGATION_RESULT contextKey = "decodedInvestigationResult"
+const DECODED_PRESCRIPTION contextKey = "decodedPrescription"
+const DECODED_PRESCRIPTION_STATUS contextKey = "decodedPrescriptionStatus"
+const DECODED_LAB_RESOLUTION contextKey = "decodedLabResolution"
+const DECODED_LAB_DISCARD contextKey = "decodedLabDiscard"
+const DECODED_MEASUREMENTS contextKey = "decodedMeasurements"
+const DECODED_MEASUREMENT_RETRACTION contextKey = "decodedMeasurementRetraction"
 
 const DATABASE_NAME = "consultations_db"
 const CONSULTATIE_TABLE = "consultation"
+const CONSULTATION_REVISION_TABLE = "consultation_revision"
+const PRESCRIPTION_TABLE = "prescription"
+const LAB_RECONCILIATION_TABLE = "lab_reconciliation"
+const MEASUREMENT_TABLE = "measurement"
 
 const (
 	COLUMN_ID_CONSULTATIE = "_id"
@@ -16,12 +32,242 @@ const (
 	COLUMN_DATE           = "date"
 	COLUMN_DIAGNOSTIC     = "diagnostic"
 	COLUMN_INVESTIGATII   = "investigations"
+	COLUMN_VERSION        = "version"
+	COLUMN_DIAGNOSES      = "diagnoses"
+
+	COLUMN_ID_APPOINTMENT = "id_appointment"
+
+	COLUMN_DIAGNOSIS_CODE        = "code"
+	COLUMN_DIAGNOSIS_DESCRIPTION = "description"
+
+	// COLUMN_TEXT_SCORE holds the relevance of a text search match, it is not stored
+	COLUMN_TEXT_SCORE = "score"
+)
+
+// CONSULTATION_TEXT_INDEX covers the plain text of a consultation, the coded diagnoses and the investigation names. The
+// free-text diagnostic and the results are encrypted, so they cannot be searched.
+const CONSULTATION_TEXT_INDEX = "consultation_text"
+
+const (
+	COLUMN_REVISION_ID_CONSULTATION = "id_consultation"
+	COLUMN_REVISION_VERSION         = "version"
+	COLUMN_REVISION_CONSULTATION    = "consultation"
+	COLUMN_REVISION_REASON          = "reason"
+	COLUMN_REVISION_CREATED_AT      = "created_at"
+)
+
+const (
+	COLUMN_PRESCRIPTION_ID            = "_id"
+	COLUMN_PRESCRIPTION_CONSULTATION  = "id_consultation"
+	COLUMN_PRESCRIPTION_ID_PATIENT    = "id_patient"
+	COLUMN_PRESCRIPTION_STATUS        = "status"
+	COLUMN_PRESCRIPTION_ISSUED_AT     = "issued_at"
+	COLUMN_PRESCRIPTION_DISPENSED_AT  = "dispensed_at"
+	COLUMN_PRESCRIPTION_CANCELED_AT   = "canceled_at"
+	COLUMN_PRESCRIPTION_CANCEL_REASON = "cancel_reason"
+)
+
+const (
+	COLUMN_LAB_ID               = "_id"
+	COLUMN_LAB_FINGERPRINT      = "fingerprint"
+	COLUMN_LAB_STATUS           = "status"
+	COLUMN_LAB_REASON           = "reason"
+	COLUMN_LAB_PATIENT_CNP      = "patient_cnp"
+	COLUMN_LAB_PATIENT_NAME     = "patient_name"
+	COLUMN_LAB_ID_PATIENT       = "id_patient"
+	COLUMN_LAB_RESULT           = "result"
+	COLUMN_LAB_MESSAGE          = "message"
+	COLUMN_LAB_RECEIVED_AT      = "received_at"
+	COLUMN_LAB_ID_CONSULTATION  = "id_consultation"
+	COLUMN_LAB_ID_INVESTIGATION = "id_investigation"
+	COLUMN_LAB_REVIEWED_AT      = "reviewed_at"
+	COLUMN_LAB_REVIEWED_BY      = "reviewed_by"
+	COLUMN_LAB_REVIEW_NOTE      = "review_note"
+)
+
+const (
+	COLUMN_MEASUREMENT_ID             = "_id"
+	COLUMN_MEASUREMENT_ID_PATIENT     = "id_patient"
+	COLUMN_MEASUREMENT_CONSULTATION   = "id_consultation"
+	COLUMN_MEASUREMENT_TYPE           = "type"
+	COLUMN_MEASUREMENT_VALUES         = "values"
+	COLUMN_MEASUREMENT_FLAG           = "flag"
+	COLUMN_MEASUREMENT_STATUS         = "status"
+	COLUMN_MEASUREMENT_MEASURED_AT    = "measured_at"
+	COLUMN_MEASUREMENT_RETRACTED_AT   = "retracted_at"
+	COLUMN_MEASUREMENT_RETRACTED_BY   = "retracted_by"
+	COLUMN_MEASUREMENT_RETRACT_REASON = "retract_reason"
+	COLUMN_MEASUREMENT_COMPONENT      = "component"
+	COLUMN_MEASUREMENT_VALUE          = "value"
+)
+
+// LAB_ENCRYPTED_COLUMNS are the values of a queued lab result encrypted at rest, each under its own field name
+var LAB_ENCRYPTED_COLUMNS = []string{COLUMN_LAB_PATIENT_CNP, COLUMN_LAB_PATIENT_NAME, COLUMN_LAB_RESULT, COLUMN_LAB_MESSAGE}
+
+// Consultations saved before versioning start at this version, their first revision is recorded on the first update
+const FIRST_CONSULTATION_VERSION = 1
+
+// Attachments are kept in a GridFS bucket, their links live in the metadata of the files collection
+const ATTACHMENT_BUCKET = "attachments"
+
+const (
+	COLUMN_ATTACHMENT_ID_PATIENT       = "metadata.id_patient"
+	COLUMN_ATTACHMENT_ID_CONSULTATION  = "metadata.id_consultation"
+	COLUMN_ATTACHMENT_ID_INVESTIGATION = "metadata.id_investigation"
+	COLUMN_ATTACHMENT_UPLOAD_DATE      = "uploadDate"
+)
+
+const (
+	ID_INVESTIGATION    = "id_investigation"
+	NAME                = "name"
+	PROCESSING_TIME     = "processing_time"
+	RESULT              = "result"
+	STATUS              = "status"
+	EXPECTED_COMPLETION = "expected_completion"
+	ORDERED_AT          = "ordered_at"
+	RESULTED_AT         = "resulted_at"
+)
+
+const (
+	INVESTIGATION_STATUS_ORDERED          models.InvestigationStatus = "ordered"
+	INVESTIGATION_STATUS_SAMPLE_COLLECTED models.InvestigationStatus = "sample_collected"
+	INVESTIGATION_STATUS_IN_PROGRESS      models.InvestigationStatus = "in_progress"
+	INVESTIGATION_STATUS_RESULTED         models.InvestigationStatus = "resulted"
+	INVESTIGATION_STATUS_CANCELED         models.InvestigationStatus = "canceled"
+)
+
+const (
+	LAB_RECONCILIATION_STATUS_OPEN      models.LabReconciliationStatus = "open"
+	LAB_RECONCILIATION_STATUS_RESOLVED  models.LabReconciliationStatus = "resolved"
+	LAB_RECONCILIATION_STATUS_DISCARDED models.LabReconciliationStatus = "discarded"
+)
+
+// What became of an order of a lab message
+const (
+	LAB_OUTCOME_RECORDED   = "recorded"
+	LAB_OUTCOME_CORRECTED  = "corrected"
+	LAB_OUTCOME_DUPLICATE  = "duplicate"
+	LAB_OUTCOME_PROGRESSED = "progressed"
+	LAB_OUTCOME_UNCHANGED  = "unchanged"
+	LAB_OUTCOME_QUEUED     = "queued"
+)
+
+// Why a lab result was queued for review instead of being recorded
+const (
+	LAB_REASON_UNREADABLE           = "unreadable_message"
+	LAB_REASON_PATIENT_UNIDENTIFIED = "patient_unidentified"
+	LAB_REASON_PATIENT_NOT_FOUND    = "patient_not_found"
+	LAB_REASON_PATIENT_AMBIGUOUS    = "patient_ambiguous"
+	LAB_REASON_PATIENT_MISMATCH     = "patient_mismatch"
+	LAB_REASON_ORDER_UNIDENTIFIED   = "order_unidentified"
+	LAB_REASON_ORDER_NOT_FOUND      = "order_not_found"
+	LAB_REASON_ORDER_CANCELED       = "order_canceled"
+	LAB_REASON_ORDER_NOT_PERFORMED  = "order_not_performed"
+	LAB_REASON_ALREADY_RESULTED     = "already_resulted"
+	LAB_REASON_NO_OBSERVATIONS      = "no_observations"
+)
+
+const (
+	LAB_SOURCE_HTTP = "http"
+	LAB_SOURCE_FILE = "file"
+)
+
+const (
+	DEFAULT_LAB_POLL_INTERVAL   = 30   // seconds
+	DEFAULT_LAB_MAX_MESSAGE_KB  = 1024 // a file or a request, with every message it holds
+	LAB_IMPORT_TIMEOUT          = 60   // seconds, for a file or a request
+	LAB_PATIENT_LOOKUP_TIMEOUT  = 5    // seconds
+	LAB_DROP_SETTLE_TIME        = 5    // seconds a dropped file is left alone after its last change, while it is written
+	LAB_SAVE_ATTEMPTS           = 3    // saves of a result retried after a concurrent update of the consultation
+	LAB_DROP_PROCESSED_DIR      = "processed"
+	LAB_DROP_FAILED_DIR         = "failed"
+	LAB_PATIENT_SEARCH_ENDPOINT = "/patients/search"
+)
+
+// LAB_MESSAGE_CONTENT_TYPES are accepted for the messages posted by the lab
+var LAB_MESSAGE_CONTENT_TYPES = []string{"x-application/hl7-v2+er7", "application/hl7-v2", "text/plain"}
+
+// PENDING_INVESTIGATION_STATUSES are the statuses of investigations still waiting for a result
+var PENDING_INVESTIGATION_STATUSES = []models.InvestigationStatus{
+	INVESTIGATION_STATUS_ORDERED,
+	INVESTIGATION_STATUS_SAMPLE_COLLECTED,
+	INVESTIGATION_STATUS_IN_PROGRESS,
+}
+
+const (
+	PRESCRIPTION_STATUS_ISSUED    models.PrescriptionStatus = "issued"
+	PRESCRIPTION_STATUS_DISPENSED models.PrescriptionStatus = "dispensed"
+	PRESCRIPTION_STATUS_CANCELED  models.PrescriptionStatus = "canceled"
+)
+
+// ACTIVE_PRESCRIPTION_STATUSES are the statuses of prescriptions the patient may still be taking, new prescriptions
+// are checked against the ones issued within ACTIVE_PRESCRIPTION_DAYS
+var ACTIVE_PRESCRIPTION_STATUSES = []models.PrescriptionStatus{
+	PRESCRIPTION_STATUS_ISSUED,
+	PRESCRIPTION_STATUS_DISPENSED,
+}
+
+const ACTIVE_PRESCRIPTION_DAYS = 90
+
+const (
+	MEASUREMENT_TYPE_BLOOD_PRESSURE    models.MeasurementType = "blood_pressure"
+	MEASUREMENT_TYPE_HEART_RATE        models.MeasurementType = "heart_rate"
+	MEASUREMENT_TYPE_RESPIRATORY_RATE  models.MeasurementType = "respiratory_rate"
+	MEASUREMENT_TYPE_TEMPERATURE       models.MeasurementType = "temperature"
+	MEASUREMENT_TYPE_OXYGEN_SATURATION models.MeasurementType = "oxygen_saturation"
+	MEASUREMENT_TYPE_WEIGHT            models.MeasurementType = "weight"
+	MEASUREMENT_TYPE_HEIGHT            models.MeasurementType = "height"
+	MEASUREMENT_TYPE_GLUCOSE           models.MeasurementType = "glucose"
+)
+
+const (
+	MEASUREMENT_COMPONENT_SYSTOLIC  = "systolic"
+	MEASUREMENT_COMPONENT_DIASTOLIC = "diastolic"
+)
+
+const (
+	MEASUREMENT_FLAG_NORMAL        models.MeasurementFlag = "normal"
+	MEASUREMENT_FLAG_LOW           models.MeasurementFlag = "low"
+	MEASUREMENT_FLAG_HIGH          models.MeasurementFlag = "high"
+	MEASUREMENT_FLAG_CRITICAL_LOW  models.MeasurementFlag = "critical_low"
+	MEASUREMENT_FLAG_CRITICAL_HIGH models.MeasurementFlag = "critical_high"
 )
+
+const (
+	MEASUREMENT_STATUS_RECORDED         models.MeasurementStatus = "recorded"
+	MEASUREMENT_STATUS_ENTERED_IN_ERROR models.MeasurementStatus = "entered_in_error"
+
+	MEASUREMENT_STATUS_ANY models.MeasurementStatus = "any" // lists the measurements whatever their status
+)
+
+// The buckets of a trend, as understood by $dateTrunc
+const (
+	MEASUREMENT_INTERVAL_DAY   = "day"
+	MEASUREMENT_INTERVAL_WEEK  = "week"
+	MEASUREMENT_INTERVAL_MONTH = "month"
+)
+
 const (
-	ID_INVESTIGATION = "id_investigation"
-	NAME             = "name"
-	PROCESSING_TIME  = "processing_time"
-	RESULT           = "result"
+	MAX_MEASUREMENT_READINGS      = 20  // readings recorded in one request
+	MEASUREMENT_CLOCK_SKEW        = 5   // minutes a measurement may be dated after the clock of the module
+	MEASUREMENT_TREND_DAYS        = 90  // period of a trend when none is given
+	MEASUREMENT_DAILY_TREND_DAYS  = 31  // longest period followed by day when no interval is given
+	MEASUREMENT_WEEKLY_TREND_DAYS = 366 // longest period followed by week, longer ones are followed by month
+)
+
+const (
+	WARNING_KIND_ALLERGY     = "allergy"
+	WARNING_KIND_INTERACTION = "interaction"
+	WARNING_KIND_DUPLICATE   = "duplicate"
+	WARNING_KIND_UNCHECKED   = "unchecked"
+)
+
+const (
+	WARNING_SEVERITY_INFO            = "info"
+	WARNING_SEVERITY_MINOR           = "minor"
+	WARNING_SEVERITY_MODERATE        = "moderate"
+	WARNING_SEVERITY_MAJOR           = "major"
+	WARNING_SEVERITY_CONTRAINDICATED = "contraindicated"
 )
 
 const (
@@ -44,10 +290,75 @@ const (
 	QUERY_DATE       = "date"
 	QUERY_PAGE       = "page"
 	QUERY_LIMIT      = "limit"
+
+	QUERY_CONSULTATION_ID  = "consultationID"
+	QUERY_INVESTIGATION_ID = "investigationID"
+	QUERY_DESCRIPTION      = "description"
+
+	QUERY_STATUS  = "status"
+	QUERY_OVERDUE = "overdue"
+
+	QUERY_CHANGED_BY = "changedBy"
+	QUERY_REASON     = "reason"
+	QUERY_FROM       = "from"
+	QUERY_TO         = "to"
+
+	QUERY_TEXT = "q"
+
+	QUERY_INVESTIGATION = "investigation"
+	QUERY_DIAGNOSIS     = "diagnosis"
+	QUERY_SORT          = "sort"
+
+	QUERY_APPOINTMENT_ID  = "appointmentID"
+	QUERY_APPOINTMENT_IDS = "appointmentIDs"
+
+	QUERY_TYPE     = "type"
+	QUERY_FLAGGED  = "flagged"
+	QUERY_INTERVAL = "interval"
+)
+
+// MAX_APPOINTMENT_LINKS caps the appointments looked up at once
+const MAX_APPOINTMENT_LINKS = 100
+
+const (
+	SORT_DATE_DESCENDING = "-date"
+	SORT_DATE_ASCENDING  = "date"
+	SORT_RELEVANCE       = "relevance"
+)
+
+const (
+	DEFAULT_TOP_DIAGNOSES = 5
+	MAX_TOP_DIAGNOSES     = 50
+)
+
+const (
+	DEFAULT_ICD10_SEARCH_LIMIT = 10
+	MAX_ICD10_SEARCH_LIMIT     = 50
+)
+
+const (
+	DEFAULT_DRUG_SEARCH_LIMIT = 10
+	MAX_DRUG_SEARCH_LIMIT     = 50
 )
 
 const TIME_FORMAT = "2006-01-02"
 
+const (
+	KEY_PROVIDER_FILE             = "file"
+	DEFAULT_KEY_ROTATION_INTERVAL = 60 // minutes
+	KEY_ROTATION_TIMEOUT          = 60 // seconds
+)
+
+const (
+	DEFAULT_ATTACHMENT_MAX_SIZE_MB = 20
+	ATTACHMENT_TIMEOUT             = 120 // seconds, uploads and downloads stream the whole file
+	ATTACHMENT_SNIFF_LENGTH        = 512 // bytes read to detect the content type
+	ATTACHMENT_FORM_FIELD          = "file"
+)
+
+// DEFAULT_ATTACHMENT_CONTENT_TYPES are accepted when the configuration lists none
+var DEFAULT_ATTACHMENT_CONTENT_TYPES = []string{"application/pdf", "image/jpeg", "image/png", "application/dicom", "text/plain"}
+
 const (
 	INSERT_CONSULTATIE_ENDPOINT = "/consultations"
 
@@ -76,7 +387,73 @@ const (
 	DELETE_CONSULTATIE_BY_PATIENT_DOCTOR_ID_ENDPOINT  = "/consultations/id/{" + DELETE_CONSULTATIE_BY_ID_PARAMETER + "}"
 	DELETE_CONSULTATIE_BY_PATIENT_DOCTOR_ID_PARAMETER = "id_patient_doctor"
 
+	REASSIGN_PATIENT_CONSULTATIONS_ENDPOINT = "/consultations/patients/reassign"
+
+	ERASE_PATIENT_CONSULTATIONS_ENDPOINT = "/consultations/patients/{" + FETCH_CONSULTATIE_BY_PATIENT_ID_PARAMETER + "}/erasure"
+
 	HEALTH_CHECK_ENDPOINT = "/consultations/health-check"
+
+	KEY_ROTATION_ENDPOINT = "/consultations/encryption/rotation"
+
+	ATTACHMENTS_ENDPOINT        = "/consultations/attachments"
+	ATTACHMENT_BY_ID_ENDPOINT   = "/consultations/attachments/{" + ATTACHMENT_ID_PARAMETER + "}"
+	ATTACHMENT_CONTENT_ENDPOINT = "/consultations/attachments/{" + ATTACHMENT_ID_PARAMETER + "}/content"
+	ATTACHMENT_ID_PARAMETER     = "id_attachment"
+
+	PENDING_INVESTIGATIONS_ENDPOINT = "/consultations/investigations"
+	INVESTIGATIONS_ENDPOINT         = "/consultations/{" + FETCH_CONSULTATIE_BY_ID_PARAMETER + "}/investigations"
+	INVESTIGATION_BY_ID_ENDPOINT    = "/consultations/{" + FETCH_CONSULTATIE_BY_ID_PARAMETER + "}/investigations/{" + INVESTIGATION_ID_PARAMETER + "}"
+	INVESTIGATION_STATUS_ENDPOINT   = "/consultations/{" + FETCH_CONSULTATIE_BY_ID_PARAMETER + "}/investigations/{" + INVESTIGATION_ID_PARAMETER + "}/status"
+	INVESTIGATION_RESULT_ENDPOINT   = "/consultations/{" + FETCH_CONSULTATIE_BY_ID_PARAMETER + "}/investigations/{" + INVESTIGATION_ID_PARAMETER + "}/result"
+	INVESTIGATION_ID_PARAMETER      = "id_investigation"
+
+	REVISIONS_ENDPOINT           = "/consultations/{" + FETCH_CONSULTATIE_BY_ID_PARAMETER + "}/revisions"
+	REVISION_DIFF_ENDPOINT       = "/consultations/{" + FETCH_CONSULTATIE_BY_ID_PARAMETER + "}/revisions/diff"
+	REVISION_BY_VERSION_ENDPOINT = "/consultations/{" + FETCH_CONSULTATIE_BY_ID_PARAMETER + "}/revisions/{" + REVISION_VERSION_PARAMETER + ":[0-9]+}"
+	REVISION_VERSION_PARAMETER   = "version"
+
+	ICD10_SEARCH_ENDPOINT = "/consultations/icd10"
+
+	APPOINTMENT_LINKS_ENDPOINT = "/consultations/appointments"
+
+	LAB_RESULTS_ENDPOINT                = "/consultations/lab-results"
+	LAB_RECONCILIATION_ENDPOINT         = "/consultations/lab-results/reconciliation"
+	LAB_RECONCILIATION_BY_ID_ENDPOINT   = "/consultations/lab-results/reconciliation/{" + LAB_RECONCILIATION_ID_PARAMETER + "}"
+	LAB_RECONCILIATION_RESOLVE_ENDPOINT = "/consultations/lab-results/reconciliation/{" + LAB_RECONCILIATION_ID_PARAMETER + "}/resolve"
+	LAB_RECONCILIATION_DISCARD_ENDPOINT = "/consultations/lab-results/reconciliation/{" + LAB_RECONCILIATION_ID_PARAMETER + "}/discard"
+	LAB_RECONCILIATION_ID_PARAMETER     = "id_item"
+
+	DIAGNOSIS_STATISTICS_ENDPOINT     = "/consultations/statistics/diagnoses"
+	INVESTIGATION_STATISTICS_ENDPOINT = "/consultations/statistics/investigations"
+
+	MEASUREMENTS_ENDPOINT           = "/consultations/measurements"
+	MEASUREMENT_TYPES_ENDPOINT      = "/consultations/measurements/types"
+	MEASUREMENT_TREND_ENDPOINT      = "/consultations/measurements/trend"
+	MEASUREMENT_BY_ID_ENDPOINT      = "/consultations/measurements/{" + MEASUREMENT_ID_PARAMETER + "}"
+	MEASUREMENT_RETRACTION_ENDPOINT = "/consultations/measurements/{" + MEASUREMENT_ID_PARAMETER + "}/retraction"
+	MEASUREMENT_ID_PARAMETER        = "id_measurement"
+
+	DRUG_SEARCH_ENDPOINT           = "/consultations/drugs"
+	PATIENT_PRESCRIPTIONS_ENDPOINT = "/consultations/prescriptions"
+	PRESCRIPTIONS_ENDPOINT         = "/consultations/{" + FETCH_CONSULTATIE_BY_ID_PARAMETER + "}/prescriptions"
+	PRESCRIPTION_BY_ID_ENDPOINT    = "/consultations/{" + FETCH_CONSULTATIE_BY_ID_PARAMETER + "}/prescriptions/{" + PRESCRIPTION_ID_PARAMETER + "}"
+	PRESCRIPTION_STATUS_ENDPOINT   = "/consultations/{" + FETCH_CONSULTATIE_BY_ID_PARAMETER + "}/prescriptions/{" + PRESCRIPTION_ID_PARAMETER + "}/status"
+	PRESCRIPTION_ID_PARAMETER      = "id_prescription"
 )
 
 const DUPLICATE_KEY_ERROR_CODE = 11000
+
+const ErasureActionRetained models.ErasureAction = "retained"
+
+const (
+	ERASURE_CATEGORY_CONSULTATIONS = "consultations"
+	ERASURE_CATEGORY_ATTACHMENTS   = "attachments"
+	ERASURE_CATEGORY_REVISIONS     = "consultation revisions"
+	ERASURE_CATEGORY_PRESCRIPTIONS = "prescriptions"
+	ERASURE_CATEGORY_MEASUREMENTS  = "measurements"
+)
+
+const (
+	REVISION_REASON_CREATED           = "created"
+	REVISION_REASON_BEFORE_VERSIONING = "recorded before versioning"
+)
@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"time"
+
+	"github.com/mihnea1711/POS_Project/services/consultatii/internal/models"
+)
+
+// investigationTransitions lists the statuses an investigation may move to from each status.
+// Resulted and canceled investigations are final, a sample is not needed for every investigation.
+var investigationTransitions = map[models.InvestigationStatus][]models.InvestigationStatus{
+	INVESTIGATION_STATUS_ORDERED:          {INVESTIGATION_STATUS_SAMPLE_COLLECTED, INVESTIGATION_STATUS_IN_PROGRESS, INVESTIGATION_STATUS_RESULTED, INVESTIGATION_STATUS_CANCELED},
+	INVESTIGATION_STATUS_SAMPLE_COLLECTED: {INVESTIGATION_STATUS_IN_PROGRESS, INVESTIGATION_STATUS_RESULTED, INVESTIGATION_STATUS_CANCELED},
+	INVESTIGATION_STATUS_IN_PROGRESS:      {INVESTIGATION_STATUS_RESULTED, INVESTIGATION_STATUS_CANCELED},
+}
+
+// IsInvestigationStatus tells whether the status is one of the known investigation statuses
+func IsInvestigationStatus(status models.InvestigationStatus) bool {
+	if status == INVESTIGATION_STATUS_RESULTED || status == INVESTIGATION_STATUS_CANCELED {
+		return true
+	}
+	_, ok := investigationTransitions[status]
+	return ok
+}
+
+// CanTransitionInvestigation tells whether an investigation may move from one status to the other
+func CanTransitionInvestigation(from, to models.InvestigationStatus) bool {
+	for _, allowed := range investigationTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// IsInvestigationPending tells whether the investigation still waits for its result
+func IsInvestigationPending(status models.InvestigationStatus) bool {
+	for _, pending := range PENDING_INVESTIGATION_STATUSES {
+		if pending == status {
+			return true
+		}
+	}
+	return false
+}
+
+// ExpectedInvestigationCompletion is the moment the result should be ready: the processing time counts from the
+// collection of the sample, or from the order while no sample was collected
+func ExpectedInvestigationCompletion(investigation *models.Investigation) time.Time {
+	start := investigation.OrderedAt
+	if investigation.SampleCollectedAt != nil {
+		start = *investigation.SampleCollectedAt
+	}
+	return start.Add(time.Duration(investigation.ProcessingTime) * time.Minute)
+}
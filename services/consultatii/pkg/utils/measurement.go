@@ -0,0 +1,144 @@
+package utils
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/mihnea1711/POS_Project/services/consultatii/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ExtractMeasurementFilters returns the filter of a measurement listing, which is narrowed to a patient, a consultation
+// or both. The measurements entered in error are left out unless status asks for them, or for any status.
+//
+//   - type matches the type of measurement
+//   - flagged, when true, keeps the measurements with a value out of its reference range
+//   - from and to bound the time of the measurement by day in location, both inclusive
+func ExtractMeasurementFilters(r *http.Request, location *time.Location) (bson.M, error) {
+	query := r.URL.Query()
+	filter := bson.M{}
+
+	if patientID := query.Get(QUERY_PATIENT_ID); patientID != "" {
+		id, err := strconv.Atoi(patientID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %v", QUERY_PATIENT_ID, err)
+		}
+		filter[COLUMN_MEASUREMENT_ID_PATIENT] = id
+	}
+	if consultationID := query.Get(QUERY_CONSULTATION_ID); consultationID != "" {
+		id, err := primitive.ObjectIDFromHex(consultationID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %v", QUERY_CONSULTATION_ID, err)
+		}
+		filter[COLUMN_MEASUREMENT_CONSULTATION] = id
+	}
+	if len(filter) == 0 {
+		return nil, fmt.Errorf("%s or %s is required", QUERY_PATIENT_ID, QUERY_CONSULTATION_ID)
+	}
+
+	if measurementType := query.Get(QUERY_TYPE); measurementType != "" {
+		filter[COLUMN_MEASUREMENT_TYPE] = measurementType
+	}
+
+	switch status := models.MeasurementStatus(query.Get(QUERY_STATUS)); status {
+	case "":
+		filter[COLUMN_MEASUREMENT_STATUS] = MEASUREMENT_STATUS_RECORDED
+	case MEASUREMENT_STATUS_ANY:
+	case MEASUREMENT_STATUS_RECORDED, MEASUREMENT_STATUS_ENTERED_IN_ERROR:
+		filter[COLUMN_MEASUREMENT_STATUS] = status
+	default:
+		return nil, fmt.Errorf("invalid %s %q", QUERY_STATUS, status)
+	}
+
+	if flagged := query.Get(QUERY_FLAGGED); flagged != "" {
+		isFlagged, err := strconv.ParseBool(flagged)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %v", QUERY_FLAGGED, err)
+		}
+		if isFlagged {
+			filter[COLUMN_MEASUREMENT_FLAG] = bson.M{"$ne": MEASUREMENT_FLAG_NORMAL}
+		}
+	}
+
+	start, end, err := extractMeasurementDays(query.Get(QUERY_FROM), query.Get(QUERY_TO), location)
+	if err != nil {
+		return nil, err
+	}
+	measuredAt := bson.M{}
+	if !start.IsZero() {
+		measuredAt["$gte"] = start
+	}
+	if !end.IsZero() {
+		measuredAt["$lt"] = end
+	}
+	if len(measuredAt) > 0 {
+		filter[COLUMN_MEASUREMENT_MEASURED_AT] = measuredAt
+	}
+
+	return filter, nil
+}
+
+// ExtractMeasurementPeriod returns the period of a trend, from the start of the from day to the end of the to day in
+// location. Without to the period ends with today, without from it covers the MEASUREMENT_TREND_DAYS before its end.
+func ExtractMeasurementPeriod(r *http.Request, location *time.Location, now time.Time) (time.Time, time.Time, error) {
+	start, end, err := extractMeasurementDays(r.URL.Query().Get(QUERY_FROM), r.URL.Query().Get(QUERY_TO), location)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	if end.IsZero() {
+		year, month, day := now.In(location).Date()
+		end = time.Date(year, month, day+1, 0, 0, 0, 0, location)
+	}
+	if start.IsZero() {
+		start = end.AddDate(0, 0, -MEASUREMENT_TREND_DAYS)
+	}
+	if !start.Before(end) {
+		return time.Time{}, time.Time{}, fmt.Errorf("%s must not be after %s", QUERY_FROM, QUERY_TO)
+	}
+	return start, end, nil
+}
+
+// MeasurementInterval returns the buckets of a trend: those asked for, otherwise days up to a month, weeks up to a
+// year and months beyond
+func MeasurementInterval(requested string, from, to time.Time) (string, error) {
+	switch requested {
+	case MEASUREMENT_INTERVAL_DAY, MEASUREMENT_INTERVAL_WEEK, MEASUREMENT_INTERVAL_MONTH:
+		return requested, nil
+	case "":
+	default:
+		return "", fmt.Errorf("invalid %s %q, use %s, %s or %s", QUERY_INTERVAL, requested, MEASUREMENT_INTERVAL_DAY, MEASUREMENT_INTERVAL_WEEK, MEASUREMENT_INTERVAL_MONTH)
+	}
+
+	switch days := to.Sub(from).Hours() / 24; {
+	case days <= MEASUREMENT_DAILY_TREND_DAYS:
+		return MEASUREMENT_INTERVAL_DAY, nil
+	case days <= MEASUREMENT_WEEKLY_TREND_DAYS:
+		return MEASUREMENT_INTERVAL_WEEK, nil
+	}
+	return MEASUREMENT_INTERVAL_MONTH, nil
+}
+
+// extractMeasurementDays parses the from and to days in location, the to day included. A day not given is left zero.
+func extractMeasurementDays(from, to string, location *time.Location) (time.Time, time.Time, error) {
+	var start, end time.Time
+	var err error
+	if from != "" {
+		if start, err = time.ParseInLocation(TIME_FORMAT, from, location); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid %s: %v", QUERY_FROM, err)
+		}
+	}
+	if to != "" {
+		if end, err = time.ParseInLocation(TIME_FORMAT, to, location); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid %s: %v", QUERY_TO, err)
+		}
+		end = end.AddDate(0, 0, 1)
+	}
+	if !start.IsZero() && !end.IsZero() && !start.Before(end) {
+		return time.Time{}, time.Time{}, fmt.Errorf("%s must not be after %s", QUERY_FROM, QUERY_TO)
+	}
+	return start, end, nil
+}
@@ -0,0 +1,31 @@
+package utils
+
+import "github.com/mihnea1711/POS_Project/services/consultatii/internal/models"
+
+// prescriptionTransitions lists the statuses a prescription may move to from each status. Dispensed and canceled
+// prescriptions are final, a dispensed prescription is not taken back.
+var prescriptionTransitions = map[models.PrescriptionStatus][]models.PrescriptionStatus{
+	PRESCRIPTION_STATUS_ISSUED: {PRESCRIPTION_STATUS_DISPENSED, PRESCRIPTION_STATUS_CANCELED},
+}
+
+// CanTransitionPrescription tells whether a prescription may move from one status to the other
+func CanTransitionPrescription(from, to models.PrescriptionStatus) bool {
+	for _, allowed := range prescriptionTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// IsBlockingWarning tells whether a warning keeps a prescription from being issued until the doctor acknowledges it:
+// every allergy, and the interactions that are major or contraindicated
+func IsBlockingWarning(warning models.PrescriptionWarning) bool {
+	switch {
+	case warning.Kind == WARNING_KIND_ALLERGY:
+		return true
+	case warning.Severity == WARNING_SEVERITY_MAJOR, warning.Severity == WARNING_SEVERITY_CONTRAINDICATED:
+		return true
+	}
+	return false
+}
@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"os"
 	"reflect"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -114,50 +115,39 @@ func ExtractPaginationParams(r *http.Request) (int, int) {
 	return limit, page
 }
 
-func ExtractQueryParams(r *http.Request) bson.D {
-	filter := bson.D{}
-
-	queryParameters := r.URL.Query()
-	for key, values := range queryParameters {
-		if len(values) > 0 {
-			// Convert the key to its BSON counterpart (replace hyphens with underscores)
-			bsonKey := strings.ReplaceAll(key, "-", "_")
-
-			// Check for equality filters (when there's only one value)
-			if len(values) == 1 {
-				filter = append(filter, bson.E{Key: bsonKey, Value: values[0]})
-			} else {
-				// If there are multiple values for the same key, create an "in" filter
-				filter = append(filter, bson.E{Key: bsonKey, Value: bson.M{"$in": values}})
-			}
-		}
-	}
-	return filter
-}
-
-// ExtractFiltersFromRequest extracts query parameters from the request and constructs a map of filters.
+// ExtractFiltersFromRequest builds the filter of a consultation search from the query of the request. Only the known
+// query keys are accepted and each is mapped to its own condition, so no query value reaches the filter as an operator
+// or a field name.
+//
+//   - patientID and doctorID match the patient and the doctor, appointmentID the appointment fulfilled
+//   - date matches the exact date, from and to bound the date by day, both inclusive
+//   - q is a text search over the coded diagnoses and the investigation names only, the encrypted free-text diagnostic
+//     and investigation results are not searched
+//   - investigation matches the investigations whose name contains it, in any case
+//   - diagnosis matches the consultations with an ICD-10 code starting with it
 func ExtractFiltersFromRequest(r *http.Request) (bson.M, error) {
 	filters := bson.M{}
+	query := r.URL.Query()
 
 	// Check for unknown filters
-	for key := range r.URL.Query() {
+	for key := range query {
 		if !isExpectedFilter(key) {
-			log.Printf("[APPOINTMENT] ExtractFiltersFromRequest: Unknown filter: %s", key)
+			log.Printf("[CONSULTATION] ExtractFiltersFromRequest: Unknown filter: %s", key)
 			return nil, fmt.Errorf("unknown filter: %s", key)
 		}
 	}
 
 	// Parse query parameters
-	patientID := r.URL.Query().Get(QUERY_PATIENT_ID)
-	doctorID := r.URL.Query().Get(QUERY_DOCTOR_ID)
-	date := r.URL.Query().Get(QUERY_DATE)
+	patientID := query.Get(QUERY_PATIENT_ID)
+	doctorID := query.Get(QUERY_DOCTOR_ID)
+	date := query.Get(QUERY_DATE)
 
 	// Convert string values to appropriate types
 	if patientID != "" {
 		if id, err := strconv.Atoi(patientID); err == nil {
 			filters[COLUMN_ID_PATIENT] = id
 		} else {
-			log.Printf("[APPOINTMENT] ExtractFiltersFromRequest: Failed to parse patientID: %v", err)
+			log.Printf("[CONSULTATION] ExtractFiltersFromRequest: Failed to parse patientID: %v", err)
 			return nil, fmt.Errorf("invalid patientID: %v", err)
 		}
 	}
@@ -165,30 +155,115 @@ func ExtractFiltersFromRequest(r *http.Request) (bson.M, error) {
 		if id, err := strconv.Atoi(doctorID); err == nil {
 			filters[COLUMN_ID_DOCTOR] = id
 		} else {
-			log.Printf("[APPOINTMENT] ExtractFiltersFromRequest: Failed to parse doctorID: %v", err)
+			log.Printf("[CONSULTATION] ExtractFiltersFromRequest: Failed to parse doctorID: %v", err)
 			return nil, fmt.Errorf("invalid doctorID: %v", err)
 		}
 	}
+	if appointmentID := query.Get(QUERY_APPOINTMENT_ID); appointmentID != "" {
+		id, err := strconv.Atoi(appointmentID)
+		if err != nil {
+			log.Printf("[CONSULTATION] ExtractFiltersFromRequest: Failed to parse appointmentID: %v", err)
+			return nil, fmt.Errorf("invalid appointmentID: %v", err)
+		}
+		filters[COLUMN_ID_APPOINTMENT] = id
+	}
 	if date != "" {
 		if t, err := time.Parse(TIME_FORMAT, date); err == nil {
 			filters[COLUMN_DATE] = bson.M{"$eq": t}
 		} else {
-			log.Printf("[APPOINTMENT] ExtractFiltersFromRequest: Failed to parse date: %v", err)
+			log.Printf("[CONSULTATION] ExtractFiltersFromRequest: Failed to parse date: %v", err)
 			return nil, fmt.Errorf("invalid date: %v", err)
 		}
 	}
 
+	dateRange, err := extractDateRange(query.Get(QUERY_FROM), query.Get(QUERY_TO))
+	if err != nil {
+		log.Printf("[CONSULTATION] ExtractFiltersFromRequest: %v", err)
+		return nil, err
+	}
+	if len(dateRange) > 0 {
+		if date != "" {
+			return nil, fmt.Errorf("%s cannot be combined with %s and %s", QUERY_DATE, QUERY_FROM, QUERY_TO)
+		}
+		filters[COLUMN_DATE] = dateRange
+	}
+
+	if text := strings.TrimSpace(query.Get(QUERY_TEXT)); text != "" {
+		filters["$text"] = bson.M{"$search": text}
+	}
+	if name := strings.TrimSpace(query.Get(QUERY_INVESTIGATION)); name != "" {
+		filters[COLUMN_INVESTIGATII+"."+NAME] = bson.M{"$regex": regexp.QuoteMeta(name), "$options": "i"}
+	}
+	if code := strings.ToUpper(strings.TrimSpace(query.Get(QUERY_DIAGNOSIS))); code != "" {
+		filters[COLUMN_DIAGNOSES+"."+COLUMN_DIAGNOSIS_CODE] = bson.M{"$regex": "^" + regexp.QuoteMeta(code)}
+	}
+
 	return filters, nil
 }
 
+// extractDateRange bounds the date of the consultations by day, the to day included
+func extractDateRange(from, to string) (bson.M, error) {
+	dateRange := bson.M{}
+
+	var start, end time.Time
+	var err error
+	if from != "" {
+		if start, err = time.Parse(TIME_FORMAT, from); err != nil {
+			return nil, fmt.Errorf("invalid %s: %v", QUERY_FROM, err)
+		}
+		dateRange["$gte"] = start
+	}
+	if to != "" {
+		if end, err = time.Parse(TIME_FORMAT, to); err != nil {
+			return nil, fmt.Errorf("invalid %s: %v", QUERY_TO, err)
+		}
+		dateRange["$lt"] = end.AddDate(0, 0, 1)
+	}
+	if from != "" && to != "" && end.Before(start) {
+		return nil, fmt.Errorf("%s must not be after %s", QUERY_FROM, QUERY_TO)
+	}
+
+	return dateRange, nil
+}
+
+// ExtractSortFromRequest returns the order of a consultation search: the latest first unless sort asks for the earliest
+// first, or for the best text matches first. A text search is ordered by relevance when no sort is given.
+func ExtractSortFromRequest(r *http.Request) (bson.D, error) {
+	sortBy := r.URL.Query().Get(QUERY_SORT)
+	if sortBy == "" && r.URL.Query().Get(QUERY_TEXT) != "" {
+		sortBy = SORT_RELEVANCE
+	}
+
+	switch sortBy {
+	case "", SORT_DATE_DESCENDING:
+		return bson.D{{Key: COLUMN_DATE, Value: -1}, {Key: COLUMN_ID_CONSULTATIE, Value: -1}}, nil
+	case SORT_DATE_ASCENDING:
+		return bson.D{{Key: COLUMN_DATE, Value: 1}, {Key: COLUMN_ID_CONSULTATIE, Value: 1}}, nil
+	case SORT_RELEVANCE:
+		if r.URL.Query().Get(QUERY_TEXT) == "" {
+			return nil, fmt.Errorf("sorting by %s needs a %s text query", SORT_RELEVANCE, QUERY_TEXT)
+		}
+		return bson.D{{Key: COLUMN_TEXT_SCORE, Value: bson.M{"$meta": "textScore"}}, {Key: COLUMN_DATE, Value: -1}}, nil
+	default:
+		return nil, fmt.Errorf("unknown %s: %s, expected one of %s, %s, %s", QUERY_SORT, sortBy, SORT_DATE_DESCENDING, SORT_DATE_ASCENDING, SORT_RELEVANCE)
+	}
+}
+
 // isExpectedFilter checks if a filter name is one of the expected names.
 func isExpectedFilter(filterName string) bool {
 	expectedFilters := map[string]struct{}{
-		QUERY_PATIENT_ID: {},
-		QUERY_DOCTOR_ID:  {},
-		QUERY_DATE:       {},
-		QUERY_PAGE:       {},
-		QUERY_LIMIT:      {},
+		QUERY_PATIENT_ID:     {},
+		QUERY_DOCTOR_ID:      {},
+		QUERY_APPOINTMENT_ID: {},
+		QUERY_DATE:           {},
+		QUERY_FROM:           {},
+		QUERY_TO:             {},
+		QUERY_TEXT:           {},
+		QUERY_INVESTIGATION:  {},
+		QUERY_DIAGNOSIS:      {},
+		QUERY_SORT:           {},
+		QUERY_PAGE:           {},
+		QUERY_LIMIT:          {},
 	}
 
 	_, ok := expectedFilters[filterName]
@@ -18,6 +18,9 @@ docker network prune --force
 echo "[CONSULTATION] Removing unused images..."
 docker image prune --force
 
+echo "[CONSULTATION] Creating the encryption keyring if missing..."
+./scripts/keyring.sh --create
+
 echo "[CONSULTATION] Building Docker images..."
 docker compose build
 
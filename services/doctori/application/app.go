@@ -46,7 +46,7 @@ func New(config *config.AppConfig, parentCtx context.Context) (*App, error) {
 	log.Println("[DOCTOR] Redis connection successfully established.")
 
 	// Setup router for the app
-	router := routes.SetupRoutes(parentCtx, app.database, app.rdb)
+	router := routes.SetupRoutes(parentCtx, app.database, app.rdb, config)
 	app.router = router
 
 	log.Println("[DOCTOR] Application successfully initialized.")
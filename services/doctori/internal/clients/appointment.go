@@ -0,0 +1,61 @@
+package clients
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/mihnea1711/POS_Project/services/doctori/internal/models"
+	"github.com/mihnea1711/POS_Project/services/doctori/pkg/config"
+	"github.com/mihnea1711/POS_Project/services/doctori/pkg/utils"
+)
+
+// AppointmentClient talks to the appointment module
+type AppointmentClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+func NewAppointmentClient(serviceConfig config.ServiceConfig) *AppointmentClient {
+	return &AppointmentClient{
+		baseURL:    fmt.Sprintf("http://%s:%d", serviceConfig.Host, serviceConfig.Port),
+		httpClient: &http.Client{},
+	}
+}
+
+// StartRescheduling asks the appointment module to reschedule the future appointments of an unavailable doctor.
+// A job already open for the doctor is not an error.
+func (c *AppointmentClient) StartRescheduling(ctx context.Context, doctorID int) error {
+	body, err := json.Marshal(models.ReschedulingRequest{IDDoctor: doctorID})
+	if err != nil {
+		return fmt.Errorf("failed to marshal rescheduling request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+utils.APPOINTMENT_CREATE_RESCHEDULING_JOB_ENDPOINT, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create rescheduling request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send rescheduling request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var response models.ResponseData
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		log.Printf("[DOCTOR] Failed to decode rescheduling response: %v", err)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusCreated, http.StatusConflict:
+		log.Printf("[DOCTOR] Appointment module answered the rescheduling request for doctor %d: %s", doctorID, response.Message)
+		return nil
+	default:
+		return fmt.Errorf("appointment module responded with status %d: %s", resp.StatusCode, response.Error)
+	}
+}
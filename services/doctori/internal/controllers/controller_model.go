@@ -5,13 +5,15 @@ import (
 	"log"
 	"net/http"
 
+	"github.com/mihnea1711/POS_Project/services/doctori/internal/clients"
 	"github.com/mihnea1711/POS_Project/services/doctori/internal/database"
 	"github.com/mihnea1711/POS_Project/services/doctori/internal/models"
 	"github.com/mihnea1711/POS_Project/services/doctori/pkg/utils"
 )
 
 type DoctorController struct {
-	DbConn database.Database
+	DbConn            database.Database
+	AppointmentClient *clients.AppointmentClient
 }
 
 func (pc *DoctorController) handleContextTimeout(ctx context.Context, w http.ResponseWriter) {
@@ -68,6 +68,12 @@ func (dController *DoctorController) ToggleDoctorActivity(w http.ResponseWriter,
 	}
 
 	log.Printf("[DOCTOR] Successfully updated doctor with user ID %d", reqData.IDUser)
+
+	// The appointments of a deactivated doctor have to be moved or canceled
+	if !reqData.IsActive {
+		dController.requestRescheduling(reqData.IDUser)
+	}
+
 	// Create a success response using ResponseData
 	utils.RespondWithJSON(w, http.StatusOK, models.ResponseData{
 		Message: fmt.Sprintf("Doctor with user ID %d updated successfully", reqData.IDUser),
@@ -76,3 +82,22 @@ func (dController *DoctorController) ToggleDoctorActivity(w http.ResponseWriter,
 		},
 	})
 }
+
+// requestRescheduling starts, in the background, the rescheduling of the appointments of a deactivated doctor.
+// The deactivation is not undone if the appointment module cannot be reached, an admin can start the job later.
+func (dController *DoctorController) requestRescheduling(doctorUserID int) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), utils.RESCHEDULING_REQUEST_TIMEOUT*time.Second)
+		defer cancel()
+
+		doctor, err := dController.DbConn.FetchDoctorByUserID(ctx, doctorUserID)
+		if err != nil {
+			log.Printf("[DOCTOR] Failed to fetch doctor with user ID %d for rescheduling: %v", doctorUserID, err)
+			return
+		}
+
+		if err := dController.AppointmentClient.StartRescheduling(ctx, doctor.IDDoctor); err != nil {
+			log.Printf("[DOCTOR] Failed to start rescheduling the appointments of doctor %d: %v", doctor.IDDoctor, err)
+		}
+	}()
+}
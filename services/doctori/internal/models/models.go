@@ -37,3 +37,8 @@ type ActivityData struct {
 	IsActive bool `json:"isActive"`
 	IDUser   int  `json:"idUser"`
 }
+
+// ReschedulingRequest asks the appointment module to reschedule the appointments of a doctor
+type ReschedulingRequest struct {
+	IDDoctor int `json:"idDoctor"`
+}
@@ -7,14 +7,16 @@ import (
 	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/mihnea1711/POS_Project/services/doctori/internal/clients"
 	"github.com/mihnea1711/POS_Project/services/doctori/internal/controllers"
 	"github.com/mihnea1711/POS_Project/services/doctori/internal/database"
 	"github.com/mihnea1711/POS_Project/services/doctori/internal/database/redis"
 	"github.com/mihnea1711/POS_Project/services/doctori/internal/middleware"
+	"github.com/mihnea1711/POS_Project/services/doctori/pkg/config"
 	"github.com/mihnea1711/POS_Project/services/doctori/pkg/utils"
 )
 
-func SetupRoutes(parentCtx context.Context, dbConn database.Database, rdb *redis.RedisClient) *mux.Router {
+func SetupRoutes(parentCtx context.Context, dbConn database.Database, rdb *redis.RedisClient, appConfig *config.AppConfig) *mux.Router {
 	log.Println("[DOCTOR] Setting up rate limiter...")
 	rateLimiter := middleware.NewRedisRateLimiter(rdb.GetClient(), parentCtx, utils.LIMITER_REQUESTS_ALLOWED, utils.LIMITER_MINUTE_MULTIPLIER*time.Minute)
 	log.Println("[DOCTOR] Rate limiter set up successfully.")
@@ -32,7 +34,8 @@ func SetupRoutes(parentCtx context.Context, dbConn database.Database, rdb *redis
 	router.Use(middleware.SanitizeInputMiddleware) // comment this out if you want to see pretty JSON :)
 
 	doctorController := &controllers.DoctorController{
-		DbConn: dbConn,
+		DbConn:            dbConn,
+		AppointmentClient: clients.NewAppointmentClient(appConfig.Appointments),
 	}
 
 	loadCrudRoutes(router, doctorController)
@@ -12,9 +12,10 @@ import (
 )
 
 type AppConfig struct {
-	Server ServerConfig `yaml:"server"`
-	MySQL  MySQLConfig  `yaml:"mysql_db"`
-	Redis  RedisConfig  `yaml:"redis"`
+	Server       ServerConfig  `yaml:"server"`
+	MySQL        MySQLConfig   `yaml:"mysql_db"`
+	Redis        RedisConfig   `yaml:"redis"`
+	Appointments ServiceConfig `yaml:"appointments"`
 }
 
 type ServerConfig struct {
@@ -41,6 +42,12 @@ type RedisConfig struct {
 	DB       int    `yaml:"db"` // usually 0 unless you're using multiple databases
 }
 
+// ServiceConfig locates another module of the application
+type ServiceConfig struct {
+	Host string `yaml:"host"`
+	Port int    `yaml:"port"`
+}
+
 // LoadConfig loads the configuration from the given file path
 func LoadConfig(filePath string) (*AppConfig, error) {
 	log.Println("[DOCTOR] Loading configuration...")
@@ -80,6 +80,11 @@ const (
 	ColumnIsActive       = "is_active"
 )
 
+// Appointment module endpoints
+const APPOINTMENT_CREATE_RESCHEDULING_JOB_ENDPOINT = "/appointments/rescheduling-jobs"
+
+const RESCHEDULING_REQUEST_TIMEOUT = 10
+
 const MySQLDuplicateEntryErrorCode = 1062
 
 const (
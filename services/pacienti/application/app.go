@@ -10,6 +10,9 @@ import (
 	"github.com/mihnea1711/POS_Project/services/pacienti/internal/database"
 	"github.com/mihnea1711/POS_Project/services/pacienti/internal/database/mysql"
 	"github.com/mihnea1711/POS_Project/services/pacienti/internal/database/redis"
+	"github.com/mihnea1711/POS_Project/services/pacienti/internal/duplicates"
+	"github.com/mihnea1711/POS_Project/services/pacienti/internal/encryption"
+	"github.com/mihnea1711/POS_Project/services/pacienti/internal/insurance"
 	"github.com/mihnea1711/POS_Project/services/pacienti/internal/routes"
 	"github.com/mihnea1711/POS_Project/services/pacienti/pkg/config"
 	"github.com/mihnea1711/POS_Project/services/pacienti/pkg/utils"
@@ -20,6 +23,9 @@ type App struct {
 	database database.Database
 	rdb      *redis.RedisClient
 	config   *config.AppConfig
+	detector *duplicates.Detector
+	verifier insurance.Verifier
+	rotator  *encryption.Rotator
 }
 
 func New(config *config.AppConfig, parentCtx context.Context) (*App, error) {
@@ -27,8 +33,15 @@ func New(config *config.AppConfig, parentCtx context.Context) (*App, error) {
 		config: config,
 	}
 
+	// The identifiers of the patients are encrypted with keys from the provider
+	keyProvider, err := encryption.NewKeyProvider(config.Encryption)
+	if err != nil {
+		log.Printf("[PATIENT] Error initializing key provider: %v", err)
+		return nil, fmt.Errorf("failed to initialize key provider: %w", err)
+	}
+
 	// Setup MySQL connection for the app
-	mysqlDB, err := mysql.NewMySQL(parentCtx, &config.MySQL)
+	mysqlDB, err := mysql.NewMySQL(parentCtx, &config.MySQL, encryption.NewFieldCipher(keyProvider))
 	if err != nil {
 		log.Printf("[PATIENT] Error initializing MySQL: %v", err)
 		return nil, fmt.Errorf("failed to initialize MySQL: %w", err)
@@ -45,8 +58,20 @@ func New(config *config.AppConfig, parentCtx context.Context) (*App, error) {
 	app.rdb = rdb
 	log.Println("[PATIENT] Redis connection successfully established.")
 
+	// The detector also serves the scans requested by admins, it only scans periodically when enabled
+	app.detector = duplicates.NewDetector(app.database, config.Duplicates)
+
+	verifier, err := insurance.NewVerifier(config.Insurance)
+	if err != nil {
+		log.Printf("[PATIENT] Error initializing insurance verifier: %v", err)
+		return nil, fmt.Errorf("failed to initialize insurance verifier: %w", err)
+	}
+	app.verifier = verifier
+
+	app.rotator = encryption.NewRotator(app.database, keyProvider, config.Encryption)
+
 	// Setup router for the app
-	router := routes.SetupRoutes(parentCtx, app.database, app.rdb)
+	router := routes.SetupRoutes(parentCtx, app.database, app.rdb, config, app.detector, app.verifier, app.rotator)
 	app.router = router
 
 	log.Println("[PATIENT] Application successfully initialized.")
@@ -61,6 +86,13 @@ func (a *App) Start(ctx context.Context) error {
 
 	log.Printf("[PATIENT] Starting server on port %d...", a.config.Server.Port)
 
+	if a.config.Duplicates.Enabled {
+		go a.detector.Start(ctx)
+	}
+
+	// The rotator always runs, identifiers stored in plaintext or under a retired key are left readable but not searchable
+	go a.rotator.Start(ctx)
+
 	channel := make(chan error, 1)
 	go func() {
 		err := server.ListenAndServe()
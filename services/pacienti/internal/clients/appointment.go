@@ -0,0 +1,38 @@
+package clients
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/mihnea1711/POS_Project/services/pacienti/internal/models"
+	"github.com/mihnea1711/POS_Project/services/pacienti/pkg/config"
+	"github.com/mihnea1711/POS_Project/services/pacienti/pkg/utils"
+)
+
+// AppointmentClient talks to the appointment module
+type AppointmentClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+func NewAppointmentClient(serviceConfig config.ServiceConfig) *AppointmentClient {
+	return &AppointmentClient{
+		baseURL:    fmt.Sprintf("http://%s:%d", serviceConfig.Host, serviceConfig.Port),
+		httpClient: &http.Client{},
+	}
+}
+
+// ReassignPatient moves the appointments of a merged duplicate to the surviving patient.
+// The appointment module does it in one transaction, calling it again after a failure is safe.
+func (c *AppointmentClient) ReassignPatient(ctx context.Context, fromPatientID, toPatientID int) (*models.AppointmentReassignment, error) {
+	var result models.AppointmentReassignment
+	request := models.PatientReassignment{IDFromPatient: fromPatientID, IDToPatient: toPatientID}
+	if err := postReassignment(ctx, c.httpClient, c.baseURL+utils.APPOINTMENT_REASSIGN_PATIENT_ENDPOINT, request, &result); err != nil {
+		return nil, fmt.Errorf("appointment module: %w", err)
+	}
+
+	log.Printf("[PATIENT] Appointment module moved %d appointments of patient %d to patient %d", result.Moved, fromPatientID, toPatientID)
+	return &result, nil
+}
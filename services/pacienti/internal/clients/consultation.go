@@ -0,0 +1,38 @@
+package clients
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/mihnea1711/POS_Project/services/pacienti/internal/models"
+	"github.com/mihnea1711/POS_Project/services/pacienti/pkg/config"
+	"github.com/mihnea1711/POS_Project/services/pacienti/pkg/utils"
+)
+
+// ConsultationClient talks to the consultation module
+type ConsultationClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+func NewConsultationClient(serviceConfig config.ServiceConfig) *ConsultationClient {
+	return &ConsultationClient{
+		baseURL:    fmt.Sprintf("http://%s:%d", serviceConfig.Host, serviceConfig.Port),
+		httpClient: &http.Client{},
+	}
+}
+
+// ReassignPatient moves the consultations of a merged duplicate to the surviving patient and returns how many were moved.
+// Calling it again after a failure is safe, the consultations already moved no longer match.
+func (c *ConsultationClient) ReassignPatient(ctx context.Context, fromPatientID, toPatientID int) (int, error) {
+	var result models.RowsAffected
+	request := models.PatientReassignment{IDFromPatient: fromPatientID, IDToPatient: toPatientID}
+	if err := postReassignment(ctx, c.httpClient, c.baseURL+utils.CONSULTATION_REASSIGN_PATIENT_ENDPOINT, request, &result); err != nil {
+		return 0, fmt.Errorf("consultation module: %w", err)
+	}
+
+	log.Printf("[PATIENT] Consultation module moved %d consultations of patient %d to patient %d", result.RowsAffected, fromPatientID, toPatientID)
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,52 @@
+package clients
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/mihnea1711/POS_Project/services/pacienti/internal/models"
+)
+
+// reassignmentResponse is the ResponseData answered by the other modules, the payload is decoded by the caller
+type reassignmentResponse struct {
+	Message string          `json:"message"`
+	Error   string          `json:"error"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// postReassignment asks the module at url to move the records of a patient and decodes the payload of its answer
+func postReassignment(ctx context.Context, httpClient *http.Client, url string, request models.PatientReassignment, payload interface{}) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("failed to marshal reassignment request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create reassignment request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send reassignment request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var response reassignmentResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return fmt.Errorf("failed to decode reassignment response (status %d): %w", resp.StatusCode, err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("module responded with status %d: %s", resp.StatusCode, response.Error)
+	}
+
+	if err := json.Unmarshal(response.Payload, payload); err != nil {
+		return fmt.Errorf("failed to decode reassignment payload: %w", err)
+	}
+	return nil
+}
@@ -6,12 +6,19 @@ import (
 	"net/http"
 
 	"github.com/mihnea1711/POS_Project/services/pacienti/internal/database"
+	"github.com/mihnea1711/POS_Project/services/pacienti/internal/duplicates"
+	"github.com/mihnea1711/POS_Project/services/pacienti/internal/encryption"
+	"github.com/mihnea1711/POS_Project/services/pacienti/internal/insurance"
 	"github.com/mihnea1711/POS_Project/services/pacienti/internal/models"
 	"github.com/mihnea1711/POS_Project/services/pacienti/pkg/utils"
 )
 
 type PatientController struct {
-	DbConn database.Database
+	DbConn   database.Database
+	Detector *duplicates.Detector
+	Merger   *duplicates.Merger
+	Verifier insurance.Verifier
+	Rotator  *encryption.Rotator
 }
 
 func (pc *PatientController) handleContextTimeout(ctx context.Context, w http.ResponseWriter) {
@@ -0,0 +1,241 @@
+package controllers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mihnea1711/POS_Project/services/pacienti/internal/database"
+	"github.com/mihnea1711/POS_Project/services/pacienti/internal/models"
+	"github.com/mihnea1711/POS_Project/services/pacienti/pkg/utils"
+)
+
+// GetDuplicateCandidates lists the likely duplicate patients, open candidates by default, the most likely first
+func (pController *PatientController) GetDuplicateCandidates(w http.ResponseWriter, r *http.Request) {
+	log.Println("[PATIENT] Attempting to fetch duplicate candidates.")
+
+	status := utils.CandidateStatusOpen
+	if value := r.URL.Query().Get(utils.QUERY_STATUS); value != "" {
+		status = models.CandidateStatus(value)
+		if !isValidCandidateStatus(status) {
+			errMsg := fmt.Sprintf("bad request: invalid %s: %q", utils.QUERY_STATUS, value)
+			log.Printf("[PATIENT] GetDuplicateCandidates: %s", errMsg)
+			utils.RespondWithJSON(w, http.StatusBadRequest, models.ResponseData{Error: errMsg, Message: "Failed to fetch duplicate candidates"})
+			return
+		}
+	}
+
+	// Extract the limit and page query parameters from the request
+	limit, page := utils.ExtractPaginationParams(r)
+
+	// Ensure a database operation doesn't take longer than 5 seconds
+	ctx, cancel := context.WithTimeout(r.Context(), utils.DB_REQ_TIMEOUT_SEC_MULTIPLIER*time.Second)
+	defer cancel()
+
+	candidates, err := pController.DbConn.FetchDuplicateCandidates(ctx, status, page, limit)
+	if err != nil {
+		errMsg := fmt.Sprintf("internal server error: %s", err)
+		log.Printf("[PATIENT] GetDuplicateCandidates: %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusInternalServerError, models.ResponseData{Error: errMsg, Message: "Failed to fetch duplicate candidates"})
+		return
+	}
+
+	log.Printf("[PATIENT] Successfully fetched %d duplicate candidates", len(candidates))
+	utils.RespondWithJSON(w, http.StatusOK, models.ResponseData{Payload: candidates, Message: fmt.Sprintf("Successfully fetched %d duplicate candidates", len(candidates))})
+}
+
+// ScanDuplicates runs the duplicate detection now instead of waiting for the next periodic scan
+func (pController *PatientController) ScanDuplicates(w http.ResponseWriter, r *http.Request) {
+	log.Println("[PATIENT] Attempting to scan for duplicate patients.")
+
+	ctx, cancel := context.WithTimeout(r.Context(), utils.DUPLICATE_SCAN_TIMEOUT*time.Second)
+	defer cancel()
+
+	result, err := pController.Detector.Scan(ctx)
+	if err != nil {
+		errMsg := fmt.Sprintf("internal server error: %s", err)
+		log.Printf("[PATIENT] ScanDuplicates: %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusInternalServerError, models.ResponseData{Error: errMsg, Message: "Failed to scan for duplicate patients"})
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, models.ResponseData{Payload: result, Message: fmt.Sprintf("Found %d duplicate candidates", result.Candidates)})
+}
+
+// DismissDuplicateCandidate records that an open candidate pair belongs to two different people
+func (pController *PatientController) DismissDuplicateCandidate(w http.ResponseWriter, r *http.Request) {
+	log.Println("[PATIENT] Attempting to dismiss a duplicate candidate.")
+
+	candidateIDStr := mux.Vars(r)[utils.DUPLICATE_CANDIDATE_ID_PARAMETER]
+	candidateID, err := strconv.Atoi(candidateIDStr)
+	if err != nil {
+		errMsg := fmt.Sprintf("Invalid candidate ID: %s", candidateIDStr)
+		log.Printf("[PATIENT] %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusBadRequest, models.ResponseData{Error: errMsg, Message: "Invalid duplicate candidate dismissal request"})
+		return
+	}
+
+	// Ensure a database operation doesn't take longer than 5 seconds
+	ctx, cancel := context.WithTimeout(r.Context(), utils.DB_REQ_TIMEOUT_SEC_MULTIPLIER*time.Second)
+	defer cancel()
+
+	rowsAffected, err := pController.DbConn.DismissDuplicateCandidate(ctx, candidateID)
+	if err != nil {
+		errMsg := fmt.Sprintf("internal server error: %s", err)
+		log.Printf("[PATIENT] DismissDuplicateCandidate: %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusInternalServerError, models.ResponseData{Error: errMsg, Message: "Failed to dismiss duplicate candidate"})
+		return
+	}
+
+	if rowsAffected == 0 {
+		errMsg := fmt.Sprintf("No open duplicate candidate found with ID: %d", candidateID)
+		log.Printf("[PATIENT] %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusNotFound, models.ResponseData{Error: errMsg, Message: "Duplicate candidate not found"})
+		return
+	}
+
+	log.Printf("[PATIENT] Successfully dismissed duplicate candidate %d", candidateID)
+	utils.RespondWithJSON(w, http.StatusOK, models.ResponseData{
+		Message: fmt.Sprintf("Duplicate candidate %d dismissed successfully", candidateID),
+		Payload: models.RowsAffected{RowsAffected: rowsAffected},
+	})
+}
+
+// CreatePatientMerge merges a duplicate patient record into the surviving one: its appointments and consultations are
+// moved to the survivor and it is deactivated. Requesting a failed merge again resumes it from the step that failed.
+func (pController *PatientController) CreatePatientMerge(w http.ResponseWriter, r *http.Request) {
+	log.Println("[PATIENT] Attempting to merge patients.")
+	request := r.Context().Value(utils.DECODED_MERGE_REQUEST).(*models.MergeRequest)
+
+	// Ensure a database operation doesn't take longer than 5 seconds
+	ctx, cancel := context.WithTimeout(r.Context(), utils.DB_REQ_TIMEOUT_SEC_MULTIPLIER*time.Second)
+	defer cancel()
+
+	merge, created, err := pController.DbConn.StartPatientMerge(ctx, request)
+	if err != nil {
+		handleMergeStartError(w, err, request, merge)
+		return
+	}
+
+	// The steps call the other modules, each one has its own timeout
+	if err := pController.Merger.Run(r.Context(), merge); err != nil {
+		errMsg := fmt.Sprintf("merge %d stopped: %s", merge.IDMerge, err)
+		log.Printf("[PATIENT] CreatePatientMerge: %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusBadGateway, models.ResponseData{
+			Error:   errMsg,
+			Message: "Failed to merge patients. Request the merge again to resume it",
+			Payload: merge,
+		})
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		w.Header().Set("Location", fmt.Sprintf("%s/%d", utils.FETCH_ALL_PATIENT_MERGES_ENDPOINT, merge.IDMerge))
+		status = http.StatusCreated
+	}
+
+	log.Printf("[PATIENT] Successfully merged patient %d into patient %d", merge.IDDuplicate, merge.IDSurvivor)
+	utils.RespondWithJSON(w, status, models.ResponseData{
+		Message: fmt.Sprintf("Patient %d merged into patient %d successfully", merge.IDDuplicate, merge.IDSurvivor),
+		Payload: merge,
+	})
+}
+
+// GetPatientMerges lists the merge history, the latest merges first
+func (pController *PatientController) GetPatientMerges(w http.ResponseWriter, r *http.Request) {
+	log.Println("[PATIENT] Attempting to fetch patient merges.")
+
+	// Extract the limit and page query parameters from the request
+	limit, page := utils.ExtractPaginationParams(r)
+
+	// Ensure a database operation doesn't take longer than 5 seconds
+	ctx, cancel := context.WithTimeout(r.Context(), utils.DB_REQ_TIMEOUT_SEC_MULTIPLIER*time.Second)
+	defer cancel()
+
+	merges, err := pController.DbConn.FetchPatientMerges(ctx, page, limit)
+	if err != nil {
+		errMsg := fmt.Sprintf("internal server error: %s", err)
+		log.Printf("[PATIENT] GetPatientMerges: %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusInternalServerError, models.ResponseData{Error: errMsg, Message: "Failed to fetch patient merges"})
+		return
+	}
+
+	log.Printf("[PATIENT] Successfully fetched %d patient merges", len(merges))
+	utils.RespondWithJSON(w, http.StatusOK, models.ResponseData{Payload: merges, Message: fmt.Sprintf("Successfully fetched %d patient merges", len(merges))})
+}
+
+func (pController *PatientController) GetPatientMergeByID(w http.ResponseWriter, r *http.Request) {
+	log.Println("[PATIENT] Attempting to fetch a patient merge.")
+
+	mergeIDStr := mux.Vars(r)[utils.PATIENT_MERGE_ID_PARAMETER]
+	mergeID, err := strconv.Atoi(mergeIDStr)
+	if err != nil {
+		errMsg := fmt.Sprintf("Invalid merge ID: %s", mergeIDStr)
+		log.Printf("[PATIENT] %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusBadRequest, models.ResponseData{Error: errMsg, Message: "Invalid patient merge request"})
+		return
+	}
+
+	// Ensure a database operation doesn't take longer than 5 seconds
+	ctx, cancel := context.WithTimeout(r.Context(), utils.DB_REQ_TIMEOUT_SEC_MULTIPLIER*time.Second)
+	defer cancel()
+
+	merge, err := pController.DbConn.FetchPatientMergeByID(ctx, mergeID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			errMsg := fmt.Sprintf("No patient merge found with ID: %d", mergeID)
+			log.Printf("[PATIENT] %s", errMsg)
+			utils.RespondWithJSON(w, http.StatusNotFound, models.ResponseData{Error: errMsg, Message: "Patient merge not found"})
+			return
+		}
+
+		errMsg := fmt.Sprintf("internal server error: %s", err)
+		log.Printf("[PATIENT] GetPatientMergeByID: %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusInternalServerError, models.ResponseData{Error: errMsg, Message: "Failed to fetch patient merge"})
+		return
+	}
+
+	log.Printf("[PATIENT] Successfully fetched patient merge %d", mergeID)
+	utils.RespondWithJSON(w, http.StatusOK, models.ResponseData{Payload: merge, Message: fmt.Sprintf("Successfully fetched patient merge %d", mergeID)})
+}
+
+// handleMergeStartError responds to a merge that could not start. merge is the conflicting merge, when there is one.
+func handleMergeStartError(w http.ResponseWriter, err error, request *models.MergeRequest, merge *models.PatientMerge) {
+	var status int
+	var message string
+	switch {
+	case err == sql.ErrNoRows:
+		status, message = http.StatusNotFound, fmt.Sprintf("Patient %d or %d not found", request.IDSurvivor, request.IDDuplicate)
+	case errors.Is(err, database.ErrMergeCompleted):
+		status, message = http.StatusConflict, "The patients were already merged"
+	case errors.Is(err, database.ErrMergeInProgress):
+		status, message = http.StatusConflict, "The merge of the patients is still running"
+	case errors.Is(err, database.ErrMergeConflict):
+		status, message = http.StatusConflict, "A patient was already merged into another patient"
+	default:
+		status, message = http.StatusInternalServerError, "Failed to merge patients"
+	}
+
+	log.Printf("[PATIENT] CreatePatientMerge: %s: %v", message, err)
+	response := models.ResponseData{Error: err.Error(), Message: message}
+	if merge != nil {
+		response.Payload = merge
+	}
+	utils.RespondWithJSON(w, status, response)
+}
+
+func isValidCandidateStatus(status models.CandidateStatus) bool {
+	for _, valid := range utils.ValidCandidateStatuses {
+		if status == valid {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,30 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/mihnea1711/POS_Project/services/pacienti/internal/models"
+	"github.com/mihnea1711/POS_Project/services/pacienti/pkg/utils"
+)
+
+// RotateKeys reloads the keyring and moves the stored identifiers to the active key now instead of waiting for the next sweep
+func (pController *PatientController) RotateKeys(w http.ResponseWriter, r *http.Request) {
+	log.Println("[PATIENT] Attempting to rotate the encryption keys.")
+
+	ctx, cancel := context.WithTimeout(r.Context(), utils.KEY_ROTATION_TIMEOUT*time.Second)
+	defer cancel()
+
+	report, err := pController.Rotator.Rotate(ctx)
+	if err != nil {
+		errMsg := fmt.Sprintf("internal server error: %s", err)
+		log.Printf("[PATIENT] RotateKeys: %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusInternalServerError, models.ResponseData{Error: errMsg, Message: "Failed to rotate the encryption keys"})
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, models.ResponseData{Payload: report, Message: fmt.Sprintf("Rewrapped the identifiers of %d patients", report.Rewrapped)})
+}
@@ -0,0 +1,216 @@
+package controllers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mihnea1711/POS_Project/services/pacienti/internal/database"
+	"github.com/mihnea1711/POS_Project/services/pacienti/internal/models"
+	"github.com/mihnea1711/POS_Project/services/pacienti/pkg/utils"
+)
+
+// PlaceLegalHold keeps the personal data of a patient from being erased until the hold is released. Placing it again
+// replaces the reason.
+func (pController *PatientController) PlaceLegalHold(w http.ResponseWriter, r *http.Request) {
+	log.Println("[PATIENT] Attempting to place a legal hold.")
+	hold := r.Context().Value(utils.DECODED_LEGAL_HOLD).(*models.LegalHold)
+
+	patientID, ok := erasureRouteID(w, r, utils.LEGAL_HOLD_PATIENT_ID_PARAMETER, "Invalid legal hold request")
+	if !ok {
+		return
+	}
+	hold.IDPatient = patientID
+
+	// Ensure a database operation doesn't take longer than 5 seconds
+	ctx, cancel := context.WithTimeout(r.Context(), utils.DB_REQ_TIMEOUT_SEC_MULTIPLIER*time.Second)
+	defer cancel()
+
+	if _, ok := pController.insuredPatient(ctx, w, patientID, "Failed to place legal hold"); !ok {
+		return
+	}
+
+	created, err := pController.DbConn.SaveLegalHold(ctx, hold)
+	if err != nil {
+		errMsg := fmt.Sprintf("internal server error: %s", err)
+		log.Printf("[PATIENT] PlaceLegalHold: %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusInternalServerError, models.ResponseData{Error: errMsg, Message: "Failed to place legal hold"})
+		return
+	}
+
+	if created {
+		log.Printf("[PATIENT] Legal hold placed on patient %d", patientID)
+		utils.RespondWithJSON(w, http.StatusCreated, models.ResponseData{Message: fmt.Sprintf("Legal hold placed on patient %d", patientID), Payload: hold})
+		return
+	}
+
+	log.Printf("[PATIENT] Legal hold of patient %d updated", patientID)
+	utils.RespondWithJSON(w, http.StatusOK, models.ResponseData{Message: fmt.Sprintf("Legal hold of patient %d updated", patientID), Payload: hold})
+}
+
+func (pController *PatientController) GetLegalHold(w http.ResponseWriter, r *http.Request) {
+	log.Println("[PATIENT] Attempting to fetch a legal hold.")
+
+	patientID, ok := erasureRouteID(w, r, utils.LEGAL_HOLD_PATIENT_ID_PARAMETER, "Invalid legal hold request")
+	if !ok {
+		return
+	}
+
+	// Ensure a database operation doesn't take longer than 5 seconds
+	ctx, cancel := context.WithTimeout(r.Context(), utils.DB_REQ_TIMEOUT_SEC_MULTIPLIER*time.Second)
+	defer cancel()
+
+	hold, err := pController.DbConn.FetchLegalHold(ctx, patientID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			errMsg := fmt.Sprintf("No legal hold found for patient: %d", patientID)
+			log.Printf("[PATIENT] %s", errMsg)
+			utils.RespondWithJSON(w, http.StatusNotFound, models.ResponseData{Error: errMsg, Message: "Failed to fetch legal hold. Legal hold not found"})
+			return
+		}
+
+		errMsg := fmt.Sprintf("internal server error: %s", err)
+		log.Printf("[PATIENT] GetLegalHold: %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusInternalServerError, models.ResponseData{Error: errMsg, Message: "Failed to fetch legal hold"})
+		return
+	}
+
+	log.Printf("[PATIENT] Successfully fetched the legal hold of patient %d", patientID)
+	utils.RespondWithJSON(w, http.StatusOK, models.ResponseData{Payload: hold, Message: fmt.Sprintf("Patient %d is under legal hold", patientID)})
+}
+
+func (pController *PatientController) ReleaseLegalHold(w http.ResponseWriter, r *http.Request) {
+	log.Println("[PATIENT] Attempting to release a legal hold.")
+
+	patientID, ok := erasureRouteID(w, r, utils.LEGAL_HOLD_PATIENT_ID_PARAMETER, "Invalid legal hold request")
+	if !ok {
+		return
+	}
+
+	// Ensure a database operation doesn't take longer than 5 seconds
+	ctx, cancel := context.WithTimeout(r.Context(), utils.DB_REQ_TIMEOUT_SEC_MULTIPLIER*time.Second)
+	defer cancel()
+
+	rowsAffected, err := pController.DbConn.DeleteLegalHold(ctx, patientID)
+	if err != nil {
+		errMsg := fmt.Sprintf("internal server error: %s", err)
+		log.Printf("[PATIENT] ReleaseLegalHold: %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusInternalServerError, models.ResponseData{Error: errMsg, Message: "Failed to release legal hold"})
+		return
+	}
+	if rowsAffected == 0 {
+		errMsg := fmt.Sprintf("No legal hold found for patient: %d", patientID)
+		log.Printf("[PATIENT] %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusNotFound, models.ResponseData{Error: errMsg, Message: "Failed to release legal hold. Legal hold not found"})
+		return
+	}
+
+	log.Printf("[PATIENT] Successfully released the legal hold of patient %d", patientID)
+	utils.RespondWithJSON(w, http.StatusOK, models.ResponseData{Message: fmt.Sprintf("Legal hold of patient %d released", patientID), Payload: rowsAffected})
+}
+
+// GetErasureSubject is the prepare step of an erasure run by the rabbit module. It names the patient of the user and
+// answers 423 while the patient is under legal hold.
+func (pController *PatientController) GetErasureSubject(w http.ResponseWriter, r *http.Request) {
+	log.Println("[PATIENT] Attempting to fetch an erasure subject.")
+
+	userID, ok := erasureRouteID(w, r, utils.ERASURE_USER_ID_PARAMETER, "Invalid erasure request")
+	if !ok {
+		return
+	}
+
+	// Ensure a database operation doesn't take longer than 5 seconds
+	ctx, cancel := context.WithTimeout(r.Context(), utils.DB_REQ_TIMEOUT_SEC_MULTIPLIER*time.Second)
+	defer cancel()
+
+	subject, err := pController.DbConn.FetchErasureSubject(ctx, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			errMsg := fmt.Sprintf("No patient found with user ID: %d", userID)
+			log.Printf("[PATIENT] %s", errMsg)
+			utils.RespondWithJSON(w, http.StatusNotFound, models.ResponseData{Error: errMsg, Message: "Failed to fetch erasure subject. Patient not found"})
+			return
+		}
+
+		errMsg := fmt.Sprintf("internal server error: %s", err)
+		log.Printf("[PATIENT] GetErasureSubject: %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusInternalServerError, models.ResponseData{Error: errMsg, Message: "Failed to fetch erasure subject"})
+		return
+	}
+
+	if subject.LegalHold != nil {
+		errMsg := fmt.Sprintf("Patient %d is under legal hold: %s", subject.IDPatient, subject.LegalHold.Reason)
+		log.Printf("[PATIENT] %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusLocked, models.ResponseData{Error: errMsg, Message: "The patient cannot be erased while under legal hold", Payload: subject})
+		return
+	}
+
+	log.Printf("[PATIENT] Patient %d of user %d can be erased", subject.IDPatient, userID)
+	utils.RespondWithJSON(w, http.StatusOK, models.ResponseData{Payload: subject, Message: fmt.Sprintf("Patient %d can be erased", subject.IDPatient)})
+}
+
+// ErasePatient erases the personal data of the patient of a user and reports what was deleted, anonymized or retained.
+// The rabbit module calls it while committing an erasure, a repeated call succeeds and changes nothing new.
+func (pController *PatientController) ErasePatient(w http.ResponseWriter, r *http.Request) {
+	log.Println("[PATIENT] Attempting to erase a patient.")
+	request := r.Context().Value(utils.DECODED_ERASURE_REQUEST).(*models.ErasureRequest)
+
+	userID, ok := erasureRouteID(w, r, utils.ERASURE_USER_ID_PARAMETER, "Invalid erasure request")
+	if !ok {
+		return
+	}
+
+	// Ensure a database operation doesn't take longer than 5 seconds
+	ctx, cancel := context.WithTimeout(r.Context(), utils.DB_REQ_TIMEOUT_SEC_MULTIPLIER*time.Second)
+	defer cancel()
+
+	patient, err := pController.DbConn.FetchPatientByUserID(ctx, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			errMsg := fmt.Sprintf("No patient found with user ID: %d", userID)
+			log.Printf("[PATIENT] %s", errMsg)
+			utils.RespondWithJSON(w, http.StatusNotFound, models.ResponseData{Error: errMsg, Message: "Failed to erase patient. Patient not found"})
+			return
+		}
+
+		errMsg := fmt.Sprintf("internal server error: %s", err)
+		log.Printf("[PATIENT] ErasePatient: %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusInternalServerError, models.ResponseData{Error: errMsg, Message: "Failed to erase patient"})
+		return
+	}
+
+	records, err := pController.DbConn.ErasePatient(ctx, patient.IDPatient, request.IDErasure)
+	if err != nil {
+		if errors.Is(err, database.ErrLegalHold) {
+			log.Printf("[PATIENT] Patient %d cannot be erased: %v", patient.IDPatient, err)
+			utils.RespondWithJSON(w, http.StatusLocked, models.ResponseData{Error: err.Error(), Message: "The patient cannot be erased while under legal hold"})
+			return
+		}
+
+		errMsg := fmt.Sprintf("internal server error: %s", err)
+		log.Printf("[PATIENT] ErasePatient: %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusInternalServerError, models.ResponseData{Error: errMsg, Message: "Failed to erase patient"})
+		return
+	}
+
+	log.Printf("[PATIENT] Erasure %s: erased the personal data of patient %d", request.IDErasure, patient.IDPatient)
+	utils.RespondWithJSON(w, http.StatusOK, models.ResponseData{Payload: records, Message: fmt.Sprintf("Personal data of patient %d erased", patient.IDPatient)})
+}
+
+func erasureRouteID(w http.ResponseWriter, r *http.Request, parameter, message string) (int, bool) {
+	idStr := mux.Vars(r)[parameter]
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		errMsg := fmt.Sprintf("Invalid %s: %s", parameter, idStr)
+		log.Printf("[PATIENT] %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusBadRequest, models.ResponseData{Error: errMsg, Message: message})
+		return 0, false
+	}
+	return id, true
+}
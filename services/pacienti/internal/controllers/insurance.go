@@ -0,0 +1,388 @@
+package controllers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/gorilla/mux"
+	"github.com/mihnea1711/POS_Project/services/pacienti/internal/models"
+	"github.com/mihnea1711/POS_Project/services/pacienti/pkg/utils"
+)
+
+// CreateInsurancePolicy adds a policy to a patient. The policy is verified before it is saved, a verifier that cannot
+// be reached leaves it unverified.
+func (pController *PatientController) CreateInsurancePolicy(w http.ResponseWriter, r *http.Request) {
+	log.Println("[PATIENT] Attempting to create an insurance policy.")
+	policy := r.Context().Value(utils.DECODED_INSURANCE_POLICY).(*models.InsurancePolicy)
+
+	patientID, ok := insuranceRouteID(w, r, utils.INSURANCE_PATIENT_ID_PARAMETER)
+	if !ok {
+		return
+	}
+	policy.IDPatient = patientID
+
+	// Ensure a database operation doesn't take longer than 5 seconds
+	ctx, cancel := context.WithTimeout(r.Context(), utils.DB_REQ_TIMEOUT_SEC_MULTIPLIER*time.Second)
+	defer cancel()
+
+	patient, ok := pController.insuredPatient(ctx, w, patientID, "Failed to create insurance policy")
+	if !ok {
+		return
+	}
+	pController.verifyPolicy(ctx, patient, policy)
+
+	policyID, err := pController.DbConn.SaveInsurancePolicy(ctx, policy)
+	if err != nil {
+		handleInsurancePolicySaveError(w, err, "Failed to create insurance policy")
+		return
+	}
+	policy.IDPolicy = policyID
+
+	w.Header().Set("Location", insurancePolicyLocation(patientID, policyID))
+	log.Printf("[PATIENT] Successfully created insurance policy %d of patient %d", policyID, patientID)
+	utils.RespondWithJSON(w, http.StatusCreated, models.ResponseData{
+		Message: fmt.Sprintf("Insurance policy %d of patient %d created successfully, %s", policyID, patientID, policy.VerificationStatus),
+		Payload: policy,
+	})
+}
+
+func (pController *PatientController) GetInsurancePolicies(w http.ResponseWriter, r *http.Request) {
+	log.Println("[PATIENT] Attempting to fetch insurance policies.")
+
+	patientID, ok := insuranceRouteID(w, r, utils.INSURANCE_PATIENT_ID_PARAMETER)
+	if !ok {
+		return
+	}
+
+	// Ensure a database operation doesn't take longer than 5 seconds
+	ctx, cancel := context.WithTimeout(r.Context(), utils.DB_REQ_TIMEOUT_SEC_MULTIPLIER*time.Second)
+	defer cancel()
+
+	if _, ok := pController.insuredPatient(ctx, w, patientID, "Failed to fetch insurance policies"); !ok {
+		return
+	}
+
+	policies, err := pController.DbConn.FetchInsurancePolicies(ctx, patientID)
+	if err != nil {
+		errMsg := fmt.Sprintf("internal server error: %s", err)
+		log.Printf("[PATIENT] GetInsurancePolicies: %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusInternalServerError, models.ResponseData{Error: errMsg, Message: "Failed to fetch insurance policies"})
+		return
+	}
+
+	log.Printf("[PATIENT] Successfully fetched %d insurance policies of patient %d", len(policies), patientID)
+	utils.RespondWithJSON(w, http.StatusOK, models.ResponseData{Payload: policies, Message: fmt.Sprintf("Successfully fetched %d insurance policies", len(policies))})
+}
+
+func (pController *PatientController) GetInsurancePolicyByID(w http.ResponseWriter, r *http.Request) {
+	log.Println("[PATIENT] Attempting to fetch an insurance policy.")
+
+	patientID, policyID, ok := insurancePolicyRouteIDs(w, r)
+	if !ok {
+		return
+	}
+
+	// Ensure a database operation doesn't take longer than 5 seconds
+	ctx, cancel := context.WithTimeout(r.Context(), utils.DB_REQ_TIMEOUT_SEC_MULTIPLIER*time.Second)
+	defer cancel()
+
+	policy, ok := pController.insurancePolicy(ctx, w, patientID, policyID, "Failed to fetch insurance policy")
+	if !ok {
+		return
+	}
+
+	log.Printf("[PATIENT] Successfully fetched insurance policy %d of patient %d", policyID, patientID)
+	utils.RespondWithJSON(w, http.StatusOK, models.ResponseData{Payload: policy, Message: fmt.Sprintf("Successfully fetched insurance policy %d", policyID)})
+}
+
+// UpdateInsurancePolicy replaces the details of a policy, which is verified again
+func (pController *PatientController) UpdateInsurancePolicy(w http.ResponseWriter, r *http.Request) {
+	log.Println("[PATIENT] Attempting to update an insurance policy.")
+	policy := r.Context().Value(utils.DECODED_INSURANCE_POLICY).(*models.InsurancePolicy)
+
+	patientID, policyID, ok := insurancePolicyRouteIDs(w, r)
+	if !ok {
+		return
+	}
+
+	// Ensure a database operation doesn't take longer than 5 seconds
+	ctx, cancel := context.WithTimeout(r.Context(), utils.DB_REQ_TIMEOUT_SEC_MULTIPLIER*time.Second)
+	defer cancel()
+
+	existing, ok := pController.insurancePolicy(ctx, w, patientID, policyID, "Failed to update insurance policy")
+	if !ok {
+		return
+	}
+	policy.IDPolicy = existing.IDPolicy
+	policy.IDPatient = existing.IDPatient
+	policy.CreatedAt = existing.CreatedAt
+
+	patient, ok := pController.insuredPatient(ctx, w, patientID, "Failed to update insurance policy")
+	if !ok {
+		return
+	}
+	pController.verifyPolicy(ctx, patient, policy)
+
+	if _, err := pController.DbConn.UpdateInsurancePolicy(ctx, policy); err != nil {
+		handleInsurancePolicySaveError(w, err, "Failed to update insurance policy")
+		return
+	}
+
+	log.Printf("[PATIENT] Successfully updated insurance policy %d of patient %d", policyID, patientID)
+	utils.RespondWithJSON(w, http.StatusOK, models.ResponseData{
+		Message: fmt.Sprintf("Insurance policy %d of patient %d updated successfully, %s", policyID, patientID, policy.VerificationStatus),
+		Payload: policy,
+	})
+}
+
+// VerifyInsurancePolicy asks the verifier about a saved policy again, e.g. after the payer could not be reached
+func (pController *PatientController) VerifyInsurancePolicy(w http.ResponseWriter, r *http.Request) {
+	log.Println("[PATIENT] Attempting to verify an insurance policy.")
+
+	patientID, policyID, ok := insurancePolicyRouteIDs(w, r)
+	if !ok {
+		return
+	}
+
+	// Ensure a database operation doesn't take longer than 5 seconds
+	ctx, cancel := context.WithTimeout(r.Context(), utils.DB_REQ_TIMEOUT_SEC_MULTIPLIER*time.Second)
+	defer cancel()
+
+	policy, ok := pController.insurancePolicy(ctx, w, patientID, policyID, "Failed to verify insurance policy")
+	if !ok {
+		return
+	}
+	patient, ok := pController.insuredPatient(ctx, w, patientID, "Failed to verify insurance policy")
+	if !ok {
+		return
+	}
+
+	if err := pController.verifyPolicy(ctx, patient, policy); err != nil {
+		errMsg := fmt.Sprintf("insurance verifier error: %s", err)
+		log.Printf("[PATIENT] VerifyInsurancePolicy: %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusBadGateway, models.ResponseData{Error: errMsg, Message: "Failed to verify insurance policy. Try again later"})
+		return
+	}
+
+	if _, err := pController.DbConn.UpdatePolicyVerification(ctx, policy); err != nil {
+		errMsg := fmt.Sprintf("internal server error: %s", err)
+		log.Printf("[PATIENT] VerifyInsurancePolicy: %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusInternalServerError, models.ResponseData{Error: errMsg, Message: "Failed to verify insurance policy"})
+		return
+	}
+
+	log.Printf("[PATIENT] Insurance policy %d of patient %d is %s", policyID, patientID, policy.VerificationStatus)
+	utils.RespondWithJSON(w, http.StatusOK, models.ResponseData{
+		Message: fmt.Sprintf("Insurance policy %d of patient %d is %s", policyID, patientID, policy.VerificationStatus),
+		Payload: policy,
+	})
+}
+
+func (pController *PatientController) DeleteInsurancePolicy(w http.ResponseWriter, r *http.Request) {
+	log.Println("[PATIENT] Attempting to delete an insurance policy.")
+
+	patientID, policyID, ok := insurancePolicyRouteIDs(w, r)
+	if !ok {
+		return
+	}
+
+	// Ensure a database operation doesn't take longer than 5 seconds
+	ctx, cancel := context.WithTimeout(r.Context(), utils.DB_REQ_TIMEOUT_SEC_MULTIPLIER*time.Second)
+	defer cancel()
+
+	rowsAffected, err := pController.DbConn.DeleteInsurancePolicy(ctx, patientID, policyID)
+	if err != nil {
+		errMsg := fmt.Sprintf("internal server error: %s", err)
+		log.Printf("[PATIENT] DeleteInsurancePolicy: %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusInternalServerError, models.ResponseData{Error: errMsg, Message: "Failed to delete insurance policy"})
+		return
+	}
+	if rowsAffected == 0 {
+		errMsg := fmt.Sprintf("No insurance policy %d found for patient: %d", policyID, patientID)
+		log.Printf("[PATIENT] %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusNotFound, models.ResponseData{Error: errMsg, Message: "Failed to delete insurance policy. Insurance policy not found"})
+		return
+	}
+
+	log.Printf("[PATIENT] Successfully deleted insurance policy %d of patient %d", policyID, patientID)
+	utils.RespondWithJSON(w, http.StatusOK, models.ResponseData{Message: fmt.Sprintf("Insurance policy %d deleted successfully", policyID), Payload: rowsAffected})
+}
+
+// GetPatientCoverage tells the front desk whether a patient is insured on a day, today unless the date query parameter names another one
+func (pController *PatientController) GetPatientCoverage(w http.ResponseWriter, r *http.Request) {
+	log.Println("[PATIENT] Attempting to fetch a patient coverage.")
+
+	patientID, ok := insuranceRouteID(w, r, utils.INSURANCE_PATIENT_ID_PARAMETER)
+	if !ok {
+		return
+	}
+
+	day := time.Now().UTC().Truncate(24 * time.Hour)
+	if value := r.URL.Query().Get(utils.QUERY_DATE); value != "" {
+		date, err := time.Parse(utils.BIRTH_DAY_QUERY_FORMAT, value)
+		if err != nil {
+			errMsg := fmt.Sprintf("Invalid %s: %s. Expected format %s", utils.QUERY_DATE, value, utils.BIRTH_DAY_QUERY_FORMAT)
+			log.Printf("[PATIENT] %s", errMsg)
+			utils.RespondWithJSON(w, http.StatusBadRequest, models.ResponseData{Error: errMsg, Message: "Invalid coverage request"})
+			return
+		}
+		day = date
+	}
+
+	// Ensure a database operation doesn't take longer than 5 seconds
+	ctx, cancel := context.WithTimeout(r.Context(), utils.DB_REQ_TIMEOUT_SEC_MULTIPLIER*time.Second)
+	defer cancel()
+
+	if _, ok := pController.insuredPatient(ctx, w, patientID, "Failed to fetch coverage"); !ok {
+		return
+	}
+
+	policies, err := pController.DbConn.FetchCoveringPolicies(ctx, patientID, day)
+	if err != nil {
+		errMsg := fmt.Sprintf("internal server error: %s", err)
+		log.Printf("[PATIENT] GetPatientCoverage: %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusInternalServerError, models.ResponseData{Error: errMsg, Message: "Failed to fetch coverage"})
+		return
+	}
+
+	coverage := models.Coverage{
+		IDPatient:  patientID,
+		Date:       day,
+		Covered:    len(policies) > 0,
+		PayerTypes: []models.PayerType{},
+		Policies:   policies,
+	}
+	for _, policy := range policies {
+		if policy.VerificationStatus == utils.VerificationStatusVerified {
+			coverage.Verified = true
+		}
+		if !containsPayerType(coverage.PayerTypes, policy.PayerType) {
+			coverage.PayerTypes = append(coverage.PayerTypes, policy.PayerType)
+		}
+	}
+
+	message := fmt.Sprintf("Patient %d is not insured on %s", patientID, day.Format(utils.BIRTH_DAY_QUERY_FORMAT))
+	if coverage.Covered {
+		message = fmt.Sprintf("Patient %d is insured on %s", patientID, day.Format(utils.BIRTH_DAY_QUERY_FORMAT))
+	}
+
+	log.Printf("[PATIENT] %s", message)
+	utils.RespondWithJSON(w, http.StatusOK, models.ResponseData{Message: message, Payload: coverage})
+}
+
+// verifyPolicy records the answer of the verifier on the policy. When the verifier fails the policy is left unverified
+// and the error returned.
+func (pController *PatientController) verifyPolicy(ctx context.Context, patient *models.Patient, policy *models.InsurancePolicy) error {
+	verifyCtx, cancel := context.WithTimeout(ctx, utils.VERIFICATION_TIMEOUT*time.Second)
+	defer cancel()
+
+	verification, err := pController.Verifier.Verify(verifyCtx, patient, policy)
+	if err != nil {
+		log.Printf("[PATIENT] Insurance policy %s of patient %d could not be verified: %v", policy.PolicyNumber, patient.IDPatient, err)
+		policy.VerificationStatus = utils.VerificationStatusUnverified
+		policy.VerificationNote = ""
+		policy.VerifiedAt = nil
+		return err
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	policy.VerificationStatus = verification.Status
+	policy.VerificationNote = verification.Note
+	policy.VerifiedAt = &now
+	return nil
+}
+
+// insuredPatient fetches the patient owning the policies, responding with 404 when there is none
+func (pController *PatientController) insuredPatient(ctx context.Context, w http.ResponseWriter, patientID int, message string) (*models.Patient, bool) {
+	patient, err := pController.DbConn.FetchPatientByID(ctx, patientID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			errMsg := fmt.Sprintf("No patient found with ID: %d", patientID)
+			log.Printf("[PATIENT] %s", errMsg)
+			utils.RespondWithJSON(w, http.StatusNotFound, models.ResponseData{Error: errMsg, Message: message + ". Patient not found"})
+			return nil, false
+		}
+
+		errMsg := fmt.Sprintf("internal server error: %s", err)
+		log.Printf("[PATIENT] %s: %s", message, errMsg)
+		utils.RespondWithJSON(w, http.StatusInternalServerError, models.ResponseData{Error: errMsg, Message: message})
+		return nil, false
+	}
+	return patient, true
+}
+
+func (pController *PatientController) insurancePolicy(ctx context.Context, w http.ResponseWriter, patientID, policyID int, message string) (*models.InsurancePolicy, bool) {
+	policy, err := pController.DbConn.FetchInsurancePolicyByID(ctx, patientID, policyID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			errMsg := fmt.Sprintf("No insurance policy %d found for patient: %d", policyID, patientID)
+			log.Printf("[PATIENT] %s", errMsg)
+			utils.RespondWithJSON(w, http.StatusNotFound, models.ResponseData{Error: errMsg, Message: message + ". Insurance policy not found"})
+			return nil, false
+		}
+
+		errMsg := fmt.Sprintf("internal server error: %s", err)
+		log.Printf("[PATIENT] %s: %s", message, errMsg)
+		utils.RespondWithJSON(w, http.StatusInternalServerError, models.ResponseData{Error: errMsg, Message: message})
+		return nil, false
+	}
+	return policy, true
+}
+
+func handleInsurancePolicySaveError(w http.ResponseWriter, err error, message string) {
+	if mysqlErr, ok := err.(*mysql.MySQLError); ok && mysqlErr.Number == utils.MySQLDuplicateEntryErrorCode {
+		errMsg := fmt.Sprintf("Conflict error: %s", mysqlErr.Message)
+		log.Printf("[PATIENT] %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusConflict, models.ResponseData{Error: errMsg, Message: message + ". The payer already issued this policy number"})
+		return
+	}
+
+	errMsg := fmt.Sprintf("internal server error: %s", err)
+	log.Printf("[PATIENT] %s: %s", message, errMsg)
+	utils.RespondWithJSON(w, http.StatusInternalServerError, models.ResponseData{Error: errMsg, Message: message})
+}
+
+func insuranceRouteID(w http.ResponseWriter, r *http.Request, parameter string) (int, bool) {
+	idStr := mux.Vars(r)[parameter]
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		errMsg := fmt.Sprintf("Invalid %s: %s", parameter, idStr)
+		log.Printf("[PATIENT] %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusBadRequest, models.ResponseData{Error: errMsg, Message: "Invalid insurance request"})
+		return 0, false
+	}
+	return id, true
+}
+
+func insurancePolicyRouteIDs(w http.ResponseWriter, r *http.Request) (int, int, bool) {
+	patientID, ok := insuranceRouteID(w, r, utils.INSURANCE_PATIENT_ID_PARAMETER)
+	if !ok {
+		return 0, 0, false
+	}
+	policyID, ok := insuranceRouteID(w, r, utils.INSURANCE_POLICY_ID_PARAMETER)
+	if !ok {
+		return 0, 0, false
+	}
+	return patientID, policyID, true
+}
+
+func insurancePolicyLocation(patientID, policyID int) string {
+	location := strings.Replace(utils.INSURANCE_POLICY_BY_ID_ENDPOINT, "{"+utils.INSURANCE_PATIENT_ID_PARAMETER+"}", strconv.Itoa(patientID), 1)
+	return strings.Replace(location, "{"+utils.INSURANCE_POLICY_ID_PARAMETER+"}", strconv.Itoa(policyID), 1)
+}
+
+func containsPayerType(payerTypes []models.PayerType, payerType models.PayerType) bool {
+	for _, existing := range payerTypes {
+		if existing == payerType {
+			return true
+		}
+	}
+	return false
+}
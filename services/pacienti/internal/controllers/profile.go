@@ -0,0 +1,211 @@
+package controllers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/gorilla/mux"
+	"github.com/mihnea1711/POS_Project/services/pacienti/internal/database"
+	"github.com/mihnea1711/POS_Project/services/pacienti/internal/models"
+	"github.com/mihnea1711/POS_Project/services/pacienti/pkg/utils"
+)
+
+func (pController *PatientController) CreateClinicalProfile(w http.ResponseWriter, r *http.Request) {
+	log.Println("[PATIENT] Attempting to create a clinical profile.")
+	profile := r.Context().Value(utils.DECODED_CLINICAL_PROFILE).(*models.ClinicalProfile)
+
+	patientID, ok := profilePatientID(w, r)
+	if !ok {
+		return
+	}
+	profile.IDPatient = patientID
+
+	// Ensure a database operation doesn't take longer than 5 seconds
+	ctx, cancel := context.WithTimeout(r.Context(), utils.DB_REQ_TIMEOUT_SEC_MULTIPLIER*time.Second)
+	defer cancel()
+
+	if err := pController.DbConn.SaveClinicalProfile(ctx, profile); err != nil {
+		if err == sql.ErrNoRows {
+			errMsg := fmt.Sprintf("No patient found with ID: %d", patientID)
+			log.Printf("[PATIENT] %s", errMsg)
+			utils.RespondWithJSON(w, http.StatusNotFound, models.ResponseData{Error: errMsg, Message: "Failed to create clinical profile. Patient not found"})
+			return
+		}
+		if mysqlErr, ok := err.(*mysql.MySQLError); ok && mysqlErr.Number == utils.MySQLDuplicateEntryErrorCode {
+			errMsg := fmt.Sprintf("Conflict error: %s", mysqlErr.Message)
+			log.Printf("[PATIENT] %s", errMsg)
+			utils.RespondWithJSON(w, http.StatusConflict, models.ResponseData{Error: errMsg, Message: "Failed to create clinical profile. The patient already has one, update it instead"})
+			return
+		}
+
+		errMsg := fmt.Sprintf("internal server error: %s", err)
+		log.Printf("[PATIENT] CreateClinicalProfile: %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusInternalServerError, models.ResponseData{Error: errMsg, Message: "Failed to create clinical profile"})
+		return
+	}
+
+	w.Header().Set("Location", strings.Replace(utils.CLINICAL_PROFILE_ENDPOINT, "{"+utils.CLINICAL_PROFILE_PATIENT_ID_PARAMETER+"}", strconv.Itoa(patientID), 1))
+	log.Printf("[PATIENT] Successfully created the clinical profile of patient %d", patientID)
+	utils.RespondWithJSON(w, http.StatusCreated, models.ResponseData{
+		Message: fmt.Sprintf("Clinical profile of patient %d created successfully", patientID),
+		Payload: profile,
+	})
+}
+
+func (pController *PatientController) GetClinicalProfile(w http.ResponseWriter, r *http.Request) {
+	log.Println("[PATIENT] Attempting to fetch a clinical profile.")
+
+	patientID, ok := profilePatientID(w, r)
+	if !ok {
+		return
+	}
+
+	// Ensure a database operation doesn't take longer than 5 seconds
+	ctx, cancel := context.WithTimeout(r.Context(), utils.DB_REQ_TIMEOUT_SEC_MULTIPLIER*time.Second)
+	defer cancel()
+
+	profile, err := pController.DbConn.FetchClinicalProfile(ctx, patientID)
+	if err != nil {
+		handleClinicalProfileError(w, err, patientID, "Failed to fetch clinical profile")
+		return
+	}
+
+	log.Printf("[PATIENT] Successfully fetched the clinical profile of patient %d", patientID)
+	utils.RespondWithJSON(w, http.StatusOK, models.ResponseData{Payload: profile, Message: fmt.Sprintf("Successfully fetched the clinical profile of patient %d", patientID)})
+}
+
+// UpdateClinicalProfile replaces the clinical profile of a patient. The request names the version it was made from,
+// so that one of two doctors editing the profile at once gets a conflict instead of losing the other one's changes.
+func (pController *PatientController) UpdateClinicalProfile(w http.ResponseWriter, r *http.Request) {
+	log.Println("[PATIENT] Attempting to update a clinical profile.")
+	profile := r.Context().Value(utils.DECODED_CLINICAL_PROFILE).(*models.ClinicalProfile)
+
+	patientID, ok := profilePatientID(w, r)
+	if !ok {
+		return
+	}
+	profile.IDPatient = patientID
+
+	if profile.Version <= 0 {
+		errMsg := "missing version: an update names the version of the profile it was made from"
+		log.Printf("[PATIENT] %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusUnprocessableEntity, models.ResponseData{Error: errMsg, Message: "Invalid clinical profile update request"})
+		return
+	}
+
+	// Ensure a database operation doesn't take longer than 5 seconds
+	ctx, cancel := context.WithTimeout(r.Context(), utils.DB_REQ_TIMEOUT_SEC_MULTIPLIER*time.Second)
+	defer cancel()
+
+	sections, err := pController.DbConn.UpdateClinicalProfile(ctx, profile)
+	if err != nil {
+		handleClinicalProfileError(w, err, patientID, "Failed to update clinical profile")
+		return
+	}
+
+	message := fmt.Sprintf("Clinical profile of patient %d did not change", patientID)
+	if len(sections) > 0 {
+		message = fmt.Sprintf("Clinical profile of patient %d updated successfully: %s", patientID, strings.Join(sections, ", "))
+	}
+
+	log.Printf("[PATIENT] %s", message)
+	utils.RespondWithJSON(w, http.StatusOK, models.ResponseData{Message: message, Payload: profile})
+}
+
+func (pController *PatientController) DeleteClinicalProfile(w http.ResponseWriter, r *http.Request) {
+	log.Println("[PATIENT] Attempting to delete a clinical profile.")
+
+	patientID, ok := profilePatientID(w, r)
+	if !ok {
+		return
+	}
+
+	// The gateway names the user deleting the profile, the history records it
+	var deletedBy *int
+	if value := r.URL.Query().Get(utils.QUERY_CHANGED_BY); value != "" {
+		userID, err := strconv.Atoi(value)
+		if err != nil {
+			errMsg := fmt.Sprintf("Invalid %s: %s", utils.QUERY_CHANGED_BY, value)
+			log.Printf("[PATIENT] %s", errMsg)
+			utils.RespondWithJSON(w, http.StatusBadRequest, models.ResponseData{Error: errMsg, Message: "Invalid clinical profile deletion request"})
+			return
+		}
+		deletedBy = &userID
+	}
+
+	// Ensure a database operation doesn't take longer than 5 seconds
+	ctx, cancel := context.WithTimeout(r.Context(), utils.DB_REQ_TIMEOUT_SEC_MULTIPLIER*time.Second)
+	defer cancel()
+
+	if err := pController.DbConn.DeleteClinicalProfile(ctx, patientID, deletedBy); err != nil {
+		handleClinicalProfileError(w, err, patientID, "Failed to delete clinical profile")
+		return
+	}
+
+	log.Printf("[PATIENT] Successfully deleted the clinical profile of patient %d", patientID)
+	utils.RespondWithJSON(w, http.StatusOK, models.ResponseData{Message: fmt.Sprintf("Clinical profile of patient %d deleted successfully", patientID)})
+}
+
+// GetClinicalProfileHistory lists the changes of the clinical profile of a patient, the latest first
+func (pController *PatientController) GetClinicalProfileHistory(w http.ResponseWriter, r *http.Request) {
+	log.Println("[PATIENT] Attempting to fetch a clinical profile history.")
+
+	patientID, ok := profilePatientID(w, r)
+	if !ok {
+		return
+	}
+
+	// Extract the limit and page query parameters from the request
+	limit, page := utils.ExtractPaginationParams(r)
+
+	// Ensure a database operation doesn't take longer than 5 seconds
+	ctx, cancel := context.WithTimeout(r.Context(), utils.DB_REQ_TIMEOUT_SEC_MULTIPLIER*time.Second)
+	defer cancel()
+
+	changes, err := pController.DbConn.FetchClinicalProfileHistory(ctx, patientID, page, limit)
+	if err != nil {
+		errMsg := fmt.Sprintf("internal server error: %s", err)
+		log.Printf("[PATIENT] GetClinicalProfileHistory: %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusInternalServerError, models.ResponseData{Error: errMsg, Message: "Failed to fetch clinical profile history"})
+		return
+	}
+
+	log.Printf("[PATIENT] Successfully fetched %d clinical profile changes of patient %d", len(changes), patientID)
+	utils.RespondWithJSON(w, http.StatusOK, models.ResponseData{Payload: changes, Message: fmt.Sprintf("Successfully fetched %d clinical profile changes", len(changes))})
+}
+
+func profilePatientID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	patientIDStr := mux.Vars(r)[utils.CLINICAL_PROFILE_PATIENT_ID_PARAMETER]
+	patientID, err := strconv.Atoi(patientIDStr)
+	if err != nil {
+		errMsg := fmt.Sprintf("Invalid patient ID: %s", patientIDStr)
+		log.Printf("[PATIENT] %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusBadRequest, models.ResponseData{Error: errMsg, Message: "Invalid clinical profile request"})
+		return 0, false
+	}
+	return patientID, true
+}
+
+func handleClinicalProfileError(w http.ResponseWriter, err error, patientID int, message string) {
+	switch {
+	case err == sql.ErrNoRows:
+		errMsg := fmt.Sprintf("No clinical profile found for patient: %d", patientID)
+		log.Printf("[PATIENT] %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusNotFound, models.ResponseData{Error: errMsg, Message: message + ". Clinical profile not found"})
+	case errors.Is(err, database.ErrProfileVersionConflict):
+		log.Printf("[PATIENT] %s: %v", message, err)
+		utils.RespondWithJSON(w, http.StatusConflict, models.ResponseData{Error: err.Error(), Message: message + ". Fetch the profile again and reapply the changes"})
+	default:
+		errMsg := fmt.Sprintf("internal server error: %s", err)
+		log.Printf("[PATIENT] %s: %s", message, errMsg)
+		utils.RespondWithJSON(w, http.StatusInternalServerError, models.ResponseData{Error: errMsg, Message: message})
+	}
+}
@@ -0,0 +1,79 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/mihnea1711/POS_Project/services/pacienti/internal/models"
+	"github.com/mihnea1711/POS_Project/services/pacienti/pkg/utils"
+)
+
+// SearchPatients finds patients by name, identifiers and birth date, the best matches first
+func (pController *PatientController) SearchPatients(w http.ResponseWriter, r *http.Request) {
+	log.Println("[PATIENT] Searching patients...")
+
+	params, err := utils.ExtractSearchParams(r)
+	if err != nil {
+		errMsg := fmt.Sprintf("bad request: %s", err)
+		log.Printf("[PATIENT] SearchPatients: Failed to extract search parameters: %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusBadRequest, models.ResponseData{Error: errMsg, Message: "Failed to extract search parameters"})
+		return
+	}
+
+	// Extract the limit and page query parameters from the request
+	limit, page := utils.ExtractPaginationParams(r)
+
+	// Ensure a database operation doesn't take longer than 5 seconds
+	ctx, cancel := context.WithTimeout(r.Context(), utils.DB_REQ_TIMEOUT_SEC_MULTIPLIER*time.Second)
+	defer cancel()
+
+	candidates, err := pController.DbConn.FetchPatientSearchCandidates(ctx, params, utils.MAX_SEARCH_CANDIDATES)
+	if err != nil {
+		errMsg := fmt.Sprintf("failed to search patients: %v", err)
+		log.Printf("[PATIENT] %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusInternalServerError, models.ResponseData{Error: errMsg, Message: "Failed to search patients"})
+		return
+	}
+	// Ranking a part of the candidates would hide better matches, the search has to be narrowed instead
+	if len(candidates) > utils.MAX_SEARCH_CANDIDATES {
+		errMsg := fmt.Sprintf("the search matches more than %d patients", utils.MAX_SEARCH_CANDIDATES)
+		log.Printf("[PATIENT] SearchPatients: %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusBadRequest, models.ResponseData{Error: errMsg, Message: "Too many patients match the search, refine your query"})
+		return
+	}
+
+	results := []models.PatientSearchResult{}
+	for _, candidate := range candidates {
+		if score, ok := utils.ScorePatient(candidate, *params); ok {
+			results = append(results, models.PatientSearchResult{Patient: candidate, Score: score})
+		}
+	}
+
+	// Rank by relevance, equally relevant patients are listed by name
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		if results[i].FirstName != results[j].FirstName {
+			return results[i].FirstName < results[j].FirstName
+		}
+		return results[i].SecondName < results[j].SecondName
+	})
+
+	offset := (page - 1) * limit
+	if offset > len(results) {
+		offset = len(results)
+	}
+	end := offset + limit
+	if end > len(results) {
+		end = len(results)
+	}
+	results = results[offset:end]
+
+	log.Printf("[PATIENT] Successfully found %d patients", len(results))
+	utils.RespondWithJSON(w, http.StatusOK, models.ResponseData{Payload: results, Message: fmt.Sprintf("Successfully found %d patients", len(results))})
+}
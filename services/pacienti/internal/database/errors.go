@@ -0,0 +1,20 @@
+package database
+
+import "errors"
+
+var (
+	// ErrMergeConflict is returned when one of the records was already merged into another patient, or is being merged
+	ErrMergeConflict = errors.New("a patient of the merge takes part in another merge")
+
+	// ErrMergeCompleted is returned when the same records were already merged
+	ErrMergeCompleted = errors.New("the patients were already merged")
+
+	// ErrMergeInProgress is returned when the merge of the same records is still running
+	ErrMergeInProgress = errors.New("the merge of the patients is in progress")
+)
+
+// ErrProfileVersionConflict is returned when a clinical profile is updated from a version that is no longer the current one
+var ErrProfileVersionConflict = errors.New("the clinical profile was changed in the meantime")
+
+// ErrLegalHold is returned when the personal data of a patient under legal hold is about to be erased
+var ErrLegalHold = errors.New("the patient is under legal hold")
@@ -2,6 +2,7 @@ package database
 
 import (
 	"context"
+	"time"
 
 	"github.com/mihnea1711/POS_Project/services/pacienti/internal/models"
 )
@@ -13,6 +14,7 @@ type Database interface {
 	FetchPatientByID(ctx context.Context, patientID int) (*models.Patient, error)
 	FetchPatientByEmail(ctx context.Context, email string) (*models.Patient, error)
 	FetchPatientByUserID(ctx context.Context, userID int) (*models.Patient, error)
+	FetchPatientSearchCandidates(ctx context.Context, params *models.PatientSearchParams, maxCandidates int) ([]models.Patient, error)
 
 	UpdatePatientByID(ctx context.Context, patient *models.Patient) (int, error)
 	DeletePatientByID(ctx context.Context, patientID int) (int, error)
@@ -20,5 +22,39 @@ type Database interface {
 
 	SetPatientActivityByUserID(ctx context.Context, isActive bool, userID int) (int, error)
 
+	FetchPatientsForDuplicateScan(ctx context.Context) ([]models.Patient, error)
+	ReplaceDuplicateCandidates(ctx context.Context, candidates []models.DuplicateCandidate) error
+	FetchDuplicateCandidates(ctx context.Context, status models.CandidateStatus, page, limit int) ([]models.DuplicateCandidate, error)
+	DismissDuplicateCandidate(ctx context.Context, candidateID int) (int, error)
+
+	StartPatientMerge(ctx context.Context, request *models.MergeRequest) (*models.PatientMerge, bool, error)
+	AdvancePatientMerge(ctx context.Context, merge *models.PatientMerge) error
+	FailPatientMerge(ctx context.Context, merge *models.PatientMerge) error
+	CompletePatientMerge(ctx context.Context, merge *models.PatientMerge) error
+	FetchPatientMerges(ctx context.Context, page, limit int) ([]models.PatientMerge, error)
+	FetchPatientMergeByID(ctx context.Context, mergeID int) (*models.PatientMerge, error)
+
+	SaveClinicalProfile(ctx context.Context, profile *models.ClinicalProfile) error
+	FetchClinicalProfile(ctx context.Context, patientID int) (*models.ClinicalProfile, error)
+	UpdateClinicalProfile(ctx context.Context, profile *models.ClinicalProfile) ([]string, error)
+	DeleteClinicalProfile(ctx context.Context, patientID int, deletedBy *int) error
+	FetchClinicalProfileHistory(ctx context.Context, patientID, page, limit int) ([]models.ClinicalProfileChange, error)
+
+	SaveInsurancePolicy(ctx context.Context, policy *models.InsurancePolicy) (int, error)
+	FetchInsurancePolicies(ctx context.Context, patientID int) ([]models.InsurancePolicy, error)
+	FetchInsurancePolicyByID(ctx context.Context, patientID, policyID int) (*models.InsurancePolicy, error)
+	FetchCoveringPolicies(ctx context.Context, patientID int, day time.Time) ([]models.InsurancePolicy, error)
+	UpdateInsurancePolicy(ctx context.Context, policy *models.InsurancePolicy) (int, error)
+	UpdatePolicyVerification(ctx context.Context, policy *models.InsurancePolicy) (int, error)
+	DeleteInsurancePolicy(ctx context.Context, patientID, policyID int) (int, error)
+
+	SaveLegalHold(ctx context.Context, hold *models.LegalHold) (bool, error)
+	FetchLegalHold(ctx context.Context, patientID int) (*models.LegalHold, error)
+	DeleteLegalHold(ctx context.Context, patientID int) (int, error)
+	FetchErasureSubject(ctx context.Context, userID int) (*models.ErasureSubject, error)
+	ErasePatient(ctx context.Context, patientID int, erasureID string) ([]models.ErasureRecord, error)
+
+	RotatePatientKeys(ctx context.Context, batchSize int) (*models.KeyRotationReport, error)
+
 	Close() error
 }
@@ -0,0 +1,195 @@
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/mihnea1711/POS_Project/services/pacienti/internal/models"
+	"github.com/mihnea1711/POS_Project/services/pacienti/pkg/utils"
+)
+
+// FetchPatientsForDuplicateScan returns the active patients. Merged duplicates are deactivated, so they are not detected again.
+func (db *MySQLDatabase) FetchPatientsForDuplicateScan(ctx context.Context) ([]models.Patient, error) {
+	query, args, err := squirrel.Select(patientColumns()...).From(utils.PatientTableName).
+		Where(squirrel.Eq{utils.ColumnIsActive: true}).OrderBy(utils.ColumnIDPatient).ToSql()
+	if err != nil {
+		log.Printf("[PATIENT] FetchPatientsForDuplicateScan: Failed to construct SQL query: %v", err)
+		return nil, fmt.Errorf("internal server error")
+	}
+
+	return db.queryPatients(ctx, query, args...)
+}
+
+// ReplaceDuplicateCandidates stores the candidates found by a scan in place of the open ones.
+// Pairs already dismissed or merged keep their status, a scan does not bring them back.
+func (db *MySQLDatabase) ReplaceDuplicateCandidates(ctx context.Context, candidates []models.DuplicateCandidate) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Printf("[PATIENT] Error starting transaction to store duplicate candidates: %v", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	deleteQuery := fmt.Sprintf("DELETE FROM %s WHERE %s = ?", utils.DuplicateCandidateTableName, utils.ColumnStatus)
+	if _, err := tx.ExecContext(ctx, deleteQuery, utils.CandidateStatusOpen); err != nil {
+		log.Printf("[PATIENT] Error clearing open duplicate candidates: %v", err)
+		return err
+	}
+
+	insertQuery := fmt.Sprintf("INSERT INTO %s (%s, %s, %s, %s, %s, %s) VALUES (?, ?, ?, ?, ?, ?) ON DUPLICATE KEY UPDATE %s = %s",
+		utils.DuplicateCandidateTableName,
+		utils.ColumnIDPatientA,
+		utils.ColumnIDPatientB,
+		utils.ColumnScore,
+		utils.ColumnReasons,
+		utils.ColumnStatus,
+		utils.ColumnDetectedAt,
+		utils.ColumnIDCandidate,
+		utils.ColumnIDCandidate,
+	)
+	for _, candidate := range candidates {
+		_, err := tx.ExecContext(ctx, insertQuery, candidate.IDPatientA, candidate.IDPatientB, candidate.Score,
+			strings.Join(candidate.Reasons, ","), utils.CandidateStatusOpen, candidate.DetectedAt)
+		if err != nil {
+			log.Printf("[PATIENT] Error storing duplicate candidate %d/%d: %v", candidate.IDPatientA, candidate.IDPatientB, err)
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("[PATIENT] Error committing duplicate candidates: %v", err)
+		return err
+	}
+
+	log.Printf("[PATIENT] Stored %d duplicate candidates.", len(candidates))
+	return nil
+}
+
+// FetchDuplicateCandidates lists the candidates having the status, the most likely duplicates first, together with both patients
+func (db *MySQLDatabase) FetchDuplicateCandidates(ctx context.Context, status models.CandidateStatus, page, limit int) ([]models.DuplicateCandidate, error) {
+	offset := (page - 1) * limit
+
+	query, args, err := squirrel.Select(
+		utils.ColumnIDCandidate,
+		utils.ColumnIDPatientA,
+		utils.ColumnIDPatientB,
+		utils.ColumnScore,
+		utils.ColumnReasons,
+		utils.ColumnStatus,
+		utils.ColumnDetectedAt,
+	).From(utils.DuplicateCandidateTableName).
+		Where(squirrel.Eq{utils.ColumnStatus: status}).
+		OrderBy(utils.ColumnScore+" DESC", utils.ColumnIDCandidate).
+		Limit(uint64(limit)).Offset(uint64(offset)).
+		ToSql()
+	if err != nil {
+		log.Printf("[PATIENT] FetchDuplicateCandidates: Failed to construct SQL query: %v", err)
+		return nil, fmt.Errorf("internal server error")
+	}
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		log.Printf("[PATIENT] FetchDuplicateCandidates: Failed to query database: %v", err)
+		return nil, fmt.Errorf("failed to fetch duplicate candidates: %v", err)
+	}
+	defer rows.Close()
+
+	candidates := []models.DuplicateCandidate{}
+	var patientIDs []int
+	for rows.Next() {
+		var candidate models.DuplicateCandidate
+		var reasons string
+		if err := rows.Scan(&candidate.IDCandidate, &candidate.IDPatientA, &candidate.IDPatientB, &candidate.Score, &reasons, &candidate.Status, &candidate.DetectedAt); err != nil {
+			log.Printf("[PATIENT] Error scanning duplicate candidate row: %v", err)
+			return nil, err
+		}
+		candidate.Reasons = strings.Split(reasons, ",")
+		candidates = append(candidates, candidate)
+		patientIDs = append(patientIDs, candidate.IDPatientA, candidate.IDPatientB)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("[PATIENT] Error after iterating over duplicate candidate rows: %v", err)
+		return nil, err
+	}
+
+	if len(patientIDs) == 0 {
+		return candidates, nil
+	}
+
+	query, args, err = squirrel.Select(patientColumns()...).From(utils.PatientTableName).
+		Where(squirrel.Eq{utils.ColumnIDPatient: patientIDs}).ToSql()
+	if err != nil {
+		log.Printf("[PATIENT] FetchDuplicateCandidates: Failed to construct SQL query: %v", err)
+		return nil, fmt.Errorf("internal server error")
+	}
+
+	patients, err := db.queryPatients(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	patientsByID := make(map[int]*models.Patient, len(patients))
+	for i := range patients {
+		patientsByID[patients[i].IDPatient] = &patients[i]
+	}
+	for i := range candidates {
+		candidates[i].PatientA = patientsByID[candidates[i].IDPatientA]
+		candidates[i].PatientB = patientsByID[candidates[i].IDPatientB]
+	}
+
+	log.Printf("[PATIENT] Successfully fetched %d duplicate candidates.", len(candidates))
+	return candidates, nil
+}
+
+// DismissDuplicateCandidate marks an open candidate as not being a duplicate, later scans leave it alone
+func (db *MySQLDatabase) DismissDuplicateCandidate(ctx context.Context, candidateID int) (int, error) {
+	query := fmt.Sprintf("UPDATE %s SET %s = ? WHERE %s = ? AND %s = ?",
+		utils.DuplicateCandidateTableName,
+		utils.ColumnStatus,
+		utils.ColumnIDCandidate,
+		utils.ColumnStatus,
+	)
+
+	result, err := db.ExecContext(ctx, query, utils.CandidateStatusDismissed, candidateID, utils.CandidateStatusOpen)
+	if err != nil {
+		log.Printf("[PATIENT] Error dismissing duplicate candidate %d: %v", candidateID, err)
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		log.Printf("[PATIENT] Error fetching rows affected for dismissal of duplicate candidate %d: %v", candidateID, err)
+		return 0, err
+	}
+
+	return int(rowsAffected), nil
+}
+
+// queryPatients runs a query selecting patientColumns and scans every row
+func (db *MySQLDatabase) queryPatients(ctx context.Context, query string, args ...interface{}) ([]models.Patient, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		log.Printf("[PATIENT] Failed to query patients: %v", err)
+		return nil, fmt.Errorf("failed to fetch patients: %v", err)
+	}
+	defer rows.Close()
+
+	var patients []models.Patient
+	for rows.Next() {
+		patient, err := db.scanPatient(ctx, rows)
+		if err != nil {
+			log.Printf("[PATIENT] Error scanning patient row: %v", err)
+			return nil, err
+		}
+		patients = append(patients, *patient)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("[PATIENT] Error after iterating over rows: %v", err)
+		return nil, err
+	}
+
+	return patients, nil
+}
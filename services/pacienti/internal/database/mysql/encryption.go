@@ -0,0 +1,242 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/mihnea1711/POS_Project/services/pacienti/internal/encryption"
+	"github.com/mihnea1711/POS_Project/services/pacienti/internal/models"
+	"github.com/mihnea1711/POS_Project/services/pacienti/pkg/utils"
+)
+
+// blindIndexColumns maps the encrypted columns to the columns holding their blind indexes
+var blindIndexColumns = map[string]string{
+	utils.ColumnEmail:       utils.ColumnEmailIndex,
+	utils.ColumnPhoneNumber: utils.ColumnPhoneNumberIndex,
+	utils.ColumnCNP:         utils.ColumnCNPIndex,
+}
+
+// sealedIdentifiers are the identifiers of a patient as they are stored, encrypted and with their blind indexes
+type sealedIdentifiers struct {
+	email            string
+	phoneNumber      string
+	cnp              string
+	emailIndex       sql.NullString
+	phoneNumberIndex sql.NullString
+	cnpIndex         sql.NullString
+}
+
+// sealIdentifiers encrypts the email, the phone number and the CNP of a patient and computes their blind indexes
+func (db *MySQLDatabase) sealIdentifiers(ctx context.Context, email, phoneNumber, cnp string) (*sealedIdentifiers, error) {
+	sealed := &sealedIdentifiers{}
+	var err error
+
+	fields := []struct {
+		column string
+		value  string
+		cipher *string
+		index  *sql.NullString
+	}{
+		{utils.ColumnEmail, email, &sealed.email, &sealed.emailIndex},
+		{utils.ColumnPhoneNumber, phoneNumber, &sealed.phoneNumber, &sealed.phoneNumberIndex},
+		{utils.ColumnCNP, cnp, &sealed.cnp, &sealed.cnpIndex},
+	}
+	for _, field := range fields {
+		if *field.cipher, err = db.cipher.Encrypt(ctx, field.column, field.value); err != nil {
+			log.Printf("[PATIENT] Error encrypting %s: %v", field.column, err)
+			return nil, err
+		}
+		if *field.index, err = db.blindIndex(ctx, field.column, field.value); err != nil {
+			log.Printf("[PATIENT] Error indexing %s: %v", field.column, err)
+			return nil, err
+		}
+	}
+	return sealed, nil
+}
+
+// openIdentifiers decrypts the identifiers of a patient read from the database
+func (db *MySQLDatabase) openIdentifiers(ctx context.Context, patient *models.Patient) error {
+	var err error
+	if patient.Email, err = db.cipher.Decrypt(ctx, utils.ColumnEmail, patient.Email); err != nil {
+		return err
+	}
+	if patient.PhoneNumber, err = db.cipher.Decrypt(ctx, utils.ColumnPhoneNumber, patient.PhoneNumber); err != nil {
+		return err
+	}
+	if patient.CNP, err = db.cipher.Decrypt(ctx, utils.ColumnCNP, patient.CNP); err != nil {
+		return err
+	}
+	return nil
+}
+
+// blindIndex is the index an identifier is looked up by. Emails are compared without case and phone numbers
+// in their national form, as the database compared them before they were encrypted.
+func (db *MySQLDatabase) blindIndex(ctx context.Context, column, value string) (sql.NullString, error) {
+	normalized := strings.TrimSpace(value)
+	switch column {
+	case utils.ColumnEmail:
+		normalized = strings.ToLower(normalized)
+	case utils.ColumnPhoneNumber:
+		normalized = utils.NormalizePhoneNumber(normalized)
+	}
+
+	index, err := db.cipher.BlindIndex(ctx, column, normalized)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	return sql.NullString{String: index, Valid: index != ""}, nil
+}
+
+// RotatePatientKeys wraps the data keys of the identifiers left under a retired key again with the active key, the
+// identifiers themselves are not decrypted. Identifiers written before the encryption was introduced are encrypted and
+// indexed. The patients are visited in batches, a patient changed during the sweep is skipped and picked up by the next one.
+func (db *MySQLDatabase) RotatePatientKeys(ctx context.Context, batchSize int) (*models.KeyRotationReport, error) {
+	activeKeyID, err := db.cipher.Provider().ActiveKeyID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.KeyRotationReport{ActiveKeyID: activeKeyID, KeysInUse: map[string]int{}}
+	selectQuery := fmt.Sprintf("SELECT %s, %s, %s, %s, %s, %s, %s FROM %s WHERE %s > ? ORDER BY %s LIMIT ?",
+		utils.ColumnIDPatient,
+		utils.ColumnEmail, utils.ColumnPhoneNumber, utils.ColumnCNP,
+		utils.ColumnEmailIndex, utils.ColumnPhoneNumberIndex, utils.ColumnCNPIndex,
+		utils.PatientTableName,
+		utils.ColumnIDPatient, utils.ColumnIDPatient,
+	)
+	updateQuery := fmt.Sprintf("UPDATE %s SET %s = ?, %s = ?, %s = ?, %s = ?, %s = ?, %s = ? WHERE %s = ? AND %s = ? AND %s = ? AND %s = ?",
+		utils.PatientTableName,
+		utils.ColumnEmail, utils.ColumnPhoneNumber, utils.ColumnCNP,
+		utils.ColumnEmailIndex, utils.ColumnPhoneNumberIndex, utils.ColumnCNPIndex,
+		utils.ColumnIDPatient, utils.ColumnEmail, utils.ColumnPhoneNumber, utils.ColumnCNP,
+	)
+
+	lastID := 0
+	for {
+		batch, err := db.fetchRotationBatch(ctx, selectQuery, lastID, batchSize)
+		if err != nil {
+			return nil, err
+		}
+		if len(batch) == 0 {
+			break
+		}
+
+		for _, stored := range batch {
+			lastID = stored.idPatient
+			report.Scanned++
+
+			if !needsRotation(activeKeyID, stored.values[:]...) {
+				countKeys(report.KeysInUse, stored.values[:]...)
+				continue
+			}
+
+			rotated, err := db.rotateIdentifiers(ctx, stored)
+			if err != nil {
+				log.Printf("[PATIENT] Error rotating the keys of patient %d: %v", stored.idPatient, err)
+				return nil, err
+			}
+
+			result, err := db.ExecContext(ctx, updateQuery,
+				rotated.values[0], rotated.values[1], rotated.values[2],
+				rotated.indexes[0], rotated.indexes[1], rotated.indexes[2],
+				stored.idPatient, stored.values[0], stored.values[1], stored.values[2],
+			)
+			if err != nil {
+				log.Printf("[PATIENT] Error saving the rotated keys of patient %d: %v", stored.idPatient, err)
+				return nil, err
+			}
+			if rowsAffected, err := result.RowsAffected(); err != nil || rowsAffected == 0 {
+				report.Skipped++
+				countKeys(report.KeysInUse, stored.values[:]...)
+				continue
+			}
+
+			report.Rewrapped++
+			countKeys(report.KeysInUse, rotated.values[:]...)
+		}
+	}
+
+	log.Printf("[PATIENT] Key rotation scanned %d patients, rewrapped %d, skipped %d.", report.Scanned, report.Rewrapped, report.Skipped)
+	return report, nil
+}
+
+// rotationRow holds the email, the phone number and the CNP of a patient as they are stored, in this order
+type rotationRow struct {
+	idPatient int
+	values    [3]string
+	indexes   [3]sql.NullString
+}
+
+var rotationColumns = [3]string{utils.ColumnEmail, utils.ColumnPhoneNumber, utils.ColumnCNP}
+
+func (db *MySQLDatabase) rotateIdentifiers(ctx context.Context, stored rotationRow) (*rotationRow, error) {
+	rotated := stored
+	for i, column := range rotationColumns {
+		value := stored.values[i]
+		if value == "" {
+			continue
+		}
+
+		if encryption.IsEncrypted(value) {
+			rewrapped, err := db.cipher.Rewrap(ctx, column, value)
+			if err != nil {
+				return nil, err
+			}
+			rotated.values[i] = rewrapped
+			continue
+		}
+
+		encrypted, err := db.cipher.Encrypt(ctx, column, value)
+		if err != nil {
+			return nil, err
+		}
+		index, err := db.blindIndex(ctx, column, value)
+		if err != nil {
+			return nil, err
+		}
+		rotated.values[i] = encrypted
+		rotated.indexes[i] = index
+	}
+	return &rotated, nil
+}
+
+func (db *MySQLDatabase) fetchRotationBatch(ctx context.Context, query string, afterID, batchSize int) ([]rotationRow, error) {
+	rows, err := db.QueryContext(ctx, query, afterID, batchSize)
+	if err != nil {
+		log.Printf("[PATIENT] Error fetching patients to rotate: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var batch []rotationRow
+	for rows.Next() {
+		var row rotationRow
+		if err := rows.Scan(&row.idPatient, &row.values[0], &row.values[1], &row.values[2], &row.indexes[0], &row.indexes[1], &row.indexes[2]); err != nil {
+			log.Printf("[PATIENT] Error scanning patient to rotate: %v", err)
+			return nil, err
+		}
+		batch = append(batch, row)
+	}
+	return batch, rows.Err()
+}
+
+// needsRotation tells whether a stored value is left in plaintext or under a key other than the active one
+func needsRotation(activeKeyID string, values ...string) bool {
+	for _, value := range values {
+		if value != "" && encryption.KeyID(value) != activeKeyID {
+			return true
+		}
+	}
+	return false
+}
+
+func countKeys(keysInUse map[string]int, values ...string) {
+	for _, value := range values {
+		if keyID := encryption.KeyID(value); keyID != "" {
+			keysInUse[keyID]++
+		}
+	}
+}
@@ -0,0 +1,287 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/mihnea1711/POS_Project/services/pacienti/internal/database"
+	"github.com/mihnea1711/POS_Project/services/pacienti/internal/models"
+	"github.com/mihnea1711/POS_Project/services/pacienti/pkg/utils"
+)
+
+// SaveLegalHold places a legal hold on a patient, or replaces the reason of the one in place. It reports whether the
+// hold is new.
+func (db *MySQLDatabase) SaveLegalHold(ctx context.Context, hold *models.LegalHold) (bool, error) {
+	hold.PlacedAt = time.Now().UTC().Truncate(time.Second)
+
+	query := fmt.Sprintf("INSERT INTO %s (%s, %s, %s, %s) VALUES (?, ?, ?, ?) ON DUPLICATE KEY UPDATE %s = VALUES(%s), %s = VALUES(%s), %s = VALUES(%s)",
+		utils.LegalHoldTableName,
+		utils.ColumnIDPatient,
+		utils.ColumnReason,
+		utils.ColumnPlacedBy,
+		utils.ColumnPlacedAt,
+		utils.ColumnReason, utils.ColumnReason,
+		utils.ColumnPlacedBy, utils.ColumnPlacedBy,
+		utils.ColumnPlacedAt, utils.ColumnPlacedAt,
+	)
+
+	result, err := db.ExecContext(ctx, query, hold.IDPatient, hold.Reason, hold.PlacedBy, hold.PlacedAt)
+	if err != nil {
+		log.Printf("[PATIENT] Error placing legal hold on patient %d: %v", hold.IDPatient, err)
+		return false, err
+	}
+
+	// MySQL counts an inserted row once and an updated one twice
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		log.Printf("[PATIENT] Error getting rows affected for legal hold of patient %d: %v", hold.IDPatient, err)
+		return false, err
+	}
+
+	log.Printf("[PATIENT] Legal hold placed on patient %d", hold.IDPatient)
+	return rowsAffected == 1, nil
+}
+
+func (db *MySQLDatabase) FetchLegalHold(ctx context.Context, patientID int) (*models.LegalHold, error) {
+	query := fmt.Sprintf("SELECT %s, %s, %s, %s FROM %s WHERE %s = ?",
+		utils.ColumnIDPatient,
+		utils.ColumnReason,
+		utils.ColumnPlacedBy,
+		utils.ColumnPlacedAt,
+		utils.LegalHoldTableName,
+		utils.ColumnIDPatient,
+	)
+
+	var hold models.LegalHold
+	var placedBy sql.NullInt64
+	err := db.QueryRowContext(ctx, query, patientID).Scan(&hold.IDPatient, &hold.Reason, &placedBy, &hold.PlacedAt)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			log.Printf("[PATIENT] Error fetching legal hold of patient %d: %v", patientID, err)
+		}
+		return nil, err
+	}
+	if placedBy.Valid {
+		userID := int(placedBy.Int64)
+		hold.PlacedBy = &userID
+	}
+
+	return &hold, nil
+}
+
+func (db *MySQLDatabase) DeleteLegalHold(ctx context.Context, patientID int) (int, error) {
+	query := fmt.Sprintf("DELETE FROM %s WHERE %s = ?", utils.LegalHoldTableName, utils.ColumnIDPatient)
+
+	result, err := db.ExecContext(ctx, query, patientID)
+	if err != nil {
+		log.Printf("[PATIENT] Error releasing legal hold of patient %d: %v", patientID, err)
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		log.Printf("[PATIENT] Error getting rows affected for legal hold release of patient %d: %v", patientID, err)
+		return 0, err
+	}
+
+	return int(rowsAffected), nil
+}
+
+// FetchErasureSubject returns the patient of an IDM user together with its legal hold and past erasure, if any
+func (db *MySQLDatabase) FetchErasureSubject(ctx context.Context, userID int) (*models.ErasureSubject, error) {
+	query := fmt.Sprintf("SELECT p.%s, p.%s, h.%s, h.%s, h.%s, e.%s FROM %s p LEFT JOIN %s h ON h.%s = p.%s LEFT JOIN %s e ON e.%s = p.%s WHERE p.%s = ?",
+		utils.ColumnIDPatient,
+		utils.ColumnIDUser,
+		utils.ColumnReason,
+		utils.ColumnPlacedBy,
+		utils.ColumnPlacedAt,
+		utils.ColumnErasedAt,
+		utils.PatientTableName,
+		utils.LegalHoldTableName, utils.ColumnIDPatient, utils.ColumnIDPatient,
+		utils.ErasureTableName, utils.ColumnIDPatient, utils.ColumnIDPatient,
+		utils.ColumnIDUser,
+	)
+
+	var subject models.ErasureSubject
+	var reason sql.NullString
+	var placedBy sql.NullInt64
+	var placedAt, erasedAt sql.NullTime
+	err := db.QueryRowContext(ctx, query, userID).Scan(&subject.IDPatient, &subject.IDUser, &reason, &placedBy, &placedAt, &erasedAt)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			log.Printf("[PATIENT] Error fetching erasure subject of user %d: %v", userID, err)
+		}
+		return nil, err
+	}
+
+	if placedAt.Valid {
+		subject.LegalHold = &models.LegalHold{IDPatient: subject.IDPatient, Reason: reason.String, PlacedAt: placedAt.Time}
+		if placedBy.Valid {
+			placedByID := int(placedBy.Int64)
+			subject.LegalHold.PlacedBy = &placedByID
+		}
+	}
+	if erasedAt.Valid {
+		subject.ErasedAt = &erasedAt.Time
+	}
+
+	return &subject, nil
+}
+
+// ErasePatient erases the personal data of a patient in a single transaction. The patient row is pseudonymized rather
+// than deleted, the clinical profile and the appointments and consultations of the other modules refer to it and are
+// kept for the legal retention period. Data nobody has to keep is deleted. Running it again changes nothing new and
+// keeps the ID of the first erasure.
+func (db *MySQLDatabase) ErasePatient(ctx context.Context, patientID int, erasureID string) ([]models.ErasureRecord, error) {
+	log.Printf("[PATIENT] Attempting to erase the personal data of patient %d", patientID)
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Printf("[PATIENT] Error starting transaction to erase patient %d: %v", patientID, err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Locking the patient keeps a legal hold from being placed while the erasure runs
+	lockQuery := fmt.Sprintf("SELECT %s FROM %s WHERE %s = ? FOR UPDATE", utils.ColumnIDPatient, utils.PatientTableName, utils.ColumnIDPatient)
+	var lockedID int
+	if err := tx.QueryRowContext(ctx, lockQuery, patientID).Scan(&lockedID); err != nil {
+		if err != sql.ErrNoRows {
+			log.Printf("[PATIENT] Error locking patient %d for erasure: %v", patientID, err)
+		}
+		return nil, err
+	}
+
+	var held bool
+	holdQuery := fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM %s WHERE %s = ?)", utils.LegalHoldTableName, utils.ColumnIDPatient)
+	if err := tx.QueryRowContext(ctx, holdQuery, patientID).Scan(&held); err != nil {
+		log.Printf("[PATIENT] Error checking legal hold of patient %d: %v", patientID, err)
+		return nil, err
+	}
+	if held {
+		return nil, database.ErrLegalHold
+	}
+
+	records := []models.ErasureRecord{}
+
+	// The pseudonyms are stored like any other identifier, the indexes keep them unique
+	sealed, err := db.sealIdentifiers(ctx, fmt.Sprintf(utils.ERASED_EMAIL_FORMAT, patientID), "", fmt.Sprintf(utils.ERASED_CNP_FORMAT, patientID))
+	if err != nil {
+		return nil, err
+	}
+
+	identityQuery := fmt.Sprintf("UPDATE %s SET %s = ?, %s = ?, %s = ?, %s = ?, %s = ?, %s = ?, %s = ?, %s = ?, %s = MAKEDATE(YEAR(%s), 1), %s = false WHERE %s = ?",
+		utils.PatientTableName,
+		utils.ColumnFirstName,
+		utils.ColumnSecondName,
+		utils.ColumnEmail,
+		utils.ColumnPhoneNumber,
+		utils.ColumnCNP,
+		utils.ColumnEmailIndex,
+		utils.ColumnPhoneNumberIndex,
+		utils.ColumnCNPIndex,
+		utils.ColumnBirthDay, utils.ColumnBirthDay,
+		utils.ColumnIsActive,
+		utils.ColumnIDPatient,
+	)
+	if _, err := tx.ExecContext(ctx, identityQuery, utils.ERASED_NAME, utils.ERASED_NAME,
+		sealed.email, sealed.phoneNumber, sealed.cnp, sealed.emailIndex, sealed.phoneNumberIndex, sealed.cnpIndex, patientID); err != nil {
+		log.Printf("[PATIENT] Error pseudonymizing patient %d: %v", patientID, err)
+		return nil, err
+	}
+	records = append(records, models.ErasureRecord{
+		Category: utils.ERASURE_CATEGORY_IDENTITY,
+		Action:   utils.ErasureActionAnonymized,
+		Count:    1,
+		Reason:   "pseudonymized, the records kept for the legal retention period refer to it",
+	})
+
+	// Emergency contacts are people other than the patient, nothing requires keeping them
+	contacts, err := execCount(ctx, tx, fmt.Sprintf("DELETE FROM %s WHERE %s = ?", utils.EmergencyContactTableName, utils.ColumnIDPatient), patientID)
+	if err != nil {
+		return nil, err
+	}
+	records = append(records, models.ErasureRecord{Category: utils.ERASURE_CATEGORY_EMERGENCY_CONTACTS, Action: utils.ErasureActionDeleted, Count: contacts})
+
+	historyQuery := fmt.Sprintf("UPDATE %s SET %s = JSON_SET(%s, '$.%s', JSON_ARRAY()) WHERE %s = ? AND JSON_LENGTH(%s, '$.%s') > 0",
+		utils.ClinicalProfileHistoryTableName,
+		utils.ColumnSnapshot, utils.ColumnSnapshot, utils.PROFILE_SECTION_EMERGENCY_CONTACTS,
+		utils.ColumnIDPatient,
+		utils.ColumnSnapshot, utils.PROFILE_SECTION_EMERGENCY_CONTACTS,
+	)
+	snapshots, err := execCount(ctx, tx, historyQuery, patientID)
+	if err != nil {
+		return nil, err
+	}
+	records = append(records, models.ErasureRecord{
+		Category: utils.ERASURE_CATEGORY_PROFILE_HISTORY,
+		Action:   utils.ErasureActionAnonymized,
+		Count:    snapshots,
+		Reason:   "emergency contacts removed from the snapshots, the medical history is kept for the legal retention period",
+	})
+
+	var profiles int
+	profileQuery := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s = ?", utils.ClinicalProfileTableName, utils.ColumnIDPatient)
+	if err := tx.QueryRowContext(ctx, profileQuery, patientID).Scan(&profiles); err != nil {
+		log.Printf("[PATIENT] Error counting clinical profile of patient %d: %v", patientID, err)
+		return nil, err
+	}
+	records = append(records, models.ErasureRecord{
+		Category: utils.ERASURE_CATEGORY_CLINICAL_PROFILE,
+		Action:   utils.ErasureActionRetained,
+		Count:    profiles,
+		Reason:   "medical record, kept for the legal retention period",
+	})
+
+	policies, err := execCount(ctx, tx, fmt.Sprintf("DELETE FROM %s WHERE %s = ?", utils.InsurancePolicyTableName, utils.ColumnIDPatient), patientID)
+	if err != nil {
+		return nil, err
+	}
+	records = append(records, models.ErasureRecord{Category: utils.ERASURE_CATEGORY_INSURANCE_POLICIES, Action: utils.ErasureActionDeleted, Count: policies})
+
+	candidatesQuery := fmt.Sprintf("DELETE FROM %s WHERE %s = ? OR %s = ?", utils.DuplicateCandidateTableName, utils.ColumnIDPatientA, utils.ColumnIDPatientB)
+	candidates, err := execCount(ctx, tx, candidatesQuery, patientID, patientID)
+	if err != nil {
+		return nil, err
+	}
+	records = append(records, models.ErasureRecord{Category: utils.ERASURE_CATEGORY_DUPLICATE_CANDIDATES, Action: utils.ErasureActionDeleted, Count: candidates})
+
+	erasureQuery := fmt.Sprintf("INSERT INTO %s (%s, %s, %s) VALUES (?, ?, ?) ON DUPLICATE KEY UPDATE %s = %s",
+		utils.ErasureTableName,
+		utils.ColumnIDPatient,
+		utils.ColumnIDErasure,
+		utils.ColumnErasedAt,
+		utils.ColumnIDErasure, utils.ColumnIDErasure,
+	)
+	if _, err := tx.ExecContext(ctx, erasureQuery, patientID, erasureID, time.Now().UTC().Truncate(time.Second)); err != nil {
+		log.Printf("[PATIENT] Error recording erasure of patient %d: %v", patientID, err)
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("[PATIENT] Error committing erasure of patient %d: %v", patientID, err)
+		return nil, err
+	}
+
+	log.Printf("[PATIENT] Erased the personal data of patient %d", patientID)
+	return records, nil
+}
+
+// execCount runs a statement in the transaction and returns the number of rows it affected
+func execCount(ctx context.Context, tx *sql.Tx, query string, args ...interface{}) (int, error) {
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		log.Printf("[PATIENT] Error executing erasure statement: %v", err)
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		log.Printf("[PATIENT] Error getting rows affected by erasure statement: %v", err)
+		return 0, err
+	}
+	return int(rowsAffected), nil
+}
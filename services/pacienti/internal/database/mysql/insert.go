@@ -2,6 +2,7 @@ package mysql
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"log"
 
@@ -11,7 +12,7 @@ import (
 
 func (db *MySQLDatabase) SavePatient(ctx context.Context, patient *models.Patient) (int, error) {
 	// Construct the SQL insert query
-	query := fmt.Sprintf("INSERT INTO %s (%s, %s, %s, %s, %s, %s, %s, %s) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+	query := fmt.Sprintf("INSERT INTO %s (%s, %s, %s, %s, %s, %s, %s, %s, %s, %s, %s, %s) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
 		utils.PatientTableName,
 		utils.ColumnIDUser,
 		utils.ColumnFirstName,
@@ -19,14 +20,24 @@ func (db *MySQLDatabase) SavePatient(ctx context.Context, patient *models.Patien
 		utils.ColumnEmail,
 		utils.ColumnPhoneNumber,
 		utils.ColumnCNP,
+		utils.ColumnEmailIndex,
+		utils.ColumnPhoneNumberIndex,
+		utils.ColumnCNPIndex,
 		utils.ColumnBirthDay,
 		utils.ColumnIsActive,
+		utils.ColumnSex,
 	)
 
 	log.Println("[PATIENT] Attempting to save patient")
 
+	sealed, err := db.sealIdentifiers(ctx, patient.Email, patient.PhoneNumber, patient.CNP)
+	if err != nil {
+		return 0, err
+	}
+
 	// Execute the SQL statement
-	result, err := db.ExecContext(ctx, query, patient.IDUser, patient.FirstName, patient.SecondName, patient.Email, patient.PhoneNumber, patient.CNP, patient.BirthDay, patient.IsActive)
+	result, err := db.ExecContext(ctx, query, patient.IDUser, patient.FirstName, patient.SecondName,
+		sealed.email, sealed.phoneNumber, sealed.cnp, sealed.emailIndex, sealed.phoneNumberIndex, sealed.cnpIndex, patient.BirthDay, patient.IsActive, nullableSex(patient.Sex))
 	if err != nil {
 		log.Printf("[PATIENT] Error executing query to save patient: %v", err)
 		return 0, err
@@ -46,3 +57,8 @@ func (db *MySQLDatabase) SavePatient(ctx context.Context, patient *models.Patien
 
 	return int(lastInsertID), nil
 }
+
+// nullableSex stores an unknown sex as NULL
+func nullableSex(sex models.Sex) sql.NullString {
+	return sql.NullString{String: string(sex), Valid: sex != ""}
+}
@@ -0,0 +1,275 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/mihnea1711/POS_Project/services/pacienti/internal/models"
+	"github.com/mihnea1711/POS_Project/services/pacienti/pkg/utils"
+)
+
+// insurancePolicyColumns lists the columns read into a models.InsurancePolicy, in the order expected by scanInsurancePolicy
+func insurancePolicyColumns() []string {
+	return []string{
+		utils.ColumnIDPolicy,
+		utils.ColumnIDPatient,
+		utils.ColumnPayerType,
+		utils.ColumnPayerName,
+		utils.ColumnPolicyNumber,
+		utils.ColumnCoverageCategory,
+		utils.ColumnValidFrom,
+		utils.ColumnValidUntil,
+		utils.ColumnVerificationStatus,
+		utils.ColumnVerificationNote,
+		utils.ColumnVerifiedAt,
+		utils.ColumnCreatedAt,
+	}
+}
+
+func scanInsurancePolicy(row rowScanner) (*models.InsurancePolicy, error) {
+	var policy models.InsurancePolicy
+	var validUntil, verifiedAt sql.NullTime
+	var note sql.NullString
+
+	err := row.Scan(
+		&policy.IDPolicy,
+		&policy.IDPatient,
+		&policy.PayerType,
+		&policy.PayerName,
+		&policy.PolicyNumber,
+		&policy.CoverageCategory,
+		&policy.ValidFrom,
+		&validUntil,
+		&policy.VerificationStatus,
+		&note,
+		&verifiedAt,
+		&policy.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if validUntil.Valid {
+		policy.ValidUntil = &validUntil.Time
+	}
+	if verifiedAt.Valid {
+		policy.VerifiedAt = &verifiedAt.Time
+	}
+	policy.VerificationNote = note.String
+
+	return &policy, nil
+}
+
+// SaveInsurancePolicy stores a new policy of a patient together with its verification
+func (db *MySQLDatabase) SaveInsurancePolicy(ctx context.Context, policy *models.InsurancePolicy) (int, error) {
+	policy.CreatedAt = time.Now().UTC().Truncate(time.Second)
+
+	query := fmt.Sprintf("INSERT INTO %s (%s, %s, %s, %s, %s, %s, %s, %s, %s, %s, %s) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		utils.InsurancePolicyTableName,
+		utils.ColumnIDPatient,
+		utils.ColumnPayerType,
+		utils.ColumnPayerName,
+		utils.ColumnPolicyNumber,
+		utils.ColumnCoverageCategory,
+		utils.ColumnValidFrom,
+		utils.ColumnValidUntil,
+		utils.ColumnVerificationStatus,
+		utils.ColumnVerificationNote,
+		utils.ColumnVerifiedAt,
+		utils.ColumnCreatedAt,
+	)
+
+	result, err := db.ExecContext(ctx, query,
+		policy.IDPatient,
+		policy.PayerType,
+		policy.PayerName,
+		policy.PolicyNumber,
+		policy.CoverageCategory,
+		policy.ValidFrom,
+		policy.ValidUntil,
+		policy.VerificationStatus,
+		nullableString(policy.VerificationNote),
+		policy.VerifiedAt,
+		policy.CreatedAt,
+	)
+	if err != nil {
+		log.Printf("[PATIENT] Error saving insurance policy of patient %d: %v", policy.IDPatient, err)
+		return 0, err
+	}
+
+	lastInsertID, err := result.LastInsertId()
+	if err != nil {
+		log.Printf("[PATIENT] Error getting last insert ID: %v", err)
+		return 0, err
+	}
+
+	log.Printf("[PATIENT] Successfully saved insurance policy %d of patient %d", lastInsertID, policy.IDPatient)
+	return int(lastInsertID), nil
+}
+
+// FetchInsurancePolicies lists the policies of a patient, the latest starting first
+func (db *MySQLDatabase) FetchInsurancePolicies(ctx context.Context, patientID int) ([]models.InsurancePolicy, error) {
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s = ? ORDER BY %s DESC, %s DESC",
+		strings.Join(insurancePolicyColumns(), ", "),
+		utils.InsurancePolicyTableName,
+		utils.ColumnIDPatient,
+		utils.ColumnValidFrom,
+		utils.ColumnIDPolicy,
+	)
+
+	return db.queryInsurancePolicies(ctx, query, patientID)
+}
+
+// FetchInsurancePolicyByID returns a policy of a patient, or sql.ErrNoRows when the patient has no such policy
+func (db *MySQLDatabase) FetchInsurancePolicyByID(ctx context.Context, patientID, policyID int) (*models.InsurancePolicy, error) {
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s = ? AND %s = ?",
+		strings.Join(insurancePolicyColumns(), ", "),
+		utils.InsurancePolicyTableName,
+		utils.ColumnIDPolicy,
+		utils.ColumnIDPatient,
+	)
+
+	policy, err := scanInsurancePolicy(db.QueryRowContext(ctx, query, policyID, patientID))
+	if err != nil {
+		log.Printf("[PATIENT] Error fetching insurance policy %d of patient %d: %v", policyID, patientID, err)
+		return nil, err
+	}
+
+	return policy, nil
+}
+
+// FetchCoveringPolicies lists the policies of a patient in force on a day that were not rejected by their payer
+func (db *MySQLDatabase) FetchCoveringPolicies(ctx context.Context, patientID int, day time.Time) ([]models.InsurancePolicy, error) {
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s = ? AND %s <= ? AND (%s IS NULL OR %s >= ?) AND %s <> ? ORDER BY %s DESC",
+		strings.Join(insurancePolicyColumns(), ", "),
+		utils.InsurancePolicyTableName,
+		utils.ColumnIDPatient,
+		utils.ColumnValidFrom,
+		utils.ColumnValidUntil,
+		utils.ColumnValidUntil,
+		utils.ColumnVerificationStatus,
+		utils.ColumnValidFrom,
+	)
+
+	date := day.Format(utils.BIRTH_DAY_QUERY_FORMAT)
+	return db.queryInsurancePolicies(ctx, query, patientID, date, date, utils.VerificationStatusRejected)
+}
+
+// UpdateInsurancePolicy replaces the details and the verification of a policy
+func (db *MySQLDatabase) UpdateInsurancePolicy(ctx context.Context, policy *models.InsurancePolicy) (int, error) {
+	query := fmt.Sprintf("UPDATE %s SET %s = ?, %s = ?, %s = ?, %s = ?, %s = ?, %s = ?, %s = ?, %s = ?, %s = ? WHERE %s = ? AND %s = ?",
+		utils.InsurancePolicyTableName,
+		utils.ColumnPayerType,
+		utils.ColumnPayerName,
+		utils.ColumnPolicyNumber,
+		utils.ColumnCoverageCategory,
+		utils.ColumnValidFrom,
+		utils.ColumnValidUntil,
+		utils.ColumnVerificationStatus,
+		utils.ColumnVerificationNote,
+		utils.ColumnVerifiedAt,
+		utils.ColumnIDPolicy,
+		utils.ColumnIDPatient,
+	)
+
+	result, err := db.ExecContext(ctx, query,
+		policy.PayerType,
+		policy.PayerName,
+		policy.PolicyNumber,
+		policy.CoverageCategory,
+		policy.ValidFrom,
+		policy.ValidUntil,
+		policy.VerificationStatus,
+		nullableString(policy.VerificationNote),
+		policy.VerifiedAt,
+		policy.IDPolicy,
+		policy.IDPatient,
+	)
+	if err != nil {
+		log.Printf("[PATIENT] Error updating insurance policy %d: %v", policy.IDPolicy, err)
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		log.Printf("[PATIENT] Error getting rows affected: %v", err)
+		return 0, err
+	}
+
+	log.Printf("[PATIENT] Insurance policy %d updated, %d rows affected", policy.IDPolicy, rowsAffected)
+	return int(rowsAffected), nil
+}
+
+// UpdatePolicyVerification records the answer of the verifier about a policy
+func (db *MySQLDatabase) UpdatePolicyVerification(ctx context.Context, policy *models.InsurancePolicy) (int, error) {
+	query := fmt.Sprintf("UPDATE %s SET %s = ?, %s = ?, %s = ? WHERE %s = ?",
+		utils.InsurancePolicyTableName,
+		utils.ColumnVerificationStatus,
+		utils.ColumnVerificationNote,
+		utils.ColumnVerifiedAt,
+		utils.ColumnIDPolicy,
+	)
+
+	result, err := db.ExecContext(ctx, query, policy.VerificationStatus, nullableString(policy.VerificationNote), policy.VerifiedAt, policy.IDPolicy)
+	if err != nil {
+		log.Printf("[PATIENT] Error updating the verification of insurance policy %d: %v", policy.IDPolicy, err)
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		log.Printf("[PATIENT] Error getting rows affected: %v", err)
+		return 0, err
+	}
+
+	return int(rowsAffected), nil
+}
+
+func (db *MySQLDatabase) DeleteInsurancePolicy(ctx context.Context, patientID, policyID int) (int, error) {
+	query := fmt.Sprintf("DELETE FROM %s WHERE %s = ? AND %s = ?", utils.InsurancePolicyTableName, utils.ColumnIDPolicy, utils.ColumnIDPatient)
+
+	result, err := db.ExecContext(ctx, query, policyID, patientID)
+	if err != nil {
+		log.Printf("[PATIENT] Error deleting insurance policy %d of patient %d: %v", policyID, patientID, err)
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		log.Printf("[PATIENT] Error getting rows affected: %v", err)
+		return 0, err
+	}
+
+	log.Printf("[PATIENT] Insurance policy %d of patient %d deleted, %d rows affected", policyID, patientID, rowsAffected)
+	return int(rowsAffected), nil
+}
+
+func (db *MySQLDatabase) queryInsurancePolicies(ctx context.Context, query string, args ...interface{}) ([]models.InsurancePolicy, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		log.Printf("[PATIENT] Failed to query insurance policies: %v", err)
+		return nil, fmt.Errorf("failed to fetch insurance policies: %v", err)
+	}
+	defer rows.Close()
+
+	policies := []models.InsurancePolicy{}
+	for rows.Next() {
+		policy, err := scanInsurancePolicy(rows)
+		if err != nil {
+			log.Printf("[PATIENT] Error scanning insurance policy row: %v", err)
+			return nil, err
+		}
+		policies = append(policies, *policy)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("[PATIENT] Error after iterating over insurance policy rows: %v", err)
+		return nil, err
+	}
+
+	return policies, nil
+}
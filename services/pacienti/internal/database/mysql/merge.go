@@ -0,0 +1,384 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mihnea1711/POS_Project/services/pacienti/internal/database"
+	"github.com/mihnea1711/POS_Project/services/pacienti/internal/models"
+	"github.com/mihnea1711/POS_Project/services/pacienti/pkg/utils"
+)
+
+func mergeColumns() []string {
+	return []string{
+		utils.ColumnIDMerge,
+		utils.ColumnIDSurvivor,
+		utils.ColumnIDDuplicate,
+		utils.ColumnStatus,
+		utils.ColumnStep,
+		utils.ColumnAppointmentsMoved,
+		utils.ColumnAppointmentsDropped,
+		utils.ColumnConsultationsMoved,
+		utils.ColumnError,
+		utils.ColumnCreatedAt,
+		utils.ColumnUpdatedAt,
+		utils.ColumnCompletedAt,
+	}
+}
+
+func scanMerge(row rowScanner) (*models.PatientMerge, error) {
+	var merge models.PatientMerge
+	var dropped string
+	var mergeError sql.NullString
+	var completedAt sql.NullTime
+
+	err := row.Scan(
+		&merge.IDMerge,
+		&merge.IDSurvivor,
+		&merge.IDDuplicate,
+		&merge.Status,
+		&merge.Step,
+		&merge.AppointmentsMoved,
+		&dropped,
+		&merge.ConsultationsMoved,
+		&mergeError,
+		&merge.CreatedAt,
+		&merge.UpdatedAt,
+		&completedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	merge.DroppedAppointments = []int{}
+	if dropped != "" {
+		for _, id := range strings.Split(dropped, ",") {
+			appointmentID, err := strconv.Atoi(id)
+			if err != nil {
+				return nil, fmt.Errorf("invalid dropped appointment ID %q: %w", id, err)
+			}
+			merge.DroppedAppointments = append(merge.DroppedAppointments, appointmentID)
+		}
+	}
+	merge.Error = mergeError.String
+	if completedAt.Valid {
+		merge.CompletedAt = &completedAt.Time
+	}
+
+	return &merge, nil
+}
+
+// StartPatientMerge records a merge and deactivates the duplicate record, so it is no longer booked or detected.
+// A failed merge of the same records, or one left pending for longer than MERGE_RESUME_AFTER_SECONDS, is resumed and false is returned.
+// Records already merged into another patient, or being merged, are refused with database.ErrMergeConflict.
+func (db *MySQLDatabase) StartPatientMerge(ctx context.Context, request *models.MergeRequest) (*models.PatientMerge, bool, error) {
+	log.Printf("[PATIENT] Attempting to start merging patient %d into patient %d", request.IDDuplicate, request.IDSurvivor)
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Printf("[PATIENT] Error starting transaction to merge patients: %v", err)
+		return nil, false, err
+	}
+	defer tx.Rollback()
+
+	// Locking both patients serializes the merges touching them
+	lockQuery := fmt.Sprintf("SELECT %s FROM %s WHERE %s IN (?, ?) FOR UPDATE", utils.ColumnIDPatient, utils.PatientTableName, utils.ColumnIDPatient)
+	rows, err := tx.QueryContext(ctx, lockQuery, request.IDSurvivor, request.IDDuplicate)
+	if err != nil {
+		log.Printf("[PATIENT] Error locking patients %d and %d: %v", request.IDSurvivor, request.IDDuplicate, err)
+		return nil, false, err
+	}
+	found := 0
+	for rows.Next() {
+		found++
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, false, err
+	}
+	if found != 2 {
+		log.Printf("[PATIENT] Patient %d or %d does not exist", request.IDSurvivor, request.IDDuplicate)
+		return nil, false, sql.ErrNoRows
+	}
+
+	// A record merged away cannot take part in another merge, a record still absorbing another one cannot be merged away
+	existingQuery := fmt.Sprintf("SELECT %s FROM %s WHERE %s IN (?, ?) OR (%s = ? AND %s <> ?)",
+		strings.Join(mergeColumns(), ", "),
+		utils.PatientMergeTableName,
+		utils.ColumnIDDuplicate,
+		utils.ColumnIDSurvivor,
+		utils.ColumnStatus,
+	)
+	rows, err = tx.QueryContext(ctx, existingQuery, request.IDSurvivor, request.IDDuplicate, request.IDDuplicate, utils.MergeStatusCompleted)
+	if err != nil {
+		log.Printf("[PATIENT] Error fetching the merges of patients %d and %d: %v", request.IDSurvivor, request.IDDuplicate, err)
+		return nil, false, err
+	}
+	var started *models.PatientMerge
+	for rows.Next() {
+		merge, err := scanMerge(rows)
+		if err != nil {
+			rows.Close()
+			log.Printf("[PATIENT] Error scanning merge row: %v", err)
+			return nil, false, err
+		}
+
+		switch {
+		case merge.IDSurvivor != request.IDSurvivor || merge.IDDuplicate != request.IDDuplicate:
+			rows.Close()
+			return nil, false, fmt.Errorf("%w: merge %d of patient %d into patient %d", database.ErrMergeConflict, merge.IDMerge, merge.IDDuplicate, merge.IDSurvivor)
+		case merge.Status == utils.MergeStatusCompleted:
+			rows.Close()
+			return merge, false, fmt.Errorf("%w: merge %d", database.ErrMergeCompleted, merge.IDMerge)
+		case merge.Status == utils.MergeStatusPending && time.Since(merge.UpdatedAt) < utils.MERGE_RESUME_AFTER_SECONDS*time.Second:
+			rows.Close()
+			return merge, false, fmt.Errorf("%w: merge %d", database.ErrMergeInProgress, merge.IDMerge)
+		default:
+			started = merge
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, false, err
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	created := started == nil
+	if !created {
+		query := fmt.Sprintf("UPDATE %s SET %s = ?, %s = NULL, %s = ? WHERE %s = ?",
+			utils.PatientMergeTableName,
+			utils.ColumnStatus,
+			utils.ColumnError,
+			utils.ColumnUpdatedAt,
+			utils.ColumnIDMerge,
+		)
+		if _, err := tx.ExecContext(ctx, query, utils.MergeStatusPending, now, started.IDMerge); err != nil {
+			log.Printf("[PATIENT] Error resuming merge %d: %v", started.IDMerge, err)
+			return nil, false, err
+		}
+		started.Status = utils.MergeStatusPending
+		started.Error = ""
+		started.UpdatedAt = now
+	} else {
+		query := fmt.Sprintf("INSERT INTO %s (%s, %s, %s, %s, %s, %s) VALUES (?, ?, ?, ?, ?, ?)",
+			utils.PatientMergeTableName,
+			utils.ColumnIDSurvivor,
+			utils.ColumnIDDuplicate,
+			utils.ColumnStatus,
+			utils.ColumnStep,
+			utils.ColumnCreatedAt,
+			utils.ColumnUpdatedAt,
+		)
+		result, err := tx.ExecContext(ctx, query, request.IDSurvivor, request.IDDuplicate, utils.MergeStatusPending, utils.MergeStepAppointments, now, now)
+		if err != nil {
+			log.Printf("[PATIENT] Error recording the merge of patient %d into patient %d: %v", request.IDDuplicate, request.IDSurvivor, err)
+			return nil, false, err
+		}
+		mergeID, err := result.LastInsertId()
+		if err != nil {
+			log.Printf("[PATIENT] Error getting the ID of the merge: %v", err)
+			return nil, false, err
+		}
+
+		started = &models.PatientMerge{
+			IDMerge:             int(mergeID),
+			IDSurvivor:          request.IDSurvivor,
+			IDDuplicate:         request.IDDuplicate,
+			Status:              utils.MergeStatusPending,
+			Step:                utils.MergeStepAppointments,
+			DroppedAppointments: []int{},
+			CreatedAt:           now,
+			UpdatedAt:           now,
+		}
+	}
+
+	if err := deactivatePatient(ctx, tx, request.IDDuplicate); err != nil {
+		return nil, false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("[PATIENT] Error committing the start of merge %d: %v", started.IDMerge, err)
+		return nil, false, err
+	}
+
+	log.Printf("[PATIENT] Merge %d of patient %d into patient %d started at step %s", started.IDMerge, request.IDDuplicate, request.IDSurvivor, started.Step)
+	return started, created, nil
+}
+
+// AdvancePatientMerge saves the counters of a merge together with the next step to run
+func (db *MySQLDatabase) AdvancePatientMerge(ctx context.Context, merge *models.PatientMerge) error {
+	query := fmt.Sprintf("UPDATE %s SET %s = ?, %s = ?, %s = ?, %s = ?, %s = ? WHERE %s = ?",
+		utils.PatientMergeTableName,
+		utils.ColumnStep,
+		utils.ColumnAppointmentsMoved,
+		utils.ColumnAppointmentsDropped,
+		utils.ColumnConsultationsMoved,
+		utils.ColumnUpdatedAt,
+		utils.ColumnIDMerge,
+	)
+
+	dropped := make([]string, 0, len(merge.DroppedAppointments))
+	for _, appointmentID := range merge.DroppedAppointments {
+		dropped = append(dropped, strconv.Itoa(appointmentID))
+	}
+
+	merge.UpdatedAt = time.Now().UTC().Truncate(time.Second)
+	_, err := db.ExecContext(ctx, query, merge.Step, merge.AppointmentsMoved, strings.Join(dropped, ","), merge.ConsultationsMoved, merge.UpdatedAt, merge.IDMerge)
+	if err != nil {
+		log.Printf("[PATIENT] Error advancing merge %d to step %s: %v", merge.IDMerge, merge.Step, err)
+		return err
+	}
+
+	log.Printf("[PATIENT] Merge %d advanced to step %s", merge.IDMerge, merge.Step)
+	return nil
+}
+
+// FailPatientMerge records why a step of a merge failed, the merge resumes from that step when requested again
+func (db *MySQLDatabase) FailPatientMerge(ctx context.Context, merge *models.PatientMerge) error {
+	query := fmt.Sprintf("UPDATE %s SET %s = ?, %s = ?, %s = ? WHERE %s = ?",
+		utils.PatientMergeTableName,
+		utils.ColumnStatus,
+		utils.ColumnError,
+		utils.ColumnUpdatedAt,
+		utils.ColumnIDMerge,
+	)
+
+	merge.UpdatedAt = time.Now().UTC().Truncate(time.Second)
+	if _, err := db.ExecContext(ctx, query, utils.MergeStatusFailed, merge.Error, merge.UpdatedAt, merge.IDMerge); err != nil {
+		log.Printf("[PATIENT] Error recording the failure of merge %d: %v", merge.IDMerge, err)
+		return err
+	}
+
+	merge.Status = utils.MergeStatusFailed
+	return nil
+}
+
+// CompletePatientMerge closes a merge once the appointments and the consultations were moved. The duplicate record
+// stays deactivated, its candidate pair with the survivor is marked merged and its other open candidates are dropped.
+func (db *MySQLDatabase) CompletePatientMerge(ctx context.Context, merge *models.PatientMerge) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Printf("[PATIENT] Error starting transaction to complete merge %d: %v", merge.IDMerge, err)
+		return err
+	}
+	defer tx.Rollback()
+
+	// The duplicate could have been reactivated while its records were moved
+	if err := deactivatePatient(ctx, tx, merge.IDDuplicate); err != nil {
+		return err
+	}
+
+	candidateQuery := fmt.Sprintf("UPDATE %s SET %s = ? WHERE %s = ? AND %s = ?",
+		utils.DuplicateCandidateTableName,
+		utils.ColumnStatus,
+		utils.ColumnIDPatientA,
+		utils.ColumnIDPatientB,
+	)
+	patientA, patientB := min(merge.IDSurvivor, merge.IDDuplicate), max(merge.IDSurvivor, merge.IDDuplicate)
+	if _, err := tx.ExecContext(ctx, candidateQuery, utils.CandidateStatusMerged, patientA, patientB); err != nil {
+		log.Printf("[PATIENT] Error marking the candidate %d/%d as merged: %v", patientA, patientB, err)
+		return err
+	}
+
+	openQuery := fmt.Sprintf("DELETE FROM %s WHERE %s = ? AND (%s = ? OR %s = ?)",
+		utils.DuplicateCandidateTableName,
+		utils.ColumnStatus,
+		utils.ColumnIDPatientA,
+		utils.ColumnIDPatientB,
+	)
+	if _, err := tx.ExecContext(ctx, openQuery, utils.CandidateStatusOpen, merge.IDDuplicate, merge.IDDuplicate); err != nil {
+		log.Printf("[PATIENT] Error dropping the open candidates of patient %d: %v", merge.IDDuplicate, err)
+		return err
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	mergeQuery := fmt.Sprintf("UPDATE %s SET %s = ?, %s = NULL, %s = ?, %s = ? WHERE %s = ?",
+		utils.PatientMergeTableName,
+		utils.ColumnStatus,
+		utils.ColumnError,
+		utils.ColumnUpdatedAt,
+		utils.ColumnCompletedAt,
+		utils.ColumnIDMerge,
+	)
+	if _, err := tx.ExecContext(ctx, mergeQuery, utils.MergeStatusCompleted, now, now, merge.IDMerge); err != nil {
+		log.Printf("[PATIENT] Error completing merge %d: %v", merge.IDMerge, err)
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("[PATIENT] Error committing the completion of merge %d: %v", merge.IDMerge, err)
+		return err
+	}
+
+	merge.Status = utils.MergeStatusCompleted
+	merge.Error = ""
+	merge.UpdatedAt = now
+	merge.CompletedAt = &now
+
+	log.Printf("[PATIENT] Merge %d of patient %d into patient %d completed", merge.IDMerge, merge.IDDuplicate, merge.IDSurvivor)
+	return nil
+}
+
+// FetchPatientMerges lists the merge history, the latest merges first
+func (db *MySQLDatabase) FetchPatientMerges(ctx context.Context, page, limit int) ([]models.PatientMerge, error) {
+	query := fmt.Sprintf("SELECT %s FROM %s ORDER BY %s DESC LIMIT ? OFFSET ?",
+		strings.Join(mergeColumns(), ", "),
+		utils.PatientMergeTableName,
+		utils.ColumnIDMerge,
+	)
+
+	rows, err := db.QueryContext(ctx, query, limit, (page-1)*limit)
+	if err != nil {
+		log.Printf("[PATIENT] FetchPatientMerges: Failed to query database: %v", err)
+		return nil, fmt.Errorf("failed to fetch patient merges: %v", err)
+	}
+	defer rows.Close()
+
+	merges := []models.PatientMerge{}
+	for rows.Next() {
+		merge, err := scanMerge(rows)
+		if err != nil {
+			log.Printf("[PATIENT] Error scanning merge row: %v", err)
+			return nil, err
+		}
+		merges = append(merges, *merge)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("[PATIENT] Error after iterating over merge rows: %v", err)
+		return nil, err
+	}
+
+	log.Printf("[PATIENT] Successfully fetched %d patient merges.", len(merges))
+	return merges, nil
+}
+
+func (db *MySQLDatabase) FetchPatientMergeByID(ctx context.Context, mergeID int) (*models.PatientMerge, error) {
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s = ?",
+		strings.Join(mergeColumns(), ", "),
+		utils.PatientMergeTableName,
+		utils.ColumnIDMerge,
+	)
+
+	merge, err := scanMerge(db.QueryRowContext(ctx, query, mergeID))
+	if err != nil {
+		log.Printf("[PATIENT] Error fetching merge by ID %d: %v", mergeID, err)
+		return nil, err
+	}
+
+	return merge, nil
+}
+
+func deactivatePatient(ctx context.Context, tx *sql.Tx, patientID int) error {
+	query := fmt.Sprintf("UPDATE %s SET %s = false WHERE %s = ?", utils.PatientTableName, utils.ColumnIsActive, utils.ColumnIDPatient)
+	if _, err := tx.ExecContext(ctx, query, patientID); err != nil {
+		log.Printf("[PATIENT] Error deactivating patient %d: %v", patientID, err)
+		return err
+	}
+	return nil
+}
@@ -9,14 +9,16 @@ import (
 
 	_ "github.com/go-sql-driver/mysql" // Import MySQL driver
 	"github.com/mihnea1711/POS_Project/services/pacienti/internal/database"
+	"github.com/mihnea1711/POS_Project/services/pacienti/internal/encryption"
 	"github.com/mihnea1711/POS_Project/services/pacienti/pkg/config"
 )
 
 type MySQLDatabase struct {
 	*sql.DB
+	cipher *encryption.FieldCipher
 }
 
-func NewMySQL(ctx context.Context, config *config.MySQLConfig) (database.Database, error) {
+func NewMySQL(ctx context.Context, config *config.MySQLConfig, cipher *encryption.FieldCipher) (database.Database, error) {
 	connStr := fmt.Sprintf(
 		"%s:%s@tcp(%s:%d)/%s?charset=%s&parseTime=%v",
 		config.User,
@@ -44,7 +46,7 @@ func NewMySQL(ctx context.Context, config *config.MySQLConfig) (database.Databas
 		return nil, fmt.Errorf("[PATIENT] Failed to ping MySQL: %v", err)
 	}
 
-	return &MySQLDatabase{DB: db}, nil
+	return &MySQLDatabase{DB: db, cipher: cipher}, nil
 }
 
 func (db *MySQLDatabase) Close() error {
@@ -0,0 +1,466 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/mihnea1711/POS_Project/services/pacienti/internal/database"
+	"github.com/mihnea1711/POS_Project/services/pacienti/internal/models"
+	"github.com/mihnea1711/POS_Project/services/pacienti/pkg/utils"
+)
+
+// querier is implemented by both the database and a transaction
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// SaveClinicalProfile creates the clinical profile of a patient. A profile created again after a deletion continues
+// the version numbers of the deleted one, so its history stays in order.
+func (db *MySQLDatabase) SaveClinicalProfile(ctx context.Context, profile *models.ClinicalProfile) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Printf("[PATIENT] Error starting transaction to create clinical profile: %v", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	patientQuery := fmt.Sprintf("SELECT %s FROM %s WHERE %s = ? FOR UPDATE", utils.ColumnIDPatient, utils.PatientTableName, utils.ColumnIDPatient)
+	var patientID int
+	if err := tx.QueryRowContext(ctx, patientQuery, profile.IDPatient).Scan(&patientID); err != nil {
+		log.Printf("[PATIENT] Error locking patient %d: %v", profile.IDPatient, err)
+		return err
+	}
+
+	versionQuery := fmt.Sprintf("SELECT COALESCE(MAX(%s), 0) FROM %s WHERE %s = ?", utils.ColumnVersion, utils.ClinicalProfileHistoryTableName, utils.ColumnIDPatient)
+	var lastVersion int
+	if err := tx.QueryRowContext(ctx, versionQuery, profile.IDPatient).Scan(&lastVersion); err != nil {
+		log.Printf("[PATIENT] Error fetching the last clinical profile version of patient %d: %v", profile.IDPatient, err)
+		return err
+	}
+
+	profile.Version = lastVersion + 1
+	profile.UpdatedAt = time.Now().UTC().Truncate(time.Second)
+
+	insertQuery := fmt.Sprintf("INSERT INTO %s (%s, %s, %s, %s, %s) VALUES (?, ?, ?, ?, ?)",
+		utils.ClinicalProfileTableName,
+		utils.ColumnIDPatient,
+		utils.ColumnBloodType,
+		utils.ColumnVersion,
+		utils.ColumnUpdatedBy,
+		utils.ColumnUpdatedAt,
+	)
+	if _, err := tx.ExecContext(ctx, insertQuery, profile.IDPatient, nullableBloodType(profile.BloodType), profile.Version, profile.UpdatedBy, profile.UpdatedAt); err != nil {
+		log.Printf("[PATIENT] Error inserting clinical profile of patient %d: %v", profile.IDPatient, err)
+		return err
+	}
+
+	if err := insertProfileItems(ctx, tx, profile); err != nil {
+		return err
+	}
+	if err := recordProfileChange(ctx, tx, profile, utils.ProfileActionCreated, utils.ClinicalProfileSections(profile), profile.Version, profile.UpdatedBy); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("[PATIENT] Error committing clinical profile of patient %d: %v", profile.IDPatient, err)
+		return err
+	}
+
+	log.Printf("[PATIENT] Successfully created the clinical profile of patient %d", profile.IDPatient)
+	return nil
+}
+
+// FetchClinicalProfile returns the clinical profile of a patient, or sql.ErrNoRows when the patient has none
+func (db *MySQLDatabase) FetchClinicalProfile(ctx context.Context, patientID int) (*models.ClinicalProfile, error) {
+	return fetchClinicalProfile(ctx, db, patientID, false)
+}
+
+// UpdateClinicalProfile replaces the clinical profile of a patient when profile.Version is the current version.
+// It returns the sections that changed. When nothing changed no version is added and profile is set to the stored one.
+func (db *MySQLDatabase) UpdateClinicalProfile(ctx context.Context, profile *models.ClinicalProfile) ([]string, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Printf("[PATIENT] Error starting transaction to update clinical profile: %v", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	current, err := fetchClinicalProfile(ctx, tx, profile.IDPatient, true)
+	if err != nil {
+		return nil, err
+	}
+	if profile.Version != current.Version {
+		return nil, fmt.Errorf("%w: the current version is %d", database.ErrProfileVersionConflict, current.Version)
+	}
+
+	sections := utils.ClinicalProfileChangedSections(current, profile)
+	if len(sections) == 0 {
+		*profile = *current
+		return sections, nil
+	}
+
+	profile.Version = current.Version + 1
+	profile.UpdatedAt = time.Now().UTC().Truncate(time.Second)
+
+	updateQuery := fmt.Sprintf("UPDATE %s SET %s = ?, %s = ?, %s = ?, %s = ? WHERE %s = ?",
+		utils.ClinicalProfileTableName,
+		utils.ColumnBloodType,
+		utils.ColumnVersion,
+		utils.ColumnUpdatedBy,
+		utils.ColumnUpdatedAt,
+		utils.ColumnIDPatient,
+	)
+	if _, err := tx.ExecContext(ctx, updateQuery, nullableBloodType(profile.BloodType), profile.Version, profile.UpdatedBy, profile.UpdatedAt, profile.IDPatient); err != nil {
+		log.Printf("[PATIENT] Error updating clinical profile of patient %d: %v", profile.IDPatient, err)
+		return nil, err
+	}
+
+	if err := deleteProfileItems(ctx, tx, profile.IDPatient); err != nil {
+		return nil, err
+	}
+	if err := insertProfileItems(ctx, tx, profile); err != nil {
+		return nil, err
+	}
+	if err := recordProfileChange(ctx, tx, profile, utils.ProfileActionUpdated, sections, profile.Version, profile.UpdatedBy); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("[PATIENT] Error committing clinical profile update of patient %d: %v", profile.IDPatient, err)
+		return nil, err
+	}
+
+	log.Printf("[PATIENT] Successfully updated the clinical profile of patient %d to version %d", profile.IDPatient, profile.Version)
+	return sections, nil
+}
+
+// DeleteClinicalProfile deletes the clinical profile of a patient. The history keeps the deleted profile.
+func (db *MySQLDatabase) DeleteClinicalProfile(ctx context.Context, patientID int, deletedBy *int) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Printf("[PATIENT] Error starting transaction to delete clinical profile: %v", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	current, err := fetchClinicalProfile(ctx, tx, patientID, true)
+	if err != nil {
+		return err
+	}
+
+	if err := recordProfileChange(ctx, tx, current, utils.ProfileActionDeleted, utils.ClinicalProfileSections(current), current.Version+1, deletedBy); err != nil {
+		return err
+	}
+
+	// The items are deleted with the profile
+	deleteQuery := fmt.Sprintf("DELETE FROM %s WHERE %s = ?", utils.ClinicalProfileTableName, utils.ColumnIDPatient)
+	if _, err := tx.ExecContext(ctx, deleteQuery, patientID); err != nil {
+		log.Printf("[PATIENT] Error deleting clinical profile of patient %d: %v", patientID, err)
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("[PATIENT] Error committing clinical profile deletion of patient %d: %v", patientID, err)
+		return err
+	}
+
+	log.Printf("[PATIENT] Successfully deleted the clinical profile of patient %d", patientID)
+	return nil
+}
+
+// FetchClinicalProfileHistory returns the changes of the clinical profile of a patient, the latest first
+func (db *MySQLDatabase) FetchClinicalProfileHistory(ctx context.Context, patientID, page, limit int) ([]models.ClinicalProfileChange, error) {
+	query := fmt.Sprintf("SELECT %s, %s, %s, %s, %s, %s, %s, %s FROM %s WHERE %s = ? ORDER BY %s DESC, %s DESC LIMIT ? OFFSET ?",
+		utils.ColumnIDChange,
+		utils.ColumnIDPatient,
+		utils.ColumnVersion,
+		utils.ColumnAction,
+		utils.ColumnSections,
+		utils.ColumnSnapshot,
+		utils.ColumnChangedBy,
+		utils.ColumnChangedAt,
+		utils.ClinicalProfileHistoryTableName,
+		utils.ColumnIDPatient,
+		utils.ColumnVersion,
+		utils.ColumnIDChange,
+	)
+
+	rows, err := db.QueryContext(ctx, query, patientID, limit, (page-1)*limit)
+	if err != nil {
+		log.Printf("[PATIENT] FetchClinicalProfileHistory: Failed to query database: %v", err)
+		return nil, fmt.Errorf("failed to fetch clinical profile history: %v", err)
+	}
+	defer rows.Close()
+
+	changes := []models.ClinicalProfileChange{}
+	for rows.Next() {
+		var change models.ClinicalProfileChange
+		var sections string
+		var snapshot []byte
+		var changedBy sql.NullInt64
+		if err := rows.Scan(&change.IDChange, &change.IDPatient, &change.Version, &change.Action, &sections, &snapshot, &changedBy, &change.ChangedAt); err != nil {
+			log.Printf("[PATIENT] Error scanning clinical profile history row: %v", err)
+			return nil, err
+		}
+
+		change.Sections = []string{}
+		if sections != "" {
+			change.Sections = strings.Split(sections, ",")
+		}
+		if err := json.Unmarshal(snapshot, &change.Profile); err != nil {
+			log.Printf("[PATIENT] Error decoding clinical profile snapshot %d: %v", change.IDChange, err)
+			return nil, err
+		}
+		if changedBy.Valid {
+			id := int(changedBy.Int64)
+			change.ChangedBy = &id
+		}
+		changes = append(changes, change)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("[PATIENT] Error after iterating over clinical profile history rows: %v", err)
+		return nil, err
+	}
+
+	log.Printf("[PATIENT] Successfully fetched %d clinical profile changes of patient %d.", len(changes), patientID)
+	return changes, nil
+}
+
+func fetchClinicalProfile(ctx context.Context, q querier, patientID int, forUpdate bool) (*models.ClinicalProfile, error) {
+	query := fmt.Sprintf("SELECT %s, %s, %s, %s FROM %s WHERE %s = ?",
+		utils.ColumnBloodType,
+		utils.ColumnVersion,
+		utils.ColumnUpdatedBy,
+		utils.ColumnUpdatedAt,
+		utils.ClinicalProfileTableName,
+		utils.ColumnIDPatient,
+	)
+	if forUpdate {
+		query += " FOR UPDATE"
+	}
+
+	profile := &models.ClinicalProfile{IDPatient: patientID}
+	var bloodType sql.NullString
+	var updatedBy sql.NullInt64
+	if err := q.QueryRowContext(ctx, query, patientID).Scan(&bloodType, &profile.Version, &updatedBy, &profile.UpdatedAt); err != nil {
+		if err != sql.ErrNoRows {
+			log.Printf("[PATIENT] Error fetching clinical profile of patient %d: %v", patientID, err)
+		}
+		return nil, err
+	}
+	profile.BloodType = models.BloodType(bloodType.String)
+	if updatedBy.Valid {
+		id := int(updatedBy.Int64)
+		profile.UpdatedBy = &id
+	}
+
+	if err := fetchProfileItems(ctx, q, profile); err != nil {
+		log.Printf("[PATIENT] Error fetching clinical profile items of patient %d: %v", patientID, err)
+		return nil, err
+	}
+	return profile, nil
+}
+
+func fetchProfileItems(ctx context.Context, q querier, profile *models.ClinicalProfile) error {
+	profile.Allergies = []models.Allergy{}
+	allergyQuery := fmt.Sprintf("SELECT %s, %s, %s, %s FROM %s WHERE %s = ? ORDER BY %s",
+		utils.ColumnIDAllergy, utils.ColumnSubstance, utils.ColumnSeverity, utils.ColumnReaction,
+		utils.AllergyTableName, utils.ColumnIDPatient, utils.ColumnIDAllergy)
+	err := queryProfileItems(ctx, q, allergyQuery, profile.IDPatient, func(row rowScanner) error {
+		var allergy models.Allergy
+		var reaction sql.NullString
+		if err := row.Scan(&allergy.IDAllergy, &allergy.Substance, &allergy.Severity, &reaction); err != nil {
+			return err
+		}
+		allergy.Reaction = reaction.String
+		profile.Allergies = append(profile.Allergies, allergy)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	profile.ChronicConditions = []models.ChronicCondition{}
+	conditionQuery := fmt.Sprintf("SELECT %s, %s, %s, %s FROM %s WHERE %s = ? ORDER BY %s",
+		utils.ColumnIDCondition, utils.ColumnName, utils.ColumnDiagnosedOn, utils.ColumnNotes,
+		utils.ChronicConditionTableName, utils.ColumnIDPatient, utils.ColumnIDCondition)
+	err = queryProfileItems(ctx, q, conditionQuery, profile.IDPatient, func(row rowScanner) error {
+		var condition models.ChronicCondition
+		var diagnosedOn sql.NullTime
+		var notes sql.NullString
+		if err := row.Scan(&condition.IDCondition, &condition.Name, &diagnosedOn, &notes); err != nil {
+			return err
+		}
+		if diagnosedOn.Valid {
+			condition.DiagnosedOn = &diagnosedOn.Time
+		}
+		condition.Notes = notes.String
+		profile.ChronicConditions = append(profile.ChronicConditions, condition)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	profile.Medications = []models.Medication{}
+	medicationQuery := fmt.Sprintf("SELECT %s, %s, %s, %s, %s FROM %s WHERE %s = ? ORDER BY %s",
+		utils.ColumnIDMedication, utils.ColumnName, utils.ColumnDosage, utils.ColumnFrequency, utils.ColumnStartedOn,
+		utils.MedicationTableName, utils.ColumnIDPatient, utils.ColumnIDMedication)
+	err = queryProfileItems(ctx, q, medicationQuery, profile.IDPatient, func(row rowScanner) error {
+		var medication models.Medication
+		var startedOn sql.NullTime
+		if err := row.Scan(&medication.IDMedication, &medication.Name, &medication.Dosage, &medication.Frequency, &startedOn); err != nil {
+			return err
+		}
+		if startedOn.Valid {
+			medication.StartedOn = &startedOn.Time
+		}
+		profile.Medications = append(profile.Medications, medication)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	profile.EmergencyContacts = []models.EmergencyContact{}
+	contactQuery := fmt.Sprintf("SELECT %s, %s, %s, %s FROM %s WHERE %s = ? ORDER BY %s",
+		utils.ColumnIDContact, utils.ColumnName, utils.ColumnRelationship, utils.ColumnPhoneNumber,
+		utils.EmergencyContactTableName, utils.ColumnIDPatient, utils.ColumnIDContact)
+	return queryProfileItems(ctx, q, contactQuery, profile.IDPatient, func(row rowScanner) error {
+		var contact models.EmergencyContact
+		if err := row.Scan(&contact.IDContact, &contact.Name, &contact.Relationship, &contact.PhoneNumber); err != nil {
+			return err
+		}
+		profile.EmergencyContacts = append(profile.EmergencyContacts, contact)
+		return nil
+	})
+}
+
+func queryProfileItems(ctx context.Context, q querier, query string, patientID int, scan func(row rowScanner) error) error {
+	rows, err := q.QueryContext(ctx, query, patientID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// insertProfileItems stores the lists of a profile and fills in the IDs of their items
+func insertProfileItems(ctx context.Context, tx *sql.Tx, profile *models.ClinicalProfile) error {
+	allergyQuery := fmt.Sprintf("INSERT INTO %s (%s, %s, %s, %s) VALUES (?, ?, ?, ?)",
+		utils.AllergyTableName, utils.ColumnIDPatient, utils.ColumnSubstance, utils.ColumnSeverity, utils.ColumnReaction)
+	for i := range profile.Allergies {
+		allergy := &profile.Allergies[i]
+		id, err := insertProfileItem(ctx, tx, allergyQuery, profile.IDPatient, allergy.Substance, allergy.Severity, nullableString(allergy.Reaction))
+		if err != nil {
+			log.Printf("[PATIENT] Error inserting allergy %q of patient %d: %v", allergy.Substance, profile.IDPatient, err)
+			return err
+		}
+		allergy.IDAllergy = id
+	}
+
+	conditionQuery := fmt.Sprintf("INSERT INTO %s (%s, %s, %s, %s) VALUES (?, ?, ?, ?)",
+		utils.ChronicConditionTableName, utils.ColumnIDPatient, utils.ColumnName, utils.ColumnDiagnosedOn, utils.ColumnNotes)
+	for i := range profile.ChronicConditions {
+		condition := &profile.ChronicConditions[i]
+		id, err := insertProfileItem(ctx, tx, conditionQuery, profile.IDPatient, condition.Name, condition.DiagnosedOn, nullableString(condition.Notes))
+		if err != nil {
+			log.Printf("[PATIENT] Error inserting chronic condition %q of patient %d: %v", condition.Name, profile.IDPatient, err)
+			return err
+		}
+		condition.IDCondition = id
+	}
+
+	medicationQuery := fmt.Sprintf("INSERT INTO %s (%s, %s, %s, %s, %s) VALUES (?, ?, ?, ?, ?)",
+		utils.MedicationTableName, utils.ColumnIDPatient, utils.ColumnName, utils.ColumnDosage, utils.ColumnFrequency, utils.ColumnStartedOn)
+	for i := range profile.Medications {
+		medication := &profile.Medications[i]
+		id, err := insertProfileItem(ctx, tx, medicationQuery, profile.IDPatient, medication.Name, medication.Dosage, medication.Frequency, medication.StartedOn)
+		if err != nil {
+			log.Printf("[PATIENT] Error inserting medication %q of patient %d: %v", medication.Name, profile.IDPatient, err)
+			return err
+		}
+		medication.IDMedication = id
+	}
+
+	contactQuery := fmt.Sprintf("INSERT INTO %s (%s, %s, %s, %s) VALUES (?, ?, ?, ?)",
+		utils.EmergencyContactTableName, utils.ColumnIDPatient, utils.ColumnName, utils.ColumnRelationship, utils.ColumnPhoneNumber)
+	for i := range profile.EmergencyContacts {
+		contact := &profile.EmergencyContacts[i]
+		id, err := insertProfileItem(ctx, tx, contactQuery, profile.IDPatient, contact.Name, contact.Relationship, contact.PhoneNumber)
+		if err != nil {
+			log.Printf("[PATIENT] Error inserting emergency contact %q of patient %d: %v", contact.Name, profile.IDPatient, err)
+			return err
+		}
+		contact.IDContact = id
+	}
+
+	return nil
+}
+
+func insertProfileItem(ctx context.Context, tx *sql.Tx, query string, args ...interface{}) (int, error) {
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	id, err := result.LastInsertId()
+	return int(id), err
+}
+
+func deleteProfileItems(ctx context.Context, tx *sql.Tx, patientID int) error {
+	for _, table := range []string{utils.AllergyTableName, utils.ChronicConditionTableName, utils.MedicationTableName, utils.EmergencyContactTableName} {
+		query := fmt.Sprintf("DELETE FROM %s WHERE %s = ?", table, utils.ColumnIDPatient)
+		if _, err := tx.ExecContext(ctx, query, patientID); err != nil {
+			log.Printf("[PATIENT] Error deleting %s rows of patient %d: %v", table, patientID, err)
+			return err
+		}
+	}
+	return nil
+}
+
+func recordProfileChange(ctx context.Context, tx *sql.Tx, profile *models.ClinicalProfile, action models.ProfileAction, sections []string, version int, changedBy *int) error {
+	snapshot, err := json.Marshal(profile)
+	if err != nil {
+		log.Printf("[PATIENT] Error encoding clinical profile snapshot of patient %d: %v", profile.IDPatient, err)
+		return err
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s (%s, %s, %s, %s, %s, %s, %s) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		utils.ClinicalProfileHistoryTableName,
+		utils.ColumnIDPatient,
+		utils.ColumnVersion,
+		utils.ColumnAction,
+		utils.ColumnSections,
+		utils.ColumnSnapshot,
+		utils.ColumnChangedBy,
+		utils.ColumnChangedAt,
+	)
+	_, err = tx.ExecContext(ctx, query, profile.IDPatient, version, action, strings.Join(sections, ","), snapshot, changedBy, time.Now().UTC().Truncate(time.Second))
+	if err != nil {
+		log.Printf("[PATIENT] Error recording clinical profile change of patient %d: %v", profile.IDPatient, err)
+		return err
+	}
+	return nil
+}
+
+// nullableBloodType stores an unknown blood type as NULL
+func nullableBloodType(bloodType models.BloodType) sql.NullString {
+	return sql.NullString{String: string(bloodType), Valid: bloodType != ""}
+}
+
+func nullableString(value string) sql.NullString {
+	return sql.NullString{String: value, Valid: value != ""}
+}
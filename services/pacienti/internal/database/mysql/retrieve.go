@@ -2,6 +2,7 @@ package mysql
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"log"
 	"strings"
@@ -15,7 +16,7 @@ func (db *MySQLDatabase) FetchPatients(ctx context.Context, filters map[string]i
 	// Get the offset based on page and limit
 	offset := (page - 1) * limit
 
-	qb := squirrel.Select("*").From(utils.PatientTableName)
+	qb := squirrel.Select(patientColumns()...).From(utils.PatientTableName)
 
 	// Check if filters is not empty and add WHERE clause if needed
 	if len(filters) > 0 {
@@ -52,13 +53,12 @@ func (db *MySQLDatabase) FetchPatients(ctx context.Context, filters map[string]i
 
 	var patients []models.Patient
 	for rows.Next() {
-		var patient models.Patient
-		err := rows.Scan(&patient.IDPatient, &patient.IDUser, &patient.FirstName, &patient.SecondName, &patient.Email, &patient.PhoneNumber, &patient.CNP, &patient.BirthDay, &patient.IsActive)
+		patient, err := db.scanPatient(ctx, rows)
 		if err != nil {
 			log.Printf("[PATIENT] Error scanning patient row: %v", err)
 			return nil, err
 		}
-		patients = append(patients, patient)
+		patients = append(patients, *patient)
 	}
 
 	err = rows.Err()
@@ -73,16 +73,8 @@ func (db *MySQLDatabase) FetchPatients(ctx context.Context, filters map[string]i
 
 func (db *MySQLDatabase) FetchPatientByID(ctx context.Context, patientID int) (*models.Patient, error) {
 	// Construct the SQL insert query
-	query := fmt.Sprintf("SELECT %s, %s, %s, %s, %s, %s, %s, %s, %s FROM %s WHERE %s = ?",
-		utils.ColumnIDPatient,
-		utils.ColumnIDUser,
-		utils.ColumnFirstName,
-		utils.ColumnSecondName,
-		utils.ColumnEmail,
-		utils.ColumnPhoneNumber,
-		utils.ColumnCNP,
-		utils.ColumnBirthDay,
-		utils.ColumnIsActive,
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s = ?",
+		strings.Join(patientColumns(), ", "),
 		utils.PatientTableName,
 		utils.ColumnIDPatient,
 	)
@@ -92,60 +84,47 @@ func (db *MySQLDatabase) FetchPatientByID(ctx context.Context, patientID int) (*
 	// Execute the SQL query with context
 	row := db.QueryRowContext(ctx, query, patientID)
 
-	var patient models.Patient
-	err := row.Scan(&patient.IDPatient, &patient.IDUser, &patient.FirstName, &patient.SecondName, &patient.Email, &patient.PhoneNumber, &patient.CNP, &patient.BirthDay, &patient.IsActive)
+	patient, err := db.scanPatient(ctx, row)
 	if err != nil {
 		log.Printf("[PATIENT] Error fetching patient by ID %d: %v", patientID, err)
 		return nil, err
 	}
 
 	log.Printf("[PATIENT] Successfully fetched patient by ID %d.", patientID)
-	return &patient, nil
+	return patient, nil
 }
 
 func (db *MySQLDatabase) FetchPatientByEmail(ctx context.Context, email string) (*models.Patient, error) {
-	// Construct the SQL insert query
-	query := fmt.Sprintf("SELECT %s, %s, %s, %s, %s, %s, %s, %s, %s FROM %s WHERE %s = ?",
-		utils.ColumnIDPatient,
-		utils.ColumnIDUser,
-		utils.ColumnFirstName,
-		utils.ColumnSecondName,
-		utils.ColumnEmail,
-		utils.ColumnPhoneNumber,
-		utils.ColumnCNP,
-		utils.ColumnBirthDay,
-		utils.ColumnIsActive,
+	// The email is encrypted, it is looked up by its blind index
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s = ?",
+		strings.Join(patientColumns(), ", "),
 		utils.PatientTableName,
-		utils.ColumnEmail,
+		utils.ColumnEmailIndex,
 	)
+	emailIndex, err := db.blindIndex(ctx, utils.ColumnEmail, email)
+	if err != nil {
+		log.Printf("[PATIENT] Error indexing email %s: %v", email, err)
+		return nil, err
+	}
 	// Execute the SQL query with context
-	row := db.QueryRowContext(ctx, query, email)
+	row := db.QueryRowContext(ctx, query, emailIndex)
 
 	log.Printf("[PATIENT] Attempting to fetch patient by email %s", email)
 
-	var patient models.Patient
-	err := row.Scan(&patient.IDPatient, &patient.IDUser, &patient.FirstName, &patient.SecondName, &patient.Email, &patient.PhoneNumber, &patient.CNP, &patient.BirthDay, &patient.IsActive)
+	patient, err := db.scanPatient(ctx, row)
 	if err != nil {
 		log.Printf("[PATIENT] Error fetching patient by email %s: %v", email, err)
 		return nil, err
 	}
 
 	log.Printf("[PATIENT] Successfully fetched patient by email %s.", email)
-	return &patient, nil
+	return patient, nil
 }
 
 func (db *MySQLDatabase) FetchPatientByUserID(ctx context.Context, userID int) (*models.Patient, error) {
 	// Construct the SQL insert query
-	query := fmt.Sprintf("SELECT %s, %s, %s, %s, %s, %s, %s, %s, %s FROM %s WHERE %s = ?",
-		utils.ColumnIDPatient,
-		utils.ColumnIDUser,
-		utils.ColumnFirstName,
-		utils.ColumnSecondName,
-		utils.ColumnEmail,
-		utils.ColumnPhoneNumber,
-		utils.ColumnCNP,
-		utils.ColumnBirthDay,
-		utils.ColumnIsActive,
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s = ?",
+		strings.Join(patientColumns(), ", "),
 		utils.PatientTableName,
 		utils.ColumnIDUser,
 	)
@@ -155,13 +134,47 @@ func (db *MySQLDatabase) FetchPatientByUserID(ctx context.Context, userID int) (
 	// Execute the SQL query with context
 	row := db.QueryRowContext(ctx, query, userID)
 
-	var patient models.Patient
-	err := row.Scan(&patient.IDPatient, &patient.IDUser, &patient.FirstName, &patient.SecondName, &patient.Email, &patient.PhoneNumber, &patient.CNP, &patient.BirthDay, &patient.IsActive)
+	patient, err := db.scanPatient(ctx, row)
 	if err != nil {
 		log.Printf("[PATIENT] Error fetching patient by user ID %d: %v", userID, err)
 		return nil, err
 	}
 
 	log.Printf("[PATIENT] Successfully fetched patient by user ID %d.", userID)
+	return patient, nil
+}
+
+// patientColumns lists the columns read into a models.Patient, in the order expected by scanPatient
+func patientColumns() []string {
+	return []string{
+		utils.ColumnIDPatient,
+		utils.ColumnIDUser,
+		utils.ColumnFirstName,
+		utils.ColumnSecondName,
+		utils.ColumnEmail,
+		utils.ColumnPhoneNumber,
+		utils.ColumnCNP,
+		utils.ColumnBirthDay,
+		utils.ColumnIsActive,
+		utils.ColumnSex,
+	}
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanPatient reads a row selected with patientColumns and decrypts its identifiers. The sex of foreign citizens may be NULL.
+func (db *MySQLDatabase) scanPatient(ctx context.Context, row rowScanner) (*models.Patient, error) {
+	var patient models.Patient
+	var sex sql.NullString
+	err := row.Scan(&patient.IDPatient, &patient.IDUser, &patient.FirstName, &patient.SecondName, &patient.Email, &patient.PhoneNumber, &patient.CNP, &patient.BirthDay, &patient.IsActive, &sex)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.openIdentifiers(ctx, &patient); err != nil {
+		return nil, err
+	}
+	patient.Sex = models.Sex(sex.String)
 	return &patient, nil
 }
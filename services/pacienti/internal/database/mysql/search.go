@@ -0,0 +1,126 @@
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/mihnea1711/POS_Project/services/pacienti/internal/models"
+	"github.com/mihnea1711/POS_Project/services/pacienti/pkg/utils"
+)
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// FetchPatientSearchCandidates returns the patients that pass the filters of a search and may match its terms.
+// The terms are ranked by the caller, the query only requires every term to share its first letter with a name,
+// or to be one of the identifiers. The accent insensitive collation makes "s%" match "Ștefan" as well.
+// The identifiers are encrypted, they are only matched whole through their blind indexes. At most maxCandidates + 1
+// patients are read, so the caller can tell a search that matches too many.
+func (db *MySQLDatabase) FetchPatientSearchCandidates(ctx context.Context, params *models.PatientSearchParams, maxCandidates int) ([]models.Patient, error) {
+	qb := squirrel.Select(patientColumns()...).From(utils.PatientTableName).OrderBy(utils.ColumnIDPatient)
+
+	for _, term := range utils.SearchTerms(params.Name) {
+		qb = qb.Where(nameInitialCondition(term))
+	}
+	for _, term := range utils.SearchTerms(params.Text) {
+		condition := squirrel.Or{nameInitialCondition(term)}
+		for _, column := range []string{utils.ColumnCNP, utils.ColumnEmail, utils.ColumnPhoneNumber} {
+			identifier, err := db.indexCondition(ctx, column, term)
+			if err != nil {
+				return nil, err
+			}
+			condition = append(condition, identifier)
+		}
+		qb = qb.Where(condition)
+	}
+
+	identifiers := []struct {
+		column string
+		value  string
+	}{
+		{utils.ColumnCNP, params.CNP},
+		{utils.ColumnPhoneNumber, params.Phone},
+		{utils.ColumnEmail, params.Email},
+	}
+	for _, identifier := range identifiers {
+		if identifier.value == "" {
+			continue
+		}
+		condition, err := db.indexCondition(ctx, identifier.column, identifier.value)
+		if err != nil {
+			return nil, err
+		}
+		qb = qb.Where(condition)
+	}
+	if params.BirthFrom != nil {
+		qb = qb.Where(squirrel.GtOrEq{utils.ColumnBirthDay: *params.BirthFrom})
+	}
+	if params.BirthTo != nil {
+		qb = qb.Where(squirrel.LtOrEq{utils.ColumnBirthDay: *params.BirthTo})
+	}
+	if params.IsActive != nil {
+		qb = qb.Where(squirrel.Eq{utils.ColumnIsActive: *params.IsActive})
+	}
+
+	qb = qb.Limit(uint64(maxCandidates + 1))
+
+	query, args, err := qb.ToSql()
+	if err != nil {
+		log.Printf("[PATIENT] FetchPatientSearchCandidates: Failed to construct SQL query: %v", err)
+		return nil, fmt.Errorf("internal server error")
+	}
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		log.Printf("[PATIENT] FetchPatientSearchCandidates: Failed to query database: %v", err)
+		return nil, fmt.Errorf("failed to search patients: %v", err)
+	}
+	defer rows.Close()
+
+	var patients []models.Patient
+	for rows.Next() {
+		patient, err := db.scanPatient(ctx, rows)
+		if err != nil {
+			log.Printf("[PATIENT] Error scanning patient row: %v", err)
+			return nil, err
+		}
+		patients = append(patients, *patient)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		log.Printf("[PATIENT] Error after iterating over rows: %v", err)
+		return nil, err
+	}
+
+	log.Printf("[PATIENT] Found %d patient search candidates.", len(patients))
+	return patients, nil
+}
+
+// nameInitialCondition matches the patients having a name that starts with the first letter of the term.
+// Names made of several words or joined by hyphens are matched on every word.
+func nameInitialCondition(term string) squirrel.Or {
+	initial := likeEscaper.Replace(string([]rune(term)[0]))
+
+	condition := squirrel.Or{}
+	for _, column := range []string{utils.ColumnFirstName, utils.ColumnSecondName} {
+		condition = append(condition,
+			squirrel.Like{column: initial + "%"},
+			squirrel.Like{column: "% " + initial + "%"},
+			squirrel.Like{column: "%-" + initial + "%"},
+		)
+	}
+	return condition
+}
+
+// indexCondition matches the patients having the identifier stored in the column, through its blind index
+func (db *MySQLDatabase) indexCondition(ctx context.Context, column, value string) (squirrel.Sqlizer, error) {
+	index, err := db.blindIndex(ctx, column, value)
+	if err != nil {
+		log.Printf("[PATIENT] Error indexing the %s search term: %v", column, err)
+		return nil, fmt.Errorf("internal server error")
+	}
+	return squirrel.Eq{blindIndexColumns[column]: index.String}, nil
+}
@@ -11,22 +11,32 @@ import (
 
 func (db *MySQLDatabase) UpdatePatientByID(ctx context.Context, patient *models.Patient) (int, error) {
 	// Construct the SQL update query
-	query := fmt.Sprintf("UPDATE %s SET %s=?, %s=?, %s=?, %s=?, %s=?, %s=?, %s=? WHERE %s=?",
+	query := fmt.Sprintf("UPDATE %s SET %s=?, %s=?, %s=?, %s=?, %s=?, %s=?, %s=?, %s=?, %s=?, %s=?, %s=? WHERE %s=?",
 		utils.PatientTableName,
 		utils.ColumnFirstName,
 		utils.ColumnSecondName,
 		utils.ColumnEmail,
 		utils.ColumnPhoneNumber,
 		utils.ColumnCNP,
+		utils.ColumnEmailIndex,
+		utils.ColumnPhoneNumberIndex,
+		utils.ColumnCNPIndex,
 		utils.ColumnBirthDay,
 		utils.ColumnIsActive,
+		utils.ColumnSex,
 		utils.ColumnIDPatient,
 	)
 
 	log.Printf("[PATIENT] Attempting to update patient with ID %d", patient.IDPatient)
 
+	sealed, err := db.sealIdentifiers(ctx, patient.Email, patient.PhoneNumber, patient.CNP)
+	if err != nil {
+		return 0, err
+	}
+
 	// Execute the SQL statement
-	result, err := db.ExecContext(ctx, query, patient.FirstName, patient.SecondName, patient.Email, patient.PhoneNumber, patient.CNP, patient.BirthDay, patient.IsActive, patient.IDPatient)
+	result, err := db.ExecContext(ctx, query, patient.FirstName, patient.SecondName,
+		sealed.email, sealed.phoneNumber, sealed.cnp, sealed.emailIndex, sealed.phoneNumberIndex, sealed.cnpIndex, patient.BirthDay, patient.IsActive, nullableSex(patient.Sex), patient.IDPatient)
 	if err != nil {
 		log.Printf("[PATIENT] Error executing query to update patient with ID %d: %v", patient.IDPatient, err)
 		return 0, err
@@ -0,0 +1,122 @@
+package duplicates
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/mihnea1711/POS_Project/services/pacienti/internal/database"
+	"github.com/mihnea1711/POS_Project/services/pacienti/internal/models"
+	"github.com/mihnea1711/POS_Project/services/pacienti/pkg/config"
+	"github.com/mihnea1711/POS_Project/services/pacienti/pkg/utils"
+)
+
+// Detector looks for patient records that likely belong to the same person and stores them as merge candidates.
+// It scans periodically once started, admins can also ask for a scan.
+type Detector struct {
+	dbConn    database.Database
+	interval  time.Duration
+	threshold float64
+	mu        sync.Mutex // a periodic scan and a requested one do not run together
+}
+
+func NewDetector(dbConn database.Database, duplicatesConfig config.DuplicatesConfig) *Detector {
+	interval := time.Duration(duplicatesConfig.ScanIntervalMinutes) * time.Minute
+	if interval <= 0 {
+		interval = utils.DEFAULT_DUPLICATE_SCAN_INTERVAL * time.Minute
+	}
+
+	threshold := duplicatesConfig.Threshold
+	if threshold <= 0 || threshold > 1 {
+		threshold = utils.DEFAULT_DUPLICATE_THRESHOLD
+	}
+
+	return &Detector{
+		dbConn:    dbConn,
+		interval:  interval,
+		threshold: threshold,
+	}
+}
+
+// Start scans periodically until the context is canceled
+func (d *Detector) Start(ctx context.Context) {
+	log.Printf("[PATIENT] Duplicate detector started. Scanning every %s.", d.interval)
+
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		scanCtx, cancel := context.WithTimeout(ctx, utils.DUPLICATE_SCAN_TIMEOUT*time.Second)
+		if _, err := d.Scan(scanCtx); err != nil {
+			log.Printf("[PATIENT] Duplicate scan failed: %v", err)
+		}
+		cancel()
+
+		select {
+		case <-ctx.Done():
+			log.Println("[PATIENT] Duplicate detector stopped.")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Scan compares the active patients sharing a birth day, a phone number or a name and replaces the open candidates
+// with the pairs scoring at least the threshold
+func (d *Detector) Scan(ctx context.Context) (*models.DuplicateScanResult, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	patients, err := d.dbConn.FetchPatientsForDuplicateScan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	blocks := make(map[string][]int)
+	for i, patient := range patients {
+		for _, key := range utils.DuplicateBlockingKeys(patient) {
+			blocks[key] = append(blocks[key], i)
+		}
+	}
+
+	result := &models.DuplicateScanResult{PatientsScanned: len(patients)}
+	detectedAt := time.Now().UTC().Truncate(time.Second)
+	compared := make(map[[2]int]struct{})
+	candidates := []models.DuplicateCandidate{}
+	for _, block := range blocks {
+		for i := 0; i < len(block); i++ {
+			for j := i + 1; j < len(block); j++ {
+				// Patients are ordered by ID, so a stays the lower ID of the pair
+				a, b := patients[block[i]], patients[block[j]]
+				pair := [2]int{a.IDPatient, b.IDPatient}
+				if _, ok := compared[pair]; ok {
+					continue
+				}
+				compared[pair] = struct{}{}
+
+				score, reasons := utils.ScoreDuplicatePair(a, b)
+				if score < d.threshold {
+					continue
+				}
+				candidates = append(candidates, models.DuplicateCandidate{
+					IDPatientA: a.IDPatient,
+					IDPatientB: b.IDPatient,
+					Score:      score,
+					Reasons:    reasons,
+					Status:     utils.CandidateStatusOpen,
+					DetectedAt: detectedAt,
+				})
+			}
+		}
+	}
+	result.PairsCompared = len(compared)
+	result.Candidates = len(candidates)
+
+	if err := d.dbConn.ReplaceDuplicateCandidates(ctx, candidates); err != nil {
+		return nil, err
+	}
+
+	log.Printf("[PATIENT] Duplicate scan compared %d pairs of %d patients and found %d candidates", result.PairsCompared, result.PatientsScanned, result.Candidates)
+	return result, nil
+}
@@ -0,0 +1,86 @@
+package duplicates
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/mihnea1711/POS_Project/services/pacienti/internal/clients"
+	"github.com/mihnea1711/POS_Project/services/pacienti/internal/database"
+	"github.com/mihnea1711/POS_Project/services/pacienti/internal/models"
+	"github.com/mihnea1711/POS_Project/services/pacienti/pkg/utils"
+)
+
+// Merger moves the records of a duplicate patient to the surviving patient. The appointments live in MySQL and the
+// consultations in Mongo, so no single transaction spans the merge: every step is idempotent and its progress is saved
+// before the next one runs. A failed merge keeps the step it stopped at and resumes from it when requested again.
+type Merger struct {
+	dbConn        database.Database
+	appointments  *clients.AppointmentClient
+	consultations *clients.ConsultationClient
+}
+
+func NewMerger(dbConn database.Database, appointments *clients.AppointmentClient, consultations *clients.ConsultationClient) *Merger {
+	return &Merger{
+		dbConn:        dbConn,
+		appointments:  appointments,
+		consultations: consultations,
+	}
+}
+
+// Run executes the remaining steps of a started merge. On failure the merge is saved as failed and the error returned.
+func (m *Merger) Run(ctx context.Context, merge *models.PatientMerge) error {
+	for merge.Status != utils.MergeStatusCompleted {
+		step := merge.Step
+		stepCtx, cancel := context.WithTimeout(ctx, utils.MERGE_STEP_TIMEOUT*time.Second)
+		err := m.runStep(stepCtx, merge)
+		cancel()
+
+		if err != nil {
+			// The saved step is the one that failed, also when only saving the progress failed
+			log.Printf("[PATIENT] Merge %d failed at step %s: %v", merge.IDMerge, step, err)
+			merge.Step = step
+			merge.Error = fmt.Sprintf("step %s: %v", step, err)
+
+			// The failure is saved even when the request context is gone, so the merge can be resumed
+			saveCtx, cancel := context.WithTimeout(context.Background(), utils.MERGE_STEP_TIMEOUT*time.Second)
+			defer cancel()
+			if saveErr := m.dbConn.FailPatientMerge(saveCtx, merge); saveErr != nil {
+				log.Printf("[PATIENT] Failed to record the failure of merge %d: %v", merge.IDMerge, saveErr)
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *Merger) runStep(ctx context.Context, merge *models.PatientMerge) error {
+	switch merge.Step {
+	case utils.MergeStepAppointments:
+		result, err := m.appointments.ReassignPatient(ctx, merge.IDDuplicate, merge.IDSurvivor)
+		if err != nil {
+			return err
+		}
+		merge.AppointmentsMoved += result.Moved
+		merge.DroppedAppointments = append(merge.DroppedAppointments, result.Dropped...)
+		merge.Step = utils.MergeStepConsultations
+		return m.dbConn.AdvancePatientMerge(ctx, merge)
+
+	case utils.MergeStepConsultations:
+		moved, err := m.consultations.ReassignPatient(ctx, merge.IDDuplicate, merge.IDSurvivor)
+		if err != nil {
+			return err
+		}
+		merge.ConsultationsMoved += moved
+		merge.Step = utils.MergeStepFinalize
+		return m.dbConn.AdvancePatientMerge(ctx, merge)
+
+	case utils.MergeStepFinalize:
+		return m.dbConn.CompletePatientMerge(ctx, merge)
+
+	default:
+		return fmt.Errorf("unknown merge step %q", merge.Step)
+	}
+}
@@ -0,0 +1,293 @@
+package encryption
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testKey is a valid base64 encoded 32 bytes key, different for every seed
+func testKey(seed byte) string {
+	key := make([]byte, keySize)
+	for i := range key {
+		key[i] = seed + byte(i)
+	}
+	return base64.StdEncoding.EncodeToString(key)
+}
+
+// writeKeyring writes a keyring file with the given keys and a fixed index key. Every write moves the modification
+// time forward, so Reload always sees the change.
+func writeKeyring(t *testing.T, path, active string, keys map[string]byte) {
+	t.Helper()
+
+	var content strings.Builder
+	fmt.Fprintf(&content, "active: %q\nindex: %q\nkeys:\n", active, testKey(200))
+	for id, seed := range keys {
+		fmt.Fprintf(&content, "  %q: %q\n", id, testKey(seed))
+	}
+	if err := os.WriteFile(path, []byte(content.String()), 0600); err != nil {
+		t.Fatalf("writing keyring: %v", err)
+	}
+
+	modTime := time.Now().Add(time.Duration(len(keys)) * time.Minute)
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatalf("touching keyring: %v", err)
+	}
+}
+
+func newTestCipher(t *testing.T) (*FieldCipher, string) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "keyring.yaml")
+	writeKeyring(t, path, "2024-01", map[string]byte{"2024-01": 1})
+	keyring, err := NewFileKeyring(path)
+	if err != nil {
+		t.Fatalf("NewFileKeyring() error = %v", err)
+	}
+	return NewFieldCipher(keyring), path
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	cipher, _ := newTestCipher(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name  string
+		field string
+		value string
+	}{
+		{name: "CNP", field: "cnp", value: "1800101221144"},
+		{name: "email", field: "email", value: "ion.popescu@example.com"},
+		{name: "phone number", field: "phone_number", value: "0712345678"},
+		{name: "diacritics", field: "email", value: "ștefan.țurcanu@example.ro"},
+		{name: "separator in the value", field: "email", value: "a:b:c"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encrypted, err := cipher.Encrypt(ctx, tt.field, tt.value)
+			if err != nil {
+				t.Fatalf("Encrypt() error = %v", err)
+			}
+			if !IsEncrypted(encrypted) || strings.Contains(encrypted, tt.value) {
+				t.Fatalf("Encrypt() = %q, want an envelope without the plaintext", encrypted)
+			}
+			if got := KeyID(encrypted); got != "2024-01" {
+				t.Errorf("KeyID() = %q, want 2024-01", got)
+			}
+
+			decrypted, err := cipher.Decrypt(ctx, tt.field, encrypted)
+			if err != nil {
+				t.Fatalf("Decrypt() error = %v", err)
+			}
+			if decrypted != tt.value {
+				t.Errorf("Decrypt() = %q, want %q", decrypted, tt.value)
+			}
+
+			again, err := cipher.Encrypt(ctx, tt.field, tt.value)
+			if err != nil {
+				t.Fatalf("Encrypt() error = %v", err)
+			}
+			if again == encrypted {
+				t.Errorf("Encrypt() gave the same envelope twice, every value needs its own data key and nonce")
+			}
+		})
+	}
+}
+
+func TestEncryptEmpty(t *testing.T) {
+	cipher, _ := newTestCipher(t)
+
+	encrypted, err := cipher.Encrypt(context.Background(), "email", "")
+	if err != nil || encrypted != "" {
+		t.Errorf("Encrypt(\"\") = %q, %v, want an empty value", encrypted, err)
+	}
+}
+
+func TestDecryptPlaintextPassthrough(t *testing.T) {
+	cipher, _ := newTestCipher(t)
+
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{name: "empty", value: ""},
+		{name: "CNP", value: "1800101221144"},
+		{name: "email", value: "ion.popescu@example.com"},
+		{name: "older envelope version", value: "enc:v0:2024-01:abc:def"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := cipher.Decrypt(context.Background(), "email", tt.value)
+			if err != nil {
+				t.Fatalf("Decrypt() error = %v", err)
+			}
+			if got != tt.value {
+				t.Errorf("Decrypt() = %q, want the value unchanged", got)
+			}
+			if KeyID(tt.value) != "" {
+				t.Errorf("KeyID() = %q, want none for a value that is not encrypted", KeyID(tt.value))
+			}
+		})
+	}
+}
+
+func TestDecryptRejected(t *testing.T) {
+	cipher, _ := newTestCipher(t)
+	ctx := context.Background()
+
+	encrypted, err := cipher.Encrypt(ctx, "cnp", "1800101221144")
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+	parts := strings.Split(strings.TrimPrefix(encrypted, envelopePrefix), ":")
+	sealed, _ := base64.RawStdEncoding.DecodeString(parts[2])
+	sealed[len(sealed)-1] ^= 1
+	tampered := envelopePrefix + strings.Join([]string{parts[0], parts[1], base64.RawStdEncoding.EncodeToString(sealed)}, ":")
+
+	tests := []struct {
+		name  string
+		field string
+		value string
+	}{
+		{name: "copied into another column", field: "email", value: encrypted},
+		{name: "tampered ciphertext", field: "cnp", value: tampered},
+		{name: "unknown key", field: "cnp", value: envelopePrefix + "2023-01:" + parts[1] + ":" + parts[2]},
+		{name: "missing part", field: "cnp", value: envelopePrefix + parts[0] + ":" + parts[1]},
+		{name: "invalid base64", field: "cnp", value: envelopePrefix + parts[0] + ":!!:" + parts[2]},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := cipher.Decrypt(ctx, tt.field, tt.value); err == nil {
+				t.Errorf("Decrypt() = %q, want an error", got)
+			}
+		})
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	cipher, path := newTestCipher(t)
+	ctx := context.Background()
+
+	values := map[string]string{
+		"cnp":          "1800101221144",
+		"email":        "ion.popescu@example.com",
+		"phone_number": "0712345678",
+	}
+	before := make(map[string]string, len(values))
+	for field, value := range values {
+		encrypted, err := cipher.Encrypt(ctx, field, value)
+		if err != nil {
+			t.Fatalf("Encrypt(%s) error = %v", field, err)
+		}
+		before[field] = encrypted
+	}
+
+	writeKeyring(t, path, "2024-06", map[string]byte{"2024-01": 1, "2024-06": 2})
+	if err := cipher.Provider().Reload(ctx); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if active, _ := cipher.Provider().ActiveKeyID(ctx); active != "2024-06" {
+		t.Fatalf("ActiveKeyID() = %q after the rotation, want 2024-06", active)
+	}
+
+	for field, value := range values {
+		t.Run(field, func(t *testing.T) {
+			decrypted, err := cipher.Decrypt(ctx, field, before[field])
+			if err != nil || decrypted != value {
+				t.Fatalf("Decrypt() of a value written before the rotation = %q, %v, want %q", decrypted, err, value)
+			}
+
+			encrypted, err := cipher.Encrypt(ctx, field, value)
+			if err != nil {
+				t.Fatalf("Encrypt() error = %v", err)
+			}
+			if got := KeyID(encrypted); got != "2024-06" {
+				t.Errorf("KeyID() of a new value = %q, want the active key 2024-06", got)
+			}
+
+			rewrapped, err := cipher.Rewrap(ctx, field, before[field])
+			if err != nil {
+				t.Fatalf("Rewrap() error = %v", err)
+			}
+			if got := KeyID(rewrapped); got != "2024-06" {
+				t.Errorf("KeyID() after Rewrap() = %q, want 2024-06", got)
+			}
+			if decrypted, err := cipher.Decrypt(ctx, field, rewrapped); err != nil || decrypted != value {
+				t.Errorf("Decrypt() after Rewrap() = %q, %v, want %q", decrypted, err, value)
+			}
+
+			plaintext, err := cipher.Rewrap(ctx, field, value)
+			if err != nil || KeyID(plaintext) != "2024-06" {
+				t.Errorf("Rewrap() of a plaintext value = %q, %v, want it encrypted with 2024-06", plaintext, err)
+			}
+		})
+	}
+
+	writeKeyring(t, path, "2024-06", map[string]byte{"2024-06": 2})
+	if err := cipher.Provider().Reload(ctx); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if _, err := cipher.Decrypt(ctx, "cnp", before["cnp"]); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("Decrypt() with a retired key error = %v, want %v", err, ErrUnknownKey)
+	}
+}
+
+func TestBlindIndex(t *testing.T) {
+	cipher, _ := newTestCipher(t)
+	ctx := context.Background()
+
+	index := func(field, value string) string {
+		t.Helper()
+		hash, err := cipher.BlindIndex(ctx, field, value)
+		if err != nil {
+			t.Fatalf("BlindIndex() error = %v", err)
+		}
+		return hash
+	}
+
+	cnp := index("cnp", "1800101221144")
+	if cnp == "" || cnp == "1800101221144" {
+		t.Fatalf("BlindIndex() = %q, want a keyed hash", cnp)
+	}
+
+	tests := []struct {
+		name  string
+		field string
+		value string
+		equal bool
+	}{
+		{name: "same column and value", field: "cnp", value: "1800101221144", equal: true},
+		{name: "other value", field: "cnp", value: "2851231401230"},
+		{name: "other column", field: "phone_number", value: "1800101221144"},
+		{name: "column and value boundary", field: "cnp1", value: "800101221144"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := index(tt.field, tt.value); (got == cnp) != tt.equal {
+				t.Errorf("BlindIndex(%s, %s) = %q, equal to the CNP index: %v, want %v", tt.field, tt.value, got, got == cnp, tt.equal)
+			}
+		})
+	}
+
+	if got := index("cnp", ""); got != "" {
+		t.Errorf("BlindIndex() of an empty value = %q, want none", got)
+	}
+
+	other, path := newTestCipher(t)
+	writeKeyring(t, path, "2024-06", map[string]byte{"2024-01": 1, "2024-06": 2})
+	if err := other.Provider().Reload(ctx); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if got, _ := other.BlindIndex(ctx, "cnp", "1800101221144"); got != cnp {
+		t.Errorf("BlindIndex() after a key rotation = %q, want %q, the index key is not rotated", got, cnp)
+	}
+}
@@ -0,0 +1,178 @@
+package encryption
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+var ErrUnknownKey = errors.New("unknown encryption key")
+
+// keyringFile is the layout of the keyring. The keys are base64 encoded and 32 bytes long.
+//
+//	active: "2024-06"
+//	index: "..."
+//	keys:
+//	  "2024-01": "..."
+//	  "2024-06": "..."
+type keyringFile struct {
+	Active string            `yaml:"active"`
+	Index  string            `yaml:"index"`
+	Keys   map[string]string `yaml:"keys"`
+}
+
+// FileKeyring is the default key provider, it reads the keys from a local file. A key is rotated by adding a new
+// key to the file and making it active, the file is read again without restarting the service.
+type FileKeyring struct {
+	path    string
+	mu      sync.RWMutex
+	modTime time.Time
+	active  string
+	index   []byte
+	keks    map[string]cipher.AEAD
+}
+
+func NewFileKeyring(path string) (*FileKeyring, error) {
+	keyring := &FileKeyring{path: path}
+	if err := keyring.Reload(context.Background()); err != nil {
+		return nil, err
+	}
+	return keyring, nil
+}
+
+// Reload reads the keyring again when the file changed. A keyring that cannot be read leaves the current keys in place.
+func (k *FileKeyring) Reload(ctx context.Context) error {
+	info, err := os.Stat(k.path)
+	if err != nil {
+		log.Printf("[PATIENT] Error reading keyring %s: %v", k.path, err)
+		return err
+	}
+
+	k.mu.RLock()
+	unchanged := info.ModTime().Equal(k.modTime)
+	k.mu.RUnlock()
+	if unchanged {
+		return nil
+	}
+
+	data, err := os.ReadFile(k.path)
+	if err != nil {
+		log.Printf("[PATIENT] Error reading keyring %s: %v", k.path, err)
+		return err
+	}
+
+	var file keyringFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("invalid keyring %s: %w", k.path, err)
+	}
+
+	index, err := decodeKey(file.Index)
+	if err != nil {
+		return fmt.Errorf("invalid index key in keyring %s: %w", k.path, err)
+	}
+
+	keks := make(map[string]cipher.AEAD, len(file.Keys))
+	for id, encoded := range file.Keys {
+		key, err := decodeKey(encoded)
+		if err != nil {
+			return fmt.Errorf("invalid key %s in keyring %s: %w", id, k.path, err)
+		}
+		keks[id], err = newAEAD(key)
+		if err != nil {
+			return err
+		}
+	}
+	if _, ok := keks[file.Active]; !ok {
+		return fmt.Errorf("the active key %q is missing from keyring %s", file.Active, k.path)
+	}
+
+	k.mu.Lock()
+	k.modTime = info.ModTime()
+	k.active = file.Active
+	k.index = index
+	k.keks = keks
+	k.mu.Unlock()
+
+	log.Printf("[PATIENT] Keyring loaded with %d keys, active key %s.", len(keks), file.Active)
+	return nil
+}
+
+func (k *FileKeyring) WrapKey(ctx context.Context, dataKey []byte) (string, []byte, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	wrapped, err := seal(k.keks[k.active], dataKey, []byte(k.active))
+	if err != nil {
+		return "", nil, err
+	}
+	return k.active, wrapped, nil
+}
+
+func (k *FileKeyring) UnwrapKey(ctx context.Context, keyID string, wrapped []byte) ([]byte, error) {
+	k.mu.RLock()
+	kek, ok := k.keks[keyID]
+	k.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKey, keyID)
+	}
+
+	return open(kek, wrapped, []byte(keyID))
+}
+
+func (k *FileKeyring) ActiveKeyID(ctx context.Context) (string, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.active, nil
+}
+
+func (k *FileKeyring) IndexKey(ctx context.Context) ([]byte, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.index, nil
+}
+
+func decodeKey(encoded string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+	if len(key) != keySize {
+		return nil, fmt.Errorf("expected a %d bytes key, got %d bytes", keySize, len(key))
+	}
+	return key, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts with a random nonce, which is put in front of the ciphertext
+func seal(aead cipher.AEAD, plaintext, additionalData []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+func open(aead cipher.AEAD, sealed, additionalData []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, additionalData)
+}
@@ -0,0 +1,77 @@
+package encryption
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/mihnea1711/POS_Project/services/pacienti/internal/database"
+	"github.com/mihnea1711/POS_Project/services/pacienti/internal/models"
+	"github.com/mihnea1711/POS_Project/services/pacienti/pkg/config"
+	"github.com/mihnea1711/POS_Project/services/pacienti/pkg/utils"
+)
+
+// Rotator moves the stored identifiers to the active key. A key is rotated by adding a new one to the keyring and making
+// it active, the rotator picks it up on its next sweep. The retired key can be removed once no identifier uses it anymore.
+type Rotator struct {
+	dbConn    database.Database
+	provider  KeyProvider
+	interval  time.Duration
+	batchSize int
+	mu        sync.Mutex // a periodic sweep and a requested one do not run together
+}
+
+func NewRotator(dbConn database.Database, provider KeyProvider, encryptionConfig config.EncryptionConfig) *Rotator {
+	interval := time.Duration(encryptionConfig.RotationIntervalMinutes) * time.Minute
+	if interval <= 0 {
+		interval = utils.DEFAULT_KEY_ROTATION_INTERVAL * time.Minute
+	}
+
+	batchSize := encryptionConfig.RotationBatchSize
+	if batchSize <= 0 {
+		batchSize = utils.DEFAULT_KEY_ROTATION_BATCH_SIZE
+	}
+
+	return &Rotator{
+		dbConn:    dbConn,
+		provider:  provider,
+		interval:  interval,
+		batchSize: batchSize,
+	}
+}
+
+// Start sweeps periodically until the context is canceled. The first sweep also encrypts the patients stored before
+// the encryption was introduced.
+func (r *Rotator) Start(ctx context.Context) {
+	log.Printf("[PATIENT] Key rotator started. Sweeping every %s.", r.interval)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		rotateCtx, cancel := context.WithTimeout(ctx, utils.KEY_ROTATION_TIMEOUT*time.Second)
+		if _, err := r.Rotate(rotateCtx); err != nil {
+			log.Printf("[PATIENT] Key rotation failed: %v", err)
+		}
+		cancel()
+
+		select {
+		case <-ctx.Done():
+			log.Println("[PATIENT] Key rotator stopped.")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Rotate reloads the keyring and wraps again the data keys still wrapped with a retired key
+func (r *Rotator) Rotate(ctx context.Context) (*models.KeyRotationReport, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.provider.Reload(ctx); err != nil {
+		return nil, err
+	}
+	return r.dbConn.RotatePatientKeys(ctx, r.batchSize)
+}
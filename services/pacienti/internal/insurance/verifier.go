@@ -0,0 +1,53 @@
+package insurance
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+
+	"github.com/mihnea1711/POS_Project/services/pacienti/internal/models"
+	"github.com/mihnea1711/POS_Project/services/pacienti/pkg/config"
+	"github.com/mihnea1711/POS_Project/services/pacienti/pkg/utils"
+)
+
+// Verifier confirms an insurance policy with its payer. An error means the payer could not be asked, the policy then
+// stays unverified and can be verified again later.
+type Verifier interface {
+	Verify(ctx context.Context, patient *models.Patient, policy *models.InsurancePolicy) (*models.PolicyVerification, error)
+}
+
+// NewVerifier returns the verifier named in the configuration
+func NewVerifier(conf config.InsuranceConfig) (Verifier, error) {
+	switch conf.Verifier {
+	case "", utils.INSURANCE_VERIFIER_STUB:
+		log.Println("[PATIENT] Insurance policies are verified by the local stub.")
+		return &StubVerifier{}, nil
+	default:
+		return nil, fmt.Errorf("unknown insurance verifier: %s", conf.Verifier)
+	}
+}
+
+var privatePolicyNumberRegex = regexp.MustCompile(`^[A-Z0-9-]{6,32}$`)
+
+// StubVerifier checks policies locally until the CNAS and insurer services are integrated. CNAS insures a patient
+// under their CNP, so a CNAS policy is confirmed when its number is the CNP of the patient. A private policy is
+// confirmed when its number has the shape issued by the insurers.
+type StubVerifier struct{}
+
+func (v *StubVerifier) Verify(ctx context.Context, patient *models.Patient, policy *models.InsurancePolicy) (*models.PolicyVerification, error) {
+	switch policy.PayerType {
+	case utils.PayerTypeCNAS:
+		if policy.PolicyNumber != patient.CNP {
+			return &models.PolicyVerification{Status: utils.VerificationStatusRejected, Note: "CNAS policy number does not match the CNP of the patient"}, nil
+		}
+	case utils.PayerTypePrivate:
+		if !privatePolicyNumberRegex.MatchString(policy.PolicyNumber) {
+			return &models.PolicyVerification{Status: utils.VerificationStatusRejected, Note: "policy number is not one issued by an insurer"}, nil
+		}
+	default:
+		return nil, fmt.Errorf("unknown payer type: %s", policy.PayerType)
+	}
+
+	return &models.PolicyVerification{Status: utils.VerificationStatusVerified}, nil
+}
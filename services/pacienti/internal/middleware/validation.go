@@ -72,22 +72,30 @@ func ValidatePacientInfo(next http.Handler) http.Handler {
 			return
 		}
 
-		// Check if DataNasterii is valid (18 years in the past)
-		minimumBirthDate := time.Now().AddDate(-18, 0, 0)
-		if patient.BirthDay.After(minimumBirthDate) {
-			errMsg := "Invalid birthday (must be at least 18 years ago)"
+		if patient.Sex != "" && !validateSex(patient.Sex) {
+			errMsg := "Invalid Sex"
 			log.Printf("[PATIENT_VALIDATION] %s in request: %s", errMsg, r.RequestURI)
-			utils.RespondWithJSON(w, http.StatusUnprocessableEntity, models.ResponseData{Error: errMsg, Message: "Patient validation failed due to birthdate"})
+			utils.RespondWithJSON(w, http.StatusUnprocessableEntity, models.ResponseData{Error: errMsg, Message: "Patient validation failed due to sex"})
 			return
 		}
 
-		// In your ValidatePacientInfo middleware
-		if ok, errMsg := validateCNPBirthdate(patient.CNP, patient.BirthDay); !ok {
+		// The CNP fills in the birth day and the sex when they are missing, otherwise they have to agree with it
+		if err := utils.CheckPatientCNP(&patient); err != nil {
+			errMsg := err.Error()
 			log.Printf("[PATIENT_VALIDATION] %s in request: %s", errMsg, r.RequestURI)
 			utils.RespondWithJSON(w, http.StatusUnprocessableEntity, models.ResponseData{Error: errMsg, Message: "Patient validation failed due to cnp"})
 			return
 		}
 
+		// Check if DataNasterii is valid (18 years in the past)
+		minimumBirthDate := time.Now().AddDate(-18, 0, 0)
+		if patient.BirthDay.After(minimumBirthDate) {
+			errMsg := "Invalid birthday (must be at least 18 years ago)"
+			log.Printf("[PATIENT_VALIDATION] %s in request: %s", errMsg, r.RequestURI)
+			utils.RespondWithJSON(w, http.StatusUnprocessableEntity, models.ResponseData{Error: errMsg, Message: "Patient validation failed due to birthdate"})
+			return
+		}
+
 		log.Printf("[PATIENT_VALIDATION] Patient info validated successfully in request: %s", r.RequestURI)
 
 		// If all validations pass, proceed to the actual controller
@@ -168,31 +176,13 @@ func isContentTypeJSON(r *http.Request) bool {
 	return contentType == "application/json"
 }
 
-// Function to validate the CNP birthdate against DataNasterii
-func validateCNPBirthdate(cnp string, dataNasterii time.Time) (bool, string) {
-	if len(cnp) != utils.MaxCNPLength || cnp == "" {
-		errMsg := "Incorrect CNP format"
-		log.Printf("[PATIENT_VALIDATION] %s", errMsg)
-		return false, errMsg
-	}
-
-	// Extract birthdate from CNP
-	cnpBirthdateStr := cnp[1:7] // Extract the 6-digit date of birth from the CNP
-	cnpBirthdate, err := time.Parse(utils.CNP_DATE_FORMAT, cnpBirthdateStr)
-	if err != nil {
-		errMsg := "CNP not matching birthdate"
-		log.Printf("[PATIENT_VALIDATION] %s", errMsg)
-		return false, errMsg
-	}
-
-	// Compare the extracted CNP birthdate with DataNasterii
-	if !cnpBirthdate.Equal(dataNasterii) {
-		errMsg := "CNP birthdate does not match DataNasterii"
-		log.Printf("[PATIENT_VALIDATION] %s", errMsg)
-		return false, errMsg
+func validateSex(sex models.Sex) bool {
+	for _, validSex := range utils.ValidSexes {
+		if sex == validSex {
+			return true
+		}
 	}
-
-	return true, ""
+	return false
 }
 
 // Middleware to validate the email param in the request
@@ -255,3 +245,381 @@ func ValidatePatientActivityInfo(next http.Handler) http.Handler {
 func isBoolean(value interface{}) bool {
 	return reflect.ValueOf(value).Kind() == reflect.Bool
 }
+
+func ValidateMergeRequest(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request models.MergeRequest
+
+		if !isContentTypeJSON(r) {
+			errMsg := "Unsupported media type. Content-Type must be application/json"
+			log.Printf("[PATIENT_VALIDATION] %s in request: %s", errMsg, r.RequestURI)
+			utils.RespondWithJSON(w, http.StatusUnsupportedMediaType, models.ResponseData{Error: errMsg, Message: "Merge validation failed due to unsupported media type"})
+			return
+		}
+
+		dec := json.NewDecoder(r.Body)
+		dec.DisallowUnknownFields()
+
+		err := dec.Decode(&request)
+		decodeFlag, decodeStatus := checkErrorOnDecode(err, w)
+		if decodeFlag || decodeStatus != http.StatusOK {
+			errMsg := "Failed to decode merge request"
+			log.Printf("[PATIENT_VALIDATION] %s in request: %s", errMsg, r.RequestURI)
+			utils.RespondWithJSON(w, decodeStatus, models.ResponseData{Error: errMsg, Message: "Merge validation failed due to decoding."})
+			return
+		}
+
+		if request.IDSurvivor <= 0 || request.IDDuplicate <= 0 {
+			errMsg := "Invalid IDSurvivor or IDDuplicate"
+			log.Printf("[PATIENT_VALIDATION] %s in request: %s", errMsg, r.RequestURI)
+			utils.RespondWithJSON(w, http.StatusUnprocessableEntity, models.ResponseData{Error: errMsg, Message: "Merge validation failed due to patient id"})
+			return
+		}
+
+		if request.IDSurvivor == request.IDDuplicate {
+			errMsg := "A patient cannot be merged into itself"
+			log.Printf("[PATIENT_VALIDATION] %s in request: %s", errMsg, r.RequestURI)
+			utils.RespondWithJSON(w, http.StatusUnprocessableEntity, models.ResponseData{Error: errMsg, Message: "Merge validation failed due to patient id"})
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), utils.DECODED_MERGE_REQUEST, &request)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func ValidateClinicalProfile(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var profile models.ClinicalProfile
+
+		if !isContentTypeJSON(r) {
+			errMsg := "Unsupported media type. Content-Type must be application/json"
+			log.Printf("[PATIENT_VALIDATION] %s in request: %s", errMsg, r.RequestURI)
+			utils.RespondWithJSON(w, http.StatusUnsupportedMediaType, models.ResponseData{Error: errMsg, Message: "Clinical profile validation failed due to unsupported media type"})
+			return
+		}
+
+		dec := json.NewDecoder(r.Body)
+		dec.DisallowUnknownFields()
+
+		err := dec.Decode(&profile)
+		decodeFlag, decodeStatus := checkErrorOnDecode(err, w)
+		if decodeFlag || decodeStatus != http.StatusOK {
+			errMsg := "Failed to decode clinical profile"
+			log.Printf("[PATIENT_VALIDATION] %s in request: %s", errMsg, r.RequestURI)
+			utils.RespondWithJSON(w, decodeStatus, models.ResponseData{Error: errMsg, Message: "Clinical profile validation failed due to decoding."})
+			return
+		}
+
+		if err := checkClinicalProfile(&profile); err != nil {
+			errMsg := err.Error()
+			log.Printf("[PATIENT_VALIDATION] %s in request: %s", errMsg, r.RequestURI)
+			utils.RespondWithJSON(w, http.StatusUnprocessableEntity, models.ResponseData{Error: errMsg, Message: "Clinical profile validation failed"})
+			return
+		}
+
+		log.Printf("[PATIENT_VALIDATION] Clinical profile validated successfully in request: %s", r.RequestURI)
+
+		ctx := context.WithValue(r.Context(), utils.DECODED_CLINICAL_PROFILE, &profile)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// checkClinicalProfile validates a clinical profile and normalizes it: texts are trimmed, dates keep only the day
+// and missing lists become empty ones
+func checkClinicalProfile(profile *models.ClinicalProfile) error {
+	if profile.BloodType != "" && !validateBloodType(profile.BloodType) {
+		return fmt.Errorf("invalid bloodType %q", profile.BloodType)
+	}
+
+	if profile.Allergies == nil {
+		profile.Allergies = []models.Allergy{}
+	}
+	if profile.ChronicConditions == nil {
+		profile.ChronicConditions = []models.ChronicCondition{}
+	}
+	if profile.Medications == nil {
+		profile.Medications = []models.Medication{}
+	}
+	if profile.EmergencyContacts == nil {
+		profile.EmergencyContacts = []models.EmergencyContact{}
+	}
+	if len(profile.Allergies) > utils.MaxProfileItems || len(profile.ChronicConditions) > utils.MaxProfileItems ||
+		len(profile.Medications) > utils.MaxProfileItems || len(profile.EmergencyContacts) > utils.MaxProfileItems {
+		return fmt.Errorf("a clinical profile list holds at most %d items", utils.MaxProfileItems)
+	}
+
+	substances := make(map[string]bool)
+	for i := range profile.Allergies {
+		allergy := &profile.Allergies[i]
+		allergy.Substance = strings.TrimSpace(allergy.Substance)
+		allergy.Reaction = strings.TrimSpace(allergy.Reaction)
+		if allergy.Substance == "" || len(allergy.Substance) > utils.MaxNameLength {
+			return fmt.Errorf("invalid or missing substance of allergy %d", i+1)
+		}
+		if !validateAllergySeverity(allergy.Severity) {
+			return fmt.Errorf("invalid severity %q of allergy %d", allergy.Severity, i+1)
+		}
+		if len(allergy.Reaction) > utils.MaxNameLength {
+			return fmt.Errorf("reaction of allergy %d is too long", i+1)
+		}
+		key := strings.ToLower(allergy.Substance)
+		if substances[key] {
+			return fmt.Errorf("allergy to %q is listed more than once", allergy.Substance)
+		}
+		substances[key] = true
+	}
+
+	for i := range profile.ChronicConditions {
+		condition := &profile.ChronicConditions[i]
+		condition.Name = strings.TrimSpace(condition.Name)
+		condition.Notes = strings.TrimSpace(condition.Notes)
+		if condition.Name == "" || len(condition.Name) > utils.MaxNameLength {
+			return fmt.Errorf("invalid or missing name of chronic condition %d", i+1)
+		}
+		if len(condition.Notes) > utils.MaxNotesLength {
+			return fmt.Errorf("notes of chronic condition %d are too long", i+1)
+		}
+		var err error
+		if condition.DiagnosedOn, err = pastDay(condition.DiagnosedOn); err != nil {
+			return fmt.Errorf("diagnosedOn of chronic condition %d %v", i+1, err)
+		}
+	}
+
+	for i := range profile.Medications {
+		medication := &profile.Medications[i]
+		medication.Name = strings.TrimSpace(medication.Name)
+		medication.Dosage = strings.TrimSpace(medication.Dosage)
+		medication.Frequency = strings.TrimSpace(medication.Frequency)
+		if medication.Name == "" || len(medication.Name) > utils.MaxNameLength {
+			return fmt.Errorf("invalid or missing name of medication %d", i+1)
+		}
+		if medication.Dosage == "" || len(medication.Dosage) > utils.MaxShortTextLength {
+			return fmt.Errorf("invalid or missing dosage of medication %d", i+1)
+		}
+		if medication.Frequency == "" || len(medication.Frequency) > utils.MaxShortTextLength {
+			return fmt.Errorf("invalid or missing frequency of medication %d", i+1)
+		}
+		var err error
+		if medication.StartedOn, err = pastDay(medication.StartedOn); err != nil {
+			return fmt.Errorf("startedOn of medication %d %v", i+1, err)
+		}
+	}
+
+	for i := range profile.EmergencyContacts {
+		contact := &profile.EmergencyContacts[i]
+		contact.Name = strings.TrimSpace(contact.Name)
+		contact.Relationship = strings.TrimSpace(contact.Relationship)
+		contact.PhoneNumber = strings.TrimSpace(contact.PhoneNumber)
+		if contact.Name == "" || len(contact.Name) > utils.MaxNameLength {
+			return fmt.Errorf("invalid or missing name of emergency contact %d", i+1)
+		}
+		if contact.Relationship == "" || len(contact.Relationship) > utils.MaxShortTextLength {
+			return fmt.Errorf("invalid or missing relationship of emergency contact %d", i+1)
+		}
+		if !utils.PhoneRegex.MatchString(contact.PhoneNumber) || len(contact.PhoneNumber) != utils.PhoneNumberLength {
+			return fmt.Errorf("invalid or missing phoneNumber of emergency contact %d", i+1)
+		}
+	}
+
+	return nil
+}
+
+// pastDay keeps only the day of an optional date, which cannot be in the future
+func pastDay(date *time.Time) (*time.Time, error) {
+	if date == nil {
+		return nil, nil
+	}
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	if day.After(time.Now().UTC()) {
+		return nil, errors.New("cannot be in the future")
+	}
+	return &day, nil
+}
+
+func validateBloodType(bloodType models.BloodType) bool {
+	for _, validBloodType := range utils.ValidBloodTypes {
+		if bloodType == validBloodType {
+			return true
+		}
+	}
+	return false
+}
+
+func validateAllergySeverity(severity models.AllergySeverity) bool {
+	for _, validSeverity := range utils.ValidAllergySeverities {
+		if severity == validSeverity {
+			return true
+		}
+	}
+	return false
+}
+
+func ValidateInsurancePolicy(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var policy models.InsurancePolicy
+
+		if !isContentTypeJSON(r) {
+			errMsg := "Unsupported media type. Content-Type must be application/json"
+			log.Printf("[PATIENT_VALIDATION] %s in request: %s", errMsg, r.RequestURI)
+			utils.RespondWithJSON(w, http.StatusUnsupportedMediaType, models.ResponseData{Error: errMsg, Message: "Insurance policy validation failed due to unsupported media type"})
+			return
+		}
+
+		dec := json.NewDecoder(r.Body)
+		dec.DisallowUnknownFields()
+
+		err := dec.Decode(&policy)
+		decodeFlag, decodeStatus := checkErrorOnDecode(err, w)
+		if decodeFlag || decodeStatus != http.StatusOK {
+			errMsg := "Failed to decode insurance policy"
+			log.Printf("[PATIENT_VALIDATION] %s in request: %s", errMsg, r.RequestURI)
+			utils.RespondWithJSON(w, decodeStatus, models.ResponseData{Error: errMsg, Message: "Insurance policy validation failed due to decoding."})
+			return
+		}
+
+		if err := checkInsurancePolicy(&policy); err != nil {
+			errMsg := err.Error()
+			log.Printf("[PATIENT_VALIDATION] %s in request: %s", errMsg, r.RequestURI)
+			utils.RespondWithJSON(w, http.StatusUnprocessableEntity, models.ResponseData{Error: errMsg, Message: "Insurance policy validation failed"})
+			return
+		}
+
+		log.Printf("[PATIENT_VALIDATION] Insurance policy validated successfully in request: %s", r.RequestURI)
+
+		ctx := context.WithValue(r.Context(), utils.DECODED_INSURANCE_POLICY, &policy)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// checkInsurancePolicy validates an insurance policy and normalizes it: texts are trimmed, dates keep only the day
+// and CNAS is named as the payer of CNAS policies. The verification is left to the verifier, whatever the request says.
+func checkInsurancePolicy(policy *models.InsurancePolicy) error {
+	if !validatePayerType(policy.PayerType) {
+		return fmt.Errorf("invalid payerType %q", policy.PayerType)
+	}
+	if !validateCoverageCategory(policy.CoverageCategory) {
+		return fmt.Errorf("invalid coverageCategory %q", policy.CoverageCategory)
+	}
+
+	policy.PayerName = strings.TrimSpace(policy.PayerName)
+	policy.PolicyNumber = strings.ToUpper(strings.TrimSpace(policy.PolicyNumber))
+	if policy.PayerType == utils.PayerTypeCNAS {
+		policy.PayerName = utils.CNAS_PAYER_NAME
+	}
+	if policy.PayerName == "" || len(policy.PayerName) > utils.MaxNameLength {
+		return errors.New("invalid or missing payerName")
+	}
+	if policy.PolicyNumber == "" || len(policy.PolicyNumber) > utils.MaxPolicyNumberLength {
+		return errors.New("invalid or missing policyNumber")
+	}
+
+	if policy.ValidFrom.IsZero() {
+		return errors.New("missing validFrom")
+	}
+	policy.ValidFrom = time.Date(policy.ValidFrom.Year(), policy.ValidFrom.Month(), policy.ValidFrom.Day(), 0, 0, 0, 0, time.UTC)
+	if policy.ValidUntil != nil {
+		validUntil := time.Date(policy.ValidUntil.Year(), policy.ValidUntil.Month(), policy.ValidUntil.Day(), 0, 0, 0, 0, time.UTC)
+		if validUntil.Before(policy.ValidFrom) {
+			return errors.New("validUntil cannot be before validFrom")
+		}
+		policy.ValidUntil = &validUntil
+	}
+
+	policy.IDPolicy = 0
+	policy.IDPatient = 0
+	policy.VerificationStatus = utils.VerificationStatusUnverified
+	policy.VerificationNote = ""
+	policy.VerifiedAt = nil
+	return nil
+}
+
+func validatePayerType(payerType models.PayerType) bool {
+	for _, validPayerType := range utils.ValidPayerTypes {
+		if payerType == validPayerType {
+			return true
+		}
+	}
+	return false
+}
+
+func validateCoverageCategory(category models.CoverageCategory) bool {
+	for _, validCategory := range utils.ValidCoverageCategories {
+		if category == validCategory {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateLegalHold validates the reason of a legal hold. The patient comes from the route, the time from the server.
+func ValidateLegalHold(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var hold models.LegalHold
+
+		if !isContentTypeJSON(r) {
+			errMsg := "Unsupported media type. Content-Type must be application/json"
+			log.Printf("[PATIENT_VALIDATION] %s in request: %s", errMsg, r.RequestURI)
+			utils.RespondWithJSON(w, http.StatusUnsupportedMediaType, models.ResponseData{Error: errMsg, Message: "Legal hold validation failed due to unsupported media type"})
+			return
+		}
+
+		dec := json.NewDecoder(r.Body)
+		dec.DisallowUnknownFields()
+
+		err := dec.Decode(&hold)
+		decodeFlag, decodeStatus := checkErrorOnDecode(err, w)
+		if decodeFlag || decodeStatus != http.StatusOK {
+			errMsg := "Failed to decode legal hold"
+			log.Printf("[PATIENT_VALIDATION] %s in request: %s", errMsg, r.RequestURI)
+			utils.RespondWithJSON(w, decodeStatus, models.ResponseData{Error: errMsg, Message: "Legal hold validation failed due to decoding."})
+			return
+		}
+
+		hold.Reason = strings.TrimSpace(hold.Reason)
+		if hold.Reason == "" || len(hold.Reason) > utils.MaxReasonLength {
+			errMsg := fmt.Sprintf("Invalid reason, it must be between 1 and %d characters long", utils.MaxReasonLength)
+			log.Printf("[PATIENT_VALIDATION] %s in request: %s", errMsg, r.RequestURI)
+			utils.RespondWithJSON(w, http.StatusUnprocessableEntity, models.ResponseData{Error: errMsg, Message: "Legal hold validation failed due to reason"})
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), utils.DECODED_LEGAL_HOLD, &hold)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func ValidateErasureRequest(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request models.ErasureRequest
+
+		if !isContentTypeJSON(r) {
+			errMsg := "Unsupported media type. Content-Type must be application/json"
+			log.Printf("[PATIENT_VALIDATION] %s in request: %s", errMsg, r.RequestURI)
+			utils.RespondWithJSON(w, http.StatusUnsupportedMediaType, models.ResponseData{Error: errMsg, Message: "Erasure validation failed due to unsupported media type"})
+			return
+		}
+
+		dec := json.NewDecoder(r.Body)
+		dec.DisallowUnknownFields()
+
+		err := dec.Decode(&request)
+		decodeFlag, decodeStatus := checkErrorOnDecode(err, w)
+		if decodeFlag || decodeStatus != http.StatusOK {
+			errMsg := "Failed to decode erasure request"
+			log.Printf("[PATIENT_VALIDATION] %s in request: %s", errMsg, r.RequestURI)
+			utils.RespondWithJSON(w, decodeStatus, models.ResponseData{Error: errMsg, Message: "Erasure validation failed due to decoding."})
+			return
+		}
+
+		if request.IDErasure == "" || len(request.IDErasure) > utils.MaxErasureIDLength {
+			errMsg := fmt.Sprintf("Invalid idErasure, it must be between 1 and %d characters long", utils.MaxErasureIDLength)
+			log.Printf("[PATIENT_VALIDATION] %s in request: %s", errMsg, r.RequestURI)
+			utils.RespondWithJSON(w, http.StatusUnprocessableEntity, models.ResponseData{Error: errMsg, Message: "Erasure validation failed due to erasure id"})
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), utils.DECODED_ERASURE_REQUEST, &request)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -4,6 +4,8 @@ import (
 	"time"
 )
 
+type Sex string
+
 type Patient struct {
 	IDPatient   int       `db:"id_patient" json:"idPatient" sql:"type:int primary key"`
 	IDUser      int       `db:"id_user" json:"idUser" sql:"type:int"`
@@ -12,8 +14,9 @@ type Patient struct {
 	Email       string    `db:"email" json:"email" sql:"type:varchar(70) unique"`
 	PhoneNumber string    `db:"phone_number" json:"phoneNumber" sql:"type:char(10) check (phone_number ~ '^[0-9]{10}$')"`
 	CNP         string    `db:"cnp" json:"cnp" sql:"type:char(13) unique"`
-	BirthDay    time.Time `db:"birth_day" json:"birthDay" sql:"type:date"`
+	BirthDay    time.Time `db:"birth_day" json:"birthDay" sql:"type:date"` // derived from the CNP when missing
 	IsActive    bool      `db:"is_active" json:"isActive"`
+	Sex         Sex       `db:"sex" json:"sex,omitempty"` // derived from the CNP, empty for foreign citizens without a declared sex
 }
 
 type ResponseData struct {
@@ -39,3 +42,227 @@ type ActivityData struct {
 	IsActive bool `json:"isActive"`
 	IDUser   int  `json:"idUser"`
 }
+
+// PatientSearchParams holds the criteria of a patient search. Text is matched against the names and
+// the identifiers of a patient, Name only against the names, the other fields are plain filters.
+type PatientSearchParams struct {
+	Text      string
+	Name      string
+	CNP       string
+	Phone     string
+	Email     string
+	BirthFrom *time.Time
+	BirthTo   *time.Time
+	IsActive  *bool
+}
+
+// PatientSearchResult is a patient found by a search together with its relevance between 0 and 1
+type PatientSearchResult struct {
+	Patient
+	Score float64 `json:"score"`
+}
+
+type CandidateStatus string
+
+type MergeStatus string
+
+type MergeStep string
+
+// DuplicateCandidate is a pair of patient records that likely belong to the same person. IDPatientA is the lower ID.
+// Reasons lists the attributes the two records share: name, birth_day, phone and cnp.
+type DuplicateCandidate struct {
+	IDCandidate int             `json:"idCandidate"`
+	IDPatientA  int             `json:"idPatientA"`
+	IDPatientB  int             `json:"idPatientB"`
+	Score       float64         `json:"score"`
+	Reasons     []string        `json:"reasons"`
+	Status      CandidateStatus `json:"status"`
+	DetectedAt  time.Time       `json:"detectedAt"`
+	PatientA    *Patient        `json:"patientA,omitempty"`
+	PatientB    *Patient        `json:"patientB,omitempty"`
+}
+
+// DuplicateScanResult sums up a run of the duplicate detection
+type DuplicateScanResult struct {
+	PatientsScanned int `json:"patientsScanned"`
+	PairsCompared   int `json:"pairsCompared"`
+	Candidates      int `json:"candidates"`
+}
+
+// MergeRequest asks to merge the duplicate patient record into the surviving one
+type MergeRequest struct {
+	IDSurvivor  int `json:"idSurvivor"`
+	IDDuplicate int `json:"idDuplicate"`
+}
+
+// PatientMerge is the history of a merge. Step is the next step to run, a failed merge resumes from it when requested again.
+// DroppedAppointments are the appointments removed because both records were booked with the same doctor on the same day.
+type PatientMerge struct {
+	IDMerge             int         `json:"idMerge"`
+	IDSurvivor          int         `json:"idSurvivor"`
+	IDDuplicate         int         `json:"idDuplicate"`
+	Status              MergeStatus `json:"status"`
+	Step                MergeStep   `json:"step"`
+	AppointmentsMoved   int         `json:"appointmentsMoved"`
+	DroppedAppointments []int       `json:"droppedAppointments"`
+	ConsultationsMoved  int         `json:"consultationsMoved"`
+	Error               string      `json:"error,omitempty"`
+	CreatedAt           time.Time   `json:"createdAt"`
+	UpdatedAt           time.Time   `json:"updatedAt"`
+	CompletedAt         *time.Time  `json:"completedAt,omitempty"`
+}
+
+// PatientReassignment asks another module to move the records of a patient to the surviving patient of a merge
+type PatientReassignment struct {
+	IDFromPatient int `json:"idFromPatient"`
+	IDToPatient   int `json:"idToPatient"`
+}
+
+// AppointmentReassignment is the answer of the appointment module to a PatientReassignment
+type AppointmentReassignment struct {
+	Moved   int   `json:"moved"`
+	Dropped []int `json:"dropped"`
+}
+
+type BloodType string
+
+type AllergySeverity string
+
+type ProfileAction string
+
+// ClinicalProfile is what a doctor needs to know about a patient before a consultation. The lists are replaced as a
+// whole on update, so the IDs of their items change with every version. UpdatedBy is the IDM user of the last change.
+type ClinicalProfile struct {
+	IDPatient         int                `json:"idPatient"`
+	BloodType         BloodType          `json:"bloodType,omitempty"`
+	Allergies         []Allergy          `json:"allergies"`
+	ChronicConditions []ChronicCondition `json:"chronicConditions"`
+	Medications       []Medication       `json:"medications"`
+	EmergencyContacts []EmergencyContact `json:"emergencyContacts"`
+	Version           int                `json:"version"`
+	UpdatedBy         *int               `json:"updatedBy,omitempty"`
+	UpdatedAt         time.Time          `json:"updatedAt"`
+}
+
+type Allergy struct {
+	IDAllergy int             `json:"idAllergy"`
+	Substance string          `json:"substance"`
+	Severity  AllergySeverity `json:"severity"`
+	Reaction  string          `json:"reaction,omitempty"`
+}
+
+type ChronicCondition struct {
+	IDCondition int        `json:"idCondition"`
+	Name        string     `json:"name"`
+	DiagnosedOn *time.Time `json:"diagnosedOn,omitempty"`
+	Notes       string     `json:"notes,omitempty"`
+}
+
+type Medication struct {
+	IDMedication int        `json:"idMedication"`
+	Name         string     `json:"name"`
+	Dosage       string     `json:"dosage"`
+	Frequency    string     `json:"frequency"`
+	StartedOn    *time.Time `json:"startedOn,omitempty"`
+}
+
+type EmergencyContact struct {
+	IDContact    int    `json:"idContact"`
+	Name         string `json:"name"`
+	Relationship string `json:"relationship"`
+	PhoneNumber  string `json:"phoneNumber"`
+}
+
+// ClinicalProfileChange is an entry of the history of a clinical profile. Sections names the parts of the profile that
+// changed and Profile is the profile after the change, or the deleted profile for a deletion.
+type ClinicalProfileChange struct {
+	IDChange  int              `json:"idChange"`
+	IDPatient int              `json:"idPatient"`
+	Version   int              `json:"version"`
+	Action    ProfileAction    `json:"action"`
+	Sections  []string         `json:"sections"`
+	Profile   *ClinicalProfile `json:"profile"`
+	ChangedBy *int             `json:"changedBy,omitempty"`
+	ChangedAt time.Time        `json:"changedAt"`
+}
+
+type PayerType string
+
+type CoverageCategory string
+
+type VerificationStatus string
+
+// InsurancePolicy is an insurance of a patient with CNAS or a private insurer. Without ValidUntil it does not expire.
+// The verification status is set by the insurance verifier whenever the policy is saved.
+type InsurancePolicy struct {
+	IDPolicy           int                `json:"idPolicy"`
+	IDPatient          int                `json:"idPatient"`
+	PayerType          PayerType          `json:"payerType"`
+	PayerName          string             `json:"payerName"`
+	PolicyNumber       string             `json:"policyNumber"`
+	CoverageCategory   CoverageCategory   `json:"coverageCategory"`
+	ValidFrom          time.Time          `json:"validFrom"`
+	ValidUntil         *time.Time         `json:"validUntil,omitempty"`
+	VerificationStatus VerificationStatus `json:"verificationStatus"`
+	VerificationNote   string             `json:"verificationNote,omitempty"`
+	VerifiedAt         *time.Time         `json:"verifiedAt,omitempty"`
+	CreatedAt          time.Time          `json:"createdAt"`
+}
+
+// PolicyVerification is the answer of an insurance verifier about a policy
+type PolicyVerification struct {
+	Status VerificationStatus
+	Note   string
+}
+
+// Coverage tells whether a patient is insured on a date. Policies are the policies in force on that date that were not
+// rejected, Verified is set when at least one of them was confirmed by the verifier.
+type Coverage struct {
+	IDPatient  int               `json:"idPatient"`
+	Date       time.Time         `json:"date"`
+	Covered    bool              `json:"covered"`
+	Verified   bool              `json:"verified"`
+	PayerTypes []PayerType       `json:"payerTypes"`
+	Policies   []InsurancePolicy `json:"policies"`
+}
+
+// LegalHold keeps the personal data of a patient from being erased until it is released
+type LegalHold struct {
+	IDPatient int       `json:"idPatient"`
+	Reason    string    `json:"reason"`
+	PlacedBy  *int      `json:"placedBy,omitempty"`
+	PlacedAt  time.Time `json:"placedAt"`
+}
+
+type ErasureAction string
+
+// ErasureSubject is the patient of an IDM user about to be erased. ErasedAt is set once an erasure went through.
+type ErasureSubject struct {
+	IDPatient int        `json:"idPatient"`
+	IDUser    int        `json:"idUser"`
+	LegalHold *LegalHold `json:"legalHold,omitempty"`
+	ErasedAt  *time.Time `json:"erasedAt,omitempty"`
+}
+
+// ErasureRequest names the erasure, the certificate issued by the rabbit module carries the same ID
+type ErasureRequest struct {
+	IDErasure string `json:"idErasure"`
+}
+
+// ErasureRecord tells what an erasure did with a category of personal data and why
+type ErasureRecord struct {
+	Category string        `json:"category"`
+	Action   ErasureAction `json:"action"`
+	Count    int           `json:"count"`
+	Reason   string        `json:"reason,omitempty"`
+}
+
+// KeyRotationReport tells what a rotation sweep did. KeysInUse counts the encrypted values left under every key,
+// a retired key can be removed from the keyring once it is no longer listed.
+type KeyRotationReport struct {
+	ActiveKeyID string         `json:"activeKeyId"`
+	Scanned     int            `json:"scanned"`
+	Rewrapped   int            `json:"rewrapped"`
+	Skipped     int            `json:"skipped"`
+	KeysInUse   map[string]int `json:"keysInUse"`
+}
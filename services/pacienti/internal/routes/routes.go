@@ -7,14 +7,19 @@ import (
 	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/mihnea1711/POS_Project/services/pacienti/internal/clients"
 	"github.com/mihnea1711/POS_Project/services/pacienti/internal/controllers"
 	"github.com/mihnea1711/POS_Project/services/pacienti/internal/database"
 	"github.com/mihnea1711/POS_Project/services/pacienti/internal/database/redis"
+	"github.com/mihnea1711/POS_Project/services/pacienti/internal/duplicates"
+	"github.com/mihnea1711/POS_Project/services/pacienti/internal/encryption"
+	"github.com/mihnea1711/POS_Project/services/pacienti/internal/insurance"
 	"github.com/mihnea1711/POS_Project/services/pacienti/internal/middleware"
+	"github.com/mihnea1711/POS_Project/services/pacienti/pkg/config"
 	"github.com/mihnea1711/POS_Project/services/pacienti/pkg/utils"
 )
 
-func SetupRoutes(parentCtx context.Context, dbConn database.Database, rdb *redis.RedisClient) *mux.Router {
+func SetupRoutes(parentCtx context.Context, dbConn database.Database, rdb *redis.RedisClient, appConfig *config.AppConfig, detector *duplicates.Detector, verifier insurance.Verifier, rotator *encryption.Rotator) *mux.Router {
 	log.Println("[PATIENT] Setting up rate limiter...")
 	rateLimiter := middleware.NewRedisRateLimiter(rdb.GetClient(), parentCtx, utils.LIMITER_REQUESTS_ALLOWED, utils.LIMITER_MINUTE_MULTIPLIER*time.Minute)
 	log.Println("[PATIENT] Rate limiter set up successfully.")
@@ -32,7 +37,11 @@ func SetupRoutes(parentCtx context.Context, dbConn database.Database, rdb *redis
 	log.Println("[PATIENT] Input sanitizer middleware set up successfully.")
 
 	pacientController := &controllers.PatientController{
-		DbConn: dbConn,
+		DbConn:   dbConn,
+		Detector: detector,
+		Merger:   duplicates.NewMerger(dbConn, clients.NewAppointmentClient(appConfig.Appointments), clients.NewConsultationClient(appConfig.Consultations)),
+		Verifier: verifier,
+		Rotator:  rotator,
 	}
 
 	loadCrudRoutes(router, pacientController)
@@ -55,11 +64,60 @@ func loadCrudRoutes(router *mux.Router, pacientController *controllers.PatientCo
 	router.Handle(utils.CREATE_PATIENT_ENDPOINT, middleware.ValidatePacientInfo(pacientCreationHandler)).Methods("POST")
 	log.Println("[PATIENT] Route POST", utils.CREATE_PATIENT_ENDPOINT, "registered.")
 
+	duplicateScanHandler := http.HandlerFunc(pacientController.ScanDuplicates)
+	router.Handle(utils.SCAN_DUPLICATES_ENDPOINT, duplicateScanHandler).Methods("POST")
+	log.Println("[PATIENT] Route POST", utils.SCAN_DUPLICATES_ENDPOINT, "registered.")
+
+	keyRotationHandler := http.HandlerFunc(pacientController.RotateKeys)
+	router.Handle(utils.KEY_ROTATION_ENDPOINT, keyRotationHandler).Methods("POST")
+	log.Println("[PATIENT] Route POST", utils.KEY_ROTATION_ENDPOINT, "registered.")
+
+	candidateDismissHandler := http.HandlerFunc(pacientController.DismissDuplicateCandidate)
+	router.Handle(utils.DISMISS_DUPLICATE_CANDIDATE_ENDPOINT, candidateDismissHandler).Methods("POST")
+	log.Println("[PATIENT] Route POST", utils.DISMISS_DUPLICATE_CANDIDATE_ENDPOINT, "registered.")
+
+	mergeCreationHandler := http.HandlerFunc(pacientController.CreatePatientMerge)
+	router.Handle(utils.CREATE_PATIENT_MERGE_ENDPOINT, middleware.ValidateMergeRequest(mergeCreationHandler)).Methods("POST")
+	log.Println("[PATIENT] Route POST", utils.CREATE_PATIENT_MERGE_ENDPOINT, "registered.")
+
+	profileCreationHandler := http.HandlerFunc(pacientController.CreateClinicalProfile)
+	router.Handle(utils.CLINICAL_PROFILE_ENDPOINT, middleware.ValidateClinicalProfile(profileCreationHandler)).Methods("POST")
+	log.Println("[PATIENT] Route POST", utils.CLINICAL_PROFILE_ENDPOINT, "registered.")
+
+	policyCreationHandler := http.HandlerFunc(pacientController.CreateInsurancePolicy)
+	router.Handle(utils.INSURANCE_POLICIES_ENDPOINT, middleware.ValidateInsurancePolicy(policyCreationHandler)).Methods("POST")
+	log.Println("[PATIENT] Route POST", utils.INSURANCE_POLICIES_ENDPOINT, "registered.")
+
+	policyVerificationHandler := http.HandlerFunc(pacientController.VerifyInsurancePolicy)
+	router.Handle(utils.VERIFY_INSURANCE_POLICY_ENDPOINT, policyVerificationHandler).Methods("POST")
+	log.Println("[PATIENT] Route POST", utils.VERIFY_INSURANCE_POLICY_ENDPOINT, "registered.")
+
+	erasureHandler := http.HandlerFunc(pacientController.ErasePatient)
+	router.Handle(utils.PATIENT_ERASURE_ENDPOINT, middleware.ValidateErasureRequest(erasureHandler)).Methods("POST")
+	log.Println("[PATIENT] Route POST", utils.PATIENT_ERASURE_ENDPOINT, "registered.")
+
 	// ---------------------------------------------------------- Retrieve --------------------------------------------------------------
 	pacientFetchAllHandler := http.HandlerFunc(pacientController.GetPatients)
 	router.HandleFunc(utils.FETCH_ALL_PATIENTS_ENDPOINT, pacientFetchAllHandler).Methods("GET")
 	log.Println("[PATIENT] Route GET", utils.FETCH_ALL_PATIENTS_ENDPOINT, "registered.")
 
+	// Registered before the fetch by ID route so "search", "duplicates" and "merges" are not parsed as a patient ID
+	pacientSearchHandler := http.HandlerFunc(pacientController.SearchPatients)
+	router.HandleFunc(utils.SEARCH_PATIENTS_ENDPOINT, pacientSearchHandler).Methods("GET")
+	log.Println("[PATIENT] Route GET", utils.SEARCH_PATIENTS_ENDPOINT, "registered.")
+
+	duplicateCandidatesFetchHandler := http.HandlerFunc(pacientController.GetDuplicateCandidates)
+	router.HandleFunc(utils.FETCH_DUPLICATE_CANDIDATES_ENDPOINT, duplicateCandidatesFetchHandler).Methods("GET")
+	log.Println("[PATIENT] Route GET", utils.FETCH_DUPLICATE_CANDIDATES_ENDPOINT, "registered.")
+
+	mergesFetchHandler := http.HandlerFunc(pacientController.GetPatientMerges)
+	router.HandleFunc(utils.FETCH_ALL_PATIENT_MERGES_ENDPOINT, mergesFetchHandler).Methods("GET")
+	log.Println("[PATIENT] Route GET", utils.FETCH_ALL_PATIENT_MERGES_ENDPOINT, "registered.")
+
+	mergeFetchByIDHandler := http.HandlerFunc(pacientController.GetPatientMergeByID)
+	router.HandleFunc(utils.FETCH_PATIENT_MERGE_BY_ID_ENDPOINT, mergeFetchByIDHandler).Methods("GET")
+	log.Println("[PATIENT] Route GET", utils.FETCH_PATIENT_MERGE_BY_ID_ENDPOINT, "registered.")
+
 	pacientFetchByEmailHandler := http.HandlerFunc(pacientController.GetPatientByEmail)
 	router.Handle(utils.FETCH_PATIENT_BY_EMAIL_ENDPOINT, middleware.ValidateEmail(pacientFetchByEmailHandler)).Methods("GET")
 	log.Println("[PATIENT] Route GET", utils.FETCH_PATIENT_BY_EMAIL_ENDPOINT, "registered.")
@@ -72,6 +130,34 @@ func loadCrudRoutes(router *mux.Router, pacientController *controllers.PatientCo
 	router.HandleFunc(utils.FETCH_PATIENT_BY_ID_ENDPOINT, pacientFetchByIDHandler).Methods("GET")
 	log.Println("[PATIENT] Route GET", utils.FETCH_PATIENT_BY_ID_ENDPOINT, "registered.")
 
+	profileFetchHandler := http.HandlerFunc(pacientController.GetClinicalProfile)
+	router.HandleFunc(utils.CLINICAL_PROFILE_ENDPOINT, profileFetchHandler).Methods("GET")
+	log.Println("[PATIENT] Route GET", utils.CLINICAL_PROFILE_ENDPOINT, "registered.")
+
+	profileHistoryFetchHandler := http.HandlerFunc(pacientController.GetClinicalProfileHistory)
+	router.HandleFunc(utils.CLINICAL_PROFILE_HISTORY_ENDPOINT, profileHistoryFetchHandler).Methods("GET")
+	log.Println("[PATIENT] Route GET", utils.CLINICAL_PROFILE_HISTORY_ENDPOINT, "registered.")
+
+	policiesFetchHandler := http.HandlerFunc(pacientController.GetInsurancePolicies)
+	router.HandleFunc(utils.INSURANCE_POLICIES_ENDPOINT, policiesFetchHandler).Methods("GET")
+	log.Println("[PATIENT] Route GET", utils.INSURANCE_POLICIES_ENDPOINT, "registered.")
+
+	policyFetchByIDHandler := http.HandlerFunc(pacientController.GetInsurancePolicyByID)
+	router.HandleFunc(utils.INSURANCE_POLICY_BY_ID_ENDPOINT, policyFetchByIDHandler).Methods("GET")
+	log.Println("[PATIENT] Route GET", utils.INSURANCE_POLICY_BY_ID_ENDPOINT, "registered.")
+
+	coverageFetchHandler := http.HandlerFunc(pacientController.GetPatientCoverage)
+	router.HandleFunc(utils.FETCH_PATIENT_COVERAGE_ENDPOINT, coverageFetchHandler).Methods("GET")
+	log.Println("[PATIENT] Route GET", utils.FETCH_PATIENT_COVERAGE_ENDPOINT, "registered.")
+
+	legalHoldFetchHandler := http.HandlerFunc(pacientController.GetLegalHold)
+	router.HandleFunc(utils.LEGAL_HOLD_ENDPOINT, legalHoldFetchHandler).Methods("GET")
+	log.Println("[PATIENT] Route GET", utils.LEGAL_HOLD_ENDPOINT, "registered.")
+
+	erasureSubjectFetchHandler := http.HandlerFunc(pacientController.GetErasureSubject)
+	router.HandleFunc(utils.PATIENT_ERASURE_ENDPOINT, erasureSubjectFetchHandler).Methods("GET")
+	log.Println("[PATIENT] Route GET", utils.PATIENT_ERASURE_ENDPOINT, "registered.")
+
 	// ---------------------------------------------------------- Update --------------------------------------------------------------
 	pacientUpdateByIDHandler := http.HandlerFunc(pacientController.UpdatePatientByID)
 	router.Handle(utils.UPDATE_PATIENT_BY_ID_ENDPOINT, middleware.ValidatePacientInfo(pacientUpdateByIDHandler)).Methods("PUT")
@@ -81,6 +167,18 @@ func loadCrudRoutes(router *mux.Router, pacientController *controllers.PatientCo
 	router.Handle(utils.TOGGLE_PATIENT_ACTIVITY_ENDPOINT, middleware.ValidatePatientActivityInfo(toggleActivityHandler)).Methods("PATCH")
 	log.Println("[PATIENT] Route POST", utils.TOGGLE_PATIENT_ACTIVITY_ENDPOINT, "registered.")
 
+	profileUpdateHandler := http.HandlerFunc(pacientController.UpdateClinicalProfile)
+	router.Handle(utils.CLINICAL_PROFILE_ENDPOINT, middleware.ValidateClinicalProfile(profileUpdateHandler)).Methods("PUT")
+	log.Println("[PATIENT] Route PUT", utils.CLINICAL_PROFILE_ENDPOINT, "registered.")
+
+	policyUpdateHandler := http.HandlerFunc(pacientController.UpdateInsurancePolicy)
+	router.Handle(utils.INSURANCE_POLICY_BY_ID_ENDPOINT, middleware.ValidateInsurancePolicy(policyUpdateHandler)).Methods("PUT")
+	log.Println("[PATIENT] Route PUT", utils.INSURANCE_POLICY_BY_ID_ENDPOINT, "registered.")
+
+	legalHoldPlaceHandler := http.HandlerFunc(pacientController.PlaceLegalHold)
+	router.Handle(utils.LEGAL_HOLD_ENDPOINT, middleware.ValidateLegalHold(legalHoldPlaceHandler)).Methods("PUT")
+	log.Println("[PATIENT] Route PUT", utils.LEGAL_HOLD_ENDPOINT, "registered.")
+
 	// ---------------------------------------------------------- Delete --------------------------------------------------------------
 	pacientDeleteByUserIDHandler := http.HandlerFunc(pacientController.DeletePatientByUserID)
 	router.Handle(utils.DELETE_PATIENT_BY_USER_ID_ENDPOINT, pacientDeleteByUserIDHandler).Methods("DELETE")
@@ -90,5 +188,17 @@ func loadCrudRoutes(router *mux.Router, pacientController *controllers.PatientCo
 	router.Handle(utils.DELETE_PATIENT_BY_ID_ENDPOINT, pacientDeleteByIDHandler).Methods("DELETE")
 	log.Println("[PATIENT] Route DELETE", utils.DELETE_PATIENT_BY_ID_ENDPOINT, "registered.")
 
+	profileDeleteHandler := http.HandlerFunc(pacientController.DeleteClinicalProfile)
+	router.Handle(utils.CLINICAL_PROFILE_ENDPOINT, profileDeleteHandler).Methods("DELETE")
+	log.Println("[PATIENT] Route DELETE", utils.CLINICAL_PROFILE_ENDPOINT, "registered.")
+
+	policyDeleteHandler := http.HandlerFunc(pacientController.DeleteInsurancePolicy)
+	router.Handle(utils.INSURANCE_POLICY_BY_ID_ENDPOINT, policyDeleteHandler).Methods("DELETE")
+	log.Println("[PATIENT] Route DELETE", utils.INSURANCE_POLICY_BY_ID_ENDPOINT, "registered.")
+
+	legalHoldReleaseHandler := http.HandlerFunc(pacientController.ReleaseLegalHold)
+	router.Handle(utils.LEGAL_HOLD_ENDPOINT, legalHoldReleaseHandler).Methods("DELETE")
+	log.Println("[PATIENT] Route DELETE", utils.LEGAL_HOLD_ENDPOINT, "registered.")
+
 	log.Println("[PATIENT] All CRUD routes for Patient entity loaded successfully.")
 }
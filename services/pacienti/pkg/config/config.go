@@ -15,6 +15,12 @@ type AppConfig struct {
 	Server ServerConfig `yaml:"server"`
 	MySQL  MySQLConfig  `yaml:"mysql_db"`
 	Redis  RedisConfig  `yaml:"redis"`
+
+	Appointments  ServiceConfig    `yaml:"appointments"`
+	Consultations ServiceConfig    `yaml:"consultations"`
+	Duplicates    DuplicatesConfig `yaml:"duplicates"`
+	Insurance     InsuranceConfig  `yaml:"insurance"`
+	Encryption    EncryptionConfig `yaml:"encryption"`
 }
 
 type ServerConfig struct {
@@ -41,6 +47,34 @@ type RedisConfig struct {
 	DB       int    `yaml:"db"` // usually 0 unless you're using multiple databases
 }
 
+// ServiceConfig locates another module of the application
+type ServiceConfig struct {
+	Host string `yaml:"host"`
+	Port int    `yaml:"port"`
+}
+
+// DuplicatesConfig drives the duplicate patient detection. Pairs scoring at least Threshold, between 0 and 1, become merge candidates.
+type DuplicatesConfig struct {
+	Enabled             bool    `yaml:"enabled"`
+	ScanIntervalMinutes int     `yaml:"scanIntervalMinutes"`
+	Threshold           float64 `yaml:"threshold"`
+}
+
+// InsuranceConfig selects the service confirming the insurance policies of the patients. Only "stub" is available for now.
+type InsuranceConfig struct {
+	Verifier string `yaml:"verifier"`
+}
+
+// EncryptionConfig selects where the keys protecting the identifiers of the patients come from. Only "file" is
+// available for now, it reads the keyring at Keyring. Values left under a retired key are wrapped again with the
+// active key every RotationIntervalMinutes.
+type EncryptionConfig struct {
+	Provider                string `yaml:"provider"`
+	Keyring                 string `yaml:"keyring"`
+	RotationIntervalMinutes int    `yaml:"rotationIntervalMinutes"`
+	RotationBatchSize       int    `yaml:"rotationBatchSize"`
+}
+
 // LoadConfig loads the configuration from the given file path
 func LoadConfig(filePath string) (*AppConfig, error) {
 	log.Println("[PATIENT] Loading configuration...")
@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// withControlDigit completes the first 12 digits of a CNP with their control digit
+func withControlDigit(prefix string) string {
+	sum := 0
+	for i, weight := range cnpControlWeights {
+		sum += int(prefix[i]-'0') * weight
+	}
+	control := sum % 11
+	if control == 10 {
+		control = 1
+	}
+	return fmt.Sprintf("%s%d", prefix, control)
+}
+
+func TestParseCNP(t *testing.T) {
+	tomorrow := time.Now().AddDate(0, 0, 1)
+	nextYear := time.Now().AddDate(1, 0, 0)
+
+	tests := []struct {
+		name     string
+		cnp      string
+		birthDay time.Time
+		sex      string
+		err      error
+	}{
+		{name: "male born in the 1900s", cnp: "1800101221144", birthDay: time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC), sex: "M"},
+		{name: "female born in the 1900s", cnp: "2851231401230", birthDay: time.Date(1985, 12, 31, 0, 0, 0, 0, time.UTC), sex: "F"},
+		{name: "male born in the 1800s", cnp: withControlDigit("399021512345"), birthDay: time.Date(1899, 2, 15, 0, 0, 0, 0, time.UTC), sex: "M"},
+		{name: "female born in the 1800s", cnp: withControlDigit("499021512345"), birthDay: time.Date(1899, 2, 15, 0, 0, 0, 0, time.UTC), sex: "F"},
+		{name: "male born in the 2000s", cnp: withControlDigit("500022940001"), birthDay: time.Date(2000, 2, 29, 0, 0, 0, 0, time.UTC), sex: "M"},
+		{name: "female born in the 2000s", cnp: withControlDigit("610070352001"), birthDay: time.Date(2010, 7, 3, 0, 0, 0, 0, time.UTC), sex: "F"},
+		{name: "male resident", cnp: withControlDigit("775060708123"), birthDay: time.Date(1975, 6, 7, 0, 0, 0, 0, time.UTC), sex: "M"},
+		{name: "female resident", cnp: withControlDigit("875060708123"), birthDay: time.Date(1975, 6, 7, 0, 0, 0, 0, time.UTC), sex: "F"},
+		{name: "foreign citizen has no sex", cnp: withControlDigit("990111048123"), birthDay: time.Date(1990, 11, 10, 0, 0, 0, 0, time.UTC)},
+		{name: "control digit 10 is written as 1", cnp: "1800101220021", birthDay: time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC), sex: "M"},
+		{name: "Bucharest sector 6", cnp: withControlDigit("180010146123"), birthDay: time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC), sex: "M"},
+		{name: "Călărași", cnp: withControlDigit("180010151123"), birthDay: time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC), sex: "M"},
+		{name: "Giurgiu", cnp: withControlDigit("180010152123"), birthDay: time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC), sex: "M"},
+
+		{name: "too short", cnp: "180010122114", err: ErrCNPFormat},
+		{name: "too long", cnp: "18001012211440", err: ErrCNPFormat},
+		{name: "not a digit", cnp: "18001012211a4", err: ErrCNPFormat},
+		{name: "empty", cnp: "", err: ErrCNPFormat},
+		{name: "sex digit 0", cnp: withControlDigit("080010122114"), err: ErrCNPSexDigit},
+		{name: "month 0", cnp: withControlDigit("180000122114"), err: ErrCNPBirthDate},
+		{name: "month 13", cnp: withControlDigit("180130122114"), err: ErrCNPBirthDate},
+		{name: "day 0", cnp: withControlDigit("180010022114"), err: ErrCNPBirthDate},
+		{name: "31 April", cnp: withControlDigit("180043122114"), err: ErrCNPBirthDate},
+		{name: "29 February of a common year", cnp: withControlDigit("190022922114"), err: ErrCNPBirthDate},
+		{name: "29 February 1900 is not a leap day", cnp: withControlDigit("100022922114"), err: ErrCNPBirthDate},
+		{name: "born tomorrow", cnp: withControlDigit("6" + tomorrow.Format("060102") + "22114"), err: ErrCNPFutureBirth},
+		{name: "born next year", cnp: withControlDigit("5" + nextYear.Format("060102") + "22114"), err: ErrCNPFutureBirth},
+		{name: "county 0", cnp: withControlDigit("180010100114"), err: ErrCNPCountyCode},
+		{name: "county 49", cnp: withControlDigit("180010149114"), err: ErrCNPCountyCode},
+		{name: "county 53", cnp: withControlDigit("180010153114"), err: ErrCNPCountyCode},
+		{name: "serial number 000", cnp: withControlDigit("180010122000"), err: ErrCNPSerialNumber},
+		{name: "wrong control digit", cnp: "1800101221145", err: ErrCNPControlDigit},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := ParseCNP(tt.cnp)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("ParseCNP(%s) error = %v, want %v", tt.cnp, err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseCNP(%s) error = %v", tt.cnp, err)
+			}
+			if !info.BirthDay.Equal(tt.birthDay) {
+				t.Errorf("ParseCNP(%s) birth day = %s, want %s", tt.cnp, info.BirthDay.Format("2006-01-02"), tt.birthDay.Format("2006-01-02"))
+			}
+			if string(info.Sex) != tt.sex {
+				t.Errorf("ParseCNP(%s) sex = %q, want %q", tt.cnp, info.Sex, tt.sex)
+			}
+		})
+	}
+}
+
+func TestParseCNPBornToday(t *testing.T) {
+	today := time.Now().UTC()
+	if _, err := ParseCNP(withControlDigit("6" + today.Format("060102") + "22114")); err != nil {
+		t.Errorf("ParseCNP() of a CNP issued today error = %v", err)
+	}
+}
@@ -1,11 +1,18 @@
 package utils
 
+import "github.com/mihnea1711/POS_Project/services/pacienti/internal/models"
+
 type contextKey string
 
 const CONFIG_PATH = "configs/config.yaml"
 
 const DECODED_PATIENT contextKey = "decodedPatient"
 const DECODED_PATIENT_ACTIVITY contextKey = "decodedPatientActivity"
+const DECODED_MERGE_REQUEST contextKey = "decodedMergeRequest"
+const DECODED_CLINICAL_PROFILE contextKey = "decodedClinicalProfile"
+const DECODED_INSURANCE_POLICY contextKey = "decodedInsurancePolicy"
+const DECODED_LEGAL_HOLD contextKey = "decodedLegalHold"
+const DECODED_ERASURE_REQUEST contextKey = "decodedErasureRequest"
 
 const (
 	LIMITER_REQUESTS_ALLOWED  = 10
@@ -26,6 +33,7 @@ const (
 	HEALTH_CHECK_ENDPOINT              = "/patients/health-check"
 	CREATE_PATIENT_ENDPOINT            = "/patients"
 	FETCH_ALL_PATIENTS_ENDPOINT        = "/patients"
+	SEARCH_PATIENTS_ENDPOINT           = "/patients/search"
 	FETCH_PATIENT_BY_ID_ENDPOINT       = "/patients/{" + FETCH_PATIENT_BY_ID_PARAMETER + "}"
 	FETCH_PATIENT_BY_EMAIL_ENDPOINT    = "/patients/email/{" + FETCH_PATIENT_BY_EMAIL_PARAMETER + "}"
 	FETCH_PATIENT_BY_USER_ID_ENDPOINT  = "/patients/users/{" + FETCH_PATIENT_BY_USER_ID_PARAMETER + "}"
@@ -34,18 +42,59 @@ const (
 	DELETE_PATIENT_BY_USER_ID_ENDPOINT = "/patients/users/{" + DELETE_PATIENT_BY_USER_ID_PARAMETER + "}"
 	TOGGLE_PATIENT_ACTIVITY_ENDPOINT   = "/patients/{" + PATCH_PATIENT_BY_ID_PARAMETER + "}"
 
+	FETCH_DUPLICATE_CANDIDATES_ENDPOINT  = "/patients/duplicates"
+	SCAN_DUPLICATES_ENDPOINT             = "/patients/duplicates/scan"
+	DISMISS_DUPLICATE_CANDIDATE_ENDPOINT = "/patients/duplicates/{" + DUPLICATE_CANDIDATE_ID_PARAMETER + "}/dismiss"
+	CREATE_PATIENT_MERGE_ENDPOINT        = "/patients/merges"
+	FETCH_ALL_PATIENT_MERGES_ENDPOINT    = "/patients/merges"
+	FETCH_PATIENT_MERGE_BY_ID_ENDPOINT   = "/patients/merges/{" + PATIENT_MERGE_ID_PARAMETER + "}"
+
+	CLINICAL_PROFILE_ENDPOINT         = "/patients/{" + CLINICAL_PROFILE_PATIENT_ID_PARAMETER + "}/profile"
+	CLINICAL_PROFILE_HISTORY_ENDPOINT = "/patients/{" + CLINICAL_PROFILE_PATIENT_ID_PARAMETER + "}/profile/history"
+
+	INSURANCE_POLICIES_ENDPOINT      = "/patients/{" + INSURANCE_PATIENT_ID_PARAMETER + "}/insurance"
+	INSURANCE_POLICY_BY_ID_ENDPOINT  = "/patients/{" + INSURANCE_PATIENT_ID_PARAMETER + "}/insurance/{" + INSURANCE_POLICY_ID_PARAMETER + "}"
+	VERIFY_INSURANCE_POLICY_ENDPOINT = "/patients/{" + INSURANCE_PATIENT_ID_PARAMETER + "}/insurance/{" + INSURANCE_POLICY_ID_PARAMETER + "}/verify"
+	FETCH_PATIENT_COVERAGE_ENDPOINT  = "/patients/{" + INSURANCE_PATIENT_ID_PARAMETER + "}/coverage"
+
+	LEGAL_HOLD_ENDPOINT      = "/patients/{" + LEGAL_HOLD_PATIENT_ID_PARAMETER + "}/legal-hold"
+	PATIENT_ERASURE_ENDPOINT = "/patients/users/{" + ERASURE_USER_ID_PARAMETER + "}/erasure"
+
+	KEY_ROTATION_ENDPOINT = "/patients/encryption/rotation"
+
+	// Endpoints of the other modules
+	APPOINTMENT_REASSIGN_PATIENT_ENDPOINT  = "/appointments/patients/reassign"
+	CONSULTATION_REASSIGN_PATIENT_ENDPOINT = "/consultations/patients/reassign"
+
 	// Parameters
-	FETCH_PATIENT_BY_ID_PARAMETER       = "patientID"
-	FETCH_PATIENT_BY_EMAIL_PARAMETER    = "patientEmail"
-	FETCH_PATIENT_BY_USER_ID_PARAMETER  = "patientID"
-	UPDATE_PATIENT_BY_ID_PARAMETER      = "patientID"
-	PATCH_PATIENT_BY_ID_PARAMETER       = "patientID"
-	DELETE_PATIENT_BY_ID_PARAMETER      = "patientID"
-	DELETE_PATIENT_BY_USER_ID_PARAMETER = "patientUserID"
+	FETCH_PATIENT_BY_ID_PARAMETER         = "patientID"
+	FETCH_PATIENT_BY_EMAIL_PARAMETER      = "patientEmail"
+	FETCH_PATIENT_BY_USER_ID_PARAMETER    = "patientID"
+	UPDATE_PATIENT_BY_ID_PARAMETER        = "patientID"
+	PATCH_PATIENT_BY_ID_PARAMETER         = "patientID"
+	DELETE_PATIENT_BY_ID_PARAMETER        = "patientID"
+	DELETE_PATIENT_BY_USER_ID_PARAMETER   = "patientUserID"
+	DUPLICATE_CANDIDATE_ID_PARAMETER      = "candidateID"
+	PATIENT_MERGE_ID_PARAMETER            = "mergeID"
+	CLINICAL_PROFILE_PATIENT_ID_PARAMETER = "patientID"
+	INSURANCE_PATIENT_ID_PARAMETER        = "patientID"
+	INSURANCE_POLICY_ID_PARAMETER         = "policyID"
+	LEGAL_HOLD_PATIENT_ID_PARAMETER       = "patientID"
+	ERASURE_USER_ID_PARAMETER             = "patientUserID"
 
-	QUERY_IS_ACIVE = "isActive"
-	QUERY_PAGE     = "page"
-	QUERY_LIMIT    = "limit"
+	QUERY_IS_ACIVE   = "isActive"
+	QUERY_PAGE       = "page"
+	QUERY_LIMIT      = "limit"
+	QUERY_TEXT       = "q"
+	QUERY_NAME       = "name"
+	QUERY_CNP        = "cnp"
+	QUERY_PHONE      = "phone"
+	QUERY_EMAIL      = "email"
+	QUERY_BIRTH_FROM = "birthFrom"
+	QUERY_BIRTH_TO   = "birthTo"
+	QUERY_STATUS     = "status"
+	QUERY_CHANGED_BY = "changedBy"
+	QUERY_DATE       = "date"
 )
 
 const (
@@ -61,9 +110,258 @@ const (
 	ColumnCNP         = "cnp"
 	ColumnBirthDay    = "birth_day"
 	ColumnIsActive    = "is_active"
+	ColumnSex         = "sex"
+
+	// Blind indexes of the encrypted identifiers
+	ColumnEmailIndex       = "email_bidx"
+	ColumnPhoneNumberIndex = "phone_number_bidx"
+	ColumnCNPIndex         = "cnp_bidx"
+
+	DuplicateCandidateTableName = "patient_duplicate_candidate"
+	ColumnIDCandidate           = "id_candidate"
+	ColumnIDPatientA            = "id_patient_a"
+	ColumnIDPatientB            = "id_patient_b"
+	ColumnScore                 = "score"
+	ColumnReasons               = "reasons"
+	ColumnStatus                = "status"
+	ColumnDetectedAt            = "detected_at"
+
+	PatientMergeTableName     = "patient_merge"
+	ColumnIDMerge             = "id_merge"
+	ColumnIDSurvivor          = "id_survivor"
+	ColumnIDDuplicate         = "id_duplicate"
+	ColumnStep                = "step"
+	ColumnAppointmentsMoved   = "appointments_moved"
+	ColumnAppointmentsDropped = "appointments_dropped"
+	ColumnConsultationsMoved  = "consultations_moved"
+	ColumnError               = "error"
+	ColumnCreatedAt           = "created_at"
+	ColumnUpdatedAt           = "updated_at"
+	ColumnCompletedAt         = "completed_at"
+
+	ClinicalProfileTableName        = "patient_clinical_profile"
+	AllergyTableName                = "patient_allergy"
+	ChronicConditionTableName       = "patient_chronic_condition"
+	MedicationTableName             = "patient_medication"
+	EmergencyContactTableName       = "patient_emergency_contact"
+	ClinicalProfileHistoryTableName = "patient_clinical_profile_history"
+	ColumnBloodType                 = "blood_type"
+	ColumnVersion                   = "version"
+	ColumnUpdatedBy                 = "updated_by"
+	ColumnIDAllergy                 = "id_allergy"
+	ColumnSubstance                 = "substance"
+	ColumnSeverity                  = "severity"
+	ColumnReaction                  = "reaction"
+	ColumnIDCondition               = "id_condition"
+	ColumnName                      = "name"
+	ColumnDiagnosedOn               = "diagnosed_on"
+	ColumnNotes                     = "notes"
+	ColumnIDMedication              = "id_medication"
+	ColumnDosage                    = "dosage"
+	ColumnFrequency                 = "frequency"
+	ColumnStartedOn                 = "started_on"
+	ColumnIDContact                 = "id_contact"
+	ColumnRelationship              = "relationship"
+	ColumnIDChange                  = "id_change"
+	ColumnAction                    = "action"
+	ColumnSections                  = "sections"
+	ColumnSnapshot                  = "snapshot"
+	ColumnChangedBy                 = "changed_by"
+	ColumnChangedAt                 = "changed_at"
+
+	InsurancePolicyTableName = "patient_insurance_policy"
+	ColumnIDPolicy           = "id_policy"
+	ColumnPayerType          = "payer_type"
+	ColumnPayerName          = "payer_name"
+	ColumnPolicyNumber       = "policy_number"
+	ColumnCoverageCategory   = "coverage_category"
+	ColumnValidFrom          = "valid_from"
+	ColumnValidUntil         = "valid_until"
+	ColumnVerificationStatus = "verification_status"
+	ColumnVerificationNote   = "verification_note"
+	ColumnVerifiedAt         = "verified_at"
+
+	LegalHoldTableName = "patient_legal_hold"
+	ColumnReason       = "reason"
+	ColumnPlacedBy     = "placed_by"
+	ColumnPlacedAt     = "placed_at"
+
+	ErasureTableName = "patient_erasure"
+	ColumnIDErasure  = "id_erasure"
+	ColumnErasedAt   = "erased_at"
+)
+
+const (
+	SexMale   models.Sex = "M"
+	SexFemale models.Sex = "F"
 )
 
-const CNP_DATE_FORMAT = "060102"
+var ValidSexes = [...]models.Sex{SexMale, SexFemale}
+
+const (
+	CandidateStatusOpen      models.CandidateStatus = "open"
+	CandidateStatusDismissed models.CandidateStatus = "dismissed"
+	CandidateStatusMerged    models.CandidateStatus = "merged"
+)
+
+var ValidCandidateStatuses = [...]models.CandidateStatus{CandidateStatusOpen, CandidateStatusDismissed, CandidateStatusMerged}
+
+const (
+	MergeStatusPending   models.MergeStatus = "pending"
+	MergeStatusFailed    models.MergeStatus = "failed"
+	MergeStatusCompleted models.MergeStatus = "completed"
+)
+
+// A merge moves the appointments, then the consultations, then deactivates the duplicate record and closes its candidates
+const (
+	MergeStepAppointments  models.MergeStep = "appointments"
+	MergeStepConsultations models.MergeStep = "consultations"
+	MergeStepFinalize      models.MergeStep = "finalize"
+)
+
+var ValidBloodTypes = [...]models.BloodType{"O+", "O-", "A+", "A-", "B+", "B-", "AB+", "AB-"}
+
+const (
+	AllergySeverityMild            models.AllergySeverity = "mild"
+	AllergySeverityModerate        models.AllergySeverity = "moderate"
+	AllergySeveritySevere          models.AllergySeverity = "severe"
+	AllergySeverityLifeThreatening models.AllergySeverity = "life_threatening"
+)
+
+var ValidAllergySeverities = [...]models.AllergySeverity{AllergySeverityMild, AllergySeverityModerate, AllergySeveritySevere, AllergySeverityLifeThreatening}
+
+const (
+	ProfileActionCreated models.ProfileAction = "created"
+	ProfileActionUpdated models.ProfileAction = "updated"
+	ProfileActionDeleted models.ProfileAction = "deleted"
+)
+
+// Sections of a clinical profile, the history records which ones a change touched
+const (
+	PROFILE_SECTION_BLOOD_TYPE         = "bloodType"
+	PROFILE_SECTION_ALLERGIES          = "allergies"
+	PROFILE_SECTION_CHRONIC_CONDITIONS = "chronicConditions"
+	PROFILE_SECTION_MEDICATIONS        = "medications"
+	PROFILE_SECTION_EMERGENCY_CONTACTS = "emergencyContacts"
+)
+
+const (
+	PayerTypeCNAS    models.PayerType = "cnas"
+	PayerTypePrivate models.PayerType = "private"
+)
+
+var ValidPayerTypes = [...]models.PayerType{PayerTypeCNAS, PayerTypePrivate}
+
+const (
+	CoverageCategoryBasic    models.CoverageCategory = "basic"
+	CoverageCategoryExtended models.CoverageCategory = "extended"
+	CoverageCategoryFull     models.CoverageCategory = "full"
+)
+
+var ValidCoverageCategories = [...]models.CoverageCategory{CoverageCategoryBasic, CoverageCategoryExtended, CoverageCategoryFull}
+
+const (
+	VerificationStatusUnverified models.VerificationStatus = "unverified"
+	VerificationStatusVerified   models.VerificationStatus = "verified"
+	VerificationStatusRejected   models.VerificationStatus = "rejected"
+)
+
+// CNAS is the payer name of every CNAS policy
+const CNAS_PAYER_NAME = "CNAS"
+
+const (
+	ErasureActionDeleted    models.ErasureAction = "deleted"
+	ErasureActionAnonymized models.ErasureAction = "anonymized"
+	ErasureActionRetained   models.ErasureAction = "retained"
+)
+
+// Categories of personal data reported by an erasure
+const (
+	ERASURE_CATEGORY_IDENTITY             = "identity"
+	ERASURE_CATEGORY_EMERGENCY_CONTACTS   = "emergencyContacts"
+	ERASURE_CATEGORY_PROFILE_HISTORY      = "clinicalProfileHistory"
+	ERASURE_CATEGORY_CLINICAL_PROFILE     = "clinicalProfile"
+	ERASURE_CATEGORY_INSURANCE_POLICIES   = "insurancePolicies"
+	ERASURE_CATEGORY_DUPLICATE_CANDIDATES = "duplicateCandidates"
+)
+
+// An erased patient keeps its row under these values, unique per patient and never a valid email or CNP.
+// Only the year of the birth day and the sex are kept, the medical records need the age.
+const (
+	ERASED_NAME         = "ERASED"
+	ERASED_EMAIL_FORMAT = "erased-%d@erased.invalid"
+	ERASED_CNP_FORMAT   = "X%012d"
+)
+
+const (
+	INSURANCE_VERIFIER_STUB = "stub"
+	VERIFICATION_TIMEOUT    = 5 // seconds
+	MaxPolicyNumberLength   = 64
+)
+
+const (
+	KEY_PROVIDER_FILE               = "file"
+	DEFAULT_KEY_ROTATION_INTERVAL   = 60 // minutes
+	DEFAULT_KEY_ROTATION_BATCH_SIZE = 200
+	KEY_ROTATION_TIMEOUT            = 60 // seconds
+)
+
+const BIRTH_DAY_QUERY_FORMAT = "2006-01-02"
+
+// MAX_SEARCH_CANDIDATES caps the patients a search reads, decrypts and ranks. A search matching more is refused, so a
+// one letter name does not load the whole table.
+const MAX_SEARCH_CANDIDATES = 1000
+
+// NATIONAL_PHONE_LENGTH is the number of digits of a phone number in its national form, starting with 0
+const NATIONAL_PHONE_LENGTH = 10
+
+// Relevance of a search term matched against a patient field, from the best to the weakest match
+const (
+	SCORE_EXACT_MATCH        = 1.0
+	SCORE_PREFIX_MATCH       = 0.8
+	SCORE_FUZZY_MATCH        = 0.6
+	SCORE_FUZZY_PREFIX_MATCH = 0.4
+)
+
+// A search term tolerates one typo from FUZZY_ONE_TYPO_LENGTH runes on and two from FUZZY_TWO_TYPOS_LENGTH runes on
+const (
+	FUZZY_ONE_TYPO_LENGTH  = 4
+	FUZZY_TWO_TYPOS_LENGTH = 7
+)
+
+// Weights of the attributes compared by the duplicate detection, they add up to 1
+const (
+	DUPLICATE_WEIGHT_NAME      = 0.35
+	DUPLICATE_WEIGHT_BIRTH_DAY = 0.25
+	DUPLICATE_WEIGHT_PHONE     = 0.2
+	DUPLICATE_WEIGHT_CNP       = 0.2
+)
+
+// Names count as matching from DUPLICATE_MIN_NAME_SIMILARITY on, CNPs up to DUPLICATE_MAX_CNP_DISTANCE typos apart.
+// CNPs a single typo apart earn the whole CNP weight, further typos halve it.
+const (
+	DUPLICATE_MIN_NAME_SIMILARITY = 0.8
+	DUPLICATE_MAX_CNP_DISTANCE    = 2
+)
+
+const (
+	DUPLICATE_REASON_NAME      = "name"
+	DUPLICATE_REASON_BIRTH_DAY = "birth_day"
+	DUPLICATE_REASON_PHONE     = "phone"
+	DUPLICATE_REASON_CNP       = "cnp"
+)
+
+const (
+	DEFAULT_DUPLICATE_SCAN_INTERVAL = 360 // minutes
+	DEFAULT_DUPLICATE_THRESHOLD     = 0.6
+	DUPLICATE_SCAN_TIMEOUT          = 60 // seconds
+)
+
+// A pending merge is taken for abandoned, and can be resumed, once it made no progress for MERGE_RESUME_AFTER_SECONDS
+const (
+	MERGE_RESUME_AFTER_SECONDS = 120
+	MERGE_STEP_TIMEOUT         = 10 // seconds
+)
 
 const (
 	MySQLDuplicateEntryErrorCode = 1062
@@ -74,5 +372,10 @@ const (
 	MaxEmailLength      = 255
 	PhoneNumberLength   = 10
 	MaxCNPLength        = 13
+	MaxShortTextLength  = 100
+	MaxNotesLength      = 1024
+	MaxReasonLength     = 255
+	MaxErasureIDLength  = 64
+	MaxProfileItems     = 50      // per list of a clinical profile
 	MaxRequestSizeBytes = 1 << 20 // 1MB
 )
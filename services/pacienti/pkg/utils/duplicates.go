@@ -0,0 +1,73 @@
+package utils
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/mihnea1711/POS_Project/services/pacienti/internal/models"
+)
+
+// DuplicateBlockingKeys returns the keys grouping the patients worth comparing: the birth day, the phone number and
+// the name. Only patients sharing at least one key are compared, which keeps a scan far from comparing every pair.
+func DuplicateBlockingKeys(patient models.Patient) []string {
+	keys := []string{"name:" + duplicateNameKey(patient)}
+	if !patient.BirthDay.IsZero() {
+		keys = append(keys, "birth:"+patient.BirthDay.Format(BIRTH_DAY_QUERY_FORMAT))
+	}
+	if phone := NormalizePhoneNumber(patient.PhoneNumber); phone != "" {
+		keys = append(keys, "phone:"+phone)
+	}
+	return keys
+}
+
+// ScoreDuplicatePair rates, between 0 and 1, how likely two patient records belong to the same person,
+// and lists the attributes they share. The names are compared regardless of their order, diacritics and small typos.
+func ScoreDuplicatePair(a, b models.Patient) (float64, []string) {
+	var score float64
+	reasons := []string{}
+
+	if similarity := nameSimilarity(duplicateNameKey(a), duplicateNameKey(b)); similarity >= DUPLICATE_MIN_NAME_SIMILARITY {
+		score += DUPLICATE_WEIGHT_NAME * similarity
+		reasons = append(reasons, DUPLICATE_REASON_NAME)
+	}
+
+	if !a.BirthDay.IsZero() && SameDay(a.BirthDay, b.BirthDay) {
+		score += DUPLICATE_WEIGHT_BIRTH_DAY
+		reasons = append(reasons, DUPLICATE_REASON_BIRTH_DAY)
+	}
+
+	if phone := NormalizePhoneNumber(a.PhoneNumber); phone != "" && phone == NormalizePhoneNumber(b.PhoneNumber) {
+		score += DUPLICATE_WEIGHT_PHONE
+		reasons = append(reasons, DUPLICATE_REASON_PHONE)
+	}
+
+	// CNPs are unique, two records of the same person can only differ by a typo
+	switch distance := levenshtein([]rune(a.CNP), []rune(b.CNP)); {
+	case distance <= 1:
+		score += DUPLICATE_WEIGHT_CNP
+		reasons = append(reasons, DUPLICATE_REASON_CNP)
+	case distance <= DUPLICATE_MAX_CNP_DISTANCE:
+		score += DUPLICATE_WEIGHT_CNP / 2
+		reasons = append(reasons, DUPLICATE_REASON_CNP)
+	}
+
+	return math.Round(score*100) / 100, reasons
+}
+
+// duplicateNameKey folds both names of a patient and sorts their words, so swapped first and second names still match
+func duplicateNameKey(patient models.Patient) string {
+	terms := SearchTerms(patient.FirstName + " " + patient.SecondName)
+	sort.Strings(terms)
+	return strings.Join(terms, " ")
+}
+
+// nameSimilarity is 1 minus the edit distance of the names relative to the longer name
+func nameSimilarity(a, b string) float64 {
+	aRunes, bRunes := []rune(a), []rune(b)
+	longest := max(len(aRunes), len(bRunes))
+	if longest == 0 {
+		return 0
+	}
+	return 1 - float64(levenshtein(aRunes, bRunes))/float64(longest)
+}
@@ -0,0 +1,86 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/mihnea1711/POS_Project/services/pacienti/internal/models"
+)
+
+// ClinicalProfileChangedSections names the sections that differ between two versions of a clinical profile. The IDs of
+// the list items are left out of the comparison, they change whenever a list is saved again.
+func ClinicalProfileChangedSections(old, new *models.ClinicalProfile) []string {
+	sections := []string{}
+	if old.BloodType != new.BloodType {
+		sections = append(sections, PROFILE_SECTION_BLOOD_TYPE)
+	}
+	if !sameProfileItems(withoutAllergyIDs(old.Allergies), withoutAllergyIDs(new.Allergies)) {
+		sections = append(sections, PROFILE_SECTION_ALLERGIES)
+	}
+	if !sameProfileItems(withoutConditionIDs(old.ChronicConditions), withoutConditionIDs(new.ChronicConditions)) {
+		sections = append(sections, PROFILE_SECTION_CHRONIC_CONDITIONS)
+	}
+	if !sameProfileItems(withoutMedicationIDs(old.Medications), withoutMedicationIDs(new.Medications)) {
+		sections = append(sections, PROFILE_SECTION_MEDICATIONS)
+	}
+	if !sameProfileItems(withoutContactIDs(old.EmergencyContacts), withoutContactIDs(new.EmergencyContacts)) {
+		sections = append(sections, PROFILE_SECTION_EMERGENCY_CONTACTS)
+	}
+	return sections
+}
+
+// ClinicalProfileSections names the sections a profile fills in, used for the history of a created or deleted profile
+func ClinicalProfileSections(profile *models.ClinicalProfile) []string {
+	return ClinicalProfileChangedSections(&models.ClinicalProfile{}, profile)
+}
+
+// sameProfileItems compares the JSON forms of two lists, the dates are compared in UTC
+func sameProfileItems(a, b interface{}) bool {
+	jsonA, errA := json.Marshal(a)
+	jsonB, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(jsonA, jsonB)
+}
+
+func withoutAllergyIDs(items []models.Allergy) []models.Allergy {
+	result := make([]models.Allergy, len(items))
+	for i, item := range items {
+		item.IDAllergy = 0
+		result[i] = item
+	}
+	return result
+}
+
+func withoutConditionIDs(items []models.ChronicCondition) []models.ChronicCondition {
+	result := make([]models.ChronicCondition, len(items))
+	for i, item := range items {
+		item.IDCondition = 0
+		if item.DiagnosedOn != nil {
+			diagnosedOn := item.DiagnosedOn.UTC()
+			item.DiagnosedOn = &diagnosedOn
+		}
+		result[i] = item
+	}
+	return result
+}
+
+func withoutMedicationIDs(items []models.Medication) []models.Medication {
+	result := make([]models.Medication, len(items))
+	for i, item := range items {
+		item.IDMedication = 0
+		if item.StartedOn != nil {
+			startedOn := item.StartedOn.UTC()
+			item.StartedOn = &startedOn
+		}
+		result[i] = item
+	}
+	return result
+}
+
+func withoutContactIDs(items []models.EmergencyContact) []models.EmergencyContact {
+	result := make([]models.EmergencyContact, len(items))
+	for i, item := range items {
+		item.IDContact = 0
+		result[i] = item
+	}
+	return result
+}
@@ -0,0 +1,265 @@
+package utils
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/mihnea1711/POS_Project/services/pacienti/internal/models"
+)
+
+var (
+	cnpSearchRegex   = regexp.MustCompile(`^[0-9]{13}$`)
+	phoneSearchRegex = regexp.MustCompile(`^0[0-9]{9}$`)
+)
+
+// diacriticReplacer folds the Romanian letters, in both the comma and the cedilla forms, and the most common
+// accented Latin letters to ASCII. The database collation is accent insensitive, so both sides agree on "Stefan".
+var diacriticReplacer = strings.NewReplacer(
+	"ă", "a", "â", "a", "î", "i", "ș", "s", "ş", "s", "ț", "t", "ţ", "t",
+	"á", "a", "à", "a", "ä", "a", "ã", "a", "å", "a",
+	"é", "e", "è", "e", "ê", "e", "ë", "e",
+	"í", "i", "ì", "i", "ï", "i",
+	"ó", "o", "ò", "o", "ô", "o", "ö", "o", "õ", "o", "ő", "o",
+	"ú", "u", "ù", "u", "û", "u", "ü", "u", "ű", "u",
+	"ç", "c", "č", "c", "ć", "c", "ñ", "n", "ń", "n", "š", "s", "ś", "s", "ž", "z", "ź", "z", "ż", "z", "ł", "l",
+)
+
+// FoldSearchText lowercases the text and removes its diacritics
+func FoldSearchText(text string) string {
+	return diacriticReplacer.Replace(strings.ToLower(text))
+}
+
+// SearchTerms splits a folded text into terms. Hyphenated names become separate terms,
+// while the characters of an email address are kept together.
+func SearchTerms(text string) []string {
+	return strings.FieldsFunc(FoldSearchText(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune("@._+", r)
+	})
+}
+
+// NormalizePhoneNumber brings a phone number to its national form, the one it is stored and blind indexed in. The
+// separators are removed and the +40 or 0040 country prefix becomes 0. A + left unencoded in a query string arrives
+// as a space, so a bare 40 followed by the nine national digits is taken for the country prefix as well.
+func NormalizePhoneNumber(phone string) string {
+	phone = strings.NewReplacer(" ", "", "-", "", ".", "", "(", "", ")", "").Replace(phone)
+	for _, prefix := range []string{"+40", "0040"} {
+		if strings.HasPrefix(phone, prefix) {
+			return "0" + strings.TrimPrefix(phone, prefix)
+		}
+	}
+	if len(phone) == NATIONAL_PHONE_LENGTH+1 && strings.HasPrefix(phone, "40") {
+		return "0" + strings.TrimPrefix(phone, "40")
+	}
+	return phone
+}
+
+// ExtractSearchParams reads the criteria of a patient search. Unknown parameters are rejected and at least one criterion is required.
+func ExtractSearchParams(r *http.Request) (*models.PatientSearchParams, error) {
+	query := r.URL.Query()
+
+	for key := range query {
+		if !isExpectedSearchParam(key) {
+			log.Printf("[PATIENT] ExtractSearchParams: Unknown search parameter: %s", key)
+			return nil, fmt.Errorf("unknown search parameter: %s", key)
+		}
+	}
+
+	params := &models.PatientSearchParams{
+		Text:  strings.TrimSpace(query.Get(QUERY_TEXT)),
+		Name:  strings.TrimSpace(query.Get(QUERY_NAME)),
+		CNP:   strings.TrimSpace(query.Get(QUERY_CNP)),
+		Phone: NormalizePhoneNumber(strings.TrimSpace(query.Get(QUERY_PHONE))),
+		Email: strings.TrimSpace(query.Get(QUERY_EMAIL)),
+	}
+
+	// The identifiers are encrypted and only found whole through their blind indexes, a prefix cannot match
+	if params.CNP != "" && !cnpSearchRegex.MatchString(params.CNP) {
+		return nil, fmt.Errorf("invalid %s: the whole CNP of 13 digits is expected, prefixes cannot be searched", QUERY_CNP)
+	}
+	if params.Phone != "" && !phoneSearchRegex.MatchString(params.Phone) {
+		return nil, fmt.Errorf("invalid %s %q: the whole phone number is expected, as 0 or +40 followed by 9 digits", QUERY_PHONE, query.Get(QUERY_PHONE))
+	}
+	if params.Email != "" && !EmailRegex.MatchString(params.Email) {
+		return nil, fmt.Errorf("invalid %s: %q", QUERY_EMAIL, params.Email)
+	}
+
+	var err error
+	if params.BirthFrom, err = parseBirthDayParam(query.Get(QUERY_BIRTH_FROM), QUERY_BIRTH_FROM); err != nil {
+		return nil, err
+	}
+	if params.BirthTo, err = parseBirthDayParam(query.Get(QUERY_BIRTH_TO), QUERY_BIRTH_TO); err != nil {
+		return nil, err
+	}
+	if params.BirthFrom != nil && params.BirthTo != nil && params.BirthTo.Before(*params.BirthFrom) {
+		return nil, fmt.Errorf("%s must not be before %s", QUERY_BIRTH_TO, QUERY_BIRTH_FROM)
+	}
+
+	if isActiveStr := query.Get(QUERY_IS_ACIVE); isActiveStr != "" {
+		isActive, err := strconv.ParseBool(isActiveStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %v", QUERY_IS_ACIVE, err)
+		}
+		params.IsActive = &isActive
+	}
+
+	if len(SearchTerms(params.Text)) == 0 && len(SearchTerms(params.Name)) == 0 && params.CNP == "" && params.Phone == "" &&
+		params.Email == "" && params.BirthFrom == nil && params.BirthTo == nil {
+		return nil, fmt.Errorf("at least one search criterion is required")
+	}
+
+	return params, nil
+}
+
+func parseBirthDayParam(value, name string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	birthDay, err := time.Parse(BIRTH_DAY_QUERY_FORMAT, value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: expected format YYYY-MM-DD", name)
+	}
+	return &birthDay, nil
+}
+
+// isExpectedSearchParam checks if a parameter name is one of the search criteria or the pagination parameters.
+func isExpectedSearchParam(paramName string) bool {
+	expectedParams := map[string]struct{}{
+		QUERY_TEXT:       {},
+		QUERY_NAME:       {},
+		QUERY_CNP:        {},
+		QUERY_PHONE:      {},
+		QUERY_EMAIL:      {},
+		QUERY_BIRTH_FROM: {},
+		QUERY_BIRTH_TO:   {},
+		QUERY_IS_ACIVE:   {},
+		QUERY_PAGE:       {},
+		QUERY_LIMIT:      {},
+	}
+
+	_, ok := expectedParams[paramName]
+	return ok
+}
+
+// ScorePatient rates how well a patient matches the search terms. Every term has to match,
+// the score is the average relevance of the terms. A search made only of filters scores 1.
+func ScorePatient(patient models.Patient, params models.PatientSearchParams) (float64, bool) {
+	nameTerms := SearchTerms(patient.FirstName + " " + patient.SecondName)
+
+	var total float64
+	var count int
+	for _, term := range SearchTerms(params.Name) {
+		score := scoreNameTerm(term, nameTerms)
+		if score == 0 {
+			return 0, false
+		}
+		total += score
+		count++
+	}
+
+	for _, term := range SearchTerms(params.Text) {
+		score := scoreNameTerm(term, nameTerms)
+		if idScore := scoreIdentifierTerm(term, patient); idScore > score {
+			score = idScore
+		}
+		if score == 0 {
+			return 0, false
+		}
+		total += score
+		count++
+	}
+
+	if count == 0 {
+		return SCORE_EXACT_MATCH, true
+	}
+	return total / float64(count), true
+}
+
+// scoreNameTerm returns the relevance of the best name matching the term: exact, prefix, fuzzy or fuzzy prefix
+func scoreNameTerm(term string, nameTerms []string) float64 {
+	var best float64
+	termRunes := []rune(term)
+	maxTypos := allowedTypos(len(termRunes))
+
+	for _, name := range nameTerms {
+		nameRunes := []rune(name)
+
+		var score float64
+		switch {
+		case name == term:
+			score = SCORE_EXACT_MATCH
+		case strings.HasPrefix(name, term):
+			score = SCORE_PREFIX_MATCH
+		case maxTypos > 0 && levenshtein(termRunes, nameRunes) <= maxTypos:
+			score = SCORE_FUZZY_MATCH
+		case maxTypos > 0 && len(nameRunes) > len(termRunes) && levenshtein(termRunes, nameRunes[:len(termRunes)]) <= maxTypos:
+			score = SCORE_FUZZY_PREFIX_MATCH
+		}
+
+		if score > best {
+			best = score
+		}
+	}
+
+	return best
+}
+
+// scoreIdentifierTerm matches the term exactly or as a prefix of the CNP, the phone number or the email address
+func scoreIdentifierTerm(term string, patient models.Patient) float64 {
+	var best float64
+	identifiers := [][2]string{
+		{patient.CNP, term},
+		{NormalizePhoneNumber(patient.PhoneNumber), NormalizePhoneNumber(term)},
+		{strings.ToLower(patient.Email), term},
+	}
+	for _, pair := range identifiers {
+		identifier, value := pair[0], pair[1]
+		switch {
+		case identifier == value:
+			return SCORE_EXACT_MATCH
+		case strings.HasPrefix(identifier, value):
+			best = SCORE_PREFIX_MATCH
+		}
+	}
+	return best
+}
+
+func allowedTypos(length int) int {
+	switch {
+	case length >= FUZZY_TWO_TYPOS_LENGTH:
+		return 2
+	case length >= FUZZY_ONE_TYPO_LENGTH:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// levenshtein computes the edit distance between two words
+func levenshtein(a, b []rune) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(b)]
+}
@@ -0,0 +1,33 @@
+#!/bin/bash
+
+# Extract port from config.yaml
+PORT=$(yq e '.server.port' configs/config.yaml)
+
+# A CNAS policy is numbered with the CNP of the patient, the payer name is always CNAS
+curl \
+    -X POST http://localhost:"$PORT"/patients/1/insurance \
+    -H "Content-Type: application/json" \
+    -d '{
+        "payerType": "cnas",
+        "policyNumber": "1000101190123",
+        "coverageCategory": "basic",
+        "validFrom": "2024-01-01T00:00:00Z"
+    }'
+
+# A private policy, valid until the end of the year
+curl \
+    -X POST http://localhost:"$PORT"/patients/1/insurance \
+    -H "Content-Type: application/json" \
+    -d '{
+        "payerType": "private",
+        "payerName": "Regina Maria",
+        "policyNumber": "RM-2024-00042",
+        "coverageCategory": "extended",
+        "validFrom": "2024-01-01T00:00:00Z",
+        "validUntil": "2024-12-31T00:00:00Z"
+    }'
+
+curl -X GET http://localhost:"$PORT"/patients/1/insurance
+curl -X POST http://localhost:"$PORT"/patients/1/insurance/1/verify
+curl -X GET "http://localhost:$PORT/patients/1/coverage?date=2024-06-15"
+curl -X DELETE http://localhost:"$PORT"/patients/1/insurance/2
@@ -0,0 +1,37 @@
+#!/bin/bash
+# Usage: ./scripts/keyring.sh [--create]
+
+# Extract port and keyring path from config.yaml. The service reads the keyring from /workspace, the module directory.
+PORT=$(yq e '.server.port' configs/config.yaml)
+KEYRING=$(yq e '.encryption.keyring' configs/config.yaml | sed 's|^/workspace/||')
+
+KEY_ID=$(date +%Y%m%d%H%M%S)
+KEY=$(openssl rand -base64 32)
+
+if [ ! -f "$KEYRING" ]; then
+    # The first key and the blind index key. Losing the keyring makes the stored identifiers unreadable.
+    mkdir -p "$(dirname "$KEYRING")"
+    INDEX_KEY=$(openssl rand -base64 32)
+    cat > "$KEYRING" <<KEYS
+active: "$KEY_ID"
+index: "$INDEX_KEY"
+keys:
+  "$KEY_ID": "$KEY"
+KEYS
+    chmod 600 "$KEYRING"
+    echo "Keyring created with key $KEY_ID"
+    exit 0
+fi
+
+# The start scripts only create a missing keyring, they never rotate
+if [ "$1" == "--create" ]; then
+    echo "Keyring $KEYRING already exists"
+    exit 0
+fi
+
+# Rotate: add a new key, make it active and move the stored identifiers to it now.
+# The retired keys stay in the keyring until keysInUse no longer lists them.
+yq e -i ".keys.\"$KEY_ID\" = \"$KEY\" | .active = \"$KEY_ID\"" "$KEYRING"
+echo "Key $KEY_ID is now active"
+
+curl -X POST http://localhost:"$PORT"/patients/encryption/rotation
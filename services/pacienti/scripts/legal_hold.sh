@@ -0,0 +1,20 @@
+#!/bin/bash
+
+# Extract port from config.yaml
+PORT=$(yq e '.server.port' configs/config.yaml)
+
+# While the hold is in place the patient cannot be erased, placing it again replaces the reason
+curl \
+    -X PUT http://localhost:"$PORT"/patients/1/legal-hold \
+    -H "Content-Type: application/json" \
+    -d '{
+        "reason": "Records requested by the court in case 1234/2024",
+        "placedBy": 1
+    }'
+
+curl -X GET http://localhost:"$PORT"/patients/1/legal-hold
+
+# The prepare step of an erasure answers 423 while the patient is held
+curl -X GET http://localhost:"$PORT"/patients/users/1/erasure
+
+curl -X DELETE http://localhost:"$PORT"/patients/1/legal-hold
@@ -0,0 +1,17 @@
+#!/bin/bash
+
+# Extract port from config.yaml
+PORT=$(yq e '.server.port' configs/config.yaml)
+
+# Detect the duplicates now and list the open candidates
+curl -X POST http://localhost:"$PORT"/patients/duplicates/scan
+curl -X GET http://localhost:"$PORT"/patients/duplicates
+
+# Merge patient 2 into patient 1, run it again to resume a failed merge
+curl \
+    -X POST http://localhost:"$PORT"/patients/merges \
+    -H "Content-Type: application/json" \
+    -d '{
+        "idSurvivor": 1,
+        "idDuplicate": 2
+    }'
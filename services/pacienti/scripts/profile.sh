@@ -0,0 +1,34 @@
+#!/bin/bash
+
+# Extract port from config.yaml
+PORT=$(yq e '.server.port' configs/config.yaml)
+
+# Create the clinical profile of patient 1
+curl \
+    -X POST http://localhost:"$PORT"/patients/1/profile \
+    -H "Content-Type: application/json" \
+    -d '{
+        "bloodType": "A+",
+        "allergies": [{"substance": "Penicillin", "severity": "severe", "reaction": "Hives"}],
+        "chronicConditions": [{"name": "Hypertension", "diagnosedOn": "2019-05-10T00:00:00Z"}],
+        "medications": [{"name": "Enalapril", "dosage": "10 mg", "frequency": "once a day"}],
+        "emergencyContacts": [{"name": "Maria Popescu", "relationship": "wife", "phoneNumber": "0712345680"}],
+        "updatedBy": 1
+    }'
+
+# Update it from version 1, a request naming an older version gets a 409
+curl \
+    -X PUT http://localhost:"$PORT"/patients/1/profile \
+    -H "Content-Type: application/json" \
+    -d '{
+        "bloodType": "A+",
+        "allergies": [{"substance": "Penicillin", "severity": "life_threatening", "reaction": "Anaphylaxis"}],
+        "chronicConditions": [{"name": "Hypertension", "diagnosedOn": "2019-05-10T00:00:00Z"}],
+        "medications": [],
+        "emergencyContacts": [{"name": "Maria Popescu", "relationship": "wife", "phoneNumber": "0712345680"}],
+        "version": 1,
+        "updatedBy": 1
+    }'
+
+curl -X GET http://localhost:"$PORT"/patients/1/profile
+curl -X GET http://localhost:"$PORT"/patients/1/profile/history
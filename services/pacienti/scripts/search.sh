@@ -0,0 +1,9 @@
+#!/bin/bash
+
+# Extract port from config.yaml
+PORT=$(yq e '.server.port' configs/config.yaml)
+
+curl -X GET --get http://localhost:"$PORT"/patients/search \
+    --data-urlencode "name=Stefan Popescu" \
+    --data-urlencode "birthFrom=1990-01-01" \
+    --data-urlencode "isActive=true"
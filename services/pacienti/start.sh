@@ -17,6 +17,9 @@ docker network prune --force
 echo "[PATIENT] Removing unused images..."
 docker image prune --force
 
+echo "[PATIENT] Creating the encryption keyring if missing..."
+./scripts/keyring.sh --create
+
 echo "[PATIENT] Building Docker images..."
 docker compose build
 
@@ -10,16 +10,22 @@ import (
 	"github.com/mihnea1711/POS_Project/services/programari/internal/database"
 	"github.com/mihnea1711/POS_Project/services/programari/internal/database/mysql"
 	"github.com/mihnea1711/POS_Project/services/programari/internal/database/redis"
+	"github.com/mihnea1711/POS_Project/services/programari/internal/notification"
+	"github.com/mihnea1711/POS_Project/services/programari/internal/patients"
+	"github.com/mihnea1711/POS_Project/services/programari/internal/reminder"
+	"github.com/mihnea1711/POS_Project/services/programari/internal/rescheduling"
 	"github.com/mihnea1711/POS_Project/services/programari/internal/routes"
 	"github.com/mihnea1711/POS_Project/services/programari/pkg/config"
 	"github.com/mihnea1711/POS_Project/services/programari/pkg/utils"
 )
 
 type App struct {
-	router   http.Handler
-	database database.Database
-	rdb      *redis.RedisClient
-	config   *config.AppConfig
+	router    http.Handler
+	database  database.Database
+	rdb       *redis.RedisClient
+	scheduler *reminder.Scheduler
+	manager   *rescheduling.Manager
+	config    *config.AppConfig
 }
 
 func New(config *config.AppConfig, parentCtx context.Context) (*App, error) {
@@ -27,6 +33,16 @@ func New(config *config.AppConfig, parentCtx context.Context) (*App, error) {
 		config: config,
 	}
 
+	// The confirmation and proposal links skip the gateway authentication, only the secrets keep them from being forged
+	if config.Reminders.Enabled && len(config.Reminders.Secret) < utils.MIN_TOKEN_SECRET_LENGTH {
+		log.Printf("[APPOINTMENT] The reminder secret is unset or shorter than %d characters", utils.MIN_TOKEN_SECRET_LENGTH)
+		return nil, fmt.Errorf("reminders are enabled but reminders.secret is unset or shorter than %d characters", utils.MIN_TOKEN_SECRET_LENGTH)
+	}
+	if config.Rescheduling.Enabled && len(config.Rescheduling.Secret) < utils.MIN_TOKEN_SECRET_LENGTH {
+		log.Printf("[APPOINTMENT] The rescheduling secret is unset or shorter than %d characters", utils.MIN_TOKEN_SECRET_LENGTH)
+		return nil, fmt.Errorf("rescheduling is enabled but rescheduling.secret is unset or shorter than %d characters", utils.MIN_TOKEN_SECRET_LENGTH)
+	}
+
 	// Setup MySQL connection for the app
 	mysqlDB, err := mysql.NewMySQL(parentCtx, &config.MySQL)
 	if err != nil {
@@ -46,9 +62,34 @@ func New(config *config.AppConfig, parentCtx context.Context) (*App, error) {
 	log.Println("[APPOINTMENT] Redis connection successfully established.")
 
 	// Setup router for the app
-	router := routes.SetupRoutes(parentCtx, app.database, app.rdb)
+	router := routes.SetupRoutes(parentCtx, app.database, app.rdb, config)
 	app.router = router
 
+	// Reminders and rescheduling notices share the notification channels
+	var channels []notification.NotificationChannel
+	if config.Reminders.Enabled || config.Rescheduling.Enabled {
+		channels, err = setupNotificationChannels(config.Reminders)
+		if err != nil {
+			log.Printf("[APPOINTMENT] Error initializing notification channels: %v", err)
+			return nil, fmt.Errorf("failed to initialize notification channels: %w", err)
+		}
+	}
+
+	// The contact details of the patients are read from the patient module, which decrypts them
+	patientDirectory := patients.NewDirectory(config.Patients)
+
+	// Setup the appointment reminder scheduler
+	if config.Reminders.Enabled {
+		app.scheduler = reminder.NewScheduler(app.database, patientDirectory, channels, config.Reminders, config.Clinic.DayStartHour)
+		log.Println("[APPOINTMENT] Reminder scheduler successfully initialized.")
+	}
+
+	// Setup the manager rescheduling the appointments of unavailable doctors
+	if config.Rescheduling.Enabled {
+		app.manager = rescheduling.NewManager(app.database, patientDirectory, channels, config.Rescheduling, config.Clinic)
+		log.Println("[APPOINTMENT] Rescheduling manager successfully initialized.")
+	}
+
 	log.Println("[APPOINTMENT] Application successfully initialized.")
 	return app, nil
 }
@@ -61,6 +102,14 @@ func (a *App) Start(ctx context.Context) error {
 
 	log.Printf("[APPOINTMENT] Starting server on port %d...", a.config.Server.Port)
 
+	if a.scheduler != nil {
+		go a.scheduler.Start(ctx)
+	}
+
+	if a.manager != nil {
+		go a.manager.Start(ctx)
+	}
+
 	channel := make(chan error, 1)
 	go func() {
 		err := server.ListenAndServe()
@@ -103,3 +152,27 @@ func (a *App) Start(ctx context.Context) error {
 		return nil
 	}
 }
+
+// setupNotificationChannels creates the notification channels enabled in the reminder config.
+// The rescheduling manager notifies patients through the same channels.
+func setupNotificationChannels(reminderConfig config.ReminderConfig) ([]notification.NotificationChannel, error) {
+	var channels []notification.NotificationChannel
+
+	if reminderConfig.Email.Enabled {
+		emailChannel, err := notification.NewEmailChannel(reminderConfig.Email.SinkPath)
+		if err != nil {
+			return nil, err
+		}
+		channels = append(channels, emailChannel)
+	}
+
+	if reminderConfig.SMS.Enabled {
+		smsChannel, err := notification.NewSMSChannel(reminderConfig.SMS.SinkPath)
+		if err != nil {
+			return nil, err
+		}
+		channels = append(channels, smsChannel)
+	}
+
+	return channels, nil
+}
@@ -0,0 +1,176 @@
+package controllers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/mihnea1711/POS_Project/services/programari/internal/models"
+	"github.com/mihnea1711/POS_Project/services/programari/pkg/utils"
+)
+
+// GetAvailability tells, day by day, whether an appointment of a type can be booked with a doctor.
+// A day is available when the doctor has a free slot and every resource the type requires has a free unit.
+func (aController *AppointmentController) GetAvailability(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[APPOINTMENT] Attempting to compute availability.")
+
+	doctorID, typeID, from, to, err := extractAvailabilityParams(r)
+	if err != nil {
+		errMsg := fmt.Sprintf("bad request: %s", err)
+		log.Printf("[APPOINTMENT] GetAvailability: %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusBadRequest, models.ResponseData{Error: errMsg, Message: "Invalid availability request"})
+		return
+	}
+
+	// Ensure a database operation doesn't take longer than 5 seconds
+	ctx, cancel := context.WithTimeout(r.Context(), utils.DB_REQ_TIMEOUT_SEC_MULTIPLIER*time.Second)
+	defer cancel()
+
+	requirements := []models.ResourceRequirement{}
+	if typeID != 0 {
+		appointmentType, err := aController.DbConn.FetchAppointmentTypeByID(ctx, typeID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				errMsg := fmt.Sprintf("No appointment type found with ID: %d", typeID)
+				log.Printf("[APPOINTMENT] %s", errMsg)
+				utils.RespondWithJSON(w, http.StatusNotFound, models.ResponseData{Error: errMsg, Message: "Appointment type not found"})
+				return
+			}
+			respondAvailabilityError(w, err)
+			return
+		}
+		requirements = appointmentType.Requirements
+	}
+
+	doctorLoads, err := aController.DbConn.FetchDoctorDailyLoad(ctx, []int{doctorID}, from, to)
+	if err != nil {
+		respondAvailabilityError(w, err)
+		return
+	}
+	doctorLoad := make(map[string]int)
+	for _, load := range doctorLoads {
+		doctorLoad[load.Date.Format(utils.TIME_PARSE_SYNTAX)] += load.Count
+	}
+
+	// Only active resources of the required categories can be booked
+	resourcesByCategory := make(map[string][]models.Resource)
+	var resourceIDs []int
+	if len(requirements) > 0 {
+		categories := make([]string, 0, len(requirements))
+		for _, requirement := range requirements {
+			categories = append(categories, requirement.Category)
+		}
+
+		resources, err := aController.DbConn.FetchResources(ctx, map[string]interface{}{utils.ColumnCategory: categories, utils.ColumnIsActive: true})
+		if err != nil {
+			respondAvailabilityError(w, err)
+			return
+		}
+		for _, resource := range resources {
+			resourcesByCategory[resource.Category] = append(resourcesByCategory[resource.Category], resource)
+			resourceIDs = append(resourceIDs, resource.IDResource)
+		}
+	}
+
+	resourceLoads, err := aController.DbConn.FetchResourceDailyLoad(ctx, resourceIDs, from, to)
+	if err != nil {
+		respondAvailabilityError(w, err)
+		return
+	}
+	resourceLoad := make(map[string]int)
+	for _, load := range resourceLoads {
+		resourceLoad[resourceDayKey(load.IDResource, load.Date)] = load.Count
+	}
+
+	days := []models.DayAvailability{}
+	for date := from; !date.After(to); date = date.AddDate(0, 0, 1) {
+		day := models.DayAvailability{Date: date, Available: true, Resources: []models.RequirementAvailability{}}
+
+		if aController.DoctorDailyCapacity > 0 {
+			free := aController.DoctorDailyCapacity - doctorLoad[date.Format(utils.TIME_PARSE_SYNTAX)]
+			if free < 0 {
+				free = 0
+			}
+			day.DoctorFreeSlots = &free
+			day.Available = free > 0
+		}
+
+		for _, requirement := range requirements {
+			availability := models.RequirementAvailability{Category: requirement.Category, Required: requirement.Quantity, FreeResources: []int{}}
+			for _, resource := range resourcesByCategory[requirement.Category] {
+				if resourceLoad[resourceDayKey(resource.IDResource, date)] < resource.DailyCapacity {
+					availability.FreeResources = append(availability.FreeResources, resource.IDResource)
+				}
+			}
+
+			if len(availability.FreeResources) < requirement.Quantity {
+				day.Available = false
+			}
+			day.Resources = append(day.Resources, availability)
+		}
+
+		days = append(days, day)
+	}
+
+	log.Printf("[APPOINTMENT] Successfully computed availability of doctor %d for %d days", doctorID, len(days))
+	utils.RespondWithJSON(w, http.StatusOK, models.ResponseData{
+		Payload: days,
+		Message: fmt.Sprintf("Successfully computed availability of doctor %d for %d days", doctorID, len(days)),
+	})
+}
+
+// extractAvailabilityParams reads the doctor, the optional appointment type and the day range.
+// The range starts today by default and spans at most MAX_AVAILABILITY_DAYS days.
+func extractAvailabilityParams(r *http.Request) (int, int, time.Time, time.Time, error) {
+	query := r.URL.Query()
+
+	doctorID, err := strconv.Atoi(query.Get(utils.QUERY_DOCTOR_ID))
+	if err != nil || doctorID <= 0 {
+		return 0, 0, time.Time{}, time.Time{}, fmt.Errorf("invalid %s: %q", utils.QUERY_DOCTOR_ID, query.Get(utils.QUERY_DOCTOR_ID))
+	}
+
+	var typeID int
+	if value := query.Get(utils.QUERY_TYPE_ID); value != "" {
+		typeID, err = strconv.Atoi(value)
+		if err != nil || typeID <= 0 {
+			return 0, 0, time.Time{}, time.Time{}, fmt.Errorf("invalid %s: %q", utils.QUERY_TYPE_ID, value)
+		}
+	}
+
+	now := time.Now()
+	from := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if value := query.Get(utils.QUERY_FROM); value != "" {
+		from, err = time.Parse(utils.TIME_PARSE_SYNTAX, value)
+		if err != nil {
+			return 0, 0, time.Time{}, time.Time{}, fmt.Errorf("invalid %s: %v", utils.QUERY_FROM, err)
+		}
+	}
+
+	to := from.AddDate(0, 0, utils.DEFAULT_AVAILABILITY_DAYS-1)
+	if value := query.Get(utils.QUERY_TO); value != "" {
+		to, err = time.Parse(utils.TIME_PARSE_SYNTAX, value)
+		if err != nil {
+			return 0, 0, time.Time{}, time.Time{}, fmt.Errorf("invalid %s: %v", utils.QUERY_TO, err)
+		}
+	}
+
+	if to.Before(from) || to.After(from.AddDate(0, 0, utils.MAX_AVAILABILITY_DAYS-1)) {
+		return 0, 0, time.Time{}, time.Time{}, fmt.Errorf("the range must end after it starts and span at most %d days", utils.MAX_AVAILABILITY_DAYS)
+	}
+
+	return doctorID, typeID, from, to, nil
+}
+
+func respondAvailabilityError(w http.ResponseWriter, err error) {
+	errMsg := fmt.Sprintf("internal server error: %s", err)
+	log.Printf("[APPOINTMENT] GetAvailability: %s", errMsg)
+	utils.RespondWithJSON(w, http.StatusInternalServerError, models.ResponseData{Error: errMsg, Message: "Failed to compute availability"})
+}
+
+func resourceDayKey(resourceID int, date time.Time) string {
+	return fmt.Sprintf("%d/%s", resourceID, date.Format(utils.TIME_PARSE_SYNTAX))
+}
@@ -2,16 +2,26 @@ package controllers
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
 
+	"github.com/mihnea1711/POS_Project/services/programari/internal/coverage"
 	"github.com/mihnea1711/POS_Project/services/programari/internal/database"
 	"github.com/mihnea1711/POS_Project/services/programari/internal/models"
+	"github.com/mihnea1711/POS_Project/services/programari/internal/policy"
 	"github.com/mihnea1711/POS_Project/services/programari/pkg/utils"
 )
 
 type AppointmentController struct {
-	DbConn database.Database
+	DbConn              database.Database
+	Policy              *policy.CancellationPolicy
+	Coverage            *coverage.Checker
+	ConfirmationSecret  string
+	ReschedulingSecret  string
+	DoctorDailyCapacity int // 0 means no limit
 }
 
 func (ac *AppointmentController) handleContextTimeout(ctx context.Context, w http.ResponseWriter) {
@@ -30,3 +40,67 @@ func (ac *AppointmentController) handleContextTimeout(ctx context.Context, w htt
 		// No context timeout, do nothing
 	}
 }
+
+// isPolicyOverride reports whether the request asks to bypass the cancellation policy or the coverage requirement. The gateway only forwards it for admins.
+func isPolicyOverride(r *http.Request) bool {
+	return r.URL.Query().Get(utils.QUERY_OVERRIDE) == "true"
+}
+
+// handlePolicyError responds to a request refused by the cancellation policy or for lack of coverage. It returns false if err is not a policy violation.
+func handlePolicyError(w http.ResponseWriter, err error, message string) bool {
+	if !errors.Is(err, policy.ErrBookingBlocked) && !errors.Is(err, policy.ErrLateCancellationLimit) && !errors.Is(err, coverage.ErrNotCovered) {
+		return false
+	}
+
+	log.Printf("[APPOINTMENT] %s: %v", message, err)
+	utils.RespondWithJSON(w, http.StatusForbidden, models.ResponseData{
+		Message: message + ". An admin override is required",
+		Error:   err.Error(),
+	})
+	return true
+}
+
+// handleBookingError responds to a booking refused because the doctor slot or a resource is taken, or the appointment type is unknown.
+// It returns false if err is none of these.
+func handleBookingError(w http.ResponseWriter, err error, message string) bool {
+	status := http.StatusConflict
+	switch {
+	case errors.Is(err, database.ErrDoctorFullyBooked):
+		message += ". The doctor is fully booked on that day"
+	case errors.Is(err, database.ErrResourceUnavailable):
+		message += ". A required room or equipment is not available on that day"
+	case errors.Is(err, database.ErrUnknownAppointmentType):
+		status = http.StatusUnprocessableEntity
+		message += ". Unknown appointment type"
+	default:
+		return false
+	}
+
+	log.Printf("[APPOINTMENT] %s: %v", message, err)
+	utils.RespondWithJSON(w, status, models.ResponseData{
+		Message: message,
+		Error:   err.Error(),
+	})
+	return true
+}
+
+// checkAppointmentType verifies that the type referenced by the appointment exists. It responds and returns false otherwise.
+func (ac *AppointmentController) checkAppointmentType(ctx context.Context, w http.ResponseWriter, appointment *models.Appointment, message string) bool {
+	if appointment.IDType == nil {
+		return true
+	}
+
+	if _, err := ac.DbConn.FetchAppointmentTypeByID(ctx, *appointment.IDType); err != nil {
+		if err == sql.ErrNoRows {
+			handleBookingError(w, fmt.Errorf("%w: %d", database.ErrUnknownAppointmentType, *appointment.IDType), message)
+			return false
+		}
+
+		errMsg := fmt.Sprintf("internal server error: %s", err)
+		log.Printf("[APPOINTMENT] Failed to fetch appointment type %d: %s", *appointment.IDType, errMsg)
+		utils.RespondWithJSON(w, http.StatusInternalServerError, models.ResponseData{Error: errMsg, Message: message})
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,64 @@
+package controllers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mihnea1711/POS_Project/services/programari/internal/models"
+	"github.com/mihnea1711/POS_Project/services/programari/pkg/utils"
+)
+
+// GetAppointmentCoverage tells the front desk whether the patient of an appointment is insured on its day
+func (aController *AppointmentController) GetAppointmentCoverage(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[APPOINTMENT] Attempting to retrieve the coverage of an appointment.")
+
+	vars := mux.Vars(r)
+	appointmentID, err := strconv.Atoi(vars[utils.FETCH_APPOINTMENT_BY_ID_PARAMETER])
+	if err != nil {
+		errMsg := fmt.Sprintf("Invalid appointment ID: %s", vars[utils.FETCH_APPOINTMENT_BY_ID_PARAMETER])
+		log.Printf("[APPOINTMENT] %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusBadRequest, models.ResponseData{Error: errMsg, Message: "Bad Request"})
+		return
+	}
+
+	// Ensure a database operation doesn't take longer than 5 seconds
+	ctx, cancel := context.WithTimeout(r.Context(), utils.DB_REQ_TIMEOUT_SEC_MULTIPLIER*time.Second)
+	defer cancel()
+
+	appointment, err := aController.DbConn.FetchAppointmentByID(ctx, appointmentID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			errMsg := fmt.Sprintf("No appointment found with ID: %d", appointmentID)
+			log.Printf("[APPOINTMENT] %s", errMsg)
+			utils.RespondWithJSON(w, http.StatusNotFound, models.ResponseData{Error: errMsg, Message: "Failed to fetch appointment coverage. Appointment not found"})
+			return
+		}
+
+		errMsg := fmt.Sprintf("internal server error: %s", err)
+		log.Printf("[APPOINTMENT] GetAppointmentCoverage: Failed to fetch appointment: %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusInternalServerError, models.ResponseData{Error: errMsg, Message: "Failed to fetch appointment coverage"})
+		return
+	}
+
+	coverage, err := aController.Coverage.Coverage(ctx, appointment.IDPatient, appointment.Date)
+	if err != nil {
+		errMsg := fmt.Sprintf("internal server error: %s", err)
+		log.Printf("[APPOINTMENT] GetAppointmentCoverage: Failed to fetch coverage: %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusInternalServerError, models.ResponseData{Error: errMsg, Message: "Failed to fetch appointment coverage"})
+		return
+	}
+
+	message := fmt.Sprintf("Patient %d is not insured on the day of appointment %d", appointment.IDPatient, appointmentID)
+	if coverage.Covered {
+		message = fmt.Sprintf("Patient %d is insured on the day of appointment %d", appointment.IDPatient, appointmentID)
+	}
+
+	log.Printf("[APPOINTMENT] %s", message)
+	utils.RespondWithJSON(w, http.StatusOK, models.ResponseData{Payload: coverage, Message: message})
+}
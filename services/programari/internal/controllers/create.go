@@ -2,12 +2,14 @@ package controllers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"time"
 
 	"github.com/go-sql-driver/mysql"
+	"github.com/mihnea1711/POS_Project/services/programari/internal/coverage"
 	"github.com/mihnea1711/POS_Project/services/programari/internal/models"
 	"github.com/mihnea1711/POS_Project/services/programari/pkg/utils"
 )
@@ -23,10 +25,39 @@ func (aController *AppointmentController) CreateAppointment(w http.ResponseWrite
 
 	aController.handleContextTimeout(ctx, w)
 
-	// Use aController.DbConn to save the appointment to the database
-	lastInsertID, err := aController.DbConn.SaveAppointment(ctx, appointment)
+	// Patients that keep missing appointments may only be booked with an admin override
+	if !isPolicyOverride(r) {
+		if err := aController.Policy.CheckBooking(ctx, appointment.IDPatient); err != nil {
+			if !handlePolicyError(w, err, "Failed to create appointment") {
+				handleDatabaseCreateError(w, err)
+			}
+			return
+		}
+	}
+
+	if !aController.checkAppointmentType(ctx, w, appointment, "Failed to create appointment") {
+		return
+	}
+
+	// The front desk learns the coverage of the patient on booking, uninsured patients need an override when coverage is required
+	patientCoverage, err := aController.Coverage.CheckBooking(ctx, appointment.IDPatient, appointment.Date)
+	if err != nil {
+		if errors.Is(err, coverage.ErrNotCovered) && isPolicyOverride(r) {
+			log.Printf("[APPOINTMENT] Booking uninsured patient %d with an admin override", appointment.IDPatient)
+		} else {
+			if !handlePolicyError(w, err, "Failed to create appointment") {
+				handleDatabaseCreateError(w, err)
+			}
+			return
+		}
+	}
+
+	// Use aController.DbConn to save the appointment to the database, the doctor slot and the resources are booked with it
+	lastInsertID, err := aController.DbConn.SaveAppointment(ctx, appointment, aController.DoctorDailyCapacity)
 	if err != nil {
-		handleDatabaseCreateError(w, err)
+		if !handleBookingError(w, err, "Failed to create appointment") {
+			handleDatabaseCreateError(w, err)
+		}
 		return
 	}
 
@@ -54,8 +85,9 @@ func (aController *AppointmentController) CreateAppointment(w http.ResponseWrite
 	// Use RespondWithJSON for success response
 	utils.RespondWithJSON(w, http.StatusCreated, models.ResponseData{
 		Message: "Appointment created successfully",
-		Payload: models.LastInsertedID{
+		Payload: models.AppointmentCreated{
 			LastInsertedID: lastInsertID,
+			Coverage:       patientCoverage,
 		},
 	})
 }
@@ -0,0 +1,47 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mihnea1711/POS_Project/services/programari/internal/models"
+	"github.com/mihnea1711/POS_Project/services/programari/pkg/utils"
+)
+
+// ErasePatientAppointments erases the appointment data of a patient whose personal data is being erased and reports
+// what was deleted or retained. The rabbit module calls it while committing an erasure, a repeated call succeeds.
+func (aController *AppointmentController) ErasePatientAppointments(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[APPOINTMENT] Attempting to erase patient appointments.")
+
+	patientIDStr := mux.Vars(r)[utils.ERASURE_PATIENT_ID_PARAMETER]
+	patientID, err := strconv.Atoi(patientIDStr)
+	if err != nil {
+		errMsg := fmt.Sprintf("Invalid patient ID: %s", patientIDStr)
+		log.Printf("[APPOINTMENT] %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusBadRequest, models.ResponseData{Error: errMsg, Message: "Invalid erasure request"})
+		return
+	}
+
+	// Ensure a database operation doesn't take longer than 5 seconds
+	ctx, cancel := context.WithTimeout(r.Context(), utils.DB_REQ_TIMEOUT_SEC_MULTIPLIER*time.Second)
+	defer cancel()
+
+	records, err := aController.DbConn.ErasePatientAppointments(ctx, patientID)
+	if err != nil {
+		errMsg := fmt.Sprintf("internal server error: %s", err)
+		log.Printf("[APPOINTMENT] ErasePatientAppointments: %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusInternalServerError, models.ResponseData{Error: errMsg, Message: "Failed to erase patient appointments"})
+		return
+	}
+
+	log.Printf("[APPOINTMENT] Successfully erased the appointments of patient %d", patientID)
+	utils.RespondWithJSON(w, http.StatusOK, models.ResponseData{
+		Payload: records,
+		Message: fmt.Sprintf("Appointments of patient %d erased", patientID),
+	})
+}
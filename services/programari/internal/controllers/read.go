@@ -140,3 +140,73 @@ func (aController *AppointmentController) GetAppointmentByID(w http.ResponseWrit
 	}
 	utils.RespondWithJSON(w, http.StatusOK, response)
 }
+
+// GetPatientStatusHistory lists the status changes of the appointments of the patient named by the patientID query,
+// the latest first. The changes are kept after their appointments are deleted.
+func (aController *AppointmentController) GetPatientStatusHistory(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[APPOINTMENT] Attempting to retrieve the status history of a patient.")
+
+	patientID, err := strconv.Atoi(r.URL.Query().Get(utils.QUERY_PATIENT_ID))
+	if err != nil || patientID <= 0 {
+		errMsg := fmt.Sprintf("bad request: a valid %s is required", utils.QUERY_PATIENT_ID)
+		log.Printf("[APPOINTMENT] GetPatientStatusHistory: %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusBadRequest, models.ResponseData{
+			Error:   errMsg,
+			Message: "Failed to fetch the status history",
+		})
+		return
+	}
+
+	// Extract the limit and page query parameters from the request
+	limit, page := utils.ExtractPaginationParams(r)
+
+	// Ensure a database operation doesn't take longer than 5 seconds
+	ctx, cancel := context.WithTimeout(r.Context(), utils.DB_REQ_TIMEOUT_SEC_MULTIPLIER*time.Second)
+	defer cancel()
+
+	changes, err := aController.DbConn.FetchPatientStatusHistory(ctx, patientID, page, limit)
+	if err != nil {
+		errMsg := fmt.Sprintf("internal server error: %s", err)
+		log.Printf("[APPOINTMENT] GetPatientStatusHistory: Failed to fetch the status history of patient %d: %s", patientID, errMsg)
+		utils.RespondWithJSON(w, http.StatusInternalServerError, models.ResponseData{
+			Error:   errMsg,
+			Message: "Failed to fetch the status history",
+		})
+		return
+	}
+
+	log.Printf("[APPOINTMENT] Successfully fetched %d status changes of patient %d", len(changes), patientID)
+	utils.RespondWithJSON(w, http.StatusOK, models.ResponseData{
+		Payload: changes,
+		Message: fmt.Sprintf("Successfully fetched %d status changes", len(changes)),
+	})
+}
+
+// GetPolicyOffenders lists the patients that reached the no-show threshold or exceeded the allowed late cancellations
+func (aController *AppointmentController) GetPolicyOffenders(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[APPOINTMENT] Attempting to retrieve cancellation policy offenders.")
+
+	// Extract the limit and page query parameters from the request
+	limit, page := utils.ExtractPaginationParams(r)
+
+	// Ensure a database operation doesn't take longer than 5 seconds
+	ctx, cancel := context.WithTimeout(r.Context(), utils.DB_REQ_TIMEOUT_SEC_MULTIPLIER*time.Second)
+	defer cancel()
+
+	offenders, err := aController.Policy.Offenders(ctx, page, limit)
+	if err != nil {
+		errMsg := fmt.Sprintf("internal server error: %s", err)
+		log.Printf("[APPOINTMENT] GetPolicyOffenders: Failed to fetch offenders: %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusInternalServerError, models.ResponseData{
+			Error:   errMsg,
+			Message: "Failed to fetch cancellation policy offenders",
+		})
+		return
+	}
+
+	log.Printf("[APPOINTMENT] Successfully fetched %d cancellation policy offenders", len(offenders))
+	utils.RespondWithJSON(w, http.StatusOK, models.ResponseData{
+		Payload: offenders,
+		Message: fmt.Sprintf("Successfully fetched %d cancellation policy offenders", len(offenders)),
+	})
+}
@@ -0,0 +1,37 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/mihnea1711/POS_Project/services/programari/internal/models"
+	"github.com/mihnea1711/POS_Project/services/programari/pkg/utils"
+)
+
+// ReassignPatientAppointments moves the appointments of a duplicate patient record to the surviving one.
+// The patient module calls it while merging two records, a repeated call moves nothing and still succeeds.
+func (aController *AppointmentController) ReassignPatientAppointments(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[APPOINTMENT] Attempting to reassign patient appointments.")
+	request := r.Context().Value(utils.DECODED_PATIENT_REASSIGNMENT).(*models.PatientReassignment)
+
+	// Ensure a database operation doesn't take longer than 5 seconds
+	ctx, cancel := context.WithTimeout(r.Context(), utils.DB_REQ_TIMEOUT_SEC_MULTIPLIER*time.Second)
+	defer cancel()
+
+	result, err := aController.DbConn.ReassignPatientAppointments(ctx, request.IDFromPatient, request.IDToPatient)
+	if err != nil {
+		errMsg := fmt.Sprintf("internal server error: %s", err)
+		log.Printf("[APPOINTMENT] ReassignPatientAppointments: %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusInternalServerError, models.ResponseData{Error: errMsg, Message: "Failed to reassign patient appointments"})
+		return
+	}
+
+	log.Printf("[APPOINTMENT] Successfully moved %d appointments of patient %d to patient %d", result.Moved, request.IDFromPatient, request.IDToPatient)
+	utils.RespondWithJSON(w, http.StatusOK, models.ResponseData{
+		Payload: result,
+		Message: fmt.Sprintf("Successfully moved %d appointments of patient %d to patient %d", result.Moved, request.IDFromPatient, request.IDToPatient),
+	})
+}
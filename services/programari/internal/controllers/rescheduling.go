@@ -0,0 +1,188 @@
+package controllers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mihnea1711/POS_Project/services/programari/internal/models"
+	"github.com/mihnea1711/POS_Project/services/programari/pkg/utils"
+)
+
+// CreateReschedulingJob starts rescheduling the future appointments of an unavailable doctor
+func (aController *AppointmentController) CreateReschedulingJob(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[APPOINTMENT] Attempting to create a rescheduling job.")
+	request := r.Context().Value(utils.DECODED_RESCHEDULING_JOB).(*models.ReschedulingRequest)
+
+	// Ensure a database operation doesn't take longer than 5 seconds
+	ctx, cancel := context.WithTimeout(r.Context(), utils.DB_REQ_TIMEOUT_SEC_MULTIPLIER*time.Second)
+	defer cancel()
+
+	// A doctor has at most one open job, a second deactivation notice is not an error
+	openJob, err := aController.DbConn.FetchOpenReschedulingJob(ctx, request.IDDoctor)
+	if err == nil {
+		errMsg := fmt.Sprintf("Doctor %d already has the open rescheduling job %d", request.IDDoctor, openJob.IDJob)
+		log.Printf("[APPOINTMENT] %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusConflict, models.ResponseData{Error: errMsg, Message: "Rescheduling job already in progress", Payload: openJob})
+		return
+	}
+	if err != sql.ErrNoRows {
+		handleDatabaseCreateError(w, err)
+		return
+	}
+
+	lastInsertID, err := aController.DbConn.SaveReschedulingJob(ctx, request)
+	if err != nil {
+		handleDatabaseCreateError(w, err)
+		return
+	}
+
+	newJobURI := fmt.Sprintf("%s/%d", utils.FETCH_ALL_RESCHEDULING_JOBS_ENDPOINT, lastInsertID)
+	log.Printf("[APPOINTMENT] Successfully created rescheduling job %d", lastInsertID)
+
+	w.Header().Set("Location", newJobURI)
+	utils.RespondWithJSON(w, http.StatusCreated, models.ResponseData{
+		Message: "Rescheduling job created successfully",
+		Payload: models.LastInsertedID{
+			LastInsertedID: lastInsertID,
+		},
+	})
+}
+
+// GetReschedulingJobs lists the rescheduling jobs together with their progress
+func (aController *AppointmentController) GetReschedulingJobs(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[APPOINTMENT] Attempting to retrieve rescheduling jobs.")
+
+	// Extract the limit and page query parameters from the request
+	limit, page := utils.ExtractPaginationParams(r)
+
+	// Ensure a database operation doesn't take longer than 5 seconds
+	ctx, cancel := context.WithTimeout(r.Context(), utils.DB_REQ_TIMEOUT_SEC_MULTIPLIER*time.Second)
+	defer cancel()
+
+	jobs, err := aController.DbConn.FetchReschedulingJobs(ctx, page, limit)
+	if err != nil {
+		errMsg := fmt.Sprintf("internal server error: %s", err)
+		log.Printf("[APPOINTMENT] GetReschedulingJobs: Failed to fetch jobs: %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusInternalServerError, models.ResponseData{Error: errMsg, Message: "Failed to fetch rescheduling jobs"})
+		return
+	}
+
+	log.Printf("[APPOINTMENT] Successfully fetched %d rescheduling jobs", len(jobs))
+	utils.RespondWithJSON(w, http.StatusOK, models.ResponseData{
+		Payload: jobs,
+		Message: fmt.Sprintf("Successfully fetched %d rescheduling jobs", len(jobs)),
+	})
+}
+
+// GetReschedulingJobByID retrieves a rescheduling job together with its proposals
+func (aController *AppointmentController) GetReschedulingJobByID(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[APPOINTMENT] Attempting to retrieve a rescheduling job by ID.")
+
+	vars := mux.Vars(r)
+	jobID, err := strconv.Atoi(vars[utils.RESCHEDULING_JOB_ID_PARAMETER])
+	if err != nil {
+		errMsg := fmt.Sprintf("Invalid rescheduling job ID: %s", vars[utils.RESCHEDULING_JOB_ID_PARAMETER])
+		log.Printf("[APPOINTMENT] %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusBadRequest, models.ResponseData{Error: errMsg, Message: "Invalid rescheduling job ID"})
+		return
+	}
+
+	// Ensure a database operation doesn't take longer than 5 seconds
+	ctx, cancel := context.WithTimeout(r.Context(), utils.DB_REQ_TIMEOUT_SEC_MULTIPLIER*time.Second)
+	defer cancel()
+
+	job, err := aController.DbConn.FetchReschedulingJobByID(ctx, jobID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			errMsg := fmt.Sprintf("No rescheduling job found with ID: %d", jobID)
+			log.Printf("[APPOINTMENT] %s", errMsg)
+			utils.RespondWithJSON(w, http.StatusNotFound, models.ResponseData{Error: errMsg, Message: "Rescheduling job not found"})
+			return
+		}
+
+		errMsg := fmt.Sprintf("internal server error: %s", err)
+		log.Printf("[APPOINTMENT] GetReschedulingJobByID: Failed to fetch job: %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusInternalServerError, models.ResponseData{Error: errMsg, Message: "Failed to fetch rescheduling job"})
+		return
+	}
+
+	proposals, err := aController.DbConn.FetchReschedulingProposals(ctx, jobID)
+	if err != nil {
+		errMsg := fmt.Sprintf("internal server error: %s", err)
+		log.Printf("[APPOINTMENT] GetReschedulingJobByID: Failed to fetch proposals: %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusInternalServerError, models.ResponseData{Error: errMsg, Message: "Failed to fetch rescheduling proposals"})
+		return
+	}
+
+	log.Printf("[APPOINTMENT] Successfully fetched rescheduling job %d", jobID)
+	utils.RespondWithJSON(w, http.StatusOK, models.ResponseData{
+		Payload: models.ReschedulingJobDetails{Job: *job, Proposals: proposals},
+		Message: fmt.Sprintf("Successfully fetched rescheduling job %d", jobID),
+	})
+}
+
+// AcceptReschedulingProposal moves the appointment to the proposed slot using the tokenized link sent to the patient
+func (aController *AppointmentController) AcceptReschedulingProposal(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[APPOINTMENT] Attempting to accept a rescheduling proposal.")
+	aController.answerReschedulingProposal(w, r, "accept", aController.DbConn.AcceptReschedulingProposal)
+}
+
+// DeclineReschedulingProposal cancels the appointment using the tokenized link sent to the patient
+func (aController *AppointmentController) DeclineReschedulingProposal(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[APPOINTMENT] Attempting to decline a rescheduling proposal.")
+	aController.answerReschedulingProposal(w, r, "decline", aController.DbConn.DeclineReschedulingProposal)
+}
+
+func (aController *AppointmentController) answerReschedulingProposal(w http.ResponseWriter, r *http.Request, action string, answer func(ctx context.Context, proposalID int, now time.Time) (int, error)) {
+	vars := mux.Vars(r)
+	proposalID, err := strconv.Atoi(vars[utils.RESCHEDULING_PROPOSAL_ID_PARAMETER])
+	if err != nil {
+		errMsg := fmt.Sprintf("Invalid proposal ID: %s", vars[utils.RESCHEDULING_PROPOSAL_ID_PARAMETER])
+		log.Printf("[APPOINTMENT] %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusBadRequest, models.ResponseData{Error: errMsg, Message: "Invalid rescheduling proposal request"})
+		return
+	}
+
+	// Check the token from the proposal link
+	token := r.URL.Query().Get(utils.QUERY_TOKEN)
+	if token == "" || !utils.ValidateProposalToken(aController.ReschedulingSecret, proposalID, token) {
+		errMsg := fmt.Sprintf("Invalid token for rescheduling proposal %d", proposalID)
+		log.Printf("[APPOINTMENT] %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusForbidden, models.ResponseData{Error: errMsg, Message: fmt.Sprintf("Failed to %s rescheduling proposal", action)})
+		return
+	}
+
+	// Ensure a database operation doesn't take longer than 5 seconds
+	ctx, cancel := context.WithTimeout(r.Context(), utils.DB_REQ_TIMEOUT_SEC_MULTIPLIER*time.Second)
+	defer cancel()
+
+	rowsAffected, err := answer(ctx, proposalID, time.Now())
+	if err != nil {
+		if !handleBookingError(w, err, fmt.Sprintf("Failed to %s rescheduling proposal", action)) {
+			handleDatabaseUpdateError(w, err)
+		}
+		return
+	}
+
+	// Only pending proposals can be answered
+	if rowsAffected == 0 {
+		errMsg := fmt.Sprintf("Rescheduling proposal %d is no longer pending", proposalID)
+		log.Printf("[APPOINTMENT] %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusConflict, models.ResponseData{Error: errMsg, Message: "Rescheduling proposal already answered or expired"})
+		return
+	}
+
+	log.Printf("[APPOINTMENT] Successfully answered rescheduling proposal %d (%s)", proposalID, action)
+	utils.RespondWithJSON(w, http.StatusOK, models.ResponseData{
+		Message: fmt.Sprintf("Rescheduling proposal %d answered successfully (%s)", proposalID, action),
+		Payload: models.RowsAffected{
+			RowsAffected: rowsAffected,
+		},
+	})
+}
@@ -0,0 +1,209 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/gorilla/mux"
+	"github.com/mihnea1711/POS_Project/services/programari/internal/models"
+	"github.com/mihnea1711/POS_Project/services/programari/pkg/utils"
+)
+
+// CreateResource adds a room or a piece of equipment. New resources are active.
+func (aController *AppointmentController) CreateResource(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[APPOINTMENT] Attempting to create a resource.")
+	resource := r.Context().Value(utils.DECODED_RESOURCE).(*models.Resource)
+	resource.IsActive = true
+
+	// Ensure a database operation doesn't take longer than 5 seconds
+	ctx, cancel := context.WithTimeout(r.Context(), utils.DB_REQ_TIMEOUT_SEC_MULTIPLIER*time.Second)
+	defer cancel()
+
+	lastInsertID, err := aController.DbConn.SaveResource(ctx, resource)
+	if err != nil {
+		handleCatalogError(w, err, "Failed to create resource")
+		return
+	}
+
+	log.Printf("[APPOINTMENT] Successfully created resource %d", lastInsertID)
+
+	w.Header().Set("Location", fmt.Sprintf("%s/%d", utils.FETCH_ALL_RESOURCES_ENDPOINT, lastInsertID))
+	utils.RespondWithJSON(w, http.StatusCreated, models.ResponseData{
+		Message: "Resource created successfully",
+		Payload: models.LastInsertedID{
+			LastInsertedID: lastInsertID,
+		},
+	})
+}
+
+// GetResources lists the rooms and equipment, optionally filtered by category and kind
+func (aController *AppointmentController) GetResources(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[APPOINTMENT] Attempting to retrieve resources.")
+
+	filters := make(map[string]interface{})
+	if category := r.URL.Query().Get(utils.QUERY_CATEGORY); category != "" {
+		filters[utils.ColumnCategory] = category
+	}
+	if kind := r.URL.Query().Get(utils.QUERY_KIND); kind != "" {
+		filters[utils.ColumnKind] = kind
+	}
+
+	// Ensure a database operation doesn't take longer than 5 seconds
+	ctx, cancel := context.WithTimeout(r.Context(), utils.DB_REQ_TIMEOUT_SEC_MULTIPLIER*time.Second)
+	defer cancel()
+
+	resources, err := aController.DbConn.FetchResources(ctx, filters)
+	if err != nil {
+		errMsg := fmt.Sprintf("internal server error: %s", err)
+		log.Printf("[APPOINTMENT] GetResources: Failed to fetch resources: %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusInternalServerError, models.ResponseData{Error: errMsg, Message: "Failed to fetch resources"})
+		return
+	}
+
+	log.Printf("[APPOINTMENT] Successfully fetched %d resources", len(resources))
+	utils.RespondWithJSON(w, http.StatusOK, models.ResponseData{
+		Payload: resources,
+		Message: fmt.Sprintf("Successfully fetched %d resources", len(resources)),
+	})
+}
+
+// UpdateResourceByID changes a resource. Deactivating it keeps the reservations already made.
+func (aController *AppointmentController) UpdateResourceByID(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[APPOINTMENT] Attempting to update a resource.")
+	resource := r.Context().Value(utils.DECODED_RESOURCE).(*models.Resource)
+
+	vars := mux.Vars(r)
+	resourceID, err := strconv.Atoi(vars[utils.RESOURCE_ID_PARAMETER])
+	if err != nil {
+		errMsg := fmt.Sprintf("Invalid resource ID: %s", vars[utils.RESOURCE_ID_PARAMETER])
+		log.Printf("[APPOINTMENT] %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusBadRequest, models.ResponseData{Error: errMsg, Message: "Invalid resource update request"})
+		return
+	}
+	resource.IDResource = resourceID
+
+	// Ensure a database operation doesn't take longer than 5 seconds
+	ctx, cancel := context.WithTimeout(r.Context(), utils.DB_REQ_TIMEOUT_SEC_MULTIPLIER*time.Second)
+	defer cancel()
+
+	rowsAffected, err := aController.DbConn.UpdateResourceByID(ctx, resource)
+	if err != nil {
+		handleCatalogError(w, err, "Failed to update resource")
+		return
+	}
+
+	if rowsAffected == 0 {
+		errMsg := fmt.Sprintf("No resource found with ID: %d", resourceID)
+		log.Printf("[APPOINTMENT] %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusNotFound, models.ResponseData{Error: errMsg, Message: "Resource not found"})
+		return
+	}
+
+	log.Printf("[APPOINTMENT] Successfully updated resource %d", resourceID)
+	utils.RespondWithJSON(w, http.StatusOK, models.ResponseData{
+		Message: fmt.Sprintf("Resource with ID %d updated successfully", resourceID),
+		Payload: models.RowsAffected{
+			RowsAffected: rowsAffected,
+		},
+	})
+}
+
+// CreateAppointmentType adds an appointment type together with the resources it requires
+func (aController *AppointmentController) CreateAppointmentType(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[APPOINTMENT] Attempting to create an appointment type.")
+	appointmentType := r.Context().Value(utils.DECODED_APPOINTMENT_TYPE).(*models.AppointmentType)
+
+	// Ensure a database operation doesn't take longer than 5 seconds
+	ctx, cancel := context.WithTimeout(r.Context(), utils.DB_REQ_TIMEOUT_SEC_MULTIPLIER*time.Second)
+	defer cancel()
+
+	lastInsertID, err := aController.DbConn.SaveAppointmentType(ctx, appointmentType)
+	if err != nil {
+		handleCatalogError(w, err, "Failed to create appointment type")
+		return
+	}
+
+	log.Printf("[APPOINTMENT] Successfully created appointment type %d", lastInsertID)
+
+	w.Header().Set("Location", fmt.Sprintf("%s/%d", utils.FETCH_ALL_APPOINTMENT_TYPES_ENDPOINT, lastInsertID))
+	utils.RespondWithJSON(w, http.StatusCreated, models.ResponseData{
+		Message: "Appointment type created successfully",
+		Payload: models.LastInsertedID{
+			LastInsertedID: lastInsertID,
+		},
+	})
+}
+
+// GetAppointmentTypes lists the appointment types and the resources they require
+func (aController *AppointmentController) GetAppointmentTypes(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[APPOINTMENT] Attempting to retrieve appointment types.")
+
+	// Ensure a database operation doesn't take longer than 5 seconds
+	ctx, cancel := context.WithTimeout(r.Context(), utils.DB_REQ_TIMEOUT_SEC_MULTIPLIER*time.Second)
+	defer cancel()
+
+	types, err := aController.DbConn.FetchAppointmentTypes(ctx)
+	if err != nil {
+		errMsg := fmt.Sprintf("internal server error: %s", err)
+		log.Printf("[APPOINTMENT] GetAppointmentTypes: Failed to fetch appointment types: %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusInternalServerError, models.ResponseData{Error: errMsg, Message: "Failed to fetch appointment types"})
+		return
+	}
+
+	log.Printf("[APPOINTMENT] Successfully fetched %d appointment types", len(types))
+	utils.RespondWithJSON(w, http.StatusOK, models.ResponseData{
+		Payload: types,
+		Message: fmt.Sprintf("Successfully fetched %d appointment types", len(types)),
+	})
+}
+
+// GetAppointmentResources lists the rooms and equipment reserved for an appointment
+func (aController *AppointmentController) GetAppointmentResources(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[APPOINTMENT] Attempting to retrieve the resources of an appointment.")
+
+	vars := mux.Vars(r)
+	appointmentID, err := strconv.Atoi(vars[utils.FETCH_APPOINTMENT_BY_ID_PARAMETER])
+	if err != nil {
+		errMsg := fmt.Sprintf("Invalid appointment ID: %s", vars[utils.FETCH_APPOINTMENT_BY_ID_PARAMETER])
+		log.Printf("[APPOINTMENT] %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusBadRequest, models.ResponseData{Error: errMsg, Message: "Bad Request"})
+		return
+	}
+
+	// Ensure a database operation doesn't take longer than 5 seconds
+	ctx, cancel := context.WithTimeout(r.Context(), utils.DB_REQ_TIMEOUT_SEC_MULTIPLIER*time.Second)
+	defer cancel()
+
+	resources, err := aController.DbConn.FetchAppointmentResources(ctx, appointmentID)
+	if err != nil {
+		errMsg := fmt.Sprintf("internal server error: %s", err)
+		log.Printf("[APPOINTMENT] GetAppointmentResources: Failed to fetch resources: %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusInternalServerError, models.ResponseData{Error: errMsg, Message: "Failed to fetch appointment resources"})
+		return
+	}
+
+	log.Printf("[APPOINTMENT] Successfully fetched %d resources of appointment %d", len(resources), appointmentID)
+	utils.RespondWithJSON(w, http.StatusOK, models.ResponseData{
+		Payload: resources,
+		Message: fmt.Sprintf("Successfully fetched %d resources of appointment %d", len(resources), appointmentID),
+	})
+}
+
+// handleCatalogError responds to a failed write of a resource or an appointment type
+func handleCatalogError(w http.ResponseWriter, err error, message string) {
+	if mysqlErr, ok := err.(*mysql.MySQLError); ok && mysqlErr.Number == utils.MySQLDuplicateEntryErrorCode {
+		errMsg := fmt.Sprintf("Conflict error: %s", mysqlErr.Message)
+		log.Printf("[APPOINTMENT] %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusConflict, models.ResponseData{Error: errMsg, Message: message + ". Name already in use"})
+		return
+	}
+
+	errMsg := fmt.Sprintf("internal server error: %s", err)
+	log.Printf("[APPOINTMENT] %s: %s", message, errMsg)
+	utils.RespondWithJSON(w, http.StatusInternalServerError, models.ResponseData{Error: errMsg, Message: message})
+}
@@ -3,6 +3,7 @@ package controllers
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -11,6 +12,7 @@ import (
 
 	"github.com/go-sql-driver/mysql"
 	"github.com/gorilla/mux"
+	"github.com/mihnea1711/POS_Project/services/programari/internal/database"
 	"github.com/mihnea1711/POS_Project/services/programari/internal/models"
 	"github.com/mihnea1711/POS_Project/services/programari/pkg/utils"
 )
@@ -40,10 +42,23 @@ func (aController *AppointmentController) UpdateAppointmentByID(w http.ResponseW
 	ctx, cancel := context.WithTimeout(r.Context(), utils.DB_REQ_TIMEOUT_SEC_MULTIPLIER*time.Second)
 	defer cancel()
 
+	// Cancellations inside the notice window are limited by the cancellation policy, those made with an admin
+	// override are exempt and not counted against the patient
+	var cancellation *models.CancellationLimit
+	if appointment.Status == utils.StatusCanceled {
+		cancellation = aController.Policy.CancellationLimit(time.Now(), isPolicyOverride(r))
+	}
+
+	if !aController.checkAppointmentType(ctx, w, appointment, "Failed to update appointment") {
+		return
+	}
+
 	// Use aController.DbConn to update the appointment by ID in the database
-	rowsAffected, err := aController.DbConn.UpdateAppointmentByID(ctx, appointment)
+	rowsAffected, err := aController.DbConn.UpdateAppointmentByID(ctx, appointment, aController.DoctorDailyCapacity, cancellation)
 	if err != nil {
-		handleDatabaseUpdateError(w, err)
+		if !handlePolicyError(w, err, "Failed to cancel appointment") && !handleBookingError(w, err, "Failed to update appointment") {
+			handleDatabaseUpdateError(w, err)
+		}
 		return
 	}
 
@@ -91,3 +106,89 @@ func handleDatabaseUpdateError(w http.ResponseWriter, err error) {
 	// Create an error response using ResponseData
 	utils.RespondWithJSON(w, http.StatusInternalServerError, models.ResponseData{Error: errMsg, Message: "Internal database server error"})
 }
+
+// ConfirmAppointment confirms a scheduled appointment using the tokenized link sent in the reminder
+func (aController *AppointmentController) ConfirmAppointment(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[APPOINTMENT] Attempting to confirm an appointment.")
+
+	vars := mux.Vars(r)
+	appointmentID, err := strconv.Atoi(vars[utils.CONFIRM_APPOINTMENT_PARAMETER])
+	if err != nil {
+		errMsg := fmt.Sprintf("Invalid appointment ID: %s", vars[utils.CONFIRM_APPOINTMENT_PARAMETER])
+		log.Printf("[APPOINTMENT] %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusBadRequest, models.ResponseData{Error: errMsg, Message: "Invalid appointment confirmation request"})
+		return
+	}
+
+	// Check the token from the confirmation link
+	token := r.URL.Query().Get(utils.QUERY_TOKEN)
+	if token == "" || !utils.ValidateConfirmationToken(aController.ConfirmationSecret, appointmentID, token) {
+		errMsg := fmt.Sprintf("Invalid confirmation token for appointment %d", appointmentID)
+		log.Printf("[APPOINTMENT] %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusForbidden, models.ResponseData{Error: errMsg, Message: "Failed to confirm appointment"})
+		return
+	}
+
+	// Ensure a database operation doesn't take longer than 5 seconds
+	ctx, cancel := context.WithTimeout(r.Context(), utils.DB_REQ_TIMEOUT_SEC_MULTIPLIER*time.Second)
+	defer cancel()
+
+	rowsAffected, err := aController.DbConn.ConfirmAppointmentByID(ctx, appointmentID)
+	if err != nil {
+		handleDatabaseUpdateError(w, err)
+		return
+	}
+
+	// Only scheduled appointments can be confirmed
+	if rowsAffected == 0 {
+		errMsg := fmt.Sprintf("No scheduled appointment found with ID: %d", appointmentID)
+		log.Printf("[APPOINTMENT] %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusConflict, models.ResponseData{Error: errMsg, Message: "Appointment not found or no longer scheduled"})
+		return
+	}
+
+	log.Printf("[APPOINTMENT] Successfully confirmed appointment %d", appointmentID)
+	utils.RespondWithJSON(w, http.StatusOK, models.ResponseData{
+		Message: fmt.Sprintf("Appointment with ID %d confirmed successfully", appointmentID),
+		Payload: models.RowsAffected{
+			RowsAffected: rowsAffected,
+		},
+	})
+}
+
+// HonorAppointment records that the visit of an appointment took place, when a consultation is opened for it.
+// Honoring an honored appointment succeeds again, a canceled or missed appointment is a conflict.
+func (aController *AppointmentController) HonorAppointment(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[APPOINTMENT] Attempting to honor an appointment.")
+
+	vars := mux.Vars(r)
+	appointmentID, err := strconv.Atoi(vars[utils.HONOR_APPOINTMENT_PARAMETER])
+	if err != nil {
+		errMsg := fmt.Sprintf("Invalid appointment ID: %s", vars[utils.HONOR_APPOINTMENT_PARAMETER])
+		log.Printf("[APPOINTMENT] %s", errMsg)
+		utils.RespondWithJSON(w, http.StatusBadRequest, models.ResponseData{Error: errMsg, Message: "Invalid appointment honor request"})
+		return
+	}
+
+	// Ensure a database operation doesn't take longer than 5 seconds
+	ctx, cancel := context.WithTimeout(r.Context(), utils.DB_REQ_TIMEOUT_SEC_MULTIPLIER*time.Second)
+	defer cancel()
+
+	appointment, err := aController.DbConn.HonorAppointmentByID(ctx, appointmentID)
+	if err != nil {
+		if errors.Is(err, database.ErrAppointmentNotHonorable) {
+			errMsg := fmt.Sprintf("Appointment %d cannot be honored: %s", appointmentID, err)
+			log.Printf("[APPOINTMENT] %s", errMsg)
+			utils.RespondWithJSON(w, http.StatusConflict, models.ResponseData{Error: errMsg, Message: "Failed to honor appointment"})
+			return
+		}
+		handleDatabaseUpdateError(w, err)
+		return
+	}
+
+	log.Printf("[APPOINTMENT] Successfully honored appointment %d", appointmentID)
+	utils.RespondWithJSON(w, http.StatusOK, models.ResponseData{
+		Message: fmt.Sprintf("Appointment with ID %d honored successfully", appointmentID),
+		Payload: appointment,
+	})
+}
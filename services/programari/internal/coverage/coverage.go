@@ -0,0 +1,61 @@
+package coverage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/mihnea1711/POS_Project/services/programari/internal/database"
+	"github.com/mihnea1711/POS_Project/services/programari/internal/models"
+	"github.com/mihnea1711/POS_Project/services/programari/pkg/config"
+	"github.com/mihnea1711/POS_Project/services/programari/pkg/utils"
+)
+
+// ErrNotCovered is returned when coverage is required and the patient is not insured on the day of the appointment
+var ErrNotCovered = errors.New("patient is not insured on the day of the appointment")
+
+// Checker tells whether a patient is insured on the day of an appointment, so the front desk knows before the visit
+type Checker struct {
+	dbConn database.Database
+	config config.CoverageConfig
+}
+
+func NewCoverageChecker(dbConn database.Database, coverageConfig config.CoverageConfig) *Checker {
+	return &Checker{
+		dbConn: dbConn,
+		config: coverageConfig,
+	}
+}
+
+// Enabled reports whether bookings are checked for coverage
+func (c *Checker) Enabled() bool {
+	return c.config.Enabled
+}
+
+// Coverage returns the coverage of the patient on the day of the appointment
+func (c *Checker) Coverage(ctx context.Context, patientID int, date time.Time) (*models.Coverage, error) {
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	return c.dbConn.FetchPatientCoverage(ctx, patientID, day)
+}
+
+// CheckBooking returns the coverage of the patient for a new appointment, or nil when the check is disabled.
+// When coverage is required an uninsured patient is refused with ErrNotCovered, the coverage is returned along.
+func (c *Checker) CheckBooking(ctx context.Context, patientID int, date time.Time) (*models.Coverage, error) {
+	if !c.config.Enabled {
+		return nil, nil
+	}
+
+	coverage, err := c.Coverage(ctx, patientID, date)
+	if err != nil {
+		return nil, err
+	}
+
+	if !coverage.Covered && c.config.Required {
+		log.Printf("[APPOINTMENT] Booking refused for patient %d: not insured on %s", patientID, date.Format(utils.TIME_PARSE_SYNTAX))
+		return coverage, fmt.Errorf("%w: %s", ErrNotCovered, date.Format(utils.TIME_PARSE_SYNTAX))
+	}
+
+	return coverage, nil
+}
@@ -0,0 +1,20 @@
+package database
+
+import "errors"
+
+var (
+	// ErrDoctorFullyBooked is returned when the doctor has no free slot left on the day of the appointment
+	ErrDoctorFullyBooked = errors.New("doctor has no free slot on the requested day")
+
+	// ErrResourceUnavailable is returned when a resource required by the appointment type is booked out on the day
+	ErrResourceUnavailable = errors.New("no free resource on the requested day")
+
+	// ErrUnknownAppointmentType is returned when the appointment references a type that does not exist
+	ErrUnknownAppointmentType = errors.New("unknown appointment type")
+
+	// ErrLateCancellationLimit is returned when a patient cancels inside the notice window after using up the allowed late cancellations
+	ErrLateCancellationLimit = errors.New("patient exceeded the allowed late cancellations")
+
+	// ErrAppointmentNotHonorable is returned when a visit is recorded for an appointment that was canceled or missed
+	ErrAppointmentNotHonorable = errors.New("only scheduled or confirmed appointments can be honored")
+)
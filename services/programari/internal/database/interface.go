@@ -2,19 +2,66 @@ package database
 
 import (
 	"context"
+	"time"
 
 	"github.com/mihnea1711/POS_Project/services/programari/internal/models"
 )
 
 type Database interface {
-	SaveAppointment(ctx context.Context, programare *models.Appointment) (int, error)
+	SaveAppointment(ctx context.Context, programare *models.Appointment, doctorCapacity int) (int, error)
 
 	FetchAppointmentByID(ctx context.Context, appointmentID int) (*models.Appointment, error)
 	FetchAppointments(ctx context.Context, filters map[string]interface{}, page, limit int) ([]models.Appointment, error)
 
-	UpdateAppointmentByID(ctx context.Context, programare *models.Appointment) (int, error)
+	UpdateAppointmentByID(ctx context.Context, programare *models.Appointment, doctorCapacity int, cancellation *models.CancellationLimit) (int, error)
 	DeleteAppointmentByID(ctx context.Context, appointmentID int) (int, error)
 
+	ConfirmAppointmentByID(ctx context.Context, appointmentID int) (int, error)
+	HonorAppointmentByID(ctx context.Context, appointmentID int) (*models.Appointment, error)
+
+	FetchReminderTargets(ctx context.Context, from, to time.Time) ([]models.ReminderTarget, error)
+	ClaimReminder(ctx context.Context, appointmentID, offsetMinutes int, channel string) (bool, error)
+	ReleaseReminder(ctx context.Context, appointmentID, offsetMinutes int, channel string) error
+
+	FetchPatientPolicyStats(ctx context.Context, patientID int, noShowSince, lateSince time.Time, lateOffsetHours int) (*models.PolicyStats, error)
+	FetchPolicyOffenders(ctx context.Context, noShowSince, lateSince time.Time, lateOffsetHours, noShowThreshold, maxLateCancellations, page, limit int) ([]models.PolicyStats, error)
+	FetchPatientStatusHistory(ctx context.Context, patientID, page, limit int) ([]models.StatusChange, error)
+
+	SaveReschedulingJob(ctx context.Context, request *models.ReschedulingRequest) (int, error)
+	FetchOpenReschedulingJob(ctx context.Context, doctorID int) (*models.ReschedulingJob, error)
+	FetchReschedulingJobs(ctx context.Context, page, limit int) ([]models.ReschedulingJob, error)
+	FetchReschedulingJobByID(ctx context.Context, jobID int) (*models.ReschedulingJob, error)
+	FetchRunnableReschedulingJobs(ctx context.Context, createdBefore time.Time) ([]models.ReschedulingJob, error)
+	UpdateReschedulingJobProgress(ctx context.Context, jobID, total, processed int) error
+	SetReschedulingJobStatus(ctx context.Context, jobID int, status models.JobStatus) error
+	CompleteReschedulingJobs(ctx context.Context) (int, error)
+
+	IsDoctorActive(ctx context.Context, doctorID int) (bool, error)
+	FetchReplacementDoctors(ctx context.Context, doctorID int) ([]int, error)
+	FetchDoctorDailyLoad(ctx context.Context, doctorIDs []int, from, to time.Time) ([]models.DoctorDayLoad, error)
+	FetchReschedulingCandidates(ctx context.Context, jobID, doctorID int, from time.Time, until *time.Time) ([]models.ReminderTarget, error)
+
+	FetchReschedulingProposals(ctx context.Context, jobID int) ([]models.ReschedulingProposal, error)
+	SaveReschedulingProposal(ctx context.Context, proposal *models.ReschedulingProposal) (int, error)
+	AcceptReschedulingProposal(ctx context.Context, proposalID int, now time.Time) (int, error)
+	DeclineReschedulingProposal(ctx context.Context, proposalID int, now time.Time) (int, error)
+	ExpireReschedulingProposals(ctx context.Context, now time.Time) ([]models.ReminderTarget, error)
+
+	SaveResource(ctx context.Context, resource *models.Resource) (int, error)
+	FetchResources(ctx context.Context, filters map[string]interface{}) ([]models.Resource, error)
+	UpdateResourceByID(ctx context.Context, resource *models.Resource) (int, error)
+	FetchAppointmentResources(ctx context.Context, appointmentID int) ([]models.Resource, error)
+	FetchResourceDailyLoad(ctx context.Context, resourceIDs []int, from, to time.Time) ([]models.ResourceDayLoad, error)
+
+	SaveAppointmentType(ctx context.Context, appointmentType *models.AppointmentType) (int, error)
+	FetchAppointmentTypes(ctx context.Context) ([]models.AppointmentType, error)
+	FetchAppointmentTypeByID(ctx context.Context, typeID int) (*models.AppointmentType, error)
+
+	ReassignPatientAppointments(ctx context.Context, fromPatientID, toPatientID int) (*models.ReassignmentResult, error)
+	ErasePatientAppointments(ctx context.Context, patientID int) ([]models.ErasureRecord, error)
+
+	FetchPatientCoverage(ctx context.Context, patientID int, day time.Time) (*models.Coverage, error)
+
 	// add more
 
 	Close() error
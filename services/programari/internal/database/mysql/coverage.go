@@ -0,0 +1,64 @@
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/mihnea1711/POS_Project/services/programari/internal/models"
+	"github.com/mihnea1711/POS_Project/services/programari/pkg/utils"
+)
+
+// FetchPatientCoverage reads the insurance policies of a patient kept by the patient module. The patient is covered
+// on a day by a policy in force on that day which the payer did not reject.
+func (db *MySQLDatabase) FetchPatientCoverage(ctx context.Context, patientID int, day time.Time) (*models.Coverage, error) {
+	query := fmt.Sprintf("SELECT DISTINCT %s, %s FROM %s WHERE %s = ? AND %s <= ? AND (%s IS NULL OR %s >= ?) AND %s <> ?",
+		utils.ColumnPayerType,
+		utils.ColumnVerificationStatus,
+		utils.InsurancePolicyTableName,
+		utils.ColumnIDPatient,
+		utils.ColumnValidFrom,
+		utils.ColumnValidUntil,
+		utils.ColumnValidUntil,
+		utils.ColumnVerificationStatus,
+	)
+
+	date := day.Format(utils.TIME_PARSE_SYNTAX)
+	rows, err := db.QueryContext(ctx, query, patientID, date, date, utils.VerificationStatusRejected)
+	if err != nil {
+		log.Printf("[APPOINTMENT] FetchPatientCoverage: Failed to query database: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	coverage := &models.Coverage{
+		IDPatient:  patientID,
+		Date:       day,
+		PayerTypes: []string{},
+	}
+	seen := make(map[string]bool)
+	for rows.Next() {
+		var payerType, status string
+		if err := rows.Scan(&payerType, &status); err != nil {
+			log.Printf("[APPOINTMENT] Error scanning coverage row: %v", err)
+			return nil, err
+		}
+
+		coverage.Covered = true
+		if status == utils.VerificationStatusVerified {
+			coverage.Verified = true
+		}
+		if !seen[payerType] {
+			seen[payerType] = true
+			coverage.PayerTypes = append(coverage.PayerTypes, payerType)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("[APPOINTMENT] Error after iterating over coverage rows: %v", err)
+		return nil, err
+	}
+
+	return coverage, nil
+}
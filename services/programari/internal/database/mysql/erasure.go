@@ -0,0 +1,98 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/mihnea1711/POS_Project/services/programari/internal/models"
+	"github.com/mihnea1711/POS_Project/services/programari/pkg/utils"
+)
+
+// ErasePatientAppointments removes the appointment data of an erased patient that nobody has to keep: the upcoming
+// appointments, with their resources and reminders, and the proposals still waiting for an answer. The past
+// appointments and the status history are kept for the legal retention period, the patient module pseudonymizes the
+// patient they refer to. Running it again changes nothing new.
+func (db *MySQLDatabase) ErasePatientAppointments(ctx context.Context, patientID int) ([]models.ErasureRecord, error) {
+	log.Printf("[APPOINTMENT] Attempting to erase the appointments of patient %d", patientID)
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Printf("[APPOINTMENT] Error starting transaction to erase appointments: %v", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	records := []models.ErasureRecord{}
+
+	proposalsQuery := fmt.Sprintf("DELETE FROM %s WHERE %s = ? AND %s = ?", utils.ReschedulingProposalTableName, utils.ColumnIDPatient, utils.ColumnStatus)
+	proposals, err := execCount(ctx, tx, proposalsQuery, patientID, utils.ProposalStatusPending)
+	if err != nil {
+		return nil, err
+	}
+	records = append(records, models.ErasureRecord{Category: utils.ERASURE_CATEGORY_RESCHEDULING_PROPOSALS, Action: utils.ErasureActionDeleted, Count: proposals})
+
+	// The reserved resources and the reminders of the upcoming appointments are removed with them
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	upcomingQuery := fmt.Sprintf("DELETE FROM %s WHERE %s = ? AND %s >= ? AND %s IN (?, ?)",
+		utils.AppointmentTableName,
+		utils.ColumnIDPatient,
+		utils.ColumnDate,
+		utils.ColumnStatus,
+	)
+	upcoming, err := execCount(ctx, tx, upcomingQuery, patientID, today, utils.StatusScheduled, utils.StatusConfirmed)
+	if err != nil {
+		return nil, err
+	}
+	records = append(records, models.ErasureRecord{Category: utils.ERASURE_CATEGORY_UPCOMING_APPOINTMENTS, Action: utils.ErasureActionDeleted, Count: upcoming})
+
+	var appointments, statusChanges int
+	countQuery := fmt.Sprintf("SELECT (SELECT COUNT(*) FROM %s WHERE %s = ?), (SELECT COUNT(*) FROM %s WHERE %s = ?)",
+		utils.AppointmentTableName, utils.ColumnIDPatient,
+		utils.StatusHistoryTableName, utils.ColumnIDPatient,
+	)
+	if err := tx.QueryRowContext(ctx, countQuery, patientID, patientID).Scan(&appointments, &statusChanges); err != nil {
+		log.Printf("[APPOINTMENT] Error counting the retained appointments of patient %d: %v", patientID, err)
+		return nil, err
+	}
+	records = append(records,
+		models.ErasureRecord{
+			Category: utils.ERASURE_CATEGORY_APPOINTMENTS,
+			Action:   utils.ErasureActionRetained,
+			Count:    appointments,
+			Reason:   "past visits, kept for the legal retention period under the pseudonymized patient",
+		},
+		models.ErasureRecord{
+			Category: utils.ERASURE_CATEGORY_APPOINTMENT_STATUS_LOGS,
+			Action:   utils.ErasureActionRetained,
+			Count:    statusChanges,
+			Reason:   "audit trail of the visits, kept for the legal retention period under the pseudonymized patient",
+		},
+	)
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("[APPOINTMENT] Error committing appointment erasure of patient %d: %v", patientID, err)
+		return nil, err
+	}
+
+	log.Printf("[APPOINTMENT] Erased %d upcoming appointments and %d pending proposals of patient %d", upcoming, proposals, patientID)
+	return records, nil
+}
+
+// execCount runs a statement in the transaction and returns the number of rows it affected
+func execCount(ctx context.Context, tx *sql.Tx, query string, args ...interface{}) (int, error) {
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		log.Printf("[APPOINTMENT] Error executing erasure statement: %v", err)
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		log.Printf("[APPOINTMENT] Error getting rows affected by erasure statement: %v", err)
+		return 0, err
+	}
+	return int(rowsAffected), nil
+}
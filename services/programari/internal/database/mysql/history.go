@@ -0,0 +1,228 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/mihnea1711/POS_Project/services/programari/internal/database"
+	"github.com/mihnea1711/POS_Project/services/programari/internal/models"
+	"github.com/mihnea1711/POS_Project/services/programari/pkg/utils"
+)
+
+// insertStatusHistory records a status transition of an appointment. oldStatus is nil when the appointment is created.
+// Transitions the patient is not responsible for, such as cancellations made by the clinic, are marked policyExempt.
+func insertStatusHistory(ctx context.Context, tx *sql.Tx, appointmentID int, appointment *models.Appointment, oldStatus *models.StatusAppointment, policyExempt bool) error {
+	query := fmt.Sprintf(
+		"INSERT INTO %s (%s, %s, %s, %s, %s, %s, %s) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		utils.StatusHistoryTableName,
+		utils.ColumnIDProgramare,
+		utils.ColumnIDPatient,
+		utils.ColumnOldStatus,
+		utils.ColumnNewStatus,
+		utils.ColumnAppointmentDate,
+		utils.ColumnChangedAt,
+		utils.ColumnPolicyExempt,
+	)
+
+	var previous interface{}
+	if oldStatus != nil {
+		previous = *oldStatus
+	}
+
+	if _, err := tx.ExecContext(ctx, query, appointmentID, appointment.IDPatient, previous, appointment.Status, appointment.Date, time.Now().UTC(), policyExempt); err != nil {
+		log.Printf("[APPOINTMENT] Error recording status history for appointment %d: %v", appointmentID, err)
+		return err
+	}
+
+	return nil
+}
+
+// lockAppointmentStatus reads the current status of an appointment and locks its row until the transaction ends
+func lockAppointmentStatus(ctx context.Context, tx *sql.Tx, appointmentID int) (models.StatusAppointment, error) {
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s = ? FOR UPDATE", utils.ColumnStatus, utils.AppointmentTableName, utils.ColumnIDProgramare)
+
+	var status models.StatusAppointment
+	if err := tx.QueryRowContext(ctx, query, appointmentID).Scan(&status); err != nil {
+		if err != sql.ErrNoRows {
+			log.Printf("[APPOINTMENT] Error locking appointment %d: %v", appointmentID, err)
+		}
+		return "", err
+	}
+
+	return status, nil
+}
+
+// fetchAppointmentByID retrieves an appointment inside a transaction
+func fetchAppointmentByID(ctx context.Context, tx *sql.Tx, appointmentID int) (*models.Appointment, error) {
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s = ?", strings.Join(appointmentColumns(), ", "), utils.AppointmentTableName, utils.ColumnIDProgramare)
+
+	appointment, err := scanAppointment(tx.QueryRowContext(ctx, query, appointmentID))
+	if err != nil {
+		log.Printf("[APPOINTMENT] Error fetching appointment %d in transaction: %v", appointmentID, err)
+		return nil, err
+	}
+
+	return appointment, nil
+}
+
+// checkLateCancellation refuses the late cancellation of an appointment with database.ErrLateCancellationLimit once its
+// patient used up the allowed late cancellations. The appointments of the patient stay locked until the transaction
+// ends, so concurrent cancellations of the patient are counted one after the other, each seeing those committed before.
+func checkLateCancellation(ctx context.Context, tx *sql.Tx, appointment *models.Appointment, limit *models.CancellationLimit) error {
+	if !limit.Now.After(appointment.Date.Add(time.Duration(limit.LateOffsetHours) * time.Hour)) {
+		return nil
+	}
+
+	lockQuery := fmt.Sprintf("SELECT %s FROM %s WHERE %s = ? FOR UPDATE", utils.ColumnIDProgramare, utils.AppointmentTableName, utils.ColumnIDPatient)
+	rows, err := tx.QueryContext(ctx, lockQuery, appointment.IDPatient)
+	if err != nil {
+		log.Printf("[APPOINTMENT] Error locking the appointments of patient %d: %v", appointment.IDPatient, err)
+		return err
+	}
+	rows.Close()
+
+	// A locking read sees the cancellations committed while waiting for the lock, a plain one would not
+	countQuery := fmt.Sprintf(
+		"SELECT COUNT(DISTINCT %s) FROM %s WHERE %s = ? AND %s = ? AND NOT %s AND %s >= ? AND %s > TIMESTAMPADD(HOUR, ?, %s) FOR SHARE",
+		utils.ColumnIDProgramare,
+		utils.StatusHistoryTableName,
+		utils.ColumnIDPatient,
+		utils.ColumnNewStatus,
+		utils.ColumnPolicyExempt,
+		utils.ColumnChangedAt,
+		utils.ColumnChangedAt,
+		utils.ColumnAppointmentDate,
+	)
+
+	var lateCancellations int
+	if err := tx.QueryRowContext(ctx, countQuery, appointment.IDPatient, utils.StatusCanceled, limit.LateSince, limit.LateOffsetHours).Scan(&lateCancellations); err != nil {
+		log.Printf("[APPOINTMENT] Error counting the late cancellations of patient %d: %v", appointment.IDPatient, err)
+		return err
+	}
+
+	if lateCancellations >= limit.MaxLateCancellations {
+		log.Printf("[APPOINTMENT] Late cancellation refused for appointment %d: %d late cancellations since %s", appointment.IDProgramare, lateCancellations, limit.LateSince.Format(utils.TIME_PARSE_SYNTAX))
+		return fmt.Errorf("%w: %d late cancellations were already made since %s", database.ErrLateCancellationLimit, lateCancellations, limit.LateSince.Format(utils.TIME_PARSE_SYNTAX))
+	}
+
+	return nil
+}
+
+// policyCountersSelect builds the expressions counting no-shows and late cancellations from the status history.
+// A cancellation is late when it happened after the appointment start shifted by lateOffsetHours and was not policy exempt.
+func policyCountersSelect() string {
+	return fmt.Sprintf(
+		"COUNT(DISTINCT CASE WHEN %s = ? AND %s >= ? THEN %s END) AS no_shows, "+
+			"COUNT(DISTINCT CASE WHEN %s = ? AND NOT %s AND %s >= ? AND %s > TIMESTAMPADD(HOUR, ?, %s) THEN %s END) AS late_cancellations",
+		utils.ColumnNewStatus, utils.ColumnChangedAt, utils.ColumnIDProgramare,
+		utils.ColumnNewStatus, utils.ColumnPolicyExempt, utils.ColumnChangedAt, utils.ColumnChangedAt, utils.ColumnAppointmentDate, utils.ColumnIDProgramare,
+	)
+}
+
+// FetchPatientPolicyStats derives the no-show and late cancellation counters of a patient from the status history
+func (db *MySQLDatabase) FetchPatientPolicyStats(ctx context.Context, patientID int, noShowSince, lateSince time.Time, lateOffsetHours int) (*models.PolicyStats, error) {
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s = ?", policyCountersSelect(), utils.StatusHistoryTableName, utils.ColumnIDPatient)
+
+	stats := models.PolicyStats{IDPatient: patientID}
+	err := db.QueryRowContext(ctx, query,
+		utils.StatusNotPresent, noShowSince,
+		utils.StatusCanceled, lateSince, lateOffsetHours,
+		patientID,
+	).Scan(&stats.NoShows, &stats.LateCancellations)
+	if err != nil {
+		log.Printf("[APPOINTMENT] Error fetching policy stats for patient %d: %v", patientID, err)
+		return nil, err
+	}
+
+	return &stats, nil
+}
+
+// FetchPolicyOffenders lists the patients whose no-shows reach noShowThreshold or whose late cancellations exceed maxLateCancellations
+func (db *MySQLDatabase) FetchPolicyOffenders(ctx context.Context, noShowSince, lateSince time.Time, lateOffsetHours, noShowThreshold, maxLateCancellations, page, limit int) ([]models.PolicyStats, error) {
+	offset := (page - 1) * limit
+
+	query := fmt.Sprintf(
+		"SELECT %s, %s FROM %s GROUP BY %s HAVING no_shows >= ? OR late_cancellations > ? ORDER BY no_shows DESC, late_cancellations DESC LIMIT ? OFFSET ?",
+		utils.ColumnIDPatient,
+		policyCountersSelect(),
+		utils.StatusHistoryTableName,
+		utils.ColumnIDPatient,
+	)
+
+	rows, err := db.QueryContext(ctx, query,
+		utils.StatusNotPresent, noShowSince,
+		utils.StatusCanceled, lateSince, lateOffsetHours,
+		noShowThreshold, maxLateCancellations,
+		limit, offset,
+	)
+	if err != nil {
+		log.Printf("[APPOINTMENT] FetchPolicyOffenders: Failed to query database: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	offenders := []models.PolicyStats{}
+	for rows.Next() {
+		var stats models.PolicyStats
+		if err := rows.Scan(&stats.IDPatient, &stats.NoShows, &stats.LateCancellations); err != nil {
+			log.Printf("[APPOINTMENT] FetchPolicyOffenders: Failed to scan rows: %v", err)
+			return nil, err
+		}
+		offenders = append(offenders, stats)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("[APPOINTMENT] FetchPolicyOffenders: Error iterating rows: %v", err)
+		return nil, err
+	}
+
+	return offenders, nil
+}
+
+// FetchPatientStatusHistory lists the status changes of a patient's appointments, the latest first
+func (db *MySQLDatabase) FetchPatientStatusHistory(ctx context.Context, patientID, page, limit int) ([]models.StatusChange, error) {
+	offset := (page - 1) * limit
+
+	query := fmt.Sprintf(
+		"SELECT %s, %s, %s, %s, %s, %s, %s, %s FROM %s WHERE %s = ? ORDER BY %s DESC, %s DESC LIMIT ? OFFSET ?",
+		utils.ColumnIDHistory, utils.ColumnIDProgramare, utils.ColumnIDPatient, utils.ColumnOldStatus, utils.ColumnNewStatus,
+		utils.ColumnAppointmentDate, utils.ColumnChangedAt, utils.ColumnPolicyExempt,
+		utils.StatusHistoryTableName,
+		utils.ColumnIDPatient,
+		utils.ColumnChangedAt, utils.ColumnIDHistory,
+	)
+
+	rows, err := db.QueryContext(ctx, query, patientID, limit, offset)
+	if err != nil {
+		log.Printf("[APPOINTMENT] FetchPatientStatusHistory: Failed to query database: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	changes := []models.StatusChange{}
+	for rows.Next() {
+		var change models.StatusChange
+		var oldStatus sql.NullString
+		if err := rows.Scan(&change.IDHistory, &change.IDAppointment, &change.IDPatient, &oldStatus, &change.NewStatus,
+			&change.AppointmentDate, &change.ChangedAt, &change.PolicyExempt); err != nil {
+			log.Printf("[APPOINTMENT] FetchPatientStatusHistory: Failed to scan rows: %v", err)
+			return nil, err
+		}
+		if oldStatus.Valid {
+			status := models.StatusAppointment(oldStatus.String)
+			change.OldStatus = &status
+		}
+		changes = append(changes, change)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("[APPOINTMENT] FetchPatientStatusHistory: Error iterating rows: %v", err)
+		return nil, err
+	}
+
+	return changes, nil
+}
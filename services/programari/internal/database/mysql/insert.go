@@ -9,21 +9,44 @@ import (
 	"github.com/mihnea1711/POS_Project/services/programari/pkg/utils"
 )
 
-func (db *MySQLDatabase) SaveAppointment(ctx context.Context, appointment *models.Appointment) (int, error) {
+// SaveAppointment stores an appointment. An active appointment takes a slot of the doctor and the resources its type requires,
+// the booking fails with database.ErrDoctorFullyBooked or database.ErrResourceUnavailable if they are taken.
+func (db *MySQLDatabase) SaveAppointment(ctx context.Context, appointment *models.Appointment, doctorCapacity int) (int, error) {
 	// Construct the SQL insert query
 	query := fmt.Sprintf(
-		"INSERT INTO %s (%s, %s, %s, %s) VALUES (?, ?, ?, ?)",
+		"INSERT INTO %s (%s, %s, %s, %s, %s) VALUES (?, ?, ?, ?, ?)",
 		utils.AppointmentTableName,
 		utils.ColumnIDPatient,
 		utils.ColumnIDDoctor,
 		utils.ColumnDate,
 		utils.ColumnStatus,
+		utils.ColumnIDType,
 	)
 
 	log.Println("[APPOINTMENT] Attempting to save appointment")
 
+	// The appointment, its reservations and its first status history entry are saved together
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Printf("[APPOINTMENT] Error starting transaction to save appointment: %v", err)
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	active := isActiveStatus(appointment.Status)
+	if active {
+		if err := lockDoctorSlot(ctx, tx, 0, appointment, doctorCapacity); err != nil {
+			return 0, err
+		}
+	}
+
+	var typeID interface{}
+	if appointment.IDType != nil {
+		typeID = *appointment.IDType
+	}
+
 	// Execute the SQL statement
-	result, err := db.ExecContext(ctx, query, appointment.IDPatient, appointment.IDDoctor, appointment.Date, appointment.Status)
+	result, err := tx.ExecContext(ctx, query, appointment.IDPatient, appointment.IDDoctor, appointment.Date, appointment.Status, typeID)
 	if err != nil {
 		log.Printf("[APPOINTMENT] Error executing query to save appointment: %v", err)
 		return 0, err
@@ -37,9 +60,24 @@ func (db *MySQLDatabase) SaveAppointment(ctx context.Context, appointment *model
 
 	if lastInsertID == 0 {
 		log.Printf("[APPOINTMENT] Something unexpected happened and the appointment could not be saved.")
-	} else {
-		log.Printf("[APPOINTMENT] Appointment saved successfully. ID: %d", lastInsertID)
+		return 0, nil
+	}
+
+	if active {
+		if err := reserveResources(ctx, tx, int(lastInsertID), appointment); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := insertStatusHistory(ctx, tx, int(lastInsertID), appointment, nil, false); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("[APPOINTMENT] Error committing appointment save: %v", err)
+		return 0, err
 	}
 
+	log.Printf("[APPOINTMENT] Appointment saved successfully. ID: %d", lastInsertID)
 	return int(lastInsertID), nil
 }
@@ -0,0 +1,108 @@
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/mihnea1711/POS_Project/services/programari/internal/models"
+	"github.com/mihnea1711/POS_Project/services/programari/pkg/utils"
+)
+
+// statusRank orders the statuses by how much of the visit they record. When two merged patient records were booked
+// with the same doctor on the same day, the appointment with the higher rank is kept.
+var statusRank = map[models.StatusAppointment]int{
+	utils.StatusHonored:    4,
+	utils.StatusConfirmed:  3,
+	utils.StatusScheduled:  2,
+	utils.StatusNotPresent: 1,
+	utils.StatusCanceled:   0,
+}
+
+// ReassignPatientAppointments moves the appointments, the status history and the rescheduling proposals of a patient
+// to another patient in a single transaction. Running it again once the appointments were moved changes nothing.
+func (db *MySQLDatabase) ReassignPatientAppointments(ctx context.Context, fromPatientID, toPatientID int) (*models.ReassignmentResult, error) {
+	log.Printf("[APPOINTMENT] Attempting to move the appointments of patient %d to patient %d", fromPatientID, toPatientID)
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Printf("[APPOINTMENT] Error starting transaction to reassign appointments: %v", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Both patients booked with the same doctor on the same day would break the unique appointment key
+	conflictQuery := fmt.Sprintf(
+		"SELECT f.%s, f.%s, t.%s, t.%s FROM %s f JOIN %s t ON t.%s = f.%s AND t.%s = f.%s AND t.%s = ? WHERE f.%s = ? FOR UPDATE",
+		utils.ColumnIDProgramare, utils.ColumnStatus, utils.ColumnIDProgramare, utils.ColumnStatus,
+		utils.AppointmentTableName, utils.AppointmentTableName,
+		utils.ColumnIDDoctor, utils.ColumnIDDoctor, utils.ColumnDate, utils.ColumnDate, utils.ColumnIDPatient,
+		utils.ColumnIDPatient,
+	)
+
+	rows, err := tx.QueryContext(ctx, conflictQuery, toPatientID, fromPatientID)
+	if err != nil {
+		log.Printf("[APPOINTMENT] Error fetching conflicting appointments of patients %d and %d: %v", fromPatientID, toPatientID, err)
+		return nil, err
+	}
+
+	result := &models.ReassignmentResult{Dropped: []int{}}
+	for rows.Next() {
+		var fromID, toID int
+		var fromStatus, toStatus models.StatusAppointment
+		if err := rows.Scan(&fromID, &fromStatus, &toID, &toStatus); err != nil {
+			rows.Close()
+			log.Printf("[APPOINTMENT] Error scanning conflicting appointment: %v", err)
+			return nil, err
+		}
+
+		if statusRank[fromStatus] > statusRank[toStatus] {
+			result.Dropped = append(result.Dropped, toID)
+		} else {
+			result.Dropped = append(result.Dropped, fromID)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		log.Printf("[APPOINTMENT] Error after iterating over conflicting appointments: %v", err)
+		return nil, err
+	}
+
+	// The reserved resources and the reminders of a dropped appointment are removed with it, its status history is kept
+	deleteQuery := fmt.Sprintf("DELETE FROM %s WHERE %s = ?", utils.AppointmentTableName, utils.ColumnIDProgramare)
+	for _, appointmentID := range result.Dropped {
+		if _, err := tx.ExecContext(ctx, deleteQuery, appointmentID); err != nil {
+			log.Printf("[APPOINTMENT] Error dropping duplicate appointment %d: %v", appointmentID, err)
+			return nil, err
+		}
+	}
+
+	res, err := tx.ExecContext(ctx, fmt.Sprintf("UPDATE %s SET %s = ? WHERE %s = ?", utils.AppointmentTableName, utils.ColumnIDPatient, utils.ColumnIDPatient), toPatientID, fromPatientID)
+	if err != nil {
+		log.Printf("[APPOINTMENT] Error moving the appointments of patient %d: %v", fromPatientID, err)
+		return nil, err
+	}
+	moved, err := res.RowsAffected()
+	if err != nil {
+		log.Printf("[APPOINTMENT] Error getting rows affected: %v", err)
+		return nil, err
+	}
+	result.Moved = int(moved)
+
+	// The no-show counters and the pending proposals follow the appointments
+	for _, table := range []string{utils.StatusHistoryTableName, utils.ReschedulingProposalTableName} {
+		query := fmt.Sprintf("UPDATE %s SET %s = ? WHERE %s = ?", table, utils.ColumnIDPatient, utils.ColumnIDPatient)
+		if _, err := tx.ExecContext(ctx, query, toPatientID, fromPatientID); err != nil {
+			log.Printf("[APPOINTMENT] Error moving the %s rows of patient %d: %v", table, fromPatientID, err)
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("[APPOINTMENT] Error committing appointment reassignment: %v", err)
+		return nil, err
+	}
+
+	log.Printf("[APPOINTMENT] Moved %d appointments of patient %d to patient %d, dropped %d duplicates", result.Moved, fromPatientID, toPatientID, len(result.Dropped))
+	return result, nil
+}
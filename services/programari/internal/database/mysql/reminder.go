@@ -0,0 +1,105 @@
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/mihnea1711/POS_Project/services/programari/internal/models"
+	"github.com/mihnea1711/POS_Project/services/programari/pkg/utils"
+)
+
+// FetchReminderTargets retrieves the scheduled appointments between from and to (inclusive). The contact details of
+// the patients are left empty, the patient module keeps them encrypted and they are read from it.
+func (db *MySQLDatabase) FetchReminderTargets(ctx context.Context, from, to time.Time) ([]models.ReminderTarget, error) {
+	query := fmt.Sprintf(
+		"SELECT %s, %s, %s, %s, %s FROM %s WHERE %s = ? AND %s BETWEEN ? AND ?",
+		utils.ColumnIDProgramare,
+		utils.ColumnIDPatient,
+		utils.ColumnIDDoctor,
+		utils.ColumnDate,
+		utils.ColumnStatus,
+		utils.AppointmentTableName,
+		utils.ColumnStatus,
+		utils.ColumnDate,
+	)
+
+	log.Printf("[APPOINTMENT] Attempting to fetch reminder targets between %s and %s", from.Format(utils.TIME_PARSE_SYNTAX), to.Format(utils.TIME_PARSE_SYNTAX))
+
+	rows, err := db.QueryContext(ctx, query, utils.StatusScheduled, from.Format(utils.TIME_PARSE_SYNTAX), to.Format(utils.TIME_PARSE_SYNTAX))
+	if err != nil {
+		log.Printf("[APPOINTMENT] FetchReminderTargets: Failed to query database: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var targets []models.ReminderTarget
+	for rows.Next() {
+		var target models.ReminderTarget
+		err := rows.Scan(
+			&target.Appointment.IDProgramare,
+			&target.Appointment.IDPatient,
+			&target.Appointment.IDDoctor,
+			&target.Appointment.Date,
+			&target.Appointment.Status,
+		)
+		if err != nil {
+			log.Printf("[APPOINTMENT] FetchReminderTargets: Failed to scan rows: %v", err)
+			return nil, err
+		}
+		targets = append(targets, target)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("[APPOINTMENT] FetchReminderTargets: Error iterating rows: %v", err)
+		return nil, err
+	}
+
+	return targets, nil
+}
+
+// ClaimReminder records that a reminder is about to be sent. It returns false if the reminder was already claimed,
+// which keeps reminders idempotent across scheduler ticks and application restarts.
+func (db *MySQLDatabase) ClaimReminder(ctx context.Context, appointmentID, offsetMinutes int, channel string) (bool, error) {
+	query := fmt.Sprintf(
+		"INSERT IGNORE INTO %s (%s, %s, %s, %s) VALUES (?, ?, ?, ?)",
+		utils.ReminderTableName,
+		utils.ColumnIDProgramare,
+		utils.ColumnOffsetMinutes,
+		utils.ColumnChannel,
+		utils.ColumnSentAt,
+	)
+
+	res, err := db.ExecContext(ctx, query, appointmentID, offsetMinutes, channel, time.Now().UTC())
+	if err != nil {
+		log.Printf("[APPOINTMENT] Error claiming %s reminder (%d min) for appointment %d: %v", channel, offsetMinutes, appointmentID, err)
+		return false, err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		log.Printf("[APPOINTMENT] Error getting rows affected while claiming reminder: %v", err)
+		return false, err
+	}
+
+	return rowsAffected == 1, nil
+}
+
+// ReleaseReminder removes a reminder claim so that the reminder is retried on the next scan.
+func (db *MySQLDatabase) ReleaseReminder(ctx context.Context, appointmentID, offsetMinutes int, channel string) error {
+	query := fmt.Sprintf(
+		"DELETE FROM %s WHERE %s = ? AND %s = ? AND %s = ?",
+		utils.ReminderTableName,
+		utils.ColumnIDProgramare,
+		utils.ColumnOffsetMinutes,
+		utils.ColumnChannel,
+	)
+
+	if _, err := db.ExecContext(ctx, query, appointmentID, offsetMinutes, channel); err != nil {
+		log.Printf("[APPOINTMENT] Error releasing %s reminder (%d min) for appointment %d: %v", channel, offsetMinutes, appointmentID, err)
+		return err
+	}
+
+	return nil
+}
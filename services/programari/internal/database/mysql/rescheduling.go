@@ -0,0 +1,885 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/mihnea1711/POS_Project/services/programari/internal/models"
+	"github.com/mihnea1711/POS_Project/services/programari/pkg/utils"
+)
+
+// reschedulingJobSelect selects a job together with the counters of its proposals
+func reschedulingJobSelect() squirrel.SelectBuilder {
+	return squirrel.Select(
+		"j."+utils.ColumnIDJob,
+		"j."+utils.ColumnIDDoctor,
+		"j."+utils.ColumnUnavailableUntil,
+		"j."+utils.ColumnStatus,
+		"j."+utils.ColumnTotal,
+		"j."+utils.ColumnProcessed,
+		"j."+utils.ColumnCreatedAt,
+		"j."+utils.ColumnUpdatedAt,
+		proposalCounter(utils.ProposalStatusPending),
+		proposalCounter(utils.ProposalStatusAccepted),
+		proposalCounter(utils.ProposalStatusDeclined),
+		proposalCounter(utils.ProposalStatusExpired),
+		proposalCounter(utils.ProposalStatusUnavailable),
+	).
+		From(utils.ReschedulingJobTableName + " j").
+		LeftJoin(fmt.Sprintf("%s p ON p.%s = j.%s", utils.ReschedulingProposalTableName, utils.ColumnIDJob, utils.ColumnIDJob)).
+		GroupBy("j." + utils.ColumnIDJob)
+}
+
+func proposalCounter(status models.ProposalStatus) string {
+	return fmt.Sprintf("COUNT(CASE WHEN p.%s = '%s' THEN 1 END)", utils.ColumnStatus, status)
+}
+
+func scanReschedulingJob(row interface{ Scan(...interface{}) error }) (*models.ReschedulingJob, error) {
+	var job models.ReschedulingJob
+	var unavailableUntil sql.NullTime
+
+	if err := row.Scan(
+		&job.IDJob,
+		&job.IDDoctor,
+		&unavailableUntil,
+		&job.Status,
+		&job.Total,
+		&job.Processed,
+		&job.CreatedAt,
+		&job.UpdatedAt,
+		&job.Pending,
+		&job.Accepted,
+		&job.Declined,
+		&job.Expired,
+		&job.Unavailable,
+	); err != nil {
+		return nil, err
+	}
+
+	if unavailableUntil.Valid {
+		job.UnavailableUntil = &unavailableUntil.Time
+	}
+	return &job, nil
+}
+
+func (db *MySQLDatabase) queryReschedulingJobs(ctx context.Context, qb squirrel.SelectBuilder) ([]models.ReschedulingJob, error) {
+	query, args, err := qb.ToSql()
+	if err != nil {
+		log.Printf("[APPOINTMENT] Failed to construct rescheduling job query: %v", err)
+		return nil, err
+	}
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		log.Printf("[APPOINTMENT] Failed to query rescheduling jobs: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	jobs := []models.ReschedulingJob{}
+	for rows.Next() {
+		job, err := scanReschedulingJob(rows)
+		if err != nil {
+			log.Printf("[APPOINTMENT] Failed to scan rescheduling job: %v", err)
+			return nil, err
+		}
+		jobs = append(jobs, *job)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("[APPOINTMENT] Error iterating rescheduling jobs: %v", err)
+		return nil, err
+	}
+
+	return jobs, nil
+}
+
+// SaveReschedulingJob creates a running rescheduling job for the doctor
+func (db *MySQLDatabase) SaveReschedulingJob(ctx context.Context, request *models.ReschedulingRequest) (int, error) {
+	query := fmt.Sprintf(
+		"INSERT INTO %s (%s, %s, %s, %s, %s) VALUES (?, ?, ?, ?, ?)",
+		utils.ReschedulingJobTableName,
+		utils.ColumnIDDoctor,
+		utils.ColumnUnavailableUntil,
+		utils.ColumnStatus,
+		utils.ColumnCreatedAt,
+		utils.ColumnUpdatedAt,
+	)
+
+	var unavailableUntil interface{}
+	if request.UnavailableUntil != nil {
+		unavailableUntil = request.UnavailableUntil.Format(utils.TIME_PARSE_SYNTAX)
+	}
+
+	now := time.Now().UTC()
+	result, err := db.ExecContext(ctx, query, request.IDDoctor, unavailableUntil, utils.JobStatusRunning, now, now)
+	if err != nil {
+		log.Printf("[APPOINTMENT] Error saving rescheduling job for doctor %d: %v", request.IDDoctor, err)
+		return 0, err
+	}
+
+	lastInsertID, err := result.LastInsertId()
+	if err != nil {
+		log.Printf("[APPOINTMENT] Error getting last insert ID for rescheduling job: %v", err)
+		return 0, err
+	}
+
+	log.Printf("[APPOINTMENT] Rescheduling job %d created for doctor %d", lastInsertID, request.IDDoctor)
+	return int(lastInsertID), nil
+}
+
+// FetchOpenReschedulingJob returns the job of the doctor that is still running or waiting for responses.
+// sql.ErrNoRows is returned if the doctor has no open job.
+func (db *MySQLDatabase) FetchOpenReschedulingJob(ctx context.Context, doctorID int) (*models.ReschedulingJob, error) {
+	jobs, err := db.queryReschedulingJobs(ctx, reschedulingJobSelect().
+		Where(squirrel.Eq{
+			"j." + utils.ColumnIDDoctor: doctorID,
+			"j." + utils.ColumnStatus:   []models.JobStatus{utils.JobStatusRunning, utils.JobStatusAwaitingResponses},
+		}).
+		Limit(1))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(jobs) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return &jobs[0], nil
+}
+
+// FetchReschedulingJobs lists the rescheduling jobs, newest first
+func (db *MySQLDatabase) FetchReschedulingJobs(ctx context.Context, page, limit int) ([]models.ReschedulingJob, error) {
+	offset := (page - 1) * limit
+	return db.queryReschedulingJobs(ctx, reschedulingJobSelect().
+		OrderBy("j."+utils.ColumnIDJob+" DESC").
+		Limit(uint64(limit)).
+		Offset(uint64(offset)))
+}
+
+// FetchReschedulingJobByID retrieves a rescheduling job by its ID
+func (db *MySQLDatabase) FetchReschedulingJobByID(ctx context.Context, jobID int) (*models.ReschedulingJob, error) {
+	jobs, err := db.queryReschedulingJobs(ctx, reschedulingJobSelect().Where(squirrel.Eq{"j." + utils.ColumnIDJob: jobID}))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(jobs) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return &jobs[0], nil
+}
+
+// FetchRunnableReschedulingJobs lists the running jobs created before the given moment
+func (db *MySQLDatabase) FetchRunnableReschedulingJobs(ctx context.Context, createdBefore time.Time) ([]models.ReschedulingJob, error) {
+	return db.queryReschedulingJobs(ctx, reschedulingJobSelect().
+		Where(squirrel.Eq{"j." + utils.ColumnStatus: utils.JobStatusRunning}).
+		Where(squirrel.LtOrEq{"j." + utils.ColumnCreatedAt: createdBefore.UTC()}).
+		OrderBy("j."+utils.ColumnIDJob))
+}
+
+// UpdateReschedulingJobProgress records how many appointments a job has to handle and how many it already handled
+func (db *MySQLDatabase) UpdateReschedulingJobProgress(ctx context.Context, jobID, total, processed int) error {
+	query := fmt.Sprintf(
+		"UPDATE %s SET %s = ?, %s = ?, %s = ? WHERE %s = ?",
+		utils.ReschedulingJobTableName,
+		utils.ColumnTotal,
+		utils.ColumnProcessed,
+		utils.ColumnUpdatedAt,
+		utils.ColumnIDJob,
+	)
+
+	if _, err := db.ExecContext(ctx, query, total, processed, time.Now().UTC(), jobID); err != nil {
+		log.Printf("[APPOINTMENT] Error updating progress of rescheduling job %d: %v", jobID, err)
+		return err
+	}
+
+	return nil
+}
+
+// SetReschedulingJobStatus moves a job to the given status
+func (db *MySQLDatabase) SetReschedulingJobStatus(ctx context.Context, jobID int, status models.JobStatus) error {
+	query := fmt.Sprintf(
+		"UPDATE %s SET %s = ?, %s = ? WHERE %s = ?",
+		utils.ReschedulingJobTableName,
+		utils.ColumnStatus,
+		utils.ColumnUpdatedAt,
+		utils.ColumnIDJob,
+	)
+
+	if _, err := db.ExecContext(ctx, query, status, time.Now().UTC(), jobID); err != nil {
+		log.Printf("[APPOINTMENT] Error setting status of rescheduling job %d to %s: %v", jobID, status, err)
+		return err
+	}
+
+	return nil
+}
+
+// CompleteReschedulingJobs marks as completed the jobs waiting for responses that have no pending proposal left
+func (db *MySQLDatabase) CompleteReschedulingJobs(ctx context.Context) (int, error) {
+	query := fmt.Sprintf(
+		"UPDATE %s j SET j.%s = ?, j.%s = ? WHERE j.%s = ? AND NOT EXISTS (SELECT 1 FROM %s p WHERE p.%s = j.%s AND p.%s = ?)",
+		utils.ReschedulingJobTableName,
+		utils.ColumnStatus,
+		utils.ColumnUpdatedAt,
+		utils.ColumnStatus,
+		utils.ReschedulingProposalTableName,
+		utils.ColumnIDJob,
+		utils.ColumnIDJob,
+		utils.ColumnStatus,
+	)
+
+	result, err := db.ExecContext(ctx, query, utils.JobStatusCompleted, time.Now().UTC(), utils.JobStatusAwaitingResponses, utils.ProposalStatusPending)
+	if err != nil {
+		log.Printf("[APPOINTMENT] Error completing rescheduling jobs: %v", err)
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		log.Printf("[APPOINTMENT] Error getting rows affected while completing rescheduling jobs: %v", err)
+		return 0, err
+	}
+
+	return int(rowsAffected), nil
+}
+
+// IsDoctorActive reports whether the doctor account is active
+func (db *MySQLDatabase) IsDoctorActive(ctx context.Context, doctorID int) (bool, error) {
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s = ?", utils.ColumnIsActive, utils.DoctorTableName, utils.ColumnIDDoctor)
+
+	var isActive bool
+	if err := db.QueryRowContext(ctx, query, doctorID).Scan(&isActive); err != nil {
+		log.Printf("[APPOINTMENT] Error checking activity of doctor %d: %v", doctorID, err)
+		return false, err
+	}
+
+	return isActive, nil
+}
+
+// FetchReplacementDoctors lists the active doctors sharing the specialization of the given doctor, the doctor excluded
+func (db *MySQLDatabase) FetchReplacementDoctors(ctx context.Context, doctorID int) ([]int, error) {
+	query := fmt.Sprintf(
+		"SELECT d.%s FROM %s d JOIN %s o ON o.%s = d.%s WHERE o.%s = ? AND d.%s <> ? AND d.%s = true ORDER BY d.%s",
+		utils.ColumnIDDoctor,
+		utils.DoctorTableName,
+		utils.DoctorTableName,
+		utils.ColumnSpecialization,
+		utils.ColumnSpecialization,
+		utils.ColumnIDDoctor,
+		utils.ColumnIDDoctor,
+		utils.ColumnIsActive,
+		utils.ColumnIDDoctor,
+	)
+
+	rows, err := db.QueryContext(ctx, query, doctorID, doctorID)
+	if err != nil {
+		log.Printf("[APPOINTMENT] FetchReplacementDoctors: Failed to query database: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var doctorIDs []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			log.Printf("[APPOINTMENT] FetchReplacementDoctors: Failed to scan rows: %v", err)
+			return nil, err
+		}
+		doctorIDs = append(doctorIDs, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("[APPOINTMENT] FetchReplacementDoctors: Error iterating rows: %v", err)
+		return nil, err
+	}
+
+	return doctorIDs, nil
+}
+
+// FetchDoctorDailyLoad counts, per doctor and day, the active appointments and the pending proposals between from and to (inclusive)
+func (db *MySQLDatabase) FetchDoctorDailyLoad(ctx context.Context, doctorIDs []int, from, to time.Time) ([]models.DoctorDayLoad, error) {
+	if len(doctorIDs) == 0 {
+		return nil, nil
+	}
+
+	appointments := squirrel.Select(utils.ColumnIDDoctor+" AS id_doctor", utils.ColumnDate+" AS day", "COUNT(*)").
+		From(utils.AppointmentTableName).
+		Where(squirrel.Eq{
+			utils.ColumnIDDoctor: doctorIDs,
+			utils.ColumnStatus:   []models.StatusAppointment{utils.StatusScheduled, utils.StatusConfirmed},
+		}).
+		Where(squirrel.Expr(utils.ColumnDate+" BETWEEN ? AND ?", from.Format(utils.TIME_PARSE_SYNTAX), to.Format(utils.TIME_PARSE_SYNTAX))).
+		GroupBy(utils.ColumnIDDoctor, utils.ColumnDate)
+
+	proposals := squirrel.Select(utils.ColumnProposedDoctor, utils.ColumnProposedDate, "COUNT(*)").
+		From(utils.ReschedulingProposalTableName).
+		Where(squirrel.Eq{
+			utils.ColumnProposedDoctor: doctorIDs,
+			utils.ColumnStatus:         utils.ProposalStatusPending,
+		}).
+		Where(squirrel.Expr(utils.ColumnProposedDate+" BETWEEN ? AND ?", from.Format(utils.TIME_PARSE_SYNTAX), to.Format(utils.TIME_PARSE_SYNTAX))).
+		GroupBy(utils.ColumnProposedDoctor, utils.ColumnProposedDate)
+
+	appointmentsQuery, appointmentsArgs, err := appointments.ToSql()
+	if err != nil {
+		log.Printf("[APPOINTMENT] FetchDoctorDailyLoad: Failed to construct SQL query: %v", err)
+		return nil, err
+	}
+	proposalsQuery, proposalsArgs, err := proposals.ToSql()
+	if err != nil {
+		log.Printf("[APPOINTMENT] FetchDoctorDailyLoad: Failed to construct SQL query: %v", err)
+		return nil, err
+	}
+
+	query := appointmentsQuery + " UNION ALL " + proposalsQuery
+	rows, err := db.QueryContext(ctx, query, append(appointmentsArgs, proposalsArgs...)...)
+	if err != nil {
+		log.Printf("[APPOINTMENT] FetchDoctorDailyLoad: Failed to query database: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var loads []models.DoctorDayLoad
+	for rows.Next() {
+		var load models.DoctorDayLoad
+		if err := rows.Scan(&load.IDDoctor, &load.Date, &load.Count); err != nil {
+			log.Printf("[APPOINTMENT] FetchDoctorDailyLoad: Failed to scan rows: %v", err)
+			return nil, err
+		}
+		loads = append(loads, load)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("[APPOINTMENT] FetchDoctorDailyLoad: Error iterating rows: %v", err)
+		return nil, err
+	}
+
+	return loads, nil
+}
+
+// FetchReschedulingCandidates retrieves the active appointments of the doctor from the given day on (up to until, if set)
+// that the job did not handle yet. The contact details of the patients are left empty, they are read from the patient module.
+func (db *MySQLDatabase) FetchReschedulingCandidates(ctx context.Context, jobID, doctorID int, from time.Time, until *time.Time) ([]models.ReminderTarget, error) {
+	qb := squirrel.Select(
+		"a."+utils.ColumnIDProgramare,
+		"a."+utils.ColumnIDPatient,
+		"a."+utils.ColumnIDDoctor,
+		"a."+utils.ColumnDate,
+		"a."+utils.ColumnStatus,
+	).
+		From(utils.AppointmentTableName+" a").
+		Where(squirrel.Eq{
+			"a." + utils.ColumnIDDoctor: doctorID,
+			"a." + utils.ColumnStatus:   []models.StatusAppointment{utils.StatusScheduled, utils.StatusConfirmed},
+		}).
+		Where(squirrel.GtOrEq{"a." + utils.ColumnDate: from.Format(utils.TIME_PARSE_SYNTAX)}).
+		Where(fmt.Sprintf("a.%s NOT IN (SELECT %s FROM %s WHERE %s = ?)", utils.ColumnIDProgramare, utils.ColumnIDProgramare, utils.ReschedulingProposalTableName, utils.ColumnIDJob), jobID).
+		OrderBy("a." + utils.ColumnDate)
+
+	if until != nil {
+		qb = qb.Where(squirrel.LtOrEq{"a." + utils.ColumnDate: until.Format(utils.TIME_PARSE_SYNTAX)})
+	}
+
+	query, args, err := qb.ToSql()
+	if err != nil {
+		log.Printf("[APPOINTMENT] FetchReschedulingCandidates: Failed to construct SQL query: %v", err)
+		return nil, err
+	}
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		log.Printf("[APPOINTMENT] FetchReschedulingCandidates: Failed to query database: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var targets []models.ReminderTarget
+	for rows.Next() {
+		var target models.ReminderTarget
+		if err := rows.Scan(
+			&target.Appointment.IDProgramare,
+			&target.Appointment.IDPatient,
+			&target.Appointment.IDDoctor,
+			&target.Appointment.Date,
+			&target.Appointment.Status,
+		); err != nil {
+			log.Printf("[APPOINTMENT] FetchReschedulingCandidates: Failed to scan rows: %v", err)
+			return nil, err
+		}
+		targets = append(targets, target)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("[APPOINTMENT] FetchReschedulingCandidates: Error iterating rows: %v", err)
+		return nil, err
+	}
+
+	return targets, nil
+}
+
+// FetchReschedulingProposals lists the proposals made by a job
+func (db *MySQLDatabase) FetchReschedulingProposals(ctx context.Context, jobID int) ([]models.ReschedulingProposal, error) {
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s = ? ORDER BY %s", proposalColumns(), utils.ReschedulingProposalTableName, utils.ColumnIDJob, utils.ColumnIDProposal)
+
+	rows, err := db.QueryContext(ctx, query, jobID)
+	if err != nil {
+		log.Printf("[APPOINTMENT] FetchReschedulingProposals: Failed to query database: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	proposals := []models.ReschedulingProposal{}
+	for rows.Next() {
+		proposal, err := scanReschedulingProposal(rows)
+		if err != nil {
+			log.Printf("[APPOINTMENT] FetchReschedulingProposals: Failed to scan rows: %v", err)
+			return nil, err
+		}
+		proposals = append(proposals, *proposal)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("[APPOINTMENT] FetchReschedulingProposals: Error iterating rows: %v", err)
+		return nil, err
+	}
+
+	return proposals, nil
+}
+
+func proposalColumns() string {
+	return fmt.Sprintf(
+		"%s, %s, %s, %s, %s, %s, %s, %s, %s, %s, %s",
+		utils.ColumnIDProposal,
+		utils.ColumnIDJob,
+		utils.ColumnIDProgramare,
+		utils.ColumnIDPatient,
+		utils.ColumnOriginalDoctor,
+		utils.ColumnOriginalDate,
+		utils.ColumnProposedDoctor,
+		utils.ColumnProposedDate,
+		utils.ColumnStatus,
+		utils.ColumnExpiresAt,
+		utils.ColumnRespondedAt,
+	)
+}
+
+func scanReschedulingProposal(row interface{ Scan(...interface{}) error }) (*models.ReschedulingProposal, error) {
+	var proposal models.ReschedulingProposal
+	var proposedDoctor sql.NullInt64
+	var proposedDate, expiresAt, respondedAt sql.NullTime
+
+	if err := row.Scan(
+		&proposal.IDProposal,
+		&proposal.IDJob,
+		&proposal.IDAppointment,
+		&proposal.IDPatient,
+		&proposal.OriginalDoctor,
+		&proposal.OriginalDate,
+		&proposedDoctor,
+		&proposedDate,
+		&proposal.Status,
+		&expiresAt,
+		&respondedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	if proposedDoctor.Valid {
+		doctorID := int(proposedDoctor.Int64)
+		proposal.ProposedDoctor = &doctorID
+	}
+	if proposedDate.Valid {
+		proposal.ProposedDate = &proposedDate.Time
+	}
+	if expiresAt.Valid {
+		proposal.ExpiresAt = &expiresAt.Time
+	}
+	if respondedAt.Valid {
+		proposal.RespondedAt = &respondedAt.Time
+	}
+
+	return &proposal, nil
+}
+
+// SaveReschedulingProposal stores a proposal. A proposal without a replacement slot cancels the appointment right away.
+func (db *MySQLDatabase) SaveReschedulingProposal(ctx context.Context, proposal *models.ReschedulingProposal) (int, error) {
+	query := fmt.Sprintf(
+		"INSERT INTO %s (%s, %s, %s, %s, %s, %s, %s, %s, %s) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		utils.ReschedulingProposalTableName,
+		utils.ColumnIDJob,
+		utils.ColumnIDProgramare,
+		utils.ColumnIDPatient,
+		utils.ColumnOriginalDoctor,
+		utils.ColumnOriginalDate,
+		utils.ColumnProposedDoctor,
+		utils.ColumnProposedDate,
+		utils.ColumnStatus,
+		utils.ColumnExpiresAt,
+	)
+
+	var proposedDoctor, proposedDate, expiresAt interface{}
+	if proposal.ProposedDoctor != nil {
+		proposedDoctor = *proposal.ProposedDoctor
+	}
+	if proposal.ProposedDate != nil {
+		proposedDate = proposal.ProposedDate.Format(utils.TIME_PARSE_SYNTAX)
+	}
+	if proposal.ExpiresAt != nil {
+		expiresAt = proposal.ExpiresAt.UTC()
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Printf("[APPOINTMENT] Error starting transaction to save rescheduling proposal: %v", err)
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, query,
+		proposal.IDJob,
+		proposal.IDAppointment,
+		proposal.IDPatient,
+		proposal.OriginalDoctor,
+		proposal.OriginalDate.Format(utils.TIME_PARSE_SYNTAX),
+		proposedDoctor,
+		proposedDate,
+		proposal.Status,
+		expiresAt,
+	)
+	if err != nil {
+		log.Printf("[APPOINTMENT] Error saving rescheduling proposal for appointment %d: %v", proposal.IDAppointment, err)
+		return 0, err
+	}
+
+	lastInsertID, err := result.LastInsertId()
+	if err != nil {
+		log.Printf("[APPOINTMENT] Error getting last insert ID for rescheduling proposal: %v", err)
+		return 0, err
+	}
+
+	if proposal.Status == utils.ProposalStatusUnavailable {
+		if _, err := cancelForRescheduling(ctx, tx, proposal.IDAppointment); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("[APPOINTMENT] Error committing rescheduling proposal: %v", err)
+		return 0, err
+	}
+
+	return int(lastInsertID), nil
+}
+
+// AcceptReschedulingProposal moves the appointment to the proposed slot.
+// It returns 0 rows affected if the proposal is no longer pending or the appointment is no longer active.
+func (db *MySQLDatabase) AcceptReschedulingProposal(ctx context.Context, proposalID int, now time.Time) (int, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Printf("[APPOINTMENT] Error starting transaction to accept rescheduling proposal: %v", err)
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	proposal, err := lockPendingProposal(ctx, tx, proposalID, now)
+	if err != nil || proposal == nil {
+		return 0, err
+	}
+
+	oldStatus, err := lockAppointmentStatus(ctx, tx, proposal.IDAppointment)
+	if err != nil && err != sql.ErrNoRows {
+		return 0, err
+	}
+	if err == sql.ErrNoRows || (oldStatus != utils.StatusScheduled && oldStatus != utils.StatusConfirmed) {
+		// The appointment was deleted or closed in the meantime, the proposal cannot be honored anymore
+		log.Printf("[APPOINTMENT] Appointment %d of proposal %d is no longer active", proposal.IDAppointment, proposalID)
+		if err := setProposalStatus(ctx, tx, proposalID, utils.ProposalStatusExpired, now); err != nil {
+			return 0, err
+		}
+		return 0, tx.Commit()
+	}
+
+	// The new slot has to be confirmed again, so the appointment goes back to scheduled
+	query := fmt.Sprintf(
+		"UPDATE %s SET %s = ?, %s = ?, %s = ? WHERE %s = ?",
+		utils.AppointmentTableName,
+		utils.ColumnIDDoctor,
+		utils.ColumnDate,
+		utils.ColumnStatus,
+		utils.ColumnIDProgramare,
+	)
+	if _, err := tx.ExecContext(ctx, query, *proposal.ProposedDoctor, proposal.ProposedDate.Format(utils.TIME_PARSE_SYNTAX), utils.StatusScheduled, proposal.IDAppointment); err != nil {
+		log.Printf("[APPOINTMENT] Error moving appointment %d to the proposed slot: %v", proposal.IDAppointment, err)
+		return 0, err
+	}
+
+	appointment, err := fetchAppointmentByID(ctx, tx, proposal.IDAppointment)
+	if err != nil {
+		return 0, err
+	}
+
+	if oldStatus != utils.StatusScheduled {
+		if err := insertStatusHistory(ctx, tx, proposal.IDAppointment, appointment, &oldStatus, true); err != nil {
+			return 0, err
+		}
+	}
+
+	// The resources of the appointment type are booked again for the new day, the doctor slot was held by the proposal
+	if err := releaseResources(ctx, tx, proposal.IDAppointment); err != nil {
+		return 0, err
+	}
+	if err := reserveResources(ctx, tx, proposal.IDAppointment, appointment); err != nil {
+		return 0, err
+	}
+
+	// Reminders sent for the old date must be sent again for the new one
+	resetReminders := fmt.Sprintf("DELETE FROM %s WHERE %s = ?", utils.ReminderTableName, utils.ColumnIDProgramare)
+	if _, err := tx.ExecContext(ctx, resetReminders, proposal.IDAppointment); err != nil {
+		log.Printf("[APPOINTMENT] Error resetting reminders of appointment %d: %v", proposal.IDAppointment, err)
+		return 0, err
+	}
+
+	if err := setProposalStatus(ctx, tx, proposalID, utils.ProposalStatusAccepted, now); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("[APPOINTMENT] Error committing accepted rescheduling proposal: %v", err)
+		return 0, err
+	}
+
+	log.Printf("[APPOINTMENT] Rescheduling proposal %d accepted, appointment %d moved", proposalID, proposal.IDAppointment)
+	return 1, nil
+}
+
+// DeclineReschedulingProposal cancels the appointment of the proposal.
+// It returns 0 rows affected if the proposal is no longer pending.
+func (db *MySQLDatabase) DeclineReschedulingProposal(ctx context.Context, proposalID int, now time.Time) (int, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Printf("[APPOINTMENT] Error starting transaction to decline rescheduling proposal: %v", err)
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	proposal, err := lockPendingProposal(ctx, tx, proposalID, now)
+	if err != nil || proposal == nil {
+		return 0, err
+	}
+
+	if _, err := cancelForRescheduling(ctx, tx, proposal.IDAppointment); err != nil {
+		return 0, err
+	}
+
+	if err := setProposalStatus(ctx, tx, proposalID, utils.ProposalStatusDeclined, now); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("[APPOINTMENT] Error committing declined rescheduling proposal: %v", err)
+		return 0, err
+	}
+
+	log.Printf("[APPOINTMENT] Rescheduling proposal %d declined, appointment %d canceled", proposalID, proposal.IDAppointment)
+	return 1, nil
+}
+
+// ExpireReschedulingProposals cancels the appointments of the pending proposals that expired before now.
+// It returns the canceled appointments together with the contact details of their patients.
+func (db *MySQLDatabase) ExpireReschedulingProposals(ctx context.Context, now time.Time) ([]models.ReminderTarget, error) {
+	query := fmt.Sprintf(
+		"SELECT %s FROM %s WHERE %s = ? AND %s <= ?",
+		utils.ColumnIDProposal,
+		utils.ReschedulingProposalTableName,
+		utils.ColumnStatus,
+		utils.ColumnExpiresAt,
+	)
+
+	rows, err := db.QueryContext(ctx, query, utils.ProposalStatusPending, now.UTC())
+	if err != nil {
+		log.Printf("[APPOINTMENT] ExpireReschedulingProposals: Failed to query database: %v", err)
+		return nil, err
+	}
+
+	var proposalIDs []int
+	for rows.Next() {
+		var proposalID int
+		if err := rows.Scan(&proposalID); err != nil {
+			rows.Close()
+			log.Printf("[APPOINTMENT] ExpireReschedulingProposals: Failed to scan rows: %v", err)
+			return nil, err
+		}
+		proposalIDs = append(proposalIDs, proposalID)
+	}
+	rows.Close()
+
+	var canceled []models.ReminderTarget
+	for _, proposalID := range proposalIDs {
+		appointmentID, err := db.expireProposal(ctx, proposalID, now)
+		if err != nil {
+			return canceled, err
+		}
+		if appointmentID == 0 {
+			continue
+		}
+
+		target, err := db.fetchReminderTarget(ctx, appointmentID)
+		if err != nil {
+			log.Printf("[APPOINTMENT] Appointment %d canceled but its patient could not be fetched: %v", appointmentID, err)
+			continue
+		}
+		canceled = append(canceled, *target)
+	}
+
+	return canceled, nil
+}
+
+// expireProposal expires a single proposal and returns the ID of the appointment it canceled, or 0
+func (db *MySQLDatabase) expireProposal(ctx context.Context, proposalID int, now time.Time) (int, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Printf("[APPOINTMENT] Error starting transaction to expire rescheduling proposal: %v", err)
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	proposal, err := lockProposal(ctx, tx, proposalID)
+	if err != nil {
+		return 0, err
+	}
+	if proposal.Status != utils.ProposalStatusPending {
+		// Answered while the expiry was running
+		return 0, nil
+	}
+
+	canceled, err := cancelForRescheduling(ctx, tx, proposal.IDAppointment)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := setProposalStatus(ctx, tx, proposalID, utils.ProposalStatusExpired, now); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("[APPOINTMENT] Error committing expired rescheduling proposal: %v", err)
+		return 0, err
+	}
+
+	log.Printf("[APPOINTMENT] Rescheduling proposal %d expired", proposalID)
+	if !canceled {
+		return 0, nil
+	}
+	return proposal.IDAppointment, nil
+}
+
+// lockProposal reads a proposal and locks its row until the transaction ends
+func lockProposal(ctx context.Context, tx *sql.Tx, proposalID int) (*models.ReschedulingProposal, error) {
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s = ? FOR UPDATE", proposalColumns(), utils.ReschedulingProposalTableName, utils.ColumnIDProposal)
+
+	proposal, err := scanReschedulingProposal(tx.QueryRowContext(ctx, query, proposalID))
+	if err != nil {
+		if err != sql.ErrNoRows {
+			log.Printf("[APPOINTMENT] Error locking rescheduling proposal %d: %v", proposalID, err)
+		}
+		return nil, err
+	}
+
+	return proposal, nil
+}
+
+// lockPendingProposal locks a proposal that can still be answered. It returns nil if the proposal was already answered or expired.
+func lockPendingProposal(ctx context.Context, tx *sql.Tx, proposalID int, now time.Time) (*models.ReschedulingProposal, error) {
+	proposal, err := lockProposal(ctx, tx, proposalID)
+	if err != nil {
+		return nil, err
+	}
+
+	if proposal.Status != utils.ProposalStatusPending || (proposal.ExpiresAt != nil && !now.Before(*proposal.ExpiresAt)) {
+		log.Printf("[APPOINTMENT] Rescheduling proposal %d can no longer be answered (status %s)", proposalID, proposal.Status)
+		return nil, nil
+	}
+
+	return proposal, nil
+}
+
+func setProposalStatus(ctx context.Context, tx *sql.Tx, proposalID int, status models.ProposalStatus, now time.Time) error {
+	query := fmt.Sprintf(
+		"UPDATE %s SET %s = ?, %s = ? WHERE %s = ?",
+		utils.ReschedulingProposalTableName,
+		utils.ColumnStatus,
+		utils.ColumnRespondedAt,
+		utils.ColumnIDProposal,
+	)
+
+	if _, err := tx.ExecContext(ctx, query, status, now.UTC(), proposalID); err != nil {
+		log.Printf("[APPOINTMENT] Error setting status of rescheduling proposal %d to %s: %v", proposalID, status, err)
+		return err
+	}
+
+	return nil
+}
+
+// cancelForRescheduling cancels an active appointment on behalf of the clinic. The cancellation does not count against the patient.
+// It returns false if the appointment no longer exists or is not active.
+func cancelForRescheduling(ctx context.Context, tx *sql.Tx, appointmentID int) (bool, error) {
+	oldStatus, err := lockAppointmentStatus(ctx, tx, appointmentID)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if oldStatus != utils.StatusScheduled && oldStatus != utils.StatusConfirmed {
+		return false, nil
+	}
+
+	query := fmt.Sprintf("UPDATE %s SET %s = ? WHERE %s = ?", utils.AppointmentTableName, utils.ColumnStatus, utils.ColumnIDProgramare)
+	if _, err := tx.ExecContext(ctx, query, utils.StatusCanceled, appointmentID); err != nil {
+		log.Printf("[APPOINTMENT] Error canceling appointment %d for rescheduling: %v", appointmentID, err)
+		return false, err
+	}
+
+	appointment, err := fetchAppointmentByID(ctx, tx, appointmentID)
+	if err != nil {
+		return false, err
+	}
+
+	if err := insertStatusHistory(ctx, tx, appointmentID, appointment, &oldStatus, true); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// fetchReminderTarget retrieves an appointment to notify its patient of. The contact details are read from the patient module.
+func (db *MySQLDatabase) fetchReminderTarget(ctx context.Context, appointmentID int) (*models.ReminderTarget, error) {
+	query := fmt.Sprintf(
+		"SELECT %s, %s, %s, %s, %s FROM %s WHERE %s = ?",
+		utils.ColumnIDProgramare,
+		utils.ColumnIDPatient,
+		utils.ColumnIDDoctor,
+		utils.ColumnDate,
+		utils.ColumnStatus,
+		utils.AppointmentTableName,
+		utils.ColumnIDProgramare,
+	)
+
+	var target models.ReminderTarget
+	if err := db.QueryRowContext(ctx, query, appointmentID).Scan(
+		&target.Appointment.IDProgramare,
+		&target.Appointment.IDPatient,
+		&target.Appointment.IDDoctor,
+		&target.Appointment.Date,
+		&target.Appointment.Status,
+	); err != nil {
+		return nil, err
+	}
+
+	return &target, nil
+}
@@ -0,0 +1,560 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/mihnea1711/POS_Project/services/programari/internal/database"
+	"github.com/mihnea1711/POS_Project/services/programari/internal/models"
+	"github.com/mihnea1711/POS_Project/services/programari/pkg/utils"
+)
+
+// querier is implemented by both *sql.DB and *sql.Tx
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// activeStatuses are the statuses of the appointments holding a doctor slot and their resources
+var activeStatuses = []models.StatusAppointment{utils.StatusScheduled, utils.StatusConfirmed}
+
+func isActiveStatus(status models.StatusAppointment) bool {
+	return status == utils.StatusScheduled || status == utils.StatusConfirmed
+}
+
+func resourceColumns() []string {
+	return []string{
+		utils.ColumnIDResource,
+		utils.ColumnName,
+		utils.ColumnKind,
+		utils.ColumnCategory,
+		utils.ColumnDailyCapacity,
+		utils.ColumnIsActive,
+	}
+}
+
+func scanResources(rows *sql.Rows) ([]models.Resource, error) {
+	resources := []models.Resource{}
+	for rows.Next() {
+		var resource models.Resource
+		if err := rows.Scan(
+			&resource.IDResource,
+			&resource.Name,
+			&resource.Kind,
+			&resource.Category,
+			&resource.DailyCapacity,
+			&resource.IsActive,
+		); err != nil {
+			return nil, err
+		}
+		resources = append(resources, resource)
+	}
+
+	return resources, rows.Err()
+}
+
+// SaveResource stores a new room or piece of equipment
+func (db *MySQLDatabase) SaveResource(ctx context.Context, resource *models.Resource) (int, error) {
+	query := fmt.Sprintf(
+		"INSERT INTO %s (%s, %s, %s, %s, %s) VALUES (?, ?, ?, ?, ?)",
+		utils.ResourceTableName,
+		utils.ColumnName,
+		utils.ColumnKind,
+		utils.ColumnCategory,
+		utils.ColumnDailyCapacity,
+		utils.ColumnIsActive,
+	)
+
+	result, err := db.ExecContext(ctx, query, resource.Name, resource.Kind, resource.Category, resource.DailyCapacity, resource.IsActive)
+	if err != nil {
+		log.Printf("[APPOINTMENT] Error executing query to save resource: %v", err)
+		return 0, err
+	}
+
+	lastInsertID, err := result.LastInsertId()
+	if err != nil {
+		log.Printf("[APPOINTMENT] Error getting last insert id when saving resource: %v", err)
+		return 0, err
+	}
+
+	log.Printf("[APPOINTMENT] Resource saved successfully. ID: %d", lastInsertID)
+	return int(lastInsertID), nil
+}
+
+// FetchResources lists the resources matching the filters
+func (db *MySQLDatabase) FetchResources(ctx context.Context, filters map[string]interface{}) ([]models.Resource, error) {
+	qb := squirrel.Select(resourceColumns()...).From(utils.ResourceTableName).OrderBy(utils.ColumnCategory, utils.ColumnIDResource)
+	if len(filters) > 0 {
+		qb = qb.Where(squirrel.Eq(filters))
+	}
+
+	query, args, err := qb.ToSql()
+	if err != nil {
+		log.Printf("[APPOINTMENT] FetchResources: Failed to construct SQL query: %v", err)
+		return nil, err
+	}
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		log.Printf("[APPOINTMENT] FetchResources: Failed to query database: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	resources, err := scanResources(rows)
+	if err != nil {
+		log.Printf("[APPOINTMENT] FetchResources: Failed to scan rows: %v", err)
+		return nil, err
+	}
+
+	return resources, nil
+}
+
+// UpdateResourceByID updates a resource. Reservations already made are kept if the capacity shrinks or the resource is deactivated.
+func (db *MySQLDatabase) UpdateResourceByID(ctx context.Context, resource *models.Resource) (int, error) {
+	query := fmt.Sprintf(
+		"UPDATE %s SET %s = ?, %s = ?, %s = ?, %s = ?, %s = ? WHERE %s = ?",
+		utils.ResourceTableName,
+		utils.ColumnName,
+		utils.ColumnKind,
+		utils.ColumnCategory,
+		utils.ColumnDailyCapacity,
+		utils.ColumnIsActive,
+		utils.ColumnIDResource,
+	)
+
+	res, err := db.ExecContext(ctx, query, resource.Name, resource.Kind, resource.Category, resource.DailyCapacity, resource.IsActive, resource.IDResource)
+	if err != nil {
+		log.Printf("[APPOINTMENT] Error executing query to update resource %d: %v", resource.IDResource, err)
+		return 0, err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		log.Printf("[APPOINTMENT] Error getting rows affected: %v", err)
+		return 0, err
+	}
+
+	return int(rowsAffected), nil
+}
+
+// FetchAppointmentResources lists the resources reserved for an appointment
+func (db *MySQLDatabase) FetchAppointmentResources(ctx context.Context, appointmentID int) ([]models.Resource, error) {
+	columns := resourceColumns()
+	for i := range columns {
+		columns[i] = "r." + columns[i]
+	}
+
+	query, args, err := squirrel.Select(columns...).
+		From(utils.ResourceTableName + " r").
+		Join(fmt.Sprintf("%s ar ON ar.%s = r.%s", utils.AppointmentResourceTableName, utils.ColumnIDResource, utils.ColumnIDResource)).
+		Where(squirrel.Eq{"ar." + utils.ColumnIDProgramare: appointmentID}).
+		OrderBy("r." + utils.ColumnCategory).
+		ToSql()
+	if err != nil {
+		log.Printf("[APPOINTMENT] FetchAppointmentResources: Failed to construct SQL query: %v", err)
+		return nil, err
+	}
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		log.Printf("[APPOINTMENT] FetchAppointmentResources: Failed to query database: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	resources, err := scanResources(rows)
+	if err != nil {
+		log.Printf("[APPOINTMENT] FetchAppointmentResources: Failed to scan rows: %v", err)
+		return nil, err
+	}
+
+	return resources, nil
+}
+
+// SaveAppointmentType stores an appointment type together with the resources it requires
+func (db *MySQLDatabase) SaveAppointmentType(ctx context.Context, appointmentType *models.AppointmentType) (int, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Printf("[APPOINTMENT] Error starting transaction to save appointment type: %v", err)
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (?)", utils.AppointmentTypeTableName, utils.ColumnName)
+	result, err := tx.ExecContext(ctx, query, appointmentType.Name)
+	if err != nil {
+		log.Printf("[APPOINTMENT] Error executing query to save appointment type: %v", err)
+		return 0, err
+	}
+
+	lastInsertID, err := result.LastInsertId()
+	if err != nil {
+		log.Printf("[APPOINTMENT] Error getting last insert id when saving appointment type: %v", err)
+		return 0, err
+	}
+
+	requirementQuery := fmt.Sprintf(
+		"INSERT INTO %s (%s, %s, %s) VALUES (?, ?, ?)",
+		utils.RequirementTableName,
+		utils.ColumnIDType,
+		utils.ColumnCategory,
+		utils.ColumnQuantity,
+	)
+	for _, requirement := range appointmentType.Requirements {
+		if _, err := tx.ExecContext(ctx, requirementQuery, lastInsertID, requirement.Category, requirement.Quantity); err != nil {
+			log.Printf("[APPOINTMENT] Error saving requirement %s of appointment type: %v", requirement.Category, err)
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("[APPOINTMENT] Error committing appointment type save: %v", err)
+		return 0, err
+	}
+
+	log.Printf("[APPOINTMENT] Appointment type saved successfully. ID: %d", lastInsertID)
+	return int(lastInsertID), nil
+}
+
+// FetchAppointmentTypes lists the appointment types together with their requirements
+func (db *MySQLDatabase) FetchAppointmentTypes(ctx context.Context) ([]models.AppointmentType, error) {
+	query := fmt.Sprintf(
+		"SELECT t.%s, t.%s, r.%s, r.%s FROM %s t LEFT JOIN %s r ON r.%s = t.%s ORDER BY t.%s, r.%s",
+		utils.ColumnIDType,
+		utils.ColumnName,
+		utils.ColumnCategory,
+		utils.ColumnQuantity,
+		utils.AppointmentTypeTableName,
+		utils.RequirementTableName,
+		utils.ColumnIDType,
+		utils.ColumnIDType,
+		utils.ColumnIDType,
+		utils.ColumnCategory,
+	)
+
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		log.Printf("[APPOINTMENT] FetchAppointmentTypes: Failed to query database: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	types := []models.AppointmentType{}
+	for rows.Next() {
+		var typeID int
+		var name string
+		var category sql.NullString
+		var quantity sql.NullInt64
+		if err := rows.Scan(&typeID, &name, &category, &quantity); err != nil {
+			log.Printf("[APPOINTMENT] FetchAppointmentTypes: Failed to scan rows: %v", err)
+			return nil, err
+		}
+
+		if len(types) == 0 || types[len(types)-1].IDType != typeID {
+			types = append(types, models.AppointmentType{IDType: typeID, Name: name, Requirements: []models.ResourceRequirement{}})
+		}
+		if category.Valid {
+			last := &types[len(types)-1]
+			last.Requirements = append(last.Requirements, models.ResourceRequirement{Category: category.String, Quantity: int(quantity.Int64)})
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("[APPOINTMENT] FetchAppointmentTypes: Error iterating rows: %v", err)
+		return nil, err
+	}
+
+	return types, nil
+}
+
+// FetchAppointmentTypeByID retrieves an appointment type with its requirements. It returns sql.ErrNoRows if the type does not exist.
+func (db *MySQLDatabase) FetchAppointmentTypeByID(ctx context.Context, typeID int) (*models.AppointmentType, error) {
+	appointmentType := models.AppointmentType{IDType: typeID}
+
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s = ?", utils.ColumnName, utils.AppointmentTypeTableName, utils.ColumnIDType)
+	if err := db.QueryRowContext(ctx, query, typeID).Scan(&appointmentType.Name); err != nil {
+		if err != sql.ErrNoRows {
+			log.Printf("[APPOINTMENT] Error fetching appointment type %d: %v", typeID, err)
+		}
+		return nil, err
+	}
+
+	requirements, err := fetchRequirements(ctx, db.DB, typeID)
+	if err != nil {
+		return nil, err
+	}
+	appointmentType.Requirements = requirements
+
+	return &appointmentType, nil
+}
+
+// fetchRequirements lists the resources required by an appointment type, ordered by category
+func fetchRequirements(ctx context.Context, q querier, typeID int) ([]models.ResourceRequirement, error) {
+	query := fmt.Sprintf(
+		"SELECT %s, %s FROM %s WHERE %s = ? ORDER BY %s",
+		utils.ColumnCategory,
+		utils.ColumnQuantity,
+		utils.RequirementTableName,
+		utils.ColumnIDType,
+		utils.ColumnCategory,
+	)
+
+	rows, err := q.QueryContext(ctx, query, typeID)
+	if err != nil {
+		log.Printf("[APPOINTMENT] Error fetching requirements of appointment type %d: %v", typeID, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	requirements := []models.ResourceRequirement{}
+	for rows.Next() {
+		var requirement models.ResourceRequirement
+		if err := rows.Scan(&requirement.Category, &requirement.Quantity); err != nil {
+			log.Printf("[APPOINTMENT] Error scanning requirements of appointment type %d: %v", typeID, err)
+			return nil, err
+		}
+		requirements = append(requirements, requirement)
+	}
+
+	return requirements, rows.Err()
+}
+
+// FetchResourceDailyLoad counts, per day, the active appointments holding each of the resources between from and to (inclusive)
+func (db *MySQLDatabase) FetchResourceDailyLoad(ctx context.Context, resourceIDs []int, from, to time.Time) ([]models.ResourceDayLoad, error) {
+	if len(resourceIDs) == 0 {
+		return nil, nil
+	}
+
+	query, args, err := resourceLoadSelect().
+		Where(squirrel.Eq{"ar." + utils.ColumnIDResource: resourceIDs}).
+		Where(squirrel.Expr("ar."+utils.ColumnDate+" BETWEEN ? AND ?", from.Format(utils.TIME_PARSE_SYNTAX), to.Format(utils.TIME_PARSE_SYNTAX))).
+		GroupBy("ar."+utils.ColumnIDResource, "ar."+utils.ColumnDate).
+		ToSql()
+	if err != nil {
+		log.Printf("[APPOINTMENT] FetchResourceDailyLoad: Failed to construct SQL query: %v", err)
+		return nil, err
+	}
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		log.Printf("[APPOINTMENT] FetchResourceDailyLoad: Failed to query database: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var loads []models.ResourceDayLoad
+	for rows.Next() {
+		var load models.ResourceDayLoad
+		if err := rows.Scan(&load.IDResource, &load.Date, &load.Count); err != nil {
+			log.Printf("[APPOINTMENT] FetchResourceDailyLoad: Failed to scan rows: %v", err)
+			return nil, err
+		}
+		loads = append(loads, load)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("[APPOINTMENT] FetchResourceDailyLoad: Error iterating rows: %v", err)
+		return nil, err
+	}
+
+	return loads, nil
+}
+
+// resourceLoadSelect counts the reservations of active appointments, grouped by the caller
+func resourceLoadSelect() squirrel.SelectBuilder {
+	return squirrel.Select("ar."+utils.ColumnIDResource, "ar."+utils.ColumnDate, "COUNT(*)").
+		From(utils.AppointmentResourceTableName + " ar").
+		Join(fmt.Sprintf("%s a ON a.%s = ar.%s", utils.AppointmentTableName, utils.ColumnIDProgramare, utils.ColumnIDProgramare)).
+		Where(squirrel.Eq{"a." + utils.ColumnStatus: activeStatuses})
+}
+
+// lockDoctorSlot checks that the doctor has a free slot on the day of the appointment, counting pending rescheduling proposals.
+// The doctor row stays locked until the transaction ends, so concurrent bookings of the doctor cannot overbook the day.
+// It has to run before the appointment row is written. A doctorCapacity of 0 means no limit.
+func lockDoctorSlot(ctx context.Context, tx *sql.Tx, appointmentID int, appointment *models.Appointment, doctorCapacity int) error {
+	if doctorCapacity <= 0 {
+		return nil
+	}
+
+	// Locking the doctor row serializes the bookings of the doctor, a missing doctor is reported by the foreign key
+	lockQuery := fmt.Sprintf("SELECT %s FROM %s WHERE %s = ? FOR UPDATE", utils.ColumnIDDoctor, utils.DoctorTableName, utils.ColumnIDDoctor)
+	var doctorID int
+	if err := tx.QueryRowContext(ctx, lockQuery, appointment.IDDoctor).Scan(&doctorID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		log.Printf("[APPOINTMENT] Error locking doctor %d: %v", appointment.IDDoctor, err)
+		return err
+	}
+
+	date := appointment.Date.Format(utils.TIME_PARSE_SYNTAX)
+	countQuery := fmt.Sprintf(
+		"SELECT (SELECT COUNT(*) FROM %s WHERE %s = ? AND %s = ? AND %s IN (?, ?) AND %s <> ?) + "+
+			"(SELECT COUNT(*) FROM %s WHERE %s = ? AND %s = ? AND %s = ?)",
+		utils.AppointmentTableName, utils.ColumnIDDoctor, utils.ColumnDate, utils.ColumnStatus, utils.ColumnIDProgramare,
+		utils.ReschedulingProposalTableName, utils.ColumnProposedDoctor, utils.ColumnProposedDate, utils.ColumnStatus,
+	)
+
+	var booked int
+	if err := tx.QueryRowContext(ctx, countQuery,
+		appointment.IDDoctor, date, utils.StatusScheduled, utils.StatusConfirmed, appointmentID,
+		appointment.IDDoctor, date, utils.ProposalStatusPending,
+	).Scan(&booked); err != nil {
+		log.Printf("[APPOINTMENT] Error counting the appointments of doctor %d on %s: %v", appointment.IDDoctor, date, err)
+		return err
+	}
+
+	if booked >= doctorCapacity {
+		log.Printf("[APPOINTMENT] Doctor %d is fully booked on %s (%d/%d)", appointment.IDDoctor, date, booked, doctorCapacity)
+		return fmt.Errorf("%w: doctor %d on %s", database.ErrDoctorFullyBooked, appointment.IDDoctor, date)
+	}
+
+	return nil
+}
+
+// reserveResources reserves, for every requirement of the appointment type, the least used active resources of the category.
+// The resources of a category stay locked until the transaction ends, so concurrent bookings cannot double-book them.
+func reserveResources(ctx context.Context, tx *sql.Tx, appointmentID int, appointment *models.Appointment) error {
+	if appointment.IDType == nil {
+		return nil
+	}
+
+	typeQuery := fmt.Sprintf("SELECT %s FROM %s WHERE %s = ?", utils.ColumnIDType, utils.AppointmentTypeTableName, utils.ColumnIDType)
+	var typeID int
+	if err := tx.QueryRowContext(ctx, typeQuery, *appointment.IDType).Scan(&typeID); err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("%w: %d", database.ErrUnknownAppointmentType, *appointment.IDType)
+		}
+		log.Printf("[APPOINTMENT] Error fetching appointment type %d: %v", *appointment.IDType, err)
+		return err
+	}
+
+	// Requirements come ordered by category, so concurrent bookings lock the categories in the same order
+	requirements, err := fetchRequirements(ctx, tx, typeID)
+	if err != nil {
+		return err
+	}
+
+	date := appointment.Date.Format(utils.TIME_PARSE_SYNTAX)
+	insertQuery := fmt.Sprintf(
+		"INSERT INTO %s (%s, %s, %s) VALUES (?, ?, ?)",
+		utils.AppointmentResourceTableName,
+		utils.ColumnIDProgramare,
+		utils.ColumnIDResource,
+		utils.ColumnDate,
+	)
+
+	for _, requirement := range requirements {
+		resourceIDs, err := pickFreeResources(ctx, tx, appointmentID, requirement, date)
+		if err != nil {
+			return err
+		}
+
+		for _, resourceID := range resourceIDs {
+			if _, err := tx.ExecContext(ctx, insertQuery, appointmentID, resourceID, date); err != nil {
+				log.Printf("[APPOINTMENT] Error reserving resource %d for appointment %d: %v", resourceID, appointmentID, err)
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// pickFreeResources locks the active resources of the category and returns the least used ones with free capacity on the day
+func pickFreeResources(ctx context.Context, tx *sql.Tx, appointmentID int, requirement models.ResourceRequirement, date string) ([]int, error) {
+	lockQuery := fmt.Sprintf(
+		"SELECT %s, %s FROM %s WHERE %s = ? AND %s ORDER BY %s FOR UPDATE",
+		utils.ColumnIDResource,
+		utils.ColumnDailyCapacity,
+		utils.ResourceTableName,
+		utils.ColumnCategory,
+		utils.ColumnIsActive,
+		utils.ColumnIDResource,
+	)
+
+	rows, err := tx.QueryContext(ctx, lockQuery, requirement.Category)
+	if err != nil {
+		log.Printf("[APPOINTMENT] Error locking resources of category %s: %v", requirement.Category, err)
+		return nil, err
+	}
+
+	capacities := make(map[int]int)
+	var resourceIDs []int
+	for rows.Next() {
+		var resourceID, capacity int
+		if err := rows.Scan(&resourceID, &capacity); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		capacities[resourceID] = capacity
+		resourceIDs = append(resourceIDs, resourceID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	loads := make(map[int]int)
+	if len(resourceIDs) > 0 {
+		query, args, err := resourceLoadSelect().
+			Where(squirrel.Eq{"ar." + utils.ColumnIDResource: resourceIDs, "ar." + utils.ColumnDate: date}).
+			Where(squirrel.NotEq{"ar." + utils.ColumnIDProgramare: appointmentID}).
+			GroupBy("ar."+utils.ColumnIDResource, "ar."+utils.ColumnDate).
+			ToSql()
+		if err != nil {
+			return nil, err
+		}
+
+		loadRows, err := tx.QueryContext(ctx, query, args...)
+		if err != nil {
+			log.Printf("[APPOINTMENT] Error counting reservations of category %s on %s: %v", requirement.Category, date, err)
+			return nil, err
+		}
+		for loadRows.Next() {
+			var resourceID, count int
+			var day time.Time
+			if err := loadRows.Scan(&resourceID, &day, &count); err != nil {
+				loadRows.Close()
+				return nil, err
+			}
+			loads[resourceID] = count
+		}
+		loadRows.Close()
+		if err := loadRows.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	var free []int
+	for _, resourceID := range resourceIDs {
+		if loads[resourceID] < capacities[resourceID] {
+			free = append(free, resourceID)
+		}
+	}
+	sort.SliceStable(free, func(i, j int) bool { return loads[free[i]] < loads[free[j]] })
+
+	if len(free) < requirement.Quantity {
+		log.Printf("[APPOINTMENT] Only %d of %d %s resources free on %s", len(free), requirement.Quantity, requirement.Category, date)
+		return nil, fmt.Errorf("%w: %s on %s", database.ErrResourceUnavailable, requirement.Category, date)
+	}
+
+	return free[:requirement.Quantity], nil
+}
+
+// releaseResources drops the reservations of an appointment
+func releaseResources(ctx context.Context, tx *sql.Tx, appointmentID int) error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE %s = ?", utils.AppointmentResourceTableName, utils.ColumnIDProgramare)
+	if _, err := tx.ExecContext(ctx, query, appointmentID); err != nil {
+		log.Printf("[APPOINTMENT] Error releasing the resources of appointment %d: %v", appointmentID, err)
+		return err
+	}
+
+	return nil
+}
@@ -2,8 +2,10 @@ package mysql
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"log"
+	"strings"
 
 	"github.com/Masterminds/squirrel"
 	"github.com/mihnea1711/POS_Project/services/programari/internal/models"
@@ -16,7 +18,7 @@ func (db *MySQLDatabase) FetchAppointments(ctx context.Context, filters map[stri
 	// Get the offset based on page and limit
 	offset := (page - 1) * limit
 
-	qb := squirrel.Select("*").From(utils.AppointmentTableName)
+	qb := squirrel.Select(appointmentColumns()...).From(utils.AppointmentTableName)
 
 	// Check if filters is not empty and add WHERE clause if needed
 	if len(filters) > 0 {
@@ -41,19 +43,12 @@ func (db *MySQLDatabase) FetchAppointments(ctx context.Context, filters map[stri
 
 	var appointments []models.Appointment
 	for rows.Next() {
-		var appointment models.Appointment
-		err := rows.Scan(
-			&appointment.IDProgramare,
-			&appointment.IDPatient,
-			&appointment.IDDoctor,
-			&appointment.Date,
-			&appointment.Status,
-		)
+		appointment, err := scanAppointment(rows)
 		if err != nil {
 			log.Printf("[APPOINTMENT] FetchAppointments: Failed to scan rows: %v", err)
 			return nil, fmt.Errorf("internal server error")
 		}
-		appointments = append(appointments, appointment)
+		appointments = append(appointments, *appointment)
 	}
 
 	return appointments, nil
@@ -62,25 +57,53 @@ func (db *MySQLDatabase) FetchAppointments(ctx context.Context, filters map[stri
 // FetchAppointmentByID retrieves a appointment by its ID.
 func (db *MySQLDatabase) FetchAppointmentByID(ctx context.Context, appointmentID int) (*models.Appointment, error) {
 	// Construct the SQL insert query
-	query := fmt.Sprintf("SELECT * FROM %s WHERE %s = ?", utils.AppointmentTableName, utils.ColumnIDProgramare)
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s = ?", strings.Join(appointmentColumns(), ", "), utils.AppointmentTableName, utils.ColumnIDProgramare)
 
 	log.Printf("[APPOINTMENT] Attempting to fetch appointment with ID %d", appointmentID)
 
 	// Execute the SQL query with context
 	row := db.QueryRowContext(ctx, query, appointmentID)
 
-	appointment := models.Appointment{}
+	appointment, err := scanAppointment(row)
+	if err != nil {
+		log.Printf("[APPOINTMENT] Error fetching appointment by ID: %v", err)
+		return nil, err
+	}
+
+	log.Printf("[DOCTOR] Successfully fetched appointment by ID %d.", appointmentID)
+	return appointment, nil
+}
+
+func appointmentColumns() []string {
+	return []string{
+		utils.ColumnIDProgramare,
+		utils.ColumnIDPatient,
+		utils.ColumnIDDoctor,
+		utils.ColumnDate,
+		utils.ColumnStatus,
+		utils.ColumnIDType,
+	}
+}
+
+func scanAppointment(row interface{ Scan(...interface{}) error }) (*models.Appointment, error) {
+	var appointment models.Appointment
+	var typeID sql.NullInt64
+
 	if err := row.Scan(
 		&appointment.IDProgramare,
 		&appointment.IDPatient,
 		&appointment.IDDoctor,
 		&appointment.Date,
 		&appointment.Status,
+		&typeID,
 	); err != nil {
-		log.Printf("[APPOINTMENT] Error fetching appointment by ID: %v", err)
 		return nil, err
 	}
 
-	log.Printf("[DOCTOR] Successfully fetched appointment by ID %d.", appointmentID)
+	if typeID.Valid {
+		id := int(typeID.Int64)
+		appointment.IDType = &id
+	}
+
 	return &appointment, nil
 }
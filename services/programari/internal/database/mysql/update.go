@@ -2,31 +2,80 @@ package mysql
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"log"
+	"time"
 
+	"github.com/mihnea1711/POS_Project/services/programari/internal/database"
 	"github.com/mihnea1711/POS_Project/services/programari/internal/models"
 	"github.com/mihnea1711/POS_Project/services/programari/pkg/utils"
 )
 
-func (db *MySQLDatabase) UpdateAppointmentByID(ctx context.Context, appointment *models.Appointment) (int, error) {
+// UpdateAppointmentByID updates an appointment. Moving an active appointment to another doctor, day or type,
+// or reactivating it, books the doctor slot and the resources again; canceling or closing it releases its resources.
+// Canceling it is bounded by the cancellation limit, nil when the cancellation policy does not apply.
+func (db *MySQLDatabase) UpdateAppointmentByID(ctx context.Context, appointment *models.Appointment, doctorCapacity int, cancellation *models.CancellationLimit) (int, error) {
 	// Construct the SQL insert query
-	fmt.Println(appointment)
-
 	query := fmt.Sprintf(
-		"UPDATE %s SET %s = ?, %s = ?, %s = ?, %s = ? WHERE %s = ?",
+		"UPDATE %s SET %s = ?, %s = ?, %s = ?, %s = ?, %s = ? WHERE %s = ?",
 		utils.AppointmentTableName,
 		utils.ColumnIDPatient,
 		utils.ColumnIDDoctor,
 		utils.ColumnDate,
 		utils.ColumnStatus,
+		utils.ColumnIDType,
 		utils.ColumnIDProgramare,
 	)
 
 	log.Println("[APPOINTMENT] Attempting to update appointment")
 
+	// The update and the status history entry are saved together
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Printf("[APPOINTMENT] Error starting transaction to update appointment: %v", err)
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	oldStatus, err := lockAppointmentStatus(ctx, tx, appointment.IDProgramare)
+	if err == sql.ErrNoRows {
+		log.Printf("[APPOINTMENT] No appointment found with ID %d to update.", appointment.IDProgramare)
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	existing, err := fetchAppointmentByID(ctx, tx, appointment.IDProgramare)
+	if err != nil {
+		return 0, err
+	}
+
+	canceling := appointment.Status == utils.StatusCanceled && oldStatus != utils.StatusCanceled
+	policyExempt := canceling && cancellation != nil && cancellation.PolicyExempt
+	if canceling && cancellation != nil && !cancellation.PolicyExempt {
+		if err := checkLateCancellation(ctx, tx, existing, cancellation); err != nil {
+			return 0, err
+		}
+	}
+
+	// The doctor slot is taken again only if the appointment lands on another doctor or day, or becomes active again
+	active := isActiveStatus(appointment.Status)
+	slotChanged := !isActiveStatus(oldStatus) || existing.IDDoctor != appointment.IDDoctor || !sameDay(existing.Date, appointment.Date)
+	if active && slotChanged {
+		if err := lockDoctorSlot(ctx, tx, appointment.IDProgramare, appointment, doctorCapacity); err != nil {
+			return 0, err
+		}
+	}
+
+	var typeID interface{}
+	if appointment.IDType != nil {
+		typeID = *appointment.IDType
+	}
+
 	// Execute the SQL statement
-	res, err := db.ExecContext(ctx, query, appointment.IDPatient, appointment.IDDoctor, appointment.Date, appointment.Status, appointment.IDProgramare)
+	res, err := tx.ExecContext(ctx, query, appointment.IDPatient, appointment.IDDoctor, appointment.Date, appointment.Status, typeID, appointment.IDProgramare)
 	if err != nil {
 		log.Printf("[APPOINTMENT] Error executing query to update appointment: %v", err)
 		return 0, err
@@ -38,11 +87,156 @@ func (db *MySQLDatabase) UpdateAppointmentByID(ctx context.Context, appointment
 		return 0, err
 	}
 
+	if !active || slotChanged || !sameType(existing.IDType, appointment.IDType) {
+		if err := releaseResources(ctx, tx, appointment.IDProgramare); err != nil {
+			return 0, err
+		}
+		if active {
+			if err := reserveResources(ctx, tx, appointment.IDProgramare, appointment); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	if oldStatus != appointment.Status {
+		if err := insertStatusHistory(ctx, tx, appointment.IDProgramare, appointment, &oldStatus, policyExempt); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("[APPOINTMENT] Error committing appointment update: %v", err)
+		return 0, err
+	}
+
 	if rowsAffected == 0 {
-		log.Printf("[APPOINTMENT] No appointment found with ID %d to update.", appointment.IDDoctor)
+		log.Printf("[APPOINTMENT] No appointment found with ID %d to update.", appointment.IDProgramare)
 	} else {
-		log.Printf("[APPOINTMENT] Successfully updated appointment with ID %d.", appointment.IDDoctor)
+		log.Printf("[APPOINTMENT] Successfully updated appointment with ID %d.", appointment.IDProgramare)
 	}
 
 	return int(rowsAffected), nil
 }
+
+// ConfirmAppointmentByID moves a scheduled appointment to the confirmed status.
+// Appointments in any other status are left untouched and 0 rows affected is returned.
+func (db *MySQLDatabase) ConfirmAppointmentByID(ctx context.Context, appointmentID int) (int, error) {
+	query := fmt.Sprintf(
+		"UPDATE %s SET %s = ? WHERE %s = ? AND %s = ?",
+		utils.AppointmentTableName,
+		utils.ColumnStatus,
+		utils.ColumnIDProgramare,
+		utils.ColumnStatus,
+	)
+
+	log.Printf("[APPOINTMENT] Attempting to confirm appointment with ID %d", appointmentID)
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Printf("[APPOINTMENT] Error starting transaction to confirm appointment: %v", err)
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, query, utils.StatusConfirmed, appointmentID, utils.StatusScheduled)
+	if err != nil {
+		log.Printf("[APPOINTMENT] Error executing query to confirm appointment: %v", err)
+		return 0, err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		log.Printf("[APPOINTMENT] Error getting rows affected: %v", err)
+		return 0, err
+	}
+
+	if rowsAffected == 0 {
+		log.Printf("[APPOINTMENT] No scheduled appointment found with ID %d to confirm.", appointmentID)
+		return 0, nil
+	}
+
+	appointment, err := fetchAppointmentByID(ctx, tx, appointmentID)
+	if err != nil {
+		return 0, err
+	}
+
+	oldStatus := utils.StatusScheduled
+	if err := insertStatusHistory(ctx, tx, appointmentID, appointment, &oldStatus, false); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("[APPOINTMENT] Error committing appointment confirmation: %v", err)
+		return 0, err
+	}
+
+	log.Printf("[APPOINTMENT] Successfully confirmed appointment with ID %d.", appointmentID)
+	return int(rowsAffected), nil
+}
+
+// HonorAppointmentByID records that the visit of a scheduled or confirmed appointment took place. Honoring an honored
+// appointment changes nothing, so a visit can be opened again. It returns sql.ErrNoRows for a missing appointment and
+// database.ErrAppointmentNotHonorable for a canceled or missed one.
+func (db *MySQLDatabase) HonorAppointmentByID(ctx context.Context, appointmentID int) (*models.Appointment, error) {
+	log.Printf("[APPOINTMENT] Attempting to honor appointment with ID %d", appointmentID)
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Printf("[APPOINTMENT] Error starting transaction to honor appointment: %v", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	oldStatus, err := lockAppointmentStatus(ctx, tx, appointmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch oldStatus {
+	case utils.StatusHonored:
+		log.Printf("[APPOINTMENT] Appointment %d is already honored.", appointmentID)
+		return fetchAppointmentByID(ctx, tx, appointmentID)
+	case utils.StatusScheduled, utils.StatusConfirmed:
+	default:
+		log.Printf("[APPOINTMENT] Appointment %d cannot be honored from status %s.", appointmentID, oldStatus)
+		return nil, database.ErrAppointmentNotHonorable
+	}
+
+	query := fmt.Sprintf("UPDATE %s SET %s = ? WHERE %s = ?", utils.AppointmentTableName, utils.ColumnStatus, utils.ColumnIDProgramare)
+	if _, err := tx.ExecContext(ctx, query, utils.StatusHonored, appointmentID); err != nil {
+		log.Printf("[APPOINTMENT] Error executing query to honor appointment: %v", err)
+		return nil, err
+	}
+
+	// A honored appointment is closed, like an update to the honored status it releases its resources
+	if err := releaseResources(ctx, tx, appointmentID); err != nil {
+		return nil, err
+	}
+
+	appointment, err := fetchAppointmentByID(ctx, tx, appointmentID)
+	if err != nil {
+		return nil, err
+	}
+	if err := insertStatusHistory(ctx, tx, appointmentID, appointment, &oldStatus, false); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("[APPOINTMENT] Error committing appointment honoring: %v", err)
+		return nil, err
+	}
+
+	log.Printf("[APPOINTMENT] Successfully honored appointment with ID %d.", appointmentID)
+	return appointment, nil
+}
+
+func sameDay(a, b time.Time) bool {
+	return a.Format(utils.TIME_PARSE_SYNTAX) == b.Format(utils.TIME_PARSE_SYNTAX)
+}
+
+func sameType(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -66,6 +66,13 @@ func ValidateAppointmentInfo(next http.Handler) http.Handler {
 			return
 		}
 
+		// Validate the IDType if the appointment needs resources
+		if appointment.IDType != nil && *appointment.IDType <= 0 {
+			log.Println("[APPOINTMENT_VALIDATION] Invalid IDType")
+			utils.RespondWithJSON(w, http.StatusUnprocessableEntity, models.ResponseData{Error: "Invalid IDType", Message: "Validation failed due to appointment type"})
+			return
+		}
+
 		// Validate the Status (assuming it should be in the list of valid statuses)
 		if !validateStatus(appointment.Status) {
 			log.Println("[APPOINTMENT_VALIDATION] Invalid Status")
@@ -160,3 +167,189 @@ func validateStatus(status models.StatusAppointment) bool {
 	}
 	return false
 }
+
+func ValidateReschedulingJobInfo(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request models.ReschedulingRequest
+
+		if !isContentTypeJSON(r) {
+			errMsg := "Unsupported media type. Content-Type must be application/json"
+			log.Printf("[APPOINTMENT_VALIDATION] %s in request: %s", errMsg, r.RequestURI)
+			utils.RespondWithJSON(w, http.StatusUnsupportedMediaType, models.ResponseData{Error: errMsg, Message: "Rescheduling job validation failed due to unsupported media type"})
+			return
+		}
+
+		dec := json.NewDecoder(r.Body)
+		dec.DisallowUnknownFields()
+
+		err := dec.Decode(&request)
+		decodeFlag, decodeStatus := checkErrorOnDecode(err, w)
+		if decodeFlag || decodeStatus != http.StatusOK {
+			errMsg := "Failed to decode rescheduling job"
+			log.Printf("[APPOINTMENT_VALIDATION] %s in request: %s", errMsg, r.RequestURI)
+			utils.RespondWithJSON(w, http.StatusUnprocessableEntity, models.ResponseData{Error: errMsg, Message: "Rescheduling job validation failed due to decoding."})
+			return
+		}
+
+		if request.IDDoctor <= 0 {
+			log.Println("[APPOINTMENT_VALIDATION] Invalid IDDoctor")
+			utils.RespondWithJSON(w, http.StatusUnprocessableEntity, models.ResponseData{Error: "Invalid IDDoctor", Message: "Validation failed due to doctor id"})
+			return
+		}
+
+		if request.UnavailableUntil != nil && request.UnavailableUntil.IsZero() {
+			log.Println("[APPOINTMENT_VALIDATION] Invalid UnavailableUntil")
+			utils.RespondWithJSON(w, http.StatusUnprocessableEntity, models.ResponseData{Error: "Invalid UnavailableUntil", Message: "Validation failed due to unavailability date"})
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), utils.DECODED_RESCHEDULING_JOB, &request)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func ValidatePatientReassignmentInfo(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request models.PatientReassignment
+
+		if !isContentTypeJSON(r) {
+			errMsg := "Unsupported media type. Content-Type must be application/json"
+			log.Printf("[APPOINTMENT_VALIDATION] %s in request: %s", errMsg, r.RequestURI)
+			utils.RespondWithJSON(w, http.StatusUnsupportedMediaType, models.ResponseData{Error: errMsg, Message: "Patient reassignment validation failed due to unsupported media type"})
+			return
+		}
+
+		dec := json.NewDecoder(r.Body)
+		dec.DisallowUnknownFields()
+
+		err := dec.Decode(&request)
+		decodeFlag, decodeStatus := checkErrorOnDecode(err, w)
+		if decodeFlag || decodeStatus != http.StatusOK {
+			errMsg := "Failed to decode patient reassignment"
+			log.Printf("[APPOINTMENT_VALIDATION] %s in request: %s", errMsg, r.RequestURI)
+			utils.RespondWithJSON(w, http.StatusUnprocessableEntity, models.ResponseData{Error: errMsg, Message: "Patient reassignment validation failed due to decoding."})
+			return
+		}
+
+		if request.IDFromPatient <= 0 || request.IDToPatient <= 0 {
+			log.Println("[APPOINTMENT_VALIDATION] Invalid patient IDs")
+			utils.RespondWithJSON(w, http.StatusUnprocessableEntity, models.ResponseData{Error: "Invalid IDFromPatient or IDToPatient", Message: "Validation failed due to patient id"})
+			return
+		}
+
+		if request.IDFromPatient == request.IDToPatient {
+			log.Println("[APPOINTMENT_VALIDATION] Appointments reassigned to the same patient")
+			utils.RespondWithJSON(w, http.StatusUnprocessableEntity, models.ResponseData{Error: "IDFromPatient and IDToPatient must differ", Message: "Validation failed due to patient id"})
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), utils.DECODED_PATIENT_REASSIGNMENT, &request)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func ValidateResourceInfo(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var resource models.Resource
+
+		if !isContentTypeJSON(r) {
+			errMsg := "Unsupported media type. Content-Type must be application/json"
+			log.Printf("[APPOINTMENT_VALIDATION] %s in request: %s", errMsg, r.RequestURI)
+			utils.RespondWithJSON(w, http.StatusUnsupportedMediaType, models.ResponseData{Error: errMsg, Message: "Resource validation failed due to unsupported media type"})
+			return
+		}
+
+		dec := json.NewDecoder(r.Body)
+		dec.DisallowUnknownFields()
+
+		err := dec.Decode(&resource)
+		decodeFlag, decodeStatus := checkErrorOnDecode(err, w)
+		if decodeFlag || decodeStatus != http.StatusOK {
+			errMsg := "Failed to decode resource"
+			log.Printf("[APPOINTMENT_VALIDATION] %s in request: %s", errMsg, r.RequestURI)
+			utils.RespondWithJSON(w, http.StatusUnprocessableEntity, models.ResponseData{Error: errMsg, Message: "Resource validation failed due to decoding."})
+			return
+		}
+
+		if strings.TrimSpace(resource.Name) == "" {
+			log.Println("[APPOINTMENT_VALIDATION] Invalid Name")
+			utils.RespondWithJSON(w, http.StatusUnprocessableEntity, models.ResponseData{Error: "Invalid Name", Message: "Validation failed due to resource name"})
+			return
+		}
+
+		if !validateResourceKind(resource.Kind) {
+			log.Println("[APPOINTMENT_VALIDATION] Invalid Kind")
+			utils.RespondWithJSON(w, http.StatusUnprocessableEntity, models.ResponseData{Error: "Invalid Kind", Message: "Validation failed due to resource kind"})
+			return
+		}
+
+		if strings.TrimSpace(resource.Category) == "" {
+			log.Println("[APPOINTMENT_VALIDATION] Invalid Category")
+			utils.RespondWithJSON(w, http.StatusUnprocessableEntity, models.ResponseData{Error: "Invalid Category", Message: "Validation failed due to resource category"})
+			return
+		}
+
+		if resource.DailyCapacity <= 0 {
+			log.Println("[APPOINTMENT_VALIDATION] Invalid DailyCapacity")
+			utils.RespondWithJSON(w, http.StatusUnprocessableEntity, models.ResponseData{Error: "Invalid DailyCapacity", Message: "Validation failed due to resource daily capacity"})
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), utils.DECODED_RESOURCE, &resource)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func ValidateAppointmentTypeInfo(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var appointmentType models.AppointmentType
+
+		if !isContentTypeJSON(r) {
+			errMsg := "Unsupported media type. Content-Type must be application/json"
+			log.Printf("[APPOINTMENT_VALIDATION] %s in request: %s", errMsg, r.RequestURI)
+			utils.RespondWithJSON(w, http.StatusUnsupportedMediaType, models.ResponseData{Error: errMsg, Message: "Appointment type validation failed due to unsupported media type"})
+			return
+		}
+
+		dec := json.NewDecoder(r.Body)
+		dec.DisallowUnknownFields()
+
+		err := dec.Decode(&appointmentType)
+		decodeFlag, decodeStatus := checkErrorOnDecode(err, w)
+		if decodeFlag || decodeStatus != http.StatusOK {
+			errMsg := "Failed to decode appointment type"
+			log.Printf("[APPOINTMENT_VALIDATION] %s in request: %s", errMsg, r.RequestURI)
+			utils.RespondWithJSON(w, http.StatusUnprocessableEntity, models.ResponseData{Error: errMsg, Message: "Appointment type validation failed due to decoding."})
+			return
+		}
+
+		if strings.TrimSpace(appointmentType.Name) == "" {
+			log.Println("[APPOINTMENT_VALIDATION] Invalid Name")
+			utils.RespondWithJSON(w, http.StatusUnprocessableEntity, models.ResponseData{Error: "Invalid Name", Message: "Validation failed due to appointment type name"})
+			return
+		}
+
+		// Every category may be required once, with a positive quantity
+		categories := make(map[string]bool)
+		for _, requirement := range appointmentType.Requirements {
+			if strings.TrimSpace(requirement.Category) == "" || requirement.Quantity <= 0 || categories[requirement.Category] {
+				log.Printf("[APPOINTMENT_VALIDATION] Invalid requirement %q", requirement.Category)
+				utils.RespondWithJSON(w, http.StatusUnprocessableEntity, models.ResponseData{Error: "Invalid Requirements", Message: "Validation failed due to appointment type requirements"})
+				return
+			}
+			categories[requirement.Category] = true
+		}
+
+		ctx := context.WithValue(r.Context(), utils.DECODED_APPOINTMENT_TYPE, &appointmentType)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func validateResourceKind(kind models.ResourceKind) bool {
+	for _, validKind := range utils.ValidResourceKinds {
+		if kind == validKind {
+			return true
+		}
+	}
+	return false
+}
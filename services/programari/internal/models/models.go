@@ -5,6 +5,9 @@ import (
 )
 
 type StatusAppointment string
+type JobStatus string
+type ProposalStatus string
+type ResourceKind string
 
 type Appointment struct {
 	IDProgramare int               `db:"id_programare" json:"idProgramare"`
@@ -12,6 +15,187 @@ type Appointment struct {
 	IDDoctor     int               `db:"id_doctor" json:"idDoctor"`
 	Date         time.Time         `db:"date" json:"date"`
 	Status       StatusAppointment `db:"status" json:"status"`
+	IDType       *int              `db:"id_type" json:"idType,omitempty"` // the resources required by the type are reserved on booking
+}
+
+// Resource is a room or a piece of equipment that can serve DailyCapacity appointments per day
+type Resource struct {
+	IDResource    int          `json:"idResource"`
+	Name          string       `json:"name"`
+	Kind          ResourceKind `json:"kind"`
+	Category      string       `json:"category"`
+	DailyCapacity int          `json:"dailyCapacity"`
+	IsActive      bool         `json:"isActive"`
+}
+
+// AppointmentType declares the resources an appointment of the type needs
+type AppointmentType struct {
+	IDType       int                   `json:"idType"`
+	Name         string                `json:"name"`
+	Requirements []ResourceRequirement `json:"requirements"`
+}
+
+// ResourceRequirement asks for Quantity resources of the category
+type ResourceRequirement struct {
+	Category string `json:"category"`
+	Quantity int    `json:"quantity"`
+}
+
+// ResourceDayLoad counts the active appointments holding a resource on a day
+type ResourceDayLoad struct {
+	IDResource int       `json:"idResource"`
+	Date       time.Time `json:"date"`
+	Count      int       `json:"count"`
+}
+
+// DayAvailability tells whether an appointment of a type can be booked with a doctor on a day.
+// DoctorFreeSlots is omitted when the doctors have no daily limit.
+type DayAvailability struct {
+	Date            time.Time                 `json:"date"`
+	Available       bool                      `json:"available"`
+	DoctorFreeSlots *int                      `json:"doctorFreeSlots,omitempty"`
+	Resources       []RequirementAvailability `json:"resources"`
+}
+
+// RequirementAvailability lists the resources of a category still free on a day
+type RequirementAvailability struct {
+	Category      string `json:"category"`
+	Required      int    `json:"required"`
+	FreeResources []int  `json:"freeResources"`
+}
+
+// CancellationLimit is how the cancellation policy bounds the cancellation of an appointment, checked in the same
+// transaction as the cancellation. A cancellation made with an admin override is PolicyExempt, it is neither limited
+// nor counted later. Otherwise a cancellation made at Now, more than LateOffsetHours after the appointment date, is late
+// and refused once MaxLateCancellations late cancellations were made since LateSince.
+type CancellationLimit struct {
+	PolicyExempt         bool
+	Now                  time.Time
+	LateOffsetHours      int
+	LateSince            time.Time
+	MaxLateCancellations int
+}
+
+// PolicyStats holds the counters derived from the status history of a patient's appointments
+type PolicyStats struct {
+	IDPatient         int `json:"idPatient"`
+	NoShows           int `json:"noShows"`
+	LateCancellations int `json:"lateCancellations"`
+}
+
+// StatusChange is a transition of an appointment's status as the status history keeps it. OldStatus is missing for
+// the creation of the appointment.
+type StatusChange struct {
+	IDHistory       int                `json:"idHistory"`
+	IDAppointment   int                `json:"idAppointment"`
+	IDPatient       int                `json:"idPatient"`
+	OldStatus       *StatusAppointment `json:"oldStatus"`
+	NewStatus       StatusAppointment  `json:"newStatus"`
+	AppointmentDate time.Time          `json:"appointmentDate"`
+	ChangedAt       time.Time          `json:"changedAt"`
+	PolicyExempt    bool               `json:"policyExempt"`
+}
+
+// ReminderTarget is an upcoming appointment together with the contact details needed to remind the patient. The
+// appointment is read from the database, the contact details from the patient module.
+type ReminderTarget struct {
+	Appointment Appointment `json:"appointment"`
+	FirstName   string      `json:"firstName"`
+	SecondName  string      `json:"secondName"`
+	Email       string      `json:"email"`
+	PhoneNumber string      `json:"phoneNumber"`
+}
+
+// Notification is a single message delivered through a notification channel
+type Notification struct {
+	IDAppointment int       `json:"idAppointment"`
+	Subject       string    `json:"subject"`
+	Body          string    `json:"body"`
+	ConfirmURL    string    `json:"confirmURL,omitempty"`
+	AcceptURL     string    `json:"acceptURL,omitempty"`
+	DeclineURL    string    `json:"declineURL,omitempty"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+// ReschedulingRequest starts a rescheduling job for the appointments of an unavailable doctor.
+// UnavailableUntil is set when the doctor returns, so the same doctor can be proposed after that day.
+type ReschedulingRequest struct {
+	IDDoctor         int        `json:"idDoctor"`
+	UnavailableUntil *time.Time `json:"unavailableUntil"`
+}
+
+// ReschedulingJob tracks the rescheduling of the future appointments of a doctor
+type ReschedulingJob struct {
+	IDJob            int        `json:"idJob"`
+	IDDoctor         int        `json:"idDoctor"`
+	UnavailableUntil *time.Time `json:"unavailableUntil,omitempty"`
+	Status           JobStatus  `json:"status"`
+	Total            int        `json:"total"`
+	Processed        int        `json:"processed"`
+	Pending          int        `json:"pending"`
+	Accepted         int        `json:"accepted"`
+	Declined         int        `json:"declined"`
+	Expired          int        `json:"expired"`
+	Unavailable      int        `json:"unavailable"`
+	CreatedAt        time.Time  `json:"createdAt"`
+	UpdatedAt        time.Time  `json:"updatedAt"`
+}
+
+// ReschedulingProposal is the replacement slot offered to the patient of an affected appointment
+type ReschedulingProposal struct {
+	IDProposal     int            `json:"idProposal"`
+	IDJob          int            `json:"idJob"`
+	IDAppointment  int            `json:"idAppointment"`
+	IDPatient      int            `json:"idPatient"`
+	OriginalDoctor int            `json:"originalDoctor"`
+	OriginalDate   time.Time      `json:"originalDate"`
+	ProposedDoctor *int           `json:"proposedDoctor,omitempty"`
+	ProposedDate   *time.Time     `json:"proposedDate,omitempty"`
+	Status         ProposalStatus `json:"status"`
+	ExpiresAt      *time.Time     `json:"expiresAt,omitempty"`
+	RespondedAt    *time.Time     `json:"respondedAt,omitempty"`
+}
+
+// ReschedulingJobDetails is a job together with every proposal it made
+type ReschedulingJobDetails struct {
+	Job       ReschedulingJob        `json:"job"`
+	Proposals []ReschedulingProposal `json:"proposals"`
+}
+
+// DoctorDayLoad is the number of active appointments and pending proposals of a doctor on a day
+type DoctorDayLoad struct {
+	IDDoctor int       `json:"idDoctor"`
+	Date     time.Time `json:"date"`
+	Count    int       `json:"count"`
+}
+
+// PatientReassignment moves the appointments of a duplicate patient record to the record that survives the merge
+type PatientReassignment struct {
+	IDFromPatient int `json:"idFromPatient"`
+	IDToPatient   int `json:"idToPatient"`
+}
+
+// ReassignmentResult counts the appointments moved to the surviving patient. When both records were booked with
+// the same doctor on the same day only one appointment is kept, the IDs of the removed ones are listed in Dropped.
+type ReassignmentResult struct {
+	Moved   int   `json:"moved"`
+	Dropped []int `json:"dropped"`
+}
+
+// Coverage tells whether a patient is insured on the day of an appointment, by CNAS or a private insurer. A patient
+// is covered by a policy in force on that day which the payer did not reject, Verified is set when one was confirmed.
+type Coverage struct {
+	IDPatient  int       `json:"idPatient"`
+	Date       time.Time `json:"date"`
+	Covered    bool      `json:"covered"`
+	Verified   bool      `json:"verified"`
+	PayerTypes []string  `json:"payerTypes"`
+}
+
+// AppointmentCreated is the payload of a created appointment, with the coverage of the patient when it is checked
+type AppointmentCreated struct {
+	LastInsertedID int       `json:"lastInsertedID"`
+	Coverage       *Coverage `json:"coverage,omitempty"`
 }
 
 type ResponseData struct {
@@ -27,3 +211,13 @@ type RowsAffected struct {
 type LastInsertedID struct {
 	LastInsertedID int `json:"lastInsertedID"`
 }
+
+type ErasureAction string
+
+// ErasureRecord tells what the erasure of a patient did with a category of appointment data and why
+type ErasureRecord struct {
+	Category string        `json:"category"`
+	Action   ErasureAction `json:"action"`
+	Count    int           `json:"count"`
+	Reason   string        `json:"reason,omitempty"`
+}
@@ -0,0 +1,16 @@
+package notification
+
+import (
+	"context"
+
+	"github.com/mihnea1711/POS_Project/services/programari/internal/models"
+)
+
+// NotificationChannel delivers a notification to the patient of an appointment
+type NotificationChannel interface {
+	// Name returns the unique name of the channel, used to keep track of the reminders sent through it
+	Name() string
+
+	// Send delivers the notification to the patient described by the target
+	Send(ctx context.Context, target *models.ReminderTarget, notification *models.Notification) error
+}
@@ -0,0 +1,41 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/mihnea1711/POS_Project/services/programari/internal/models"
+	"github.com/mihnea1711/POS_Project/services/programari/pkg/utils"
+)
+
+// EmailChannel delivers notifications by email. Messages are written to a local sink file.
+type EmailChannel struct {
+	sink *fileSink
+}
+
+func NewEmailChannel(sinkPath string) (*EmailChannel, error) {
+	sink, err := newFileSink(sinkPath)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("[APPOINTMENT] Email notification channel writing to %s", sinkPath)
+	return &EmailChannel{sink: sink}, nil
+}
+
+func (c *EmailChannel) Name() string {
+	return utils.CHANNEL_EMAIL
+}
+
+func (c *EmailChannel) Send(ctx context.Context, target *models.ReminderTarget, notification *models.Notification) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if target.Email == "" {
+		return fmt.Errorf("patient %d has no email address", target.Appointment.IDPatient)
+	}
+
+	return c.sink.write(c.Name(), target.Email, notification)
+}
@@ -0,0 +1,63 @@
+package notification
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/mihnea1711/POS_Project/services/programari/internal/models"
+	"github.com/mihnea1711/POS_Project/services/programari/pkg/utils"
+)
+
+// fileSink appends delivered notifications to a local file, one JSON object per line
+type fileSink struct {
+	path string
+	mu   sync.Mutex
+}
+
+type sinkRecord struct {
+	Channel      string               `json:"channel"`
+	Recipient    string               `json:"recipient"`
+	DeliveredAt  time.Time            `json:"deliveredAt"`
+	Notification *models.Notification `json:"notification"`
+}
+
+func newFileSink(path string) (*fileSink, error) {
+	if err := os.MkdirAll(filepath.Dir(path), utils.REMINDER_SINK_DIR_PERMISSIONS); err != nil {
+		log.Printf("[APPOINTMENT] Error creating directory for sink file %s: %v", path, err)
+		return nil, fmt.Errorf("failed to create sink directory: %w", err)
+	}
+
+	return &fileSink{path: path}, nil
+}
+
+func (s *fileSink) write(channel, recipient string, notification *models.Notification) error {
+	record, err := json.Marshal(sinkRecord{
+		Channel:      channel,
+		Recipient:    recipient,
+		DeliveredAt:  time.Now().UTC(),
+		Notification: notification,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal notification: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	file, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, utils.REMINDER_SINK_FILE_PERMISSIONS)
+	if err != nil {
+		return fmt.Errorf("failed to open sink file: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(record, '\n')); err != nil {
+		return fmt.Errorf("failed to write to sink file: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,41 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/mihnea1711/POS_Project/services/programari/internal/models"
+	"github.com/mihnea1711/POS_Project/services/programari/pkg/utils"
+)
+
+// SMSChannel delivers notifications by SMS. Messages are written to a local sink file.
+type SMSChannel struct {
+	sink *fileSink
+}
+
+func NewSMSChannel(sinkPath string) (*SMSChannel, error) {
+	sink, err := newFileSink(sinkPath)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("[APPOINTMENT] SMS notification channel writing to %s", sinkPath)
+	return &SMSChannel{sink: sink}, nil
+}
+
+func (c *SMSChannel) Name() string {
+	return utils.CHANNEL_SMS
+}
+
+func (c *SMSChannel) Send(ctx context.Context, target *models.ReminderTarget, notification *models.Notification) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if target.PhoneNumber == "" {
+		return fmt.Errorf("patient %d has no phone number", target.Appointment.IDPatient)
+	}
+
+	return c.sink.write(c.Name(), target.PhoneNumber, notification)
+}
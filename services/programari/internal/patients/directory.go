@@ -0,0 +1,77 @@
+package patients
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mihnea1711/POS_Project/services/programari/internal/models"
+	"github.com/mihnea1711/POS_Project/services/programari/pkg/config"
+	"github.com/mihnea1711/POS_Project/services/programari/pkg/utils"
+)
+
+// ErrPatientNotFound is returned when the patient module has no patient with the ID of the appointment
+var ErrPatientNotFound = errors.New("patient not found")
+
+// Directory completes the reminder targets with the contact details of their patients. The patient module keeps the
+// email and the phone number encrypted, so they are read through its API, which decrypts them, never from its tables.
+type Directory interface {
+	FillContact(ctx context.Context, target *models.ReminderTarget) error
+}
+
+// patientModule reads the patients from the patient module, by its fetch by ID endpoint
+type patientModule struct {
+	baseURL string
+	client  *http.Client
+}
+
+func NewDirectory(patientsConfig config.PatientsConfig) Directory {
+	return &patientModule{
+		baseURL: strings.TrimRight(patientsConfig.BaseURL, "/"),
+		client:  &http.Client{Timeout: utils.PATIENT_LOOKUP_TIMEOUT * time.Second},
+	}
+}
+
+func (p *patientModule) FillContact(ctx context.Context, target *models.ReminderTarget) error {
+	patientID := target.Appointment.IDPatient
+	endpoint := fmt.Sprintf("%s%s/%d", p.baseURL, utils.PATIENT_FETCH_PATIENT_BY_ID_ENDPOINT, patientID)
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	response, err := p.client.Do(request)
+	if err != nil {
+		return fmt.Errorf("patient module unreachable: %w", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%w: %d", ErrPatientNotFound, patientID)
+	}
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("patient module answered the lookup of patient %d with status %d", patientID, response.StatusCode)
+	}
+
+	var body struct {
+		Payload struct {
+			FirstName   string `json:"firstName"`
+			SecondName  string `json:"secondName"`
+			Email       string `json:"email"`
+			PhoneNumber string `json:"phoneNumber"`
+		} `json:"payload"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&body); err != nil {
+		return fmt.Errorf("decoding patient %d: %w", patientID, err)
+	}
+
+	target.FirstName = body.Payload.FirstName
+	target.SecondName = body.Payload.SecondName
+	target.Email = body.Payload.Email
+	target.PhoneNumber = body.Payload.PhoneNumber
+	return nil
+}
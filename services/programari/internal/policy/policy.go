@@ -0,0 +1,106 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/mihnea1711/POS_Project/services/programari/internal/database"
+	"github.com/mihnea1711/POS_Project/services/programari/internal/models"
+	"github.com/mihnea1711/POS_Project/services/programari/pkg/config"
+)
+
+var (
+	// ErrBookingBlocked is returned when a patient reached the no-show threshold and may only book with an admin override
+	ErrBookingBlocked = errors.New("patient reached the no-show threshold")
+
+	// ErrLateCancellationLimit is returned when a patient cancels inside the notice window after using up the allowed late
+	// cancellations. The limit is checked by the database, in the transaction of the cancellation.
+	ErrLateCancellationLimit = database.ErrLateCancellationLimit
+)
+
+// CancellationPolicy enforces the cancellation notice window and the no-show threshold
+type CancellationPolicy struct {
+	dbConn       database.Database
+	config       config.PolicyConfig
+	dayStartHour int
+}
+
+func NewCancellationPolicy(dbConn database.Database, policyConfig config.PolicyConfig, dayStartHour int) *CancellationPolicy {
+	return &CancellationPolicy{
+		dbConn:       dbConn,
+		config:       policyConfig,
+		dayStartHour: dayStartHour,
+	}
+}
+
+// CheckBooking verifies that the patient may book a new appointment
+func (p *CancellationPolicy) CheckBooking(ctx context.Context, patientID int) error {
+	if p.config.NoShowThreshold <= 0 {
+		return nil
+	}
+
+	stats, err := p.Stats(ctx, patientID)
+	if err != nil {
+		return err
+	}
+
+	if stats.NoShows >= p.config.NoShowThreshold {
+		log.Printf("[APPOINTMENT] Booking blocked for patient %d: %d no-shows in the last %d days", patientID, stats.NoShows, p.config.NoShowPeriodDays)
+		return fmt.Errorf("%w: %d no-shows in the last %d days", ErrBookingBlocked, stats.NoShows, p.config.NoShowPeriodDays)
+	}
+
+	return nil
+}
+
+// CancellationLimit returns how a cancellation made at the given moment is bounded, nil when it is not. The update
+// checks it in its own transaction, so concurrent late cancellations of a patient cannot both pass the limit.
+// A cancellation made with an admin override is exempt from the policy and not counted against the patient.
+func (p *CancellationPolicy) CancellationLimit(now time.Time, override bool) *models.CancellationLimit {
+	if override {
+		return &models.CancellationLimit{PolicyExempt: true}
+	}
+	if p.config.MinCancellationNoticeHours <= 0 {
+		return nil
+	}
+
+	_, lateSince := p.periods(now)
+	return &models.CancellationLimit{
+		Now:                  now,
+		LateOffsetHours:      p.lateOffsetHours(),
+		LateSince:            lateSince,
+		MaxLateCancellations: p.config.MaxLateCancellations,
+	}
+}
+
+// Stats derives the counters of a patient over the configured periods
+func (p *CancellationPolicy) Stats(ctx context.Context, patientID int) (*models.PolicyStats, error) {
+	noShowSince, lateSince := p.periods(time.Now())
+	return p.dbConn.FetchPatientPolicyStats(ctx, patientID, noShowSince, lateSince, p.lateOffsetHours())
+}
+
+// Offenders lists the patients that reached the no-show threshold or exceeded the late cancellation limit
+func (p *CancellationPolicy) Offenders(ctx context.Context, page, limit int) ([]models.PolicyStats, error) {
+	noShowThreshold := p.config.NoShowThreshold
+	if noShowThreshold <= 0 {
+		// The no-show threshold is disabled, only late cancellations make a patient an offender
+		noShowThreshold = math.MaxInt32
+	}
+
+	noShowSince, lateSince := p.periods(time.Now())
+	return p.dbConn.FetchPolicyOffenders(ctx, noShowSince, lateSince, p.lateOffsetHours(), noShowThreshold, p.config.MaxLateCancellations, page, limit)
+}
+
+func (p *CancellationPolicy) periods(now time.Time) (time.Time, time.Time) {
+	noShowSince := now.AddDate(0, 0, -p.config.NoShowPeriodDays)
+	lateSince := now.AddDate(0, 0, -p.config.LateCancellationPeriodDays)
+	return noShowSince, lateSince
+}
+
+// lateOffsetHours is the offset from the appointment date after which a cancellation is late
+func (p *CancellationPolicy) lateOffsetHours() int {
+	return p.dayStartHour - p.config.MinCancellationNoticeHours
+}
@@ -0,0 +1,164 @@
+package reminder
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/mihnea1711/POS_Project/services/programari/internal/database"
+	"github.com/mihnea1711/POS_Project/services/programari/internal/models"
+	"github.com/mihnea1711/POS_Project/services/programari/internal/notification"
+	"github.com/mihnea1711/POS_Project/services/programari/internal/patients"
+	"github.com/mihnea1711/POS_Project/services/programari/pkg/config"
+	"github.com/mihnea1711/POS_Project/services/programari/pkg/utils"
+)
+
+// Scheduler periodically scans upcoming appointments and reminds patients through the configured channels
+type Scheduler struct {
+	dbConn       database.Database
+	patients     patients.Directory
+	channels     []notification.NotificationChannel
+	offsets      []time.Duration
+	interval     time.Duration
+	dayStartHour int
+	config       config.ReminderConfig
+}
+
+func NewScheduler(dbConn database.Database, patientDirectory patients.Directory, channels []notification.NotificationChannel, reminderConfig config.ReminderConfig, dayStartHour int) *Scheduler {
+	offsets := make([]time.Duration, 0, len(reminderConfig.OffsetsMinutes))
+	for _, minutes := range reminderConfig.OffsetsMinutes {
+		if minutes > 0 {
+			offsets = append(offsets, time.Duration(minutes)*time.Minute)
+		}
+	}
+	// Largest offset first, so the closest due reminder is the last one matched
+	sort.Slice(offsets, func(i, j int) bool { return offsets[i] > offsets[j] })
+
+	interval := time.Duration(reminderConfig.ScanIntervalSeconds) * time.Second
+	if interval <= 0 {
+		interval = utils.DEFAULT_REMINDER_SCAN_INTERVAL * time.Second
+	}
+
+	return &Scheduler{
+		dbConn:       dbConn,
+		patients:     patientDirectory,
+		channels:     channels,
+		offsets:      offsets,
+		interval:     interval,
+		dayStartHour: dayStartHour,
+		config:       reminderConfig,
+	}
+}
+
+// Start runs the scheduler until the context is canceled
+func (s *Scheduler) Start(ctx context.Context) {
+	if len(s.offsets) == 0 || len(s.channels) == 0 {
+		log.Println("[APPOINTMENT] Reminder scheduler has no offsets or channels configured. Not starting.")
+		return
+	}
+
+	log.Printf("[APPOINTMENT] Reminder scheduler started. Scanning every %s for offsets %v.", s.interval, s.offsets)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.scan(ctx, time.Now())
+
+		select {
+		case <-ctx.Done():
+			log.Println("[APPOINTMENT] Reminder scheduler stopped.")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// scan sends every reminder that became due at the given moment
+func (s *Scheduler) scan(ctx context.Context, now time.Time) {
+	scanCtx, cancel := context.WithTimeout(ctx, utils.DB_REQ_TIMEOUT_SEC_MULTIPLIER*time.Second)
+	defer cancel()
+
+	targets, err := s.dbConn.FetchReminderTargets(scanCtx, now, now.Add(s.offsets[0]))
+	if err != nil {
+		log.Printf("[APPOINTMENT] Reminder scan failed: %v", err)
+		return
+	}
+
+	for i := range targets {
+		target := &targets[i]
+		start := utils.AppointmentStart(target.Appointment.Date, s.dayStartHour)
+
+		offset, due := s.dueOffset(start, now)
+		if !due {
+			continue
+		}
+
+		// Nothing is claimed yet, so a reminder whose patient cannot be read is retried on the next scan
+		if err := s.patients.FillContact(scanCtx, target); err != nil {
+			log.Printf("[APPOINTMENT] Failed to read the contact details for appointment %d: %v", target.Appointment.IDProgramare, err)
+			continue
+		}
+
+		for _, channel := range s.channels {
+			s.remind(scanCtx, channel, target, start, offset)
+		}
+	}
+}
+
+// dueOffset returns the closest offset whose reminder time has passed for an appointment that has not started yet.
+// Earlier offsets that were missed (e.g. while the service was down) are skipped in favour of the most recent one.
+func (s *Scheduler) dueOffset(start, now time.Time) (time.Duration, bool) {
+	if !now.Before(start) {
+		return 0, false
+	}
+
+	var offset time.Duration
+	found := false
+	for _, candidate := range s.offsets {
+		if !now.Before(start.Add(-candidate)) {
+			offset = candidate
+			found = true
+		}
+	}
+
+	return offset, found
+}
+
+func (s *Scheduler) remind(ctx context.Context, channel notification.NotificationChannel, target *models.ReminderTarget, start time.Time, offset time.Duration) {
+	appointmentID := target.Appointment.IDProgramare
+	offsetMinutes := int(offset / time.Minute)
+
+	claimed, err := s.dbConn.ClaimReminder(ctx, appointmentID, offsetMinutes, channel.Name())
+	if err != nil || !claimed {
+		return
+	}
+
+	token := utils.GenerateConfirmationToken(s.config.Secret, appointmentID)
+	message := &models.Notification{
+		IDAppointment: appointmentID,
+		Subject:       "Appointment reminder",
+		Body: fmt.Sprintf(
+			"Hello %s %s, this is a reminder of your appointment on %s. Please confirm your attendance using the link below.",
+			target.FirstName,
+			target.SecondName,
+			start.Format("2006-01-02 15:04"),
+		),
+		ConfirmURL: fmt.Sprintf("%s/%d/confirm?%s=%s", s.config.ConfirmBaseURL, appointmentID, utils.QUERY_TOKEN, token),
+		CreatedAt:  time.Now().UTC(),
+	}
+
+	if err := channel.Send(ctx, target, message); err != nil {
+		log.Printf("[APPOINTMENT] Failed to send %s reminder for appointment %d: %v", channel.Name(), appointmentID, err)
+
+		// Release the claim so the reminder is retried on the next scan
+		if err := s.dbConn.ReleaseReminder(ctx, appointmentID, offsetMinutes, channel.Name()); err != nil {
+			log.Printf("[APPOINTMENT] Failed to release %s reminder for appointment %d: %v", channel.Name(), appointmentID, err)
+		}
+		return
+	}
+
+	log.Printf("[APPOINTMENT] Sent %s reminder (%d min before) for appointment %d", channel.Name(), offsetMinutes, appointmentID)
+}
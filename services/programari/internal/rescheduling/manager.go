@@ -0,0 +1,284 @@
+package rescheduling
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/mihnea1711/POS_Project/services/programari/internal/database"
+	"github.com/mihnea1711/POS_Project/services/programari/internal/models"
+	"github.com/mihnea1711/POS_Project/services/programari/internal/notification"
+	"github.com/mihnea1711/POS_Project/services/programari/internal/patients"
+	"github.com/mihnea1711/POS_Project/services/programari/pkg/config"
+	"github.com/mihnea1711/POS_Project/services/programari/pkg/utils"
+)
+
+// Manager runs the rescheduling jobs of unavailable doctors. It proposes replacement slots to the affected patients,
+// expires the proposals left unanswered and completes the jobs once every proposal is resolved.
+type Manager struct {
+	dbConn       database.Database
+	patients     patients.Directory
+	channels     []notification.NotificationChannel
+	interval     time.Duration
+	gracePeriod  time.Duration
+	dayStartHour int
+	capacity     int // slots of a doctor per day, 0 means no limit
+	config       config.ReschedulingConfig
+}
+
+func NewManager(dbConn database.Database, patientDirectory patients.Directory, channels []notification.NotificationChannel, reschedulingConfig config.ReschedulingConfig, clinicConfig config.ClinicConfig) *Manager {
+	interval := time.Duration(reschedulingConfig.ScanIntervalSeconds) * time.Second
+	if interval <= 0 {
+		interval = utils.DEFAULT_RESCHEDULING_SCAN_INTERVAL * time.Second
+	}
+
+	return &Manager{
+		dbConn:       dbConn,
+		patients:     patientDirectory,
+		channels:     channels,
+		interval:     interval,
+		gracePeriod:  time.Duration(reschedulingConfig.GracePeriodSeconds) * time.Second,
+		dayStartHour: clinicConfig.DayStartHour,
+		capacity:     clinicConfig.MaxAppointmentsPerDay,
+		config:       reschedulingConfig,
+	}
+}
+
+// Start runs the manager until the context is canceled
+func (m *Manager) Start(ctx context.Context) {
+	log.Printf("[APPOINTMENT] Rescheduling manager started. Scanning every %s.", m.interval)
+
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		m.scan(ctx, time.Now())
+
+		select {
+		case <-ctx.Done():
+			log.Println("[APPOINTMENT] Rescheduling manager stopped.")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (m *Manager) scan(ctx context.Context, now time.Time) {
+	scanCtx, cancel := context.WithTimeout(ctx, m.interval)
+	defer cancel()
+
+	// Jobs wait for the grace period, so a deactivation rolled back by the delete transaction moves no appointment
+	jobs, err := m.dbConn.FetchRunnableReschedulingJobs(scanCtx, now.Add(-m.gracePeriod))
+	if err != nil {
+		log.Printf("[APPOINTMENT] Rescheduling scan failed: %v", err)
+		return
+	}
+
+	for i := range jobs {
+		m.processJob(scanCtx, &jobs[i], now)
+	}
+
+	canceled, err := m.dbConn.ExpireReschedulingProposals(scanCtx, now)
+	if err != nil {
+		log.Printf("[APPOINTMENT] Failed to expire rescheduling proposals: %v", err)
+	}
+	for i := range canceled {
+		m.notify(scanCtx, &canceled[i], cancellationNotification(&canceled[i].Appointment))
+	}
+
+	completed, err := m.dbConn.CompleteReschedulingJobs(scanCtx)
+	if err != nil {
+		log.Printf("[APPOINTMENT] Failed to complete rescheduling jobs: %v", err)
+	} else if completed > 0 {
+		log.Printf("[APPOINTMENT] %d rescheduling jobs completed", completed)
+	}
+}
+
+// processJob proposes a replacement slot for every affected appointment the job did not handle yet
+func (m *Manager) processJob(ctx context.Context, job *models.ReschedulingJob, now time.Time) {
+	// A job started by a deactivation is dropped if the doctor is active again
+	if job.UnavailableUntil == nil {
+		active, err := m.dbConn.IsDoctorActive(ctx, job.IDDoctor)
+		if err != nil {
+			log.Printf("[APPOINTMENT] Rescheduling job %d: failed to check doctor %d: %v", job.IDJob, job.IDDoctor, err)
+			return
+		}
+		if active {
+			log.Printf("[APPOINTMENT] Rescheduling job %d aborted, doctor %d is active again", job.IDJob, job.IDDoctor)
+			if err := m.dbConn.SetReschedulingJobStatus(ctx, job.IDJob, utils.JobStatusAborted); err != nil {
+				log.Printf("[APPOINTMENT] Rescheduling job %d: failed to abort: %v", job.IDJob, err)
+			}
+			return
+		}
+	}
+
+	today := day(now)
+	targets, err := m.dbConn.FetchReschedulingCandidates(ctx, job.IDJob, job.IDDoctor, today, job.UnavailableUntil)
+	if err != nil {
+		log.Printf("[APPOINTMENT] Rescheduling job %d: failed to fetch affected appointments: %v", job.IDJob, err)
+		return
+	}
+
+	total, processed := job.Processed+len(targets), job.Processed
+	if err := m.dbConn.UpdateReschedulingJobProgress(ctx, job.IDJob, total, processed); err != nil {
+		return
+	}
+
+	if len(targets) > 0 {
+		log.Printf("[APPOINTMENT] Rescheduling job %d: %d appointments of doctor %d to reschedule", job.IDJob, len(targets), job.IDDoctor)
+
+		slots, err := m.newPlanner(ctx, job, targets, today.AddDate(0, 0, 1))
+		if err != nil {
+			log.Printf("[APPOINTMENT] Rescheduling job %d: failed to plan replacement slots: %v", job.IDJob, err)
+			return
+		}
+
+		for i := range targets {
+			if err := m.reschedule(ctx, job, slots, &targets[i], now); err != nil {
+				// The appointment is picked up again on the next scan
+				log.Printf("[APPOINTMENT] Rescheduling job %d: failed to handle appointment %d: %v", job.IDJob, targets[i].Appointment.IDProgramare, err)
+				return
+			}
+
+			processed++
+			if err := m.dbConn.UpdateReschedulingJobProgress(ctx, job.IDJob, total, processed); err != nil {
+				return
+			}
+		}
+	}
+
+	if err := m.dbConn.SetReschedulingJobStatus(ctx, job.IDJob, utils.JobStatusAwaitingResponses); err != nil {
+		return
+	}
+	log.Printf("[APPOINTMENT] Rescheduling job %d is waiting for patient responses", job.IDJob)
+}
+
+// reschedule proposes a replacement slot for the appointment, or cancels it if no slot is free
+func (m *Manager) reschedule(ctx context.Context, job *models.ReschedulingJob, slots *planner, target *models.ReminderTarget, now time.Time) error {
+	appointment := &target.Appointment
+	proposal := &models.ReschedulingProposal{
+		IDJob:          job.IDJob,
+		IDAppointment:  appointment.IDProgramare,
+		IDPatient:      appointment.IDPatient,
+		OriginalDoctor: appointment.IDDoctor,
+		OriginalDate:   appointment.Date,
+		Status:         utils.ProposalStatusUnavailable,
+	}
+
+	booked, err := m.patientBookings(ctx, appointment.IDPatient)
+	if err != nil {
+		return err
+	}
+
+	doctorID, date, found := slots.find(appointment, booked)
+	if found {
+		// Patients have to answer before the response window ends and before the proposed slot starts
+		expiresAt := now.Add(time.Duration(m.config.ResponseWindowHours) * time.Hour)
+		if start := utils.AppointmentStart(date, m.dayStartHour); start.Before(expiresAt) {
+			expiresAt = start
+		}
+
+		proposal.ProposedDoctor = &doctorID
+		proposal.ProposedDate = &date
+		proposal.ExpiresAt = &expiresAt
+		proposal.Status = utils.ProposalStatusPending
+	}
+
+	proposalID, err := m.dbConn.SaveReschedulingProposal(ctx, proposal)
+	if err != nil {
+		if found {
+			slots.release(doctorID, date)
+		}
+		return err
+	}
+
+	if !found {
+		log.Printf("[APPOINTMENT] Rescheduling job %d: no free slot for appointment %d, appointment canceled", job.IDJob, appointment.IDProgramare)
+		m.notify(ctx, target, cancellationNotification(appointment))
+		return nil
+	}
+
+	log.Printf("[APPOINTMENT] Rescheduling job %d: proposed doctor %d on %s for appointment %d", job.IDJob, doctorID, date.Format(utils.TIME_PARSE_SYNTAX), appointment.IDProgramare)
+	m.notify(ctx, target, m.proposalNotification(proposalID, proposal))
+	return nil
+}
+
+// patientBookings returns the doctor and day pairs the patient already has an appointment for
+func (m *Manager) patientBookings(ctx context.Context, patientID int) (map[string]bool, error) {
+	booked := make(map[string]bool)
+
+	for page := utils.DEFAULT_PAGINATION_PAGE; ; page++ {
+		appointments, err := m.dbConn.FetchAppointments(ctx, map[string]interface{}{utils.ColumnIDPatient: patientID}, page, utils.MAX_PAGINATION_LIMIT)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, appointment := range appointments {
+			booked[slotKey(appointment.IDDoctor, appointment.Date)] = true
+		}
+
+		if len(appointments) < utils.MAX_PAGINATION_LIMIT {
+			return booked, nil
+		}
+	}
+}
+
+func (m *Manager) proposalNotification(proposalID int, proposal *models.ReschedulingProposal) *models.Notification {
+	doctor := "another doctor of the same specialization"
+	if *proposal.ProposedDoctor == proposal.OriginalDoctor {
+		doctor = "the same doctor"
+	}
+
+	token := utils.GenerateProposalToken(m.config.Secret, proposalID)
+	return &models.Notification{
+		IDAppointment: proposal.IDAppointment,
+		Subject:       "Your appointment has to be rescheduled",
+		Body: fmt.Sprintf(
+			"Your doctor is unavailable on %s. We can offer you a new appointment on %s with %s. Please accept or decline before %s, otherwise the appointment will be canceled.",
+			proposal.OriginalDate.Format(utils.TIME_PARSE_SYNTAX),
+			proposal.ProposedDate.Format(utils.TIME_PARSE_SYNTAX),
+			doctor,
+			proposal.ExpiresAt.Format("2006-01-02 15:04"),
+		),
+		AcceptURL:  fmt.Sprintf("%s/%d/accept?%s=%s", m.config.ResponseBaseURL, proposalID, utils.QUERY_TOKEN, token),
+		DeclineURL: fmt.Sprintf("%s/%d/decline?%s=%s", m.config.ResponseBaseURL, proposalID, utils.QUERY_TOKEN, token),
+		CreatedAt:  time.Now().UTC(),
+	}
+}
+
+func cancellationNotification(appointment *models.Appointment) *models.Notification {
+	return &models.Notification{
+		IDAppointment: appointment.IDProgramare,
+		Subject:       "Your appointment has been canceled",
+		Body: fmt.Sprintf(
+			"Your appointment on %s has been canceled because your doctor is unavailable and no replacement was arranged. This cancellation does not count against you, please book a new appointment.",
+			appointment.Date.Format(utils.TIME_PARSE_SYNTAX),
+		),
+		CreatedAt: time.Now().UTC(),
+	}
+}
+
+// notify sends the notification through every channel, once the contact details of the patient are read.
+// Failures are logged, the proposal stays valid regardless.
+func (m *Manager) notify(ctx context.Context, target *models.ReminderTarget, message *models.Notification) {
+	if err := m.patients.FillContact(ctx, target); err != nil {
+		log.Printf("[APPOINTMENT] Failed to read the contact details for appointment %d, rescheduling notification not sent: %v", message.IDAppointment, err)
+		return
+	}
+
+	for _, channel := range m.channels {
+		if err := channel.Send(ctx, target, message); err != nil {
+			log.Printf("[APPOINTMENT] Failed to send %s rescheduling notification for appointment %d: %v", channel.Name(), message.IDAppointment, err)
+		}
+	}
+}
+
+// day returns the calendar day of t, in the same form as the dates read from the database
+func day(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func slotKey(doctorID int, date time.Time) string {
+	return fmt.Sprintf("%d/%s", doctorID, date.Format(utils.TIME_PARSE_SYNTAX))
+}
@@ -0,0 +1,122 @@
+package rescheduling
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/mihnea1711/POS_Project/services/programari/internal/models"
+)
+
+// planner finds free replacement slots for the appointments of a job.
+// Slots it hands out are reserved in memory, so the appointments of one run do not overbook a doctor.
+type planner struct {
+	originalDoctor   int
+	unavailableUntil *time.Time
+	replacements     []int
+	earliest         time.Time
+	windowDays       int
+	capacity         int // 0 means no limit
+	load             map[string]int
+}
+
+func (m *Manager) newPlanner(ctx context.Context, job *models.ReschedulingJob, targets []models.ReminderTarget, earliest time.Time) (*planner, error) {
+	replacements, err := m.dbConn.FetchReplacementDoctors(ctx, job.IDDoctor)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &planner{
+		originalDoctor:   job.IDDoctor,
+		unavailableUntil: job.UnavailableUntil,
+		replacements:     replacements,
+		earliest:         earliest,
+		windowDays:       m.config.SearchWindowDays,
+		capacity:         m.capacity,
+		load:             make(map[string]int),
+	}
+
+	doctorIDs := append([]int{}, replacements...)
+	if job.UnavailableUntil != nil {
+		doctorIDs = append(doctorIDs, job.IDDoctor)
+	}
+
+	// Targets are ordered by date, the last one bounds the days that can be proposed
+	latest := p.start(&targets[len(targets)-1].Appointment).AddDate(0, 0, p.windowDays)
+	if job.UnavailableUntil != nil && latest.Before(job.UnavailableUntil.AddDate(0, 0, p.windowDays+1)) {
+		latest = job.UnavailableUntil.AddDate(0, 0, p.windowDays+1)
+	}
+
+	loads, err := m.dbConn.FetchDoctorDailyLoad(ctx, doctorIDs, earliest, latest)
+	if err != nil {
+		return nil, err
+	}
+	for _, load := range loads {
+		p.load[slotKey(load.IDDoctor, load.Date)] += load.Count
+	}
+
+	return p, nil
+}
+
+// start returns the first day a replacement can be proposed for the appointment
+func (p *planner) start(appointment *models.Appointment) time.Time {
+	if appointment.Date.Before(p.earliest) {
+		return p.earliest
+	}
+	return day(appointment.Date)
+}
+
+// find returns the earliest free slot for the appointment. On the same day the original doctor is preferred,
+// then the least busy replacement doctor. Slots the patient already booked are skipped.
+func (p *planner) find(appointment *models.Appointment, booked map[string]bool) (int, time.Time, bool) {
+	first := p.start(appointment)
+	last := first.AddDate(0, 0, p.windowDays)
+
+	// The same doctor can only be proposed after returning, which may lie beyond the search window of the appointment
+	if p.unavailableUntil != nil {
+		if returning := p.unavailableUntil.AddDate(0, 0, p.windowDays+1); returning.After(last) {
+			last = returning
+		}
+	}
+
+	for date := first; !date.After(last); date = date.AddDate(0, 0, 1) {
+		for _, doctorID := range p.candidates(date, first.AddDate(0, 0, p.windowDays)) {
+			key := slotKey(doctorID, date)
+			if booked[key] || (p.capacity > 0 && p.load[key] >= p.capacity) {
+				continue
+			}
+
+			p.load[key]++
+			return doctorID, date, true
+		}
+	}
+
+	return 0, time.Time{}, false
+}
+
+// candidates lists the doctors that may take the appointment on the given day, in order of preference
+func (p *planner) candidates(date, replacementsUntil time.Time) []int {
+	var candidates []int
+	if p.unavailableUntil != nil && date.After(*p.unavailableUntil) {
+		candidates = append(candidates, p.originalDoctor)
+	}
+
+	if date.After(replacementsUntil) {
+		return candidates
+	}
+
+	replacements := append([]int{}, p.replacements...)
+	sort.SliceStable(replacements, func(i, j int) bool {
+		return p.load[slotKey(replacements[i], date)] < p.load[slotKey(replacements[j], date)]
+	})
+
+	return append(candidates, replacements...)
+}
+
+// release frees a slot reserved by find that could not be proposed
+func (p *planner) release(doctorID int, date time.Time) {
+	key := slotKey(doctorID, date)
+	if p.load[key] > 0 {
+		p.load[key]--
+	}
+}
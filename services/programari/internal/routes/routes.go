@@ -8,13 +8,16 @@ import (
 
 	"github.com/gorilla/mux"
 	"github.com/mihnea1711/POS_Project/services/programari/internal/controllers"
+	"github.com/mihnea1711/POS_Project/services/programari/internal/coverage"
 	"github.com/mihnea1711/POS_Project/services/programari/internal/database"
 	"github.com/mihnea1711/POS_Project/services/programari/internal/database/redis"
 	"github.com/mihnea1711/POS_Project/services/programari/internal/middleware"
+	"github.com/mihnea1711/POS_Project/services/programari/internal/policy"
+	"github.com/mihnea1711/POS_Project/services/programari/pkg/config"
 	"github.com/mihnea1711/POS_Project/services/programari/pkg/utils"
 )
 
-func SetupRoutes(ctx context.Context, dbConn database.Database, rdb *redis.RedisClient) *mux.Router {
+func SetupRoutes(ctx context.Context, dbConn database.Database, rdb *redis.RedisClient, appConfig *config.AppConfig) *mux.Router {
 	log.Println("[APPOINTMENT] Setting up rate limiter...")
 	rateLimiter := middleware.NewRedisRateLimiter(ctx, rdb.GetClient(), utils.LIMITER_REQUESTS_ALLOWED, utils.LIMITER_MINUTE_MULTIPLIER*time.Minute)
 	log.Println("[APPOINTMENT] Rate limiter set up successfully.")
@@ -32,7 +35,12 @@ func SetupRoutes(ctx context.Context, dbConn database.Database, rdb *redis.Redis
 	log.Println("[APPOINTMENT] Input sanitizer middleware set up successfully.")
 
 	appointmentsController := &controllers.AppointmentController{
-		DbConn: dbConn,
+		DbConn:              dbConn,
+		Policy:              policy.NewCancellationPolicy(dbConn, appConfig.Policy, appConfig.Clinic.DayStartHour),
+		Coverage:            coverage.NewCoverageChecker(dbConn, appConfig.Coverage),
+		ConfirmationSecret:  appConfig.Reminders.Secret,
+		ReschedulingSecret:  appConfig.Rescheduling.Secret,
+		DoctorDailyCapacity: appConfig.Clinic.MaxAppointmentsPerDay,
 	}
 
 	loadCrudRoutes(router, appointmentsController)
@@ -55,20 +63,97 @@ func loadCrudRoutes(router *mux.Router, appointmentController *controllers.Appoi
 	router.Handle(utils.CREATE_APPOINTMENT_ENDPOINT, middleware.ValidateAppointmentInfo(appointmentCreationHandler)).Methods("POST") // Creates a new appointment
 	log.Println("[APPOINTMENT] Route POST", utils.CREATE_APPOINTMENT_ENDPOINT, "registered.")
 
+	reschedulingJobCreationHandler := http.HandlerFunc(appointmentController.CreateReschedulingJob)
+	router.Handle(utils.CREATE_RESCHEDULING_JOB_ENDPOINT, middleware.ValidateReschedulingJobInfo(reschedulingJobCreationHandler)).Methods("POST") // Starts rescheduling the appointments of a doctor
+	log.Println("[APPOINTMENT] Route POST", utils.CREATE_RESCHEDULING_JOB_ENDPOINT, "registered.")
+
+	resourceCreationHandler := http.HandlerFunc(appointmentController.CreateResource)
+	router.Handle(utils.CREATE_RESOURCE_ENDPOINT, middleware.ValidateResourceInfo(resourceCreationHandler)).Methods("POST") // Adds a room or a piece of equipment
+	log.Println("[APPOINTMENT] Route POST", utils.CREATE_RESOURCE_ENDPOINT, "registered.")
+
+	appointmentTypeCreationHandler := http.HandlerFunc(appointmentController.CreateAppointmentType)
+	router.Handle(utils.CREATE_APPOINTMENT_TYPE_ENDPOINT, middleware.ValidateAppointmentTypeInfo(appointmentTypeCreationHandler)).Methods("POST") // Adds an appointment type and the resources it requires
+	log.Println("[APPOINTMENT] Route POST", utils.CREATE_APPOINTMENT_TYPE_ENDPOINT, "registered.")
+
+	patientReassignmentHandler := http.HandlerFunc(appointmentController.ReassignPatientAppointments)
+	router.Handle(utils.REASSIGN_PATIENT_APPOINTMENTS_ENDPOINT, middleware.ValidatePatientReassignmentInfo(patientReassignmentHandler)).Methods("POST") // Moves the appointments of a merged duplicate patient
+	log.Println("[APPOINTMENT] Route POST", utils.REASSIGN_PATIENT_APPOINTMENTS_ENDPOINT, "registered.")
+
+	patientErasureHandler := http.HandlerFunc(appointmentController.ErasePatientAppointments)
+	router.Handle(utils.ERASE_PATIENT_APPOINTMENTS_ENDPOINT, patientErasureHandler).Methods("POST") // Erases the appointment data of a patient
+	log.Println("[APPOINTMENT] Route POST", utils.ERASE_PATIENT_APPOINTMENTS_ENDPOINT, "registered.")
+
 	// ---------------------------------------------------------- Retrieve --------------------------------------------------------------
 	appointmentFetchAllHandler := http.HandlerFunc(appointmentController.GetAppointments)
 	router.HandleFunc(utils.FETCH_ALL_APPOINTMENTS_ENDPOINT, appointmentFetchAllHandler).Methods("GET") // Lists all appointments
 	log.Println("[APPOINTMENT] Route GET", utils.FETCH_ALL_APPOINTMENTS_ENDPOINT, "registered.")
 
+	// Registered before the fetch by ID route so the static segments below are not parsed as an appointment ID
+	offendersFetchHandler := http.HandlerFunc(appointmentController.GetPolicyOffenders)
+	router.HandleFunc(utils.FETCH_POLICY_OFFENDERS_ENDPOINT, offendersFetchHandler).Methods("GET") // Lists the patients breaking the cancellation policy
+	log.Println("[APPOINTMENT] Route GET", utils.FETCH_POLICY_OFFENDERS_ENDPOINT, "registered.")
+
+	statusHistoryFetchHandler := http.HandlerFunc(appointmentController.GetPatientStatusHistory)
+	router.HandleFunc(utils.FETCH_STATUS_HISTORY_ENDPOINT, statusHistoryFetchHandler).Methods("GET") // Lists the status changes of a patient's appointments, the latest first
+	log.Println("[APPOINTMENT] Route GET", utils.FETCH_STATUS_HISTORY_ENDPOINT, "registered.")
+
+	reschedulingJobsFetchHandler := http.HandlerFunc(appointmentController.GetReschedulingJobs)
+	router.HandleFunc(utils.FETCH_ALL_RESCHEDULING_JOBS_ENDPOINT, reschedulingJobsFetchHandler).Methods("GET") // Lists the rescheduling jobs and their progress
+	log.Println("[APPOINTMENT] Route GET", utils.FETCH_ALL_RESCHEDULING_JOBS_ENDPOINT, "registered.")
+
+	reschedulingJobFetchByIDHandler := http.HandlerFunc(appointmentController.GetReschedulingJobByID)
+	router.HandleFunc(utils.FETCH_RESCHEDULING_JOB_BY_ID_ENDPOINT, reschedulingJobFetchByIDHandler).Methods("GET") // Get a rescheduling job and its proposals
+	log.Println("[APPOINTMENT] Route GET", utils.FETCH_RESCHEDULING_JOB_BY_ID_ENDPOINT, "registered.")
+
+	resourcesFetchHandler := http.HandlerFunc(appointmentController.GetResources)
+	router.HandleFunc(utils.FETCH_ALL_RESOURCES_ENDPOINT, resourcesFetchHandler).Methods("GET") // Lists the rooms and equipment
+	log.Println("[APPOINTMENT] Route GET", utils.FETCH_ALL_RESOURCES_ENDPOINT, "registered.")
+
+	appointmentTypesFetchHandler := http.HandlerFunc(appointmentController.GetAppointmentTypes)
+	router.HandleFunc(utils.FETCH_ALL_APPOINTMENT_TYPES_ENDPOINT, appointmentTypesFetchHandler).Methods("GET") // Lists the appointment types and their requirements
+	log.Println("[APPOINTMENT] Route GET", utils.FETCH_ALL_APPOINTMENT_TYPES_ENDPOINT, "registered.")
+
+	availabilityFetchHandler := http.HandlerFunc(appointmentController.GetAvailability)
+	router.HandleFunc(utils.FETCH_AVAILABILITY_ENDPOINT, availabilityFetchHandler).Methods("GET") // Intersects the doctor, room and equipment calendars
+	log.Println("[APPOINTMENT] Route GET", utils.FETCH_AVAILABILITY_ENDPOINT, "registered.")
+
 	appointmentFetchByIDHandler := http.HandlerFunc(appointmentController.GetAppointmentByID)
 	router.HandleFunc(utils.FETCH_APPOINTMENT_BY_ID_ENDPOINT, appointmentFetchByIDHandler).Methods("GET") // Get a specific appointment by ID
 	log.Println("[APPOINTMENT] Route GET", utils.FETCH_APPOINTMENT_BY_ID_ENDPOINT, "registered.")
 
+	appointmentResourcesFetchHandler := http.HandlerFunc(appointmentController.GetAppointmentResources)
+	router.HandleFunc(utils.FETCH_APPOINTMENT_RESOURCES_ENDPOINT, appointmentResourcesFetchHandler).Methods("GET") // Lists the resources reserved for an appointment
+	log.Println("[APPOINTMENT] Route GET", utils.FETCH_APPOINTMENT_RESOURCES_ENDPOINT, "registered.")
+
+	appointmentCoverageFetchHandler := http.HandlerFunc(appointmentController.GetAppointmentCoverage)
+	router.HandleFunc(utils.FETCH_APPOINTMENT_COVERAGE_ENDPOINT, appointmentCoverageFetchHandler).Methods("GET") // Tells whether the patient is insured on the day of the appointment
+	log.Println("[APPOINTMENT] Route GET", utils.FETCH_APPOINTMENT_COVERAGE_ENDPOINT, "registered.")
+
 	// ---------------------------------------------------------- Update --------------------------------------------------------------
 	appointmentUpdateByIDHandler := http.HandlerFunc(appointmentController.UpdateAppointmentByID)
 	router.Handle(utils.UPDATE_APPOINTMENT_BY_ID_ENDPOINT, middleware.ValidateAppointmentInfo(appointmentUpdateByIDHandler)).Methods("PUT") // Updates a specific appointment
 	log.Println("[APPOINTMENT] Route PUT", utils.UPDATE_APPOINTMENT_BY_ID_ENDPOINT, "registered.")
 
+	resourceUpdateByIDHandler := http.HandlerFunc(appointmentController.UpdateResourceByID)
+	router.Handle(utils.UPDATE_RESOURCE_BY_ID_ENDPOINT, middleware.ValidateResourceInfo(resourceUpdateByIDHandler)).Methods("PUT") // Updates or deactivates a resource
+	log.Println("[APPOINTMENT] Route PUT", utils.UPDATE_RESOURCE_BY_ID_ENDPOINT, "registered.")
+
+	appointmentConfirmHandler := http.HandlerFunc(appointmentController.ConfirmAppointment)
+	router.Handle(utils.CONFIRM_APPOINTMENT_ENDPOINT, appointmentConfirmHandler).Methods("GET") // Confirms a scheduled appointment from a reminder link
+	log.Println("[APPOINTMENT] Route GET", utils.CONFIRM_APPOINTMENT_ENDPOINT, "registered.")
+
+	appointmentHonorHandler := http.HandlerFunc(appointmentController.HonorAppointment)
+	router.Handle(utils.HONOR_APPOINTMENT_ENDPOINT, appointmentHonorHandler).Methods("POST") // Marks the visit of an appointment as taken place when its consultation is opened
+	log.Println("[APPOINTMENT] Route POST", utils.HONOR_APPOINTMENT_ENDPOINT, "registered.")
+
+	proposalAcceptHandler := http.HandlerFunc(appointmentController.AcceptReschedulingProposal)
+	router.Handle(utils.ACCEPT_RESCHEDULING_PROPOSAL_ENDPOINT, proposalAcceptHandler).Methods("GET") // Moves an appointment to the proposed slot from a rescheduling link
+	log.Println("[APPOINTMENT] Route GET", utils.ACCEPT_RESCHEDULING_PROPOSAL_ENDPOINT, "registered.")
+
+	proposalDeclineHandler := http.HandlerFunc(appointmentController.DeclineReschedulingProposal)
+	router.Handle(utils.DECLINE_RESCHEDULING_PROPOSAL_ENDPOINT, proposalDeclineHandler).Methods("GET") // Cancels an appointment from a rescheduling link
+	log.Println("[APPOINTMENT] Route GET", utils.DECLINE_RESCHEDULING_PROPOSAL_ENDPOINT, "registered.")
+
 	// ---------------------------------------------------------- Delete --------------------------------------------------------------
 	appointmentDeleteByIDHandler := http.HandlerFunc(appointmentController.DeleteAppointmentByID)
 	router.Handle(utils.DELETE_APPOINTMENT_BY_ID_ENDPOINT, appointmentDeleteByIDHandler).Methods("DELETE") // Deletes a appointment
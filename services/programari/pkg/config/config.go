@@ -12,9 +12,15 @@ import (
 )
 
 type AppConfig struct {
-	Server ServerConfig `yaml:"server"`
-	MySQL  MySQLConfig  `yaml:"mysql_db"`
-	Redis  RedisConfig  `yaml:"redis"`
+	Server       ServerConfig       `yaml:"server"`
+	MySQL        MySQLConfig        `yaml:"mysql_db"`
+	Redis        RedisConfig        `yaml:"redis"`
+	Clinic       ClinicConfig       `yaml:"clinic"`
+	Reminders    ReminderConfig     `yaml:"reminders"`
+	Policy       PolicyConfig       `yaml:"policy"`
+	Rescheduling ReschedulingConfig `yaml:"rescheduling"`
+	Coverage     CoverageConfig     `yaml:"coverage"`
+	Patients     PatientsConfig     `yaml:"patients"`
 }
 
 type ServerConfig struct {
@@ -41,6 +47,55 @@ type RedisConfig struct {
 	DB       int    `yaml:"db"` // usually 0 unless you're using multiple databases
 }
 
+type ClinicConfig struct {
+	DayStartHour          int `yaml:"dayStartHour"`          // appointments are stored per day and are considered to start at this hour
+	MaxAppointmentsPerDay int `yaml:"maxAppointmentsPerDay"` // slots of a doctor per day, 0 means no limit
+}
+
+type ReminderConfig struct {
+	Enabled             bool             `yaml:"enabled"`
+	ScanIntervalSeconds int              `yaml:"scanIntervalSeconds"`
+	OffsetsMinutes      []int            `yaml:"offsetsMinutes"`
+	Secret              string           `yaml:"secret"`
+	ConfirmBaseURL      string           `yaml:"confirmBaseURL"`
+	Email               NotificationSink `yaml:"email"`
+	SMS                 NotificationSink `yaml:"sms"`
+}
+
+type NotificationSink struct {
+	Enabled  bool   `yaml:"enabled"`
+	SinkPath string `yaml:"sinkPath"`
+}
+
+type PolicyConfig struct {
+	MinCancellationNoticeHours int `yaml:"minCancellationNoticeHours"`
+	MaxLateCancellations       int `yaml:"maxLateCancellations"`
+	LateCancellationPeriodDays int `yaml:"lateCancellationPeriodDays"`
+	NoShowThreshold            int `yaml:"noShowThreshold"`
+	NoShowPeriodDays           int `yaml:"noShowPeriodDays"`
+}
+
+type ReschedulingConfig struct {
+	Enabled             bool   `yaml:"enabled"`
+	ScanIntervalSeconds int    `yaml:"scanIntervalSeconds"`
+	GracePeriodSeconds  int    `yaml:"gracePeriodSeconds"` // jobs wait this long so a rolled back deactivation does not move appointments
+	SearchWindowDays    int    `yaml:"searchWindowDays"`
+	ResponseWindowHours int    `yaml:"responseWindowHours"`
+	Secret              string `yaml:"secret"`
+	ResponseBaseURL     string `yaml:"responseBaseURL"`
+}
+
+// CoverageConfig drives the insurance check on booking. When Required is set uninsured patients may only be booked with an admin override.
+type CoverageConfig struct {
+	Enabled  bool `yaml:"enabled"`
+	Required bool `yaml:"required"`
+}
+
+// PatientsConfig locates the patient module, the contact details of the patients are read from its API
+type PatientsConfig struct {
+	BaseURL string `yaml:"baseURL"`
+}
+
 // LoadConfig loads the configuration from the given file path
 func LoadConfig(filePath string) (*AppConfig, error) {
 	log.Println("[APPOINTMENT] Loading configuration...")
@@ -14,9 +14,35 @@ const (
 
 var ValidStatus = [...]models.StatusAppointment{StatusScheduled, StatusConfirmed, StatusNotPresent, StatusCanceled, StatusHonored}
 
+const (
+	JobStatusRunning           models.JobStatus = "running"
+	JobStatusAwaitingResponses models.JobStatus = "awaiting_responses"
+	JobStatusCompleted         models.JobStatus = "completed"
+	JobStatusAborted           models.JobStatus = "aborted"
+)
+
+const (
+	ProposalStatusPending     models.ProposalStatus = "pending"
+	ProposalStatusAccepted    models.ProposalStatus = "accepted"
+	ProposalStatusDeclined    models.ProposalStatus = "declined"
+	ProposalStatusExpired     models.ProposalStatus = "expired"
+	ProposalStatusUnavailable models.ProposalStatus = "unavailable"
+)
+
+const (
+	ResourceKindRoom      models.ResourceKind = "room"
+	ResourceKindEquipment models.ResourceKind = "equipment"
+)
+
+var ValidResourceKinds = [...]models.ResourceKind{ResourceKindRoom, ResourceKindEquipment}
+
 const CONFIG_PATH = "configs/config.yaml"
 
 const DECODED_APPOINTMENT contextKey = "decodedAppointment"
+const DECODED_RESCHEDULING_JOB contextKey = "decodedReschedulingJob"
+const DECODED_RESOURCE contextKey = "decodedResource"
+const DECODED_APPOINTMENT_TYPE contextKey = "decodedAppointmentType"
+const DECODED_PATIENT_REASSIGNMENT contextKey = "decodedPatientReassignment"
 
 const (
 	LIMITER_REQUESTS_ALLOWED  = 10
@@ -34,13 +60,39 @@ const CLEAR_DB_RESOURCES_TIMEOUT = 10
 
 const TIME_PARSE_SYNTAX = "2006-01-02"
 
+// MIN_TOKEN_SECRET_LENGTH is the shortest secret the links sent to patients may be signed with, the size of the
+// HMAC-SHA256 key. The links skip the gateway authentication, so a guessable secret lets anyone forge them.
+const MIN_TOKEN_SECRET_LENGTH = 32
+
 const (
 	// Endpoints
-	CREATE_APPOINTMENT_ENDPOINT       = "/appointments"
-	FETCH_ALL_APPOINTMENTS_ENDPOINT   = "/appointments"
-	FETCH_APPOINTMENT_BY_ID_ENDPOINT  = "/appointments/{" + FETCH_APPOINTMENT_BY_ID_PARAMETER + "}"
-	UPDATE_APPOINTMENT_BY_ID_ENDPOINT = "/appointments/{" + UPDATE_APPOINTMENT_BY_ID_PARAMETER + "}"
-	DELETE_APPOINTMENT_BY_ID_ENDPOINT = "/appointments/{" + DELETE_APPOINTMENT_BY_ID_PARAMETER + "}"
+	CREATE_APPOINTMENT_ENDPOINT          = "/appointments"
+	FETCH_ALL_APPOINTMENTS_ENDPOINT      = "/appointments"
+	FETCH_APPOINTMENT_BY_ID_ENDPOINT     = "/appointments/{" + FETCH_APPOINTMENT_BY_ID_PARAMETER + "}"
+	UPDATE_APPOINTMENT_BY_ID_ENDPOINT    = "/appointments/{" + UPDATE_APPOINTMENT_BY_ID_PARAMETER + "}"
+	DELETE_APPOINTMENT_BY_ID_ENDPOINT    = "/appointments/{" + DELETE_APPOINTMENT_BY_ID_PARAMETER + "}"
+	CONFIRM_APPOINTMENT_ENDPOINT         = "/appointments/{" + CONFIRM_APPOINTMENT_PARAMETER + "}/confirm"
+	HONOR_APPOINTMENT_ENDPOINT           = "/appointments/{" + HONOR_APPOINTMENT_PARAMETER + "}/honor"
+	FETCH_POLICY_OFFENDERS_ENDPOINT      = "/appointments/reports/offenders"
+	FETCH_STATUS_HISTORY_ENDPOINT        = "/appointments/status-history"
+	FETCH_AVAILABILITY_ENDPOINT          = "/appointments/availability"
+	FETCH_APPOINTMENT_RESOURCES_ENDPOINT = "/appointments/{" + FETCH_APPOINTMENT_BY_ID_PARAMETER + "}/resources"
+	FETCH_APPOINTMENT_COVERAGE_ENDPOINT  = "/appointments/{" + FETCH_APPOINTMENT_BY_ID_PARAMETER + "}/coverage"
+
+	CREATE_RESOURCE_ENDPOINT             = "/appointments/resources"
+	FETCH_ALL_RESOURCES_ENDPOINT         = "/appointments/resources"
+	UPDATE_RESOURCE_BY_ID_ENDPOINT       = "/appointments/resources/{" + RESOURCE_ID_PARAMETER + "}"
+	CREATE_APPOINTMENT_TYPE_ENDPOINT     = "/appointments/types"
+	FETCH_ALL_APPOINTMENT_TYPES_ENDPOINT = "/appointments/types"
+
+	REASSIGN_PATIENT_APPOINTMENTS_ENDPOINT = "/appointments/patients/reassign"
+	ERASE_PATIENT_APPOINTMENTS_ENDPOINT    = "/appointments/patients/{" + ERASURE_PATIENT_ID_PARAMETER + "}/erasure"
+
+	CREATE_RESCHEDULING_JOB_ENDPOINT       = "/appointments/rescheduling-jobs"
+	FETCH_ALL_RESCHEDULING_JOBS_ENDPOINT   = "/appointments/rescheduling-jobs"
+	FETCH_RESCHEDULING_JOB_BY_ID_ENDPOINT  = "/appointments/rescheduling-jobs/{" + RESCHEDULING_JOB_ID_PARAMETER + "}"
+	ACCEPT_RESCHEDULING_PROPOSAL_ENDPOINT  = "/appointments/rescheduling-proposals/{" + RESCHEDULING_PROPOSAL_ID_PARAMETER + "}/accept"
+	DECLINE_RESCHEDULING_PROPOSAL_ENDPOINT = "/appointments/rescheduling-proposals/{" + RESCHEDULING_PROPOSAL_ID_PARAMETER + "}/decline"
 
 	HEALTH_CHECK_ENDPOINT = "/appointments/health-check"
 )
@@ -50,6 +102,12 @@ const (
 	FETCH_APPOINTMENT_BY_ID_PARAMETER  = "appointmentID"
 	UPDATE_APPOINTMENT_BY_ID_PARAMETER = "appointmentID"
 	DELETE_APPOINTMENT_BY_ID_PARAMETER = "appointmentID"
+	CONFIRM_APPOINTMENT_PARAMETER      = "appointmentID"
+	HONOR_APPOINTMENT_PARAMETER        = "appointmentID"
+	RESCHEDULING_JOB_ID_PARAMETER      = "jobID"
+	RESCHEDULING_PROPOSAL_ID_PARAMETER = "proposalID"
+	RESOURCE_ID_PARAMETER              = "resourceID"
+	ERASURE_PATIENT_ID_PARAMETER       = "patientID"
 
 	QUERY_PATIENT_ID = "patientID"
 	QUERY_DOCTOR_ID  = "doctorID"
@@ -57,6 +115,13 @@ const (
 	QUERY_STATUS     = "status"
 	QUERY_PAGE       = "page"
 	QUERY_LIMIT      = "limit"
+	QUERY_TOKEN      = "token"
+	QUERY_OVERRIDE   = "override"
+	QUERY_TYPE_ID    = "typeID"
+	QUERY_FROM       = "from"
+	QUERY_TO         = "to"
+	QUERY_CATEGORY   = "category"
+	QUERY_KIND       = "kind"
 )
 
 const (
@@ -67,6 +132,103 @@ const (
 	ColumnIDDoctor       = "id_doctor"
 	ColumnDate           = "date"
 	ColumnStatus         = "status"
+	ColumnIDType         = "id_type"
+
+	ResourceTableName   = "resource"
+	ColumnIDResource    = "id_resource"
+	ColumnName          = "name"
+	ColumnKind          = "kind"
+	ColumnCategory      = "category"
+	ColumnDailyCapacity = "daily_capacity"
+
+	AppointmentTypeTableName = "appointment_type"
+	RequirementTableName     = "appointment_type_requirement"
+	ColumnQuantity           = "quantity"
+
+	AppointmentResourceTableName = "appointment_resource"
+
+	ReminderTableName   = "appointment_reminder"
+	ColumnOffsetMinutes = "offset_minutes"
+	ColumnChannel       = "channel"
+	ColumnSentAt        = "sent_at"
+
+	StatusHistoryTableName = "appointment_status_history"
+	ColumnOldStatus        = "old_status"
+	ColumnNewStatus        = "new_status"
+	ColumnAppointmentDate  = "appointment_date"
+	ColumnChangedAt        = "changed_at"
+	ColumnPolicyExempt     = "policy_exempt"
+	ColumnIDHistory        = "id_history"
+
+	ReschedulingJobTableName = "rescheduling_job"
+	ColumnIDJob              = "id_job"
+	ColumnUnavailableUntil   = "unavailable_until"
+	ColumnTotal              = "total"
+	ColumnProcessed          = "processed"
+	ColumnCreatedAt          = "created_at"
+	ColumnUpdatedAt          = "updated_at"
+
+	ReschedulingProposalTableName = "rescheduling_proposal"
+	ColumnIDProposal              = "id_proposal"
+	ColumnOriginalDoctor          = "original_doctor"
+	ColumnOriginalDate            = "original_date"
+	ColumnProposedDoctor          = "proposed_doctor"
+	ColumnProposedDate            = "proposed_date"
+	ColumnExpiresAt               = "expires_at"
+	ColumnRespondedAt             = "responded_at"
+
+	DoctorTableName      = "doctor"
+	ColumnSpecialization = "specialization"
+	ColumnIsActive       = "is_active"
+
+	InsurancePolicyTableName = "patient_insurance_policy"
+	ColumnPayerType          = "payer_type"
+	ColumnValidFrom          = "valid_from"
+	ColumnValidUntil         = "valid_until"
+	ColumnVerificationStatus = "verification_status"
+)
+
+const (
+	CHANNEL_EMAIL = "email"
+	CHANNEL_SMS   = "sms"
+)
+
+const (
+	DEFAULT_REMINDER_SCAN_INTERVAL = 60
+	REMINDER_SINK_FILE_PERMISSIONS = 0644
+	REMINDER_SINK_DIR_PERMISSIONS  = 0755
+)
+
+const DEFAULT_RESCHEDULING_SCAN_INTERVAL = 30
+
+// The patient module keeps the contact details of the patients, encrypted, and decrypts them on read
+const (
+	PATIENT_LOOKUP_TIMEOUT               = 5 // seconds
+	PATIENT_FETCH_PATIENT_BY_ID_ENDPOINT = "/patients"
+)
+
+// The verification statuses of an insurance policy, as stored by the patient module
+const (
+	VerificationStatusVerified = "verified"
+	VerificationStatusRejected = "rejected"
+)
+
+const (
+	DEFAULT_AVAILABILITY_DAYS = 7
+	MAX_AVAILABILITY_DAYS     = 31
+)
+
+const (
+	ErasureActionDeleted  models.ErasureAction = "deleted"
+	ErasureActionRetained models.ErasureAction = "retained"
+)
+
+// Categories of appointment data reported by the erasure of a patient
+const (
+	ERASURE_CATEGORY_UPCOMING_APPOINTMENTS   = "upcomingAppointments"
+	ERASURE_CATEGORY_RESCHEDULING_PROPOSALS  = "reschedulingProposals"
+	ERASURE_CATEGORY_APPOINTMENTS            = "appointments"
+	ERASURE_CATEGORY_APPOINTMENT_STATUS_LOGS = "appointmentStatusHistory"
 )
 
 const MySQLDuplicateEntryErrorCode = 1062
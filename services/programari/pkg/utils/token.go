@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+)
+
+// GenerateConfirmationToken signs the appointment ID with the reminder secret.
+// The token is embedded in the confirmation link sent to the patient.
+func GenerateConfirmationToken(secret string, appointmentID int) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.Itoa(appointmentID)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ValidateConfirmationToken checks that the token was generated for the given appointment ID.
+// No token is valid under a secret too short to be kept from guessing, such as an unset one.
+func ValidateConfirmationToken(secret string, appointmentID int, token string) bool {
+	if len(secret) < MIN_TOKEN_SECRET_LENGTH {
+		return false
+	}
+	expected := GenerateConfirmationToken(secret, appointmentID)
+	return hmac.Equal([]byte(expected), []byte(token))
+}
+
+// GenerateProposalToken signs a rescheduling proposal ID with the rescheduling secret.
+// The same token authorizes both accepting and declining the proposal.
+func GenerateProposalToken(secret string, proposalID int) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("proposal:" + strconv.Itoa(proposalID)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ValidateProposalToken checks that the token was generated for the given proposal ID.
+// No token is valid under a secret too short to be kept from guessing, such as an unset one.
+func ValidateProposalToken(secret string, proposalID int, token string) bool {
+	if len(secret) < MIN_TOKEN_SECRET_LENGTH {
+		return false
+	}
+	expected := GenerateProposalToken(secret, proposalID)
+	return hmac.Equal([]byte(expected), []byte(token))
+}
@@ -153,3 +153,8 @@ func isExpectedFilter(filterName string) bool {
 	_, ok := expectedFilters[filterName]
 	return ok
 }
+
+// AppointmentStart computes the moment an appointment begins, since appointments are stored with day granularity
+func AppointmentStart(date time.Time, dayStartHour int) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day(), dayStartHour, 0, 0, 0, time.Local)
+}
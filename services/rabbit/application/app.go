@@ -9,6 +9,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/mihnea1711/POS_Project/services/rabbit/idm"
+	"github.com/mihnea1711/POS_Project/services/rabbit/internal/erasure"
 	"github.com/mihnea1711/POS_Project/services/rabbit/internal/models"
 	"github.com/mihnea1711/POS_Project/services/rabbit/internal/models/participants"
 	"github.com/mihnea1711/POS_Project/services/rabbit/internal/rabbitmq"
@@ -19,10 +20,11 @@ import (
 )
 
 type App struct {
-	webServer *http.Server
-	idmClient idm.IDMClient
-	rabbitMQ  *rabbitmq.RabbitMQ
-	config    *config.AppConfig
+	webServer    *http.Server
+	idmClient    idm.IDMClient
+	rabbitMQ     *rabbitmq.RabbitMQ
+	certificates *erasure.Store
+	config       *config.AppConfig
 }
 
 func New(config *config.AppConfig, parentCtx context.Context) (*App, error) {
@@ -39,8 +41,16 @@ func New(config *config.AppConfig, parentCtx context.Context) (*App, error) {
 	app.rabbitMQ = rabbit
 	log.Println("[RABBIT] RabbitMQ connection successfully established.")
 
+	// Erasure certificates are written by the erasure handler and read through the web part
+	certificates, err := erasure.NewStore(config.Erasure)
+	if err != nil {
+		log.Printf("[RABBIT] Failed to initialize erasure certificate store: %v", err)
+		return nil, fmt.Errorf("failed to initialize erasure certificate store: %w", err)
+	}
+	app.certificates = certificates
+
 	// Setup router for the app with the rabbit in case the web part wants to publish to queues or smth
-	router := routes.SetupRoutes(rabbit, config.JWT)
+	router := routes.SetupRoutes(rabbit, certificates, config.JWT)
 	log.Println("[RABBIT] HTTP Routes successfully loaded.")
 
 	// Setup server
@@ -84,11 +94,20 @@ func (a *App) Start(ctx context.Context) error {
 		participants.NewPatient(uuid.New(), utils.PATIENT),
 		participants.NewDoctor(uuid.New(), utils.DOCTOR),
 	}
+	// The patient is erased last, a repeated erasure still finds the patient of the user
+	erasureParticipantList := []models.Transactional{
+		participants.NewIDM(uuid.New(), utils.IDM, a.idmClient),
+		participants.NewAppointmentErasure(uuid.New(), utils.APPOINTMENT_ERASURE),
+		participants.NewConsultationErasure(uuid.New(), utils.CONSULTATION_ERASURE),
+		participants.NewPatientErasure(uuid.New(), utils.PATIENT_ERASURE),
+	}
 	// Store dependencies in the service container
 	serviceContainer := &services.ServiceContainer{
-		IDMClient:    a.idmClient,
-		JWTConfig:    a.config.JWT,
-		Participants: participantList,
+		IDMClient:           a.idmClient,
+		JWTConfig:           a.config.JWT,
+		Participants:        participantList,
+		ErasureParticipants: erasureParticipantList,
+		Certificates:        a.certificates,
 	}
 
 	// Set up the handlers for the queues with the service container
@@ -4,11 +4,13 @@ import (
 	"log"
 	"net/http"
 
+	"github.com/mihnea1711/POS_Project/services/rabbit/internal/erasure"
 	"github.com/mihnea1711/POS_Project/services/rabbit/internal/rabbitmq"
 )
 
 type RabbitController struct {
-	RabbitMQ *rabbitmq.RabbitMQ
+	RabbitMQ     *rabbitmq.RabbitMQ
+	Certificates *erasure.Store
 }
 
 func (rc *RabbitController) HandleHealthCheck(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,97 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/mihnea1711/POS_Project/services/rabbit/internal/erasure"
+	"github.com/mihnea1711/POS_Project/services/rabbit/internal/middleware"
+	"github.com/mihnea1711/POS_Project/services/rabbit/internal/middleware/validation"
+	"github.com/mihnea1711/POS_Project/services/rabbit/internal/models"
+	"github.com/mihnea1711/POS_Project/services/rabbit/pkg/utils"
+)
+
+// RequestErasure opens a pending erasure certificate and publishes the erasure of the user to the erasure queue.
+// The certificate tells the outcome once the erasure is handled.
+func (rc *RabbitController) RequestErasure(w http.ResponseWriter, r *http.Request) {
+	log.Println("[RABBIT] Requesting the erasure of a user...")
+
+	userID, err := validation.ValidateUserID(r)
+	if err != nil {
+		log.Printf("[RABBIT] Validation error: %v\n", err)
+		utils.RespondWithJSON(w, http.StatusUnprocessableEntity, models.ResponseData{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	// The subject of the token is the admin asking for the erasure
+	requestedBy := 0
+	if claims, ok := r.Context().Value(utils.JWT_CLAIMS_CONTEXT_KEY).(*middleware.MyCustomClaims); ok {
+		requestedBy, _ = strconv.Atoi(claims.Subject)
+	}
+
+	certificate, err := rc.Certificates.Start(userID, requestedBy)
+	if err != nil {
+		log.Printf("[RABBIT] Error opening erasure certificate: %v\n", err)
+		utils.RespondWithJSON(w, http.StatusInternalServerError, models.ResponseData{
+			Error:   err.Error(),
+			Message: "Failed to request erasure",
+		})
+		return
+	}
+
+	err = rc.publishToQueue(utils.ERASURE_QUEUE, models.ErasureMessageData{
+		IDUser:      userID,
+		IDErasure:   certificate.IDErasure,
+		RequestedBy: requestedBy,
+	})
+	if err != nil {
+		log.Printf("[RABBIT] Error publishing erasure to queue: %v\n", err)
+		utils.RespondWithJSON(w, http.StatusInternalServerError, models.ResponseData{
+			Error:   err.Error(),
+			Message: "Failed to request erasure",
+		})
+		return
+	}
+
+	log.Printf("[RABBIT] Erasure %s of user %d published to RabbitMQ\n", certificate.IDErasure, userID)
+	w.Header().Set("Location", fmt.Sprintf("%s/%s", utils.ERASURES_ENDPOINT, certificate.IDErasure))
+	utils.RespondWithJSON(w, http.StatusAccepted, models.ResponseData{
+		Message: fmt.Sprintf("Erasure of user %d requested", userID),
+		Payload: certificate,
+	})
+}
+
+func (rc *RabbitController) GetErasureCertificate(w http.ResponseWriter, r *http.Request) {
+	erasureID := mux.Vars(r)[utils.ERASURE_ID_PARAMETER]
+	log.Printf("[RABBIT] Fetching erasure certificate %s...\n", erasureID)
+
+	certificate, err := rc.Certificates.Get(erasureID)
+	if err != nil {
+		if errors.Is(err, erasure.ErrCertificateNotFound) {
+			log.Printf("[RABBIT] Erasure certificate %s not found\n", erasureID)
+			utils.RespondWithJSON(w, http.StatusNotFound, models.ResponseData{
+				Error:   err.Error(),
+				Message: fmt.Sprintf("No erasure found with ID: %s", erasureID),
+			})
+			return
+		}
+
+		log.Printf("[RABBIT] Error fetching erasure certificate %s: %v\n", erasureID, err)
+		utils.RespondWithJSON(w, http.StatusInternalServerError, models.ResponseData{
+			Error:   err.Error(),
+			Message: "Failed to fetch erasure certificate",
+		})
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, models.ResponseData{
+		Message: fmt.Sprintf("Erasure %s is %s", certificate.IDErasure, certificate.Status),
+		Payload: certificate,
+	})
+}
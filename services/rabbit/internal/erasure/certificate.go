@@ -0,0 +1,73 @@
+package erasure
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/mihnea1711/POS_Project/services/rabbit/internal/models"
+)
+
+type Status string
+
+const (
+	StatusPending   Status = "pending"
+	StatusCompleted Status = "completed"
+	StatusRejected  Status = "rejected"
+	StatusPartial   Status = "partial"
+	StatusFailed    Status = "failed"
+)
+
+// ParticipantOutcome is what one module answered when it was asked to erase the data of the user
+type ParticipantOutcome struct {
+	Participant models.ParticipantType `json:"participant"`
+	Code        int                    `json:"code"`
+	Message     string                 `json:"message"`
+	Records     []models.ErasureRecord `json:"records,omitempty"`
+}
+
+// Certificate tells the compliance officer what happened to the personal data of a user: what every module deleted,
+// anonymized or kept under legal retention, or why nothing was erased. The checksum is taken over the rest of the
+// certificate once it is final.
+type Certificate struct {
+	IDErasure    string               `json:"idErasure"`
+	IDUser       int                  `json:"idUser"`
+	IDPatient    int                  `json:"idPatient,omitempty"`
+	RequestedBy  int                  `json:"requestedBy,omitempty"`
+	Status       Status               `json:"status"`
+	Reason       string               `json:"reason,omitempty"`
+	RequestedAt  time.Time            `json:"requestedAt"`
+	CompletedAt  *time.Time           `json:"completedAt,omitempty"`
+	Participants []ParticipantOutcome `json:"participants"`
+	Checksum     string               `json:"checksum,omitempty"`
+}
+
+// Finish closes the certificate with the given status and seals it with its checksum
+func (c *Certificate) Finish(status Status, reason string) error {
+	completedAt := time.Now().UTC()
+	c.Status = status
+	c.Reason = reason
+	c.CompletedAt = &completedAt
+	c.Checksum = ""
+
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(data)
+	c.Checksum = hex.EncodeToString(sum[:])
+	return nil
+}
+
+// Record adds the answers of the participants to the certificate
+func (c *Certificate) Record(responses []*models.ParticipantResponse) {
+	for _, response := range responses {
+		c.Participants = append(c.Participants, ParticipantOutcome{
+			Participant: response.Type,
+			Code:        response.Code,
+			Message:     response.Message,
+			Records:     response.Records,
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package erasure
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mihnea1711/POS_Project/services/rabbit/pkg/config"
+)
+
+var ErrCertificateNotFound = errors.New("erasure certificate not found")
+
+// Store keeps the erasure certificates as JSON files, one per erasure, so they outlive the service
+type Store struct {
+	directory string
+	mu        sync.Mutex
+}
+
+func NewStore(conf config.ErasureConfig) (*Store, error) {
+	if err := os.MkdirAll(conf.Directory, 0700); err != nil {
+		log.Printf("[RABBIT] Error creating erasure certificate directory %s: %v", conf.Directory, err)
+		return nil, err
+	}
+
+	log.Printf("[RABBIT] Erasure certificates are stored in %s", conf.Directory)
+	return &Store{directory: conf.Directory}, nil
+}
+
+// Start opens a pending certificate for an erasure of the given user
+func (s *Store) Start(userID, requestedBy int) (*Certificate, error) {
+	certificate := &Certificate{
+		IDErasure:    uuid.New().String(),
+		IDUser:       userID,
+		RequestedBy:  requestedBy,
+		Status:       StatusPending,
+		RequestedAt:  time.Now().UTC(),
+		Participants: []ParticipantOutcome{},
+	}
+
+	if err := s.Save(certificate); err != nil {
+		return nil, err
+	}
+	return certificate, nil
+}
+
+func (s *Store) Get(erasureID string) (*Certificate, error) {
+	// Only names the store made itself are looked up, so the ID cannot point outside the directory
+	if _, err := uuid.Parse(erasureID); err != nil {
+		return nil, ErrCertificateNotFound
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := os.ReadFile(s.path(erasureID))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrCertificateNotFound
+		}
+		log.Printf("[RABBIT] Error reading erasure certificate %s: %v", erasureID, err)
+		return nil, err
+	}
+
+	var certificate Certificate
+	if err := json.Unmarshal(data, &certificate); err != nil {
+		log.Printf("[RABBIT] Error decoding erasure certificate %s: %v", erasureID, err)
+		return nil, err
+	}
+	return &certificate, nil
+}
+
+func (s *Store) Save(certificate *Certificate) error {
+	data, err := json.MarshalIndent(certificate, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode erasure certificate: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Write next to the certificate and rename, a reader never sees half of it
+	path := s.path(certificate.IDErasure)
+	if err := os.WriteFile(path+".tmp", data, 0600); err != nil {
+		log.Printf("[RABBIT] Error writing erasure certificate %s: %v", certificate.IDErasure, err)
+		return err
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		log.Printf("[RABBIT] Error saving erasure certificate %s: %v", certificate.IDErasure, err)
+		return err
+	}
+	return nil
+}
+
+func (s *Store) path(erasureID string) string {
+	return filepath.Join(s.directory, erasureID+".json")
+}
@@ -2,11 +2,14 @@ package models
 
 import "github.com/google/uuid"
 
-// ParticipantResponse represents the response from a participant in a transaction
+// ParticipantResponse represents the response from a participant in a transaction.
+// Records tell what an erasure participant did with the personal data it holds.
 type ParticipantResponse struct {
 	ID      uuid.UUID
+	Type    ParticipantType
 	Code    int
 	Message string
+	Records []ErasureRecord
 }
 
 // ClientResponse represents the response that is sent to the client after 2pc finishes
@@ -19,6 +22,13 @@ type DeleteMessageData struct {
 	IDUser int `json:"idUser"`
 }
 
+// ErasureMessageData asks for the personal data of a user to be erased. IDErasure names the erasure certificate.
+type ErasureMessageData struct {
+	IDUser      int    `json:"idUser"`
+	IDErasure   string `json:"idErasure"`
+	RequestedBy int    `json:"requestedBy,omitempty"`
+}
+
 type ResponseData struct {
 	Message string      `json:"message"`
 	Error   string      `json:"error"`
@@ -30,7 +40,32 @@ type ActivityData struct {
 	IDUser   int  `json:"idUser"`
 }
 
+// MessageWrapper is what the participants of a transaction are told about it.
+// IDPatient and IDErasure are only set for erasures.
 type MessageWrapper struct {
 	IDUser        int    `json:"idUser"`
+	IDPatient     int    `json:"idPatient,omitempty"`
+	IDErasure     string `json:"idErasure,omitempty"`
 	TransactionID string `json:"transactionID"`
 }
+
+type ErasureAction string
+
+// ErasureRecord tells what a participant did with a category of personal data and why
+type ErasureRecord struct {
+	Category string        `json:"category"`
+	Action   ErasureAction `json:"action"`
+	Count    int           `json:"count"`
+	Reason   string        `json:"reason,omitempty"`
+}
+
+// ErasureRequest is sent to the patient module when committing an erasure
+type ErasureRequest struct {
+	IDErasure string `json:"idErasure"`
+}
+
+// ErasureSubject is the answer of the patient module to the prepare step of an erasure
+type ErasureSubject struct {
+	IDPatient int `json:"idPatient"`
+	IDUser    int `json:"idUser"`
+}
@@ -1,16 +1,17 @@
 package models
 
-// Transactional defines the methods that a participant in a distributed transaction should implement
+// Transactional defines the methods that a participant in a distributed transaction should implement.
+// Every phase is told about the transaction it belongs to.
 type Transactional interface {
 	// Prepare is called to prepare the participant for the transaction
-	Prepare() (*ParticipantResponse, error)
+	Prepare(msgWrapper MessageWrapper) (*ParticipantResponse, error)
 
 	// Commit is called to commit the changes made during the transaction
-	Commit(userId int) (*ParticipantResponse, error)
+	Commit(msgWrapper MessageWrapper) (*ParticipantResponse, error)
 
 	// Abort is called to abort the current transaction
-	Abort() (*ParticipantResponse, error)
+	Abort(msgWrapper MessageWrapper) (*ParticipantResponse, error)
 
 	// Rollback is called to undo the changes made during the transaction
-	Rollback(userId int) (*ParticipantResponse, error)
+	Rollback(msgWrapper MessageWrapper) (*ParticipantResponse, error)
 }
@@ -4,6 +4,12 @@ import "github.com/google/uuid"
 
 type ParticipantType string
 
+// Identifiable is implemented by the participants that embed Participant
+type Identifiable interface {
+	GetID() uuid.UUID
+	GetType() ParticipantType
+}
+
 // Participant represents a module that participates in a distributed transaction
 type Participant struct {
 	_id   uuid.UUID
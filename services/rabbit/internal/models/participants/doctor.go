@@ -29,7 +29,7 @@ func NewDoctor(participantID uuid.UUID, participantType models.ParticipantType)
 }
 
 // Implement the Transactional interface methods for Participant
-func (d *Doctor) Prepare() (*models.ParticipantResponse, error) {
+func (d *Doctor) Prepare(msgWrapper models.MessageWrapper) (*models.ParticipantResponse, error) {
 	log.Println("[2PC] Sending Doctor prepare request")
 
 	// Create a context with a timeout
@@ -50,14 +50,14 @@ func (d *Doctor) Prepare() (*models.ParticipantResponse, error) {
 	}, nil
 }
 
-func (d *Doctor) Commit(userID int) (*models.ParticipantResponse, error) {
+func (d *Doctor) Commit(msgWrapper models.MessageWrapper) (*models.ParticipantResponse, error) {
 	log.Println("[2PC] Sending Doctor commit request")
 
 	// Create a context with a timeout
 	ctx, cancel := context.WithTimeout(context.Background(), utils.REQUEST_TIMEOUT_MULTIPLIER*time.Second)
 	defer cancel()
 
-	response, status, err := utils.MakeRequest(ctx, http.MethodPatch, utils.DOCTOR_HOST, fmt.Sprintf("%s/%d", utils.COMMIT_DOCTOR_ENDPOINT, userID), utils.DOCTOR_PORT, models.ActivityData{
+	response, status, err := utils.MakeRequest(ctx, http.MethodPatch, utils.DOCTOR_HOST, fmt.Sprintf("%s/%d", utils.COMMIT_DOCTOR_ENDPOINT, msgWrapper.IDUser), utils.DOCTOR_PORT, models.ActivityData{
 		IsActive: false,
 	})
 	if err != nil {
@@ -73,7 +73,7 @@ func (d *Doctor) Commit(userID int) (*models.ParticipantResponse, error) {
 	}, nil
 }
 
-func (d *Doctor) Abort() (*models.ParticipantResponse, error) {
+func (d *Doctor) Abort(msgWrapper models.MessageWrapper) (*models.ParticipantResponse, error) {
 	log.Println("[2PC] Sending Doctor abort request")
 
 	return &models.ParticipantResponse{
@@ -82,14 +82,14 @@ func (d *Doctor) Abort() (*models.ParticipantResponse, error) {
 	}, nil
 }
 
-func (d *Doctor) Rollback(userID int) (*models.ParticipantResponse, error) {
+func (d *Doctor) Rollback(msgWrapper models.MessageWrapper) (*models.ParticipantResponse, error) {
 	log.Println("[2PC] Sending Doctor rollback request")
 
 	// Create a context with a timeout
 	ctx, cancel := context.WithTimeout(context.Background(), utils.REQUEST_TIMEOUT_MULTIPLIER*time.Second)
 	defer cancel()
 
-	response, status, err := utils.MakeRequest(ctx, http.MethodPatch, utils.DOCTOR_HOST, fmt.Sprintf("%s/%d", utils.COMMIT_DOCTOR_ENDPOINT, userID), utils.DOCTOR_PORT, models.ActivityData{
+	response, status, err := utils.MakeRequest(ctx, http.MethodPatch, utils.DOCTOR_HOST, fmt.Sprintf("%s/%d", utils.COMMIT_DOCTOR_ENDPOINT, msgWrapper.IDUser), utils.DOCTOR_PORT, models.ActivityData{
 		IsActive: true,
 	})
 	if err != nil {
@@ -0,0 +1,153 @@
+package participants
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mihnea1711/POS_Project/services/rabbit/internal/models"
+	"github.com/mihnea1711/POS_Project/services/rabbit/pkg/utils"
+)
+
+// PatientErasure erases the personal data held by the Patient module. It refuses to prepare while the patient is under legal hold.
+type PatientErasure struct {
+	models.Participant
+}
+
+// AppointmentErasure erases the appointments of a patient that are not under legal retention
+type AppointmentErasure struct {
+	models.Participant
+}
+
+// ConsultationErasure reports the consultations of a patient, which are kept under legal retention
+type ConsultationErasure struct {
+	models.Participant
+}
+
+func NewPatientErasure(participantID uuid.UUID, participantType models.ParticipantType) *PatientErasure {
+	log.Printf("New patient erasure participant registered - ID: %s\n", participantID.String())
+	return &PatientErasure{Participant: *models.NewParticipant(participantID, participantType)}
+}
+
+func NewAppointmentErasure(participantID uuid.UUID, participantType models.ParticipantType) *AppointmentErasure {
+	log.Printf("New appointment erasure participant registered - ID: %s\n", participantID.String())
+	return &AppointmentErasure{Participant: *models.NewParticipant(participantID, participantType)}
+}
+
+func NewConsultationErasure(participantID uuid.UUID, participantType models.ParticipantType) *ConsultationErasure {
+	log.Printf("New consultation erasure participant registered - ID: %s\n", participantID.String())
+	return &ConsultationErasure{Participant: *models.NewParticipant(participantID, participantType)}
+}
+
+// Prepare answers 423 while the patient is under legal hold, which aborts the erasure
+func (p *PatientErasure) Prepare(msgWrapper models.MessageWrapper) (*models.ParticipantResponse, error) {
+	log.Println("[2PC] Sending Patient erasure prepare request")
+	return erasureRequest(http.MethodGet, utils.PATIENT_HOST, fmt.Sprintf(utils.PATIENT_ERASURE_ENDPOINT_FORMAT, msgWrapper.IDUser), utils.PATIENT_PORT, nil, false)
+}
+
+func (p *PatientErasure) Commit(msgWrapper models.MessageWrapper) (*models.ParticipantResponse, error) {
+	log.Println("[2PC] Sending Patient erasure commit request")
+	return erasureRequest(http.MethodPost, utils.PATIENT_HOST, fmt.Sprintf(utils.PATIENT_ERASURE_ENDPOINT_FORMAT, msgWrapper.IDUser), utils.PATIENT_PORT, models.ErasureRequest{
+		IDErasure: msgWrapper.IDErasure,
+	}, true)
+}
+
+func (p *PatientErasure) Abort(msgWrapper models.MessageWrapper) (*models.ParticipantResponse, error) {
+	log.Println("[2PC] Sending Patient erasure abort request")
+	return abortErasure(), nil
+}
+
+func (p *PatientErasure) Rollback(msgWrapper models.MessageWrapper) (*models.ParticipantResponse, error) {
+	log.Println("[2PC] Sending Patient erasure rollback request")
+	return rollbackErasure(), nil
+}
+
+func (a *AppointmentErasure) Prepare(msgWrapper models.MessageWrapper) (*models.ParticipantResponse, error) {
+	log.Println("[2PC] Sending Appointment erasure prepare request")
+	return erasureRequest(http.MethodGet, utils.APPOINTMENT_HOST, utils.PREPARE_APPOINTMENT_ENDPOINT, utils.APPOINTMENT_PORT, nil, false)
+}
+
+func (a *AppointmentErasure) Commit(msgWrapper models.MessageWrapper) (*models.ParticipantResponse, error) {
+	log.Println("[2PC] Sending Appointment erasure commit request")
+	return erasureRequest(http.MethodPost, utils.APPOINTMENT_HOST, fmt.Sprintf(utils.APPOINTMENT_ERASURE_ENDPOINT_FORMAT, msgWrapper.IDPatient), utils.APPOINTMENT_PORT, nil, true)
+}
+
+func (a *AppointmentErasure) Abort(msgWrapper models.MessageWrapper) (*models.ParticipantResponse, error) {
+	log.Println("[2PC] Sending Appointment erasure abort request")
+	return abortErasure(), nil
+}
+
+func (a *AppointmentErasure) Rollback(msgWrapper models.MessageWrapper) (*models.ParticipantResponse, error) {
+	log.Println("[2PC] Sending Appointment erasure rollback request")
+	return rollbackErasure(), nil
+}
+
+func (c *ConsultationErasure) Prepare(msgWrapper models.MessageWrapper) (*models.ParticipantResponse, error) {
+	log.Println("[2PC] Sending Consultation erasure prepare request")
+	return erasureRequest(http.MethodGet, utils.CONSULTATION_HOST, utils.PREPARE_CONSULTATION_ENDPOINT, utils.CONSULTATION_PORT, nil, false)
+}
+
+func (c *ConsultationErasure) Commit(msgWrapper models.MessageWrapper) (*models.ParticipantResponse, error) {
+	log.Println("[2PC] Sending Consultation erasure commit request")
+	return erasureRequest(http.MethodPost, utils.CONSULTATION_HOST, fmt.Sprintf(utils.CONSULTATION_ERASURE_ENDPOINT_FORMAT, msgWrapper.IDPatient), utils.CONSULTATION_PORT, nil, true)
+}
+
+func (c *ConsultationErasure) Abort(msgWrapper models.MessageWrapper) (*models.ParticipantResponse, error) {
+	log.Println("[2PC] Sending Consultation erasure abort request")
+	return abortErasure(), nil
+}
+
+func (c *ConsultationErasure) Rollback(msgWrapper models.MessageWrapper) (*models.ParticipantResponse, error) {
+	log.Println("[2PC] Sending Consultation erasure rollback request")
+	return rollbackErasure(), nil
+}
+
+// erasureRequest calls an erasure endpoint of a module. The records of a successful commit are decoded from the payload.
+func erasureRequest(method, host, endpoint string, port int, data interface{}, withRecords bool) (*models.ParticipantResponse, error) {
+	// Create a context with a timeout
+	ctx, cancel := context.WithTimeout(context.Background(), utils.REQUEST_TIMEOUT_MULTIPLIER*time.Second)
+	defer cancel()
+
+	response, status, err := utils.MakeRequest(ctx, method, host, endpoint, port, data)
+	if err != nil {
+		log.Printf("Error making erasure request to %s%s: %v", host, endpoint, err)
+		return nil, err
+	}
+
+	participantResponse := &models.ParticipantResponse{
+		Code:    status,
+		Message: response.Message,
+	}
+	if response.Error != "" {
+		participantResponse.Message = fmt.Sprintf("%s: %s", response.Message, response.Error)
+	}
+
+	if withRecords && status == http.StatusOK {
+		if err := utils.DecodePayload(response.Payload, &participantResponse.Records); err != nil {
+			log.Printf("Error decoding erasure records from %s%s: %v", host, endpoint, err)
+			return nil, err
+		}
+	}
+
+	log.Printf("[2PC] Erasure request to %s%s handled. Status Code: %d", host, endpoint, status)
+	return participantResponse, nil
+}
+
+func abortErasure() *models.ParticipantResponse {
+	return &models.ParticipantResponse{
+		Code:    http.StatusOK,
+		Message: "Transaction aborted successfully",
+	}
+}
+
+// rollbackErasure reports that erased data cannot be restored. The erasure endpoints are idempotent, so a partial
+// erasure is completed by requesting it again.
+func rollbackErasure() *models.ParticipantResponse {
+	return &models.ParticipantResponse{
+		Code:    http.StatusConflict,
+		Message: "Erased data cannot be restored, request the erasure again to complete it",
+	}
+}
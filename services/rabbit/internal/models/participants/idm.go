@@ -32,7 +32,7 @@ func NewIDM(participantID uuid.UUID, participantType models.ParticipantType, idm
 }
 
 // Implement the Transactional interface methods for Participant
-func (idm *IDM) Prepare() (*models.ParticipantResponse, error) {
+func (idm *IDM) Prepare(msgWrapper models.MessageWrapper) (*models.ParticipantResponse, error) {
 	log.Println("[2PC] Sending IDM prepare request")
 
 	// Create a context with a timeout
@@ -56,7 +56,7 @@ func (idm *IDM) Prepare() (*models.ParticipantResponse, error) {
 
 	// additionally check if user id to see if in exists before trying to delete smth that doesn t exist
 	// although it should be handled ok by teh db
-	// userIDResponse, err := idm.IDMClient.GetUserByID(ctx, &proto_files.UserIDRequest{UserID: &proto_files.UserID{ID: int64(userID)}})
+	// userIDResponse, err := idm.IDMClient.GetUserByID(ctx, &proto_files.UserIDRequest{UserID: &proto_files.UserID{ID: int64(msgWrapper.IDUser)}})
 	// if err != nil {
 	// 	log.Printf("Error making IDM prepare request: %v", err)
 	// 	return nil, err
@@ -70,14 +70,14 @@ func (idm *IDM) Prepare() (*models.ParticipantResponse, error) {
 	}, nil
 }
 
-func (idm *IDM) Commit(userID int) (*models.ParticipantResponse, error) {
+func (idm *IDM) Commit(msgWrapper models.MessageWrapper) (*models.ParticipantResponse, error) {
 	log.Println("[2PC] Sending IDM commit request")
 
 	// Create a context with a timeout
 	ctx, cancel := context.WithTimeout(context.Background(), utils.REQUEST_TIMEOUT_MULTIPLIER*time.Second)
 	defer cancel()
 
-	response, err := idm.IDMClient.DeleteUserByID(ctx, &proto_files.UserIDRequest{UserID: &proto_files.UserID{ID: int64(userID)}})
+	response, err := idm.IDMClient.DeleteUserByID(ctx, &proto_files.UserIDRequest{UserID: &proto_files.UserID{ID: int64(msgWrapper.IDUser)}})
 	if err != nil {
 		log.Printf("Error making IDM commit request: %v", err)
 		return nil, err
@@ -85,13 +85,23 @@ func (idm *IDM) Commit(userID int) (*models.ParticipantResponse, error) {
 
 	log.Printf("[2PC] IDM commit request handled successfully. Status Code: %d, Rows Affected: %d", response.Info.Status, response.RowsAffected)
 
-	return &models.ParticipantResponse{
+	participantResponse := &models.ParticipantResponse{
 		Code:    int(response.Info.Status),
 		Message: fmt.Sprintf("%s. Rows Affected: %d", response.Info.Message, response.RowsAffected),
-	}, nil
+	}
+	// The account is part of the erasure certificate
+	if msgWrapper.IDErasure != "" {
+		participantResponse.Records = []models.ErasureRecord{{
+			Category: utils.ERASURE_CATEGORY_ACCOUNT,
+			Action:   utils.ErasureActionDeleted,
+			Count:    int(response.RowsAffected),
+		}}
+	}
+
+	return participantResponse, nil
 }
 
-func (idm *IDM) Abort() (*models.ParticipantResponse, error) {
+func (idm *IDM) Abort(msgWrapper models.MessageWrapper) (*models.ParticipantResponse, error) {
 	log.Println("[2PC] Sending IDM abort request")
 
 	return &models.ParticipantResponse{
@@ -100,14 +110,14 @@ func (idm *IDM) Abort() (*models.ParticipantResponse, error) {
 	}, nil
 }
 
-func (idm *IDM) Rollback(userID int) (*models.ParticipantResponse, error) {
+func (idm *IDM) Rollback(msgWrapper models.MessageWrapper) (*models.ParticipantResponse, error) {
 	log.Println("[2PC] Sending IDM rollback request")
 
 	// Create a context with a timeout
 	ctx, cancel := context.WithTimeout(context.Background(), utils.REQUEST_TIMEOUT_MULTIPLIER*time.Second)
 	defer cancel()
 
-	response, err := idm.IDMClient.RestoreUserByID(ctx, &proto_files.UserIDRequest{UserID: &proto_files.UserID{ID: int64(userID)}})
+	response, err := idm.IDMClient.RestoreUserByID(ctx, &proto_files.UserIDRequest{UserID: &proto_files.UserID{ID: int64(msgWrapper.IDUser)}})
 	if err != nil {
 		log.Printf("Error making IDM rollback request: %v", err)
 		return nil, err
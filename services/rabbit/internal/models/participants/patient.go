@@ -29,7 +29,7 @@ func NewPatient(participantID uuid.UUID, participantType models.ParticipantType)
 }
 
 // Implement the Transactional interface methods for Participant
-func (p *Patient) Prepare() (*models.ParticipantResponse, error) {
+func (p *Patient) Prepare(msgWrapper models.MessageWrapper) (*models.ParticipantResponse, error) {
 	log.Println("[2PC] Sending Patient prepare request")
 
 	// Create a context with a timeout
@@ -50,14 +50,14 @@ func (p *Patient) Prepare() (*models.ParticipantResponse, error) {
 	}, nil
 }
 
-func (p *Patient) Commit(userID int) (*models.ParticipantResponse, error) {
+func (p *Patient) Commit(msgWrapper models.MessageWrapper) (*models.ParticipantResponse, error) {
 	log.Println("[2PC] Sending Patient commit request")
 
 	// Create a context with a timeout
 	ctx, cancel := context.WithTimeout(context.Background(), utils.REQUEST_TIMEOUT_MULTIPLIER*time.Second)
 	defer cancel()
 
-	response, status, err := utils.MakeRequest(ctx, http.MethodPatch, utils.PATIENT_HOST, fmt.Sprintf("%s/%d", utils.COMMIT_PATIENT_ENDPOINT, userID), utils.PATIENT_PORT, models.ActivityData{
+	response, status, err := utils.MakeRequest(ctx, http.MethodPatch, utils.PATIENT_HOST, fmt.Sprintf("%s/%d", utils.COMMIT_PATIENT_ENDPOINT, msgWrapper.IDUser), utils.PATIENT_PORT, models.ActivityData{
 		IsActive: false,
 	})
 	if err != nil {
@@ -73,7 +73,7 @@ func (p *Patient) Commit(userID int) (*models.ParticipantResponse, error) {
 	}, nil
 }
 
-func (p *Patient) Abort() (*models.ParticipantResponse, error) {
+func (p *Patient) Abort(msgWrapper models.MessageWrapper) (*models.ParticipantResponse, error) {
 	log.Println("[2PC] Sending Patient abort request")
 
 	return &models.ParticipantResponse{
@@ -82,14 +82,14 @@ func (p *Patient) Abort() (*models.ParticipantResponse, error) {
 	}, nil
 }
 
-func (p *Patient) Rollback(userID int) (*models.ParticipantResponse, error) {
+func (p *Patient) Rollback(msgWrapper models.MessageWrapper) (*models.ParticipantResponse, error) {
 	log.Println("[2PC] Sending Patient rollback request")
 
 	// Create a context with a timeout
 	ctx, cancel := context.WithTimeout(context.Background(), utils.REQUEST_TIMEOUT_MULTIPLIER*time.Second)
 	defer cancel()
 
-	response, status, err := utils.MakeRequest(ctx, http.MethodPatch, utils.PATIENT_HOST, fmt.Sprintf("%s/%d", utils.COMMIT_PATIENT_ENDPOINT, userID), utils.PATIENT_PORT, models.ActivityData{
+	response, status, err := utils.MakeRequest(ctx, http.MethodPatch, utils.PATIENT_HOST, fmt.Sprintf("%s/%d", utils.COMMIT_PATIENT_ENDPOINT, msgWrapper.IDUser), utils.PATIENT_PORT, models.ActivityData{
 		IsActive: true,
 	})
 	if err != nil {
@@ -22,6 +22,10 @@ func (rmq *RabbitMQ) SetupHandlers(serviceContainer *services.ServiceContainer)
 	rmq.SetHandler(utils.DELETE_QUEUE, serviceContainer.DeleteUserMessageHandler)
 	log.Printf("[RABBIT] Handler set up for queue '%s'", utils.DELETE_QUEUE)
 
+	// Setting up a handler for the ERASURE_QUEUE
+	rmq.SetHandler(utils.ERASURE_QUEUE, serviceContainer.EraseUserMessageHandler)
+	log.Printf("[RABBIT] Handler set up for queue '%s'", utils.ERASURE_QUEUE)
+
 	// Add more handlers for other queues as needed
 
 	log.Println("[RABBIT] Message handlers set up successfully.")
@@ -6,6 +6,7 @@ import (
 
 	"github.com/gorilla/mux"
 	"github.com/mihnea1711/POS_Project/services/rabbit/internal/controllers"
+	"github.com/mihnea1711/POS_Project/services/rabbit/internal/erasure"
 	"github.com/mihnea1711/POS_Project/services/rabbit/internal/middleware"
 	"github.com/mihnea1711/POS_Project/services/rabbit/internal/middleware/authorization"
 	"github.com/mihnea1711/POS_Project/services/rabbit/internal/middleware/cors_config"
@@ -14,7 +15,7 @@ import (
 	"github.com/mihnea1711/POS_Project/services/rabbit/pkg/utils"
 )
 
-func SetupRoutes(rabbitMQ *rabbitmq.RabbitMQ, jwtConfig config.JWTConfig) *mux.Router {
+func SetupRoutes(rabbitMQ *rabbitmq.RabbitMQ, certificates *erasure.Store, jwtConfig config.JWTConfig) *mux.Router {
 	log.Println("[RABBIT] Setting up routes...")
 	router := mux.NewRouter()
 
@@ -28,7 +29,8 @@ func SetupRoutes(rabbitMQ *rabbitmq.RabbitMQ, jwtConfig config.JWTConfig) *mux.R
 	log.Println("[RABBIT] Input sanitizer middleware set up successfully.")
 
 	rabbitController := &controllers.RabbitController{
-		RabbitMQ: rabbitMQ,
+		RabbitMQ:     rabbitMQ,
+		Certificates: certificates,
 	}
 
 	loadRoutes(router, rabbitController, jwtConfig)
@@ -50,5 +52,14 @@ func loadRoutes(router *mux.Router, rabbitController *controllers.RabbitControll
 	router.Handle(utils.PUBLISH_ENDPOINT, authorization.AdminOnlyMiddleware(jwtConfig, publishHandler)).Methods(http.MethodPost)
 	log.Println("[RABBIT] Route POST", utils.PUBLISH_ENDPOINT, "registered.")
 
+	// ---------------------------------------------------------- ERASURE --------------------------------------------------------------
+	requestErasureHandler := http.HandlerFunc(rabbitController.RequestErasure)
+	router.Handle(utils.ERASURES_ENDPOINT, authorization.AdminOnlyMiddleware(jwtConfig, requestErasureHandler)).Methods(http.MethodPost)
+	log.Println("[RABBIT] Route POST", utils.ERASURES_ENDPOINT, "registered.")
+
+	getErasureCertificateHandler := http.HandlerFunc(rabbitController.GetErasureCertificate)
+	router.Handle(utils.ERASURE_ENDPOINT, authorization.AdminOnlyMiddleware(jwtConfig, getErasureCertificateHandler)).Methods(http.MethodGet)
+	log.Println("[RABBIT] Route GET", utils.ERASURE_ENDPOINT, "registered.")
+
 	log.Println("[RABBIT] All routes for RABBIT entity loaded successfully.")
 }
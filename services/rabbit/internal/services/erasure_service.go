@@ -0,0 +1,151 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/mihnea1711/POS_Project/services/rabbit/internal/erasure"
+	"github.com/mihnea1711/POS_Project/services/rabbit/internal/models"
+	twophasecommit "github.com/mihnea1711/POS_Project/services/rabbit/internal/two_phase_commit"
+	"github.com/mihnea1711/POS_Project/services/rabbit/pkg/utils"
+)
+
+// EraseUserMessageHandler erases the personal data of a user in every module with the same two phases as
+// DeleteUserMessageHandler. The patient module answers 'NO' while the patient is under legal hold. Erased data cannot
+// be restored, so a failed commit leaves a partial erasure that is completed by requesting it again. Every outcome
+// is written on the erasure certificate.
+func (s *ServiceContainer) EraseUserMessageHandler(message []byte) error {
+	fmt.Printf("[RABBIT] Handling erasure message: %s\n", string(message))
+
+	transactionID := utils.StartTransaction()
+	messageWrapper := models.MessageWrapper{
+		TransactionID: transactionID,
+	}
+
+	var erasureMessageData models.ErasureMessageData
+	if err := json.Unmarshal(message, &erasureMessageData); err != nil {
+		log.Printf("[2PC] Failed to unmarshal erasure message: %v", err)
+		twophasecommit.InformClient("clientID", messageWrapper, models.ClientResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "Internal Server Error during message decoding.",
+		})
+
+		return err
+	}
+	messageWrapper.IDUser = erasureMessageData.IDUser
+	messageWrapper.IDErasure = erasureMessageData.IDErasure
+
+	certificate, err := s.Certificates.Get(erasureMessageData.IDErasure)
+	if err != nil {
+		log.Printf("[2PC] Failed to load erasure certificate %s: %v", erasureMessageData.IDErasure, err)
+		twophasecommit.InformClient("clientID", messageWrapper, models.ClientResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "Internal Server Error while loading the erasure certificate.",
+		})
+
+		return err
+	}
+
+	// The appointments and the consultations are kept by patient, not by user
+	code, subject, reason := resolveErasureSubject(messageWrapper.IDUser)
+	if code != http.StatusOK {
+		status := erasure.StatusRejected
+		if code != http.StatusNotFound && code != http.StatusLocked {
+			status = erasure.StatusFailed
+		}
+		certificate.Participants = append(certificate.Participants, erasure.ParticipantOutcome{Participant: utils.PATIENT_ERASURE, Code: code, Message: reason})
+
+		return s.finishErasure(certificate, messageWrapper, status, reason, code)
+	}
+	messageWrapper.IDPatient = subject.IDPatient
+	certificate.IDPatient = subject.IDPatient
+
+	// Phase 1: Prepare Phase
+	prepareResponses, err := twophasecommit.SendPrepareMessage(s.ErasureParticipants, messageWrapper)
+	if err != nil {
+		log.Printf("[2PC] Error in erasure Prepare Phase. Transaction ID: %s, Error: %v", transactionID, err)
+		return s.finishErasure(certificate, messageWrapper, erasure.StatusFailed, "Internal Server Error during erasure preparation.", http.StatusInternalServerError)
+	}
+	if twophasecommit.AnyParticipantRespondedNo(prepareResponses, messageWrapper) || !twophasecommit.AllParticipantsResponded(prepareResponses, s.ErasureParticipants, messageWrapper) {
+		log.Println("[2PC] Erasure Prepare Phase: One or more participants responded with 'NO'")
+		twophasecommit.SendAbortMessage(s.ErasureParticipants, messageWrapper)
+		certificate.Record(prepareResponses)
+
+		return s.finishErasure(certificate, messageWrapper, erasure.StatusRejected, "Erasure aborted due to participant response with 'NO'.", http.StatusServiceUnavailable)
+	}
+
+	// Phase 2: Commit Phase
+	commitResponses, err := twophasecommit.SendCommitMessage(s.ErasureParticipants, messageWrapper)
+	if err != nil {
+		log.Printf("[2PC] Error in erasure Commit Phase. Transaction ID: %s, Error: %v", transactionID, err)
+		return s.finishErasure(certificate, messageWrapper, erasure.StatusFailed, "Internal Server Error during erasure commit.", http.StatusInternalServerError)
+	}
+	certificate.Record(commitResponses)
+	if twophasecommit.AnyParticipantFailed(commitResponses, messageWrapper) || !twophasecommit.AllParticipantsResponded(commitResponses, s.ErasureParticipants, messageWrapper) {
+		log.Println("[2PC] Erasure Commit Phase: One or more participants failed")
+		twophasecommit.SendRollbackMessage(s.ErasureParticipants, messageWrapper)
+
+		return s.finishErasure(certificate, messageWrapper, erasure.StatusPartial, "One or more participants failed, request the erasure again to complete it.", http.StatusFailedDependency)
+	}
+
+	log.Printf("[2PC] Erasure successfully committed. Transaction ID: %s", transactionID)
+	if err := s.finishErasure(certificate, messageWrapper, erasure.StatusCompleted, "", http.StatusOK); err != nil {
+		return err
+	}
+
+	// Return nil to indicate that the message was successfully processed.
+	return nil
+}
+
+// finishErasure seals the certificate and informs the client. A rejected erasure is an answer, not a failure of the handler.
+func (s *ServiceContainer) finishErasure(certificate *erasure.Certificate, messageWrapper models.MessageWrapper, status erasure.Status, reason string, code int) error {
+	if err := certificate.Finish(status, reason); err != nil {
+		log.Printf("[2PC] Failed to seal erasure certificate %s: %v", certificate.IDErasure, err)
+		return err
+	}
+	if err := s.Certificates.Save(certificate); err != nil {
+		log.Printf("[2PC] Failed to save erasure certificate %s: %v", certificate.IDErasure, err)
+		return err
+	}
+
+	message := fmt.Sprintf("Erasure %s %s.", certificate.IDErasure, status)
+	if reason != "" {
+		message = fmt.Sprintf("%s %s", message, reason)
+	}
+	twophasecommit.InformClient("clientID", messageWrapper, models.ClientResponse{
+		Code:    code,
+		Message: message,
+	})
+
+	if status == erasure.StatusCompleted || status == erasure.StatusRejected {
+		return nil
+	}
+	return fmt.Errorf("erasure %s %s: %s", certificate.IDErasure, status, reason)
+}
+
+// resolveErasureSubject asks the patient module which patient belongs to the user. It answers 423 with the reason of
+// the legal hold when the patient cannot be erased.
+func resolveErasureSubject(userID int) (int, *models.ErasureSubject, string) {
+	ctx, cancel := context.WithTimeout(context.Background(), utils.REQUEST_TIMEOUT_MULTIPLIER*time.Second)
+	defer cancel()
+
+	response, status, err := utils.MakeRequest(ctx, http.MethodGet, utils.PATIENT_HOST, fmt.Sprintf(utils.PATIENT_ERASURE_ENDPOINT_FORMAT, userID), utils.PATIENT_PORT, nil)
+	if err != nil {
+		log.Printf("[2PC] Error resolving the erasure subject of user %d: %v", userID, err)
+		return http.StatusInternalServerError, nil, "The patient module could not be reached."
+	}
+	if status != http.StatusOK {
+		log.Printf("[2PC] The patient module refused the erasure of user %d. Status Code: %d, Error: %s", userID, status, response.Error)
+		return status, nil, fmt.Sprintf("%s: %s", response.Message, response.Error)
+	}
+
+	var subject models.ErasureSubject
+	if err := utils.DecodePayload(response.Payload, &subject); err != nil {
+		return http.StatusInternalServerError, nil, "The erasure subject could not be decoded."
+	}
+	return status, &subject, ""
+}
@@ -2,6 +2,7 @@ package services
 
 import (
 	"github.com/mihnea1711/POS_Project/services/rabbit/idm"
+	"github.com/mihnea1711/POS_Project/services/rabbit/internal/erasure"
 	"github.com/mihnea1711/POS_Project/services/rabbit/internal/models"
 	"github.com/mihnea1711/POS_Project/services/rabbit/pkg/config"
 )
@@ -15,4 +16,8 @@ type ServiceContainer struct {
 	// maybe add here list of participants
 	Participants []models.Transactional
 	// have a queue that listens to gateway sending info about current participants
+
+	// ErasureParticipants take part in the erasure of the personal data of a user
+	ErasureParticipants []models.Transactional
+	Certificates        *erasure.Store
 }
@@ -11,7 +11,7 @@ func SendPrepareMessage(participants []models.Transactional, msgWrapper models.M
 	var prepareResponses []*models.ParticipantResponse
 
 	for _, participant := range participants {
-		response, err := sendMessage(participant, "PREPARE", nil)
+		response, err := sendMessage(participant, "PREPARE", msgWrapper)
 		if err != nil {
 			log.Printf("[2PC] Error sending PREPARE message to participant %T for Transaction ID %s: %v\n", participant, msgWrapper.TransactionID, err)
 			continue
@@ -28,7 +28,7 @@ func SendAbortMessage(participants []models.Transactional, msgWrapper models.Mes
 	var abortResponses []*models.ParticipantResponse
 
 	for _, participant := range participants {
-		response, err := sendMessage(participant, "ABORT", nil)
+		response, err := sendMessage(participant, "ABORT", msgWrapper)
 		if err != nil {
 			log.Printf("[2PC] Error sending ABORT message to participant %T for Transaction ID %s: %v\n", participant, msgWrapper.TransactionID, err)
 			continue
@@ -45,7 +45,7 @@ func SendCommitMessage(participants []models.Transactional, msgWrapper models.Me
 	var commitResponses []*models.ParticipantResponse
 
 	for _, participant := range participants {
-		response, err := sendMessage(participant, "COMMIT", msgWrapper.IDUser)
+		response, err := sendMessage(participant, "COMMIT", msgWrapper)
 		if err != nil {
 			log.Printf("[2PC] Error sending COMMIT message to participant %T for Transaction ID %s: %v\n", participant, msgWrapper.TransactionID, err)
 			continue
@@ -62,7 +62,7 @@ func SendRollbackMessage(participants []models.Transactional, msgWrapper models.
 	var rollbackResponses []*models.ParticipantResponse
 
 	for _, participant := range participants {
-		response, err := sendMessage(participant, "ROLLBACK", msgWrapper.IDUser)
+		response, err := sendMessage(participant, "ROLLBACK", msgWrapper)
 		if err != nil {
 			log.Printf("[2PC] Error sending ROLLBACK message to participant %T for Transaction ID %s: %v\n", participant, msgWrapper.TransactionID, err)
 			continue
@@ -76,19 +76,19 @@ func SendRollbackMessage(participants []models.Transactional, msgWrapper models.
 }
 
 // sendMessage is a hypothetical function to send messages to a participant
-func sendMessage(participant models.Transactional, messageType string, payload interface{}) (*models.ParticipantResponse, error) {
+func sendMessage(participant models.Transactional, messageType string, msgWrapper models.MessageWrapper) (*models.ParticipantResponse, error) {
 	var response *models.ParticipantResponse
 	var err error
 
 	switch messageType {
 	case "PREPARE":
-		response, err = participant.Prepare()
+		response, err = participant.Prepare(msgWrapper)
 	case "COMMIT":
-		response, err = participant.Commit(payload.(int))
+		response, err = participant.Commit(msgWrapper)
 	case "ABORT":
-		response, err = participant.Abort()
+		response, err = participant.Abort(msgWrapper)
 	case "ROLLBACK":
-		response, err = participant.Rollback(payload.(int))
+		response, err = participant.Rollback(msgWrapper)
 	default:
 		err = fmt.Errorf("unsupported message type: %s", messageType)
 	}
@@ -99,5 +99,11 @@ func sendMessage(participant models.Transactional, messageType string, payload i
 		return nil, err
 	}
 
+	// Tell which participant answered
+	if identifiable, ok := participant.(models.Identifiable); ok {
+		response.ID = identifiable.GetID()
+		response.Type = identifiable.GetType()
+	}
+
 	return response, nil
 }
@@ -30,3 +30,12 @@ func AnyParticipantFailed(responses []*models.ParticipantResponse, msgWrapper mo
 	}
 	return false
 }
+
+// AllParticipantsResponded checks that no participant was left out because it could not be reached
+func AllParticipantsResponded(responses []*models.ParticipantResponse, participants []models.Transactional, msgWrapper models.MessageWrapper) bool {
+	if len(responses) != len(participants) {
+		log.Printf("[2PC] Only %d of %d participants responded for Transaction ID %s\n", len(responses), len(participants), msgWrapper.TransactionID)
+		return false
+	}
+	return true
+}
@@ -14,6 +14,7 @@ type AppConfig struct {
 	Server   ServerConfig   `yaml:"server"`
 	JWT      JWTConfig      `yaml:"jwt"`
 	RabbitMq RabbitMqConfig `yaml:"rabbitmq"`
+	Erasure  ErasureConfig  `yaml:"erasure"`
 }
 
 type ServerConfig struct {
@@ -24,6 +25,11 @@ type JWTConfig struct {
 	Secret string `yaml:"secret"`
 }
 
+// ErasureConfig tells where the erasure certificates are kept
+type ErasureConfig struct {
+	Directory string `yaml:"directory"`
+}
+
 // RabbitMqConfig represents the RabbitMQ configuration
 type RabbitMqConfig struct {
 	Schema   string        `yaml:"schema"`
@@ -18,7 +18,8 @@ const (
 const JWT_CLAIMS_CONTEXT_KEY contextKey = "jwtClaims"
 
 const (
-	DELETE_QUEUE = "delete_queue"
+	DELETE_QUEUE  = "delete_queue"
+	ERASURE_QUEUE = "erasure_queue"
 )
 
 const (
@@ -55,8 +56,12 @@ const (
 const (
 	HEALTH_CHECK_ENDPOINT = "/api/rabbit/health-check"
 	PUBLISH_ENDPOINT      = "/api/rabbit/publish"
+	ERASURES_ENDPOINT     = "/api/rabbit/erasures"
+	ERASURE_ENDPOINT      = "/api/rabbit/erasures/{erasureID}"
 )
 
+const ERASURE_ID_PARAMETER = "erasureID"
+
 const (
 	PREPARE_PATIENT_ENDPOINT = "/patients/health-check"
 	COMMIT_PATIENT_ENDPOINT  = "/patients"
@@ -67,12 +72,30 @@ const (
 	COMMIT_DOCTOR_ENDPOINT  = "/doctors"
 	// ABORT_DOCTOR_ENDPOINT    = "/doctors/transaction/abort"
 	// ROLLBACK_DOCTOR_ENDPOINT = "/doctors/transaction/rollback"
+
+	PATIENT_ERASURE_ENDPOINT_FORMAT      = "/patients/users/%d/erasure"
+	APPOINTMENT_ERASURE_ENDPOINT_FORMAT  = "/appointments/patients/%d/erasure"
+	CONSULTATION_ERASURE_ENDPOINT_FORMAT = "/consultations/patients/%d/erasure"
+	PREPARE_APPOINTMENT_ENDPOINT         = "/appointments/health-check"
+	PREPARE_CONSULTATION_ENDPOINT        = "/consultations/health-check"
 )
 
 const (
 	IDM     models.ParticipantType = "IDM"
 	PATIENT models.ParticipantType = "PATIENT"
 	DOCTOR  models.ParticipantType = "DOCTOR"
+
+	PATIENT_ERASURE      models.ParticipantType = "PATIENT_ERASURE"
+	APPOINTMENT_ERASURE  models.ParticipantType = "APPOINTMENT_ERASURE"
+	CONSULTATION_ERASURE models.ParticipantType = "CONSULTATION_ERASURE"
 )
 
+const (
+	ErasureActionDeleted    models.ErasureAction = "deleted"
+	ErasureActionAnonymized models.ErasureAction = "anonymized"
+	ErasureActionRetained   models.ErasureAction = "retained"
+)
+
+const ERASURE_CATEGORY_ACCOUNT = "account"
+
 const REQUEST_TIMEOUT_MULTIPLIER = 5
@@ -117,6 +117,20 @@ func MakeRequest(ctx context.Context, methodType, host, endpoint string, port in
 	return decodedResponse, response.StatusCode, nil
 }
 
+// DecodePayload converts the generic payload of a participant response into the given value
+func DecodePayload(payload interface{}, v interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("[RABBIT] Error marshaling response payload: %v", err)
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		log.Printf("[RABBIT] Error unmarshaling response payload: %v", err)
+		return err
+	}
+	return nil
+}
+
 func StartTransaction() string {
 	// Generate a unique transaction ID, e.g., using UUID
 	transactionID := uuid.New().String()
@@ -0,0 +1,15 @@
+#!/bin/bash
+
+# Extract port from config.yaml
+PORT=$(yq e '.server.port' configs/config.yaml)
+
+# An admin token is required, e.g. TOKEN=$(...) ./scripts/erasure.sh
+LOCATION=$(curl -s -i -w "\n" \
+    -X POST http://localhost:"$PORT"/api/rabbit/erasures \
+    -H "Authorization: Bearer $TOKEN" \
+    -H "Content-Type: application/json" \
+    -d '{"userID": 1}' | grep -i '^Location:' | awk '{print $2}' | tr -d '\r')
+
+# The certificate stays pending until the erasure is handled
+sleep 2
+curl -i -w "\n" http://localhost:"$PORT""$LOCATION" -H "Authorization: Bearer $TOKEN"
@@ -12,6 +12,10 @@ docker container prune --force
 docker image prune --force
 docker volume prune --force
 
+echo "[DOCKER] Creating the encryption keyrings if missing..."
+(cd services/pacienti && ./scripts/keyring.sh --create)
+(cd services/consultatii && ./scripts/keyring.sh --create)
+
 echo "[DOCKER] Building Docker images..."
 docker compose build
 